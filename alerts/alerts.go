@@ -0,0 +1,262 @@
+// Package alerts tells organizers when their events are selling fast: when an event
+// reaches 80% and 100% of its capacity, and when bookings spike. Thresholds are
+// evaluated after every booking and alerts are delivered in the background, so
+// bookings never wait for them. Organizers are also told when attendees message them,
+// and sent a daily digest of the actions pending on their events.
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"event_booking_restapi_golang/jobs"
+	"event_booking_restapi_golang/logging"
+	"event_booking_restapi_golang/models"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Alert kinds.
+const (
+	CapacityNearlyFull = "capacity_80"      // Registrations reached 80% of the capacity
+	CapacityFull       = "capacity_100"     // Registrations reached the capacity
+	SalesVelocity      = "sales_velocity"   // Bookings within the velocity window reached the threshold
+	AttendeeMessage    = "attendee_message" // An attendee sent the organizer a message
+	PendingDigest      = "pending_digest"   // Daily checklist of the organizer's pending actions
+)
+
+// alertJob is the kind of the jobs that deliver alerts.
+const alertJob = "alert"
+
+// nearlyFullPercent is the share of the capacity that triggers CapacityNearlyFull.
+const nearlyFullPercent = 80
+
+// notifyTimeout bounds the delivery of one alert.
+const notifyTimeout = 10 * time.Second
+
+// Alert is a notification to an event's organizer.
+type Alert struct {
+	Kind          string               // One of the alert kinds, e.g. CapacityFull
+	EventID       string               // Event the alert is about
+	EventTitle    string               // Title of the event
+	UserID        string               // Organizer of the event
+	Registrations int                  // Registrations held when the alert fired
+	Capacity      int                  // Capacity of the event, 0 when unlimited
+	RecentSales   int                  // Bookings within the velocity window, for SalesVelocity
+	Items         []models.PendingItem // Pending actions across the organizer's events, for PendingDigest
+	Message       string               // Human-readable summary
+	CreatedAt     time.Time            // When the alert fired
+}
+
+// Notifier delivers alerts to organizers.
+type Notifier interface {
+	// Name identifies the notifier in logs.
+	Name() string
+	// Notify delivers the alert.
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// Policy decides which bookings trigger alerts and where they are sent.
+// A nil *Policy is valid and never alerts, which is how alerts are disabled.
+type Policy struct {
+	notifier        Notifier
+	velocityWindow  time.Duration // Window bookings are counted in for SalesVelocity
+	velocityTrigger int           // Bookings within the window that trigger SalesVelocity, 0 disables it
+	digestHour      int           // Hour of the day, in UTC, digests are sent at
+	queue           jobs.Queue    // Job queue alerts are delivered through, nil for a goroutine per booking
+}
+
+// NewPolicy returns a policy sending alerts to notifier. A SalesVelocity alert fires
+// when velocityTrigger bookings are made within velocityWindow, at most once per
+// window; a velocityTrigger of 0 disables sales velocity alerts.
+func NewPolicy(notifier Notifier, velocityWindow time.Duration, velocityTrigger int) *Policy {
+	return &Policy{notifier: notifier, velocityWindow: velocityWindow, velocityTrigger: velocityTrigger, digestHour: defaultDigestHour}
+}
+
+// PolicyFromEnv builds the policy from ALERT_WEBHOOK_URL, ALERT_VELOCITY_BOOKINGS
+// (default 20, 0 disables), ALERT_VELOCITY_WINDOW (default 1h) and ALERT_DIGEST_HOUR
+// (0 to 23 UTC, default 8). Returns nil when no webhook is configured, which disables alerts.
+func PolicyFromEnv() (*Policy, error) {
+	url := os.Getenv("ALERT_WEBHOOK_URL")
+	if url == "" {
+		return nil, nil
+	}
+	trigger := 20
+	if raw := os.Getenv("ALERT_VELOCITY_BOOKINGS"); raw != "" {
+		var err error
+		trigger, err = strconv.Atoi(raw)
+		if err != nil || trigger < 0 {
+			return nil, fmt.Errorf("unsupported ALERT_VELOCITY_BOOKINGS %q, use a number of bookings or 0", raw)
+		}
+	}
+	window := time.Hour
+	if raw := os.Getenv("ALERT_VELOCITY_WINDOW"); raw != "" {
+		var err error
+		window, err = time.ParseDuration(raw)
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("unsupported ALERT_VELOCITY_WINDOW %q, use a positive duration such as 30m", raw)
+		}
+	}
+	digestHour := defaultDigestHour
+	if raw := os.Getenv("ALERT_DIGEST_HOUR"); raw != "" {
+		var err error
+		digestHour, err = strconv.Atoi(raw)
+		if err != nil || digestHour < 0 || digestHour > 23 {
+			return nil, fmt.Errorf("unsupported ALERT_DIGEST_HOUR %q, use an hour from 0 to 23", raw)
+		}
+	}
+	notifier, err := NewWebhookNotifier(url)
+	if err != nil {
+		return nil, err
+	}
+	policy := NewPolicy(notifier, window, trigger)
+	policy.digestHour = digestHour
+	return policy, nil
+}
+
+// UseQueue delivers the policy's alerts as jobs on queue, so failed deliveries are
+// retried and alerts survive restarts.
+func (p *Policy) UseQueue(queue jobs.Queue) {
+	if p == nil {
+		return
+	}
+	queue.Handle(alertJob, p.send)
+	p.queue = queue
+}
+
+// Check evaluates the alert thresholds for the event after a booking and sends the
+// alerts that fired in the background. Each capacity alert is sent once per event;
+// sales velocity alerts at most once per velocity window. Failures are logged on
+// the logger carried by ctx and never affect the booking.
+func (p *Policy) Check(ctx context.Context, event models.Event) {
+	if p == nil {
+		return
+	}
+	alerts, err := p.evaluate(ctx, event)
+	if err != nil {
+		logging.FromContext(ctx).Error("Couldn't evaluate capacity alerts", "event_id", event.ID, "error", err)
+	}
+	p.deliver(ctx, alerts)
+}
+
+// NotifyMessage tells the event's organizer in the background that an attendee
+// sent them a message. Failures are logged and never affect the message.
+func (p *Policy) NotifyMessage(ctx context.Context, event models.Event, message models.Message) {
+	if p == nil {
+		return
+	}
+	p.deliver(ctx, []Alert{{
+		Kind:       AttendeeMessage,
+		EventID:    event.ID,
+		EventTitle: event.Title,
+		UserID:     event.UserID,
+		Message:    "New message from an attendee of " + event.Title + ": " + message.Body,
+		CreatedAt:  message.CreatedAt,
+	}})
+}
+
+// deliver sends the alerts in the background, logging failures.
+func (p *Policy) deliver(ctx context.Context, alerts []Alert) {
+	if len(alerts) == 0 {
+		return
+	}
+	if p.queue != nil {
+		for _, alert := range alerts {
+			err := p.queue.Enqueue(ctx, alertJob, alert)
+			if err != nil {
+				logging.FromContext(ctx).Error("Couldn't queue alert", "kind", alert.Kind, "event_id", alert.EventID, "error", err)
+			}
+		}
+		return
+	}
+	requestID := logging.RequestID(ctx)
+	go func() {
+		notifyCtx, cancel := context.WithTimeout(logging.WithRequestID(context.Background(), requestID), notifyTimeout)
+		defer cancel()
+		for _, alert := range alerts {
+			err := p.notifier.Notify(notifyCtx, alert)
+			if err != nil {
+				slog.Error("Couldn't send alert", "notifier", p.notifier.Name(), "kind", alert.Kind,
+					"event_id", alert.EventID, "request_id", requestID, "error", err)
+			}
+		}
+	}()
+}
+
+// send delivers the alert of a queued job.
+func (p *Policy) send(ctx context.Context, payload []byte) error {
+	var alert Alert
+	if err := json.Unmarshal(payload, &alert); err != nil {
+		return jobs.Permanent(err)
+	}
+	notifyCtx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+	return p.notifier.Notify(notifyCtx, alert)
+}
+
+// evaluate returns the alerts the event's current bookings fire, recording them
+// so they aren't sent again.
+func (p *Policy) evaluate(ctx context.Context, event models.Event) ([]Alert, error) {
+	registrations, err := event.CountRegistrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	base := Alert{
+		EventID:       event.ID,
+		EventTitle:    event.Title,
+		UserID:        event.UserID,
+		Registrations: registrations,
+		Capacity:      event.Capacity,
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	var candidates []Alert
+	// A booking that fills the event at once fires both capacity alerts, nearly full first
+	if event.Capacity > 0 && registrations*100 >= event.Capacity*nearlyFullPercent {
+		alert := base
+		alert.Kind = CapacityNearlyFull
+		alert.Message = fmt.Sprintf("%s is %d%% full: %d of %d places are booked",
+			event.Title, registrations*100/event.Capacity, registrations, event.Capacity)
+		candidates = append(candidates, alert)
+	}
+	if event.Capacity > 0 && registrations >= event.Capacity {
+		alert := base
+		alert.Kind = CapacityFull
+		alert.Message = fmt.Sprintf("%s is sold out: all %d places are booked", event.Title, event.Capacity)
+		candidates = append(candidates, alert)
+	}
+	if p.velocityTrigger > 0 {
+		recent, err := event.CountRegistrationsSince(ctx, base.CreatedAt.Add(-p.velocityWindow))
+		if err != nil {
+			return nil, err
+		}
+		if recent >= p.velocityTrigger {
+			alert := base
+			alert.Kind = SalesVelocity
+			alert.RecentSales = recent
+			alert.Message = fmt.Sprintf("%s is selling fast: %d bookings in the last %s", event.Title, recent, p.velocityWindow)
+			candidates = append(candidates, alert)
+		}
+	}
+
+	var fired []Alert
+	var errs []error
+	for _, alert := range candidates {
+		repeatAfter := time.Duration(0)
+		if alert.Kind == SalesVelocity {
+			repeatAfter = p.velocityWindow
+		}
+		send, err := models.RecordAlert(ctx, event.ID, alert.Kind, repeatAfter)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if send {
+			fired = append(fired, alert)
+		}
+	}
+	return fired, errors.Join(errs...)
+}
@@ -0,0 +1,246 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"event_booking_restapi_golang/jobs"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/testutils"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeNotifier passes the alerts it is sent to a channel and can be told to fail
+type fakeNotifier struct {
+	alerts chan Alert
+	err    error
+}
+
+func newFakeNotifier() *fakeNotifier {
+	return &fakeNotifier{alerts: make(chan Alert, 10)}
+}
+
+func (n *fakeNotifier) Name() string {
+	return "fake"
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, alert Alert) error {
+	n.alerts <- alert
+	return n.err
+}
+
+// received returns the kinds of the alerts sent within a short wait
+func (n *fakeNotifier) received(t *testing.T, expected int) []string {
+	var kinds []string
+	for len(kinds) < expected {
+		select {
+		case alert := <-n.alerts:
+			kinds = append(kinds, alert.Kind)
+		case <-time.After(time.Second):
+			t.Fatalf("Expected %d alerts, got %v", expected, kinds)
+		}
+	}
+	select {
+	case alert := <-n.alerts:
+		t.Fatalf("Unexpected alert %+v", alert)
+	case <-time.After(20 * time.Millisecond):
+	}
+	return kinds
+}
+
+// bookedEvent inserts an event with the capacity and books places on it
+func bookedEvent(t *testing.T, tdb *testutils.TestDB, capacity, bookings int) models.Event {
+	id := uuid.NewString()
+	_, err := tdb.DB.Exec("INSERT INTO events (id, name, description, location, datetime, user_id, capacity) VALUES (?,?,?,?,?,?,?)",
+		id, "Concert", "Live music", "Hall", time.Now().Add(24*time.Hour), "organizer-1", capacity)
+	if err != nil {
+		t.Fatalf("Failed to insert event: %v", err)
+	}
+	event := models.Event{ID: id, Title: "Concert", UserID: "organizer-1", Capacity: capacity}
+	book(t, event, bookings)
+	return event
+}
+
+// book registers count new attendees for the event
+func book(t *testing.T, event models.Event, count int) {
+	for i := 0; i < count; i++ {
+		email := fmt.Sprintf("attendee-%d-%d@example.com", time.Now().UnixNano(), i)
+		_, err := event.Register(context.Background(), models.Registration{Name: "Attendee", Email: email})
+		if err != nil {
+			t.Fatalf("Failed to register: %v", err)
+		}
+	}
+}
+
+// TestCheckCapacity tests that each capacity threshold alerts once
+func TestCheckCapacity(t *testing.T) {
+	tdb := testutils.SetupTestDatabase(t)
+	defer tdb.Cleanup()
+	notifier := newFakeNotifier()
+	policy := NewPolicy(notifier, time.Hour, 0)
+
+	event := bookedEvent(t, tdb, 10, 7)
+	policy.Check(context.Background(), event)
+	notifier.received(t, 0)
+
+	book(t, event, 1)
+	policy.Check(context.Background(), event)
+	if kinds := notifier.received(t, 1); kinds[0] != CapacityNearlyFull {
+		t.Errorf("Expected the 80%% alert, got %v", kinds)
+	}
+	book(t, event, 1)
+	policy.Check(context.Background(), event)
+	notifier.received(t, 0)
+
+	book(t, event, 1)
+	policy.Check(context.Background(), event)
+	if kinds := notifier.received(t, 1); kinds[0] != CapacityFull {
+		t.Errorf("Expected the sold out alert, got %v", kinds)
+	}
+	policy.Check(context.Background(), event)
+	notifier.received(t, 0)
+
+	// An event filled by its first bookings gets both alerts, nearly full first
+	small := bookedEvent(t, tdb, 2, 2)
+	policy.Check(context.Background(), small)
+	kinds := notifier.received(t, 2)
+	if kinds[0] != CapacityNearlyFull || kinds[1] != CapacityFull {
+		t.Errorf("Expected both capacity alerts in order, got %v", kinds)
+	}
+
+	unlimited := bookedEvent(t, tdb, 0, 3)
+	policy.Check(context.Background(), unlimited)
+	notifier.received(t, 0)
+}
+
+// TestCheckSalesVelocity tests that bookings spikes alert at most once per window
+func TestCheckSalesVelocity(t *testing.T) {
+	tdb := testutils.SetupTestDatabase(t)
+	defer tdb.Cleanup()
+	notifier := newFakeNotifier()
+	policy := NewPolicy(notifier, time.Hour, 3)
+
+	event := bookedEvent(t, tdb, 0, 2)
+	policy.Check(context.Background(), event)
+	notifier.received(t, 0)
+
+	book(t, event, 1)
+	policy.Check(context.Background(), event)
+	if kinds := notifier.received(t, 1); kinds[0] != SalesVelocity {
+		t.Errorf("Expected the sales velocity alert, got %v", kinds)
+	}
+	book(t, event, 1)
+	policy.Check(context.Background(), event)
+	notifier.received(t, 0)
+
+	// Once the window has passed since the last alert, a spike alerts again
+	_, err := tdb.DB.Exec("UPDATE event_alerts SET sent_at = ?", time.Now().Add(-2*time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to age the alert: %v", err)
+	}
+	policy.Check(context.Background(), event)
+	notifier.received(t, 1)
+}
+
+// TestCheckNotifierFailure tests that a failing notifier doesn't resend the alert
+func TestCheckNotifierFailure(t *testing.T) {
+	tdb := testutils.SetupTestDatabase(t)
+	defer tdb.Cleanup()
+	notifier := newFakeNotifier()
+	notifier.err = errors.New("webhook is down")
+	policy := NewPolicy(notifier, time.Hour, 0)
+
+	event := bookedEvent(t, tdb, 1, 1)
+	policy.Check(context.Background(), event)
+	notifier.received(t, 2)
+	policy.Check(context.Background(), event)
+	notifier.received(t, 0)
+}
+
+// TestCheckQueued tests that alerts go through the job queue when one is used, which
+// retries failed deliveries
+func TestCheckQueued(t *testing.T) {
+	tdb := testutils.SetupTestDatabase(t)
+	defer tdb.Cleanup()
+	notifier := newFakeNotifier()
+	notifier.err = errors.New("webhook is down")
+	policy := NewPolicy(notifier, time.Hour, 0)
+	pool := jobs.New(1)
+	policy.UseQueue(pool)
+
+	event := bookedEvent(t, tdb, 1, 1)
+	policy.Check(context.Background(), event)
+	notifier.received(t, 0)
+	for range 2 {
+		if ran, err := pool.RunNext(context.Background()); !ran || err != nil {
+			t.Fatalf("Expected an alert job to run, got %v, %v", ran, err)
+		}
+	}
+	notifier.received(t, 2)
+
+	var pending int
+	err := tdb.DB.QueryRow("SELECT COUNT(*) FROM jobs WHERE status=? AND last_error=?", models.JobPending, "webhook is down").Scan(&pending)
+	if err != nil || pending != 2 {
+		t.Errorf("Expected both alerts to wait for a retry, got %d, %v", pending, err)
+	}
+}
+
+// TestNotifyMessage tests that attendee messages are sent to the organizer
+func TestNotifyMessage(t *testing.T) {
+	notifier := newFakeNotifier()
+	policy := NewPolicy(notifier, time.Hour, 0)
+	event := models.Event{ID: "e1", Title: "Concert", UserID: "organizer-1"}
+	policy.NotifyMessage(context.Background(), event, models.Message{Body: "Is there parking?", CreatedAt: time.Now()})
+
+	select {
+	case alert := <-notifier.alerts:
+		if alert.Kind != AttendeeMessage || alert.UserID != "organizer-1" || alert.Message != "New message from an attendee of Concert: Is there parking?" {
+			t.Errorf("Unexpected alert %+v", alert)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the organizer to be notified")
+	}
+}
+
+// TestNilPolicy tests that a nil policy never alerts
+func TestNilPolicy(t *testing.T) {
+	var policy *Policy
+	policy.Check(context.Background(), models.Event{ID: "e1", Capacity: 1})
+	policy.NotifyMessage(context.Background(), models.Event{ID: "e1"}, models.Message{Body: "Hi"})
+	if sent, err := policy.SendDigests(context.Background(), time.Now()); sent != 0 || err != nil {
+		t.Errorf("Expected no digests, got %d, %v", sent, err)
+	}
+}
+
+// TestPolicyFromEnv tests reading the alert settings from the environment
+func TestPolicyFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		enabled bool
+		wantErr bool
+	}{
+		{"disabled", map[string]string{}, false, false},
+		{"webhook", map[string]string{"ALERT_WEBHOOK_URL": "https://hooks.example.com/alerts"}, true, false},
+		{"velocity settings", map[string]string{"ALERT_WEBHOOK_URL": "https://hooks.example.com/alerts", "ALERT_VELOCITY_BOOKINGS": "0", "ALERT_VELOCITY_WINDOW": "15m"}, true, false},
+		{"invalid url", map[string]string{"ALERT_WEBHOOK_URL": "hooks.example.com"}, false, true},
+		{"invalid bookings", map[string]string{"ALERT_WEBHOOK_URL": "https://hooks.example.com/alerts", "ALERT_VELOCITY_BOOKINGS": "-1"}, false, true},
+		{"invalid window", map[string]string{"ALERT_WEBHOOK_URL": "https://hooks.example.com/alerts", "ALERT_VELOCITY_WINDOW": "hourly"}, false, true},
+		{"digest hour", map[string]string{"ALERT_WEBHOOK_URL": "https://hooks.example.com/alerts", "ALERT_DIGEST_HOUR": "0"}, true, false},
+		{"invalid digest hour", map[string]string{"ALERT_WEBHOOK_URL": "https://hooks.example.com/alerts", "ALERT_DIGEST_HOUR": "24"}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"ALERT_WEBHOOK_URL", "ALERT_VELOCITY_BOOKINGS", "ALERT_VELOCITY_WINDOW", "ALERT_DIGEST_HOUR"} {
+				t.Setenv(key, tt.env[key])
+			}
+			policy, err := PolicyFromEnv()
+			if (err != nil) != tt.wantErr || (policy != nil) != tt.enabled {
+				t.Errorf("Expected enabled %v and error %v, got %v, %v", tt.enabled, tt.wantErr, policy, err)
+			}
+		})
+	}
+}
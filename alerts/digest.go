@@ -0,0 +1,93 @@
+package alerts
+
+import (
+	"context"
+	"event_booking_restapi_golang/models"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// defaultDigestHour is the hour of the day, in UTC, digests are sent at unless configured.
+const defaultDigestHour = 8
+
+// SendDigests sends every organizer with pending actions a single PendingDigest alert
+// listing them, as of now. Organizers with nothing pending get no digest.
+// Returns the number of digests delivered; delivery failures are logged and the
+// remaining digests still sent.
+func (p *Policy) SendDigests(ctx context.Context, now time.Time) (int, error) {
+	if p == nil {
+		return 0, nil
+	}
+	byOrganizer, err := models.GetPendingItemsByOrganizer(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	organizers := make([]string, 0, len(byOrganizer))
+	for userID := range byOrganizer {
+		organizers = append(organizers, userID)
+	}
+	sort.Strings(organizers)
+
+	sent := 0
+	for _, userID := range organizers {
+		items := byOrganizer[userID]
+		notifyCtx, cancel := context.WithTimeout(ctx, notifyTimeout)
+		err := p.notifier.Notify(notifyCtx, Alert{
+			Kind:      PendingDigest,
+			UserID:    userID,
+			Items:     items,
+			Message:   digestMessage(items),
+			CreatedAt: now,
+		})
+		cancel()
+		if err != nil {
+			slog.Error("Couldn't send the pending actions digest", "notifier", p.notifier.Name(), "user_id", userID, "error", err)
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// digestMessage summarizes the pending items, one per line under a count.
+func digestMessage(items []models.PendingItem) string {
+	message := "You have 1 pending action:"
+	if len(items) != 1 {
+		message = fmt.Sprintf("You have %d pending actions:", len(items))
+	}
+	for _, item := range items {
+		message += "\n- " + item.Message
+	}
+	return message
+}
+
+// nextDigest returns the first time after now at the policy's digest hour.
+func (p *Policy) nextDigest(now time.Time) time.Time {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), p.digestHour, 0, 0, 0, time.UTC)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// StartDigests sends the digests every day at the policy's digest hour in a
+// background goroutine. Failures are logged and retried the next day.
+func (p *Policy) StartDigests() {
+	if p == nil {
+		return
+	}
+	go func() {
+		for {
+			time.Sleep(time.Until(p.nextDigest(time.Now())))
+			sent, err := p.SendDigests(context.Background(), time.Now())
+			if err != nil {
+				slog.Error("Couldn't compile the pending actions digests", "error", err)
+				continue
+			}
+			slog.Info("Sent the pending actions digests", "digests", sent)
+		}
+	}()
+}
@@ -0,0 +1,69 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/testutils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TestSendDigests tests that each organizer with pending actions gets one digest listing them
+func TestSendDigests(t *testing.T) {
+	tdb := testutils.SetupTestDatabase(t)
+	defer tdb.Cleanup()
+	notifier := newFakeNotifier()
+	policy := NewPolicy(notifier, time.Hour, 0)
+
+	for _, organizer := range []string{"organizer-2", "organizer-1", "organizer-1"} {
+		_, err := tdb.DB.Exec("INSERT INTO events (id, name, description, location, datetime, user_id) VALUES (?,?,?,?,?,?)",
+			uuid.NewString(), "Concert for "+organizer, "Live music", "Hall", time.Now().Add(24*time.Hour), organizer)
+		if err != nil {
+			t.Fatalf("Failed to insert event: %v", err)
+		}
+	}
+
+	sent, err := policy.SendDigests(context.Background(), time.Now())
+	if err != nil || sent != 2 {
+		t.Fatalf("Expected 2 digests, got %d, %v", sent, err)
+	}
+	first, second := <-notifier.alerts, <-notifier.alerts
+	if first.Kind != PendingDigest || first.UserID != "organizer-1" || len(first.Items) != 2 || second.UserID != "organizer-2" || len(second.Items) != 1 {
+		t.Fatalf("Expected a digest per organizer, got %+v and %+v", first, second)
+	}
+	if first.Items[0].Kind != models.PendingMissingImage || !strings.HasPrefix(first.Message, "You have 2 pending actions:\n- ") {
+		t.Errorf("Unexpected digest %+v", first)
+	}
+	if !strings.HasPrefix(second.Message, "You have 1 pending action:") {
+		t.Errorf("Expected a singular summary, got %q", second.Message)
+	}
+
+	notifier.err = errors.New("webhook down")
+	sent, err = policy.SendDigests(context.Background(), time.Now())
+	if err != nil || sent != 0 {
+		t.Errorf("Expected failed deliveries not to count, got %d, %v", sent, err)
+	}
+}
+
+// TestNextDigest tests scheduling digests at the configured hour
+func TestNextDigest(t *testing.T) {
+	policy := NewPolicy(newFakeNotifier(), time.Hour, 0)
+	tests := []struct {
+		now      time.Time
+		expected time.Time
+	}{
+		{time.Date(2026, 3, 10, 7, 30, 0, 0, time.UTC), time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC)},
+		{time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC), time.Date(2026, 3, 11, 8, 0, 0, 0, time.UTC)},
+		{time.Date(2026, 3, 31, 23, 0, 0, 0, time.UTC), time.Date(2026, 4, 1, 8, 0, 0, 0, time.UTC)},
+		{time.Date(2026, 3, 10, 8, 30, 0, 0, time.FixedZone("CET", 3600)), time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := policy.nextDigest(tt.now); !got.Equal(tt.expected) {
+			t.Errorf("nextDigest(%v) = %v, expected %v", tt.now, got, tt.expected)
+		}
+	}
+}
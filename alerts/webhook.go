@@ -0,0 +1,71 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/logging"
+	"event_booking_restapi_golang/models"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// webhookNotifier posts alerts as JSON to a URL, such as a chat integration or the
+// service that emails organizers.
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// webhookPayload is the JSON body of an alert webhook.
+type webhookPayload struct {
+	Kind          string               `json:"kind"`
+	EventID       string               `json:"event_id"`
+	EventTitle    string               `json:"event_title"`
+	UserID        string               `json:"user_id"`
+	Registrations int                  `json:"registrations"`
+	Capacity      int                  `json:"capacity"`
+	RecentSales   int                  `json:"recent_sales,omitempty"`
+	Items         []models.PendingItem `json:"items,omitempty"`
+	Message       string               `json:"message"`
+	CreatedAt     time.Time            `json:"created_at"`
+}
+
+// NewWebhookNotifier returns a notifier that posts each alert to rawURL, which must
+// be an http or https URL. The request ID of the booking that fired the alert is
+// sent in the X-Request-ID header.
+func NewWebhookNotifier(rawURL string) (Notifier, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("alert webhook URL %q must be an http or https URL", rawURL)
+	}
+	return &webhookNotifier{url: rawURL, client: &http.Client{Timeout: notifyTimeout}}, nil
+}
+
+func (n *webhookNotifier) Name() string {
+	return "webhook"
+}
+
+func (n *webhookNotifier) Notify(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(webhookPayload(alert))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	logging.SetRequestID(req)
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("alert webhook answered %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/logging"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestWebhookNotifier tests that alerts are posted as JSON with the request ID
+func TestWebhookNotifier(t *testing.T) {
+	var received map[string]any
+	var requestID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID = r.Header.Get(logging.RequestIDHeader)
+		json.NewDecoder(r.Body).Decode(&received)
+		if received["event_id"] == "broken" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	notifier, err := NewWebhookNotifier(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+	alert := Alert{
+		Kind:          CapacityFull,
+		EventID:       "e1",
+		EventTitle:    "Concert",
+		UserID:        "organizer-1",
+		Registrations: 10,
+		Capacity:      10,
+		Message:       "Concert is sold out: all 10 places are booked",
+		CreatedAt:     time.Now(),
+	}
+	err = notifier.Notify(logging.WithRequestID(context.Background(), "req-1"), alert)
+	if err != nil {
+		t.Fatalf("Failed to notify: %v", err)
+	}
+	if received["kind"] != CapacityFull || received["user_id"] != "organizer-1" || received["capacity"] != float64(10) || requestID != "req-1" {
+		t.Errorf("Unexpected webhook %v with request ID %q", received, requestID)
+	}
+	if _, ok := received["recent_sales"]; ok {
+		t.Errorf("Expected recent_sales to be left out of capacity alerts, got %v", received)
+	}
+
+	alert.EventID = "broken"
+	err = notifier.Notify(context.Background(), alert)
+	if err == nil {
+		t.Error("Expected an error for a failed delivery")
+	}
+}
@@ -0,0 +1,171 @@
+// Package analytics sends anonymized product usage events, such as searches and
+// bookings, to an analytics sink. Events carry a keyed hash of the session instead
+// of the session ID and have personal data scrubbed from their properties before
+// they are queued, so nothing that identifies an attendee leaves the API.
+package analytics
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Usage events the API emits.
+const (
+	SearchPerformed  = "search.performed"  // An event listing was requested
+	EventViewed      = "event.viewed"      // A single event was fetched
+	BookingCompleted = "booking.completed" // A registration was created
+)
+
+const (
+	queueSize  = 1000 // Events buffered between flushes; further events are dropped
+	flushBatch = 100  // Events sent to the sink at once
+)
+
+// Event is an anonymized usage event.
+type Event struct {
+	MessageID   string         // Unique ID of the event, so sinks can drop duplicates
+	Name        string         // One of the usage event names, e.g. SearchPerformed
+	AnonymousID string         // Keyed hash of the session, see Pipeline.AnonymousID
+	Properties  map[string]any // Scrubbed event details
+	Timestamp   time.Time      // Time the event happened
+}
+
+// Sink receives batches of usage events.
+type Sink interface {
+	// Name identifies the sink in logs.
+	Name() string
+	// Write delivers the events.
+	Write(ctx context.Context, events []Event) error
+}
+
+// Pipeline anonymizes usage events and delivers them to a sink in the background.
+// A nil *Pipeline is valid and drops every event, which is how analytics is disabled.
+type Pipeline struct {
+	sink  Sink
+	key   []byte
+	queue chan Event
+}
+
+// NewPipeline returns a pipeline delivering to sink. Session IDs are hashed with
+// salt; when salt is empty a random one is used, so anonymous IDs can't be linked
+// across restarts.
+func NewPipeline(sink Sink, salt string) *Pipeline {
+	key := []byte(salt)
+	if salt == "" {
+		key = make([]byte, 32)
+		rand.Read(key)
+	}
+	return &Pipeline{sink: sink, key: key, queue: make(chan Event, queueSize)}
+}
+
+// FromEnv builds the pipeline from ANALYTICS_SINK ("file" or "segment") and
+// ANALYTICS_SALT. Returns nil when no sink is configured, which disables analytics.
+func FromEnv() (*Pipeline, error) {
+	var sink Sink
+	switch name := os.Getenv("ANALYTICS_SINK"); name {
+	case "":
+		return nil, nil
+	case "file":
+		path := os.Getenv("ANALYTICS_FILE")
+		if path == "" {
+			path = "analytics.ndjson"
+		}
+		sink = NewFileSink(path)
+	case "segment":
+		var err error
+		sink, err = NewSegmentSink(os.Getenv("ANALYTICS_SEGMENT_WRITE_KEY"), os.Getenv("ANALYTICS_SEGMENT_ENDPOINT"))
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported ANALYTICS_SINK %q, use file or segment", name)
+	}
+	return NewPipeline(sink, os.Getenv("ANALYTICS_SALT")), nil
+}
+
+// Enabled reports whether events are delivered anywhere.
+func (p *Pipeline) Enabled() bool {
+	return p != nil
+}
+
+// AnonymousID returns the hex HMAC-SHA256 of the session ID under the pipeline's
+// salt. It is stable for a session but can't be turned back into the session ID.
+func (p *Pipeline) AnonymousID(sessionID string) string {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(sessionID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Track queues a usage event for the session with its properties scrubbed of
+// personal data. Sessions that are empty get a one-off anonymous ID. Track never
+// blocks: when the queue is full the event is dropped and logged.
+func (p *Pipeline) Track(name, sessionID string, properties map[string]any) {
+	if p == nil {
+		return
+	}
+	if sessionID == "" {
+		sessionID = uuid.NewString()
+	}
+	event := Event{
+		MessageID:   uuid.NewString(),
+		Name:        name,
+		AnonymousID: p.AnonymousID(sessionID),
+		Properties:  Scrub(properties),
+		Timestamp:   time.Now().UTC(),
+	}
+	select {
+	case p.queue <- event:
+	default:
+		slog.Warn("Analytics queue full, dropping event", "event", name)
+	}
+}
+
+// Flush sends the queued events to the sink in batches. Analytics is best effort:
+// a batch the sink rejects is dropped rather than retried, and the error returned.
+func (p *Pipeline) Flush(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+	for {
+		batch := make([]Event, 0, flushBatch)
+	fill:
+		for len(batch) < flushBatch {
+			select {
+			case event := <-p.queue:
+				batch = append(batch, event)
+			default:
+				break fill
+			}
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		err := p.sink.Write(ctx, batch)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Start flushes queued events to the sink on every interval in a background
+// goroutine. Failures are logged.
+func (p *Pipeline) Start(interval time.Duration) {
+	go func() {
+		for {
+			time.Sleep(interval)
+			err := p.Flush(context.Background())
+			if err != nil {
+				slog.Error("Couldn't send usage events", "sink", p.sink.Name(), "error", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,152 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// fakeSink records the batches written to it and can be told to fail
+type fakeSink struct {
+	batches [][]Event
+	err     error
+}
+
+func (s *fakeSink) Name() string {
+	return "fake"
+}
+
+func (s *fakeSink) Write(ctx context.Context, events []Event) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.batches = append(s.batches, events)
+	return nil
+}
+
+// TestTrackAndFlush tests that tracked events are anonymized, scrubbed and delivered in batches
+func TestTrackAndFlush(t *testing.T) {
+	sink := &fakeSink{}
+	p := NewPipeline(sink, "salt")
+
+	for i := 0; i < flushBatch+1; i++ {
+		p.Track(SearchPerformed, "session-1", map[string]any{"location": "jane@example.com", "email": "jane@example.com"})
+	}
+	err := p.Flush(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	if len(sink.batches) != 2 || len(sink.batches[0]) != flushBatch || len(sink.batches[1]) != 1 {
+		t.Fatalf("Expected batches of %d and 1 events, got %d batches", flushBatch, len(sink.batches))
+	}
+
+	event := sink.batches[0][0]
+	if event.Name != SearchPerformed || event.MessageID == "" || event.Timestamp.IsZero() {
+		t.Errorf("Unexpected event %+v", event)
+	}
+	if event.AnonymousID == "session-1" || event.AnonymousID != p.AnonymousID("session-1") {
+		t.Errorf("Expected the session to be replaced by its hash, got %s", event.AnonymousID)
+	}
+	if _, ok := event.Properties["email"]; ok {
+		t.Error("Expected the email property to be removed")
+	}
+	if event.Properties["location"] != Redacted {
+		t.Errorf("Expected the email in location to be redacted, got %v", event.Properties["location"])
+	}
+
+	err = p.Flush(context.Background())
+	if err != nil || len(sink.batches) != 2 {
+		t.Errorf("Expected nothing left to flush, got %d batches, %v", len(sink.batches), err)
+	}
+}
+
+// TestTrackWithoutSession tests that events without a session get unlinkable anonymous IDs
+func TestTrackWithoutSession(t *testing.T) {
+	sink := &fakeSink{}
+	p := NewPipeline(sink, "")
+	p.Track(EventViewed, "", nil)
+	p.Track(EventViewed, "", nil)
+	p.Flush(context.Background())
+
+	events := sink.batches[0]
+	if events[0].AnonymousID == "" || events[0].AnonymousID == events[1].AnonymousID {
+		t.Errorf("Expected distinct anonymous IDs, got %q and %q", events[0].AnonymousID, events[1].AnonymousID)
+	}
+}
+
+// TestAnonymousIDSalt tests that anonymous IDs depend on the salt
+func TestAnonymousIDSalt(t *testing.T) {
+	a, b := NewPipeline(nil, "one"), NewPipeline(nil, "two")
+	if a.AnonymousID("s") != a.AnonymousID("s") {
+		t.Error("Expected anonymous IDs to be stable")
+	}
+	if a.AnonymousID("s") == b.AnonymousID("s") {
+		t.Error("Expected anonymous IDs to differ between salts")
+	}
+}
+
+// TestTrackQueueFull tests that tracking never blocks when the queue is full
+func TestTrackQueueFull(t *testing.T) {
+	sink := &fakeSink{}
+	p := NewPipeline(sink, "salt")
+	for i := 0; i < queueSize+10; i++ {
+		p.Track(EventViewed, "s", nil)
+	}
+	p.Flush(context.Background())
+
+	delivered := 0
+	for _, batch := range sink.batches {
+		delivered += len(batch)
+	}
+	if delivered != queueSize {
+		t.Errorf("Expected %d events delivered, got %d", queueSize, delivered)
+	}
+}
+
+// TestFlushError tests that a failing sink's error is returned
+func TestFlushError(t *testing.T) {
+	p := NewPipeline(&fakeSink{err: errors.New("down")}, "salt")
+	p.Track(EventViewed, "s", nil)
+	if err := p.Flush(context.Background()); err == nil {
+		t.Error("Expected the sink error to be returned")
+	}
+}
+
+// TestNilPipeline tests that a disabled pipeline accepts and drops events
+func TestNilPipeline(t *testing.T) {
+	var p *Pipeline
+	if p.Enabled() {
+		t.Error("Expected a nil pipeline to be disabled")
+	}
+	p.Track(EventViewed, "s", nil)
+	if err := p.Flush(context.Background()); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+// TestFromEnv tests sink selection from the environment
+func TestFromEnv(t *testing.T) {
+	t.Setenv("ANALYTICS_SINK", "")
+	p, err := FromEnv()
+	if err != nil || p != nil {
+		t.Errorf("Expected analytics to be disabled, got %v, %v", p, err)
+	}
+
+	t.Setenv("ANALYTICS_SINK", "file")
+	t.Setenv("ANALYTICS_FILE", t.TempDir()+"/events.ndjson")
+	p, err = FromEnv()
+	if err != nil || p == nil {
+		t.Errorf("Expected a file pipeline, got %v, %v", p, err)
+	}
+
+	t.Setenv("ANALYTICS_SINK", "segment")
+	t.Setenv("ANALYTICS_SEGMENT_WRITE_KEY", "")
+	if _, err = FromEnv(); err == nil {
+		t.Error("Expected an error for segment without a write key")
+	}
+
+	t.Setenv("ANALYTICS_SINK", "mixpanel")
+	if _, err = FromEnv(); err == nil {
+		t.Error("Expected an error for an unsupported sink")
+	}
+}
@@ -0,0 +1,49 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// fileSink appends events to a file as JSON Lines.
+type fileSink struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileSink returns a sink that appends each event as a JSON object on its own
+// line to the file at path, creating it if needed. The file can be shipped to a
+// warehouse or tailed by a log collector.
+func NewFileSink(path string) Sink {
+	return &fileSink{path: path}
+}
+
+func (s *fileSink) Name() string {
+	return "file:" + s.path
+}
+
+func (s *fileSink) Write(ctx context.Context, events []Event) error {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, event := range events {
+		err := encoder.Encode(event)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(body.Bytes())
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,42 @@
+package analytics
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestFileSinkWrite tests that events are appended to the file as JSON Lines
+func TestFileSinkWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	sink := NewFileSink(path)
+
+	for _, name := range []string{SearchPerformed, BookingCompleted} {
+		err := sink.Write(context.Background(), []Event{{MessageID: "m", Name: name, AnonymousID: "a", Timestamp: time.Now()}})
+		if err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open the file: %v", err)
+	}
+	defer file.Close()
+	var names []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("Invalid JSON line %s: %v", scanner.Text(), err)
+		}
+		names = append(names, event.Name)
+	}
+	if len(names) != 2 || names[0] != SearchPerformed || names[1] != BookingCompleted {
+		t.Errorf("Expected both batches appended in order, got %v", names)
+	}
+}
@@ -0,0 +1,105 @@
+package analytics
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces personal data found in property values.
+const Redacted = "[redacted]"
+
+// piiKeys are property names whose values are always personal data, compared
+// case-insensitively with dashes and underscores removed.
+var piiKeys = map[string]bool{
+	"email": true, "name": true, "firstname": true, "lastname": true, "fullname": true,
+	"phone": true, "phonenumber": true, "address": true, "ip": true, "ipaddress": true,
+	"sessionid": true, "userid": true, "useragent": true,
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[^\s@]+@[^\s@]+\.[^\s@]+`)
+	// Runs of 7 or more digits, optionally separated, catch phone and card numbers
+	phonePattern = regexp.MustCompile(`\+?\d[\d\s().-]{5,}\d`)
+)
+
+// Scrub returns a copy of the properties without personal data: properties whose
+// names denote personal data are removed, and email addresses and phone-like
+// numbers inside string values are replaced with Redacted. Nested maps and slices
+// are scrubbed too.
+func Scrub(properties map[string]any) map[string]any {
+	if properties == nil {
+		return nil
+	}
+	scrubbed := make(map[string]any, len(properties))
+	for key, value := range properties {
+		if isPIIKey(key) {
+			continue
+		}
+		scrubbed[key] = scrubValue(value)
+	}
+	return scrubbed
+}
+
+func isPIIKey(key string) bool {
+	key = strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+	return piiKeys[key]
+}
+
+func scrubValue(value any) any {
+	switch v := value.(type) {
+	case string:
+		return scrubString(v)
+	case map[string]any:
+		return Scrub(v)
+	case []any:
+		scrubbed := make([]any, len(v))
+		for i, item := range v {
+			scrubbed[i] = scrubValue(item)
+		}
+		return scrubbed
+	case []string:
+		scrubbed := make([]string, len(v))
+		for i, item := range v {
+			scrubbed[i] = scrubString(item)
+		}
+		return scrubbed
+	default:
+		return value
+	}
+}
+
+func scrubString(s string) string {
+	s = emailPattern.ReplaceAllString(s, Redacted)
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range phonePattern.FindAllStringIndex(s, -1) {
+		start, end := loc[0], loc[1]
+		// Digit runs inside identifiers such as UUIDs aren't phone numbers
+		if start > 0 && isAlphanumeric(s[start-1]) || end < len(s) && isAlphanumeric(s[end]) {
+			continue
+		}
+		if countDigits(s[start:end]) < 7 {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(Redacted)
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func countDigits(s string) int {
+	digits := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			digits++
+		}
+	}
+	return digits
+}
+
+func isAlphanumeric(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
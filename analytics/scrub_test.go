@@ -0,0 +1,38 @@
+package analytics
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestScrub tests that personal data is removed from properties
+func TestScrub(t *testing.T) {
+	properties := map[string]any{
+		"Email":        "jane@example.com",
+		"user_id":      "u1",
+		"phone-number": "+1 555 123 4567",
+		"event_id":     "b01377fe-c441-4411-ba63-8d6d00d68c84",
+		"query":        "concerts near jane@example.com or call +44 20 7946 0958",
+		"total":        12,
+		"year":         "2026",
+		"nested":       map[string]any{"name": "Jane", "city": "Berlin"},
+		"tags":         []string{"music", "jane.doe@example.org"},
+	}
+	expected := map[string]any{
+		"event_id": "b01377fe-c441-4411-ba63-8d6d00d68c84",
+		"query":    "concerts near [redacted] or call [redacted]",
+		"total":    12,
+		"year":     "2026",
+		"nested":   map[string]any{"city": "Berlin"},
+		"tags":     []string{"music", "[redacted]"},
+	}
+	if got := Scrub(properties); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	if properties["Email"] != "jane@example.com" {
+		t.Error("Expected the original properties to be left unchanged")
+	}
+	if Scrub(nil) != nil {
+		t.Error("Expected nil properties to stay nil")
+	}
+}
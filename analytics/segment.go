@@ -0,0 +1,92 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultSegmentEndpoint is the base URL of Segment's HTTP Tracking API.
+const defaultSegmentEndpoint = "https://api.segment.io"
+
+// segmentSink sends events to Segment, or any service accepting its batch API.
+type segmentSink struct {
+	writeKey string
+	endpoint string
+	client   *http.Client
+}
+
+// segmentMessage is a track call in Segment's batch format.
+type segmentMessage struct {
+	Type        string         `json:"type"`
+	MessageID   string         `json:"messageId"`
+	Event       string         `json:"event"`
+	AnonymousID string         `json:"anonymousId"`
+	Properties  map[string]any `json:"properties,omitempty"`
+	Timestamp   time.Time      `json:"timestamp"`
+}
+
+// NewSegmentSink returns a sink that posts events as track calls to the /v1/batch
+// endpoint of Segment's HTTP Tracking API, authenticated with the source's write key.
+// The endpoint defaults to https://api.segment.io; set it to use a compatible
+// service such as RudderStack or a regional Segment workspace.
+func NewSegmentSink(writeKey, endpoint string) (Sink, error) {
+	if writeKey == "" {
+		return nil, errors.New("the segment analytics sink needs a write key")
+	}
+	if endpoint == "" {
+		endpoint = defaultSegmentEndpoint
+	}
+	return &segmentSink{
+		writeKey: writeKey,
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (s *segmentSink) Name() string {
+	return "segment"
+}
+
+func (s *segmentSink) Write(ctx context.Context, events []Event) error {
+	batch := make([]segmentMessage, len(events))
+	for i, event := range events {
+		batch[i] = segmentMessage{
+			Type:        "track",
+			MessageID:   event.MessageID,
+			Event:       event.Name,
+			AnonymousID: event.AnonymousID,
+			Properties:  event.Properties,
+			Timestamp:   event.Timestamp,
+		}
+	}
+	body, err := json.Marshal(map[string]any{"batch": batch})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint+"/v1/batch", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(s.writeKey, "")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("segment batch of %d events failed with status %d", len(events), resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestSegmentSinkWrite tests that events are posted as track calls authenticated with the write key
+func TestSegmentSinkWrite(t *testing.T) {
+	var gotPath, gotKey string
+	var gotBody struct {
+		Batch []map[string]any `json:"batch"`
+	}
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotKey, _, _ = r.BasicAuth()
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink, err := NewSegmentSink("write-key", server.URL+"/")
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	events := []Event{{MessageID: "m1", Name: EventViewed, AnonymousID: "a1", Properties: map[string]any{"event_id": "e1"}, Timestamp: time.Now()}}
+	err = sink.Write(context.Background(), events)
+	if err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	if gotPath != "/v1/batch" || gotKey != "write-key" {
+		t.Errorf("Expected /v1/batch with the write key, got %s and %q", gotPath, gotKey)
+	}
+	if len(gotBody.Batch) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(gotBody.Batch))
+	}
+	message := gotBody.Batch[0]
+	if message["type"] != "track" || message["event"] != EventViewed || message["anonymousId"] != "a1" || message["messageId"] != "m1" {
+		t.Errorf("Unexpected message %v", message)
+	}
+
+	status = http.StatusBadRequest
+	if err := sink.Write(context.Background(), events); err == nil {
+		t.Error("Expected an error for a rejected batch")
+	}
+}
@@ -0,0 +1,97 @@
+// Package audit exports the ticket sales audit log to external sinks. A copy kept
+// outside the application's database lets auditors check the log's hash chain
+// against records the application can no longer change.
+package audit
+
+import (
+	"context"
+	"errors"
+	"event_booking_restapi_golang/models"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// exportBatch is the number of entries sent to a sink at once.
+const exportBatch = 500
+
+// Sink receives exported audit log entries.
+type Sink interface {
+	// Name identifies the sink, so that each sink keeps its own export progress.
+	Name() string
+	// Write stores the entries, which are consecutive and in log order. Entries may
+	// be written again after a failure, so sinks should tolerate duplicates.
+	Write(ctx context.Context, entries []models.AuditEntry) error
+}
+
+// SinkFromEnv selects the export sink from AUDIT_SINK ("syslog" or "s3").
+// Returns nil when no sink is configured, which disables the export.
+func SinkFromEnv() (Sink, error) {
+	switch sink := os.Getenv("AUDIT_SINK"); sink {
+	case "":
+		return nil, nil
+	case "syslog":
+		return NewSyslogSink(os.Getenv("AUDIT_SYSLOG_NETWORK"), os.Getenv("AUDIT_SYSLOG_ADDR"))
+	case "s3":
+		return NewS3Sink(S3Config{
+			Bucket:       os.Getenv("AUDIT_S3_BUCKET"),
+			Region:       os.Getenv("AUDIT_S3_REGION"),
+			Endpoint:     os.Getenv("AUDIT_S3_ENDPOINT"),
+			Prefix:       os.Getenv("AUDIT_S3_PREFIX"),
+			AccessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		})
+	default:
+		return nil, fmt.Errorf("unsupported AUDIT_SINK %q, use syslog or s3", sink)
+	}
+}
+
+// Export sends the entries appended since the last export to the sink, in batches,
+// and records the progress after each batch. Returns the number of entries sent.
+func Export(ctx context.Context, sink Sink) (int, error) {
+	if sink == nil {
+		return 0, errors.New("no audit sink configured")
+	}
+	after, err := models.GetAuditExportCursor(ctx, sink.Name())
+	if err != nil {
+		return 0, err
+	}
+
+	exported := 0
+	for {
+		entries, err := models.GetAuditEntries(ctx, after, exportBatch)
+		if err != nil {
+			return exported, err
+		}
+		if len(entries) == 0 {
+			return exported, nil
+		}
+		err = sink.Write(ctx, entries)
+		if err != nil {
+			return exported, err
+		}
+		after = entries[len(entries)-1].Seq
+		err = models.SetAuditExportCursor(ctx, sink.Name(), after)
+		if err != nil {
+			return exported, err
+		}
+		exported += len(entries)
+	}
+}
+
+// StartExport exports new audit log entries to the sink immediately and then on
+// every interval in a background goroutine. Failures are logged and retried on the
+// next run from the last recorded progress.
+func StartExport(sink Sink, interval time.Duration) {
+	go func() {
+		for {
+			_, err := Export(context.Background(), sink)
+			if err != nil {
+				slog.Error("Couldn't export the audit log", "sink", sink.Name(), "error", err)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
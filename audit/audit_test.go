@@ -0,0 +1,108 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/testutils"
+	"testing"
+	"time"
+)
+
+// fakeSink records the batches written to it and can be told to fail
+type fakeSink struct {
+	batches [][]models.AuditEntry
+	err     error
+}
+
+func (s *fakeSink) Name() string {
+	return "fake"
+}
+
+func (s *fakeSink) Write(ctx context.Context, entries []models.AuditEntry) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.batches = append(s.batches, entries)
+	return nil
+}
+
+// registerAttendees books a place for each email with the audit log enabled
+func registerAttendees(t *testing.T, event models.Event, emails ...string) {
+	models.AuditEnabled = true
+	t.Cleanup(func() { models.AuditEnabled = false })
+
+	for _, email := range emails {
+		_, err := event.Register(context.Background(), models.Registration{Name: "Attendee", Email: email})
+		if err != nil {
+			t.Fatalf("Failed to register: %v", err)
+		}
+	}
+}
+
+// TestExport tests that only entries added since the last export are sent
+func TestExport(t *testing.T) {
+	tdb := testutils.SetupTestDatabase(t)
+	defer tdb.Cleanup()
+	event := models.Event{ID: testutils.InsertTestEvent(t, tdb.DB, "Audited", "Audited event", "Hall", time.Now(), "user1")}
+	registerAttendees(t, event, "a@example.com", "b@example.com", "c@example.com")
+
+	sink := &fakeSink{}
+	exported, err := Export(context.Background(), sink)
+	if err != nil || exported != 3 {
+		t.Fatalf("Expected 3 entries exported, got %d, %v", exported, err)
+	}
+	exported, err = Export(context.Background(), sink)
+	if err != nil || exported != 0 {
+		t.Errorf("Expected nothing new to export, got %d, %v", exported, err)
+	}
+
+	registerAttendees(t, event, "d@example.com")
+	sink.err = errors.New("sink is down")
+	_, err = Export(context.Background(), sink)
+	if err == nil {
+		t.Fatal("Expected the sink error to be returned")
+	}
+
+	sink.err = nil
+	exported, err = Export(context.Background(), sink)
+	if err != nil || exported != 1 {
+		t.Fatalf("Expected the failed entry to be retried, got %d, %v", exported, err)
+	}
+	last := sink.batches[len(sink.batches)-1]
+	if last[0].Seq != 4 {
+		t.Errorf("Expected the retry to start at entry 4, got %d", last[0].Seq)
+	}
+}
+
+// TestSinkFromEnv tests sink selection from the environment
+func TestSinkFromEnv(t *testing.T) {
+	t.Setenv("AUDIT_SINK", "")
+	sink, err := SinkFromEnv()
+	if sink != nil || err != nil {
+		t.Errorf("Expected no sink, got %v, %v", sink, err)
+	}
+
+	t.Setenv("AUDIT_SINK", "s3")
+	t.Setenv("AUDIT_S3_BUCKET", "audit-bucket")
+	t.Setenv("AUDIT_S3_REGION", "eu-west-1")
+	t.Setenv("AUDIT_S3_PREFIX", "sales/")
+	t.Setenv("AWS_ACCESS_KEY_ID", "key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	sink, err = SinkFromEnv()
+	if err != nil || sink.Name() != "s3:audit-bucket/sales/" {
+		t.Errorf("Expected the S3 sink, got %v, %v", sink, err)
+	}
+
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	_, err = SinkFromEnv()
+	if err == nil {
+		t.Error("Expected an error for missing S3 credentials")
+	}
+
+	t.Setenv("AUDIT_SINK", "kafka")
+	_, err = SinkFromEnv()
+	if err == nil {
+		t.Error("Expected an error for an unsupported sink")
+	}
+}
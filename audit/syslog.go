@@ -0,0 +1,45 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/models"
+	"log/syslog"
+)
+
+// syslogTag is the program name audit messages are sent with.
+const syslogTag = "event-booking-audit"
+
+// syslogSink sends each audit entry as a JSON syslog message.
+type syslogSink struct {
+	writer *syslog.Writer
+}
+
+// NewSyslogSink connects to the syslog server at addr over network ("udp", "tcp"),
+// or to the local syslog daemon when both are empty. Entries are logged at the
+// info level of the local0 facility.
+func NewSyslogSink(network, addr string) (Sink, error) {
+	writer, err := syslog.Dial(network, addr, syslog.LOG_INFO|syslog.LOG_LOCAL0, syslogTag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{writer: writer}, nil
+}
+
+func (s *syslogSink) Name() string {
+	return "syslog"
+}
+
+func (s *syslogSink) Write(ctx context.Context, entries []models.AuditEntry) error {
+	for _, entry := range entries {
+		message, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		err = s.writer.Info(string(message))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
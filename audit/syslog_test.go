@@ -0,0 +1,44 @@
+package audit
+
+import (
+	"context"
+	"event_booking_restapi_golang/models"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSyslogSinkWrite tests that each entry is sent as a JSON syslog message
+func TestSyslogSinkWrite(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer conn.Close()
+
+	sink, err := NewSyslogSink("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	err = sink.Write(context.Background(), []models.AuditEntry{
+		{Seq: 1, Action: models.AuditRegistered, Hash: "h1"},
+		{Seq: 2, Action: models.AuditCancelled, PrevHash: "h1", Hash: "h2"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+
+	buf := make([]byte, 2048)
+	for _, want := range []string{`"Seq":1`, `"Seq":2`} {
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("Failed to receive syslog message: %v", err)
+		}
+		message := string(buf[:n])
+		if !strings.Contains(message, syslogTag) || !strings.Contains(message, want) {
+			t.Errorf("Expected a %s message containing %s, got %s", syslogTag, want, message)
+		}
+	}
+}
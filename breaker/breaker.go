@@ -0,0 +1,110 @@
+// Package breaker stops calling an external provider, such as the mail or payment
+// provider, that keeps failing. A Breaker opens after a run of consecutive failures
+// and refuses calls until a cooldown has passed; then it lets one call through to
+// try the provider again, and closes when that call succeeds. Callers that queue
+// their calls as jobs simply see a failure and retry later, so an unavailable
+// provider isn't hammered and the status page can tell it is down.
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// State is the state of a breaker's circuit.
+type State string
+
+const (
+	Closed   State = "closed"    // Calls go through
+	Open     State = "open"      // Calls are refused until the cooldown has passed
+	HalfOpen State = "half_open" // The cooldown has passed; the next call tries the provider again
+)
+
+// ErrOpen is returned instead of calling the provider while the circuit is open.
+var ErrOpen = errors.New("the provider failed repeatedly and isn't called until it has had time to recover")
+
+// Snapshot is the state of a breaker at a point in time.
+type Snapshot struct {
+	State     State     // State of the circuit
+	Failures  int       // Consecutive failed calls
+	LastError string    // Error of the latest failed call, empty when the latest call succeeded
+	OpenedAt  time.Time // When the circuit last opened, zero if it never did
+}
+
+// Breaker counts the consecutive failures of the calls to a provider. A nil
+// *Breaker is valid and lets every call through. It is safe for concurrent use.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	lastErr  string
+	openedAt time.Time
+	trying   bool // Whether the half-open call is in flight
+}
+
+// New returns a breaker that opens after threshold consecutive failures and lets a
+// call through again once cooldown has passed.
+func New(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{threshold: max(threshold, 1), cooldown: cooldown}
+}
+
+// state returns the state of the circuit at now. b.mu must be held.
+func (b *Breaker) state(now time.Time) State {
+	switch {
+	case b.failures < b.threshold:
+		return Closed
+	case now.Sub(b.openedAt) < b.cooldown || b.trying:
+		return Open
+	default:
+		return HalfOpen
+	}
+}
+
+// Call calls fn unless the circuit is open, and counts its outcome. Errors for which
+// counts returns false, such as a provider refusing a request it understood, show
+// the provider works and count as successes; a nil counts counts every error.
+// Returns ErrOpen without calling fn while the circuit is open, otherwise fn's error.
+func (b *Breaker) Call(fn func() error, counts func(error) bool) error {
+	if b == nil {
+		return fn()
+	}
+	b.mu.Lock()
+	state := b.state(time.Now())
+	if state == Open {
+		b.mu.Unlock()
+		return ErrOpen
+	}
+	if state == HalfOpen {
+		b.trying = true
+	}
+	b.mu.Unlock()
+
+	err := fn()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trying = false
+	if err == nil || (counts != nil && !counts(err)) {
+		b.failures, b.lastErr = 0, ""
+		return err
+	}
+	b.failures++
+	b.lastErr = err.Error()
+	if b.failures >= b.threshold {
+		b.openedAt = time.Now()
+	}
+	return err
+}
+
+// Snapshot returns the current state of the breaker.
+func (b *Breaker) Snapshot() Snapshot {
+	if b == nil {
+		return Snapshot{State: Closed}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return Snapshot{State: b.state(time.Now()), Failures: b.failures, LastError: b.lastErr, OpenedAt: b.openedAt}
+}
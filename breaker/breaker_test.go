@@ -0,0 +1,69 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var errUnavailable = errors.New("provider unavailable")
+
+// TestBreaker tests that the circuit opens after the threshold, refuses calls during
+// the cooldown and closes after a successful retry
+func TestBreaker(t *testing.T) {
+	b := New(3, 20*time.Millisecond)
+	calls := 0
+	fail := func() error { calls++; return errUnavailable }
+	succeed := func() error { calls++; return nil }
+
+	for i := 0; i < 3; i++ {
+		if err := b.Call(fail, nil); !errors.Is(err, errUnavailable) {
+			t.Fatalf("Expected the provider's error, got %v", err)
+		}
+	}
+	snapshot := b.Snapshot()
+	if snapshot.State != Open || snapshot.Failures != 3 || snapshot.LastError != errUnavailable.Error() || snapshot.OpenedAt.IsZero() {
+		t.Fatalf("Expected the circuit to open after 3 failures, got %+v", snapshot)
+	}
+	if err := b.Call(succeed, nil); !errors.Is(err, ErrOpen) || calls != 3 {
+		t.Errorf("Expected ErrOpen without calling the provider, got %v after %d calls", err, calls)
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	if state := b.Snapshot().State; state != HalfOpen {
+		t.Errorf("Expected the circuit to be half open after the cooldown, got %s", state)
+	}
+	if err := b.Call(fail, nil); !errors.Is(err, errUnavailable) {
+		t.Errorf("Expected the retry to reach the provider, got %v", err)
+	}
+	if state := b.Snapshot().State; state != Open {
+		t.Errorf("Expected a failed retry to open the circuit again, got %s", state)
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	if err := b.Call(succeed, nil); err != nil {
+		t.Errorf("Expected the retry to succeed, got %v", err)
+	}
+	if snapshot := b.Snapshot(); snapshot.State != Closed || snapshot.Failures != 0 || snapshot.LastError != "" {
+		t.Errorf("Expected a successful retry to close the circuit, got %+v", snapshot)
+	}
+}
+
+// TestBreakerUncounted tests that errors showing the provider works don't open the circuit
+func TestBreakerUncounted(t *testing.T) {
+	errRejected := errors.New("rejected")
+	b := New(1, time.Minute)
+	counts := func(err error) bool { return !errors.Is(err, errRejected) }
+
+	if err := b.Call(func() error { return errRejected }, counts); !errors.Is(err, errRejected) {
+		t.Fatalf("Expected the rejection, got %v", err)
+	}
+	if state := b.Snapshot().State; state != Closed {
+		t.Errorf("Expected rejections to keep the circuit closed, got %s", state)
+	}
+
+	var disabled *Breaker
+	if err := disabled.Call(func() error { return errUnavailable }, nil); !errors.Is(err, errUnavailable) || disabled.Snapshot().State != Closed {
+		t.Errorf("Expected a nil breaker to let calls through, got %v", err)
+	}
+}
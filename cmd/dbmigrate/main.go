@@ -0,0 +1,73 @@
+// Command dbmigrate copies the SQLite database to PostgreSQL while a deployment moves
+// off SQLite, and checks the copy. It reads DB_DSN and DB_MIGRATION_DSN like the server.
+//
+// Usage:
+//
+//	dbmigrate backfill   copy the rows missing or different in PostgreSQL, then verify
+//	dbmigrate verify     compare the row counts and checksums of every table
+//
+// It exits with status 1 when a table differs after the command.
+package main
+
+import (
+	"context"
+	"errors"
+	"event_booking_restapi_golang/config"
+	"event_booking_restapi_golang/db"
+	"event_booking_restapi_golang/logging"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+)
+
+func main() {
+	if len(os.Args) != 2 || (os.Args[1] != "backfill" && os.Args[1] != "verify") {
+		fmt.Fprintln(os.Stderr, "usage: dbmigrate backfill|verify")
+		os.Exit(2)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		logging.Fatal("Couldn't load configuration", err)
+	}
+	if cfg.DBDriver != db.SQLite || cfg.DBMigrationDSN == "" {
+		logging.Fatal("Nothing to migrate", errors.New("set DB_DRIVER=sqlite3 and DB_MIGRATION_DSN to the PostgreSQL database"))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	source, target, err := db.OpenMigration(cfg.DBDSN, cfg.DBMigrationDSN)
+	if err != nil {
+		logging.Fatal("Couldn't open the databases", err)
+	}
+	defer source.Close()
+	defer target.Close()
+
+	var reports []db.TableReport
+	if os.Args[1] == "backfill" {
+		reports, err = db.Backfill(ctx, source, target)
+	} else {
+		reports, err = db.Verify(ctx, source, target)
+	}
+	if err != nil {
+		logging.Fatal("Couldn't "+os.Args[1]+" the PostgreSQL database", err)
+	}
+
+	matching := true
+	out := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(out, "TABLE\tSQLITE ROWS\tPOSTGRES ROWS\tCOPIED\tDELETED\tSTATUS")
+	for _, r := range reports {
+		status := "ok"
+		if !r.Matches() {
+			status = "differs"
+			matching = false
+		}
+		fmt.Fprintf(out, "%s\t%d\t%d\t%d\t%d\t%s\n", r.Table, r.SourceRows, r.TargetRows, r.Copied, r.Deleted, status)
+	}
+	out.Flush()
+	if !matching {
+		// Deferred closes don't run on exit, the process ending closes the connections
+		os.Exit(1)
+	}
+}
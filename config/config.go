@@ -0,0 +1,302 @@
+// Package config loads the application settings from environment variables.
+// Variables can also be kept in a .env file in the working directory; values
+// already set in the environment take precedence over the file.
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Config holds the settings the server is started with.
+type Config struct {
+	Port              string        // TCP port the HTTP server listens on (PORT, default 8080)
+	DBDriver          string        // Database driver, sqlite3 or postgres (DB_DRIVER, default sqlite3)
+	DBDSN             string        // Database connection string (DB_DSN, defaults to the db.sql SQLite file)
+	DBMigrate         bool          // Migrate databases set up by older releases at startup, instead of refusing them (DB_MIGRATE auto or off, default auto)
+	SchemaMismatch    string        // What to do when the database schema is newer than this release's, refuse to start or serve read-only (DB_SCHEMA_MISMATCH, default refuse)
+	DBMigration       string        // Stage of moving the SQLite database to PostgreSQL, off, dual-write or cutover (DB_MIGRATION, default off)
+	DBMigrationDSN    string        // Connection string of the PostgreSQL database being moved to (DB_MIGRATION_DSN)
+	DBRequestTx       bool          // Handle each mutating request within one database transaction (DB_REQUEST_TRANSACTIONS=true)
+	JWTSecret         string        // Key for signing access tokens (JWT_SECRET)
+	TicketSecret      string        // Key for signing ticket codes (TICKET_SECRET)
+	LogLevel          slog.Level    // Minimum level of log output (LOG_LEVEL, default info)
+	GinMode           string        // Gin mode, debug, release or test (GIN_MODE, default debug)
+	AttachmentsDir    string        // Directory event attachments are stored in (ATTACHMENTS_DIR, default attachments)
+	AuditLog          bool          // Record ticket sales in the hash-chained audit log (AUDIT_LOG=true)
+	ReadOnly          bool          // Serve only the public read endpoints, as a public mirror (READ_ONLY=true)
+	IDVersion         string        // UUID version of new entity IDs, v7 or v4 (ID_VERSION, default v7)
+	ShutdownTimeout   time.Duration // Time to let in-flight requests finish on shutdown (SHUTDOWN_TIMEOUT, default 15s)
+	SummaryRefresh    time.Duration // Time between refreshes of the analytics summary tables (SUMMARY_REFRESH_INTERVAL, default 5m)
+	WriteFlush        time.Duration // Time between flushes of buffered writes such as view counts (WRITE_FLUSH_INTERVAL, default 10s)
+	StatusInterval    time.Duration // Time between checks of the components shown on the status page (STATUS_PROBE_INTERVAL, default 1m)
+	FreeEventLimit    int           // Active events a free user may have, 0 for no limit (FREE_EVENT_LIMIT, default 0)
+	JobWorkers        int           // Workers running background jobs such as emails (JOB_WORKERS, default 4)
+	RefundAutoApprove time.Duration // Refund requests made at least this long before the event are approved at once, 0 to never (REFUND_AUTO_APPROVE_BEFORE, default 0)
+	RescheduleCutoff  time.Duration // Bookings can be moved to another occurrence of a series until this long before either starts (RESCHEDULE_CUTOFF, default 24h)
+	CompressMinSize   int           // Smallest response body gzip-compressed for clients accepting it, in bytes, -1 to never compress (COMPRESS_MIN_SIZE, default 1024, off for -1)
+	PaidUserIDs       string        // Comma-separated IDs of the paying users, who have no event limit (PAID_USER_IDS)
+	SyncKeys          string        // Comma-separated source:key pairs of the partner systems syncing by external ID (EXTERNAL_SYNC_KEYS)
+	PublicIDSecret    string        // Key for obfuscating entity IDs in the API, empty to show UUIDs (PUBLIC_ID_SECRET)
+	PublicIDChars     string        // Alphabet of obfuscated IDs, empty for letters and digits (PUBLIC_ID_ALPHABET)
+	ContentReuse      string        // Which public events can be exported to other sites, all, licensed or none (CONTENT_REUSE, default all)
+	GRPCPort          string        // TCP port the gRPC EventService listens on, empty to not serve gRPC (GRPC_PORT)
+	GRPCToken         string        // Bearer token gRPC callers must send, empty to accept any caller (GRPC_TOKEN)
+}
+
+// defaultSQLiteDSN opens "db.sql" so that transactions take the write lock when
+// they begin, keeping read-then-write sequences such as capacity checks from interleaving.
+const defaultSQLiteDSN = "db.sql?_txlock=immediate&_busy_timeout=5000"
+
+// Load reads the .env file, if there is one, into the environment and builds the
+// configuration from the environment variables, filling in defaults.
+// Returns an error for unreadable .env files and invalid settings.
+func Load() (Config, error) {
+	err := LoadDotEnv(".env")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Config{}, err
+	}
+
+	cfg := Config{
+		Port:           getEnv("PORT", "8080"),
+		DBDriver:       getEnv("DB_DRIVER", "sqlite3"),
+		DBDSN:          os.Getenv("DB_DSN"),
+		SchemaMismatch: getEnv("DB_SCHEMA_MISMATCH", "refuse"),
+		DBMigration:    getEnv("DB_MIGRATION", "off"),
+		DBMigrationDSN: os.Getenv("DB_MIGRATION_DSN"),
+		DBRequestTx:    os.Getenv("DB_REQUEST_TRANSACTIONS") == "true",
+		JWTSecret:      os.Getenv("JWT_SECRET"),
+		TicketSecret:   os.Getenv("TICKET_SECRET"),
+		GinMode:        getEnv("GIN_MODE", gin.DebugMode),
+		AttachmentsDir: getEnv("ATTACHMENTS_DIR", "attachments"),
+		AuditLog:       os.Getenv("AUDIT_LOG") == "true",
+		ReadOnly:       os.Getenv("READ_ONLY") == "true",
+		IDVersion:      getEnv("ID_VERSION", "v7"),
+		PaidUserIDs:    os.Getenv("PAID_USER_IDS"),
+		SyncKeys:       os.Getenv("EXTERNAL_SYNC_KEYS"),
+		PublicIDSecret: os.Getenv("PUBLIC_ID_SECRET"),
+		PublicIDChars:  os.Getenv("PUBLIC_ID_ALPHABET"),
+		ContentReuse:   getEnv("CONTENT_REUSE", "all"),
+		GRPCPort:       os.Getenv("GRPC_PORT"),
+		GRPCToken:      os.Getenv("GRPC_TOKEN"),
+	}
+
+	switch cfg.DBDriver {
+	case "sqlite3":
+		if cfg.DBDSN == "" {
+			cfg.DBDSN = defaultSQLiteDSN
+		}
+	case "postgres":
+		if cfg.DBDSN == "" {
+			return Config{}, errors.New("DB_DSN is required for the postgres driver")
+		}
+	default:
+		return Config{}, fmt.Errorf("unsupported DB_DRIVER %q, use sqlite3 or postgres", cfg.DBDriver)
+	}
+
+	switch getEnv("DB_MIGRATE", "auto") {
+	case "auto":
+		cfg.DBMigrate = true
+	case "off":
+	default:
+		return Config{}, fmt.Errorf("unsupported DB_MIGRATE %q, use auto or off", os.Getenv("DB_MIGRATE"))
+	}
+
+	switch cfg.SchemaMismatch {
+	case "refuse", "read-only":
+	default:
+		return Config{}, fmt.Errorf("unsupported DB_SCHEMA_MISMATCH %q, use refuse or read-only", cfg.SchemaMismatch)
+	}
+
+	switch cfg.DBMigration {
+	case "off":
+	case "dual-write", "cutover":
+		if cfg.DBDriver != "sqlite3" {
+			return Config{}, fmt.Errorf("DB_MIGRATION %s moves a sqlite3 database, not %s", cfg.DBMigration, cfg.DBDriver)
+		}
+		if cfg.DBMigrationDSN == "" {
+			return Config{}, fmt.Errorf("DB_MIGRATION_DSN is required for DB_MIGRATION %s", cfg.DBMigration)
+		}
+	default:
+		return Config{}, fmt.Errorf("unsupported DB_MIGRATION %q, use off, dual-write or cutover", cfg.DBMigration)
+	}
+
+	switch cfg.GinMode {
+	case gin.DebugMode, gin.ReleaseMode, gin.TestMode:
+	default:
+		return Config{}, fmt.Errorf("unsupported GIN_MODE %q, use debug, release or test", cfg.GinMode)
+	}
+
+	switch cfg.IDVersion {
+	case "v7", "v4":
+	default:
+		return Config{}, fmt.Errorf("unsupported ID_VERSION %q, use v7 or v4", cfg.IDVersion)
+	}
+
+	switch cfg.ContentReuse {
+	case "all", "licensed", "none":
+	default:
+		return Config{}, fmt.Errorf("unsupported CONTENT_REUSE %q, use all, licensed or none", cfg.ContentReuse)
+	}
+
+	if cfg.GRPCPort != "" {
+		port, err := strconv.Atoi(cfg.GRPCPort)
+		if err != nil || port <= 0 || port > 65535 || cfg.GRPCPort == cfg.Port {
+			return Config{}, fmt.Errorf("unsupported GRPC_PORT %q, use a port other than PORT", cfg.GRPCPort)
+		}
+	}
+
+	for _, pair := range strings.Split(cfg.SyncKeys, ",") {
+		source, key, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if strings.TrimSpace(pair) != "" && (!ok || strings.TrimSpace(source) == "" || strings.TrimSpace(key) == "") {
+			return Config{}, fmt.Errorf("unsupported EXTERNAL_SYNC_KEYS entry %q, use source:key pairs separated by commas", pair)
+		}
+	}
+
+	if cfg.PublicIDChars != "" && cfg.PublicIDSecret == "" {
+		return Config{}, errors.New("PUBLIC_ID_ALPHABET needs PUBLIC_ID_SECRET to be set")
+	}
+
+	cfg.ShutdownTimeout, err = time.ParseDuration(getEnv("SHUTDOWN_TIMEOUT", "15s"))
+	if err != nil || cfg.ShutdownTimeout <= 0 {
+		return Config{}, fmt.Errorf("unsupported SHUTDOWN_TIMEOUT %q, use a positive duration such as 30s", os.Getenv("SHUTDOWN_TIMEOUT"))
+	}
+
+	cfg.SummaryRefresh, err = time.ParseDuration(getEnv("SUMMARY_REFRESH_INTERVAL", "5m"))
+	if err != nil || cfg.SummaryRefresh <= 0 {
+		return Config{}, fmt.Errorf("unsupported SUMMARY_REFRESH_INTERVAL %q, use a positive duration such as 10m", os.Getenv("SUMMARY_REFRESH_INTERVAL"))
+	}
+
+	cfg.WriteFlush, err = time.ParseDuration(getEnv("WRITE_FLUSH_INTERVAL", "10s"))
+	if err != nil || cfg.WriteFlush <= 0 {
+		return Config{}, fmt.Errorf("unsupported WRITE_FLUSH_INTERVAL %q, use a positive duration such as 30s", os.Getenv("WRITE_FLUSH_INTERVAL"))
+	}
+
+	cfg.StatusInterval, err = time.ParseDuration(getEnv("STATUS_PROBE_INTERVAL", "1m"))
+	if err != nil || cfg.StatusInterval <= 0 {
+		return Config{}, fmt.Errorf("unsupported STATUS_PROBE_INTERVAL %q, use a positive duration such as 30s", os.Getenv("STATUS_PROBE_INTERVAL"))
+	}
+
+	cfg.FreeEventLimit, err = strconv.Atoi(getEnv("FREE_EVENT_LIMIT", "0"))
+	if err != nil || cfg.FreeEventLimit < 0 {
+		return Config{}, fmt.Errorf("unsupported FREE_EVENT_LIMIT %q, use a number of events or 0 for no limit", os.Getenv("FREE_EVENT_LIMIT"))
+	}
+
+	cfg.JobWorkers, err = strconv.Atoi(getEnv("JOB_WORKERS", "4"))
+	if err != nil || cfg.JobWorkers <= 0 {
+		return Config{}, fmt.Errorf("unsupported JOB_WORKERS %q, use a positive number of workers", os.Getenv("JOB_WORKERS"))
+	}
+
+	cfg.RefundAutoApprove, err = time.ParseDuration(getEnv("REFUND_AUTO_APPROVE_BEFORE", "0s"))
+	if err != nil || cfg.RefundAutoApprove < 0 {
+		return Config{}, fmt.Errorf("unsupported REFUND_AUTO_APPROVE_BEFORE %q, use a duration such as 168h or 0 to never approve refunds automatically", os.Getenv("REFUND_AUTO_APPROVE_BEFORE"))
+	}
+
+	cfg.RescheduleCutoff, err = time.ParseDuration(getEnv("RESCHEDULE_CUTOFF", "24h"))
+	if err != nil || cfg.RescheduleCutoff < 0 {
+		return Config{}, fmt.Errorf("unsupported RESCHEDULE_CUTOFF %q, use a duration such as 24h or 0 to allow rescheduling until the event starts", os.Getenv("RESCHEDULE_CUTOFF"))
+	}
+
+	cfg.CompressMinSize = -1
+	if raw := getEnv("COMPRESS_MIN_SIZE", "1024"); raw != "off" {
+		cfg.CompressMinSize, err = strconv.Atoi(raw)
+		if err != nil || cfg.CompressMinSize < 0 {
+			return Config{}, fmt.Errorf("unsupported COMPRESS_MIN_SIZE %q, use a number of bytes or off to never compress responses", raw)
+		}
+	}
+
+	err = cfg.LogLevel.UnmarshalText([]byte(getEnv("LOG_LEVEL", "info")))
+	if err != nil {
+		return Config{}, fmt.Errorf("unsupported LOG_LEVEL: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// Addr returns the listen address for the configured port.
+func (c Config) Addr() string {
+	return ":" + c.Port
+}
+
+// GRPCAddr returns the listen address for the configured gRPC port.
+func (c Config) GRPCAddr() string {
+	return ":" + c.GRPCPort
+}
+
+// PaidUsers returns the set of the IDs in PaidUserIDs.
+func (c Config) PaidUsers() map[string]bool {
+	users := map[string]bool{}
+	for _, id := range strings.Split(c.PaidUserIDs, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			users[id] = true
+		}
+	}
+	return users
+}
+
+// SourceKeys returns the key of each source in SyncKeys.
+func (c Config) SourceKeys() map[string]string {
+	keys := map[string]string{}
+	for _, pair := range strings.Split(c.SyncKeys, ",") {
+		if source, key, ok := strings.Cut(pair, ":"); ok {
+			keys[strings.TrimSpace(source)] = strings.TrimSpace(key)
+		}
+	}
+	return keys
+}
+
+// getEnv returns the value of the environment variable, or fallback when it is unset or empty.
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// LoadDotEnv sets environment variables from KEY=VALUE lines in the file at path.
+// Blank lines and lines starting with # are skipped, an optional "export " prefix
+// is ignored and values may be wrapped in single or double quotes. Variables that
+// are already set in the environment are left untouched.
+func LoadDotEnv(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return fmt.Errorf("%s:%d: expected KEY=VALUE", path, lineNumber)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+
+		if _, set := os.LookupEnv(key); set {
+			continue
+		}
+		err = os.Setenv(key, value)
+		if err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,228 @@
+// Package config contains unit tests for loading the application settings.
+package config
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// configKeys lists every variable Load reads, so tests start from a clean environment
+var configKeys = []string{"PORT", "DB_DRIVER", "DB_DSN", "JWT_SECRET", "LOG_LEVEL", "GIN_MODE", "ATTACHMENTS_DIR", "AUDIT_LOG", "READ_ONLY", "SHUTDOWN_TIMEOUT",
+	"SUMMARY_REFRESH_INTERVAL", "ID_VERSION", "TICKET_SECRET", "WRITE_FLUSH_INTERVAL", "FREE_EVENT_LIMIT", "PAID_USER_IDS", "EXTERNAL_SYNC_KEYS",
+	"PUBLIC_ID_SECRET", "PUBLIC_ID_ALPHABET", "JOB_WORKERS", "REFUND_AUTO_APPROVE_BEFORE", "RESCHEDULE_CUTOFF", "COMPRESS_MIN_SIZE", "STATUS_PROBE_INTERVAL", "CONTENT_REUSE",
+	"DB_MIGRATE", "DB_SCHEMA_MISMATCH", "GRPC_PORT", "GRPC_TOKEN",
+	"DB_MIGRATION", "DB_MIGRATION_DSN", "DB_REQUEST_TRANSACTIONS"}
+
+// clearConfigEnv unsets the configuration variables for the duration of a test
+// and runs it in an empty directory so that no .env file is picked up
+func clearConfigEnv(t *testing.T) {
+	for _, key := range configKeys {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+	t.Chdir(t.TempDir())
+}
+
+// TestLoadDefaults tests the configuration used when nothing is set
+func TestLoadDefaults(t *testing.T) {
+	clearConfigEnv(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Addr() != ":8080" {
+		t.Errorf("Expected :8080, got %s", cfg.Addr())
+	}
+	if cfg.DBDriver != "sqlite3" || cfg.DBDSN != defaultSQLiteDSN {
+		t.Errorf("Expected the db.sql SQLite database, got %s %s", cfg.DBDriver, cfg.DBDSN)
+	}
+	if cfg.LogLevel != slog.LevelInfo || cfg.GinMode != "debug" || cfg.AttachmentsDir != "attachments" || cfg.AuditLog || cfg.ReadOnly ||
+		cfg.IDVersion != "v7" || cfg.ShutdownTimeout != 15*time.Second || cfg.SummaryRefresh != 5*time.Minute || cfg.WriteFlush != 10*time.Second || cfg.StatusInterval != time.Minute ||
+		cfg.FreeEventLimit != 0 || cfg.JobWorkers != 4 || cfg.RefundAutoApprove != 0 || cfg.RescheduleCutoff != 24*time.Hour || cfg.CompressMinSize != 1024 || len(cfg.PaidUsers()) != 0 || len(cfg.SourceKeys()) != 0 || cfg.PublicIDSecret != "" ||
+		cfg.ContentReuse != "all" || !cfg.DBMigrate || cfg.SchemaMismatch != "refuse" || cfg.GRPCPort != "" || cfg.GRPCToken != "" ||
+		cfg.DBMigration != "off" || cfg.DBMigrationDSN != "" || cfg.DBRequestTx {
+		t.Errorf("Unexpected defaults %+v", cfg)
+	}
+}
+
+// TestLoadFromEnv tests that environment variables override the defaults
+func TestLoadFromEnv(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("PORT", "9090")
+	t.Setenv("DB_DRIVER", "postgres")
+	t.Setenv("DB_DSN", "postgres://localhost/events")
+	t.Setenv("JWT_SECRET", "s3cret")
+	t.Setenv("TICKET_SECRET", "t1cket")
+	t.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("GIN_MODE", "release")
+	t.Setenv("AUDIT_LOG", "true")
+	t.Setenv("READ_ONLY", "true")
+	t.Setenv("SHUTDOWN_TIMEOUT", "1m")
+	t.Setenv("SUMMARY_REFRESH_INTERVAL", "30s")
+	t.Setenv("WRITE_FLUSH_INTERVAL", "2s")
+	t.Setenv("STATUS_PROBE_INTERVAL", "30s")
+	t.Setenv("ID_VERSION", "v4")
+	t.Setenv("FREE_EVENT_LIMIT", "3")
+	t.Setenv("JOB_WORKERS", "8")
+	t.Setenv("REFUND_AUTO_APPROVE_BEFORE", "168h")
+	t.Setenv("RESCHEDULE_CUTOFF", "2h")
+	t.Setenv("COMPRESS_MIN_SIZE", "512")
+	t.Setenv("PAID_USER_IDS", "user-1, user-2,")
+	t.Setenv("EXTERNAL_SYNC_KEYS", "tickets:k1, crm:k2")
+	t.Setenv("PUBLIC_ID_SECRET", "ids")
+	t.Setenv("PUBLIC_ID_ALPHABET", "0123456789abcdef")
+	t.Setenv("CONTENT_REUSE", "licensed")
+	t.Setenv("DB_MIGRATE", "off")
+	t.Setenv("DB_SCHEMA_MISMATCH", "read-only")
+	t.Setenv("GRPC_PORT", "9091")
+	t.Setenv("GRPC_TOKEN", "internal")
+	t.Setenv("DB_REQUEST_TRANSACTIONS", "true")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	expected := Config{
+		Port:              "9090",
+		DBDriver:          "postgres",
+		DBDSN:             "postgres://localhost/events",
+		SchemaMismatch:    "read-only",
+		DBMigration:       "off",
+		DBRequestTx:       true,
+		JWTSecret:         "s3cret",
+		TicketSecret:      "t1cket",
+		LogLevel:          slog.LevelDebug,
+		GinMode:           "release",
+		AttachmentsDir:    "attachments",
+		AuditLog:          true,
+		ReadOnly:          true,
+		IDVersion:         "v4",
+		ShutdownTimeout:   time.Minute,
+		SummaryRefresh:    30 * time.Second,
+		WriteFlush:        2 * time.Second,
+		StatusInterval:    30 * time.Second,
+		FreeEventLimit:    3,
+		JobWorkers:        8,
+		RefundAutoApprove: 7 * 24 * time.Hour,
+		RescheduleCutoff:  2 * time.Hour,
+		CompressMinSize:   512,
+		PaidUserIDs:       "user-1, user-2,",
+		SyncKeys:          "tickets:k1, crm:k2",
+		PublicIDSecret:    "ids",
+		PublicIDChars:     "0123456789abcdef",
+		ContentReuse:      "licensed",
+		GRPCPort:          "9091",
+		GRPCToken:         "internal",
+	}
+	if cfg != expected {
+		t.Errorf("Expected %+v, got %+v", expected, cfg)
+	}
+	if cfg.GRPCAddr() != ":9091" {
+		t.Errorf("Expected :9091, got %s", cfg.GRPCAddr())
+	}
+	if paid := cfg.PaidUsers(); len(paid) != 2 || !paid["user-1"] || !paid["user-2"] {
+		t.Errorf("Expected user-1 and user-2 to be paying users, got %v", paid)
+	}
+	if keys := cfg.SourceKeys(); len(keys) != 2 || keys["tickets"] != "k1" || keys["crm"] != "k2" {
+		t.Errorf("Expected the keys of tickets and crm, got %v", keys)
+	}
+}
+
+// TestLoadMigration tests the settings of a move from SQLite to PostgreSQL
+func TestLoadMigration(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("DB_MIGRATION", "dual-write")
+	t.Setenv("DB_MIGRATION_DSN", "postgres://localhost/events")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.DBMigration != "dual-write" || cfg.DBMigrationDSN != "postgres://localhost/events" || cfg.DBDriver != "sqlite3" {
+		t.Errorf("Expected dual-writes from SQLite to postgres://localhost/events, got %+v", cfg)
+	}
+}
+
+// TestLoadInvalid tests that unsupported settings are rejected
+func TestLoadInvalid(t *testing.T) {
+	tests := map[string]map[string]string{
+		"unknown driver":     {"DB_DRIVER": "mysql"},
+		"postgres no dsn":    {"DB_DRIVER": "postgres"},
+		"unknown gin mode":   {"GIN_MODE": "production"},
+		"unknown log level":  {"LOG_LEVEL": "chatty"},
+		"invalid timeout":    {"SHUTDOWN_TIMEOUT": "soon"},
+		"negative timeout":   {"SHUTDOWN_TIMEOUT": "-5s"},
+		"zero refresh":       {"SUMMARY_REFRESH_INTERVAL": "0s"},
+		"unknown id version": {"ID_VERSION": "v1"},
+		"invalid flush":      {"WRITE_FLUSH_INTERVAL": "often"},
+		"zero probe":         {"STATUS_PROBE_INTERVAL": "0s"},
+		"invalid limit":      {"FREE_EVENT_LIMIT": "few"},
+		"negative limit":     {"FREE_EVENT_LIMIT": "-1"},
+		"alphabet no secret": {"PUBLIC_ID_ALPHABET": "0123456789abcdef"},
+		"no job workers":     {"JOB_WORKERS": "0"},
+		"negative refund":    {"REFUND_AUTO_APPROVE_BEFORE": "-1h"},
+		"unknown reuse":      {"CONTENT_REUSE": "some"},
+		"sync key no source": {"EXTERNAL_SYNC_KEYS": "tickets:k1,k2"},
+		"invalid compress":   {"COMPRESS_MIN_SIZE": "big"},
+		"unknown migrate":    {"DB_MIGRATE": "manual"},
+		"unknown mismatch":   {"DB_SCHEMA_MISMATCH": "ignore"},
+		"invalid grpc port":  {"GRPC_PORT": "grpc"},
+		"grpc on http port":  {"GRPC_PORT": "8080"},
+		"unknown migration":  {"DB_MIGRATION": "copy", "DB_MIGRATION_DSN": "postgres://localhost/events"},
+		"migration no dsn":   {"DB_MIGRATION": "dual-write"},
+		"migration postgres": {"DB_MIGRATION": "cutover", "DB_MIGRATION_DSN": "postgres://localhost/events", "DB_DRIVER": "postgres", "DB_DSN": "postgres://localhost/old"},
+	}
+
+	for name, env := range tests {
+		t.Run(name, func(t *testing.T) {
+			clearConfigEnv(t)
+			for key, value := range env {
+				t.Setenv(key, value)
+			}
+			_, err := Load()
+			if err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+// TestLoadDotEnv tests reading variables from a .env file
+func TestLoadDotEnv(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("PORT", "7000")
+	content := `# local settings
+PORT=9999
+export JWT_SECRET="quoted secret"
+GIN_MODE='test'
+
+LOG_LEVEL = warn
+`
+	err := os.WriteFile(filepath.Join(".", ".env"), []byte(content), 0o600)
+	if err != nil {
+		t.Fatalf("Failed to write .env: %v", err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Port != "7000" {
+		t.Errorf("Expected the environment to win over .env, got port %s", cfg.Port)
+	}
+	if cfg.JWTSecret != "quoted secret" || cfg.GinMode != "test" || cfg.LogLevel != slog.LevelWarn {
+		t.Errorf("Expected values from .env, got %+v", cfg)
+	}
+	for _, key := range []string{"JWT_SECRET", "GIN_MODE", "LOG_LEVEL"} {
+		os.Unsetenv(key)
+	}
+
+	os.WriteFile(".env", []byte("NOT A VARIABLE\n"), 0o600)
+	if err = LoadDotEnv(".env"); err == nil {
+		t.Error("Expected an error for a malformed line")
+	}
+}
@@ -0,0 +1,416 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// appendOnlyTables lists the tables set up with appendOnly. The backfill adds their
+// missing rows but can't change or remove the others.
+var appendOnlyTables = map[string]bool{"audit_log": true}
+
+// TableReport compares a table of the SQLite database with its copy in the PostgreSQL
+// database. Checksums cover every column of every row, independently of row order.
+type TableReport struct {
+	Table          string
+	SourceRows     int    // Rows in the SQLite database
+	TargetRows     int    // Rows in the PostgreSQL database
+	SourceChecksum string // SHA-256 of the SQLite rows
+	TargetChecksum string // SHA-256 of the PostgreSQL rows
+	Copied         int    // Rows Backfill inserted into or overwrote in the PostgreSQL database
+	Deleted        int    // Rows Backfill removed from the PostgreSQL database as they are gone from SQLite
+}
+
+// Matches reports whether the PostgreSQL table holds exactly the SQLite rows.
+func (r TableReport) Matches() bool {
+	return r.SourceRows == r.TargetRows && r.SourceChecksum == r.TargetChecksum
+}
+
+// OpenMigration opens the SQLite database being moved off and the PostgreSQL database
+// it moves to, for Backfill and Verify.
+func OpenMigration(sqliteDSN, postgresDSN string) (*sql.DB, *sql.DB, error) {
+	source, err := sql.Open(SQLite, sqliteDSN)
+	if err != nil {
+		return nil, nil, err
+	}
+	target, err := sql.Open(Postgres, postgresDSN)
+	if err != nil {
+		source.Close()
+		return nil, nil, err
+	}
+	return source, target, nil
+}
+
+// Backfill copies the rows of every table from the SQLite database source to the
+// PostgreSQL database target: rows missing from target or different there are
+// written, and rows gone from source are removed, so it can be run again while writes
+// are dual-written to bring target back in line. Tables are cleaned up child first and
+// then copied parent first, each in a transaction of its own. Each table is read whole
+// into memory. Rows of append-only tables that differ can't be fixed and are left for
+// the report to show.
+// Both databases must be at SchemaVersion; start the server in dual-write mode once
+// to set up the PostgreSQL schema.
+// Returns the report of each table, checked again after the copy.
+func Backfill(ctx context.Context, source, target *sql.DB) ([]TableReport, error) {
+	err := checkMigrationSchemas(ctx, source, target)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stale rows go first, child tables first, so they can't clash with the copied
+	// rows on unique columns and no copied row references them
+	reports := make([]TableReport, len(schemaTables))
+	diffs := make([]tableDiff, len(schemaTables))
+	for i, table := range schemaTables {
+		diffs[i], err = diffTable(ctx, source, target, table)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't compare %s: %w", table, err)
+		}
+		diffs[i].changed = nil
+	}
+	for i := len(schemaTables) - 1; i >= 0; i-- {
+		reports[i].Deleted, err = deleteRows(ctx, target, schemaTables[i], diffs[i].keys, diffs[i].stale)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't clean up %s: %w", schemaTables[i], err)
+		}
+	}
+	for i, table := range schemaTables {
+		diff, err := diffTable(ctx, source, target, table)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't compare %s: %w", table, err)
+		}
+		reports[i].Copied, err = copyRows(ctx, target, table, diff)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't copy %s: %w", table, err)
+		}
+	}
+
+	verified, err := Verify(ctx, source, target)
+	if err != nil {
+		return nil, err
+	}
+	for i := range verified {
+		verified[i].Copied, verified[i].Deleted = reports[i].Copied, reports[i].Deleted
+	}
+	return verified, nil
+}
+
+// Verify compares every table of the SQLite database source with the PostgreSQL
+// database target by row count and checksum, without changing either.
+func Verify(ctx context.Context, source, target *sql.DB) ([]TableReport, error) {
+	err := checkMigrationSchemas(ctx, source, target)
+	if err != nil {
+		return nil, err
+	}
+	reports := make([]TableReport, len(schemaTables))
+	for i, table := range schemaTables {
+		columns, keys, err := tableColumns(ctx, source, table)
+		if err != nil {
+			return nil, err
+		}
+		sourceRows, err := readTable(ctx, source, table, columns, keys)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read %s from SQLite: %w", table, err)
+		}
+		targetRows, err := readTable(ctx, target, table, columns, keys)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read %s from PostgreSQL: %w", table, err)
+		}
+		reports[i] = TableReport{
+			Table:          table,
+			SourceRows:     len(sourceRows),
+			TargetRows:     len(targetRows),
+			SourceChecksum: checksum(sourceRows),
+			TargetChecksum: checksum(targetRows),
+		}
+	}
+	return reports, nil
+}
+
+// CheckCutover verifies that the PostgreSQL database holds exactly the rows of the
+// SQLite database, so the server can switch to it. Returns an error naming the tables
+// that differ otherwise.
+func CheckCutover(ctx context.Context, sqliteDSN, postgresDSN string) error {
+	source, target, err := OpenMigration(sqliteDSN, postgresDSN)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	defer target.Close()
+
+	reports, err := Verify(ctx, source, target)
+	if err != nil {
+		return err
+	}
+	var differing []string
+	for _, r := range reports {
+		if !r.Matches() {
+			differing = append(differing, r.Table)
+		}
+	}
+	if len(differing) > 0 {
+		return fmt.Errorf("the PostgreSQL database doesn't match the SQLite database in %s: keep dual-writing and run the backfill again",
+			strings.Join(differing, ", "))
+	}
+	return nil
+}
+
+// checkMigrationSchemas checks that both databases are at SchemaVersion, so their
+// tables have the same columns.
+func checkMigrationSchemas(ctx context.Context, source, target *sql.DB) error {
+	for _, database := range []struct {
+		name string
+		conn *sql.DB
+	}{{"SQLite", source}, {"PostgreSQL", target}} {
+		var version sql.NullInt64
+		err := database.conn.QueryRowContext(ctx, "SELECT MAX(version) FROM schema_version").Scan(&version)
+		if err != nil {
+			return fmt.Errorf("couldn't read the schema version of the %s database: %w", database.name, err)
+		}
+		if version.Int64 != SchemaVersion {
+			return fmt.Errorf("the %s database is at schema version %d instead of %d: start this release in dual-write mode to migrate it",
+				database.name, version.Int64, SchemaVersion)
+		}
+	}
+	return nil
+}
+
+// tableColumns returns the columns of the SQLite table and its primary key columns.
+func tableColumns(ctx context.Context, source *sql.DB, table string) ([]string, []string, error) {
+	rows, err := source.QueryContext(ctx, "SELECT name, pk FROM pragma_table_info(?) ORDER BY cid", table)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	keyPositions := map[int]string{}
+	for rows.Next() {
+		var name string
+		var pk int
+		err := rows.Scan(&name, &pk)
+		if err != nil {
+			return nil, nil, err
+		}
+		columns = append(columns, name)
+		if pk > 0 {
+			keyPositions[pk] = name
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	if len(keyPositions) == 0 {
+		return nil, nil, fmt.Errorf("%s has no primary key", table)
+	}
+	keys := make([]string, len(keyPositions))
+	for position, name := range keyPositions {
+		keys[position-1] = name
+	}
+	return columns, keys, nil
+}
+
+// tableRow is a row read for the backfill, with its values in a form comparable
+// across the databases.
+type tableRow struct {
+	values []any  // Column values
+	key    []any  // Primary key values
+	hash   string // Hex digest of the values
+}
+
+// readTable reads every row of the table, keyed by their encoded primary key.
+func readTable(ctx context.Context, conn *sql.DB, table string, columns, keys []string) (map[string]tableRow, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT "+strings.Join(columns, ", ")+" FROM "+table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keyIndexes := make([]int, len(keys))
+	for i, key := range keys {
+		keyIndexes[i] = slices.Index(columns, key)
+	}
+	read := map[string]tableRow{}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		err := rows.Scan(pointers...)
+		if err != nil {
+			return nil, err
+		}
+
+		digest := sha256.New()
+		for i := range values {
+			values[i] = comparableValue(values[i])
+			encodeValue(digest, values[i])
+		}
+		row := tableRow{values: values, hash: hex.EncodeToString(digest.Sum(nil))}
+		var key strings.Builder
+		for _, i := range keyIndexes {
+			row.key = append(row.key, values[i])
+			encodeValue(&key, values[i])
+		}
+		read[key.String()] = row
+	}
+	return read, rows.Err()
+}
+
+// comparableValue converts a scanned value to the form both drivers agree on: text
+// as strings and timestamps in UTC at PostgreSQL's microsecond precision.
+func comparableValue(v any) any {
+	switch v := v.(type) {
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.UTC().Round(time.Microsecond)
+	case int32:
+		return int64(v)
+	}
+	return v
+}
+
+// encodeValue writes the value with its type and length, so that different rows never
+// encode the same.
+func encodeValue(w io.Writer, v any) {
+	var s string
+	switch v := v.(type) {
+	case nil:
+		s = "null"
+	case time.Time:
+		s = "time:" + v.Format(time.RFC3339Nano)
+	default:
+		s = fmt.Sprintf("%T:%v", v, v)
+	}
+	fmt.Fprintf(w, "%d:%s;", len(s), s)
+}
+
+// checksum digests the rows in primary key order.
+func checksum(rows map[string]tableRow) string {
+	keys := make([]string, 0, len(rows))
+	for key := range rows {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	digest := sha256.New()
+	for _, key := range keys {
+		encodeValue(digest, key)
+		encodeValue(digest, rows[key].hash)
+	}
+	return hex.EncodeToString(digest.Sum(nil))
+}
+
+// tableDiff is what differs between the SQLite table and its PostgreSQL copy.
+type tableDiff struct {
+	columns []string
+	keys    []string   // Primary key columns
+	changed []tableRow // SQLite rows missing or different in PostgreSQL
+	stale   []tableRow // PostgreSQL rows gone from SQLite
+}
+
+// diffTable reads the table from both databases and compares the rows by primary key.
+// Rows of append-only tables are never reported as changed or stale once both have them.
+func diffTable(ctx context.Context, source, target *sql.DB, table string) (tableDiff, error) {
+	var diff tableDiff
+	var err error
+	diff.columns, diff.keys, err = tableColumns(ctx, source, table)
+	if err != nil {
+		return diff, err
+	}
+	sourceRows, err := readTable(ctx, source, table, diff.columns, diff.keys)
+	if err != nil {
+		return diff, err
+	}
+	targetRows, err := readTable(ctx, target, table, diff.columns, diff.keys)
+	if err != nil {
+		return diff, err
+	}
+
+	for key, row := range sourceRows {
+		existing, ok := targetRows[key]
+		if !ok || existing.hash != row.hash && !appendOnlyTables[table] {
+			diff.changed = append(diff.changed, row)
+		}
+	}
+	for key, row := range targetRows {
+		if _, ok := sourceRows[key]; !ok && !appendOnlyTables[table] {
+			diff.stale = append(diff.stale, row)
+		}
+	}
+	return diff, nil
+}
+
+// copyRows writes the rows into the PostgreSQL table, in one transaction.
+func copyRows(ctx context.Context, target *sql.DB, table string, diff tableDiff) (int, error) {
+	if len(diff.changed) == 0 {
+		return 0, nil
+	}
+	upsert := upsertStatement(table, diff.columns, diff.keys)
+
+	tx, err := target.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	for _, row := range diff.changed {
+		_, err = tx.ExecContext(ctx, upsert, row.values...)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(diff.changed), tx.Commit()
+}
+
+// upsertStatement returns the statement inserting a row into the PostgreSQL table, or
+// overwriting the row with its primary key. Rows of append-only tables are only inserted.
+func upsertStatement(table string, columns, keys []string) string {
+	placeholders := make([]string, len(columns))
+	var updates []string
+	for i, column := range columns {
+		placeholders[i] = "$" + strconv.Itoa(i+1)
+		if !slices.Contains(keys, column) {
+			updates = append(updates, column+" = excluded."+column)
+		}
+	}
+	q := "INSERT INTO " + table + " (" + strings.Join(columns, ", ") + ") VALUES (" + strings.Join(placeholders, ", ") + ")" +
+		" ON CONFLICT (" + strings.Join(keys, ", ") + ")"
+	if len(updates) == 0 || appendOnlyTables[table] {
+		return q + " DO NOTHING"
+	}
+	return q + " DO UPDATE SET " + strings.Join(updates, ", ")
+}
+
+// deleteRows removes the rows from the PostgreSQL table by primary key, in one transaction.
+func deleteRows(ctx context.Context, target *sql.DB, table string, keys []string, rows []tableRow) (int, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	conditions := make([]string, len(keys))
+	for i, key := range keys {
+		conditions[i] = key + " = $" + strconv.Itoa(i+1)
+	}
+	q := "DELETE FROM " + table + " WHERE " + strings.Join(conditions, " AND ")
+
+	tx, err := target.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	for _, row := range rows {
+		_, err = tx.ExecContext(ctx, q, row.key...)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(rows), tx.Commit()
+}
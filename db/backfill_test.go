@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// migratedTestDB returns a new SQLite database with the application schema
+func migratedTestDB(t *testing.T, name string) *sql.DB {
+	t.Helper()
+	conn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), name))
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	originalDB := DB
+	DB = conn
+	defer func() { DB = originalDB }()
+	if err := migrate(); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+	return conn
+}
+
+// mustExec runs a statement on the test database
+func mustExec(t *testing.T, conn *sql.DB, query string, args ...any) {
+	t.Helper()
+	if _, err := conn.Exec(query, args...); err != nil {
+		t.Fatalf("Failed to run %q: %v", query, err)
+	}
+}
+
+// reportsByTable indexes the reports by table
+func reportsByTable(reports []TableReport) map[string]TableReport {
+	byTable := map[string]TableReport{}
+	for _, r := range reports {
+		byTable[r.Table] = r
+	}
+	return byTable
+}
+
+// TestBackfill tests that the backfill copies missing and changed rows, removes rows
+// gone from the source, and leaves the databases matching
+func TestBackfill(t *testing.T) {
+	ctx := context.Background()
+	// Both sides are SQLite here, which accepts the $1 placeholders written for PostgreSQL
+	source, target := migratedTestDB(t, "source.sql"), migratedTestDB(t, "target.sql")
+	start := time.Date(2026, 5, 1, 18, 30, 0, 123456789, time.UTC)
+
+	insertEvent := "INSERT INTO events (id, name, description, location, datetime, user_id) VALUES (?, ?, 'Talks', 'Hall', ?, 'org-1')"
+	mustExec(t, source, insertEvent, "event-1", "Meetup", start)
+	mustExec(t, source, insertEvent, "event-2", "Workshop", start.Add(time.Hour))
+	mustExec(t, source, "INSERT INTO registrations (id, event_id, name, email, created_at) VALUES ('reg-1', 'event-1', 'Ann', 'ann@example.com', ?)", start)
+	mustExec(t, source, "INSERT INTO content_rules (id, pattern, is_regex, action, created_at) VALUES ('rule-1', 'casino', ?, 'flag', ?)", true, start)
+	// The target has an outdated copy of event-1, and an event with its registration
+	// that were deleted since, holding the same email address for event-1
+	mustExec(t, target, insertEvent, "event-1", "Old title", start)
+	mustExec(t, target, insertEvent, "event-gone", "Cancelled", start)
+	mustExec(t, target, "INSERT INTO registrations (id, event_id, name, email, created_at) VALUES ('reg-gone', 'event-1', 'Ann', 'ann@example.com', ?)", start)
+
+	reports, err := Verify(ctx, source, target)
+	if err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+	byTable := reportsByTable(reports)
+	if byTable["events"].Matches() || byTable["registrations"].Matches() || !byTable["tickets"].Matches() || len(reports) != len(schemaTables) {
+		t.Errorf("Expected events and registrations to differ, got %+v", reports)
+	}
+
+	reports, err = Backfill(ctx, source, target)
+	if err != nil {
+		t.Fatalf("Failed to backfill: %v", err)
+	}
+	for _, r := range reports {
+		if !r.Matches() {
+			t.Errorf("Expected %s to match after the backfill, got %+v", r.Table, r)
+		}
+	}
+	byTable = reportsByTable(reports)
+	if events := byTable["events"]; events.Copied != 2 || events.Deleted != 1 || events.TargetRows != 2 {
+		t.Errorf("Expected 2 events copied and 1 deleted, got %+v", events)
+	}
+	if registrations := byTable["registrations"]; registrations.Copied != 1 || registrations.Deleted != 1 {
+		t.Errorf("Expected the stale registration replaced, got %+v", registrations)
+	}
+	var title string
+	var datetime time.Time
+	err = target.QueryRow("SELECT name, datetime FROM events WHERE id = 'event-1'").Scan(&title, &datetime)
+	if err != nil || title != "Meetup" || !datetime.Equal(start.Round(time.Microsecond)) {
+		t.Errorf("Expected the event overwritten at microsecond precision, got %q %v (%v)", title, datetime, err)
+	}
+
+	reports, err = Backfill(ctx, source, target)
+	if err != nil {
+		t.Fatalf("Failed to backfill again: %v", err)
+	}
+	for _, r := range reports {
+		if r.Copied != 0 || r.Deleted != 0 || !r.Matches() {
+			t.Errorf("Expected nothing left to copy in %s, got %+v", r.Table, r)
+		}
+	}
+}
+
+// TestBackfillSchemaVersion tests that databases at another schema version are refused
+func TestBackfillSchemaVersion(t *testing.T) {
+	source, target := migratedTestDB(t, "source.sql"), migratedTestDB(t, "target.sql")
+	mustExec(t, target, "INSERT INTO schema_version (version, migrated_at) VALUES (?, CURRENT_TIMESTAMP)", SchemaVersion+1)
+
+	if _, err := Backfill(context.Background(), source, target); err == nil {
+		t.Error("Expected a target at a newer schema version to be refused")
+	}
+}
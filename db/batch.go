@@ -0,0 +1,154 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Batch buffers small, frequent writes such as view counters in memory and writes
+// them in a single transaction on Flush, so that hot read paths never wait for the
+// database's only writer on SQLite. Writes to the same key are merged while they
+// wait, e.g. by adding up counts or keeping the latest timestamp.
+type Batch[K comparable, V any] struct {
+	name    string
+	merge   func(pending, added V) V
+	write   func(ctx context.Context, tx *sql.Tx, key K, value V) error
+	mu      sync.Mutex
+	pending map[K]V
+}
+
+// batches lists every Batch created, for FlushBatches.
+var (
+	batchesMu sync.Mutex
+	batches   []interface {
+		Flush(ctx context.Context) error
+		Name() string
+	}
+)
+
+// batchesDiscarded is set by DiscardBatches.
+var batchesDiscarded atomic.Bool
+
+// NewBatch creates a batch of buffered writes, named for logging, that merges writes to
+// the same key with merge and stores each merged write with write. The batch is
+// flushed with the others by FlushBatches and StartBatchFlush.
+func NewBatch[K comparable, V any](name string, merge func(pending, added V) V, write func(ctx context.Context, tx *sql.Tx, key K, value V) error) *Batch[K, V] {
+	b := &Batch[K, V]{name: name, merge: merge, write: write, pending: map[K]V{}}
+	batchesMu.Lock()
+	batches = append(batches, b)
+	batchesMu.Unlock()
+	return b
+}
+
+// Name returns the name of the batch.
+func (b *Batch[K, V]) Name() string {
+	return b.name
+}
+
+// Add buffers a write, merging it into the pending write of the same key. It does
+// nothing after DiscardBatches.
+func (b *Batch[K, V]) Add(key K, value V) {
+	if batchesDiscarded.Load() {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if pending, ok := b.pending[key]; ok {
+		value = b.merge(pending, value)
+	}
+	b.pending[key] = value
+}
+
+// Len returns the number of pending writes.
+func (b *Batch[K, V]) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.pending)
+}
+
+// Flush stores the pending writes in one transaction. When the transaction fails the
+// writes are put back and merged with those added meanwhile, for the next flush.
+func (b *Batch[K, V]) Flush(ctx context.Context) error {
+	b.mu.Lock()
+	writes := b.pending
+	b.pending = map[K]V{}
+	b.mu.Unlock()
+	if len(writes) == 0 {
+		return nil
+	}
+
+	err := b.store(ctx, writes)
+	if err != nil {
+		b.mu.Lock()
+		for key, value := range writes {
+			if added, ok := b.pending[key]; ok {
+				value = b.merge(value, added)
+			}
+			b.pending[key] = value
+		}
+		b.mu.Unlock()
+	}
+	return err
+}
+
+// store writes the batch in a single transaction, which is part of the transaction the
+// context carries, if any, so that a request flushing the batches doesn't wait for
+// its own lock on SQLite.
+func (b *Batch[K, V]) store(ctx context.Context, writes map[K]V) error {
+	tx, err := BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for key, value := range writes {
+		err = b.write(ctx, tx.Tx, key, value)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// FlushBatches flushes every batch, also when some of them fail, as on shutdown.
+// Returns the errors of the batches that couldn't be flushed.
+func FlushBatches(ctx context.Context) error {
+	batchesMu.Lock()
+	all := batches
+	batchesMu.Unlock()
+
+	var errs []error
+	for _, b := range all {
+		err := b.Flush(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", b.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DiscardBatches makes every batch drop the writes added from now on, for deployments
+// that never flush them, such as read-only mirrors, whose buffers would otherwise grow
+// without bound.
+func DiscardBatches() {
+	batchesDiscarded.Store(true)
+}
+
+// StartBatchFlush flushes the batches on every interval in a background goroutine.
+// Failures are logged and the writes retried on the next run.
+func StartBatchFlush(interval time.Duration) {
+	go func() {
+		for {
+			time.Sleep(interval)
+			err := FlushBatches(context.Background())
+			if err != nil {
+				slog.Error("Couldn't flush the buffered writes", "error", err)
+			}
+		}
+	}()
+}
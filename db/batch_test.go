@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+// setupBatchDB points DB at an in-memory database with a counters table
+func setupBatchDB(t *testing.T) {
+	testDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	testDB.SetMaxOpenConns(1)
+	originalDB := DB
+	DB = testDB
+	t.Cleanup(func() {
+		DB = originalDB
+		testDB.Close()
+	})
+	_, err = testDB.Exec("CREATE TABLE counters (name TEXT PRIMARY KEY, count INTEGER NOT NULL)")
+	if err != nil {
+		t.Fatalf("Failed to create counters table: %v", err)
+	}
+}
+
+// newCounterBatch creates a batch adding counts to the counters table
+func newCounterBatch() *Batch[string, int] {
+	return NewBatch("counters", func(pending, added int) int { return pending + added },
+		func(ctx context.Context, tx *sql.Tx, name string, count int) error {
+			q := "INSERT INTO counters (name, count) VALUES (?, ?) ON CONFLICT (name) DO UPDATE SET count = counters.count + excluded.count"
+			_, err := tx.ExecContext(ctx, q, name, count)
+			return err
+		})
+}
+
+// counter returns the stored count of the counter, 0 when there is none
+func counter(t *testing.T, name string) int {
+	var count int
+	err := DB.QueryRow("SELECT count FROM counters WHERE name = ?", name).Scan(&count)
+	if err != nil && err != sql.ErrNoRows {
+		t.Fatalf("Failed to read counter: %v", err)
+	}
+	return count
+}
+
+// TestBatchFlush tests that writes to the same key are merged and stored together
+func TestBatchFlush(t *testing.T) {
+	setupBatchDB(t)
+	batch := newCounterBatch()
+
+	batch.Add("a", 1)
+	batch.Add("a", 2)
+	batch.Add("b", 5)
+	if batch.Len() != 2 {
+		t.Errorf("Expected 2 pending writes, got %d", batch.Len())
+	}
+	if counter(t, "a") != 0 {
+		t.Error("Expected nothing to be written before the flush")
+	}
+
+	err := batch.Flush(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	if counter(t, "a") != 3 || counter(t, "b") != 5 || batch.Len() != 0 {
+		t.Errorf("Expected a=3 and b=5 with nothing pending, got a=%d b=%d and %d pending", counter(t, "a"), counter(t, "b"), batch.Len())
+	}
+}
+
+// TestBatchFlushFailure tests that a failed flush writes nothing and keeps the writes
+func TestBatchFlushFailure(t *testing.T) {
+	setupBatchDB(t)
+	batch := newCounterBatch()
+	batch.Add("a", 1)
+	batch.Add("b", 1)
+
+	_, err := DB.Exec("CREATE TRIGGER reject_b BEFORE INSERT ON counters WHEN NEW.name = 'b' BEGIN SELECT RAISE(ABORT, 'rejected'); END")
+	if err != nil {
+		t.Fatalf("Failed to create trigger: %v", err)
+	}
+	err = batch.Flush(context.Background())
+	if err == nil {
+		t.Fatal("Expected the flush to fail")
+	}
+	if counter(t, "a") != 0 {
+		t.Error("Expected the whole batch to be rolled back")
+	}
+
+	batch.Add("a", 1)
+	_, err = DB.Exec("DROP TRIGGER reject_b")
+	if err != nil {
+		t.Fatalf("Failed to drop trigger: %v", err)
+	}
+	err = FlushBatches(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to flush batches: %v", err)
+	}
+	if counter(t, "a") != 2 || counter(t, "b") != 1 {
+		t.Errorf("Expected the kept writes merged with the new one, got a=%d b=%d", counter(t, "a"), counter(t, "b"))
+	}
+}
+
+// TestDiscardBatches tests that batches buffer nothing once discarded, as on read-only
+// mirrors that never flush them
+func TestDiscardBatches(t *testing.T) {
+	setupBatchDB(t)
+	t.Cleanup(func() { batchesDiscarded.Store(false) })
+	batch := newCounterBatch()
+
+	DiscardBatches()
+	batch.Add("a", 1)
+	if batch.Len() != 0 {
+		t.Errorf("Expected the write to be dropped, got %d pending", batch.Len())
+	}
+}
@@ -3,37 +3,132 @@
 package db
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"errors"
+	"event_booking_restapi_golang/logging"
+	"event_booking_restapi_golang/textnorm"
+	"fmt"
+	"strings"
 
+	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
 )
 
 // DB is the global database connection pool used throughout the application.
 var DB *sql.DB
 
-// InitDB initializes the SQLite database connection and configures connection settings.
-// It opens a connection to "db.sql", sets connection limits, and creates required tables.
-// Panics if the database connection fails.
-func InitDB() {
+// InitDB opens the database with the given driver ("sqlite3" or "postgres") and
+// connection string, configures connection settings, and creates required tables,
+// migrating databases set up by older builds unless AutoMigrate is off.
+// Statements run through the pool are timed for the /metrics endpoint.
+// Returns a *SchemaMismatchError, leaving the database unchanged, when its schema
+// version doesn't match SchemaVersion and it can't be migrated; the database stays open.
+// With DualWriteDSN set, the SQLite database's writes are mirrored to that PostgreSQL
+// database, whose schema is set up first.
+// Logs the error and exits if the database can't be opened or the schema set up.
+func InitDB(driver, dsn string) error {
 	var err error
-	DB, err = sql.Open("sqlite3", "db.sql")
+	if DualWriteDSN != "" {
+		if driver != SQLite {
+			logging.Fatal("Couldn't init DB", errors.New("dual-write mirrors a SQLite database"))
+		}
+		target, err := openDualWriteTarget(DualWriteDSN)
+		if err != nil {
+			logging.Fatal("Couldn't init the dual-write database", err)
+		}
+		DB, err = openDualWrite(dsn, target)
+	} else {
+		DB, err = openInstrumented(driver, dsn)
+	}
+	Driver = driver
 
 	if err != nil {
-		log.Fatal("Couldn't init DB ", err)
-		panic(1)
+		logging.Fatal("Couldn't init DB", err)
 	}
 
 	DB.SetMaxOpenConns(10)
 	DB.SetMaxIdleConns(5)
 
-	createTables()
+	err = migrate()
+	var mismatch *SchemaMismatchError
+	if err != nil && !errors.As(err, &mismatch) {
+		logging.Fatal("Couldn't migrate the database", err)
+	}
+	return err
+}
+
+// schemaTables lists the tables created by createTables, which Ping checks for.
+var schemaTables = []string{
+	"events", "registrations", "waitlist", "content_rules", "attachments", "experiment_events",
+	"audit_log", "audit_export", "analytics_opt_outs",
+	"registration_daily_summary", "event_view_daily_summary", "summary_refresh",
+	"event_alerts", "event_senders", "event_messages", "user_blocks", "event_imports", "feed_runs", "tickets", "event_versions", "jobs", "refund_requests",
+	"venue_blackouts", "organizer_onboarding", "status_probes", "registration_cancellations", "registration_moves",
+	"schema_version",
+}
+
+// externalIDTables lists the tables whose rows can carry the ID they have in an
+// external system, such as a ticketing platform they are synced from.
+var externalIDTables = []string{"events", "registrations"}
+
+// externalIDIndex returns the statement creating the index that keeps the external
+// IDs of table unique per source. Rows without an external ID aren't indexed.
+func externalIDIndex(table string) string {
+	return "CREATE UNIQUE INDEX IF NOT EXISTS " + table + "_external_id ON " + table +
+		" (external_source, external_id) WHERE external_id <> ''"
+}
+
+// versionedEventColumns lists the events columns that event_versions keeps a copy of.
+const versionedEventColumns = "name, description, location, datetime, user_id, capacity, review_status, review_reason, created_at, " +
+	"confirmation_url, confirmation_message, external_id, external_source, end_datetime, status, slug, license, series_id"
+
+// addedColumns lists the columns added after their tables were first released.
+// createTables adds them to older databases and Ping checks that they exist.
+var addedColumns = []struct{ table, name, definition string }{
+	{"events", "capacity", "INTEGER NOT NULL DEFAULT 0"},
+	{"events", "review_status", "TEXT NOT NULL DEFAULT 'approved'"},
+	{"events", "review_reason", "TEXT NOT NULL DEFAULT ''"},
+	{"events", "created_at", "DATETIME"},
+	{"registrations", "waiver_id", "TEXT NOT NULL DEFAULT ''"},
+	{"registrations", "waiver_accepted_at", "DATETIME"},
+	{"registrations", "waiver_ip", "TEXT NOT NULL DEFAULT ''"},
+	{"waitlist", "waiver_id", "TEXT NOT NULL DEFAULT ''"},
+	{"waitlist", "waiver_accepted_at", "DATETIME"},
+	{"waitlist", "waiver_ip", "TEXT NOT NULL DEFAULT ''"},
+	{"events", "confirmation_url", "TEXT NOT NULL DEFAULT ''"},
+	{"events", "confirmation_message", "TEXT NOT NULL DEFAULT ''"},
+	{"registrations", "utm_source", "TEXT NOT NULL DEFAULT ''"},
+	{"registrations", "utm_medium", "TEXT NOT NULL DEFAULT ''"},
+	{"registrations", "utm_campaign", "TEXT NOT NULL DEFAULT ''"},
+	{"registrations", "utm_term", "TEXT NOT NULL DEFAULT ''"},
+	{"registrations", "utm_content", "TEXT NOT NULL DEFAULT ''"},
+	{"registrations", "ref", "TEXT NOT NULL DEFAULT ''"},
+	{"waitlist", "utm_source", "TEXT NOT NULL DEFAULT ''"},
+	{"waitlist", "utm_medium", "TEXT NOT NULL DEFAULT ''"},
+	{"waitlist", "utm_campaign", "TEXT NOT NULL DEFAULT ''"},
+	{"waitlist", "utm_term", "TEXT NOT NULL DEFAULT ''"},
+	{"waitlist", "utm_content", "TEXT NOT NULL DEFAULT ''"},
+	{"waitlist", "ref", "TEXT NOT NULL DEFAULT ''"},
+	{"events", "external_id", "TEXT NOT NULL DEFAULT ''"},
+	{"events", "external_source", "TEXT NOT NULL DEFAULT ''"},
+	{"registrations", "external_id", "TEXT NOT NULL DEFAULT ''"},
+	{"registrations", "external_source", "TEXT NOT NULL DEFAULT ''"},
+	{"events", "end_datetime", "DATETIME"},
+	{"events", "status", "TEXT NOT NULL DEFAULT 'published'"},
+	{"events", "slug", "TEXT NOT NULL DEFAULT ''"},
+	{"registrations", "checked_in_at", "DATETIME"},
+	{"events", "license", "TEXT NOT NULL DEFAULT ''"},
+	{"events", "version", "INTEGER NOT NULL DEFAULT 0"},
+	{"events", "series_id", "TEXT NOT NULL DEFAULT ''"},
+	{"registrations", "moved_at", "DATETIME"},
 }
 
 // createTables creates the necessary database tables for the application.
-// Currently creates the events table if it doesn't exist.
+// Creates all application tables if they don't exist.
 // Panics if table creation fails.
 func createTables() {
+	// Column types are written for SQLite and translated by ddl for PostgreSQL
 	createEventsTable := `
 		CREATE TABLE IF NOT EXISTS events (
 		id TEXT PRIMARY KEY,
@@ -41,12 +136,682 @@ func createTables() {
 		description TEXT NOT NULL,
 		location TEXT NOT NULL,
 		datetime DATETIME NOT NULL,
-		user_id int
+		user_id TEXT,
+		capacity INTEGER NOT NULL DEFAULT 0,
+		review_status TEXT NOT NULL DEFAULT 'approved',
+		review_reason TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		confirmation_url TEXT NOT NULL DEFAULT '',
+		confirmation_message TEXT NOT NULL DEFAULT ''
+		)
+		`
+	_, err := DB.Exec(ddl(createEventsTable))
+	if err != nil {
+		logging.Fatal("Couldn't create events table", err)
+	}
+
+	createRegistrationsTable := `
+		CREATE TABLE IF NOT EXISTS registrations (
+		id TEXT PRIMARY KEY,
+		event_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		email TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		waiver_id TEXT NOT NULL DEFAULT '',
+		waiver_accepted_at DATETIME,
+		waiver_ip TEXT NOT NULL DEFAULT '',
+		utm_source TEXT NOT NULL DEFAULT '',
+		utm_medium TEXT NOT NULL DEFAULT '',
+		utm_campaign TEXT NOT NULL DEFAULT '',
+		utm_term TEXT NOT NULL DEFAULT '',
+		utm_content TEXT NOT NULL DEFAULT '',
+		ref TEXT NOT NULL DEFAULT '',
+		UNIQUE (event_id, email),
+		FOREIGN KEY (event_id) REFERENCES events(id)
+		)
+		`
+	_, err = DB.Exec(ddl(createRegistrationsTable))
+	if err != nil {
+		logging.Fatal("Couldn't create registrations table", err)
+	}
+
+	createWaitlistTable := `
+		CREATE TABLE IF NOT EXISTS waitlist (
+		id TEXT PRIMARY KEY,
+		event_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		email TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		waiver_id TEXT NOT NULL DEFAULT '',
+		waiver_accepted_at DATETIME,
+		waiver_ip TEXT NOT NULL DEFAULT '',
+		utm_source TEXT NOT NULL DEFAULT '',
+		utm_medium TEXT NOT NULL DEFAULT '',
+		utm_campaign TEXT NOT NULL DEFAULT '',
+		utm_term TEXT NOT NULL DEFAULT '',
+		utm_content TEXT NOT NULL DEFAULT '',
+		ref TEXT NOT NULL DEFAULT '',
+		UNIQUE (event_id, email),
+		FOREIGN KEY (event_id) REFERENCES events(id)
+		)
+		`
+	_, err = DB.Exec(ddl(createWaitlistTable))
+	if err != nil {
+		logging.Fatal("Couldn't create waitlist table", err)
+	}
+
+	createContentRulesTable := `
+		CREATE TABLE IF NOT EXISTS content_rules (
+		id TEXT PRIMARY KEY,
+		pattern TEXT NOT NULL,
+		is_regex BOOLEAN NOT NULL DEFAULT FALSE,
+		action TEXT NOT NULL,
+		created_at DATETIME NOT NULL
+		)
+		`
+	_, err = DB.Exec(ddl(createContentRulesTable))
+	if err != nil {
+		logging.Fatal("Couldn't create content rules table", err)
+	}
+
+	createAttachmentsTable := `
+		CREATE TABLE IF NOT EXISTS attachments (
+		id TEXT PRIMARY KEY,
+		event_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		file_name TEXT NOT NULL,
+		content_type TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		attendees_only BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at DATETIME NOT NULL,
+		FOREIGN KEY (event_id) REFERENCES events(id)
+		)
+		`
+	_, err = DB.Exec(ddl(createAttachmentsTable))
+	if err != nil {
+		logging.Fatal("Couldn't create attachments table", err)
+	}
+
+	// Bring tables created by older versions up to date
+	for _, column := range addedColumns {
+		err = ensureColumn(column.table, column.name, column.definition)
+		if err != nil {
+			logging.Fatal("Couldn't add "+column.name+" column to "+column.table+" table", err)
+		}
+	}
+
+	// External IDs are unique per source, so syncs can upsert by them
+	for _, table := range externalIDTables {
+		_, err = DB.Exec(externalIDIndex(table))
+		if err != nil {
+			logging.Fatal("Couldn't create external ID index on "+table+" table", err)
+		}
+	}
+
+	// The occurrences of a series are looked up per organizer when rescheduling bookings
+	_, err = DB.Exec("CREATE INDEX IF NOT EXISTS events_series ON events (user_id, series_id) WHERE series_id <> ''")
+	if err != nil {
+		logging.Fatal("Couldn't create series index on events table", err)
+	}
+
+	// Events that predate the created_at column are stamped with the upgrade time
+	_, err = DB.Exec("UPDATE events SET created_at = CURRENT_TIMESTAMP WHERE created_at IS NULL")
+	if err != nil {
+		logging.Fatal("Couldn't backfill events created_at", err)
+	}
+
+	err = backfillSlugs()
+	if err != nil {
+		logging.Fatal("Couldn't backfill events slug", err)
+	}
+
+	createExperimentEventsTable := `
+		CREATE TABLE IF NOT EXISTS experiment_events (
+		id TEXT PRIMARY KEY,
+		experiment TEXT NOT NULL,
+		variant TEXT NOT NULL,
+		session_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		event_id TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL
+		)
+		`
+	_, err = DB.Exec(ddl(createExperimentEventsTable))
+	if err != nil {
+		logging.Fatal("Couldn't create experiment events table", err)
+	}
+
+	createAuditLogTable := `
+		CREATE TABLE IF NOT EXISTS audit_log (
+		seq INTEGER PRIMARY KEY,
+		action TEXT NOT NULL,
+		event_id TEXT NOT NULL DEFAULT '',
+		subject_id TEXT NOT NULL DEFAULT '',
+		details TEXT NOT NULL DEFAULT '{}',
+		created_at DATETIME NOT NULL,
+		prev_hash TEXT NOT NULL,
+		hash TEXT NOT NULL
+		)
+		`
+	_, err = DB.Exec(ddl(createAuditLogTable))
+	if err != nil {
+		logging.Fatal("Couldn't create audit log table", err)
+	}
+	for _, statement := range appendOnly("audit_log") {
+		_, err = DB.Exec(statement)
+		if err != nil {
+			logging.Fatal("Couldn't make the audit log append-only", err)
+		}
+	}
+
+	createAuditExportTable := `
+		CREATE TABLE IF NOT EXISTS audit_export (
+		sink TEXT PRIMARY KEY,
+		last_seq INTEGER NOT NULL DEFAULT 0
+		)
+		`
+	_, err = DB.Exec(ddl(createAuditExportTable))
+	if err != nil {
+		logging.Fatal("Couldn't create audit export table", err)
+	}
+
+	createAnalyticsOptOutsTable := `
+		CREATE TABLE IF NOT EXISTS analytics_opt_outs (
+		session_id TEXT PRIMARY KEY,
+		created_at DATETIME NOT NULL
+		)
+		`
+	_, err = DB.Exec(ddl(createAnalyticsOptOutsTable))
+	if err != nil {
+		logging.Fatal("Couldn't create analytics opt outs table", err)
+	}
+
+	createRegistrationDailySummaryTable := `
+		CREATE TABLE IF NOT EXISTS registration_daily_summary (
+		event_id TEXT NOT NULL,
+		day TEXT NOT NULL,
+		registrations INTEGER NOT NULL,
+		PRIMARY KEY (event_id, day)
+		)
+		`
+	_, err = DB.Exec(ddl(createRegistrationDailySummaryTable))
+	if err != nil {
+		logging.Fatal("Couldn't create registration daily summary table", err)
+	}
+
+	createEventViewDailySummaryTable := `
+		CREATE TABLE IF NOT EXISTS event_view_daily_summary (
+		event_id TEXT NOT NULL,
+		day TEXT NOT NULL,
+		views INTEGER NOT NULL,
+		PRIMARY KEY (event_id, day)
+		)
+		`
+	_, err = DB.Exec(ddl(createEventViewDailySummaryTable))
+	if err != nil {
+		logging.Fatal("Couldn't create event view daily summary table", err)
+	}
+
+	createSummaryRefreshTable := `
+		CREATE TABLE IF NOT EXISTS summary_refresh (
+		name TEXT PRIMARY KEY,
+		refreshed_at DATETIME NOT NULL
+		)
+		`
+	_, err = DB.Exec(ddl(createSummaryRefreshTable))
+	if err != nil {
+		logging.Fatal("Couldn't create summary refresh table", err)
+	}
+
+	createEventAlertsTable := `
+		CREATE TABLE IF NOT EXISTS event_alerts (
+		event_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		sent_at DATETIME NOT NULL,
+		PRIMARY KEY (event_id, kind)
+		)
+		`
+	_, err = DB.Exec(ddl(createEventAlertsTable))
+	if err != nil {
+		logging.Fatal("Couldn't create event alerts table", err)
+	}
+
+	createEventSendersTable := `
+		CREATE TABLE IF NOT EXISTS event_senders (
+		event_id TEXT PRIMARY KEY,
+		sender_name TEXT NOT NULL DEFAULT '',
+		reply_to TEXT NOT NULL DEFAULT '',
+		reply_to_verified_at DATETIME,
+		verification_hash TEXT NOT NULL DEFAULT '',
+		verification_expires_at DATETIME,
+		FOREIGN KEY (event_id) REFERENCES events(id)
+		)
+		`
+	_, err = DB.Exec(ddl(createEventSendersTable))
+	if err != nil {
+		logging.Fatal("Couldn't create event senders table", err)
+	}
+
+	createEventMessagesTable := `
+		CREATE TABLE IF NOT EXISTS event_messages (
+		id TEXT PRIMARY KEY,
+		event_id TEXT NOT NULL,
+		registration_id TEXT NOT NULL,
+		sender TEXT NOT NULL,
+		body TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		read_at DATETIME,
+		FOREIGN KEY (event_id) REFERENCES events(id)
+		)
+		`
+	_, err = DB.Exec(ddl(createEventMessagesTable))
+	if err != nil {
+		logging.Fatal("Couldn't create event messages table", err)
+	}
+
+	createUserBlocksTable := `
+		CREATE TABLE IF NOT EXISTS user_blocks (
+		organizer_id TEXT NOT NULL,
+		email TEXT NOT NULL,
+		blocked_by TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		PRIMARY KEY (organizer_id, email, blocked_by)
+		)
+		`
+	_, err = DB.Exec(ddl(createUserBlocksTable))
+	if err != nil {
+		logging.Fatal("Couldn't create user blocks table", err)
+	}
+
+	createEventImportsTable := `
+		CREATE TABLE IF NOT EXISTS event_imports (
+		event_id TEXT PRIMARY KEY,
+		source TEXT NOT NULL,
+		source_event_id TEXT NOT NULL,
+		media TEXT NOT NULL,
+		imported_at DATETIME NOT NULL,
+		UNIQUE (source, source_event_id),
+		FOREIGN KEY (event_id) REFERENCES events(id)
+		)
+		`
+	_, err = DB.Exec(ddl(createEventImportsTable))
+	if err != nil {
+		logging.Fatal("Couldn't create event imports table", err)
+	}
+
+	createFeedRunsTable := `
+		CREATE TABLE IF NOT EXISTS feed_runs (
+		id TEXT PRIMARY KEY,
+		feed TEXT NOT NULL,
+		started_at DATETIME NOT NULL,
+		finished_at DATETIME NOT NULL,
+		added INTEGER NOT NULL DEFAULT 0,
+		updated INTEGER NOT NULL DEFAULT 0,
+		skipped INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		skips TEXT NOT NULL DEFAULT '[]'
+		)
+		`
+	_, err = DB.Exec(ddl(createFeedRunsTable))
+	if err != nil {
+		logging.Fatal("Couldn't create feed runs table", err)
+	}
+
+	createTicketsTable := `
+		CREATE TABLE IF NOT EXISTS tickets (
+		id TEXT PRIMARY KEY,
+		registration_id TEXT NOT NULL UNIQUE,
+		event_id TEXT NOT NULL,
+		code TEXT NOT NULL UNIQUE,
+		created_at DATETIME NOT NULL,
+		FOREIGN KEY (event_id) REFERENCES events(id)
+		)
+		`
+	_, err = DB.Exec(ddl(createTicketsTable))
+	if err != nil {
+		logging.Fatal("Couldn't create tickets table", err)
+	}
+
+	createEventVersionsTable := `
+		CREATE TABLE IF NOT EXISTS event_versions (
+		event_id TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		recorded_at DATETIME NOT NULL,
+		deleted BOOLEAN NOT NULL DEFAULT FALSE,
+		name TEXT NOT NULL,
+		description TEXT NOT NULL,
+		location TEXT NOT NULL,
+		datetime DATETIME NOT NULL,
+		user_id TEXT,
+		capacity INTEGER NOT NULL DEFAULT 0,
+		review_status TEXT NOT NULL DEFAULT 'approved',
+		review_reason TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		confirmation_url TEXT NOT NULL DEFAULT '',
+		confirmation_message TEXT NOT NULL DEFAULT '',
+		external_id TEXT NOT NULL DEFAULT '',
+		external_source TEXT NOT NULL DEFAULT '',
+		end_datetime DATETIME,
+		status TEXT NOT NULL DEFAULT 'published',
+		slug TEXT NOT NULL DEFAULT '',
+		license TEXT NOT NULL DEFAULT '',
+		series_id TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (event_id, version)
+		)
+		`
+	_, err = DB.Exec(ddl(createEventVersionsTable))
+	if err != nil {
+		logging.Fatal("Couldn't create event versions table", err)
+	}
+	// The history was released before the license and series_id columns, and is created
+	// after addedColumns are applied, so it is brought up to date here
+	err = ensureColumn("event_versions", "license", "TEXT NOT NULL DEFAULT ''")
+	if err != nil {
+		logging.Fatal("Couldn't add license column to event_versions table", err)
+	}
+	err = ensureColumn("event_versions", "series_id", "TEXT NOT NULL DEFAULT ''")
+	if err != nil {
+		logging.Fatal("Couldn't add series_id column to event_versions table", err)
+	}
+
+	// Events that predate the history start it with their state at the upgrade time
+	_, err = DB.Exec("INSERT INTO event_versions (event_id, version, recorded_at, " + versionedEventColumns + ") " +
+		"SELECT id, 1, CURRENT_TIMESTAMP, " + versionedEventColumns + " FROM events " +
+		"WHERE NOT EXISTS (SELECT 1 FROM event_versions WHERE event_versions.event_id = events.id)")
+	if err != nil {
+		logging.Fatal("Couldn't backfill event versions", err)
+	}
+	// Events that predate their version column take the number of their latest change
+	_, err = DB.Exec("UPDATE events SET version = (SELECT MAX(version) FROM event_versions WHERE event_versions.event_id = events.id) WHERE version = 0")
+	if err != nil {
+		logging.Fatal("Couldn't backfill event version numbers", err)
+	}
+
+	createJobsTable := `
+		CREATE TABLE IF NOT EXISTS jobs (
+		id TEXT PRIMARY KEY,
+		kind TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		run_at DATETIME NOT NULL,
+		locked_at DATETIME,
+		last_error TEXT NOT NULL DEFAULT '',
+		request_id TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL
+		)
+		`
+	_, err = DB.Exec(ddl(createJobsTable))
+	if err != nil {
+		logging.Fatal("Couldn't create jobs table", err)
+	}
+
+	createRefundRequestsTable := `
+		CREATE TABLE IF NOT EXISTS refund_requests (
+		id TEXT PRIMARY KEY,
+		registration_id TEXT NOT NULL UNIQUE,
+		event_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		email TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'requested',
+		auto_approved BOOLEAN NOT NULL DEFAULT FALSE,
+		decision_reason TEXT NOT NULL DEFAULT '',
+		provider_reference TEXT NOT NULL DEFAULT '',
+		error TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		decided_at DATETIME,
+		refunded_at DATETIME,
+		FOREIGN KEY (event_id) REFERENCES events(id)
+		)
+		`
+	_, err = DB.Exec(ddl(createRefundRequestsTable))
+	if err != nil {
+		logging.Fatal("Couldn't create refund requests table", err)
+	}
+
+	createVenueBlackoutsTable := `
+		CREATE TABLE IF NOT EXISTS venue_blackouts (
+		id TEXT PRIMARY KEY,
+		location TEXT NOT NULL,
+		blackout_date TEXT NOT NULL,
+		reason TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL
+		)
+		`
+	_, err = DB.Exec(ddl(createVenueBlackoutsTable))
+	if err != nil {
+		logging.Fatal("Couldn't create venue blackouts table", err)
+	}
+
+	createOrganizerOnboardingTable := `
+		CREATE TABLE IF NOT EXISTS organizer_onboarding (
+		user_id TEXT NOT NULL,
+		step TEXT NOT NULL,
+		completed_at DATETIME NOT NULL,
+		PRIMARY KEY (user_id, step)
+		)
+		`
+	_, err = DB.Exec(ddl(createOrganizerOnboardingTable))
+	if err != nil {
+		logging.Fatal("Couldn't create organizer onboarding table", err)
+	}
+
+	// Organizers who published events or verified a sender before onboarding was tracked
+	// start with those steps completed
+	backfillOnboarding := []string{
+		"INSERT INTO organizer_onboarding (user_id, step, completed_at) " +
+			"SELECT user_id, 'first_event_published', MIN(created_at) FROM events WHERE status = 'published' AND user_id <> '' GROUP BY user_id " +
+			"ON CONFLICT (user_id, step) DO NOTHING",
+		"INSERT INTO organizer_onboarding (user_id, step, completed_at) " +
+			"SELECT e.user_id, 'profile_completed', MIN(s.reply_to_verified_at) FROM event_senders s JOIN events e ON e.id = s.event_id " +
+			"WHERE s.reply_to_verified_at IS NOT NULL AND s.sender_name <> '' AND e.user_id <> '' GROUP BY e.user_id " +
+			"ON CONFLICT (user_id, step) DO NOTHING",
+	}
+	for _, statement := range backfillOnboarding {
+		_, err = DB.Exec(statement)
+		if err != nil {
+			logging.Fatal("Couldn't backfill organizer onboarding", err)
+		}
+	}
+
+	// One row per check of a component by the status page monitor, kept for uptime
+	createStatusProbesTable := `
+		CREATE TABLE IF NOT EXISTS status_probes (
+		id TEXT PRIMARY KEY,
+		component TEXT NOT NULL,
+		checked_at DATETIME NOT NULL,
+		state TEXT NOT NULL,
+		detail TEXT NOT NULL DEFAULT ''
 		)
 		`
-	_, err := DB.Exec(createEventsTable)
+	_, err = DB.Exec(ddl(createStatusProbesTable))
 	if err != nil {
-		log.Fatal("Couldn't create events table ", err)
-		panic(1)
+		logging.Fatal("Couldn't create status probes table", err)
+	}
+	_, err = DB.Exec("CREATE INDEX IF NOT EXISTS status_probes_component ON status_probes (component, checked_at)")
+	if err != nil {
+		logging.Fatal("Couldn't create status probes index", err)
+	}
+
+	// One row per cancelled registration, which is deleted, for the event's statistics
+	createRegistrationCancellationsTable := `
+		CREATE TABLE IF NOT EXISTS registration_cancellations (
+		registration_id TEXT PRIMARY KEY,
+		event_id TEXT NOT NULL,
+		registered_at DATETIME NOT NULL,
+		cancelled_at DATETIME NOT NULL
+		)
+		`
+	_, err = DB.Exec(ddl(createRegistrationCancellationsTable))
+	if err != nil {
+		logging.Fatal("Couldn't create registration cancellations table", err)
+	}
+	_, err = DB.Exec("CREATE INDEX IF NOT EXISTS registration_cancellations_event ON registration_cancellations (event_id)")
+	if err != nil {
+		logging.Fatal("Couldn't create registration cancellations index", err)
+	}
+
+	// One row per booking moved to another occurrence, for the statistics of the one left
+	createRegistrationMovesTable := `
+		CREATE TABLE IF NOT EXISTS registration_moves (
+		id TEXT PRIMARY KEY,
+		registration_id TEXT NOT NULL,
+		event_id TEXT NOT NULL,
+		to_event_id TEXT NOT NULL,
+		registered_at DATETIME NOT NULL,
+		moved_at DATETIME NOT NULL
+		)
+		`
+	_, err = DB.Exec(ddl(createRegistrationMovesTable))
+	if err != nil {
+		logging.Fatal("Couldn't create registration moves table", err)
+	}
+	_, err = DB.Exec("CREATE INDEX IF NOT EXISTS registration_moves_event ON registration_moves (event_id)")
+	if err != nil {
+		logging.Fatal("Couldn't create registration moves index", err)
+	}
+
+	// One row per schema version the database was migrated to (see migrate)
+	createSchemaVersionTable := `
+		CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER PRIMARY KEY,
+		migrated_at DATETIME NOT NULL
+		)
+		`
+	_, err = DB.Exec(ddl(createSchemaVersionTable))
+	if err != nil {
+		logging.Fatal("Couldn't create schema version table", err)
+	}
+}
+
+// ErrNotInitialized is returned by Ping before InitDB has opened the database.
+var ErrNotInitialized = errors.New("database is not initialized")
+
+// Ping checks that the database is reachable and that its schema is up to date:
+// every table exists with every column added since it was first released.
+// It backs the readiness probe, so it only reads the schema and never changes it.
+func Ping(ctx context.Context) error {
+	if DB == nil {
+		return ErrNotInitialized
+	}
+	err := DB.PingContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	columns := map[string][]string{}
+	for _, column := range addedColumns {
+		columns[column.table] = append(columns[column.table], column.name)
+	}
+	for _, table := range schemaTables {
+		selected := "*"
+		if len(columns[table]) > 0 {
+			selected = strings.Join(columns[table], ", ")
+		}
+		rows, err := DB.QueryContext(ctx, "SELECT "+selected+" FROM "+table+" LIMIT 0")
+		if err != nil {
+			return fmt.Errorf("migrations not applied to %s table: %w", table, err)
+		}
+		rows.Close()
+	}
+	return nil
+
+}
+
+// ddl translates SQLite column types in a schema statement to their PostgreSQL equivalents.
+func ddl(statement string) string {
+	if Driver != Postgres {
+		return statement
+	}
+	return strings.ReplaceAll(statement, "DATETIME", "TIMESTAMPTZ")
+
+}
+
+// appendOnly returns the statements that make the database refuse to update or
+// delete rows of table, so records such as the audit log can only grow.
+func appendOnly(table string) []string {
+	if Driver == Postgres {
+		return []string{
+			"CREATE OR REPLACE FUNCTION " + table + "_append_only() RETURNS trigger AS $$ " +
+				"BEGIN RAISE EXCEPTION '" + table + " is append-only'; END $$ LANGUAGE plpgsql",
+			"CREATE OR REPLACE TRIGGER " + table + "_append_only BEFORE UPDATE OR DELETE OR TRUNCATE ON " + table +
+				" FOR EACH STATEMENT EXECUTE FUNCTION " + table + "_append_only()",
+		}
+	}
+	return []string{
+		"CREATE TRIGGER IF NOT EXISTS " + table + "_no_update BEFORE UPDATE ON " + table +
+			" BEGIN SELECT RAISE(ABORT, '" + table + " is append-only'); END",
+		"CREATE TRIGGER IF NOT EXISTS " + table + "_no_delete BEFORE DELETE ON " + table +
+			" BEGIN SELECT RAISE(ABORT, '" + table + " is append-only'); END",
+	}
+}
+
+// ensureColumn adds a column to an existing table when it is missing.
+// It lets databases created by older versions pick up new columns,
+// since CREATE TABLE IF NOT EXISTS leaves existing tables untouched.
+func ensureColumn(table, column, definition string) error {
+	if Driver == Postgres {
+		_, err := DB.Exec(ddl("ALTER TABLE " + table + " ADD COLUMN IF NOT EXISTS " + column + " " + definition))
+		return err
+	}
+
+	rows, err := DB.Query("PRAGMA table_info(" + table + ")")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, dataType string
+		var defaultValue any
+		err = rows.Scan(&cid, &name, &dataType, &notNull, &defaultValue, &pk)
+		if err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = DB.Exec("ALTER TABLE " + table + " ADD COLUMN " + column + " " + definition)
+	return err
+}
+
+// backfillSlugs sets the slugs of the events that predate the slug column from their
+// titles. Titles without letters or digits keep an empty slug.
+func backfillSlugs() error {
+	rows, err := DB.Query("SELECT id, name FROM events WHERE slug = ''")
+	if err != nil {
+		return err
+	}
+	slugs := map[string]string{}
+	for rows.Next() {
+		var id, title string
+		err = rows.Scan(&id, &title)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		if slug := textnorm.Slug(title); slug != "" {
+			slugs[id] = slug
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for id, slug := range slugs {
+		_, err = DB.Exec(Rebind("UPDATE events SET slug=? WHERE id=?"), slug, id)
+		if err != nil {
+			return err
+		}
 	}
+	return nil
 }
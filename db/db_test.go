@@ -2,8 +2,11 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"os"
+	"strings"
 	"testing"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -174,3 +177,113 @@ func TestDatabaseErrorHandling(t *testing.T) {
 		t.Error("Expected error when executing invalid SQL")
 	}
 }
+
+// TestEnsureColumn tests that missing columns are added to existing tables
+func TestEnsureColumn(t *testing.T) {
+	testDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer testDB.Close()
+
+	originalDB := DB
+	DB = testDB
+	defer func() { DB = originalDB }()
+
+	_, err = testDB.Exec("CREATE TABLE events (id TEXT PRIMARY KEY, name TEXT NOT NULL)")
+	if err != nil {
+		t.Fatalf("Failed to create events table: %v", err)
+	}
+
+	// Running twice must not fail on the already existing column
+	for i := 0; i < 2; i++ {
+		err = ensureColumn("events", "capacity", "INTEGER NOT NULL DEFAULT 0")
+		if err != nil {
+			t.Fatalf("Failed to ensure column: %v", err)
+		}
+	}
+
+	var count int
+	err = testDB.QueryRow("SELECT COUNT(*) FROM pragma_table_info('events') WHERE name = 'capacity'").Scan(&count)
+	if err != nil {
+		t.Errorf("Failed to verify column: %v", err)
+	}
+	if count != 1 {
+		t.Error("Capacity column was not added")
+	}
+}
+
+// TestPing tests that Ping reports a missing database, missing tables and missing columns
+func TestPing(t *testing.T) {
+	originalDB := DB
+	defer func() { DB = originalDB }()
+
+	DB = nil
+	if err := Ping(context.Background()); !errors.Is(err, ErrNotInitialized) {
+		t.Errorf("Expected ErrNotInitialized before InitDB, got %v", err)
+	}
+
+	testDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer testDB.Close()
+	testDB.SetMaxOpenConns(1)
+	DB = testDB
+
+	if err := Ping(context.Background()); err == nil {
+		t.Error("Expected an error for a database without tables")
+	}
+
+	createTables()
+	if err := Ping(context.Background()); err != nil {
+		t.Errorf("Expected a migrated database to be ready, got %v", err)
+	}
+
+	// A table from an older version that is missing an added column isn't ready
+	_, err = testDB.Exec("ALTER TABLE waitlist DROP COLUMN ref")
+	if err != nil {
+		t.Fatalf("Failed to drop column: %v", err)
+	}
+	err = Ping(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "waitlist") {
+		t.Errorf("Expected an error naming the waitlist table, got %v", err)
+	}
+
+	testDB.Close()
+	if err := Ping(context.Background()); err == nil {
+		t.Error("Expected an error for a closed database")
+	}
+}
+
+// TestAuditLogAppendOnly tests that the database refuses to change or remove audit log entries
+func TestAuditLogAppendOnly(t *testing.T) {
+	testDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer testDB.Close()
+	testDB.SetMaxOpenConns(1)
+
+	originalDB := DB
+	DB = testDB
+	defer func() { DB = originalDB }()
+
+	// Running twice must not fail on the existing triggers
+	createTables()
+	createTables()
+
+	_, err = testDB.Exec("INSERT INTO audit_log (seq, action, created_at, prev_hash, hash) VALUES (1, 'registration.created', CURRENT_TIMESTAMP, '', 'abc')")
+	if err != nil {
+		t.Fatalf("Failed to append to the audit log: %v", err)
+	}
+	for _, statement := range []string{
+		"UPDATE audit_log SET hash = 'def' WHERE seq = 1",
+		"DELETE FROM audit_log WHERE seq = 1",
+	} {
+		_, err = testDB.Exec(statement)
+		if err == nil || !strings.Contains(err.Error(), "append-only") {
+			t.Errorf("Expected %q to be refused, got %v", statement, err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Supported database drivers.
+const (
+	SQLite   = "sqlite3"
+	Postgres = "postgres"
+)
+
+// Driver is the driver of the open database. Queries are written with SQLite's
+// "?" placeholders and translated with Rebind when running against PostgreSQL.
+var Driver = SQLite
+
+// Rebind converts the "?" placeholders of query into the placeholder style of
+// the configured driver. Question marks inside quoted string literals are kept.
+func Rebind(query string) string {
+	if Driver != Postgres {
+		return query
+	}
+	return rebindPostgres(query)
+}
+
+// rebindPostgres numbers the "?" placeholders of query as PostgreSQL's $1, $2, ...
+func rebindPostgres(query string) string {
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n := 0
+	quoted := false
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case r == '?' && !quoted:
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Timestamp wraps a timestamp column or placeholder so that it compares and
+// sorts chronologically. SQLite stores timestamps as text in varying formats,
+// so they are normalized with julianday; PostgreSQL compares them natively.
+func Timestamp(expr string) string {
+	if Driver == Postgres {
+		return expr
+	}
+	return "julianday(" + expr + ")"
+}
+
+// Date returns the UTC calendar day of a timestamp column as YYYY-MM-DD text, for
+// grouping rows by day.
+func Date(expr string) string {
+	if Driver == Postgres {
+		return "to_char(" + expr + " AT TIME ZONE 'UTC', 'YYYY-MM-DD')"
+	}
+	return "date(" + expr + ")"
+}
+
+// Month returns the UTC calendar month of a timestamp column as YYYY-MM text, for
+// grouping rows by month.
+func Month(expr string) string {
+	if Driver == Postgres {
+		return "to_char(" + expr + " AT TIME ZONE 'UTC', 'YYYY-MM')"
+	}
+	return "strftime('%Y-%m', " + expr + ")"
+}
+
+// Contains returns a condition matching rows whose column contains the text
+// bound to a "?" placeholder, ignoring case.
+func Contains(column string) string {
+	if Driver == Postgres {
+		return "strpos(lower(" + column + "), lower(?)) > 0"
+	}
+	return "instr(lower(" + column + "), lower(?)) > 0"
+}
+
+// ForUpdate returns the locking clause for a SELECT that reads a row the
+// transaction is about to base writes on. SQLite transactions already hold the
+// write lock, so only PostgreSQL needs the row locked explicitly.
+func ForUpdate() string {
+	if Driver == Postgres {
+		return " FOR UPDATE"
+	}
+	return ""
+}
+
+// LockTable returns a statement that blocks other writers to table until the
+// transaction ends, for appends that must be serialized such as a hash chain.
+// SQLite transactions already hold the write lock, so it is empty there.
+func LockTable(table string) string {
+	if Driver == Postgres {
+		return "LOCK TABLE " + table + " IN EXCLUSIVE MODE"
+	}
+	return ""
+}
@@ -0,0 +1,60 @@
+package db
+
+import "testing"
+
+// usePostgres switches the dialect helpers to PostgreSQL for the duration of a test
+func usePostgres(t *testing.T) {
+	originalDriver := Driver
+	Driver = Postgres
+	t.Cleanup(func() { Driver = originalDriver })
+}
+
+// TestRebind tests placeholder translation for both drivers
+func TestRebind(t *testing.T) {
+	query := "SELECT * FROM events WHERE id=? AND name != '?' AND location=?"
+
+	if got := Rebind(query); got != query {
+		t.Errorf("Expected SQLite query to be unchanged, got %s", got)
+	}
+
+	usePostgres(t)
+	expected := "SELECT * FROM events WHERE id=$1 AND name != '?' AND location=$2"
+	if got := Rebind(query); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+
+	values := "INSERT INTO t VALUES (?,?,?,?,?,?,?,?,?,?,?)"
+	expected = "INSERT INTO t VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)"
+	if got := Rebind(values); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
+
+// TestDialectHelpers tests the dialect specific SQL fragments
+func TestDialectHelpers(t *testing.T) {
+	tests := []struct {
+		name     string
+		sqlite   string
+		postgres string
+		build    func() string
+	}{
+		{"timestamp", "julianday(datetime)", "datetime", func() string { return Timestamp("datetime") }},
+		{"date", "date(created_at)", "to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD')", func() string { return Date("created_at") }},
+		{"month", "strftime('%Y-%m', created_at)", "to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM')", func() string { return Month("created_at") }},
+		{"contains", "instr(lower(location), lower(?)) > 0", "strpos(lower(location), lower(?)) > 0", func() string { return Contains("location") }},
+		{"for update", "", " FOR UPDATE", ForUpdate},
+		{"ddl", "created_at DATETIME", "created_at TIMESTAMPTZ", func() string { return ddl("created_at DATETIME") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.build(); got != tt.sqlite {
+				t.Errorf("Expected SQLite %q, got %q", tt.sqlite, got)
+			}
+			usePostgres(t)
+			if got := tt.build(); got != tt.postgres {
+				t.Errorf("Expected PostgreSQL %q, got %q", tt.postgres, got)
+			}
+		})
+	}
+}
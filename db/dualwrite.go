@@ -0,0 +1,308 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"event_booking_restapi_golang/logging"
+	"event_booking_restapi_golang/metrics"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// DualWriteDSN is the connection string of the PostgreSQL database that writes to the
+// SQLite database are mirrored to while a deployment moves off SQLite, empty for none.
+// It is set by main from DB_MIGRATION=dual-write and DB_MIGRATION_DSN.
+var DualWriteDSN string
+
+var dualWriteErrors = metrics.NewCounterVec("db_dual_write_errors_total",
+	"Writes that couldn't be mirrored to the dual-write database, by operation.", "operation")
+
+// postgresCompatFunctions define the SQLite functions the dialect helpers emit for
+// SQLite, so that statements mirrored from it run on PostgreSQL too.
+var postgresCompatFunctions = []string{
+	"CREATE OR REPLACE FUNCTION julianday(timestamptz) RETURNS double precision AS " +
+		"'SELECT (extract(epoch FROM $1) / 86400 + 2440587.5)::double precision' LANGUAGE SQL IMMUTABLE",
+	"CREATE OR REPLACE FUNCTION instr(text, text) RETURNS integer AS 'SELECT strpos($1, $2)' LANGUAGE SQL IMMUTABLE",
+}
+
+// openDualWriteTarget opens the PostgreSQL database writes are mirrored to and sets
+// up its schema as InitDB does, along with the functions mirrored statements call.
+func openDualWriteTarget(dsn string) (*sql.DB, error) {
+	Driver = Postgres
+	defer func() { Driver = SQLite }()
+
+	var err error
+	DB, err = openInstrumented(Postgres, dsn)
+	if err != nil {
+		return nil, err
+	}
+	err = migrate()
+	if err != nil {
+		DB.Close()
+		return nil, fmt.Errorf("dual-write database: %w", err)
+	}
+	for _, statement := range postgresCompatFunctions {
+		_, err = DB.Exec(statement)
+		if err != nil {
+			DB.Close()
+			return nil, fmt.Errorf("dual-write database: %w", err)
+		}
+	}
+	return DB, nil
+}
+
+// openDualWrite opens the SQLite database like openInstrumented, mirroring the inserts,
+// updates and deletes that succeed on it to target.
+func openDualWrite(dsn string, target *sql.DB) (*sql.DB, error) {
+	connector, err := openConnector(SQLite, dsn)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(instrumentedConnector{dualWriteConnector{Connector: connector, target: target}}), nil
+}
+
+// dualWriteConnector hands out connections that mirror their writes to target.
+type dualWriteConnector struct {
+	driver.Connector
+	target *sql.DB
+}
+
+func (c dualWriteConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &dualWriteConn{Conn: conn, target: c.target}, nil
+}
+
+// dualWriteConn runs statements on the primary connection and repeats the writes that
+// succeed on the target database, with the placeholders rebound for PostgreSQL. Writes
+// in a transaction are repeated in a target transaction committed after the primary
+// one. The primary database stays the source of truth: writes the target refuses are
+// logged and counted in db_dual_write_errors_total, and a transaction with such a write
+// isn't committed on the target. The backfill reconciles them.
+type dualWriteConn struct {
+	driver.Conn
+	target *sql.DB
+	tx     *dualWriteTx // Transaction open on the connection, nil outside of one
+}
+
+// mirror repeats a statement that succeeded on the primary connection on the target
+// database, when it is an insert, update or delete, or within a transaction, a
+// savepoint statement of a nested transaction (see BeginTx), so that the writes a
+// nested transaction rolled back are undone on the target too.
+func (c *dualWriteConn) mirror(ctx context.Context, query string, args []driver.NamedValue) {
+	operation := queryOperation(query)
+	if operation == "other" && c.tx != nil && isSavepointStatement(query) {
+		operation = "savepoint"
+	}
+	if operation != "insert" && operation != "update" && operation != "delete" && operation != "savepoint" {
+		return
+	}
+	values := make([]any, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	// The primary write went through, so it is mirrored even if the request is cancelled
+	ctx = context.WithoutCancel(ctx)
+	query = rebindPostgres(query)
+
+	var err error
+	if tx := c.tx; tx != nil {
+		if tx.failed {
+			return
+		}
+		if tx.target == nil {
+			tx.target, err = c.target.BeginTx(ctx, nil)
+		}
+		if err == nil {
+			_, err = tx.target.ExecContext(ctx, query, values...)
+		}
+		tx.failed = err != nil
+	} else {
+		_, err = c.target.ExecContext(ctx, query, values...)
+	}
+	if err != nil {
+		dualWriteErrors.Inc(operation)
+		logging.FromContext(ctx).Error("Couldn't mirror a write to the dual-write database", "operation", operation, "error", err)
+	}
+}
+
+// isSavepointStatement reports whether the query creates, releases or rolls back to a savepoint.
+func isSavepointStatement(query string) bool {
+	query = strings.ToUpper(strings.TrimSpace(query))
+	return strings.HasPrefix(query, "SAVEPOINT ") || strings.HasPrefix(query, "RELEASE SAVEPOINT ") ||
+		strings.HasPrefix(query, "ROLLBACK TO SAVEPOINT ")
+}
+
+func (c *dualWriteConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	result, err := execer.ExecContext(ctx, query, args)
+	if err == nil {
+		c.mirror(ctx, query, args)
+	}
+	return result, err
+}
+
+func (c *dualWriteConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err == nil {
+		c.mirror(ctx, query, args)
+	}
+	return rows, err
+}
+
+func (c *dualWriteConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &dualWriteStmt{Stmt: stmt, conn: c, query: query}, nil
+}
+
+func (c *dualWriteConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *dualWriteConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var tx driver.Tx
+	var err error
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err = beginner.BeginTx(ctx, opts)
+	} else {
+		tx, err = c.Conn.Begin() //nolint:staticcheck // Fallback for drivers without BeginTx
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.tx = &dualWriteTx{Tx: tx, conn: c}
+	return c.tx, nil
+}
+
+func (c *dualWriteConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *dualWriteConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *dualWriteConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *dualWriteConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+// dualWriteTx is a primary transaction with the target transaction its writes are
+// mirrored in, begun with the first write.
+type dualWriteTx struct {
+	driver.Tx
+	conn   *dualWriteConn
+	target *sql.Tx
+	failed bool // Whether a write couldn't be mirrored, so the target transaction is rolled back
+}
+
+func (t *dualWriteTx) Commit() error {
+	t.conn.tx = nil
+	err := t.Tx.Commit()
+	if t.target == nil {
+		return err
+	}
+	if err != nil || t.failed {
+		t.target.Rollback()
+		return err
+	}
+	if targetErr := t.target.Commit(); targetErr != nil {
+		dualWriteErrors.Inc("commit")
+		slog.Error("Couldn't commit the mirrored writes to the dual-write database", "error", targetErr)
+	}
+	return nil
+}
+
+func (t *dualWriteTx) Rollback() error {
+	t.conn.tx = nil
+	if t.target != nil {
+		t.target.Rollback()
+	}
+	return t.Tx.Rollback()
+}
+
+// dualWriteStmt mirrors the writes of prepared statements, which database/sql uses
+// when a driver can't run a query directly.
+type dualWriteStmt struct {
+	driver.Stmt
+	conn  *dualWriteConn
+	query string
+}
+
+func (s *dualWriteStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	var result driver.Result
+	var err error
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else {
+		var values []driver.Value
+		values, err = namedValues(args)
+		if err == nil {
+			result, err = s.Stmt.Exec(values) //nolint:staticcheck // Fallback for drivers without StmtExecContext
+		}
+	}
+	if err == nil {
+		s.conn.mirror(ctx, s.query, args)
+	}
+	return result, err
+}
+
+func (s *dualWriteStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	var rows driver.Rows
+	var err error
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		values, err = namedValues(args)
+		if err == nil {
+			rows, err = s.Stmt.Query(values) //nolint:staticcheck // Fallback for drivers without StmtQueryContext
+		}
+	}
+	if err == nil {
+		s.conn.mirror(ctx, s.query, args)
+	}
+	return rows, err
+}
+
+func (s *dualWriteStmt) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
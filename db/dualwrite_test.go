@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+// countRows counts the rows of the table
+func countRows(t *testing.T, conn *sql.DB, table string) int {
+	t.Helper()
+	var n int
+	if err := conn.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n); err != nil {
+		t.Fatalf("Failed to count %s: %v", table, err)
+	}
+	return n
+}
+
+// TestDualWrite tests that writes to the primary database are mirrored to the target,
+// inside transactions only once they commit, and that writes the target refuses don't
+// fail the primary
+func TestDualWrite(t *testing.T) {
+	ctx := context.Background()
+	target, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "target.sql"))
+	if err != nil {
+		t.Fatalf("Failed to open target: %v", err)
+	}
+	defer target.Close()
+	primary, err := openDualWrite(filepath.Join(t.TempDir(), "primary.sql"), target)
+	if err != nil {
+		t.Fatalf("Failed to open primary: %v", err)
+	}
+	defer primary.Close()
+	for _, conn := range []*sql.DB{primary, target} {
+		mustExec(t, conn, "CREATE TABLE notes (id TEXT PRIMARY KEY, body TEXT NOT NULL)")
+	}
+	mustExec(t, primary, "CREATE TABLE drafts (id TEXT PRIMARY KEY)")
+
+	mustExec(t, primary, "INSERT INTO notes (id, body) VALUES (?, ?)", "n1", "first")
+	mustExec(t, primary, "UPDATE notes SET body = ? WHERE id = ?", "edited", "n1")
+	var body string
+	if err := target.QueryRow("SELECT body FROM notes WHERE id = 'n1'").Scan(&body); err != nil || body != "edited" {
+		t.Errorf("Expected the insert and update mirrored, got %q (%v)", body, err)
+	}
+
+	tx, err := primary.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("Failed to begin: %v", err)
+	}
+	if _, err := tx.Exec("INSERT INTO notes (id, body) VALUES (?, ?)", "n2", "second"); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+	if n := countRows(t, target, "notes"); n != 1 {
+		t.Errorf("Expected the write held back until commit, got %d notes", n)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	if n := countRows(t, target, "notes"); n != 2 {
+		t.Errorf("Expected the committed write mirrored, got %d notes", n)
+	}
+
+	tx, err = primary.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("Failed to begin: %v", err)
+	}
+	tx.Exec("DELETE FROM notes WHERE id = ?", "n1")
+	tx.Rollback()
+	if countRows(t, primary, "notes") != 2 || countRows(t, target, "notes") != 2 {
+		t.Error("Expected the rolled back delete on neither database")
+	}
+
+	// The target has no drafts table, so the transaction is only committed on the primary
+	tx, err = primary.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("Failed to begin: %v", err)
+	}
+	tx.Exec("INSERT INTO drafts (id) VALUES (?)", "d1")
+	tx.Exec("DELETE FROM notes WHERE id = ?", "n2")
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Expected the primary to commit despite the target, got %v", err)
+	}
+	if countRows(t, primary, "drafts") != 1 || countRows(t, primary, "notes") != 1 || countRows(t, target, "notes") != 2 {
+		t.Error("Expected the failed transaction committed on the primary only")
+	}
+
+	// Nested transactions are savepoints, whose rollbacks are repeated on the target
+	tx, err = primary.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("Failed to begin: %v", err)
+	}
+	for _, statement := range []string{"SAVEPOINT sp_1", "INSERT INTO notes (id, body) VALUES ('n3', 'undone')",
+		"ROLLBACK TO SAVEPOINT sp_1", "RELEASE SAVEPOINT sp_1", "INSERT INTO notes (id, body) VALUES ('n4', 'kept')"} {
+		if _, err := tx.Exec(statement); err != nil {
+			t.Fatalf("Failed to run %q: %v", statement, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	var kept, undone int
+	target.QueryRow("SELECT COUNT(*) FROM notes WHERE id = 'n4'").Scan(&kept)
+	target.QueryRow("SELECT COUNT(*) FROM notes WHERE id = 'n3'").Scan(&undone)
+	if kept != 1 || undone != 0 {
+		t.Errorf("Expected only the write kept by the savepoint mirrored, got %d kept and %d undone", kept, undone)
+	}
+}
@@ -0,0 +1,239 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"event_booking_restapi_golang/metrics"
+	"strings"
+	"time"
+)
+
+var (
+	queryDuration = metrics.NewHistogramVec("db_query_duration_seconds",
+		"Time taken by database statements, by operation.", metrics.DefaultBuckets, "operation")
+	queryErrors = metrics.NewCounterVec("db_query_errors_total",
+		"Database statements that failed, by operation.", "operation")
+)
+
+// openInstrumented opens the database like sql.Open, but through a connector that
+// times every statement for the db_query_duration_seconds metric.
+func openInstrumented(driverName, dsn string) (*sql.DB, error) {
+	connector, err := openConnector(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(instrumentedConnector{connector}), nil
+}
+
+// openConnector returns a connector for the database of the registered driver.
+func openConnector(driverName, dsn string) (driver.Connector, error) {
+	// sql.Open doesn't connect, it only looks up the registered driver
+	probe, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	d := probe.Driver()
+	probe.Close()
+
+	if dc, ok := d.(driver.DriverContext); ok {
+		return dc.OpenConnector(dsn)
+	}
+	return dsnConnector{dsn: dsn, driver: d}, nil
+}
+
+// queryOperation returns the metric label of a statement: its leading keyword when
+// it is select, insert, update or delete, and "other" for schema and locking statements.
+func queryOperation(query string) string {
+	keyword, _, _ := strings.Cut(strings.TrimLeft(query, " \t\r\n("), " ")
+	switch keyword = strings.ToLower(strings.TrimSpace(keyword)); keyword {
+	case "select", "insert", "update", "delete":
+		return keyword
+	default:
+		return "other"
+	}
+}
+
+// observeQuery records how long a statement took and whether it failed.
+func observeQuery(query string, start time.Time, err error) {
+	operation := queryOperation(query)
+	queryDuration.Observe(time.Since(start).Seconds(), operation)
+	if err != nil && !errors.Is(err, driver.ErrSkip) {
+		queryErrors.Inc(operation)
+	}
+}
+
+// dsnConnector adapts drivers that don't implement driver.DriverContext.
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// instrumentedConnector hands out instrumented connections.
+type instrumentedConnector struct {
+	driver.Connector
+}
+
+func (c instrumentedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedConn{conn}, nil
+}
+
+// instrumentedConn times the statements run on a connection. Optional driver
+// interfaces are passed through, returning driver.ErrSkip where the wrapped
+// connection doesn't implement them so database/sql falls back as it would without
+// the wrapper.
+type instrumentedConn struct {
+	driver.Conn
+}
+
+func (c *instrumentedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	if !errors.Is(err, driver.ErrSkip) {
+		observeQuery(query, start, err)
+	}
+	return result, err
+}
+
+func (c *instrumentedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if !errors.Is(err, driver.ErrSkip) {
+		observeQuery(query, start, err)
+	}
+	return rows, err
+}
+
+func (c *instrumentedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedStmt{Stmt: stmt, query: query}, nil
+}
+
+func (c *instrumentedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *instrumentedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin() //nolint:staticcheck // Fallback for drivers without BeginTx
+}
+
+func (c *instrumentedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *instrumentedConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+// instrumentedStmt times prepared statements, which database/sql uses when a
+// driver can't run a query directly.
+type instrumentedStmt struct {
+	driver.Stmt
+	query string
+}
+
+func (s *instrumentedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var result driver.Result
+	var err error
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else {
+		var values []driver.Value
+		values, err = namedValues(args)
+		if err == nil {
+			result, err = s.Stmt.Exec(values) //nolint:staticcheck // Fallback for drivers without StmtExecContext
+		}
+	}
+	observeQuery(s.query, start, err)
+	return result, err
+}
+
+func (s *instrumentedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var rows driver.Rows
+	var err error
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		values, err = namedValues(args)
+		if err == nil {
+			rows, err = s.Stmt.Query(values) //nolint:staticcheck // Fallback for drivers without StmtQueryContext
+		}
+	}
+	observeQuery(s.query, start, err)
+	return rows, err
+}
+
+func (s *instrumentedStmt) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+// namedValues converts positional arguments for drivers that only take plain values.
+func namedValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("db: driver doesn't support named parameters")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
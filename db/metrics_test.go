@@ -0,0 +1,85 @@
+package db
+
+import (
+	"event_booking_restapi_golang/metrics"
+	"strings"
+	"testing"
+)
+
+// TestQueryOperation tests the operation label derived from statements
+func TestQueryOperation(t *testing.T) {
+	tests := map[string]string{
+		"SELECT * FROM events":                   "select",
+		"\n\t\tinsert INTO events VALUES (?)":    "insert",
+		"UPDATE events SET name = ?":             "update",
+		"DELETE FROM events":                     "delete",
+		"(SELECT 1) UNION (SELECT 2)":            "select",
+		"CREATE TABLE IF NOT EXISTS events (id)": "other",
+		"LOCK TABLE events IN EXCLUSIVE MODE":    "other",
+		"":                                       "other",
+	}
+	for query, expected := range tests {
+		if operation := queryOperation(query); operation != expected {
+			t.Errorf("queryOperation(%q) = %q, expected %q", query, operation, expected)
+		}
+	}
+}
+
+// TestOpenInstrumented tests that statements run through the pool are timed and failures counted
+func TestOpenInstrumented(t *testing.T) {
+	testDB, err := openInstrumented("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer testDB.Close()
+	testDB.SetMaxOpenConns(1)
+
+	_, err = testDB.Exec("CREATE TABLE events (id TEXT PRIMARY KEY)")
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	_, err = testDB.Exec("INSERT INTO events (id) VALUES (?)", "e1")
+	if err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+
+	tx, err := testDB.Begin()
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	var count int
+	err = tx.QueryRow("SELECT COUNT(*) FROM events").Scan(&count)
+	tx.Commit()
+	if err != nil || count != 1 {
+		t.Fatalf("Expected 1 event in the transaction, got %d (%v)", count, err)
+	}
+
+	stmt, err := testDB.Prepare("DELETE FROM events WHERE id = ?")
+	if err != nil {
+		t.Fatalf("Failed to prepare: %v", err)
+	}
+	_, err = stmt.Exec("e1")
+	stmt.Close()
+	if err != nil {
+		t.Fatalf("Failed to run prepared statement: %v", err)
+	}
+
+	_, err = testDB.Query("SELECT * FROM missing")
+	if err == nil {
+		t.Fatal("Expected an error selecting from a missing table")
+	}
+
+	var out strings.Builder
+	metrics.Default.WriteText(&out)
+	for _, expected := range []string{
+		`db_query_duration_seconds_count{operation="insert"}`,
+		`db_query_duration_seconds_count{operation="select"}`,
+		`db_query_duration_seconds_count{operation="delete"}`,
+		`db_query_duration_seconds_count{operation="other"}`,
+		`db_query_errors_total{operation="select"}`,
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected %s in the metrics, got:\n%s", expected, out.String())
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+)
+
+// SchemaVersion is the version of the schema createTables sets up. Bump it whenever
+// createTables changes the schema, such as adding a table or a column, so that older
+// builds refuse the migrated database instead of running against it.
+// Databases migrated before versions were recorded count as version 0.
+const SchemaVersion = 5
+
+// AutoMigrate is whether InitDB migrates databases with an older schema version. When
+// false they are refused with a SchemaMismatchError. Empty databases are always set up.
+// It is set by main from DB_MIGRATE.
+var AutoMigrate = true
+
+// SchemaMismatchError reports that the database's schema version isn't the one this
+// build expects, with what to do about it.
+type SchemaMismatchError struct {
+	Database int // Schema version recorded in the database
+	Build    int // Schema version this build expects, SchemaVersion
+}
+
+// Newer reports whether the database was migrated by a newer build. Migrations only
+// add tables and columns, so this build can still read it.
+func (e *SchemaMismatchError) Newer() bool {
+	return e.Database > e.Build
+}
+
+func (e *SchemaMismatchError) Error() string {
+	if e.Newer() {
+		return fmt.Sprintf("database schema version %d is newer than version %d of this build, it was migrated by a newer release: "+
+			"deploy that release again, or restore the backup taken before the upgrade. "+
+			"DB_SCHEMA_MISMATCH=read-only serves the public reads meanwhile", e.Database, e.Build)
+	}
+	return fmt.Sprintf("database schema version %d is older than version %d of this build: "+
+		"back up the database, then start this build once with DB_MIGRATE=auto to migrate it", e.Database, e.Build)
+}
+
+// migrate brings the schema up to SchemaVersion, recording the version it migrated to.
+// Returns a SchemaMismatchError, without changing the database, when its schema is
+// newer, or older while AutoMigrate is off.
+func migrate() error {
+	version, empty, err := storedSchemaVersion()
+	if err != nil {
+		return err
+	}
+	if version > SchemaVersion || (version < SchemaVersion && !empty && !AutoMigrate) {
+		return &SchemaMismatchError{Database: version, Build: SchemaVersion}
+	}
+	if version < SchemaVersion && !empty {
+		slog.Info("Migrating the database schema", "from", version, "to", SchemaVersion)
+	}
+
+	createTables()
+	_, err = DB.Exec(Rebind("INSERT INTO schema_version (version, migrated_at) VALUES (?, CURRENT_TIMESTAMP) ON CONFLICT (version) DO NOTHING"), SchemaVersion)
+	return err
+}
+
+// storedSchemaVersion returns the schema version recorded in the database, and whether
+// the database is empty, with none of the application's tables.
+func storedSchemaVersion() (int, bool, error) {
+	var version sql.NullInt64
+	err := DB.QueryRow("SELECT MAX(version) FROM schema_version").Scan(&version)
+	if err == nil {
+		return int(version.Int64), false, nil
+	}
+	if !tableMissing("schema_version") {
+		return 0, false, err
+	}
+	if tableMissing("events") {
+		return 0, true, nil
+	}
+	return 0, false, nil
+}
+
+// tableMissing reports whether the table can't be read, as when it doesn't exist.
+func tableMissing(table string) bool {
+	rows, err := DB.Query("SELECT 1 FROM " + table + " LIMIT 0")
+	if err != nil {
+		return true
+	}
+	rows.Close()
+	return false
+}
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// useSchemaTestDB points DB at a new in-memory database for the test
+func useSchemaTestDB(t *testing.T) *sql.DB {
+	testDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	testDB.SetMaxOpenConns(1)
+	originalDB, originalMigrate := DB, AutoMigrate
+	DB = testDB
+	t.Cleanup(func() {
+		DB, AutoMigrate = originalDB, originalMigrate
+		testDB.Close()
+	})
+	return testDB
+}
+
+// TestMigrateEmpty tests that empty databases are set up and stamped with the schema
+// version even when migrations are off
+func TestMigrateEmpty(t *testing.T) {
+	testDB := useSchemaTestDB(t)
+	AutoMigrate = false
+
+	for i := 0; i < 2; i++ {
+		if err := migrate(); err != nil {
+			t.Fatalf("Failed to set up an empty database: %v", err)
+		}
+	}
+	var version int
+	err := testDB.QueryRow("SELECT MAX(version) FROM schema_version").Scan(&version)
+	if err != nil || version != SchemaVersion {
+		t.Errorf("Expected schema version %d, got %d (%v)", SchemaVersion, version, err)
+	}
+}
+
+// TestMigrateOlder tests that databases from before the schema version was recorded
+// are refused untouched while migrations are off, and migrated otherwise
+func TestMigrateOlder(t *testing.T) {
+	testDB := useSchemaTestDB(t)
+	_, err := testDB.Exec("CREATE TABLE events (id TEXT PRIMARY KEY, name TEXT NOT NULL, description TEXT NOT NULL, location TEXT NOT NULL, datetime DATETIME NOT NULL, user_id TEXT)")
+	if err != nil {
+		t.Fatalf("Failed to create events table: %v", err)
+	}
+	_, err = testDB.Exec("INSERT INTO events (id, name, description, location, datetime, user_id) VALUES ('event-1', 'Meetup', 'Talks', 'Hall', CURRENT_TIMESTAMP, 'user-1')")
+	if err != nil {
+		t.Fatalf("Failed to insert event: %v", err)
+	}
+
+	AutoMigrate = false
+	err = migrate()
+	var mismatch *SchemaMismatchError
+	if !errors.As(err, &mismatch) || mismatch.Database != 0 || mismatch.Newer() || !strings.Contains(err.Error(), "DB_MIGRATE=auto") {
+		t.Fatalf("Expected the older database to be refused, got %v", err)
+	}
+	if !tableMissing("registrations") {
+		t.Error("Expected the refused database to be left unchanged")
+	}
+
+	AutoMigrate = true
+	if err := migrate(); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if version, _, err := storedSchemaVersion(); err != nil || version != SchemaVersion {
+		t.Errorf("Expected schema version %d after migrating, got %d (%v)", SchemaVersion, version, err)
+	}
+	// The event starts its history, and its version number, with the state at the upgrade
+	var eventVersion, historyVersion int
+	err = testDB.QueryRow("SELECT events.version, event_versions.version FROM events JOIN event_versions ON event_versions.event_id = events.id").
+		Scan(&eventVersion, &historyVersion)
+	if err != nil || eventVersion != 1 || historyVersion != 1 {
+		t.Errorf("Expected the event at version 1, got %d and %d in the history (%v)", eventVersion, historyVersion, err)
+	}
+}
+
+// TestMigrateNewer tests that databases migrated by a newer build are refused untouched
+func TestMigrateNewer(t *testing.T) {
+	testDB := useSchemaTestDB(t)
+	if err := migrate(); err != nil {
+		t.Fatalf("Failed to set up the database: %v", err)
+	}
+	_, err := testDB.Exec("INSERT INTO schema_version (version, migrated_at) VALUES (?, CURRENT_TIMESTAMP)", SchemaVersion+1)
+	if err != nil {
+		t.Fatalf("Failed to record a newer version: %v", err)
+	}
+
+	err = migrate()
+	var mismatch *SchemaMismatchError
+	if !errors.As(err, &mismatch) || !mismatch.Newer() || mismatch.Database != SchemaVersion+1 || mismatch.Build != SchemaVersion {
+		t.Errorf("Expected the newer database to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// Querier runs queries, on the database or within a transaction. It is implemented by
+// *sql.DB, *sql.Tx and *Tx.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// requestTx is the transaction a context carries, see WithTx.
+type requestTx struct {
+	tx   *sql.Tx
+	mu   sync.Mutex // Held while the transaction ends
+	done bool
+}
+
+// txKey is the context key of the carried transaction.
+type txKey struct{}
+
+// savepoints numbers the savepoints of nested transactions, so that their names never clash.
+var savepoints atomic.Uint64
+
+// WithTx returns a context carrying tx, so that the queries made with it through Conn
+// and the transactions begun with it through BeginTx run within tx. That makes all the
+// changes of a request one transaction, which the caller commits or rolls back with the
+// returned function once the request is handled; after that the context's queries run
+// on DB again.
+func WithTx(ctx context.Context, tx *sql.Tx) (context.Context, func(commit bool) error) {
+	carried := &requestTx{tx: tx}
+	end := func(commit bool) error {
+		carried.mu.Lock()
+		defer carried.mu.Unlock()
+		carried.done = true
+		if commit {
+			return tx.Commit()
+		}
+		return tx.Rollback()
+	}
+	return context.WithValue(ctx, txKey{}, carried), end
+}
+
+// WithoutTx returns a context that doesn't carry the transaction of ctx, for work that
+// outlives the request, such as sending emails in the background.
+func WithoutTx(ctx context.Context) context.Context {
+	return context.WithValue(ctx, txKey{}, (*requestTx)(nil))
+}
+
+// carriedTx returns the transaction the context carries, or nil when it carries none
+// or it has ended.
+func carriedTx(ctx context.Context) *sql.Tx {
+	carried, _ := ctx.Value(txKey{}).(*requestTx)
+	if carried == nil {
+		return nil
+	}
+	carried.mu.Lock()
+	defer carried.mu.Unlock()
+	if carried.done {
+		return nil
+	}
+	return carried.tx
+}
+
+// Conn returns what queries made with ctx run on: the transaction it carries, if any,
+// otherwise DB.
+func Conn(ctx context.Context) Querier {
+	if tx := carriedTx(ctx); tx != nil {
+		return tx
+	}
+	return DB
+}
+
+// Tx is a transaction begun with BeginTx. Within a transaction carried by the context
+// it is a savepoint of that transaction: committing it releases the savepoint, keeping
+// its changes for the carried transaction to commit, and rolling it back undoes only
+// its own changes.
+type Tx struct {
+	*sql.Tx
+	savepoint string // Empty for transactions of their own
+	done      bool
+}
+
+// BeginTx begins a transaction on DB, or a savepoint within the transaction the context
+// carries. The options only apply to transactions of their own.
+func BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	if tx := carriedTx(ctx); tx != nil {
+		savepoint := "sp_" + strconv.FormatUint(savepoints.Add(1), 10)
+		_, err := tx.ExecContext(ctx, "SAVEPOINT "+savepoint)
+		if err != nil {
+			return nil, err
+		}
+		return &Tx{Tx: tx, savepoint: savepoint}, nil
+	}
+	tx, err := DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx}, nil
+}
+
+// Commit commits the transaction, or releases the savepoint.
+func (t *Tx) Commit() error {
+	if t.savepoint == "" {
+		return t.Tx.Commit()
+	}
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	_, err := t.Tx.Exec("RELEASE SAVEPOINT " + t.savepoint)
+	return err
+}
+
+// Rollback rolls the transaction back, or undoes the changes made since the savepoint.
+// Like sql.Tx.Rollback, it returns sql.ErrTxDone once the transaction is committed, so
+// it can be deferred.
+func (t *Tx) Rollback() error {
+	if t.savepoint == "" {
+		return t.Tx.Rollback()
+	}
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	_, err := t.Tx.Exec("ROLLBACK TO SAVEPOINT " + t.savepoint)
+	if err != nil {
+		return err
+	}
+	_, err = t.Tx.Exec("RELEASE SAVEPOINT " + t.savepoint)
+	return err
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+// TestRequestTx tests that a context carrying a transaction runs its queries and the
+// transactions begun with it within that transaction, as savepoints that roll back on
+// their own
+func TestRequestTx(t *testing.T) {
+	setupBatchDB(t)
+	outer, err := DB.Begin()
+	if err != nil {
+		t.Fatalf("Failed to begin: %v", err)
+	}
+	ctx, end := WithTx(context.Background(), outer)
+	if Conn(ctx) != outer || Conn(WithoutTx(ctx)) != DB {
+		t.Fatal("Expected Conn to return the carried transaction, and DB without it")
+	}
+	if _, err := Conn(ctx).ExecContext(ctx, "INSERT INTO counters (name, count) VALUES ('direct', 1)"); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+
+	kept, err := BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("Failed to begin the savepoint: %v", err)
+	}
+	kept.ExecContext(ctx, "INSERT INTO counters (name, count) VALUES ('kept', 1)")
+	if err := kept.Commit(); err != nil {
+		t.Fatalf("Failed to release the savepoint: %v", err)
+	}
+	if err := kept.Rollback(); !errors.Is(err, sql.ErrTxDone) {
+		t.Errorf("Expected a rollback after the commit to be ErrTxDone, got %v", err)
+	}
+	undone, err := BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("Failed to begin the savepoint: %v", err)
+	}
+	undone.ExecContext(ctx, "INSERT INTO counters (name, count) VALUES ('undone', 1)")
+	if err := undone.Rollback(); err != nil {
+		t.Fatalf("Failed to roll back the savepoint: %v", err)
+	}
+
+	if err := end(true); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	if Conn(ctx) != DB {
+		t.Error("Expected queries to run on DB once the transaction ended")
+	}
+	if counter(t, "direct") != 1 || counter(t, "kept") != 1 || counter(t, "undone") != 0 {
+		t.Error("Expected the changes of the released savepoint only to be committed")
+	}
+
+	outer, _ = DB.Begin()
+	ctx, end = WithTx(context.Background(), outer)
+	inner, _ := BeginTx(ctx, nil)
+	inner.ExecContext(ctx, "INSERT INTO counters (name, count) VALUES ('rolled back', 1)")
+	inner.Commit()
+	end(false)
+	if counter(t, "rolled back") != 0 {
+		t.Error("Expected rolling back the carried transaction to undo its savepoints")
+	}
+}
@@ -0,0 +1,20 @@
+// Package docs holds the API's OpenAPI 3 specification and the Swagger UI page that
+// renders it. Both are embedded in the binary. The specification is maintained by hand
+// alongside the handlers, and a test in the routes package fails when a registered
+// route is missing from it.
+package docs
+
+import (
+	_ "embed"
+)
+
+// Spec is the OpenAPI 3 specification of the API in JSON.
+//
+//go:embed openapi.json
+var Spec []byte
+
+// SwaggerUI is an HTML page that loads Swagger UI and points it at the specification
+// served at /docs/openapi.json.
+//
+//go:embed swagger.html
+var SwaggerUI []byte
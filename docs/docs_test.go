@@ -0,0 +1,48 @@
+package docs
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestSpecReferencesResolve tests that the specification parses and every $ref points at a defined component
+func TestSpecReferencesResolve(t *testing.T) {
+	var spec map[string]any
+	if err := json.Unmarshal(Spec, &spec); err != nil {
+		t.Fatalf("Failed to parse the specification: %v", err)
+	}
+
+	var check func(node any)
+	check = func(node any) {
+		switch n := node.(type) {
+		case map[string]any:
+			if ref, ok := n["$ref"].(string); ok {
+				var target any = spec
+				for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+					m, _ := target.(map[string]any)
+					target = m[part]
+				}
+				if target == nil {
+					t.Errorf("Reference %s doesn't resolve", ref)
+				}
+			}
+			for _, v := range n {
+				check(v)
+			}
+		case []any:
+			for _, v := range n {
+				check(v)
+			}
+		}
+	}
+	check(spec)
+}
+
+// TestSwaggerUILoadsSpec tests that the Swagger UI page loads the served specification
+func TestSwaggerUILoadsSpec(t *testing.T) {
+	if !bytes.Contains(SwaggerUI, []byte(`url: "/docs/openapi.json"`)) {
+		t.Error("Expected Swagger UI to load /docs/openapi.json")
+	}
+}
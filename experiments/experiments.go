@@ -0,0 +1,66 @@
+// Package experiments assigns anonymous sessions to A/B test variants.
+// Sessions are bucketed deterministically, so a visitor keeps seeing the same
+// variant for as long as they present the same session ID.
+package experiments
+
+import (
+	"hash/fnv"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Variants a session can be assigned to.
+const (
+	Control   = "control"   // The current behaviour
+	Treatment = "treatment" // The alternative being evaluated
+)
+
+// RankingExperimentName identifies the event listing order experiment in exposure and conversion logs.
+const RankingExperimentName = "listing_ranking"
+
+// Experiment splits sessions between the control and treatment variants.
+type Experiment struct {
+	Name    string // Name the exposures and conversions are logged under
+	Percent int    // Share of sessions, from 0 to 100, that get the treatment
+}
+
+// Enabled reports whether any session gets the treatment.
+func (e Experiment) Enabled() bool {
+	return e.Percent > 0
+}
+
+// Variant returns the variant the session is assigned to. The assignment depends only
+// on the experiment name and the session ID, so it is stable across requests and
+// independent between experiments.
+func (e Experiment) Variant(sessionID string) string {
+	h := fnv.New32a()
+	h.Write([]byte(e.Name + ":" + sessionID))
+	if int(h.Sum32()%100) < e.Percent {
+		return Treatment
+	}
+	return Control
+}
+
+// Ranking is an alternative event listing order served to the treatment group.
+type Ranking struct {
+	Experiment
+	Sort  string // Listing sort field, as accepted by the sort query parameter
+	Order string // asc or desc
+}
+
+// RankingFromEnv builds the listing order experiment from RANKING_EXPERIMENT_PERCENT and
+// RANKING_EXPERIMENT_SORT, which holds the alternative order as "field" or "field:order"
+// using the fields of the sort query parameter (for example "created_at:desc").
+// The experiment is disabled when either is unset or the percentage isn't from 0 to 100.
+func RankingFromEnv() Ranking {
+	ranking := Ranking{Experiment: Experiment{Name: RankingExperimentName}}
+	percent, err := strconv.Atoi(os.Getenv("RANKING_EXPERIMENT_PERCENT"))
+	sort := strings.TrimSpace(os.Getenv("RANKING_EXPERIMENT_SORT"))
+	if err != nil || percent < 0 || percent > 100 || sort == "" {
+		return ranking
+	}
+	ranking.Percent = percent
+	ranking.Sort, ranking.Order, _ = strings.Cut(sort, ":")
+	return ranking
+}
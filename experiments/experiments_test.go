@@ -0,0 +1,48 @@
+package experiments
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestVariant tests that sessions are bucketed stably and according to the percentage
+func TestVariant(t *testing.T) {
+	for _, percent := range []int{0, 30, 100} {
+		e := Experiment{Name: "test", Percent: percent}
+		treated := 0
+		for i := 0; i < 1000; i++ {
+			session := fmt.Sprint("session-", i)
+			variant := e.Variant(session)
+			if variant != e.Variant(session) {
+				t.Fatalf("Expected a stable variant for %s", session)
+			}
+			if variant == Treatment {
+				treated++
+			}
+		}
+		if treated < percent*10-50 || treated > percent*10+50 {
+			t.Errorf("Expected about %d%% of sessions in the treatment, got %d of 1000", percent, treated)
+		}
+	}
+}
+
+// TestRankingFromEnv tests reading the listing order experiment from the environment
+func TestRankingFromEnv(t *testing.T) {
+	tests := []struct {
+		percent, sort string
+		expected      Ranking
+	}{
+		{"", "", Ranking{Experiment: Experiment{Name: RankingExperimentName}}},
+		{"20", "", Ranking{Experiment: Experiment{Name: RankingExperimentName}}},
+		{"150", "title", Ranking{Experiment: Experiment{Name: RankingExperimentName}}},
+		{"20", "created_at:desc", Ranking{Experiment: Experiment{Name: RankingExperimentName, Percent: 20}, Sort: "created_at", Order: "desc"}},
+		{"100", "title", Ranking{Experiment: Experiment{Name: RankingExperimentName, Percent: 100}, Sort: "title"}},
+	}
+	for _, tt := range tests {
+		t.Setenv("RANKING_EXPERIMENT_PERCENT", tt.percent)
+		t.Setenv("RANKING_EXPERIMENT_SORT", tt.sort)
+		if got := RankingFromEnv(); got != tt.expected {
+			t.Errorf("Expected %+v for %q/%q, got %+v", tt.expected, tt.percent, tt.sort, got)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+// Package feeds imports events from the XML feeds that municipal event calendars and
+// event portals publish. Each feed maps its own element names to event fields in the
+// configuration and is fetched on its own schedule; every run is recorded as a report
+// of the events it added, updated and skipped.
+package feeds
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"event_booking_restapi_golang/models"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// defaultInterval is how often feeds without an interval are imported.
+const defaultInterval = 6 * time.Hour
+
+// minInterval is the shortest allowed interval, so a typo can't hammer a feed.
+const minInterval = time.Minute
+
+// maxFeedSize bounds the feed documents read, in bytes.
+const maxFeedSize = 20 << 20
+
+// SourcePrefix starts the external source of imported events. The feed name follows,
+// so each feed's item IDs are unique on their own.
+const SourcePrefix = "feed:"
+
+// client fetches the feeds.
+var client = &http.Client{Timeout: 30 * time.Second}
+
+// Feed is a configured XML event feed.
+type Feed struct {
+	Name     string        // Unique name, used in run reports and the events' external source
+	URL      string        // Where the feed is fetched from
+	Interval time.Duration // How often the feed is imported
+	Mapping  Mapping       // Where the event fields are found in the feed
+}
+
+// feedConfig is a feed as written in the configuration file.
+type feedConfig struct {
+	Name     string  `json:"name"`
+	URL      string  `json:"url"`
+	Interval string  `json:"interval"`
+	Mapping  Mapping `json:"mapping"`
+}
+
+// FromEnv loads the feeds from the JSON file named by EVENT_FEEDS_FILE.
+// Returns no feeds when it isn't set, which disables feed imports.
+func FromEnv() ([]Feed, error) {
+	path := os.Getenv("EVENT_FEEDS_FILE")
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// Load reads a JSON array of feeds, each with a name, url, optional interval (a
+// duration such as "6h", the default) and mapping. Mapping fields left out take their
+// DefaultMapping value.
+// Returns an error naming the first invalid feed.
+func Load(r io.Reader) ([]Feed, error) {
+	var configs []feedConfig
+	err := json.NewDecoder(r).Decode(&configs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid feeds configuration: %w", err)
+	}
+
+	feeds := make([]Feed, 0, len(configs))
+	names := map[string]bool{}
+	for i, cfg := range configs {
+		if cfg.Name == "" {
+			return nil, fmt.Errorf("feed %d has no name", i+1)
+		}
+		if names[cfg.Name] {
+			return nil, fmt.Errorf("feed %q is configured twice", cfg.Name)
+		}
+		names[cfg.Name] = true
+		u, err := url.Parse(cfg.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("feed %q needs an http or https url", cfg.Name)
+		}
+		interval := defaultInterval
+		if cfg.Interval != "" {
+			interval, err = time.ParseDuration(cfg.Interval)
+			if err != nil || interval < minInterval {
+				return nil, fmt.Errorf("feed %q has an invalid interval %q, use a duration of at least 1m such as 6h", cfg.Name, cfg.Interval)
+			}
+		}
+		mapping := cfg.Mapping.withDefaults()
+		_, err = mapping.location()
+		if err != nil {
+			return nil, fmt.Errorf("feed %q has an unknown time zone %q", cfg.Name, mapping.TimeZone)
+		}
+		feeds = append(feeds, Feed{Name: cfg.Name, URL: cfg.URL, Interval: interval, Mapping: mapping})
+	}
+	return feeds, nil
+}
+
+// Find returns the feed with the given name.
+func Find(feeds []Feed, name string) (Feed, bool) {
+	for _, feed := range feeds {
+		if feed.Name == name {
+			return feed, true
+		}
+	}
+	return Feed{}, false
+}
+
+// Source is the external source the feed's events are stored under.
+func (f Feed) Source() string {
+	return SourcePrefix + f.Name
+}
+
+// organizerID is the user ID imported events are created with, derived from the feed
+// URL so its events share an organizer.
+func (f Feed) organizerID() string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(f.URL)).String()
+}
+
+// Import fetches the feed once, creates the events it doesn't know yet and updates
+// those that changed. Items without an ID, title, location or valid start are skipped
+// and listed in the report, as are unchanged ones without being listed. A feed that
+// can't be fetched or parsed, or a storage failure, ends the run with its Error set.
+// The report is stored and returned; the error is only set if storing it fails.
+// Under a models.WithDryRun context the events are left unchanged and the report is
+// returned without being stored.
+func Import(ctx context.Context, feed Feed) (models.FeedRun, error) {
+	run := models.FeedRun{Feed: feed.Name, StartedAt: time.Now()}
+	err := importItems(ctx, feed, &run)
+	if err != nil {
+		run.Error = err.Error()
+	}
+	run.FinishedAt = time.Now()
+	if models.IsDryRun(ctx) {
+		return run, nil
+	}
+	return run.Save(ctx)
+}
+
+// importItems imports the feed's items, counting them in run.
+func importItems(ctx context.Context, feed Feed, run *models.FeedRun) error {
+	items, err := fetch(ctx, feed)
+	if err != nil {
+		return err
+	}
+	loc, err := feed.Mapping.location()
+	if err != nil {
+		return err
+	}
+
+	for i, item := range items {
+		event, err := feed.Mapping.event(item, loc)
+		if err != nil {
+			run.AddSkip(i+1, event.ExternalID, err.Error())
+			continue
+		}
+		event.Source = feed.Source()
+
+		existing, err := models.GetEventByExternalID(ctx, event.Source, event.ExternalID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if err == nil {
+			if unchanged(existing, event, feed.Mapping.Capacity != "") {
+				run.Skipped++
+				continue
+			}
+			event.ID, event.UserID, event.CreatedAt = existing.ID, existing.UserID, existing.CreatedAt
+			event.ReviewStatus, event.ReviewReason = existing.ReviewStatus, existing.ReviewReason
+			event.ConfirmationURL, event.ConfirmationMessage = existing.ConfirmationURL, existing.ConfirmationMessage
+			event.License = existing.License
+			if feed.Mapping.Capacity == "" {
+				event.Capacity = existing.Capacity
+			}
+		} else {
+			event.UserID = feed.organizerID()
+			event.ReviewStatus = models.ReviewApproved
+		}
+
+		_, created, err := event.UpsertByExternalID(ctx)
+		if err != nil {
+			return err
+		}
+		if created {
+			run.Added++
+		} else {
+			run.Updated++
+		}
+	}
+	return nil
+}
+
+// unchanged reports whether the feed item matches the stored event. The capacity
+// only counts when the feed maps it.
+func unchanged(existing, event models.Event, withCapacity bool) bool {
+	if withCapacity && existing.Capacity != event.Capacity {
+		return false
+	}
+	sameEnd := existing.EndDateTime == nil && event.EndDateTime == nil ||
+		existing.EndDateTime != nil && event.EndDateTime != nil && existing.EndDateTime.Equal(*event.EndDateTime)
+	return sameEnd && existing.Title == event.Title && existing.Description == event.Description &&
+		existing.Location == event.Location && existing.DateTime.Equal(event.DateTime)
+}
+
+// fetch downloads the feed and returns its event items.
+func fetch(ctx context.Context, feed Feed) ([]node, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feed.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/xml, text/xml")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("unexpected status fetching the feed: " + resp.Status)
+	}
+
+	root, err := parse(io.LimitReader(resp.Body, maxFeedSize))
+	if err != nil {
+		return nil, fmt.Errorf("invalid feed XML: %w", err)
+	}
+	return root.find(feed.Mapping.Item), nil
+}
+
+// Start imports every feed immediately and then on its interval, each in its own
+// background goroutine. Failed runs are logged and recorded, and retried on the next
+// interval.
+func Start(feeds []Feed) {
+	for _, feed := range feeds {
+		go func() {
+			for {
+				run, err := Import(context.Background(), feed)
+				if err != nil {
+					slog.Error("Couldn't store the feed import report", "feed", feed.Name, "error", err)
+				} else if run.Error != "" {
+					slog.Error("Couldn't import the event feed", "feed", feed.Name, "error", run.Error)
+				} else {
+					slog.Info("Imported the event feed", "feed", feed.Name, "added", run.Added, "updated", run.Updated, "skipped", run.Skipped)
+				}
+				time.Sleep(feed.Interval)
+			}
+		}()
+	}
+}
@@ -0,0 +1,190 @@
+package feeds
+
+import (
+	"context"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/testutils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestLoad tests reading the feed configuration
+func TestLoad(t *testing.T) {
+	loaded, err := Load(strings.NewReader(`[
+		{"name": "city", "url": "https://example.com/events.xml"},
+		{"name": "portal", "url": "http://portal.example.com/feed", "interval": "30m",
+		 "mapping": {"item": "list/item", "title": "name", "time_zone": "Europe/Berlin"}}
+	]`))
+	if err != nil {
+		t.Fatalf("Failed to load feeds: %v", err)
+	}
+	if len(loaded) != 2 || loaded[0].Interval != defaultInterval || loaded[0].Mapping != DefaultMapping {
+		t.Fatalf("Expected the first feed with the defaults, got %+v", loaded)
+	}
+	portal := loaded[1]
+	if portal.Interval != 30*time.Minute || portal.Mapping.Item != "list/item" || portal.Mapping.Title != "name" || portal.Mapping.Start != "start" {
+		t.Errorf("Expected the portal mapping merged with the defaults, got %+v", portal)
+	}
+	if feed, ok := Find(loaded, "portal"); !ok || feed.Source() != "feed:portal" {
+		t.Errorf("Expected to find the portal feed, got %+v", feed)
+	}
+
+	for _, invalid := range []string{
+		`{"name": "city"}`,
+		`[{"url": "https://example.com/events.xml"}]`,
+		`[{"name": "city", "url": "ftp://example.com/events.xml"}]`,
+		`[{"name": "city", "url": "https://example.com/a"}, {"name": "city", "url": "https://example.com/b"}]`,
+		`[{"name": "city", "url": "https://example.com/a", "interval": "10s"}]`,
+		`[{"name": "city", "url": "https://example.com/a", "mapping": {"time_zone": "Mars/Olympus"}}]`,
+	} {
+		_, err := Load(strings.NewReader(invalid))
+		if err == nil {
+			t.Errorf("Expected an error for %s", invalid)
+		}
+	}
+}
+
+// feedServer serves the XML document set with its set method.
+type feedServer struct {
+	mu  sync.Mutex
+	doc string
+}
+
+func (s *feedServer) set(doc string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.doc = doc
+}
+
+func (s *feedServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/xml")
+	w.Write([]byte(s.doc))
+}
+
+// TestImport tests that runs add new events, update changed ones and skip the rest
+func TestImport(t *testing.T) {
+	tdb := testutils.SetupTestDatabase(t)
+	defer tdb.Cleanup()
+	ctx := context.Background()
+
+	feeds := &feedServer{}
+	server := httptest.NewServer(feeds)
+	defer server.Close()
+	feed := Feed{Name: "city", URL: server.URL, Interval: time.Hour, Mapping: DefaultMapping}
+
+	feeds.set(`<events>
+		<event id="1"><title>Concert</title><location>Park</location><start>2030-06-01T18:00:00Z</start><end>2030-06-01T21:00:00Z</end></event>
+		<event id="2"><title>Market</title><location>Square</location><start>2030-06-02 08:00</start></event>
+		<event id="3"><location>Nowhere</location><start>2030-06-03</start></event>
+	</events>`)
+	run, err := Import(ctx, feed)
+	if err != nil {
+		t.Fatalf("Failed to import feed: %v", err)
+	}
+	if run.Error != "" || run.Added != 2 || run.Updated != 0 || run.Skipped != 1 {
+		t.Fatalf("Expected 2 added and 1 skipped, got %+v", run)
+	}
+	if len(run.Skips) != 1 || run.Skips[0].Item != 3 || run.Skips[0].ExternalID != "3" {
+		t.Errorf("Expected the untitled item in the report, got %+v", run.Skips)
+	}
+
+	concert, err := models.GetEventByExternalID(ctx, "feed:city", "1")
+	if err != nil {
+		t.Fatalf("Failed to get imported event: %v", err)
+	}
+	if concert.Title != "Concert" || concert.ReviewStatus != models.ReviewApproved || concert.UserID != feed.organizerID() || concert.EndDateTime == nil {
+		t.Errorf("Unexpected imported event %+v", concert)
+	}
+	_, err = tdb.DB.Exec("UPDATE events SET capacity = 50 WHERE id = ?", concert.ID)
+	if err != nil {
+		t.Fatalf("Failed to set capacity: %v", err)
+	}
+
+	feeds.set(`<events>
+		<event id="1"><title>Concert</title><location>Park</location><start>2030-06-01T18:00:00Z</start><end>2030-06-01T21:00:00Z</end></event>
+		<event id="2"><title>Farmers' Market</title><location>Square</location><start>2030-06-02 08:00</start></event>
+	</events>`)
+	run, err = Import(ctx, feed)
+	if err != nil {
+		t.Fatalf("Failed to import feed: %v", err)
+	}
+	if run.Error != "" || run.Added != 0 || run.Updated != 1 || run.Skipped != 1 || len(run.Skips) != 0 {
+		t.Errorf("Expected 1 updated and 1 unchanged, got %+v", run)
+	}
+	market, _ := models.GetEventByExternalID(ctx, "feed:city", "2")
+	if market.Title != "Farmers' Market" {
+		t.Errorf("Expected the market to be renamed, got %q", market.Title)
+	}
+	concert, _ = models.GetEventByExternalID(ctx, "feed:city", "1")
+	if concert.Capacity != 50 {
+		t.Errorf("Expected the capacity set in the API to be kept, got %d", concert.Capacity)
+	}
+
+	runs, err := models.GetFeedRuns(ctx, "city", 10, 0)
+	if err != nil || len(runs) != 2 {
+		t.Errorf("Expected both runs to be reported, got %d (%v)", len(runs), err)
+	}
+}
+
+// TestImportDryRun tests that dry runs report what a feed would change without storing it
+func TestImportDryRun(t *testing.T) {
+	tdb := testutils.SetupTestDatabase(t)
+	defer tdb.Cleanup()
+	ctx := models.WithDryRun(context.Background())
+
+	feeds := &feedServer{}
+	server := httptest.NewServer(feeds)
+	defer server.Close()
+	feed := Feed{Name: "city", URL: server.URL, Interval: time.Hour, Mapping: DefaultMapping}
+	feeds.set(`<events>
+		<event id="1"><title>Concert</title><location>Park</location><start>2030-06-01T18:00:00Z</start></event>
+		<event id="2"><location>Nowhere</location><start>2030-06-03</start></event>
+	</events>`)
+
+	run, err := Import(ctx, feed)
+	if err != nil {
+		t.Fatalf("Failed to dry-run the feed: %v", err)
+	}
+	if run.Error != "" || run.Added != 1 || run.Skipped != 1 {
+		t.Errorf("Expected 1 added and 1 skipped, got %+v", run)
+	}
+	if _, err := models.GetEventByExternalID(context.Background(), "feed:city", "1"); err == nil {
+		t.Error("Expected the event not to be stored")
+	}
+	runs, err := models.GetFeedRuns(context.Background(), "city", 10, 0)
+	if err != nil || len(runs) != 0 {
+		t.Errorf("Expected the run not to be reported, got %d (%v)", len(runs), err)
+	}
+}
+
+// TestImportFailure tests that feeds that can't be fetched or parsed are reported
+func TestImportFailure(t *testing.T) {
+	tdb := testutils.SetupTestDatabase(t)
+	defer tdb.Cleanup()
+
+	feeds := &feedServer{}
+	server := httptest.NewServer(feeds)
+	defer server.Close()
+
+	feeds.set(`<events><event id="1">`)
+	run, err := Import(context.Background(), Feed{Name: "broken", URL: server.URL, Mapping: DefaultMapping})
+	if err != nil {
+		t.Fatalf("Failed to store the report: %v", err)
+	}
+	if !strings.Contains(run.Error, "invalid feed XML") || run.Added != 0 {
+		t.Errorf("Expected an XML error in the report, got %+v", run)
+	}
+
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+	run, err = Import(context.Background(), Feed{Name: "missing", URL: missing.URL, Mapping: DefaultMapping})
+	if err != nil || !strings.Contains(run.Error, "404") {
+		t.Errorf("Expected the status in the report, got %+v (%v)", run, err)
+	}
+}
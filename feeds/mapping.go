@@ -0,0 +1,258 @@
+package feeds
+
+import (
+	"encoding/xml"
+	"errors"
+	"event_booking_restapi_golang/models"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Mapping locates the event fields in a feed. Fields are paths of element names below
+// an event item, separated by slashes, such as "venue/name"; a last segment starting
+// with @ names an attribute, such as "@id" or "schedule/@start". Namespace prefixes
+// are ignored.
+type Mapping struct {
+	Item        string `json:"item"`        // Path of the event items below the root element, such as "events/event"
+	ID          string `json:"id"`          // The item's ID in the feed, which must be stable across runs
+	Title       string `json:"title"`       // Required
+	Description string `json:"description"` // Optional in the feed
+	Location    string `json:"location"`    // Required
+	Start       string `json:"start"`       // Start date and time, or only the date when StartTime is set
+	StartTime   string `json:"start_time"`  // Start time of day, for feeds that split it from the date
+	End         string `json:"end"`         // End date and time, or only the date when EndTime is set
+	EndTime     string `json:"end_time"`    // End time of day, for feeds that split it from the date
+	Capacity    string `json:"capacity"`    // Number of places; events keep their capacity when unmapped
+	TimeLayout  string `json:"time_layout"` // Go layout of the start and end values; common formats are recognised without it
+	TimeZone    string `json:"time_zone"`   // IANA time zone of values without an offset, default UTC
+}
+
+// DefaultMapping reads feeds of <event id="..."> items with title, description,
+// location, start and end elements.
+var DefaultMapping = Mapping{
+	Item:        "event",
+	ID:          "@id",
+	Title:       "title",
+	Description: "description",
+	Location:    "location",
+	Start:       "start",
+	End:         "end",
+}
+
+// timeLayouts are the date and time formats tried when the mapping sets no layout,
+// covering ISO 8601 variants, RSS dates and the day-first dates of many municipal feeds.
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	time.RFC1123Z,
+	time.RFC1123,
+	"02.01.2006 15:04",
+	"02.01.2006 15:04:05",
+	"02/01/2006 15:04",
+	time.DateOnly,
+	"02.01.2006",
+	"02/01/2006",
+}
+
+// withDefaults fills the fields left empty from DefaultMapping. Optional fields without
+// a default stay empty.
+func (m Mapping) withDefaults() Mapping {
+	fill := func(field *string, value string) {
+		if *field == "" {
+			*field = value
+		}
+	}
+	fill(&m.Item, DefaultMapping.Item)
+	fill(&m.ID, DefaultMapping.ID)
+	fill(&m.Title, DefaultMapping.Title)
+	fill(&m.Description, DefaultMapping.Description)
+	fill(&m.Location, DefaultMapping.Location)
+	fill(&m.Start, DefaultMapping.Start)
+	fill(&m.End, DefaultMapping.End)
+	return m
+}
+
+// location returns the time zone of values without an offset.
+func (m Mapping) location() (*time.Location, error) {
+	if m.TimeZone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(m.TimeZone)
+}
+
+// event reads the event from a feed item. The returned event carries the item's ID
+// even when the item is invalid, for the run report.
+// Returns an error describing why an invalid item can't be imported.
+func (m Mapping) event(item node, loc *time.Location) (models.Event, error) {
+	event := models.Event{
+		ExternalID:  item.value(m.ID),
+		Title:       item.value(m.Title),
+		Description: item.value(m.Description),
+		Location:    item.value(m.Location),
+	}
+	switch {
+	case event.ExternalID == "":
+		return event, errors.New("the item has no ID")
+	case event.Title == "":
+		return event, errors.New("the item has no title")
+	case event.Location == "":
+		return event, errors.New("the item has no location")
+	}
+
+	start := joinDateTime(item.value(m.Start), item.value(m.StartTime))
+	if start == "" {
+		return event, errors.New("the item has no start")
+	}
+	var err error
+	event.DateTime, err = m.parseTime(start, loc)
+	if err != nil {
+		return event, fmt.Errorf("the item's start %q isn't a known date format", start)
+	}
+	if end := joinDateTime(item.value(m.End), item.value(m.EndTime)); end != "" {
+		endTime, err := m.parseTime(end, loc)
+		if err != nil {
+			return event, fmt.Errorf("the item's end %q isn't a known date format", end)
+		}
+		if !endTime.After(event.DateTime) {
+			return event, errors.New("the item ends before it starts")
+		}
+		event.EndDateTime = &endTime
+	}
+	if m.Capacity != "" {
+		if raw := item.value(m.Capacity); raw != "" {
+			event.Capacity, err = strconv.Atoi(raw)
+			if err != nil || event.Capacity < 0 {
+				return event, fmt.Errorf("the item's capacity %q isn't a number of places", raw)
+			}
+		}
+	}
+	return event, nil
+}
+
+// joinDateTime joins a date and a separate time of day, which is optional.
+func joinDateTime(date, timeOfDay string) string {
+	if date == "" || timeOfDay == "" {
+		return date
+	}
+	return date + " " + timeOfDay
+}
+
+// parseTime parses a start or end value with the mapping's layout, or else the first
+// of the common layouts that matches. Values without an offset are in loc.
+func (m Mapping) parseTime(value string, loc *time.Location) (time.Time, error) {
+	if m.TimeLayout != "" {
+		return time.ParseInLocation(m.TimeLayout, value, loc)
+	}
+	for _, layout := range timeLayouts {
+		t, err := time.ParseInLocation(layout, value, loc)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("unknown date format")
+}
+
+// node is an XML element with its attributes, text and child elements.
+type node struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Text     string     `xml:",chardata"`
+	Children []node     `xml:",any"`
+}
+
+// parse reads an XML document into its root element. Besides UTF-8, documents may be
+// encoded in ISO-8859-1, and HTML entities such as &nbsp; are understood, as older
+// feeds often still use both.
+func parse(r io.Reader) (node, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charsetReader
+	decoder.Entity = xml.HTMLEntity
+	var root node
+	err := decoder.Decode(&root)
+	return root, err
+}
+
+// charsetReader decodes the non-UTF-8 encodings feeds may declare.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "iso8859-1", "latin1", "latin-1":
+		return latin1Reader{input}, nil
+	default:
+		return nil, fmt.Errorf("unsupported charset %q, use UTF-8 or ISO-8859-1", charset)
+	}
+}
+
+// latin1Reader converts ISO-8859-1 text to UTF-8. Each byte is the code point of its
+// character, so bytes above 0x7F become two-byte UTF-8 sequences.
+type latin1Reader struct {
+	r io.Reader
+}
+
+func (l latin1Reader) Read(p []byte) (int, error) {
+	if len(p) < 2 {
+		return 0, io.ErrShortBuffer
+	}
+	// Reading half of p leaves room for every byte to double
+	buf := make([]byte, len(p)/2)
+	n, err := l.r.Read(buf)
+	out := p[:0]
+	for _, b := range buf[:n] {
+		out = utf8.AppendRune(out, rune(b))
+	}
+	return len(out), err
+}
+
+// find returns the descendants at the slash-separated path of element names.
+func (n node) find(path string) []node {
+	current := []node{n}
+	for _, name := range strings.Split(path, "/") {
+		var next []node
+		for _, parent := range current {
+			for _, child := range parent.Children {
+				if child.XMLName.Local == name {
+					next = append(next, child)
+				}
+			}
+		}
+		current = next
+	}
+	return current
+}
+
+// value returns the trimmed text at the path, or the attribute when its last segment
+// starts with @. The first match wins; an empty path or missing value gives "".
+func (n node) value(path string) string {
+	if path == "" {
+		return ""
+	}
+	elements, last := "", path
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		elements, last = path[:i], path[i+1:]
+	}
+	if attr, ok := strings.CutPrefix(last, "@"); ok {
+		holders := []node{n}
+		if elements != "" {
+			holders = n.find(elements)
+		}
+		for _, holder := range holders {
+			for _, a := range holder.Attrs {
+				if a.Name.Local == attr {
+					return strings.TrimSpace(a.Value)
+				}
+			}
+		}
+		return ""
+	}
+	matches := n.find(path)
+	if len(matches) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(matches[0].Text)
+}
@@ -0,0 +1,132 @@
+package feeds
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMappingEvent tests reading events from items with a custom mapping
+func TestMappingEvent(t *testing.T) {
+	doc := `<?xml version="1.0" encoding="UTF-8"?>
+<veranstaltungen xmlns:v="urn:example">
+  <liste>
+    <termin nr="101">
+      <v:titel>  Stadtfest  </v:titel>
+      <text><![CDATA[Music & food]]></text>
+      <ort><name>Marktplatz</name></ort>
+      <datum>01.06.2030</datum><beginn>18:00</beginn>
+      <datum-ende>01.06.2030</datum-ende><ende>23:30</ende>
+      <plaetze>250</plaetze>
+    </termin>
+    <termin nr="102">
+      <v:titel>Lesung f&uuml;r Kinder</v:titel>
+      <ort><name>Bibliothek</name></ort>
+      <datum>2030-06-02</datum>
+    </termin>
+  </liste>
+</veranstaltungen>`
+	root, err := parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("Failed to parse feed: %v", err)
+	}
+	mapping := Mapping{
+		Item:        "liste/termin",
+		ID:          "@nr",
+		Title:       "titel",
+		Description: "text",
+		Location:    "ort/name",
+		Start:       "datum",
+		StartTime:   "beginn",
+		End:         "datum-ende",
+		EndTime:     "ende",
+		Capacity:    "plaetze",
+		TimeZone:    "Europe/Berlin",
+	}
+	loc, _ := mapping.location()
+
+	items := root.find(mapping.Item)
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(items))
+	}
+	event, err := mapping.event(items[0], loc)
+	if err != nil {
+		t.Fatalf("Failed to read the first item: %v", err)
+	}
+	if event.ExternalID != "101" || event.Title != "Stadtfest" || event.Description != "Music & food" || event.Location != "Marktplatz" || event.Capacity != 250 {
+		t.Errorf("Unexpected event %+v", event)
+	}
+	start := time.Date(2030, 6, 1, 16, 0, 0, 0, time.UTC)
+	if !event.DateTime.Equal(start) || event.EndDateTime == nil || !event.EndDateTime.Equal(start.Add(5*time.Hour+30*time.Minute)) {
+		t.Errorf("Unexpected times %s to %v", event.DateTime, event.EndDateTime)
+	}
+
+	event, err = mapping.event(items[1], loc)
+	if err != nil {
+		t.Fatalf("Failed to read the second item: %v", err)
+	}
+	if event.Title != "Lesung für Kinder" || event.EndDateTime != nil || event.Capacity != 0 {
+		t.Errorf("Unexpected event %+v", event)
+	}
+	if !event.DateTime.Equal(time.Date(2030, 6, 2, 0, 0, 0, 0, loc)) {
+		t.Errorf("Expected the start at midnight in Berlin, got %s", event.DateTime)
+	}
+}
+
+// TestMappingEventInvalid tests the reasons invalid items are skipped for
+func TestMappingEventInvalid(t *testing.T) {
+	tests := []struct {
+		item   string
+		reason string
+	}{
+		{`<event><title>T</title><location>L</location><start>2030-06-01</start></event>`, "no ID"},
+		{`<event id="1"><location>L</location><start>2030-06-01</start></event>`, "no title"},
+		{`<event id="1"><title>T</title><start>2030-06-01</start></event>`, "no location"},
+		{`<event id="1"><title>T</title><location>L</location></event>`, "no start"},
+		{`<event id="1"><title>T</title><location>L</location><start>soon</start></event>`, "start \"soon\""},
+		{`<event id="1"><title>T</title><location>L</location><start>2030-06-02</start><end>2030-06-01</end></event>`, "ends before"},
+		{`<event id="1"><title>T</title><location>L</location><start>2030-06-01</start><capacity>many</capacity></event>`, "capacity"},
+	}
+	mapping := DefaultMapping
+	mapping.Capacity = "capacity"
+	for _, tt := range tests {
+		item, err := parse(strings.NewReader(tt.item))
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", tt.item, err)
+		}
+		_, err = mapping.event(item, time.UTC)
+		if err == nil || !strings.Contains(err.Error(), tt.reason) {
+			t.Errorf("Expected an error about %q for %s, got %v", tt.reason, tt.item, err)
+		}
+	}
+}
+
+// TestMappingTimeLayout tests that a configured layout replaces the common ones
+func TestMappingTimeLayout(t *testing.T) {
+	mapping := Mapping{TimeLayout: "20060102T1504"}
+	got, err := mapping.parseTime("20300601T1830", time.UTC)
+	if err != nil || !got.Equal(time.Date(2030, 6, 1, 18, 30, 0, 0, time.UTC)) {
+		t.Errorf("Expected the configured layout to parse, got %s (%v)", got, err)
+	}
+	_, err = mapping.parseTime("2030-06-01", time.UTC)
+	if err == nil {
+		t.Error("Expected values in other layouts to be rejected")
+	}
+}
+
+// TestParseLatin1 tests reading feeds encoded in ISO-8859-1
+func TestParseLatin1(t *testing.T) {
+	doc := "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><events><event id=\"1\"><title>Stra\xdfenfest M\xfcnchen</title></event></events>"
+	root, err := parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("Failed to parse feed: %v", err)
+	}
+	if title := root.find("event")[0].value("title"); title != "Straßenfest München" {
+		t.Errorf("Expected the title decoded to UTF-8, got %q", title)
+	}
+
+	_, err = parse(strings.NewReader(`<?xml version="1.0" encoding="KOI8-R"?><events/>`))
+	if err == nil || !strings.Contains(err.Error(), "unsupported charset") {
+		t.Errorf("Expected an unsupported charset error, got %v", err)
+	}
+}
@@ -0,0 +1,368 @@
+// Package grpcapi serves the EventService defined in eventspb/events.proto, for internal
+// services that would rather not speak JSON over HTTP. It is backed by the same models
+// layer as the REST API and applies the same validation, content policy and booking rules.
+package grpcapi
+
+//go:generate protoc -I eventspb --go_out=eventspb --go_opt=paths=source_relative --go-grpc_out=eventspb --go-grpc_opt=paths=source_relative events.proto
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"event_booking_restapi_golang/grpcapi/eventspb"
+	"event_booking_restapi_golang/ids"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/validation"
+	"fmt"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// UserMetadata is the metadata key identifying the calling user, as the X-User-ID header
+// does for the REST API.
+const UserMetadata = "x-user-id"
+
+// Server implements the EventService on top of an EventRepository.
+type Server struct {
+	eventspb.UnimplementedEventServiceServer
+	events models.EventRepository
+
+	// Moderation puts newly created events into review, as MODERATION_MODE does for the REST API.
+	Moderation bool
+	// Booked is called after each booking with the event and the new registration, to run
+	// the follow-ups of the REST API such as the confirmation email. Nil runs none.
+	Booked func(ctx context.Context, event models.Event, registration models.Registration)
+}
+
+// NewServer returns an EventService backed by the repository.
+func NewServer(events models.EventRepository) *Server {
+	return &Server{events: events}
+}
+
+// New returns a gRPC server with the service registered. With a token, callers must send
+// it as a bearer token in the authorization metadata; other calls fail with Unauthenticated.
+func New(service *Server, token string) *grpc.Server {
+	var opts []grpc.ServerOption
+	if token != "" {
+		opts = append(opts, grpc.UnaryInterceptor(requireToken(token)))
+	}
+	server := grpc.NewServer(opts...)
+	eventspb.RegisterEventServiceServer(server, service)
+	return server
+}
+
+// requireToken returns an interceptor refusing calls without the bearer token.
+func requireToken(token string) grpc.UnaryServerInterceptor {
+	want := []byte("Bearer " + token)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		got := metadataValue(ctx, "authorization")
+		if subtle.ConstantTimeCompare([]byte(got), want) != 1 {
+			return nil, status.Error(codes.Unauthenticated, "a valid bearer token is required in the authorization metadata")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// metadataValue returns the first value of the incoming metadata key, or "".
+func metadataValue(ctx context.Context, key string) string {
+	values := metadata.ValueFromIncomingContext(ctx, key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// ListEvents returns a page of the events matching the request, leaving out drafts
+// other than the caller's own, with the total number of matching events.
+// Fails with InvalidArgument for invalid pagination, statuses or sorting.
+func (s *Server) ListEvents(ctx context.Context, req *eventspb.ListEventsRequest) (*eventspb.ListEventsResponse, error) {
+	if req.Limit < 0 || req.Offset < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit and offset must not be negative")
+	}
+	filter := models.EventFilter{
+		Location: req.Location,
+		Search:   req.Query,
+		UserID:   decodeID(req.UserId),
+		Status:   req.Status,
+		ViewerID: metadataValue(ctx, UserMetadata),
+		Limit:    int(req.Limit),
+		Offset:   int(req.Offset),
+		Sort:     req.Sort,
+		Order:    req.Order,
+	}
+	if req.From != nil {
+		filter.From = req.From.AsTime()
+	}
+	if req.To != nil {
+		filter.To = req.To.AsTime()
+	}
+	switch filter.Status {
+	case "", models.StatusDraft, models.StatusPublished, models.StatusCancelled:
+	default:
+		return nil, status.Error(codes.InvalidArgument, "status must be one of draft, published or cancelled")
+	}
+
+	events, err := s.events.QueryEvents(ctx, filter)
+	if errors.Is(err, models.ErrInvalidSort) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "couldn't fetch events: %v", err)
+	}
+	total, err := s.events.CountEvents(ctx, filter)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "couldn't count events: %v", err)
+	}
+	resp := &eventspb.ListEventsResponse{Total: int32(total)}
+	for _, event := range events {
+		resp.Events = append(resp.Events, eventMessage(event))
+	}
+	return resp, nil
+}
+
+// GetEvent returns an approved event. Drafts are only returned to their organizer.
+// Fails with NotFound if there is no such event.
+func (s *Server) GetEvent(ctx context.Context, req *eventspb.GetEventRequest) (*eventspb.Event, error) {
+	event, err := s.viewableEvent(ctx, req.Id, metadataValue(ctx, UserMetadata))
+	if err != nil {
+		return nil, err
+	}
+	return eventMessage(event), nil
+}
+
+// CreateEvent creates an event for the organizer in the x-user-id metadata, checked and
+// screened as on the REST API. Without moderation or a flag from the content policy the
+// event is approved at once.
+// Fails with InvalidArgument for missing or invalid fields or content the policy blocks,
+// PermissionDenied without an organizer when free users have an event limit,
+// ResourceExhausted when the organizer reached that limit and FailedPrecondition when the
+// event falls on a blackout date of its venue.
+func (s *Server) CreateEvent(ctx context.Context, req *eventspb.CreateEventRequest) (*eventspb.Event, error) {
+	if req.Title == "" || req.Description == "" || req.Location == "" || req.DateTime == nil {
+		return nil, status.Error(codes.InvalidArgument, "title, description, location and date_time are required")
+	}
+	if req.Capacity < 0 {
+		return nil, status.Error(codes.InvalidArgument, "capacity must not be negative")
+	}
+	event := models.Event{
+		ID:                  ids.New(),
+		Title:               req.Title,
+		Description:         req.Description,
+		Location:            req.Location,
+		DateTime:            req.DateTime.AsTime(),
+		UserID:              metadataValue(ctx, UserMetadata),
+		Capacity:            int(req.Capacity),
+		ReviewStatus:        models.ReviewApproved,
+		CreatedAt:           time.Now(),
+		Status:              req.Status,
+		ConfirmationURL:     req.ConfirmationUrl,
+		ConfirmationMessage: req.ConfirmationMessage,
+		License:             req.License,
+	}
+	if req.EndDateTime != nil {
+		end := req.EndDateTime.AsTime()
+		event.EndDateTime = &end
+	}
+	if event.UserID == "" {
+		if models.FreeEventLimit > 0 {
+			return nil, status.Error(codes.PermissionDenied, "creating events requires your user ID in the "+UserMetadata+" metadata")
+		}
+		event.UserID = ids.New()
+	}
+	switch event.Status {
+	case "":
+		event.Status = models.StatusPublished
+	case models.StatusDraft, models.StatusPublished, models.StatusCancelled:
+	default:
+		return nil, status.Error(codes.InvalidArgument, "status must be one of draft, published or cancelled")
+	}
+	if event.EndDateTime != nil && !event.EndDateTime.After(event.DateTime) {
+		return nil, status.Error(codes.InvalidArgument, "end_date_time must be after date_time")
+	}
+	var err error
+	event.ConfirmationURL, err = validation.ValidateRedirectURL(event.ConfirmationURL)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	event.ConfirmationMessage, err = validation.SanitizeConfirmationMessage(event.ConfirmationMessage)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	event.License, err = validation.ValidateLicense(event.License)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if s.Moderation {
+		event.ReviewStatus = models.ReviewPending
+	}
+	result, err := models.ScreenEventContent(ctx, &event)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	switch result.Action {
+	case models.PolicyBlock:
+		return nil, status.Errorf(codes.InvalidArgument, "the event violates the content policy, rules %v", result.RuleIDs)
+	case models.PolicyFlag:
+		event.ReviewStatus, event.ReviewReason = models.ReviewPending, models.FlaggedReason
+	}
+
+	event, err = s.events.Save(ctx, event)
+	var quotaErr models.QuotaError
+	if errors.As(err, &quotaErr) {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
+	var unavailable models.VenueUnavailableError
+	if errors.As(err, &unavailable) {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return eventMessage(event), nil
+}
+
+// bookingErrors maps the booking errors to the status codes they are reported with.
+var bookingErrors = []struct {
+	err  error
+	code codes.Code
+}{
+	{models.ErrEventFull, codes.ResourceExhausted},
+	{models.ErrEventCancelled, codes.FailedPrecondition},
+	{models.ErrAlreadyRegistered, codes.AlreadyExists},
+	{models.ErrBlockedByOrganizer, codes.PermissionDenied},
+	{models.ErrBlockedByAttendee, codes.PermissionDenied},
+}
+
+// Book registers the attendee for a published, approved event and runs the Booked
+// follow-ups. Events with a waiver need its ID as waiver_id, recording the acceptance
+// with the caller's address.
+// Fails with NotFound if there is no such event, InvalidArgument for a missing name, an
+// invalid email address or an unaccepted waiver, ResourceExhausted when the event is full,
+// FailedPrecondition when it is cancelled, AlreadyExists when the email address already
+// holds a place and PermissionDenied when the organizer and the attendee blocked each other.
+func (s *Server) Book(ctx context.Context, req *eventspb.BookRequest) (*eventspb.Registration, error) {
+	event, err := s.viewableEvent(ctx, req.EventId, "")
+	if err != nil {
+		return nil, err
+	}
+	if req.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	err = validation.ValidateEmail(req.Email)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	registration := models.Registration{Name: req.Name, Email: req.Email}
+	waiver, err := event.GetWaiver(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if waiver != nil {
+		if req.WaiverId != ids.Public.Encode(waiver.ID) {
+			return nil, status.Errorf(codes.InvalidArgument, "the event waiver must be accepted by sending its ID %s as waiver_id", ids.Public.Encode(waiver.ID))
+		}
+		now := time.Now()
+		registration.WaiverAcceptance = models.WaiverAcceptance{WaiverID: waiver.ID, WaiverAcceptedAt: &now, WaiverIP: peerIP(ctx)}
+	}
+
+	registration, err = event.Register(ctx, registration)
+	for _, e := range bookingErrors {
+		if errors.Is(err, e.err) {
+			return nil, status.Error(e.code, err.Error())
+		}
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if s.Booked != nil {
+		s.Booked(ctx, event, registration)
+	}
+	return registrationMessage(registration), nil
+}
+
+// viewableEvent retrieves an approved event by its public ID. Drafts are only returned
+// to their organizer, identified by viewerID.
+func (s *Server) viewableEvent(ctx context.Context, publicID, viewerID string) (models.Event, error) {
+	notFound := status.Error(codes.NotFound, fmt.Sprint("Couldn't find an event with the ID of ", publicID))
+	id, ok := ids.Public.Decode(publicID)
+	if !ok {
+		return models.Event{}, notFound
+	}
+	event, err := s.events.GetEventById(ctx, id)
+	if errors.Is(err, models.ErrEventNotFound) {
+		return models.Event{}, notFound
+	}
+	if err != nil {
+		return models.Event{}, status.Error(codes.Internal, err.Error())
+	}
+	if event.ReviewStatus != models.ReviewApproved || event.Status == models.StatusDraft && (viewerID == "" || viewerID != event.UserID) {
+		return models.Event{}, notFound
+	}
+	return event, nil
+}
+
+// decodeID returns the UUID of a public identifier, or s unchanged if it isn't one,
+// as the REST API does for ID query parameters.
+func decodeID(s string) string {
+	if id, ok := ids.Public.Decode(s); ok {
+		return id
+	}
+	return s
+}
+
+// peerIP returns the address the call came from, or "" when it is unknown.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// eventMessage converts an event to its protobuf form, with its public ID.
+func eventMessage(e models.Event) *eventspb.Event {
+	m := &eventspb.Event{
+		Id:           ids.Public.Encode(e.ID),
+		Title:        e.Title,
+		Description:  e.Description,
+		Location:     e.Location,
+		DateTime:     timestamppb.New(e.DateTime),
+		UserId:       ids.Public.Encode(e.UserID),
+		Capacity:     int32(e.Capacity),
+		Status:       e.Status,
+		ReviewStatus: e.ReviewStatus,
+		Slug:         e.Slug,
+		License:      e.License,
+		CreatedAt:    timestamppb.New(e.CreatedAt),
+	}
+	if e.EndDateTime != nil {
+		m.EndDateTime = timestamppb.New(*e.EndDateTime)
+	}
+	return m
+}
+
+// registrationMessage converts a registration to its protobuf form, with public IDs.
+func registrationMessage(r models.Registration) *eventspb.Registration {
+	m := &eventspb.Registration{
+		Id:        ids.Public.Encode(r.ID),
+		EventId:   ids.Public.Encode(r.EventID),
+		Name:      r.Name,
+		Email:     r.Email,
+		CreatedAt: timestamppb.New(r.CreatedAt),
+	}
+	if r.Ticket != nil {
+		m.Ticket = r.Ticket.Code
+	}
+	return m
+}
@@ -0,0 +1,117 @@
+package grpcapi
+
+import (
+	"context"
+	"event_booking_restapi_golang/grpcapi/eventspb"
+	"event_booking_restapi_golang/ids"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/testutils"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// dialService serves the service over an in-memory connection and returns a client for it
+func dialService(t *testing.T, service *Server, token string) eventspb.EventServiceClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := New(service, token)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return eventspb.NewEventServiceClient(conn)
+}
+
+// expectCode fails the test unless err carries the status code
+func expectCode(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+	if status.Code(err) != code {
+		t.Errorf("Expected %v, got %v", code, err)
+	}
+}
+
+// TestEventService tests creating, listing, fetching and booking events over gRPC
+func TestEventService(t *testing.T) {
+	testDB := testutils.SetupTestDatabase(t)
+	defer testDB.Cleanup()
+
+	service := NewServer(models.NewSQLEventRepository())
+	var booked []models.Registration
+	service.Booked = func(ctx context.Context, event models.Event, registration models.Registration) {
+		booked = append(booked, registration)
+	}
+	client := dialService(t, service, "s3cret")
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer s3cret", UserMetadata, "org-1")
+
+	_, err := client.ListEvents(context.Background(), &eventspb.ListEventsRequest{})
+	expectCode(t, err, codes.Unauthenticated)
+
+	start := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	_, err = client.CreateEvent(ctx, &eventspb.CreateEventRequest{Title: "Meetup", Location: "Hall", DateTime: timestamppb.New(start)})
+	expectCode(t, err, codes.InvalidArgument)
+	_, err = client.CreateEvent(ctx, &eventspb.CreateEventRequest{
+		Title: "Meetup", Description: "Monthly", Location: "Hall",
+		DateTime: timestamppb.New(start), EndDateTime: timestamppb.New(start.Add(-time.Hour)),
+	})
+	expectCode(t, err, codes.InvalidArgument)
+
+	created, err := client.CreateEvent(ctx, &eventspb.CreateEventRequest{
+		Title: "Meetup", Description: "Monthly", Location: "Hall",
+		DateTime: timestamppb.New(start), Capacity: 1, License: "CC-BY-4.0",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create event: %v", err)
+	}
+	if created.UserId != "org-1" || created.Status != models.StatusPublished || created.ReviewStatus != models.ReviewApproved ||
+		!created.DateTime.AsTime().Equal(start) || created.License != "CC-BY-4.0" {
+		t.Errorf("Expected a published event of org-1, got %v", created)
+	}
+
+	fetched, err := client.GetEvent(ctx, &eventspb.GetEventRequest{Id: created.Id})
+	if err != nil || fetched.Title != "Meetup" {
+		t.Errorf("Expected the created event, got %v (%v)", fetched, err)
+	}
+	_, err = client.GetEvent(ctx, &eventspb.GetEventRequest{Id: ids.New()})
+	expectCode(t, err, codes.NotFound)
+
+	listed, err := client.ListEvents(ctx, &eventspb.ListEventsRequest{Query: "meetup"})
+	if err != nil || listed.Total != 1 || len(listed.Events) != 1 || listed.Events[0].Id != created.Id {
+		t.Errorf("Expected the created event to be listed, got %v (%v)", listed, err)
+	}
+	_, err = client.ListEvents(ctx, &eventspb.ListEventsRequest{Sort: "popularity"})
+	expectCode(t, err, codes.InvalidArgument)
+
+	_, err = client.Book(ctx, &eventspb.BookRequest{EventId: created.Id, Name: "Ann", Email: "not-an-email"})
+	expectCode(t, err, codes.InvalidArgument)
+	registration, err := client.Book(ctx, &eventspb.BookRequest{EventId: created.Id, Name: "Ann", Email: "ann@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to book: %v", err)
+	}
+	if registration.EventId != created.Id || registration.Id == "" || registration.Ticket == "" {
+		t.Errorf("Expected a ticketed registration for the event, got %v", registration)
+	}
+	if len(booked) != 1 || booked[0].Email != "ann@example.com" {
+		t.Errorf("Expected the booking follow-ups to run, got %+v", booked)
+	}
+	_, err = client.Book(ctx, &eventspb.BookRequest{EventId: created.Id, Name: "Bob", Email: "bob@example.com"})
+	expectCode(t, err, codes.ResourceExhausted)
+	_, err = client.Book(ctx, &eventspb.BookRequest{EventId: ids.New(), Name: "Bob", Email: "bob@example.com"})
+	expectCode(t, err, codes.NotFound)
+}
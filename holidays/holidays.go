@@ -0,0 +1,135 @@
+// Package holidays knows the public holidays and other blackout dates of each region,
+// so organizers can be warned when they schedule an event on one. Unlike venue
+// blackouts, holidays never stop an event from being scheduled.
+package holidays
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// maxDays bounds the days of an event that are looked up, so events spanning years
+// don't take a lookup per day.
+const maxDays = 366
+
+// Holiday is a blackout date of a region.
+type Holiday struct {
+	Region string `json:"region"` // Region the holiday is observed in
+	Date   string `json:"date"`   // Day of the holiday, as YYYY-MM-DD
+	Name   string `json:"name"`   // Name of the holiday
+}
+
+// entry is a holiday as written in the calendar file.
+type entry struct {
+	Date string `json:"date"`
+	Name string `json:"name"`
+}
+
+// Calendar holds the holidays of each region. A nil *Calendar is valid and has no
+// holidays, which is how holiday warnings are disabled.
+type Calendar struct {
+	defaultRegion string
+	dates         map[string]map[string]string // Region to YYYY-MM-DD to name
+	yearly        map[string]map[string]string // Region to MM-DD to name, for holidays on the same day every year
+}
+
+// FromEnv loads the calendar from the JSON file named by HOLIDAYS_FILE, with
+// HOLIDAY_REGION as the region of events that don't name one. Returns nil when no
+// file is set, which disables holiday warnings.
+func FromEnv() (*Calendar, error) {
+	path := os.Getenv("HOLIDAYS_FILE")
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f, os.Getenv("HOLIDAY_REGION"))
+}
+
+// Load reads a JSON object mapping each region to its holidays, each with a date and
+// a name. Dates are YYYY-MM-DD, or MM-DD for holidays on the same day every year.
+// Region names are matched ignoring case. defaultRegion, which may be empty, is the
+// region of events that don't name one.
+// Returns an error naming the first invalid holiday, or if defaultRegion has no holidays.
+func Load(r io.Reader, defaultRegion string) (*Calendar, error) {
+	var regions map[string][]entry
+	err := json.NewDecoder(r).Decode(&regions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid holiday calendar: %w", err)
+	}
+
+	c := &Calendar{
+		defaultRegion: strings.ToLower(defaultRegion),
+		dates:         map[string]map[string]string{},
+		yearly:        map[string]map[string]string{},
+	}
+	for region, entries := range regions {
+		region = strings.ToLower(region)
+		if c.dates[region] == nil {
+			c.dates[region], c.yearly[region] = map[string]string{}, map[string]string{}
+		}
+		for _, e := range entries {
+			if e.Name == "" {
+				return nil, fmt.Errorf("holiday %q of region %q has no name", e.Date, region)
+			}
+			if day, err := time.Parse("2006-01-02", e.Date); err == nil {
+				c.dates[region][day.Format("2006-01-02")] = e.Name
+			} else if day, err := time.Parse("01-02", e.Date); err == nil {
+				c.yearly[region][day.Format("01-02")] = e.Name
+			} else {
+				return nil, fmt.Errorf("holiday %q of region %q has an invalid date %q, use YYYY-MM-DD or MM-DD", e.Name, region, e.Date)
+			}
+		}
+	}
+	if c.defaultRegion != "" && c.dates[c.defaultRegion] == nil {
+		return nil, fmt.Errorf("the default region %q has no holidays in the calendar", defaultRegion)
+	}
+	return c, nil
+}
+
+// Region resolves the region an event names to a region of the calendar, using the
+// default region when it names none.
+// Returns false if the calendar doesn't know the region. Without a calendar every
+// region resolves to none.
+func (c *Calendar) Region(name string) (string, bool) {
+	if c == nil {
+		return "", true
+	}
+	if name == "" {
+		return c.defaultRegion, true
+	}
+	region := strings.ToLower(name)
+	_, ok := c.dates[region]
+	return region, ok
+}
+
+// Between returns the holidays of the region on the UTC days from start to end, in
+// date order. Events without an end are looked up on the day they start.
+func (c *Calendar) Between(region string, start time.Time, end *time.Time) []Holiday {
+	if c == nil || region == "" {
+		return nil
+	}
+	last := start.UTC()
+	if end != nil {
+		last = end.UTC()
+	}
+	var found []Holiday
+	day := start.UTC().Truncate(24 * time.Hour)
+	for i := 0; i < maxDays && !day.After(last); i++ {
+		date := day.Format("2006-01-02")
+		if name, ok := c.dates[region][date]; ok {
+			found = append(found, Holiday{Region: region, Date: date, Name: name})
+		} else if name, ok := c.yearly[region][day.Format("01-02")]; ok {
+			found = append(found, Holiday{Region: region, Date: date, Name: name})
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return found
+}
@@ -0,0 +1,103 @@
+package holidays
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCalendar is a calendar with fixed and yearly holidays in two regions
+const testCalendar = `{
+	"US": [{"date": "12-25", "name": "Christmas Day"}, {"date": "2026-11-26", "name": "Thanksgiving"}],
+	"de": [{"date": "10-03", "name": "Tag der Deutschen Einheit"}]
+}`
+
+// TestLoad tests reading the calendar and resolving regions
+func TestLoad(t *testing.T) {
+	calendar, err := Load(strings.NewReader(testCalendar), "us")
+	if err != nil {
+		t.Fatalf("Failed to load calendar: %v", err)
+	}
+	if region, ok := calendar.Region(""); !ok || region != "us" {
+		t.Errorf("Expected events without a region in us, got %q %v", region, ok)
+	}
+	if region, ok := calendar.Region("DE"); !ok || region != "de" {
+		t.Errorf("Expected DE to resolve to de, got %q %v", region, ok)
+	}
+	if _, ok := calendar.Region("fr"); ok {
+		t.Error("Expected an unknown region to be reported")
+	}
+	var disabled *Calendar
+	if region, ok := disabled.Region("fr"); !ok || region != "" || disabled.Between("us", time.Now(), nil) != nil {
+		t.Errorf("Expected no calendar to have no regions or holidays, got %q %v", region, ok)
+	}
+
+	for _, invalid := range []string{
+		`[]`,
+		`{"us": [{"date": "12-25"}]}`,
+		`{"us": [{"date": "25.12.", "name": "Christmas Day"}]}`,
+		`{"us": [{"date": "2026-13-01", "name": "Nonsense"}]}`,
+	} {
+		if _, err := Load(strings.NewReader(invalid), ""); err == nil {
+			t.Errorf("Expected an error for %s", invalid)
+		}
+	}
+	if _, err := Load(strings.NewReader(testCalendar), "fr"); err == nil {
+		t.Error("Expected an error for a default region without holidays")
+	}
+}
+
+// TestBetween tests finding the holidays an event falls on
+func TestBetween(t *testing.T) {
+	calendar, err := Load(strings.NewReader(testCalendar), "")
+	if err != nil {
+		t.Fatalf("Failed to load calendar: %v", err)
+	}
+	christmas := time.Date(2027, 12, 25, 18, 0, 0, 0, time.UTC)
+	if found := calendar.Between("us", christmas, nil); len(found) != 1 || found[0] != (Holiday{Region: "us", Date: "2027-12-25", Name: "Christmas Day"}) {
+		t.Errorf("Expected the yearly Christmas Day, got %+v", found)
+	}
+	end := time.Date(2026, 12, 26, 2, 0, 0, 0, time.UTC)
+	found := calendar.Between("us", time.Date(2026, 11, 25, 18, 0, 0, 0, time.UTC), &end)
+	if len(found) != 2 || found[0].Name != "Thanksgiving" || found[1].Date != "2026-12-25" {
+		t.Errorf("Expected Thanksgiving and Christmas Day within the event, got %+v", found)
+	}
+	// Days are UTC days
+	evening := time.Date(2026, 12, 24, 20, 0, 0, 0, time.FixedZone("EST", -5*3600))
+	if found := calendar.Between("us", evening, nil); len(found) != 1 {
+		t.Errorf("Expected the evening of December 24 in New York to fall on Christmas Day in UTC, got %+v", found)
+	}
+	if found := calendar.Between("de", christmas, nil); len(found) != 0 {
+		t.Errorf("Expected no holidays of another region, got %+v", found)
+	}
+	if found := calendar.Between("", christmas, nil); len(found) != 0 {
+		t.Errorf("Expected no holidays without a region, got %+v", found)
+	}
+}
+
+// TestFromEnv tests loading the calendar named by HOLIDAYS_FILE
+func TestFromEnv(t *testing.T) {
+	t.Setenv("HOLIDAYS_FILE", "")
+	if calendar, err := FromEnv(); calendar != nil || err != nil {
+		t.Errorf("Expected no calendar without a file, got %v, %v", calendar, err)
+	}
+	path := filepath.Join(t.TempDir(), "holidays.json")
+	if err := os.WriteFile(path, []byte(testCalendar), 0o600); err != nil {
+		t.Fatalf("Failed to write calendar: %v", err)
+	}
+	t.Setenv("HOLIDAYS_FILE", path)
+	t.Setenv("HOLIDAY_REGION", "de")
+	calendar, err := FromEnv()
+	if err != nil {
+		t.Fatalf("Failed to load calendar: %v", err)
+	}
+	if region, _ := calendar.Region(""); region != "de" {
+		t.Errorf("Expected HOLIDAY_REGION to be the default region, got %q", region)
+	}
+	t.Setenv("HOLIDAYS_FILE", filepath.Join(t.TempDir(), "missing.json"))
+	if _, err := FromEnv(); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
@@ -0,0 +1,124 @@
+// Package ical writes iCalendar (RFC 5545) files, so events can be added to calendar
+// applications such as Google Calendar, Apple Calendar and Outlook, and reads the events
+// of the files other tools export, so organizers can import them.
+package ical
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ContentType is the media type of iCalendar files.
+const ContentType = "text/calendar; charset=utf-8"
+
+// prodID identifies this API as the producer of the calendars.
+const prodID = "-//event_booking_restapi_golang//Event Booking API//EN"
+
+// maxLineOctets is the longest content line allowed before it must be folded.
+const maxLineOctets = 75
+
+// timeFormat is the UTC form of the DATE-TIME value type.
+const timeFormat = "20060102T150405Z"
+
+// Event is a VEVENT. Events without an end are points in time.
+type Event struct {
+	UID         string    // Globally unique and stable, so calendars update rather than duplicate the event
+	Summary     string    // Title
+	Description string    // Plain-text description, optional
+	Location    string    // Optional
+	Start       time.Time // When the event starts
+	End         time.Time // When the event ends, optional
+	Created     time.Time // When the event was created, optional
+	Sequence    int       // Revision of the event, raised with every change so subscribed calendars apply it
+	Cancelled   bool      // Whether the event was called off, so calendars show it as cancelled
+}
+
+// Calendar is a VCALENDAR holding events.
+type Calendar struct {
+	Name            string        // Display name shown by calendar applications, optional
+	RefreshInterval time.Duration // How often subscribed calendars should fetch it again, optional
+	Events          []Event
+}
+
+// Write writes the calendar in the iCalendar format, stamping its events with now.
+func (cal Calendar) Write(w io.Writer, now time.Time) error {
+	var b strings.Builder
+	line := func(name, value string) {
+		writeLine(&b, name+":"+value)
+	}
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", prodID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if cal.Name != "" {
+		line("X-WR-CALNAME", escape(cal.Name))
+	}
+	if cal.RefreshInterval > 0 {
+		// RFC 7986, and the older property Outlook and Google Calendar read
+		interval := fmt.Sprintf("PT%dM", max(1, int(cal.RefreshInterval/time.Minute)))
+		writeLine(&b, "REFRESH-INTERVAL;VALUE=DURATION:"+interval)
+		line("X-PUBLISHED-TTL", interval)
+	}
+	for _, e := range cal.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", escape(e.UID))
+		line("DTSTAMP", now.UTC().Format(timeFormat))
+		if e.Sequence > 0 {
+			line("SEQUENCE", fmt.Sprint(e.Sequence))
+		}
+		line("DTSTART", e.Start.UTC().Format(timeFormat))
+		if !e.End.IsZero() {
+			line("DTEND", e.End.UTC().Format(timeFormat))
+		}
+		if !e.Created.IsZero() {
+			line("CREATED", e.Created.UTC().Format(timeFormat))
+		}
+		line("SUMMARY", escape(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION", escape(e.Description))
+		}
+		if e.Location != "" {
+			line("LOCATION", escape(e.Location))
+		}
+		if e.Cancelled {
+			line("STATUS", "CANCELLED")
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escape escapes a TEXT value: backslashes, semicolons, commas and line breaks.
+func escape(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`, "\r", `\n`).Replace(s)
+}
+
+// writeLine writes a content line ending in CRLF, folding it into lines of at most
+// 75 octets without splitting UTF-8 characters. Continuation lines start with a space.
+func writeLine(b *strings.Builder, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space counts towards the length of continuation lines
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+// isRuneStart reports whether the byte starts a UTF-8 encoded character.
+func isRuneStart(c byte) bool {
+	return c&0xC0 != 0x80
+}
@@ -0,0 +1,146 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWrite tests the rendered calendar
+func TestWrite(t *testing.T) {
+	start := time.Date(2030, 1, 1, 21, 0, 0, 0, time.FixedZone("CET", 3600))
+	cal := Calendar{
+		Name: "My bookings",
+		Events: []Event{{
+			UID:         "e1@example.com",
+			Summary:     "Jazz Night; live, loud",
+			Description: "Doors at 8.\nBring a friend.",
+			Location:    `Club \ Hall`,
+			Start:       start,
+			End:         start.Add(2 * time.Hour),
+		}},
+	}
+	var b strings.Builder
+	err := cal.Write(&b, time.Date(2029, 12, 1, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Failed to write calendar: %v", err)
+	}
+	rendered := b.String()
+	for _, expected := range []string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n",
+		"X-WR-CALNAME:My bookings\r\n",
+		"BEGIN:VEVENT\r\nUID:e1@example.com\r\nDTSTAMP:20291201T120000Z\r\n",
+		"DTSTART:20300101T200000Z\r\nDTEND:20300101T220000Z\r\n",
+		`SUMMARY:Jazz Night\; live\, loud` + "\r\n",
+		`DESCRIPTION:Doors at 8.\nBring a friend.` + "\r\n",
+		`LOCATION:Club \\ Hall` + "\r\n",
+		"END:VEVENT\r\nEND:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(rendered, expected) {
+			t.Errorf("Expected %q in\n%s", expected, rendered)
+		}
+	}
+	if strings.Contains(rendered, "CREATED") {
+		t.Error("Expected CREATED to be left out when unknown")
+	}
+}
+
+// TestWriteSubscription tests the properties that let subscribed calendars follow changes
+func TestWriteSubscription(t *testing.T) {
+	cal := Calendar{
+		RefreshInterval: time.Hour,
+		Events: []Event{
+			{UID: "e1", Summary: "Moved", Start: time.Now(), Sequence: 3},
+			{UID: "e2", Summary: "Called off", Start: time.Now(), Sequence: 1, Cancelled: true},
+		},
+	}
+	var b strings.Builder
+	err := cal.Write(&b, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to write calendar: %v", err)
+	}
+	rendered := b.String()
+	for _, expected := range []string{
+		"REFRESH-INTERVAL;VALUE=DURATION:PT60M\r\nX-PUBLISHED-TTL:PT60M\r\n",
+		"UID:e1\r\n",
+		"SEQUENCE:3\r\n",
+		"SUMMARY:Called off\r\nSTATUS:CANCELLED\r\n",
+	} {
+		if !strings.Contains(rendered, expected) {
+			t.Errorf("Expected %q in\n%s", expected, rendered)
+		}
+	}
+	if strings.Count(rendered, "STATUS:") != 1 {
+		t.Error("Expected only the cancelled event to have a status")
+	}
+}
+
+// TestWriteFoldsLongLines tests that lines are folded at 75 octets without splitting characters
+func TestWriteFoldsLongLines(t *testing.T) {
+	summary := strings.Repeat("é", 100)
+	var b strings.Builder
+	err := Calendar{Events: []Event{{UID: "e1", Summary: summary, Start: time.Now()}}}.Write(&b, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to write calendar: %v", err)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("Expected lines of at most 75 octets, got %d: %q", len(line), line)
+		}
+	}
+	unfolded := strings.ReplaceAll(b.String(), "\r\n ", "")
+	if !strings.Contains(unfolded, "SUMMARY:"+summary+"\r\n") {
+		t.Error("Expected the folded summary to unfold to the original")
+	}
+}
+
+// TestParse tests reading the events of a calendar, with the ones that can't be read reported
+func TestParse(t *testing.T) {
+	calendar := "\ufeffBEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:e1@example.com\r\n" +
+		"DTSTART:20300101T200000Z\r\n" +
+		"DTEND;TZID=Europe/Berlin:20300101T230000\r\n" +
+		"SUMMARY:Jazz Night\\; live\\, lo\r\n ud\r\n" +
+		"DESCRIPTION:Doors at 8.\\nBring a friend.\r\n" +
+		"BEGIN:VALARM\r\nDESCRIPTION:Reminder\r\nEND:VALARM\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"DTSTART;VALUE=DATE:20300102\r\n" +
+		"SUMMARY:Fair\r\n" +
+		"STATUS:CANCELLED\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"SUMMARY:No start\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"DTSTART:tomorrow\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	loc := time.FixedZone("UTC+2", 2*3600)
+	entries, err := Parse(strings.NewReader(calendar), loc)
+	if err != nil {
+		t.Fatalf("Failed to parse the calendar: %v", err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("Expected 4 events, got %d", len(entries))
+	}
+	first := entries[0]
+	if first.Err != nil || first.Line != 3 || first.UID != "e1@example.com" || first.Summary != "Jazz Night; live, loud" ||
+		first.Description != "Doors at 8.\nBring a friend." || !first.Start.Equal(time.Date(2030, 1, 1, 20, 0, 0, 0, time.UTC)) ||
+		!first.End.Equal(time.Date(2030, 1, 1, 22, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected first event %+v", first)
+	}
+	second := entries[1]
+	if second.Err != nil || !second.Cancelled || !second.Start.Equal(time.Date(2030, 1, 2, 0, 0, 0, 0, loc)) {
+		t.Errorf("Expected a cancelled all-day event in the given zone, got %+v", second)
+	}
+	if entries[2].Err == nil || entries[3].Err == nil {
+		t.Errorf("Expected errors for the events without a valid start, got %v and %v", entries[2].Err, entries[3].Err)
+	}
+
+	if _, err := Parse(strings.NewReader("title,date_time\n"), time.UTC); err == nil {
+		t.Error("Expected an error for a file that isn't a calendar")
+	}
+}
@@ -0,0 +1,177 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// maxLineLength bounds an unfolded content line, so a malformed file can't exhaust memory.
+const maxLineLength = 1 << 20
+
+// Entry is a VEVENT read from a calendar, or why it couldn't be read.
+type Entry struct {
+	Event
+	Line int   // Line of its BEGIN:VEVENT in the file, starting at 1
+	Err  error // Why the event couldn't be read, nil when it could
+}
+
+// Parse reads the VEVENTs of an iCalendar file, such as one exported from another
+// calendar or ticketing tool. Start and end times in UTC, with a TZID or floating, and
+// all-day dates are understood; floating times and dates are taken in loc. Events that
+// can't be read are returned with their error, so one bad event doesn't hide the others.
+// Returns an error when the file isn't an iCalendar file.
+func Parse(r io.Reader, loc *time.Location) ([]Entry, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 || !strings.EqualFold(lines[0].text, "BEGIN:VCALENDAR") {
+		return nil, errors.New("not an iCalendar file, it doesn't start with BEGIN:VCALENDAR")
+	}
+
+	var entries []Entry
+	var current *Entry
+	nested := 0 // Depth of the components within the current event, such as alarms
+	for _, l := range lines {
+		name, params, value, ok := split(l.text)
+		if !ok {
+			if current != nil && current.Err == nil {
+				current.Err = fmt.Errorf("line %d isn't a property", l.number)
+			}
+			continue
+		}
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			if current != nil && current.Err == nil {
+				current.Err = errors.New("the event has no END:VEVENT")
+			}
+			nested = 0
+			entries = append(entries, Entry{Line: l.number})
+			current = &entries[len(entries)-1]
+		case current != nil && name == "BEGIN":
+			nested++
+		case current != nil && name == "END" && nested > 0:
+			nested--
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if current != nil && current.Err == nil && current.Start.IsZero() {
+				current.Err = errors.New("the event has no DTSTART")
+			}
+			current = nil
+		case current != nil && current.Err == nil && nested == 0:
+			current.Err = current.set(name, params, value, loc)
+		}
+	}
+	if current != nil && current.Err == nil {
+		current.Err = errors.New("the event has no END:VEVENT")
+	}
+	return entries, nil
+}
+
+// set sets the event property name from its parameters and value.
+func (e *Entry) set(name string, params map[string]string, value string, loc *time.Location) error {
+	var err error
+	switch name {
+	case "UID":
+		e.UID = unescape(value)
+	case "SUMMARY":
+		e.Summary = unescape(value)
+	case "DESCRIPTION":
+		e.Description = unescape(value)
+	case "LOCATION":
+		e.Location = unescape(value)
+	case "STATUS":
+		e.Cancelled = strings.EqualFold(value, "CANCELLED")
+	case "DTSTART":
+		e.Start, err = parseTime(params, value, loc)
+	case "DTEND":
+		e.End, err = parseTime(params, value, loc)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return nil
+}
+
+// parseTime parses a DATE-TIME or DATE value. Times ending in Z are UTC, times with a
+// TZID are in that zone and the rest are in loc; dates are midnight in loc.
+func parseTime(params map[string]string, value string, loc *time.Location) (time.Time, error) {
+	if params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		return time.ParseInLocation("20060102", value, loc)
+	}
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(timeFormat, value)
+	}
+	if tzid := params["TZID"]; tzid != "" {
+		zone, err := time.LoadLocation(strings.Trim(tzid, `"`))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unknown time zone %s", tzid)
+		}
+		loc = zone
+	}
+	return time.ParseInLocation("20060102T150405", value, loc)
+}
+
+// line is an unfolded content line and the number of the line it starts on.
+type line struct {
+	text   string
+	number int
+}
+
+// unfold reads the content lines of r, joining folded lines back together.
+func unfold(r io.Reader) ([]line, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineLength)
+	var lines []line
+	number := 0
+	for scanner.Scan() {
+		number++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if number == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		if (strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t")) && len(lines) > 0 {
+			last := &lines[len(lines)-1]
+			if len(last.text)+len(text) > maxLineLength {
+				return nil, fmt.Errorf("line %d is too long", last.number)
+			}
+			last.text += text[1:]
+			continue
+		}
+		if text != "" {
+			lines = append(lines, line{text: text, number: number})
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// split splits a content line into its upper-case name, its parameters and its value.
+func split(text string) (string, map[string]string, string, bool) {
+	colon, quoted := -1, false
+	for i, c := range text {
+		if c == '"' {
+			quoted = !quoted
+		} else if c == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon <= 0 {
+		return "", nil, "", false
+	}
+	parts := strings.Split(text[:colon], ";")
+	params := map[string]string{}
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		params[strings.ToUpper(key)] = value
+	}
+	return strings.ToUpper(parts[0]), params, text[colon+1:], true
+}
+
+// unescape reverses escape for a TEXT value.
+func unescape(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(s)
+}
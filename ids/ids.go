@@ -0,0 +1,54 @@
+// Package ids generates the identifiers of stored entities such as events and
+// registrations. New identifiers are time-ordered UUIDv7s, so rows are appended to
+// the primary key index in creation order and identifiers sort like their creation
+// time. Identifiers created before the switch are random UUIDv4s and remain valid;
+// nothing relies on an identifier's version.
+package ids
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Generator creates new entity identifiers.
+type Generator interface {
+	NewID() string
+}
+
+// UUIDv7 generates time-ordered UUIDs. Identifiers created by the same process are
+// strictly increasing, even within the same millisecond.
+type UUIDv7 struct{}
+
+// NewID returns a new UUIDv7.
+func (UUIDv7) NewID() string {
+	return uuid.Must(uuid.NewV7()).String()
+}
+
+// UUIDv4 generates random UUIDs, as used before UUIDv7s were introduced.
+type UUIDv4 struct{}
+
+// NewID returns a new random UUID.
+func (UUIDv4) NewID() string {
+	return uuid.NewString()
+}
+
+// Default is the generator used by New. It is set up by main from ID_VERSION and
+// must not be changed while requests are served.
+var Default Generator = UUIDv7{}
+
+// New returns a new identifier from the Default generator.
+func New() string {
+	return Default.NewID()
+}
+
+// Time returns the creation time encoded in a UUIDv7 identifier, to the millisecond.
+// Returns false for UUIDv4 and other identifiers, which carry no time.
+func Time(id string) (time.Time, bool) {
+	parsed, err := uuid.Parse(id)
+	if err != nil || parsed.Version() != 7 {
+		return time.Time{}, false
+	}
+	sec, nsec := parsed.Time().UnixTime()
+	return time.Unix(sec, nsec).UTC(), true
+}
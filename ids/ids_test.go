@@ -0,0 +1,47 @@
+package ids
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TestUUIDv7Ordered tests that new identifiers sort in creation order
+func TestUUIDv7Ordered(t *testing.T) {
+	generated := make([]string, 1000)
+	for i := range generated {
+		generated[i] = UUIDv7{}.NewID()
+	}
+	if !slices.IsSorted(generated) {
+		t.Error("Expected identifiers to sort in creation order")
+	}
+	if version := uuid.MustParse(generated[0]).Version(); version != 7 {
+		t.Errorf("Expected a version 7 UUID, got version %d", version)
+	}
+}
+
+// TestNewUsesDefault tests that New can be switched back to random UUIDs
+func TestNewUsesDefault(t *testing.T) {
+	defer func() { Default = UUIDv7{} }()
+	Default = UUIDv4{}
+	if version := uuid.MustParse(New()).Version(); version != 4 {
+		t.Errorf("Expected a version 4 UUID, got version %d", version)
+	}
+}
+
+// TestTime tests reading the creation time from UUIDv7s and rejecting other identifiers
+func TestTime(t *testing.T) {
+	before := time.Now().Truncate(time.Millisecond)
+	created, ok := Time(UUIDv7{}.NewID())
+	if !ok || created.Before(before) || created.After(time.Now()) {
+		t.Errorf("Expected a creation time after %v, got %v (%v)", before, created, ok)
+	}
+
+	for _, id := range []string{UUIDv4{}.NewID(), "not-a-uuid", ""} {
+		if _, ok := Time(id); ok {
+			t.Errorf("Expected no creation time for %q", id)
+		}
+	}
+}
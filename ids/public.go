@@ -0,0 +1,154 @@
+package ids
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// DefaultAlphabet is the alphabet of obfuscated identifiers unless one is configured.
+const DefaultAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// minAlphabet is the fewest characters an alphabet may have, which keeps public
+// identifiers at most 32 characters long.
+const minAlphabet = 16
+
+// Codec converts the UUIDs entities are stored under to the identifiers clients see
+// and back.
+type Codec interface {
+	// Encode returns the public identifier of the UUID id. Anything else is returned unchanged.
+	Encode(id string) string
+	// Decode returns the UUID of the public identifier. UUIDs are returned unchanged,
+	// so identifiers handed out before encoding was enabled stay valid.
+	// Returns false if public is neither.
+	Decode(public string) (string, bool)
+}
+
+// Plain shows the UUIDs themselves, which is the default.
+type Plain struct{}
+
+// Encode returns id.
+func (Plain) Encode(id string) string {
+	return id
+}
+
+// Decode returns public if it is a UUID.
+func (Plain) Decode(public string) (string, bool) {
+	return public, isUUID(public)
+}
+
+// isUUID reports whether s is a UUID in its canonical 36 character form. Other forms
+// google/uuid accepts, such as 32 hex digits, could be public identifiers.
+func isUUID(s string) bool {
+	return len(s) == 36 && uuid.Validate(s) == nil
+}
+
+// Obfuscator shows UUIDs as short opaque identifiers, in the manner of hashids. The 16
+// bytes of the UUID are encrypted with AES under a key derived from the deployment's
+// secret and written in base len(alphabet), so a UUIDv7's creation time and the order
+// of identifiers can't be read off them, and they can't be guessed without the secret.
+// Every UUID has exactly one public identifier, of a fixed length: 22 characters with
+// the default alphabet.
+type Obfuscator struct {
+	block    cipher.Block
+	alphabet string
+	width    int
+}
+
+// NewObfuscator returns an obfuscator keyed with secret, writing identifiers in the
+// characters of alphabet, or DefaultAlphabet when it is empty.
+// Returns an error if the secret is empty or the alphabet has fewer than 16 distinct
+// ASCII letters and digits or repeats one.
+func NewObfuscator(secret, alphabet string) (*Obfuscator, error) {
+	if secret == "" {
+		return nil, errors.New("public identifiers need a secret")
+	}
+	if alphabet == "" {
+		alphabet = DefaultAlphabet
+	}
+	if len(alphabet) < minAlphabet {
+		return nil, fmt.Errorf("the public identifier alphabet needs at least %d characters", minAlphabet)
+	}
+	for i, r := range alphabet {
+		if !('0' <= r && r <= '9' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z') {
+			return nil, fmt.Errorf("the public identifier alphabet can only contain ASCII letters and digits, not %q", r)
+		}
+		if strings.IndexRune(alphabet[:i], r) >= 0 {
+			return nil, fmt.Errorf("the public identifier alphabet repeats %q", r)
+		}
+	}
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	o := &Obfuscator{block: block, alphabet: alphabet}
+	maxID := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+	o.width = len(o.format(maxID))
+	return o, nil
+}
+
+// Encode returns the public identifier of the UUID id, or id unchanged if it isn't a UUID.
+func (o *Obfuscator) Encode(id string) string {
+	if !isUUID(id) {
+		return id
+	}
+	parsed := uuid.MustParse(id)
+	var encrypted [16]byte
+	o.block.Encrypt(encrypted[:], parsed[:])
+	public := o.format(new(big.Int).SetBytes(encrypted[:]))
+	return strings.Repeat(o.alphabet[:1], o.width-len(public)) + public
+}
+
+// Decode returns the UUID of the public identifier, or public unchanged if it is a UUID.
+// Returns false if public is neither.
+func (o *Obfuscator) Decode(public string) (string, bool) {
+	if isUUID(public) {
+		return public, true
+	}
+	if len(public) != o.width {
+		return "", false
+	}
+	base := big.NewInt(int64(len(o.alphabet)))
+	n := new(big.Int)
+	for i := 0; i < len(public); i++ {
+		digit := strings.IndexByte(o.alphabet, public[i])
+		if digit < 0 {
+			return "", false
+		}
+		n.Mul(n, base).Add(n, big.NewInt(int64(digit)))
+	}
+	if n.BitLen() > 128 {
+		return "", false
+	}
+	var encrypted, id uuid.UUID
+	n.FillBytes(encrypted[:])
+	o.block.Decrypt(id[:], encrypted[:])
+	return id.String(), true
+}
+
+// format writes n in base len(alphabet) without leading zeros.
+func (o *Obfuscator) format(n *big.Int) string {
+	base := big.NewInt(int64(len(o.alphabet)))
+	n = new(big.Int).Set(n)
+	digit := new(big.Int)
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, base, digit)
+		out = append(out, o.alphabet[digit.Int64()])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+// Public is the codec of the identifiers clients see. It is set up by main from
+// PUBLIC_ID_SECRET and must not be changed while requests are served.
+var Public Codec = Plain{}
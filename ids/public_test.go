@@ -0,0 +1,96 @@
+package ids
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// TestObfuscatorRoundTrip tests that public identifiers are short, opaque and decode to their UUID
+func TestObfuscatorRoundTrip(t *testing.T) {
+	o, err := NewObfuscator("deployment secret", "")
+	if err != nil {
+		t.Fatalf("Failed to create obfuscator: %v", err)
+	}
+	for _, id := range []string{UUIDv7{}.NewID(), UUIDv4{}.NewID(), uuid.Nil.String(), "ffffffff-ffff-ffff-ffff-ffffffffffff"} {
+		public := o.Encode(id)
+		if len(public) != 22 || strings.Contains(public, "-") {
+			t.Errorf("Expected a 22 character identifier for %s, got %q", id, public)
+		}
+		if o.Encode(id) != public {
+			t.Errorf("Expected %s to always encode to %q", id, public)
+		}
+		decoded, ok := o.Decode(public)
+		if !ok || decoded != id {
+			t.Errorf("Expected %q to decode to %s, got %q (%v)", public, id, decoded, ok)
+		}
+	}
+
+	first, second := UUIDv7{}.NewID(), UUIDv7{}.NewID()
+	if o.Encode(first)[:4] == o.Encode(second)[:4] {
+		t.Errorf("Expected identifiers created together not to share a prefix, got %q and %q", o.Encode(first), o.Encode(second))
+	}
+	other, _ := NewObfuscator("another secret", "")
+	if other.Encode(first) == o.Encode(first) {
+		t.Error("Expected deployments with different secrets to show different identifiers")
+	}
+}
+
+// TestObfuscatorDecode tests that UUIDs pass through and malformed identifiers are rejected
+func TestObfuscatorDecode(t *testing.T) {
+	o, _ := NewObfuscator("deployment secret", "")
+	id := UUIDv4{}.NewID()
+	if decoded, ok := o.Decode(id); !ok || decoded != id {
+		t.Errorf("Expected the UUID to be accepted as it is, got %q (%v)", decoded, ok)
+	}
+	if o.Encode("not-a-uuid") != "not-a-uuid" {
+		t.Error("Expected identifiers that aren't UUIDs to be shown unchanged")
+	}
+	for _, public := range []string{"", "abc", strings.Repeat("z", 22), strings.Repeat("0", 21) + "-", strings.Repeat("0", 23), "not-a-uuid"} {
+		if decoded, ok := o.Decode(public); ok {
+			t.Errorf("Expected %q to be rejected, got %q", public, decoded)
+		}
+	}
+}
+
+// TestObfuscatorAlphabet tests custom alphabets and rejects invalid settings
+func TestObfuscatorAlphabet(t *testing.T) {
+	o, err := NewObfuscator("secret", "0123456789abcdef")
+	if err != nil {
+		t.Fatalf("Failed to create obfuscator: %v", err)
+	}
+	id := UUIDv7{}.NewID()
+	public := o.Encode(id)
+	if len(public) != 32 || strings.Trim(public, "0123456789abcdef") != "" {
+		t.Errorf("Expected 32 hex characters, got %q", public)
+	}
+	if decoded, _ := o.Decode(public); decoded != id {
+		t.Errorf("Expected %q to decode to %s, got %q", public, id, decoded)
+	}
+
+	for name, settings := range map[string][2]string{
+		"no secret":      {"", ""},
+		"short alphabet": {"secret", "abcdef"},
+		"repeated":       {"secret", "0123456789abcdeff"},
+		"punctuation":    {"secret", "0123456789abcdef-"},
+	} {
+		if _, err := NewObfuscator(settings[0], settings[1]); err == nil {
+			t.Errorf("Expected %s to be rejected", name)
+		}
+	}
+}
+
+// TestPlain tests that the default codec shows UUIDs unchanged
+func TestPlain(t *testing.T) {
+	id := New()
+	if (Plain{}).Encode(id) != id {
+		t.Error("Expected the UUID to be shown unchanged")
+	}
+	if decoded, ok := (Plain{}).Decode(id); !ok || decoded != id {
+		t.Errorf("Expected the UUID to be accepted, got %q (%v)", decoded, ok)
+	}
+	if _, ok := (Plain{}).Decode("abc"); ok {
+		t.Error("Expected a non-UUID to be rejected")
+	}
+}
@@ -0,0 +1,252 @@
+// Package jobs runs background work, such as emails and webhooks, outside of the
+// HTTP requests that cause it. Jobs are stored in the jobs table before they run, so
+// work enqueued by a request survives a restart, and failed jobs are retried with
+// backoff. A Pool runs them with in-process workers; a broker such as Redis or
+// RabbitMQ can take its place by implementing Queue.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"event_booking_restapi_golang/logging"
+	"event_booking_restapi_golang/models"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// MaxAttempts is how many times a job is tried before it is kept as failed.
+const MaxAttempts = 5
+
+// retryBackoff is the delay before the first retry of a failed job. It doubles with
+// every further attempt.
+const retryBackoff = 30 * time.Second
+
+// runTimeout bounds one attempt at a job.
+const runTimeout = 30 * time.Second
+
+// staleAfter is how long a job can be claimed before it is assumed its worker died
+// and it is run again. It is well above runTimeout.
+const staleAfter = 5 * time.Minute
+
+// pollInterval is how often idle workers look for jobs that became due, such as
+// retries and jobs enqueued by other instances.
+const pollInterval = time.Second
+
+// ErrClosed is returned for jobs enqueued after the pool was closed.
+var ErrClosed = errors.New("the job queue is shut down")
+
+// Handler runs a job with the JSON payload it was enqueued with.
+type Handler func(ctx context.Context, payload []byte) error
+
+// Queue runs jobs in the background.
+type Queue interface {
+	// Handle registers the handler that runs jobs of the kind.
+	Handle(kind string, handler Handler)
+	// Enqueue queues a job of the kind whose payload is encoded as JSON.
+	Enqueue(ctx context.Context, kind string, payload any) error
+}
+
+// attemptKey is the context key of the number of the attempt at the job a handler runs.
+type attemptKey struct{}
+
+// LastAttempt reports whether the job a handler runs with ctx won't be retried if it
+// fails, so the handler can record the failure.
+func LastAttempt(ctx context.Context) bool {
+	attempt, _ := ctx.Value(attemptKey{}).(int)
+	return attempt >= MaxAttempts
+}
+
+// permanentError marks a failure that retrying can't fix.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent wraps a handler's error to keep the job as failed without retrying it,
+// such as for a payload that can't be decoded.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// Pool runs the jobs stored in the jobs table with a fixed number of workers. Jobs
+// enqueued by this instance wake an idle worker at once; others are picked up within
+// a second.
+type Pool struct {
+	workers  int
+	handlers map[string]Handler
+	kinds    []string
+	wake     chan struct{}
+	stop     chan struct{}
+	running  sync.WaitGroup
+
+	mu      sync.RWMutex // Guards closed against enqueues racing Close
+	closed  bool
+	started bool
+}
+
+// New returns a pool with the given number of workers. Handlers are registered with
+// Handle before the pool is started with Start.
+func New(workers int) *Pool {
+	return &Pool{
+		workers:  max(workers, 1),
+		handlers: map[string]Handler{},
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Handle registers the handler that runs jobs of the kind. It panics if the pool was
+// started or the kind already has a handler.
+func (p *Pool) Handle(kind string, handler Handler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.started {
+		panic("jobs: Handle called after Start")
+	}
+	if _, ok := p.handlers[kind]; ok {
+		panic("jobs: duplicate handler for " + kind)
+	}
+	p.handlers[kind] = handler
+	p.kinds = append(p.kinds, kind)
+}
+
+// Enqueue stores a job of the kind with the payload encoded as JSON, to run as soon as
+// a worker is free. Nothing is stored under a dry run. The ID of the request in ctx is
+// kept with the job for its logs.
+// Returns an error if the kind has no handler, the payload can't be encoded or the
+// job can't be stored.
+func (p *Pool) Enqueue(ctx context.Context, kind string, payload any) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrClosed
+	}
+	if _, ok := p.handlers[kind]; !ok {
+		return fmt.Errorf("no handler for %s jobs", kind)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if models.IsDryRun(ctx) {
+		return nil
+	}
+	_, err = models.EnqueueJob(ctx, kind, data, time.Now(), logging.RequestID(ctx))
+	if err != nil {
+		return err
+	}
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Start releases the jobs a previous run of the server left claimed and starts the
+// workers in background goroutines.
+func (p *Pool) Start() {
+	p.mu.Lock()
+	p.started = true
+	p.mu.Unlock()
+	released, err := models.ReleaseStaleJobs(context.Background(), time.Now().Add(-staleAfter))
+	if err != nil {
+		slog.Error("Couldn't release stale jobs", "error", err)
+	} else if released > 0 {
+		slog.Info("Released stale jobs", "jobs", released)
+	}
+	for i := 0; i < p.workers; i++ {
+		p.running.Add(1)
+		go p.work()
+	}
+}
+
+// Close stops accepting jobs and waits until the workers finish the jobs they are
+// running or ctx is done, whichever comes first. Jobs still pending stay stored and
+// run once the server is started again.
+func (p *Pool) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.stop)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// work runs due jobs until the pool is closed, waiting for a wake-up or the next
+// poll when there are none.
+func (p *Pool) work() {
+	defer p.running.Done()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		default:
+		}
+		ran, err := p.RunNext(context.Background())
+		if err != nil {
+			slog.Error("Couldn't claim a job", "error", err)
+		}
+		if ran {
+			continue
+		}
+		select {
+		case <-p.stop:
+			return
+		case <-p.wake:
+		case <-ticker.C:
+			models.ReleaseStaleJobs(context.Background(), time.Now().Add(-staleAfter))
+		}
+	}
+}
+
+// RunNext claims the earliest due job and runs it. A failed job is retried after a
+// backoff that doubles with each attempt, until it has been tried MaxAttempts times
+// or its handler returns a Permanent error.
+// Returns false if no job was due.
+func (p *Pool) RunNext(ctx context.Context) (bool, error) {
+	job, ok, err := models.ClaimJob(ctx, p.kinds, time.Now())
+	if err != nil || !ok {
+		return false, err
+	}
+	runCtx := context.WithValue(logging.WithRequestID(ctx, job.RequestID), attemptKey{}, job.Attempts)
+	runCtx, cancel := context.WithTimeout(runCtx, runTimeout)
+	err = p.handlers[job.Kind](runCtx, job.Payload)
+	cancel()
+
+	switch {
+	case err == nil:
+		err = models.CompleteJob(ctx, job.ID)
+	case job.Attempts >= MaxAttempts || errors.As(err, new(permanentError)):
+		slog.Error("Gave up on the job", "kind", job.Kind, "job_id", job.ID, "attempts", job.Attempts, "request_id", job.RequestID, "error", err)
+		err = models.FailJob(ctx, job.ID, err.Error())
+	default:
+		retryAt := time.Now().Add(retryBackoff << (job.Attempts - 1))
+		slog.Warn("Job failed, retrying", "kind", job.Kind, "job_id", job.ID, "attempts", job.Attempts, "retry_at", retryAt, "request_id", job.RequestID, "error", err)
+		err = models.RetryJob(ctx, job.ID, retryAt, err.Error())
+	}
+	return true, err
+}
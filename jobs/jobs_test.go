@@ -0,0 +1,177 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/testutils"
+	"testing"
+	"time"
+)
+
+// jobStatus returns the status, attempts and last error of the only stored job
+func jobStatus(t *testing.T, tdb *testutils.TestDB) (string, int, string) {
+	t.Helper()
+	var status, lastError string
+	var attempts int
+	err := tdb.DB.QueryRow("SELECT status, attempts, last_error FROM jobs").Scan(&status, &attempts, &lastError)
+	if err != nil {
+		t.Fatalf("Failed to read the job: %v", err)
+	}
+	return status, attempts, lastError
+}
+
+// dueNow makes every stored job due, skipping the retry backoff
+func dueNow(t *testing.T, tdb *testutils.TestDB) {
+	t.Helper()
+	_, err := tdb.DB.Exec("UPDATE jobs SET run_at = ?", time.Now().Add(-time.Second).UTC())
+	if err != nil {
+		t.Fatalf("Failed to make the jobs due: %v", err)
+	}
+}
+
+// TestRunNext tests that jobs run with their payload and request ID and are deleted once they succeed
+func TestRunNext(t *testing.T) {
+	tdb := testutils.SetupTestDatabase(t)
+	defer tdb.Cleanup()
+	pool := New(1)
+	var got []string
+	pool.Handle("greet", func(ctx context.Context, payload []byte) error {
+		got = append(got, string(payload))
+		return nil
+	})
+
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := pool.Enqueue(context.Background(), "greet", map[string]string{"name": name}); err != nil {
+			t.Fatalf("Failed to enqueue: %v", err)
+		}
+	}
+	if err := pool.Enqueue(context.Background(), "unknown", nil); err == nil {
+		t.Error("Expected a job without a handler to be rejected")
+	}
+	if err := pool.Enqueue(models.WithDryRun(context.Background()), "greet", map[string]string{"name": "Carol"}); err != nil {
+		t.Errorf("Expected a dry run enqueue to succeed, got %v", err)
+	}
+
+	for {
+		ran, err := pool.RunNext(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to run the job: %v", err)
+		}
+		if !ran {
+			break
+		}
+	}
+	if len(got) != 2 || got[0] != `{"name":"Alice"}` || got[1] != `{"name":"Bob"}` {
+		t.Errorf("Expected the jobs to run in order, got %v", got)
+	}
+	var left int
+	tdb.DB.QueryRow("SELECT COUNT(*) FROM jobs").Scan(&left)
+	if left != 0 {
+		t.Errorf("Expected the finished jobs to be deleted, %d left", left)
+	}
+}
+
+// TestRetry tests that failed jobs are retried with backoff until they run out of attempts
+func TestRetry(t *testing.T) {
+	tdb := testutils.SetupTestDatabase(t)
+	defer tdb.Cleanup()
+	pool := New(1)
+	var last []bool
+	pool.Handle("flaky", func(ctx context.Context, payload []byte) error {
+		last = append(last, LastAttempt(ctx))
+		return errors.New("provider down")
+	})
+	if err := pool.Enqueue(context.Background(), "flaky", "payload"); err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+
+	if ran, err := pool.RunNext(context.Background()); !ran || err != nil {
+		t.Fatalf("Expected the job to run, got %v, %v", ran, err)
+	}
+	if ran, _ := pool.RunNext(context.Background()); ran {
+		t.Error("Expected the retry to wait for its backoff")
+	}
+	var runAt time.Time
+	tdb.DB.QueryRow("SELECT run_at FROM jobs").Scan(&runAt)
+	if wait := time.Until(runAt); wait < retryBackoff-time.Second || wait > retryBackoff {
+		t.Errorf("Expected the retry in %v, got %v", retryBackoff, wait)
+	}
+
+	for i := 1; i < MaxAttempts; i++ {
+		dueNow(t, tdb)
+		if ran, err := pool.RunNext(context.Background()); !ran || err != nil {
+			t.Fatalf("Expected attempt %d to run, got %v, %v", i+1, ran, err)
+		}
+	}
+	status, attempts, lastError := jobStatus(t, tdb)
+	if status != models.JobFailed || attempts != MaxAttempts || lastError != "provider down" {
+		t.Errorf("Expected the job to fail after %d attempts, got %s after %d: %s", MaxAttempts, status, attempts, lastError)
+	}
+	if len(last) != MaxAttempts || last[0] || !last[MaxAttempts-1] {
+		t.Errorf("Expected only the last attempt to be reported as such, got %v", last)
+	}
+	dueNow(t, tdb)
+	if ran, _ := pool.RunNext(context.Background()); ran {
+		t.Error("Expected failed jobs not to run again")
+	}
+}
+
+// TestPermanent tests that permanent failures aren't retried
+func TestPermanent(t *testing.T) {
+	tdb := testutils.SetupTestDatabase(t)
+	defer tdb.Cleanup()
+	pool := New(1)
+	pool.Handle("broken", func(ctx context.Context, payload []byte) error {
+		return Permanent(errors.New("invalid payload"))
+	})
+	pool.Enqueue(context.Background(), "broken", nil)
+	pool.RunNext(context.Background())
+	if status, attempts, _ := jobStatus(t, tdb); status != models.JobFailed || attempts != 1 {
+		t.Errorf("Expected the job to fail at once, got %s after %d attempts", status, attempts)
+	}
+}
+
+// TestStartAndClose tests that workers pick up stored and new jobs, and that closed pools refuse jobs
+func TestStartAndClose(t *testing.T) {
+	tdb := testutils.SetupTestDatabase(t)
+	defer tdb.Cleanup()
+	// A job claimed by a worker of a previous run that never finished it
+	_, err := tdb.DB.Exec("INSERT INTO jobs (id, kind, payload, status, attempts, run_at, locked_at, created_at) VALUES (?,?,?,?,?,?,?,?)",
+		"stale", "record", `"stale"`, models.JobRunning, 1, time.Now().Add(-time.Hour).UTC(), time.Now().Add(-time.Hour).UTC(), time.Now().Add(-time.Hour).UTC())
+	if err != nil {
+		t.Fatalf("Failed to insert the job: %v", err)
+	}
+
+	pool := New(2)
+	done := make(chan string, 2)
+	pool.Handle("record", func(ctx context.Context, payload []byte) error {
+		done <- string(payload)
+		return nil
+	})
+	pool.Start()
+	if err := pool.Enqueue(context.Background(), "record", "new"); err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+	got := map[string]bool{}
+	for len(got) < 2 {
+		select {
+		case payload := <-done:
+			got[payload] = true
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Expected the stale and the new job to run, got %v", got)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := pool.Close(ctx); err != nil {
+		t.Fatalf("Failed to close the pool: %v", err)
+	}
+	if err := pool.Enqueue(context.Background(), "record", "late"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed after Close, got %v", err)
+	}
+	if err := pool.Close(context.Background()); err != nil {
+		t.Errorf("Expected closing twice to succeed, got %v", err)
+	}
+}
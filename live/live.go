@@ -0,0 +1,64 @@
+// Package live tells the connections following an event's attendance when its
+// registrations or check-ins change, so they can push the new counts to organizers
+// running the doors without polling. Changes are only known within this process;
+// connections also re-read the counts periodically to pick up changes made elsewhere.
+package live
+
+import "sync"
+
+// Hub tracks the watchers of each event. A nil *Hub is valid and notifies nobody.
+type Hub struct {
+	mu       sync.Mutex
+	watchers map[string]map[chan struct{}]struct{} // Event ID to the channels of its watchers
+}
+
+// NewHub returns a hub without watchers.
+func NewHub() *Hub {
+	return &Hub{watchers: map[string]map[chan struct{}]struct{}{}}
+}
+
+// Watch registers a watcher of the event. The returned channel receives a value when
+// the event's attendance changes; changes made while a value is pending are merged
+// into it, so slow watchers never hold up Notify. Call stop when done watching.
+func (h *Hub) Watch(eventID string) (changed <-chan struct{}, stop func()) {
+	ch := make(chan struct{}, 1)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.watchers[eventID] == nil {
+		h.watchers[eventID] = map[chan struct{}]struct{}{}
+	}
+	h.watchers[eventID][ch] = struct{}{}
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.watchers[eventID], ch)
+		if len(h.watchers[eventID]) == 0 {
+			delete(h.watchers, eventID)
+		}
+	}
+}
+
+// Notify tells the watchers of the event that its attendance changed.
+func (h *Hub) Notify(eventID string) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.watchers[eventID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Watchers returns the number of watchers of the event.
+func (h *Hub) Watchers(eventID string) int {
+	if h == nil {
+		return 0
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.watchers[eventID])
+}
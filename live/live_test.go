@@ -0,0 +1,45 @@
+package live
+
+import "testing"
+
+// TestHub tests that watchers are told of changes to their event only, and that
+// pending changes are merged
+func TestHub(t *testing.T) {
+	hub := NewHub()
+	changed, stop := hub.Watch("e1")
+	other, stopOther := hub.Watch("e2")
+	defer stopOther()
+
+	hub.Notify("e1")
+	hub.Notify("e1")
+	select {
+	case <-changed:
+	default:
+		t.Fatal("Expected the watcher to be notified")
+	}
+	select {
+	case <-changed:
+		t.Error("Expected the two changes to be merged into one notification")
+	default:
+	}
+	select {
+	case <-other:
+		t.Error("Expected the watcher of another event not to be notified")
+	default:
+	}
+
+	if n := hub.Watchers("e1"); n != 1 {
+		t.Errorf("Expected 1 watcher, got %d", n)
+	}
+	stop()
+	if n := hub.Watchers("e1"); n != 0 {
+		t.Errorf("Expected no watchers after stopping, got %d", n)
+	}
+	hub.Notify("e1")
+
+	var disabled *Hub
+	disabled.Notify("e1")
+	if disabled.Watchers("e1") != 0 {
+		t.Error("Expected a nil hub to have no watchers")
+	}
+}
@@ -0,0 +1,68 @@
+// Package logging sets up the structured JSON logger and carries a request-scoped
+// logger and the request ID through contexts, so that log lines written while serving
+// a request share its request ID and route, and calls to other services can pass the
+// ID on.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+)
+
+// RequestIDHeader carries the ID that ties a request to its log lines, both on
+// requests to the API and on the requests it makes to other services.
+const RequestIDHeader = "X-Request-ID"
+
+// contextKey is the context key the request-scoped logger is stored under.
+type contextKey struct{}
+
+// requestIDKey is the context key the request ID is stored under.
+type requestIDKey struct{}
+
+// New returns a logger writing JSON lines at level and above to w.
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}))
+}
+
+// WithLogger returns a copy of ctx carrying logger.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or the default logger when it
+// carries none.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID carried by ctx, or "" outside of a request.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// SetRequestID sets the RequestIDHeader of an outgoing request to the request ID
+// carried by its context, if any, so the other service can log the same ID.
+func SetRequestID(req *http.Request) {
+	if requestID := RequestID(req.Context()); requestID != "" {
+		req.Header.Set(RequestIDHeader, requestID)
+	}
+}
+
+// Fatal logs msg with err at error level on the default logger and exits with
+// status 1. It is for startup failures the server can't run without.
+func Fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
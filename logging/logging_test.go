@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+)
+
+// TestNew tests that the logger writes JSON lines and honours the level
+func TestNew(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(&out, slog.LevelInfo)
+	logger.Debug("hidden")
+	logger.Info("shown", "request_id", "r1")
+
+	var line map[string]any
+	if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+		t.Fatalf("Expected a single JSON line, got %q: %v", out.String(), err)
+	}
+	if line["msg"] != "shown" || line["level"] != "INFO" || line["request_id"] != "r1" {
+		t.Errorf("Unexpected log line %v", line)
+	}
+}
+
+// TestFromContext tests carrying a logger through a context
+func TestFromContext(t *testing.T) {
+	if FromContext(context.Background()) != slog.Default() {
+		t.Error("Expected the default logger for a context without one")
+	}
+	logger := New(&bytes.Buffer{}, slog.LevelInfo)
+	ctx := WithLogger(context.Background(), logger)
+	if FromContext(ctx) != logger {
+		t.Error("Expected the logger carried by the context")
+	}
+}
+
+// TestRequestID tests carrying the request ID through a context onto outgoing requests
+func TestRequestID(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://scanner.internal/scan", nil)
+	SetRequestID(req)
+	if RequestID(req.Context()) != "" || req.Header.Get(RequestIDHeader) != "" {
+		t.Error("Expected no request ID outside of a request")
+	}
+
+	req = req.WithContext(WithRequestID(context.Background(), "req-1"))
+	SetRequestID(req)
+	if RequestID(req.Context()) != "req-1" || req.Header.Get(RequestIDHeader) != "req-1" {
+		t.Errorf("Expected the request ID to be passed on, got %q", req.Header.Get(RequestIDHeader))
+	}
+}
@@ -0,0 +1,158 @@
+// Package mail sends email through an SMTP relay or SendGrid. Messages are plain
+// text; the display name and reply-to address can be set per message, so emails
+// about an event can come from its organizer.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// sendTimeout bounds the SMTP conversation for one message.
+const sendTimeout = 30 * time.Second
+
+// Message is a plain text email.
+type Message struct {
+	To       string // Recipient address
+	FromName string // Display name shown with the sender address, optional
+	ReplyTo  string // Address replies go to, optional
+	Subject  string
+	Body     string
+}
+
+// Mailer sends email.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// smtpMailer sends email through an SMTP relay, using STARTTLS when the relay offers it.
+type smtpMailer struct {
+	addr     string
+	from     string
+	username string
+	password string
+}
+
+// NewSMTPMailer returns a Mailer that relays through the SMTP server at addr
+// (host:port) as from, authenticating with username and password when a username
+// is given.
+func NewSMTPMailer(addr, from, username, password string) (Mailer, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return nil, fmt.Errorf("SMTP address %q must be host:port", addr)
+	}
+	if _, err := mail.ParseAddress(from); err != nil {
+		return nil, fmt.Errorf("sender address %q is invalid: %w", from, err)
+	}
+	return &smtpMailer{addr: addr, from: from, username: username, password: password}, nil
+}
+
+// FromEnv builds the mailer from the environment. SENDGRID_API_KEY selects SendGrid,
+// with SENDGRID_ENDPOINT to override its API; otherwise SMTP_ADDR, SMTP_USERNAME and
+// SMTP_PASSWORD select an SMTP relay. Both send as MAIL_FROM.
+// Returns nil when neither is set, which disables email.
+func FromEnv() (Mailer, error) {
+	if apiKey := os.Getenv("SENDGRID_API_KEY"); apiKey != "" {
+		return NewSendGridMailer(apiKey, os.Getenv("MAIL_FROM"), os.Getenv("SENDGRID_ENDPOINT"))
+	}
+	addr := os.Getenv("SMTP_ADDR")
+	if addr == "" {
+		return nil, nil
+	}
+	return NewSMTPMailer(addr, os.Getenv("MAIL_FROM"), os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"))
+}
+
+// Send delivers the message to the relay.
+func (m *smtpMailer) Send(ctx context.Context, msg Message) error {
+	data, err := msg.build(m.from, time.Now())
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	host, _, _ := net.SplitHostPort(m.addr)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		err = client.StartTLS(&tls.Config{ServerName: host})
+		if err != nil {
+			return err
+		}
+	}
+	if m.username != "" {
+		err = client.Auth(smtp.PlainAuth("", m.username, m.password, host))
+		if err != nil {
+			return err
+		}
+	}
+	err = client.Mail(m.from)
+	if err != nil {
+		return err
+	}
+	err = client.Rcpt(msg.To)
+	if err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	if err != nil {
+		return err
+	}
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// build renders the message with its headers as sent by from at date. Header values
+// are checked for line breaks, so they can't add headers of their own.
+func (msg Message) build(from string, date time.Time) ([]byte, error) {
+	for _, value := range []string{msg.To, msg.FromName, msg.ReplyTo, msg.Subject} {
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, errors.New("email headers can't contain line breaks")
+		}
+	}
+	sender := from
+	if msg.FromName != "" {
+		sender = (&mail.Address{Name: msg.FromName, Address: from}).String()
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", sender)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	if msg.ReplyTo != "" {
+		fmt.Fprintf(&b, "Reply-To: %s\r\n", msg.ReplyTo)
+	}
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	body := strings.ReplaceAll(msg.Body, "\r\n", "\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return b.Bytes(), nil
+}
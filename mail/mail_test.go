@@ -0,0 +1,152 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestBuild tests the rendered headers and body
+func TestBuild(t *testing.T) {
+	msg := Message{
+		To:       "attendee@example.com",
+		FromName: "Jazz Club",
+		ReplyTo:  "bookings@jazz.example.com",
+		Subject:  "Your ticket",
+		Body:     "See you there.\nBring the ticket.",
+	}
+	data, err := msg.build("events@example.com", time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Failed to build message: %v", err)
+	}
+	rendered := string(data)
+	for _, expected := range []string{
+		"From: \"Jazz Club\" <events@example.com>\r\n",
+		"To: attendee@example.com\r\n",
+		"Reply-To: bookings@jazz.example.com\r\n",
+		"Subject: Your ticket\r\n",
+		"Date: Tue, 10 Mar 2026 12:00:00 +0000\r\n",
+		"\r\n\r\nSee you there.\r\nBring the ticket.",
+	} {
+		if !strings.Contains(rendered, expected) {
+			t.Errorf("Expected %q in %q", expected, rendered)
+		}
+	}
+
+	msg.ReplyTo = ""
+	data, _ = msg.build("events@example.com", time.Now())
+	if strings.Contains(string(data), "Reply-To") {
+		t.Errorf("Expected no Reply-To header, got %q", data)
+	}
+}
+
+// TestBuildHeaderInjection tests that header values can't contain line breaks
+func TestBuildHeaderInjection(t *testing.T) {
+	for _, msg := range []Message{
+		{To: "a@example.com", FromName: "Club\r\nBcc: victim@example.com"},
+		{To: "a@example.com", Subject: "Hi\nBcc: victim@example.com"},
+		{To: "a@example.com", ReplyTo: "b@example.com\r\nX-Spam: yes"},
+	} {
+		_, err := msg.build("events@example.com", time.Now())
+		if err == nil {
+			t.Errorf("Expected %+v to be rejected", msg)
+		}
+	}
+}
+
+// fakeSMTPServer accepts one message and sends what it received on the channel
+func fakeSMTPServer(t *testing.T) (string, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		text := textproto.NewConn(conn)
+		text.PrintfLine("220 fake ESMTP")
+		var transcript strings.Builder
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			transcript.WriteString(line + "\n")
+			switch {
+			case strings.HasPrefix(line, "EHLO"), strings.HasPrefix(line, "HELO"):
+				text.PrintfLine("250 fake")
+			case line == "DATA":
+				text.PrintfLine("354 go ahead")
+				data, _ := bufio.NewReader(text.DotReader()).ReadString(0)
+				transcript.WriteString(data)
+				text.PrintfLine("250 queued")
+			case line == "QUIT":
+				text.PrintfLine("221 bye")
+				received <- transcript.String()
+				return
+			default:
+				text.PrintfLine("250 ok")
+			}
+		}
+	}()
+	return listener.Addr().String(), received
+}
+
+// TestSMTPMailerSend tests relaying a message through an SMTP server
+func TestSMTPMailerSend(t *testing.T) {
+	addr, received := fakeSMTPServer(t)
+	mailer, err := NewSMTPMailer(addr, "events@example.com", "", "")
+	if err != nil {
+		t.Fatalf("Failed to create mailer: %v", err)
+	}
+	err = mailer.Send(context.Background(), Message{To: "organizer@example.com", Subject: "Confirm", Body: "Code: 1234"})
+	if err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	select {
+	case transcript := <-received:
+		for _, expected := range []string{"MAIL FROM:<events@example.com>", "RCPT TO:<organizer@example.com>", "Subject: Confirm", "Code: 1234"} {
+			if !strings.Contains(transcript, expected) {
+				t.Errorf("Expected %q in the SMTP conversation %q", expected, transcript)
+			}
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the server to receive the message")
+	}
+}
+
+// TestFromEnv tests mailer configuration from the environment
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		addr    string
+		from    string
+		enabled bool
+		wantErr bool
+	}{
+		{"disabled", "", "", false, false},
+		{"relay", "smtp.example.com:587", "events@example.com", true, false},
+		{"missing port", "smtp.example.com", "events@example.com", false, true},
+		{"invalid sender", "smtp.example.com:587", "events", false, true},
+	}
+	t.Setenv("SENDGRID_API_KEY", "")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SMTP_ADDR", tt.addr)
+			t.Setenv("MAIL_FROM", tt.from)
+			mailer, err := FromEnv()
+			if (err != nil) != tt.wantErr || (mailer != nil) != tt.enabled {
+				t.Errorf("Expected enabled %v and error %v, got %v, %v", tt.enabled, tt.wantErr, mailer, err)
+			}
+		})
+	}
+}
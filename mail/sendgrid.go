@@ -0,0 +1,114 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"strings"
+)
+
+// defaultSendGridEndpoint is the base URL of SendGrid's v3 Web API.
+const defaultSendGridEndpoint = "https://api.sendgrid.com"
+
+// sendGridMailer sends email through SendGrid's v3 mail send API.
+type sendGridMailer struct {
+	apiKey   string
+	from     string
+	endpoint string
+	client   *http.Client
+}
+
+// sendGridAddress is an email address in SendGrid's request format.
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+// sendGridPersonalization lists the recipients of a mail send request.
+type sendGridPersonalization struct {
+	To []sendGridAddress `json:"to"`
+}
+
+// sendGridContent is a body of a mail send request in one content type.
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// sendGridRequest is the body of a mail send request.
+type sendGridRequest struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	ReplyTo          *sendGridAddress          `json:"reply_to,omitempty"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+}
+
+// NewSendGridMailer returns a Mailer that sends as from through SendGrid's
+// /v3/mail/send endpoint, authenticated with the API key. The endpoint defaults to
+// https://api.sendgrid.com; set it to use SendGrid's EU region or a test server.
+func NewSendGridMailer(apiKey, from, endpoint string) (Mailer, error) {
+	if apiKey == "" {
+		return nil, errors.New("the SendGrid mailer needs an API key")
+	}
+	if _, err := mail.ParseAddress(from); err != nil {
+		return nil, fmt.Errorf("sender address %q is invalid: %w", from, err)
+	}
+	if endpoint == "" {
+		endpoint = defaultSendGridEndpoint
+	}
+	return &sendGridMailer{
+		apiKey:   apiKey,
+		from:     from,
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		client:   &http.Client{Timeout: sendTimeout},
+	}, nil
+}
+
+// Send posts the message to SendGrid, which accepts it for delivery.
+func (m *sendGridMailer) Send(ctx context.Context, msg Message) error {
+	for _, value := range []string{msg.To, msg.FromName, msg.ReplyTo, msg.Subject} {
+		if strings.ContainsAny(value, "\r\n") {
+			return errors.New("email headers can't contain line breaks")
+		}
+	}
+	request := sendGridRequest{
+		Personalizations: []sendGridPersonalization{{To: []sendGridAddress{{Email: msg.To}}}},
+		From:             sendGridAddress{Email: m.from, Name: msg.FromName},
+		Subject:          msg.Subject,
+		Content:          []sendGridContent{{Type: "text/plain", Value: msg.Body}},
+	}
+	if msg.ReplyTo != "" {
+		request.ReplyTo = &sendGridAddress{Email: msg.ReplyTo}
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.endpoint+"/v3/mail/send", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+m.apiKey)
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("SendGrid rejected the email with status %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestSendGridMailerSend tests the request posted to SendGrid's mail send API
+func TestSendGridMailerSend(t *testing.T) {
+	var got sendGridRequest
+	var auth, path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, path = r.Header.Get("Authorization"), r.URL.Path
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	mailer, err := NewSendGridMailer("SG.key", "events@example.com", server.URL+"/")
+	if err != nil {
+		t.Fatalf("Failed to create mailer: %v", err)
+	}
+	err = mailer.Send(context.Background(), Message{
+		To:       "attendee@example.com",
+		FromName: "Jazz Club",
+		ReplyTo:  "bookings@jazz.example.com",
+		Subject:  "Your ticket",
+		Body:     "See you there.",
+	})
+	if err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	if auth != "Bearer SG.key" || path != "/v3/mail/send" {
+		t.Errorf("Expected an authenticated request to /v3/mail/send, got %q to %q", auth, path)
+	}
+	if len(got.Personalizations) != 1 || got.Personalizations[0].To[0].Email != "attendee@example.com" ||
+		got.From != (sendGridAddress{Email: "events@example.com", Name: "Jazz Club"}) ||
+		got.ReplyTo == nil || got.ReplyTo.Email != "bookings@jazz.example.com" ||
+		got.Subject != "Your ticket" || got.Content[0].Value != "See you there." {
+		t.Errorf("Unexpected request %+v", got)
+	}
+}
+
+// TestSendGridMailerErrors tests rejected messages and requests
+func TestSendGridMailerErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"errors":[{"message":"The from address does not match a verified Sender Identity."}]}`, http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	mailer, _ := NewSendGridMailer("SG.key", "events@example.com", server.URL)
+	err := mailer.Send(context.Background(), Message{To: "attendee@example.com", Subject: "Hi"})
+	if err == nil || !strings.Contains(err.Error(), "403") || !strings.Contains(err.Error(), "Sender Identity") {
+		t.Errorf("Expected the rejection with SendGrid's reason, got %v", err)
+	}
+	err = mailer.Send(context.Background(), Message{To: "attendee@example.com", Subject: "Hi\r\nBcc: victim@example.com"})
+	if err == nil {
+		t.Error("Expected a subject with a line break to be rejected")
+	}
+
+	if _, err := NewSendGridMailer("", "events@example.com", ""); err == nil {
+		t.Error("Expected a missing API key to be rejected")
+	}
+	if _, err := NewSendGridMailer("SG.key", "events", ""); err == nil {
+		t.Error("Expected an invalid sender to be rejected")
+	}
+}
+
+// TestFromEnvSendGrid tests that an API key selects SendGrid over SMTP
+func TestFromEnvSendGrid(t *testing.T) {
+	t.Setenv("SENDGRID_API_KEY", "SG.key")
+	t.Setenv("SMTP_ADDR", "smtp.example.com:587")
+	t.Setenv("MAIL_FROM", "events@example.com")
+	mailer, err := FromEnv()
+	if err != nil {
+		t.Fatalf("Failed to create mailer: %v", err)
+	}
+	if _, ok := mailer.(*sendGridMailer); !ok {
+		t.Errorf("Expected a SendGrid mailer, got %T", mailer)
+	}
+}
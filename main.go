@@ -3,18 +3,290 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"event_booking_restapi_golang/alerts"
+	"event_booking_restapi_golang/analytics"
+	"event_booking_restapi_golang/audit"
+	"event_booking_restapi_golang/config"
 	"event_booking_restapi_golang/db"
+	"event_booking_restapi_golang/feeds"
+	"event_booking_restapi_golang/grpcapi"
+	"event_booking_restapi_golang/holidays"
+	"event_booking_restapi_golang/ids"
+	"event_booking_restapi_golang/jobs"
+	"event_booking_restapi_golang/logging"
+	"event_booking_restapi_golang/mail"
+	"event_booking_restapi_golang/middlewares"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/notifications"
+	"event_booking_restapi_golang/payments"
 	"event_booking_restapi_golang/routes"
+	"event_booking_restapi_golang/statuspage"
+	"event_booking_restapi_golang/summaries"
+	"event_booking_restapi_golang/validation"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
 )
 
-// main is the application entry point.
-// It initializes the database connection, creates a Gin HTTP server,
-// registers all API routes, and starts the server on port 8080.
+// main is the application entry point. It loads the configuration, sets up the
+// database, background jobs and integrations the environment enables, and serves the
+// API over HTTP and, when configured, gRPC. Read-only deployments serve only the public
+// reads and leave the writing jobs to the main instance.
+// On SIGINT or SIGTERM it drains in-flight requests, flushes buffered writes and closes
+// the database before exiting.
 func main() {
-	db.InitDB()
-	server := gin.Default()
-	routes.RegisterRoutes(server)
-	server.Run(":8080")
+	cfg, err := config.Load()
+	if err != nil {
+		logging.Fatal("Couldn't load configuration", err)
+	}
+	logger := logging.New(os.Stdout, cfg.LogLevel)
+	// Routes the standard log package, used by dependencies, through the JSON logger too
+	slog.SetDefault(logger)
+	gin.SetMode(cfg.GinMode)
+	models.AttachmentsDir = cfg.AttachmentsDir
+	models.AuditEnabled = cfg.AuditLog
+	if cfg.IDVersion == "v4" {
+		ids.Default = ids.UUIDv4{}
+	}
+	if cfg.PublicIDSecret != "" {
+		ids.Public, err = ids.NewObfuscator(cfg.PublicIDSecret, cfg.PublicIDChars)
+		if err != nil {
+			logging.Fatal("Couldn't set up public identifiers", err)
+		}
+	}
+	models.FreeEventLimit = cfg.FreeEventLimit
+	models.PaidUsers = cfg.PaidUsers()
+	models.TicketSecret = []byte(cfg.TicketSecret)
+	if len(models.TicketSecret) == 0 {
+		models.TicketSecret = make([]byte, 32)
+		rand.Read(models.TicketSecret)
+		slog.Warn("TICKET_SECRET is not set, tickets issued before a restart won't pass check-in")
+	}
+
+	db.AutoMigrate = cfg.DBMigrate
+	switch cfg.DBMigration {
+	case "dual-write":
+		db.DualWriteDSN = cfg.DBMigrationDSN
+	case "cutover":
+		err = db.CheckCutover(context.Background(), cfg.DBDSN, cfg.DBMigrationDSN)
+		if err != nil {
+			logging.Fatal("Refusing to cut over to PostgreSQL", err)
+		}
+		cfg.DBDriver, cfg.DBDSN = db.Postgres, cfg.DBMigrationDSN
+		slog.Warn("Cut over to PostgreSQL, set DB_DRIVER=postgres and DB_DSN to its connection string and unset DB_MIGRATION")
+	}
+	err = db.InitDB(cfg.DBDriver, cfg.DBDSN)
+	var mismatch *db.SchemaMismatchError
+	if errors.As(err, &mismatch) && mismatch.Newer() && cfg.SchemaMismatch == "read-only" {
+		slog.Warn("Serving read-only until the database schema matches this release", "error", err)
+		cfg.ReadOnly = true
+	} else if err != nil {
+		logging.Fatal("Refusing to start against this database", err)
+	}
+	if !cfg.ReadOnly {
+		db.StartBatchFlush(cfg.WriteFlush)
+		summaries.Start(cfg.SummaryRefresh, 24*time.Hour)
+	} else {
+		db.DiscardBatches()
+	}
+	if url := os.Getenv("DISPOSABLE_DOMAINS_URL"); url != "" {
+		validation.StartDisposableDomainsRefresh(url, 24*time.Hour)
+	}
+	auditSink, err := audit.SinkFromEnv()
+	if err != nil {
+		logging.Fatal("Couldn't set up the audit log export", err)
+	}
+	if auditSink != nil && !cfg.ReadOnly {
+		audit.StartExport(auditSink, time.Minute)
+	}
+	routes.Analytics, err = analytics.FromEnv()
+	if err != nil {
+		logging.Fatal("Couldn't set up usage analytics", err)
+	}
+	if routes.Analytics.Enabled() {
+		routes.Analytics.Start(10 * time.Second)
+	}
+	jobQueue := jobs.New(cfg.JobWorkers)
+	routes.Alerts, err = alerts.PolicyFromEnv()
+	if err != nil {
+		logging.Fatal("Couldn't set up capacity alerts", err)
+	}
+	routes.Alerts.UseQueue(jobQueue)
+	if !cfg.ReadOnly {
+		routes.Alerts.StartDigests()
+	}
+	mailer, err := mail.FromEnv()
+	if err != nil {
+		logging.Fatal("Couldn't set up email", err)
+	}
+	routes.Notifications = notifications.New(mailer, jobQueue)
+	models.RefundAutoApproveBefore = cfg.RefundAutoApprove
+	models.RescheduleCutoff = cfg.RescheduleCutoff
+	refunder, err := payments.FromEnv()
+	if err != nil {
+		logging.Fatal("Couldn't set up refunds", err)
+	}
+	routes.Refunds = payments.NewProcessor(refunder, jobQueue)
+	routes.Status = statuspage.New(cfg.StatusInterval)
+	routes.Status.Add("database", statuspage.DatabaseCheck())
+	routes.Status.Add("queue", statuspage.QueueCheck())
+	if cfg.ReadOnly {
+		// Mirrors send no emails or refunds, and the main instance stores the checks
+		routes.Status.ReadOnly()
+	} else {
+		jobQueue.Start()
+		if routes.Notifications.Enabled() {
+			routes.Status.Add("email", statuspage.BreakerCheck(routes.Notifications.Breaker()))
+		}
+		if routes.Refunds != nil {
+			routes.Status.Add("payments", statuspage.BreakerCheck(routes.Refunds.Breaker()))
+		}
+	}
+	routes.Status.Start()
+	routes.ContentReuse = cfg.ContentReuse
+	routes.SyncKeys = cfg.SourceKeys()
+	routes.Holidays, err = holidays.FromEnv()
+	if err != nil {
+		logging.Fatal("Couldn't load the holiday calendar", err)
+	}
+	routes.Feeds, err = feeds.FromEnv()
+	if err != nil {
+		logging.Fatal("Couldn't set up the event feeds", err)
+	}
+	if !cfg.ReadOnly {
+		feeds.Start(routes.Feeds)
+	}
+	routes.Recorder, err = middlewares.RecorderFromEnv()
+	if err != nil {
+		logging.Fatal("Couldn't set up request recording", err)
+	}
+	server := gin.New()
+	server.Use(middlewares.Metrics())
+	server.Use(middlewares.RequestLogger(logger), middlewares.Recovery())
+	server.Use(middlewares.ClientIdentification(middlewares.ClientPolicyFromEnv()))
+	if cfg.CompressMinSize >= 0 {
+		server.Use(middlewares.Compress(cfg.CompressMinSize))
+	}
+	if routes.Recorder.Enabled() {
+		server.Use(routes.Recorder.Middleware())
+		slog.Warn("Recording requests for debugging", "users", os.Getenv("RECORD_USERS"), "routes", os.Getenv("RECORD_ROUTES"))
+	}
+	if cfg.ReadOnly {
+		server.Use(middlewares.ReadOnly(routes.PublicReadEndpoints))
+		slog.Info("Serving read-only, only the public read endpoints are available")
+	}
+	if cfg.DBRequestTx && !cfg.ReadOnly {
+		server.Use(middlewares.Transaction())
+	}
+	events := models.NewSQLEventRepository()
+	routes.RegisterRoutes(server, events)
+
+	var grpcServer *grpc.Server
+	if cfg.GRPCPort != "" && !cfg.ReadOnly {
+		service := grpcapi.NewServer(events)
+		service.Moderation = os.Getenv("MODERATION_MODE") == "true"
+		service.Booked = routes.BookingMade
+		grpcServer = grpcapi.New(service, cfg.GRPCToken)
+		listener, err := net.Listen("tcp", cfg.GRPCAddr())
+		if err != nil {
+			logging.Fatal("Couldn't listen for gRPC calls", err)
+		}
+		go func() {
+			err := grpcServer.Serve(listener)
+			if err != nil {
+				slog.Error("gRPC server stopped with error", "error", err)
+			}
+		}()
+		if cfg.GRPCToken == "" {
+			slog.Warn("GRPC_TOKEN is not set, the gRPC EventService accepts any caller that can reach its port")
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	err = serve(ctx, &http.Server{Addr: cfg.Addr(), Handler: server}, cfg.ShutdownTimeout)
+	// A second signal during the rest of the shutdown exits immediately
+	stop()
+	if err != nil {
+		slog.Error("Server stopped with error", "error", err)
+	}
+	if grpcServer != nil {
+		stopGRPC(grpcServer, cfg.ShutdownTimeout)
+	}
+
+	flushCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	err = routes.Analytics.Flush(flushCtx)
+	cancel()
+	if err != nil {
+		slog.Error("Couldn't send the remaining usage events", "error", err)
+	}
+	flushCtx, cancel = context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	err = jobQueue.Close(flushCtx)
+	cancel()
+	if err != nil {
+		slog.Error("Couldn't finish the running jobs", "error", err)
+	}
+	if !cfg.ReadOnly {
+		err = db.FlushBatches(context.Background())
+		if err != nil {
+			slog.Error("Couldn't store the buffered writes", "error", err)
+		}
+	}
+	err = db.DB.Close()
+	if err != nil {
+		slog.Error("Couldn't close the database", "error", err)
+	}
+}
+
+// serve runs the server until it fails or ctx is cancelled. On cancellation it stops
+// accepting connections and waits up to timeout for in-flight requests to finish,
+// then closes the connections that are still open.
+// Returns nil after a clean shutdown.
+func serve(ctx context.Context, srv *http.Server, timeout time.Duration) error {
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down, waiting for in-flight requests", "timeout", timeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err := srv.Shutdown(shutdownCtx)
+	if err != nil {
+		srv.Close()
+		return fmt.Errorf("in-flight requests didn't finish in time: %w", err)
+	}
+	return nil
+}
+
+// stopGRPC stops accepting gRPC calls and waits up to timeout for the running ones to
+// finish, then closes the connections that are still open.
+func stopGRPC(srv *grpc.Server, timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		slog.Error("In-flight gRPC calls didn't finish in time")
+		srv.Stop()
+	}
 }
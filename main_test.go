@@ -0,0 +1,130 @@
+// Package main contains unit tests for serving and shutting down the API.
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// freeAddr returns a local address nothing is listening on
+func freeAddr(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find a free port: %v", err)
+	}
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+// startSlowServer serves a handler that answers once release is closed, and
+// returns the channel serve's result is sent on and one closed when a request arrives
+func startSlowServer(t *testing.T, ctx context.Context, timeout time.Duration, release chan struct{}) (string, chan error, chan struct{}) {
+	addr := freeAddr(t)
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "done")
+	})
+	result := make(chan error, 1)
+	go func() {
+		result <- serve(ctx, &http.Server{Addr: addr, Handler: handler}, timeout)
+	}()
+	return addr, result, started
+}
+
+// get requests the address, retrying until the server is listening
+func get(t *testing.T, addr string) (*http.Response, error) {
+	var resp *http.Response
+	var err error
+	for i := 0; i < 50; i++ {
+		resp, err = http.Get("http://" + addr)
+		if err == nil {
+			return resp, nil
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return nil, err
+}
+
+// TestServeDrainsInFlightRequests tests that shutdown waits for running requests to complete
+func TestServeDrainsInFlightRequests(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	addr, result, started := startSlowServer(t, ctx, 5*time.Second, release)
+
+	responses := make(chan string, 1)
+	go func() {
+		resp, err := get(t, addr)
+		if err != nil {
+			responses <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		responses <- string(body)
+	}()
+
+	<-started
+	cancel()
+	select {
+	case err := <-result:
+		t.Fatalf("Expected serve to wait for the in-flight request, returned %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	if body := <-responses; body != "done" {
+		t.Errorf("Expected the in-flight request to complete, got %q", body)
+	}
+	if err := <-result; err != nil {
+		t.Errorf("Expected a clean shutdown, got %v", err)
+	}
+	if _, err := http.Get("http://" + addr); err == nil {
+		t.Error("Expected the server to stop accepting connections")
+	}
+}
+
+// TestServeShutdownTimeout tests that requests still running after the timeout are cut off
+func TestServeShutdownTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	defer close(release)
+	addr, result, started := startSlowServer(t, ctx, 50*time.Millisecond, release)
+
+	go func() {
+		resp, err := get(t, addr)
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+	cancel()
+
+	select {
+	case err := <-result:
+		if err == nil {
+			t.Error("Expected an error when in-flight requests don't finish in time")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected serve to give up after the shutdown timeout")
+	}
+}
+
+// TestServeListenError tests that a server that can't listen reports the error
+func TestServeListenError(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	err = serve(context.Background(), &http.Server{Addr: listener.Addr().String()}, time.Second)
+	if err == nil {
+		t.Error("Expected an error for an address in use")
+	}
+}
@@ -0,0 +1,247 @@
+// Package metrics collects counters and histograms and writes them in the Prometheus
+// text exposition format, so operators can scrape the API without it depending on
+// the Prometheus client library. Only labelled counters and histograms are supported,
+// which is all the API records.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the media type of the text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the upper bounds, in seconds, of latency histograms. They match
+// the Prometheus client defaults so that dashboards carry over.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// collector is a metric family that can write itself in the exposition format.
+type collector interface {
+	name() string
+	write(w io.Writer)
+}
+
+// Registry holds the metric families exposed together.
+type Registry struct {
+	mu         sync.Mutex
+	collectors map[string]collector
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{collectors: map[string]collector{}}
+}
+
+// Default is the registry the API's metrics are recorded in and served from.
+var Default = NewRegistry()
+
+// register adds the collector, panicking on a duplicate name since that is a programming error.
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.collectors[c.name()]; ok {
+		panic("metrics: duplicate metric " + c.name())
+	}
+	r.collectors[c.name()] = c
+}
+
+// WriteText writes every metric family in the text exposition format, ordered by name.
+func (r *Registry) WriteText(w io.Writer) {
+	r.mu.Lock()
+	collectors := make([]collector, 0, len(r.collectors))
+	for _, c := range r.collectors {
+		collectors = append(collectors, c)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(collectors, func(i, j int) bool { return collectors[i].name() < collectors[j].name() })
+	for _, c := range collectors {
+		c.write(w)
+	}
+}
+
+// family holds what counters and histograms share: the name, help text and label names.
+type family struct {
+	metricName string
+	help       string
+	labels     []string
+}
+
+func (f family) name() string {
+	return f.metricName
+}
+
+// key joins label values into a map key. The separator can't appear in valid UTF-8.
+func (f family) key(values []string) string {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", f.metricName, len(f.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// labelPairs formats the label values as {name="value",...}, followed by extra
+// pairs such as a histogram's le. Returns "" when there are no labels.
+func (f family) labelPairs(values []string, extra ...string) string {
+	pairs := make([]string, 0, len(values)+len(extra)/2)
+	for i, value := range values {
+		pairs = append(pairs, f.labels[i]+`="`+escapeLabel(value)+`"`)
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+`="`+escapeLabel(extra[i+1])+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// header writes the HELP and TYPE lines of the family.
+func (f family) header(w io.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.metricName, escapeHelp(f.help), f.metricName, kind)
+}
+
+// CounterVec is a counter partitioned by label values.
+type CounterVec struct {
+	family
+	mu     sync.Mutex
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	labels []string
+	value  float64
+}
+
+// NewCounterVec creates a counter with the given label names in the Default registry.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return Default.NewCounterVec(name, help, labels...)
+}
+
+// NewCounterVec creates a counter with the given label names in the registry.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{family: family{name, help, labels}, series: map[string]*counterSeries{}}
+	r.register(c)
+	return c
+}
+
+// Inc adds one to the counter for the label values.
+func (c *CounterVec) Inc(labelValues ...string) {
+	key := c.key(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.series[key]
+	if !ok {
+		s = &counterSeries{labels: append([]string(nil), labelValues...)}
+		c.series[key] = s
+	}
+	s.value++
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header(w, "counter")
+	for _, key := range sortedKeys(c.series) {
+		s := c.series[key]
+		fmt.Fprintf(w, "%s%s %s\n", c.metricName, c.labelPairs(s.labels), formatFloat(s.value))
+	}
+}
+
+// HistogramVec is a histogram partitioned by label values.
+type HistogramVec struct {
+	family
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labels []string
+	counts []uint64 // Observations per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec creates a histogram in the Default registry with the given bucket
+// upper bounds, in increasing order, and label names.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return Default.NewHistogramVec(name, help, buckets, labels...)
+}
+
+// NewHistogramVec creates a histogram in the registry with the given bucket upper
+// bounds, in increasing order, and label names.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{family: family{name, help, labels}, buckets: buckets, series: map[string]*histogramSeries{}}
+	r.register(h)
+	return h
+}
+
+// Observe records a value for the label values.
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{labels: append([]string(nil), labelValues...), counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += value
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(w, "histogram")
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelPairs(s.labels, "le", formatFloat(bound)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelPairs(s.labels, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.metricName, h.labelPairs(s.labels), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.metricName, h.labelPairs(s.labels), s.count)
+	}
+}
+
+// sortedKeys returns the series keys in order, so output is stable between scrapes.
+func sortedKeys[T any](series map[string]T) []string {
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
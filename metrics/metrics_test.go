@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestWriteText tests the exposition format of counters and histograms
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounterVec("requests_total", "Requests served.", "route", "status")
+	latency := r.NewHistogramVec("latency_seconds", "Request latency.", []float64{0.1, 1}, "route")
+
+	requests.Inc("/events/:id", "200")
+	requests.Inc("/events/:id", "200")
+	requests.Inc(`/a"b\c`, "500")
+	latency.Observe(0.05, "/events")
+	latency.Observe(0.1, "/events")
+	latency.Observe(0.5, "/events")
+	latency.Observe(3, "/events")
+
+	var out strings.Builder
+	r.WriteText(&out)
+	expected := `# HELP latency_seconds Request latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/events",le="0.1"} 2
+latency_seconds_bucket{route="/events",le="1"} 3
+latency_seconds_bucket{route="/events",le="+Inf"} 4
+latency_seconds_sum{route="/events"} 3.65
+latency_seconds_count{route="/events"} 4
+# HELP requests_total Requests served.
+# TYPE requests_total counter
+requests_total{route="/a\"b\\c",status="500"} 1
+requests_total{route="/events/:id",status="200"} 2
+`
+	if out.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, out.String())
+	}
+}
+
+// TestRegistryPanics tests that duplicate names and wrong label counts are refused
+func TestRegistryPanics(t *testing.T) {
+	expectPanic := func(name string, f func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Expected %s to panic", name)
+			}
+		}()
+		f()
+	}
+
+	r := NewRegistry()
+	counter := r.NewCounterVec("requests_total", "Requests served.", "route")
+	expectPanic("a duplicate metric", func() { r.NewCounterVec("requests_total", "Again.") })
+	expectPanic("a missing label value", func() { counter.Inc() })
+}
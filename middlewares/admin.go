@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"event_booking_restapi_golang/problem"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdmin returns a middleware that only lets through requests carrying
+// apiKey as a bearer token in the Authorization header. An empty apiKey
+// disables the protected endpoints entirely.
+func RequireAdmin(apiKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if apiKey == "" {
+			problem.Respond(c, http.StatusForbidden, problem.CodeAdminDisabled, "admin endpoints are disabled")
+			return
+		}
+
+		if !IsAdmin(c, apiKey) {
+			problem.Respond(c, http.StatusUnauthorized, problem.CodeUnauthorized, "a valid admin API key is required")
+			return
+		}
+
+		c.Set("admin", true)
+		c.Next()
+	}
+}
+
+// IsAdmin reports whether the request carries apiKey as a bearer token in the
+// Authorization header, for endpoints that admins share with other callers. It is
+// always false when apiKey is empty.
+func IsAdmin(c *gin.Context, apiKey string) bool {
+	return HasBearerToken(c, apiKey)
+}
+
+// HasBearerToken reports whether the request carries token as a bearer token in the
+// Authorization header. It is always false when token is empty.
+func HasBearerToken(c *gin.Context, token string) bool {
+	got, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	return ok && token != "" && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
@@ -0,0 +1,46 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestRequireAdmin tests admin API key checking
+func TestRequireAdmin(t *testing.T) {
+	tests := []struct {
+		name          string
+		apiKey        string
+		authorization string
+		expected      int
+	}{
+		{"disabled", "", "Bearer anything", http.StatusForbidden},
+		{"missing header", "secret", "", http.StatusUnauthorized},
+		{"wrong key", "secret", "Bearer wrong", http.StatusUnauthorized},
+		{"wrong scheme", "secret", "Basic secret", http.StatusUnauthorized},
+		{"valid key", "secret", "Bearer secret", http.StatusOK},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/admin", RequireAdmin(tt.apiKey), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req, _ := http.NewRequest("GET", "/admin", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expected {
+				t.Errorf("Expected status code %d, got %d", tt.expected, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"event_booking_restapi_golang/problem"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CaptchaHeader carries the CAPTCHA response token produced by the client widget.
+const CaptchaHeader = "X-Captcha-Token"
+
+// CaptchaVerifier checks a CAPTCHA response token with a verification provider.
+type CaptchaVerifier interface {
+	Verify(token, remoteIP string) (bool, error)
+}
+
+// siteVerifier verifies tokens against a "siteverify" style endpoint.
+// hCaptcha and Cloudflare Turnstile share the same request and response shape.
+type siteVerifier struct {
+	endpoint string
+	secret   string
+	client   *http.Client
+}
+
+// NewHCaptchaVerifier returns a CaptchaVerifier backed by hCaptcha.
+func NewHCaptchaVerifier(secret string) CaptchaVerifier {
+	return siteVerifier{
+		endpoint: "https://api.hcaptcha.com/siteverify",
+		secret:   secret,
+		client:   &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// NewTurnstileVerifier returns a CaptchaVerifier backed by Cloudflare Turnstile.
+func NewTurnstileVerifier(secret string) CaptchaVerifier {
+	return siteVerifier{
+		endpoint: "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+		secret:   secret,
+		client:   &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Verify posts the token to the provider and reports whether it was accepted.
+func (v siteVerifier) Verify(token, remoteIP string) (bool, error) {
+	form := url.Values{
+		"secret":   {v.secret},
+		"response": {token},
+		"remoteip": {remoteIP},
+	}
+	resp, err := v.client.PostForm(v.endpoint, form)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Success bool `json:"success"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return false, err
+	}
+	return result.Success, nil
+}
+
+// CaptchaVerifierFromEnv selects the CAPTCHA provider from CAPTCHA_PROVIDER
+// ("hcaptcha" or "turnstile") using the CAPTCHA_SECRET key.
+// Returns nil when no provider is configured, which disables the check.
+func CaptchaVerifierFromEnv() CaptchaVerifier {
+	secret := os.Getenv("CAPTCHA_SECRET")
+	switch os.Getenv("CAPTCHA_PROVIDER") {
+	case "hcaptcha":
+		return NewHCaptchaVerifier(secret)
+	case "turnstile":
+		return NewTurnstileVerifier(secret)
+	default:
+		return nil
+	}
+}
+
+// Captcha returns a middleware that requires a valid CAPTCHA token in the
+// X-Captcha-Token header. A nil verifier lets every request through so that
+// development and test environments don't need a provider.
+func Captcha(verifier CaptchaVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if verifier == nil {
+			c.Next()
+			return
+		}
+
+		token := c.GetHeader(CaptchaHeader)
+		if token == "" {
+			problem.Respond(c, http.StatusBadRequest, problem.CodeCaptchaRequired, "the "+CaptchaHeader+" header is required")
+			return
+		}
+
+		ok, err := verifier.Verify(token, c.ClientIP())
+		if err != nil {
+			problem.Respond(c, http.StatusServiceUnavailable, problem.CodeCaptchaUnavailable, "couldn't verify the CAPTCHA, please try again")
+			return
+		}
+		if !ok {
+			problem.Respond(c, http.StatusForbidden, problem.CodeCaptchaFailed, "CAPTCHA verification failed")
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,85 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeVerifier is a CaptchaVerifier returning fixed results for testing
+type fakeVerifier struct {
+	ok  bool
+	err error
+}
+
+func (f fakeVerifier) Verify(token, remoteIP string) (bool, error) {
+	return f.ok, f.err
+}
+
+// TestCaptcha tests the Captcha middleware with the different verifier outcomes
+func TestCaptcha(t *testing.T) {
+	tests := []struct {
+		name     string
+		verifier CaptchaVerifier
+		token    string
+		expected int
+	}{
+		{"disabled", nil, "", http.StatusOK},
+		{"missing token", fakeVerifier{ok: true}, "", http.StatusBadRequest},
+		{"valid token", fakeVerifier{ok: true}, "token", http.StatusOK},
+		{"rejected token", fakeVerifier{ok: false}, "token", http.StatusForbidden},
+		{"provider error", fakeVerifier{err: errors.New("timeout")}, "token", http.StatusServiceUnavailable},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/book", Captcha(tt.verifier), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req, _ := http.NewRequest("POST", "/book", nil)
+			if tt.token != "" {
+				req.Header.Set(CaptchaHeader, tt.token)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expected {
+				t.Errorf("Expected status code %d, got %d", tt.expected, w.Code)
+			}
+		})
+	}
+}
+
+// TestSiteVerifier tests token verification against a siteverify endpoint
+func TestSiteVerifier(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("secret") != "secret" {
+			t.Errorf("Expected secret to be sent, got %q", r.Form.Get("secret"))
+		}
+		if r.Form.Get("response") == "good" {
+			w.Write([]byte(`{"success":true}`))
+			return
+		}
+		w.Write([]byte(`{"success":false}`))
+	}))
+	defer server.Close()
+
+	verifier := siteVerifier{endpoint: server.URL, secret: "secret", client: server.Client()}
+
+	ok, err := verifier.Verify("good", "127.0.0.1")
+	if err != nil || !ok {
+		t.Errorf("Expected good token to verify, got %v, %v", ok, err)
+	}
+
+	ok, err = verifier.Verify("bad", "127.0.0.1")
+	if err != nil || ok {
+		t.Errorf("Expected bad token to be rejected, got %v, %v", ok, err)
+	}
+}
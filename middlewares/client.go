@@ -0,0 +1,176 @@
+// Package middlewares contains Gin middleware shared by all API routes.
+// It provides cross-cutting request handling such as client identification.
+package middlewares
+
+import (
+	"event_booking_restapi_golang/problem"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientHeader is the request header API consumers use to identify themselves,
+// formatted as "name/version" (for example "ios-app/2.3.1").
+const ClientHeader = "X-Client"
+
+// unknownClient is recorded when a request carries no identifying header.
+const unknownClient = "unknown"
+
+// otherClient counts the requests of clients missing from the policy's known clients.
+const otherClient = "other"
+
+// maxClientVersions caps the name/version pairs counted separately. Versions seen once
+// the cap is reached are counted under their bare client name.
+const maxClientVersions = 100
+
+// ClientInfo describes the client application that issued a request.
+type ClientInfo struct {
+	Name    string
+	Version string
+}
+
+// String returns the client in its "name/version" header form.
+func (c ClientInfo) String() string {
+	if c.Version == "" {
+		return c.Name
+	}
+	return c.Name + "/" + c.Version
+}
+
+// ClientPolicy controls how requests are treated based on their client header.
+type ClientPolicy struct {
+	Require bool            // Reject requests without an X-Client header
+	Blocked map[string]bool // Client name/version pairs (or bare names) that are refused
+	Known   map[string]bool // Client names counted on their own; others count as "other"
+}
+
+// ClientPolicyFromEnv builds a ClientPolicy from REQUIRE_CLIENT_HEADER,
+// BLOCKED_CLIENTS (a comma separated list of "name/version" or "name" entries) and
+// KNOWN_CLIENTS (a comma separated list of client names). Blocked clients are known.
+func ClientPolicyFromEnv() ClientPolicy {
+	policy := ClientPolicy{
+		Require: os.Getenv("REQUIRE_CLIENT_HEADER") == "true",
+		Blocked: map[string]bool{},
+		Known:   map[string]bool{},
+	}
+	for _, entry := range strings.Split(os.Getenv("BLOCKED_CLIENTS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry != "" {
+			policy.Blocked[entry] = true
+			name, _, _ := strings.Cut(entry, "/")
+			policy.Known[name] = true
+		}
+	}
+	for _, name := range strings.Split(os.Getenv("KNOWN_CLIENTS"), ",") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			policy.Known[name] = true
+		}
+	}
+	return policy
+}
+
+// ClientStats holds the number of requests and failed requests seen for a client.
+type ClientStats struct {
+	Requests int `json:"requests"`
+	Errors   int `json:"errors"`
+}
+
+var (
+	clientStatsMu sync.Mutex
+	clientStats   = map[string]*ClientStats{}
+)
+
+// ParseClient extracts the client name and version from the X-Client header,
+// falling back to the User-Agent header when it is absent.
+func ParseClient(r *http.Request) ClientInfo {
+	raw := strings.TrimSpace(r.Header.Get(ClientHeader))
+	if raw == "" {
+		raw = strings.TrimSpace(r.UserAgent())
+		// User agents list several products; the first one names the client.
+		if i := strings.IndexByte(raw, ' '); i >= 0 {
+			raw = raw[:i]
+		}
+	}
+	if raw == "" {
+		return ClientInfo{Name: unknownClient}
+	}
+	name, version, _ := strings.Cut(raw, "/")
+	return ClientInfo{Name: name, Version: version}
+}
+
+// ClientIdentification returns a middleware that records the calling client on
+// the Gin context under "client", counts requests and errors per known client,
+// and refuses requests from missing or blocked clients according to policy.
+func ClientIdentification(policy ClientPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if policy.Require && c.GetHeader(ClientHeader) == "" {
+			problem.Respond(c, http.StatusBadRequest, problem.CodeClientRequired, "the "+ClientHeader+" header is required")
+			return
+		}
+
+		client := ParseClient(c.Request)
+		if policy.Blocked[client.String()] || policy.Blocked[client.Name] {
+			problem.Respond(c, http.StatusForbidden, problem.CodeClientBlocked, "client "+client.String()+" is no longer supported, please upgrade")
+			recordClient(policy.statsKey(client), true)
+			return
+		}
+
+		c.Set("client", client)
+		c.Next()
+
+		recordClient(policy.statsKey(client), c.Writer.Status() >= http.StatusBadRequest)
+	}
+}
+
+// statsKey names the counters of the client. The headers are chosen by the caller, so
+// only known clients get their own, "name/version" until maxClientVersions pairs are
+// counted, keeping the counters bounded.
+func (p ClientPolicy) statsKey(client ClientInfo) string {
+	if client.Name == unknownClient {
+		return unknownClient
+	}
+	if !p.Known[client.Name] {
+		return otherClient
+	}
+	if client.Version == "" {
+		return client.Name
+	}
+	clientStatsMu.Lock()
+	defer clientStatsMu.Unlock()
+	if _, ok := clientStats[client.String()]; !ok && len(clientStats) >= maxClientVersions {
+		return client.Name
+	}
+	return client.String()
+}
+
+// recordClient increments the request counters under the given key.
+func recordClient(key string, failed bool) {
+	clientStatsMu.Lock()
+	defer clientStatsMu.Unlock()
+
+	stats, ok := clientStats[key]
+	if !ok {
+		stats = &ClientStats{}
+		clientStats[key] = stats
+	}
+	stats.Requests++
+	if failed {
+		stats.Errors++
+	}
+}
+
+// GetClientStats returns a snapshot of the per-client request and error counters.
+func GetClientStats() map[string]ClientStats {
+	clientStatsMu.Lock()
+	defer clientStatsMu.Unlock()
+
+	snapshot := make(map[string]ClientStats, len(clientStats))
+	for name, stats := range clientStats {
+		snapshot[name] = *stats
+	}
+	return snapshot
+}
@@ -0,0 +1,123 @@
+// Package middlewares contains unit tests for the shared Gin middleware.
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupTestRouter creates a Gin router with the client middleware for testing
+func setupTestRouter(policy ClientPolicy) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ClientIdentification(policy))
+	router.GET("/ping", func(c *gin.Context) {
+		client, _ := c.Get("client")
+		c.String(http.StatusOK, client.(ClientInfo).String())
+	})
+	return router
+}
+
+// TestParseClient tests parsing of the X-Client and User-Agent headers
+func TestParseClient(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    string
+		userAgent string
+		expected  ClientInfo
+	}{
+		{"client header", "ios-app/2.3.1", "", ClientInfo{Name: "ios-app", Version: "2.3.1"}},
+		{"client header without version", "cli", "", ClientInfo{Name: "cli"}},
+		{"user agent fallback", "", "curl/8.4.0 extra", ClientInfo{Name: "curl", Version: "8.4.0"}},
+		{"no headers", "", "", ClientInfo{Name: "unknown"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/", nil)
+			req.Header.Set("User-Agent", tt.userAgent)
+			if tt.header != "" {
+				req.Header.Set(ClientHeader, tt.header)
+			}
+
+			client := ParseClient(req)
+			if client != tt.expected {
+				t.Errorf("Expected client %+v, got %+v", tt.expected, client)
+			}
+		})
+	}
+}
+
+// TestClientIdentification tests that the client is stored on the context and counted
+func TestClientIdentification(t *testing.T) {
+	router := setupTestRouter(ClientPolicy{Known: map[string]bool{"test-suite": true}})
+
+	req, _ := http.NewRequest("GET", "/ping", nil)
+	req.Header.Set(ClientHeader, "test-suite/1.0")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if w.Body.String() != "test-suite/1.0" {
+		t.Errorf("Expected client test-suite/1.0, got %s", w.Body.String())
+	}
+	if GetClientStats()["test-suite/1.0"].Requests == 0 {
+		t.Error("Expected request to be counted for test-suite/1.0")
+	}
+
+	// Clients outside the known ones share a single counter
+	before := GetClientStats()[otherClient].Requests
+	for _, header := range []string{"scraper/1", "scraper/2", "made-up"} {
+		req, _ := http.NewRequest("GET", "/ping", nil)
+		req.Header.Set(ClientHeader, header)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	stats := GetClientStats()
+	if stats[otherClient].Requests != before+3 || stats["scraper/1"].Requests != 0 || stats["made-up"].Requests != 0 {
+		t.Errorf("Expected unknown clients to be counted as %s, got %+v", otherClient, stats)
+	}
+}
+
+// TestClientIdentificationRequired tests rejection of requests without a client header
+func TestClientIdentificationRequired(t *testing.T) {
+	router := setupTestRouter(ClientPolicy{Require: true})
+
+	req, _ := http.NewRequest("GET", "/ping", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestClientIdentificationBlocked tests that blocked client versions are refused
+func TestClientIdentificationBlocked(t *testing.T) {
+	router := setupTestRouter(ClientPolicy{Blocked: map[string]bool{"android-app/1.0.0": true}, Known: map[string]bool{"android-app": true}})
+
+	req, _ := http.NewRequest("GET", "/ping", nil)
+	req.Header.Set(ClientHeader, "android-app/1.0.0")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d, got %d", http.StatusForbidden, w.Code)
+	}
+	if GetClientStats()["android-app/1.0.0"].Errors == 0 {
+		t.Error("Expected blocked request to be counted as an error")
+	}
+
+	req, _ = http.NewRequest("GET", "/ping", nil)
+	req.Header.Set(ClientHeader, "android-app/1.1.0")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d for a newer version, got %d", http.StatusOK, w.Code)
+	}
+}
@@ -0,0 +1,187 @@
+package middlewares
+
+import (
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gzipWriters reuses gzip writers across responses, which saves their sizeable
+// compression state from being allocated per request.
+var gzipWriters = sync.Pool{New: func() any { return gzip.NewWriter(nil) }}
+
+// Compress returns a middleware that gzip-compresses response bodies of at least
+// minSize bytes for clients that accept gzip in Accept-Encoding; 0 compresses every
+// body. Smaller bodies are held back until they end and sent as they are, since
+// compressing them saves less than it costs. Only textual media types such as JSON,
+// CSV, XML and iCalendar are compressed; files such as images, which usually are
+// compressed already, partial content and websocket upgrades are written through.
+// It must be installed before middlewares that read or rewrite response bodies, such
+// as the request recorder, so they see the body uncompressed.
+func Compress(minSize int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodHead || c.GetHeader("Upgrade") != "" || !acceptsGzip(c.GetHeader("Accept-Encoding")) {
+			c.Next()
+			return
+		}
+		writer := &compressWriter{ResponseWriter: c.Writer, minSize: minSize}
+		c.Writer = writer
+		// Restored even when a handler panics, so the recovery middleware's problem reaches the client
+		defer func() { c.Writer = writer.ResponseWriter }()
+		c.Next()
+		writer.Close()
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip, by naming it or
+// through "*", with a non-zero quality value.
+func acceptsGzip(header string) bool {
+	wildcard := false
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			q, err = strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				q = 0
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(coding)) {
+		case "gzip", "x-gzip":
+			return q > 0
+		case "*":
+			wildcard = q > 0
+		}
+	}
+	return wildcard
+}
+
+// compressible reports whether bodies of the media type are worth compressing.
+func compressible(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml") {
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript", "application/x-ndjson":
+		return true
+	}
+	return false
+}
+
+// compressWriter holds back the start of a response body until it is known whether
+// it reaches the minimum size, then compresses it or writes it through.
+type compressWriter struct {
+	gin.ResponseWriter
+	minSize int
+	decided bool         // Whether the body is being compressed or written through
+	gzip    *gzip.Writer // The compressor, nil when writing through
+	buffer  []byte       // The held back start of the body
+}
+
+// eligible reports whether the response may be compressed, from its status and headers.
+func (w *compressWriter) eligible() bool {
+	status := w.Status()
+	header := w.Header()
+	return status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusPartialContent &&
+		status != http.StatusNotModified && header.Get("Content-Encoding") == "" && header.Get("Content-Range") == "" &&
+		compressible(header.Get("Content-Type"))
+}
+
+// decide starts compressing the body or writing it through and sends what was held back.
+func (w *compressWriter) decide(compress bool) error {
+	w.decided = true
+	if compress {
+		header := w.Header()
+		header.Set("Content-Encoding", "gzip")
+		header.Add("Vary", "Accept-Encoding")
+		header.Del("Content-Length")
+		w.gzip = gzipWriters.Get().(*gzip.Writer)
+		w.gzip.Reset(w.ResponseWriter)
+	}
+	buffer := w.buffer
+	w.buffer = nil
+	if len(buffer) == 0 {
+		return nil
+	}
+	if w.gzip != nil {
+		_, err := w.gzip.Write(buffer)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buffer)
+	return err
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if !w.decided && len(w.buffer) == 0 && !w.eligible() {
+		w.decide(false)
+	}
+	if w.decided {
+		if w.gzip != nil {
+			return w.gzip.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+	w.buffer = append(w.buffer, data...)
+	if len(w.buffer) >= w.minSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// WriteHeaderNow sends the headers as they are, so the body is written through.
+func (w *compressWriter) WriteHeaderNow() {
+	if !w.decided {
+		w.decide(false)
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *compressWriter) Written() bool {
+	return len(w.buffer) > 0 || w.ResponseWriter.Written()
+}
+
+func (w *compressWriter) Size() int {
+	if len(w.buffer) > 0 {
+		return len(w.buffer)
+	}
+	return w.ResponseWriter.Size()
+}
+
+// Flush sends what was written so far. A streamed body is compressed when its first
+// part could be, whatever its size, and the headers still allow it, and written through
+// when nothing preceded the flush, as for event streams sending their headers first.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(len(w.buffer) > 0 && w.eligible())
+	}
+	if w.gzip != nil {
+		w.gzip.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Close sends a body that stayed below the minimum size as it is, or ends the
+// compressed one.
+func (w *compressWriter) Close() {
+	if !w.decided {
+		w.decide(false)
+	}
+	if w.gzip != nil {
+		w.gzip.Close()
+		gzipWriters.Put(w.gzip)
+		w.gzip = nil
+	}
+}
@@ -0,0 +1,128 @@
+package middlewares
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestCompress tests that large textual responses are compressed for clients accepting gzip
+func TestCompress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Compress(100))
+	large := strings.Repeat("a long description ", 50)
+	router.GET("/large", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"description": large}) })
+	router.GET("/small", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"id": "42"}) })
+	router.GET("/image", func(c *gin.Context) { c.Data(http.StatusOK, "image/png", []byte(large)) })
+	router.GET("/empty", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	tests := []struct {
+		path, acceptEncoding string
+		compressed           bool
+	}{
+		{"/large", "gzip, deflate, br", true},
+		{"/large", "br;q=1.0, *;q=0.5", true},
+		{"/large", "", false},
+		{"/large", "gzip;q=0", false},
+		{"/large", "*, gzip;q=0", false},
+		{"/small", "gzip", false},
+		{"/image", "gzip", false},
+		{"/empty", "gzip", false},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", tt.path, nil)
+		if tt.acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		encoding := w.Header().Get("Content-Encoding")
+		if tt.compressed != (encoding == "gzip") {
+			t.Errorf("%s with %q: expected compressed %v, got Content-Encoding %q", tt.path, tt.acceptEncoding, tt.compressed, encoding)
+			continue
+		}
+		if !tt.compressed {
+			if tt.path == "/large" && !strings.Contains(w.Body.String(), large) {
+				t.Errorf("%s with %q: expected the body as it is, got %q", tt.path, tt.acceptEncoding, w.Body.String())
+			}
+			continue
+		}
+		if w.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("%s: expected Vary: Accept-Encoding, got %q", tt.path, w.Header().Get("Vary"))
+		}
+		reader, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("%s: failed to read gzip body: %v", tt.path, err)
+		}
+		body, err := io.ReadAll(reader)
+		if err != nil || !strings.Contains(string(body), large) {
+			t.Errorf("%s: expected the compressed body to hold the description, got %q (%v)", tt.path, body, err)
+		}
+	}
+}
+
+// TestCompressRecovery tests that a panic's problem still reaches the client uncompressed
+func TestCompressRecovery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Recovery(), Compress(0))
+	router.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	req, _ := http.NewRequest("GET", "/panic", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), `"code":"internal_error"`) {
+		t.Errorf("Expected an uncompressed internal problem, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+// TestCompressFlush tests that a flushed response is only compressed while its headers
+// allow it, also when they changed after its first part was written
+func TestCompressFlush(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Compress(100))
+	stream := func(contentType string) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			c.Header("Content-Type", "text/plain")
+			c.Writer.WriteString("first part")
+			c.Header("Content-Type", contentType)
+			c.Writer.Flush()
+			c.Writer.WriteString(", second part")
+		}
+	}
+	router.GET("/text", stream("text/plain"))
+	router.GET("/image", stream("image/png"))
+
+	for path, compressed := range map[string]bool{"/text": true, "/image": false} {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		body := w.Body.String()
+		if compressed {
+			reader, err := gzip.NewReader(w.Body)
+			if err != nil {
+				t.Fatalf("%s: failed to read gzip body: %v", path, err)
+			}
+			decoded, _ := io.ReadAll(reader)
+			body = string(decoded)
+		}
+		if encoding := w.Header().Get("Content-Encoding"); compressed != (encoding == "gzip") {
+			t.Errorf("%s: expected compressed %v, got Content-Encoding %q", path, compressed, encoding)
+		}
+		if body != "first part, second part" {
+			t.Errorf("%s: expected the whole body, got %q", path, body)
+		}
+	}
+}
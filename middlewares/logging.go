@@ -0,0 +1,89 @@
+package middlewares
+
+import (
+	"event_booking_restapi_golang/logging"
+	"event_booking_restapi_golang/problem"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the ID that ties a request to its log lines. A request
+// ID sent by the client or a proxy is kept, otherwise a new one is generated, and
+// it is echoed in the response.
+const RequestIDHeader = logging.RequestIDHeader
+
+// maxRequestIDLength bounds client supplied request IDs so they can't bloat the logs.
+const maxRequestIDLength = 128
+
+// RequestLogger returns a middleware that gives each request a logger carrying its
+// request ID, method and route, available through logging.FromContext on the
+// request context. The request ID is also stored in the Gin context as "request_id"
+// and in the request context for logging.RequestID, which error responses and
+// calls to other services pick it up from. When the request completes it logs one
+// line with its status, latency, response size and client. Server errors are logged
+// at error level and client errors at warn level. It replaces Gin's text access log.
+func RequestLogger(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.NewString()
+		}
+		c.Header(RequestIDHeader, requestID)
+		c.Set("request_id", requestID)
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		requestLogger := logger.With("request_id", requestID, "method", c.Request.Method, "route", route)
+		ctx := logging.WithRequestID(c.Request.Context(), requestID)
+		c.Request = c.Request.WithContext(logging.WithLogger(ctx, requestLogger))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []any{
+			"path", c.Request.URL.Path,
+			"status", status,
+			"latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+			"bytes", c.Writer.Size(),
+		}
+		if client, ok := c.Get("client"); ok {
+			attrs = append(attrs, "client", fmt.Sprint(client))
+		}
+		if c.GetBool("admin") {
+			attrs = append(attrs, "admin", true)
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, "errors", c.Errors.String())
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		requestLogger.Log(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// Recovery returns a middleware that turns a panic in a handler into an HTTP 500
+// problem response and logs the panic with its stack trace on the request's logger.
+// It replaces Gin's recovery, which writes plain text to stderr.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		logging.FromContext(c.Request.Context()).Error("panic serving request",
+			"panic", fmt.Sprint(recovered), "stack", string(debug.Stack()))
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, "internal server error")
+	})
+}
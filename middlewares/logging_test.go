@@ -0,0 +1,106 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"event_booking_restapi_golang/logging"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// logLines decodes the JSON lines written to out
+func logLines(t *testing.T, out *bytes.Buffer) []map[string]any {
+	var lines []map[string]any
+	for _, raw := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var line map[string]any
+		if err := json.Unmarshal([]byte(raw), &line); err != nil {
+			t.Fatalf("Expected JSON log lines, got %q: %v", raw, err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// TestRequestLogger tests that each request is logged with its request ID, route, status and client
+func TestRequestLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var out bytes.Buffer
+	router := gin.New()
+	router.Use(RequestLogger(logging.New(&out, slog.LevelInfo)), ClientIdentification(ClientPolicy{}))
+	router.GET("/logging-test/:id", func(c *gin.Context) {
+		logging.FromContext(c.Request.Context()).Info("handling")
+		c.Status(http.StatusNotFound)
+	})
+
+	req, _ := http.NewRequest("GET", "/logging-test/42", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	req.Header.Set(ClientHeader, "ios-app/2.3.1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Header().Get(RequestIDHeader) != "req-1" {
+		t.Errorf("Expected the request ID to be echoed, got %q", w.Header().Get(RequestIDHeader))
+	}
+	lines := logLines(t, &out)
+	if len(lines) != 2 {
+		t.Fatalf("Expected the handler's line and the request line, got %v", lines)
+	}
+	handler, request := lines[0], lines[1]
+	if handler["msg"] != "handling" || handler["request_id"] != "req-1" || handler["route"] != "/logging-test/:id" {
+		t.Errorf("Expected the handler's line to carry the request fields, got %v", handler)
+	}
+	if request["msg"] != "request" || request["level"] != "WARN" || request["status"] != float64(404) ||
+		request["path"] != "/logging-test/42" || request["client"] != "ios-app/2.3.1" || request["latency_ms"] == nil {
+		t.Errorf("Unexpected request line %v", request)
+	}
+}
+
+// TestRequestLoggerGeneratesID tests that requests without a usable request ID get a new one
+func TestRequestLoggerGeneratesID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestLogger(logging.New(&bytes.Buffer{}, slog.LevelInfo)))
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, sent := range []string{"", strings.Repeat("x", maxRequestIDLength+1)} {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set(RequestIDHeader, sent)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if id := w.Header().Get(RequestIDHeader); id == "" || id == sent {
+			t.Errorf("Expected a generated request ID, got %q", id)
+		}
+	}
+}
+
+// TestRecovery tests that a panic becomes a logged HTTP 500 problem response
+func TestRecovery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var out bytes.Buffer
+	router := gin.New()
+	router.Use(RequestLogger(logging.New(&out, slog.LevelInfo)), Recovery())
+	router.GET("/", func(c *gin.Context) { panic("boom") })
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Header().Get("Content-Type"), "problem+json") {
+		t.Errorf("Expected a 500 problem response, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	var body map[string]any
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if body["request_id"] == nil || body["request_id"] != w.Header().Get(RequestIDHeader) {
+		t.Errorf("Expected the problem to carry the request ID %q, got %v", w.Header().Get(RequestIDHeader), body)
+	}
+	lines := logLines(t, &out)
+	if len(lines) != 2 || lines[0]["panic"] != "boom" || lines[0]["stack"] == nil || lines[1]["level"] != "ERROR" ||
+		lines[0]["request_id"] != body["request_id"] {
+		t.Errorf("Expected the panic and an error request line, got %v", lines)
+	}
+}
@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"event_booking_restapi_golang/metrics"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute labels requests that don't match a route, so that scans of random
+// paths can't create a series per path.
+const unmatchedRoute = "unmatched"
+
+var (
+	httpRequests = metrics.NewCounterVec("http_requests_total",
+		"HTTP requests served, by route, method and status.", "route", "method", "status")
+	httpDuration = metrics.NewHistogramVec("http_request_duration_seconds",
+		"Time taken to serve HTTP requests, by route, method and status.", metrics.DefaultBuckets, "route", "method", "status")
+)
+
+// Metrics returns a middleware that counts requests and records their latency per
+// route template (such as /events/:id), method and response status. It should be
+// installed first so that the time spent in other middlewares is included.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		httpRequests.Inc(route, c.Request.Method, status)
+		httpDuration.Observe(time.Since(start).Seconds(), route, c.Request.Method, status)
+	}
+}
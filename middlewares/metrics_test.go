@@ -0,0 +1,43 @@
+package middlewares
+
+import (
+	"event_booking_restapi_golang/metrics"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestMetrics tests that requests are counted and timed per route template, method and status
+func TestMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Metrics())
+	router.GET("/metrics-test/:id", func(c *gin.Context) {
+		if c.Param("id") == "missing" {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	for _, path := range []string{"/metrics-test/1", "/metrics-test/2", "/metrics-test/missing", "/no-such-route"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	var out strings.Builder
+	metrics.Default.WriteText(&out)
+	for _, line := range []string{
+		`http_requests_total{route="/metrics-test/:id",method="GET",status="200"} 2`,
+		`http_requests_total{route="/metrics-test/:id",method="GET",status="404"} 1`,
+		`http_requests_total{route="unmatched",method="GET",status="404"} 1`,
+		`http_request_duration_seconds_count{route="/metrics-test/:id",method="GET",status="200"} 2`,
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("Expected metrics to contain %s, got\n%s", line, out.String())
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package middlewares
+
+import (
+	"event_booking_restapi_golang/problem"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadOnly returns a middleware for read-only deployments that only lets through
+// requests to the given endpoints, written as the method and the route pattern they
+// are registered with, such as "GET /events/:id". Requests to every other endpoint are
+// refused with HTTP 403; paths matching no route fall through to the 404 handler.
+// It must be installed before the routes are registered.
+func ReadOnly(endpoints []string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(endpoints))
+	for _, endpoint := range endpoints {
+		allowed[endpoint] = true
+	}
+	return func(c *gin.Context) {
+		if c.FullPath() == "" || allowed[c.Request.Method+" "+c.FullPath()] {
+			c.Next()
+			return
+		}
+		problem.Respond(c, http.StatusForbidden, problem.CodeReadOnly, "this is a read-only mirror; "+c.Request.Method+" "+c.FullPath()+" isn't available")
+	}
+}
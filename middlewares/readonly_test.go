@@ -0,0 +1,46 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestReadOnly tests that only the listed endpoints are served
+func TestReadOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ReadOnly([]string{"GET /events", "GET /events/:id"}))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/events", ok)
+	router.GET("/events/:id", ok)
+	router.PUT("/events/:id", ok)
+	router.GET("/events/:id/waitlist", ok)
+	router.POST("/event", ok)
+
+	tests := []struct {
+		method, path string
+		expected     int
+	}{
+		{"GET", "/events", http.StatusOK},
+		{"GET", "/events/e1", http.StatusOK},
+		{"PUT", "/events/e1", http.StatusForbidden},
+		{"GET", "/events/e1/waitlist", http.StatusForbidden},
+		{"POST", "/event", http.StatusForbidden},
+		{"GET", "/nowhere", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, tt.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.expected {
+			t.Errorf("%s %s: expected status code %d, got %d", tt.method, tt.path, tt.expected, w.Code)
+		}
+		if tt.expected == http.StatusForbidden && !strings.Contains(w.Body.String(), `"code":"read_only"`) {
+			t.Errorf("%s %s: expected the read_only code, got %s", tt.method, tt.path, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,402 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxRecordedBody bounds the request and response body bytes kept per recording.
+const maxRecordedBody = 16 << 10
+
+// defaultRecordingCapacity is the number of recordings kept when RECORD_BUFFER_SIZE isn't set.
+const defaultRecordingCapacity = 200
+
+// redacted replaces the values of sensitive headers, parameters and body fields.
+const redacted = "[redacted]"
+
+// recordedUserHeaders identify the user a request is made for: the organizer ID,
+// the attendee's registration ID and the anonymous session ID.
+var recordedUserHeaders = []string{"X-User-ID", "X-Registration-ID", "X-Session-ID"}
+
+// sensitiveHeaders are never recorded in clear text.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":     true,
+	"Cookie":            true,
+	"Set-Cookie":        true,
+	CaptchaHeader:       true,
+	SignatureHeader:     true,
+	"X-Registration-ID": true,
+	"X-Organizer-Token": true,
+}
+
+// sensitiveFields are the JSON body fields and query parameters that are never recorded
+// in clear text, lowercased and without underscores.
+var sensitiveFields = map[string]bool{
+	"email":    true,
+	"replyto":  true,
+	"password": true,
+	"secret":   true,
+	"token":    true,
+	"waiverip": true,
+}
+
+// sensitiveRequestFields are also redacted from request bodies only, such as the
+// verification codes clients send; responses use "code" for problem codes.
+var sensitiveRequestFields = map[string]bool{
+	"code": true,
+}
+
+// Recording is a sanitized request and the response it got.
+type Recording struct {
+	ID              string            // Unique identifier for the recording
+	RecordedAt      time.Time         // When the request was received
+	RequestID       string            // X-Request-ID of the request, to find its log lines
+	Method          string            // HTTP method
+	Path            string            // Request path, with sensitive route parameters redacted
+	Route           string            // Route pattern the request matched, empty for unmatched paths
+	Query           string            // Query string, with sensitive parameters redacted
+	Client          string            // Calling client as name/version
+	User            string            // User header value the request was recorded for, empty when recorded for its route
+	Status          int               // Response status code
+	LatencyMS       float64           // Time taken to serve the request in milliseconds
+	RequestHeaders  map[string]string // Request headers, with sensitive ones redacted
+	RequestBody     string            // Request body, with sensitive JSON fields redacted
+	ResponseHeaders map[string]string // Response headers, with sensitive ones redacted
+	ResponseBody    string            // Response body, with sensitive JSON fields redacted
+	Truncated       bool              // Whether a body was longer than the recorded part
+}
+
+// RecordingPolicy selects the requests a Recorder records. Requests match when they
+// are made for one of the users or to one of the routes.
+type RecordingPolicy struct {
+	Users    map[string]bool // Organizer, registration or session IDs sent in the user headers
+	Routes   map[string]bool // Route patterns, alone or after the method, such as "POST /events/:id/register"
+	Capacity int             // Number of recordings kept; the oldest are dropped first
+	File     io.Writer       // Also receives every recording as a line of JSON when set
+}
+
+// Recorder keeps the most recent recordings of the requests matching its policy in a
+// ring buffer. A nil Recorder records nothing.
+type Recorder struct {
+	policy RecordingPolicy
+
+	mu         sync.Mutex
+	recordings []Recording // Ring buffer; next is the slot written next
+	next       int
+	full       bool
+}
+
+// NewRecorder creates a Recorder for the requests matching policy.
+func NewRecorder(policy RecordingPolicy) *Recorder {
+	if policy.Capacity <= 0 {
+		policy.Capacity = defaultRecordingCapacity
+	}
+	return &Recorder{policy: policy, recordings: make([]Recording, policy.Capacity)}
+}
+
+// RecorderFromEnv builds a Recorder from RECORD_USERS and RECORD_ROUTES, comma separated
+// lists of user IDs and route patterns to record, RECORD_BUFFER_SIZE, the number of
+// recordings kept, and RECORD_FILE, a file the recordings are appended to as JSON lines.
+// Returns nil when neither users nor routes are configured, which disables recording.
+func RecorderFromEnv() (*Recorder, error) {
+	policy := RecordingPolicy{Users: splitList(os.Getenv("RECORD_USERS")), Routes: splitList(os.Getenv("RECORD_ROUTES"))}
+	if len(policy.Users) == 0 && len(policy.Routes) == 0 {
+		return nil, nil
+	}
+	if raw := os.Getenv("RECORD_BUFFER_SIZE"); raw != "" {
+		var err error
+		policy.Capacity, err = strconv.Atoi(raw)
+		if err != nil || policy.Capacity <= 0 {
+			return nil, fmt.Errorf("unsupported RECORD_BUFFER_SIZE %q, use a positive number of recordings", raw)
+		}
+	}
+	if path := os.Getenv("RECORD_FILE"); path != "" {
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't open RECORD_FILE: %w", err)
+		}
+		policy.File = file
+	}
+	return NewRecorder(policy), nil
+}
+
+// splitList returns the non-empty entries of a comma separated list as a set.
+func splitList(raw string) map[string]bool {
+	entries := map[string]bool{}
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry != "" {
+			entries[entry] = true
+		}
+	}
+	return entries
+}
+
+// Middleware returns a middleware that records the requests matching the policy with
+// their responses. Sensitive headers, route parameters, query parameters and JSON body
+// fields are redacted, and only the first 16 KiB of each body are kept. It should be
+// installed after RequestLogger and ClientIdentification so the request ID and client
+// are recorded.
+func (r *Recorder) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := r.match(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		requestBody, truncated := peekBody(c.Request)
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		recording := Recording{
+			ID:              uuid.NewString(),
+			RecordedAt:      start.UTC(),
+			RequestID:       c.GetString("request_id"),
+			Method:          c.Request.Method,
+			Path:            redactPath(c),
+			Route:           c.FullPath(),
+			Query:           redactQuery(c.Request.URL.RawQuery),
+			User:            user,
+			Status:          writer.Status(),
+			LatencyMS:       float64(time.Since(start).Microseconds()) / 1000,
+			RequestHeaders:  redactHeaders(c.Request.Header),
+			RequestBody:     redactBody(requestBody, c.ContentType(), true),
+			ResponseHeaders: redactHeaders(writer.Header()),
+			ResponseBody:    redactBody(writer.body.Bytes(), writer.Header().Get("Content-Type"), false),
+			Truncated:       truncated || writer.truncated,
+		}
+		if client, ok := c.Get("client"); ok {
+			recording.Client = fmt.Sprint(client)
+		}
+		r.add(recording)
+	}
+}
+
+// match reports whether the request is recorded, and for which user when it is
+// recorded for one.
+func (r *Recorder) match(c *gin.Context) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+	for _, header := range recordedUserHeaders {
+		if id := c.GetHeader(header); id != "" && r.policy.Users[id] {
+			return id, true
+		}
+	}
+	route := c.FullPath()
+	return "", route != "" && (r.policy.Routes[route] || r.policy.Routes[c.Request.Method+" "+route])
+}
+
+// add stores the recording in the ring buffer and appends it to the file, if any.
+func (r *Recorder) add(recording Recording) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.recordings[r.next] = recording
+	r.next = (r.next + 1) % len(r.recordings)
+	if r.next == 0 {
+		r.full = true
+	}
+	if r.policy.File != nil {
+		line, err := json.Marshal(recording)
+		if err == nil {
+			_, err = r.policy.File.Write(append(line, '\n'))
+		}
+		if err != nil {
+			slog.Error("Couldn't write the request recording", "error", err)
+		}
+	}
+}
+
+// Recordings returns the recordings in the buffer, newest first.
+func (r *Recorder) Recordings() []Recording {
+	recordings := []Recording{}
+	if r == nil {
+		return recordings
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := r.next
+	if r.full {
+		count = len(r.recordings)
+	}
+	for i := 1; i <= count; i++ {
+		recordings = append(recordings, r.recordings[(r.next-i+len(r.recordings))%len(r.recordings)])
+	}
+	return recordings
+}
+
+// Clear drops the recordings in the buffer. The file, if any, is kept.
+func (r *Recorder) Clear() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.recordings = make([]Recording, len(r.recordings))
+	r.next, r.full = 0, false
+}
+
+// Enabled reports whether the recorder records any requests.
+func (r *Recorder) Enabled() bool {
+	return r != nil
+}
+
+// recordingWriter keeps the first part of the response body while writing it.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.keep(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.keep([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *recordingWriter) keep(data []byte) {
+	room := maxRecordedBody - w.body.Len()
+	if len(data) > room {
+		data = data[:room]
+		w.truncated = true
+	}
+	w.body.Write(data)
+}
+
+// peekBody returns the first part of the request body and whether there is more,
+// leaving the whole body for the handlers to read.
+func peekBody(req *http.Request) ([]byte, bool) {
+	if req.Body == nil {
+		return nil, false
+	}
+	body := req.Body
+	prefix, _ := io.ReadAll(io.LimitReader(body, maxRecordedBody+1))
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), body), body}
+	if len(prefix) > maxRecordedBody {
+		return prefix[:maxRecordedBody], true
+	}
+	return prefix, false
+}
+
+// redactHeaders flattens the headers, replacing the values of sensitive ones.
+func redactHeaders(header http.Header) map[string]string {
+	flat := make(map[string]string, len(header))
+	for name, values := range header {
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			flat[name] = redacted
+			continue
+		}
+		flat[name] = strings.Join(values, ", ")
+	}
+	return flat
+}
+
+// redactPath returns the request path with the values of sensitive route parameters,
+// such as the email address of a blocked attendee, replaced.
+func redactPath(c *gin.Context) string {
+	path := c.Request.URL.Path
+	for _, param := range c.Params {
+		if sensitiveFields[fieldKey(param.Key)] {
+			path = strings.Replace(path, "/"+param.Value, "/"+redacted, 1)
+		}
+	}
+	return path
+}
+
+// redactQuery returns the query string with the values of sensitive parameters replaced.
+func redactQuery(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	query, err := url.ParseQuery(raw)
+	if err != nil {
+		return redacted
+	}
+	for name, values := range query {
+		if sensitiveFields[fieldKey(name)] {
+			for i := range values {
+				values[i] = redacted
+			}
+		}
+	}
+	return query.Encode()
+}
+
+// redactBody returns a body for recording. JSON bodies have the values of sensitive
+// fields replaced, at any depth, and plain text is kept as is. Other content, such as
+// uploaded files or CSV exports that can't be sanitized, is only described.
+func redactBody(body []byte, contentType string, request bool) string {
+	if len(body) == 0 {
+		return ""
+	}
+	switch {
+	case strings.Contains(contentType, "json"):
+		var value any
+		if json.Unmarshal(body, &value) != nil {
+			// Truncated or malformed JSON can't be sanitized field by field
+			return fmt.Sprintf("[%d bytes of unparsed JSON]", len(body))
+		}
+		sanitized, err := json.Marshal(redactValue(value, request))
+		if err != nil {
+			return fmt.Sprintf("[%d bytes of unparsed JSON]", len(body))
+		}
+		return string(sanitized)
+	case strings.HasPrefix(contentType, "text/plain"):
+		return string(body)
+	default:
+		return fmt.Sprintf("[%d bytes of %s]", len(body), contentType)
+	}
+}
+
+// redactValue replaces the values of sensitive fields in a decoded JSON value.
+func redactValue(value any, request bool) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			name := fieldKey(key)
+			if sensitiveFields[name] || request && sensitiveRequestFields[name] {
+				v[key] = redacted
+				continue
+			}
+			v[key] = redactValue(field, request)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactValue(item, request)
+		}
+	}
+	return value
+}
+
+// fieldKey normalizes a field or parameter name for the sensitive field lookups, so
+// that "reply_to", "ReplyTo" and "replyTo" match alike.
+func fieldKey(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
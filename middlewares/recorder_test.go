@@ -0,0 +1,163 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupRecorderRouter creates a Gin router that records requests with the given policy
+func setupRecorderRouter(recorder *Recorder) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(recorder.Middleware())
+	router.POST("/events/:id/register", func(c *gin.Context) {
+		var body map[string]any
+		c.ShouldBindJSON(&body)
+		delete(body, "code")
+		c.JSON(http.StatusCreated, gin.H{"registration": body})
+	})
+	router.DELETE("/events/:id/blocks/:email", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "unblocked"})
+	})
+	router.GET("/events", func(c *gin.Context) {
+		c.String(http.StatusOK, strings.Repeat("x", maxRecordedBody+10))
+	})
+	return router
+}
+
+// TestRecorderMatch tests that only requests for the configured users and routes are recorded
+func TestRecorderMatch(t *testing.T) {
+	recorder := NewRecorder(RecordingPolicy{
+		Users:  map[string]bool{"organizer-1": true},
+		Routes: map[string]bool{"POST /events/:id/register": true},
+	})
+	router := setupRecorderRouter(recorder)
+
+	serve := func(method, path, userID string) {
+		req, _ := http.NewRequest(method, path, nil)
+		if userID != "" {
+			req.Header.Set("X-User-ID", userID)
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	serve("POST", "/events/1/register", "")
+	serve("GET", "/events", "organizer-1")
+	serve("GET", "/events", "organizer-2")
+	serve("DELETE", "/events/1/blocks/a@example.com", "")
+	serve("GET", "/missing", "")
+
+	recordings := recorder.Recordings()
+	if len(recordings) != 2 {
+		t.Fatalf("Expected 2 recordings, got %d", len(recordings))
+	}
+	if recordings[0].Route != "/events" || recordings[0].User != "organizer-1" {
+		t.Errorf("Expected the user's listing first, got %+v", recordings[0])
+	}
+	if recordings[1].Route != "/events/:id/register" || recordings[1].User != "" {
+		t.Errorf("Expected the recorded route second, got %+v", recordings[1])
+	}
+}
+
+// TestRecorderSanitizes tests that secrets and personal data are redacted from recordings
+func TestRecorderSanitizes(t *testing.T) {
+	recorder := NewRecorder(RecordingPolicy{Routes: map[string]bool{
+		"/events/:id/register":      true,
+		"/events/:id/blocks/:email": true,
+		"/events":                   true,
+	}})
+	router := setupRecorderRouter(recorder)
+
+	body := `{"name": "Alice", "email": "alice@example.com", "code": "123456", "guests": [{"Email": "bob@example.com"}]}`
+	req, _ := http.NewRequest("POST", "/events/1/register?ref=newsletter&token=secret", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer key")
+	req.Header.Set(CaptchaHeader, "captcha")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), "alice@example.com") {
+		t.Fatalf("Expected the handler to read the whole body, got %s", w.Body.String())
+	}
+
+	recording := recorder.Recordings()[0]
+	for _, secret := range []string{"alice@example.com", "bob@example.com", "123456", "Bearer key", "captcha", "secret"} {
+		encoded, _ := json.Marshal(recording)
+		if bytes.Contains(encoded, []byte(secret)) {
+			t.Errorf("Expected %q to be redacted from %s", secret, encoded)
+		}
+	}
+	if !strings.Contains(recording.RequestBody, `"name":"Alice"`) || !strings.Contains(recording.ResponseBody, `"name":"Alice"`) {
+		t.Errorf("Expected the other fields to be kept, got %s and %s", recording.RequestBody, recording.ResponseBody)
+	}
+	if recording.Query != "ref=newsletter&token=%5Bredacted%5D" || recording.Status != http.StatusCreated {
+		t.Errorf("Unexpected recording %+v", recording)
+	}
+
+	req, _ = http.NewRequest("DELETE", "/events/1/blocks/a@example.com", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if path := recorder.Recordings()[0].Path; path != "/events/1/blocks/[redacted]" {
+		t.Errorf("Expected the email address redacted from the path, got %s", path)
+	}
+
+	req, _ = http.NewRequest("GET", "/events", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	recording = recorder.Recordings()[0]
+	if !recording.Truncated || len(recording.ResponseBody) != maxRecordedBody {
+		t.Errorf("Expected the response body cut to %d bytes, got %d", maxRecordedBody, len(recording.ResponseBody))
+	}
+}
+
+// TestRecorderRingBuffer tests that the oldest recordings are dropped and that recordings
+// are also written to the file
+func TestRecorderRingBuffer(t *testing.T) {
+	var file bytes.Buffer
+	recorder := NewRecorder(RecordingPolicy{Routes: map[string]bool{"/events/:id/register": true}, Capacity: 2, File: &file})
+	router := setupRecorderRouter(recorder)
+
+	for _, id := range []string{"1", "2", "3"} {
+		req, _ := http.NewRequest("POST", "/events/"+id+"/register", nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	recordings := recorder.Recordings()
+	if len(recordings) != 2 || recordings[0].Path != "/events/3/register" || recordings[1].Path != "/events/2/register" {
+		t.Errorf("Expected the two newest recordings, got %+v", recordings)
+	}
+	if lines := strings.Count(file.String(), "\n"); lines != 3 {
+		t.Errorf("Expected every recording in the file, got %d lines", lines)
+	}
+
+	recorder.Clear()
+	if len(recorder.Recordings()) != 0 {
+		t.Error("Expected no recordings after clearing")
+	}
+
+	var disabled *Recorder
+	if disabled.Enabled() || len(disabled.Recordings()) != 0 {
+		t.Error("Expected a nil recorder to record nothing")
+	}
+}
+
+// TestRecorderFromEnv tests that recording is opt-in and validates its settings
+func TestRecorderFromEnv(t *testing.T) {
+	recorder, err := RecorderFromEnv()
+	if err != nil || recorder != nil {
+		t.Errorf("Expected recording to be disabled by default, got %v (%v)", recorder, err)
+	}
+
+	t.Setenv("RECORD_USERS", "organizer-1, session-2")
+	recorder, err = RecorderFromEnv()
+	if err != nil || !recorder.Enabled() || !recorder.policy.Users["session-2"] || recorder.policy.Capacity != defaultRecordingCapacity {
+		t.Errorf("Expected a recorder for both users, got %+v (%v)", recorder, err)
+	}
+
+	t.Setenv("RECORD_BUFFER_SIZE", "none")
+	_, err = RecorderFromEnv()
+	if err == nil {
+		t.Error("Expected an error for an invalid buffer size")
+	}
+}
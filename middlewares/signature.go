@@ -0,0 +1,123 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"event_booking_restapi_golang/problem"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Headers partners send with signed callbacks.
+const (
+	SignatureHeader = "X-Signature" // "sha256=" followed by the hex HMAC of the signed payload
+	TimestampHeader = "X-Timestamp" // Unix time in seconds when the callback was sent
+	NonceHeader     = "X-Nonce"     // Unique value per callback, used to reject replays
+)
+
+// maxCallbackBody limits the size of signed callback bodies read for verification.
+const maxCallbackBody = 1 << 20
+
+// NonceStore remembers the nonces of accepted callbacks so that they can't be replayed.
+type NonceStore interface {
+	// Use records the nonce until the given time and reports whether it was unused.
+	Use(nonce string, until time.Time) bool
+}
+
+// memoryNonceStore is a NonceStore kept in process memory.
+type memoryNonceStore struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time
+	now    func() time.Time
+}
+
+// NewMemoryNonceStore returns a NonceStore held in memory. Expired nonces are dropped as
+// new ones are recorded. It only protects a single instance of the API.
+func NewMemoryNonceStore() NonceStore {
+	return &memoryNonceStore{nonces: map[string]time.Time{}, now: time.Now}
+}
+
+func (s *memoryNonceStore) Use(nonce string, until time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for n, expires := range s.nonces {
+		if now.After(expires) {
+			delete(s.nonces, n)
+		}
+	}
+	if _, used := s.nonces[nonce]; used {
+		return false
+	}
+	s.nonces[nonce] = until
+	return true
+}
+
+// SignCallback returns the X-Signature value for a callback: the hex HMAC-SHA256 of
+// "timestamp.nonce.body" keyed with the shared secret, prefixed with "sha256=".
+func SignCallback(secret []byte, timestamp, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "." + nonce + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature returns a middleware for inbound partner callbacks, such as payment
+// provider or calendar sync webhooks. Before the handler runs it checks that the request
+// is signed with the shared secret (see SignCallback), that its timestamp is within
+// tolerance of the current time, and that its nonce hasn't been used before. The body
+// is restored afterwards so the handler can read it. An empty secret rejects every request.
+func VerifySignature(secret []byte, nonces NonceStore, tolerance time.Duration) gin.HandlerFunc {
+	return verifySignature(secret, nonces, tolerance, time.Now)
+}
+
+// verifySignature implements VerifySignature with a replaceable clock.
+func verifySignature(secret []byte, nonces NonceStore, tolerance time.Duration, now func() time.Time) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timestamp := c.GetHeader(TimestampHeader)
+		nonce := c.GetHeader(NonceHeader)
+		signature := c.GetHeader(SignatureHeader)
+		if len(secret) == 0 || timestamp == "" || nonce == "" || !strings.HasPrefix(signature, "sha256=") {
+			problem.Respond(c, http.StatusUnauthorized, problem.CodeSignatureInvalid,
+				"callbacks must be signed with the "+SignatureHeader+", "+TimestampHeader+" and "+NonceHeader+" headers")
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxCallbackBody+1))
+		if err != nil || len(body) > maxCallbackBody {
+			problem.Respond(c, http.StatusRequestEntityTooLarge, problem.CodeInvalidRequest, "callback body is too large")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		expected := SignCallback(secret, timestamp, nonce, body)
+		if !hmac.Equal([]byte(signature), []byte(expected)) {
+			problem.Respond(c, http.StatusUnauthorized, problem.CodeSignatureInvalid, "callback signature doesn't match")
+			return
+		}
+
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		sent := time.Unix(seconds, 0)
+		if err != nil || sent.Before(now().Add(-tolerance)) || sent.After(now().Add(tolerance)) {
+			problem.Respond(c, http.StatusUnauthorized, problem.CodeSignatureExpired, "callback timestamp is outside the accepted window")
+			return
+		}
+
+		// Nonces only need to be remembered while their timestamp would still be accepted.
+		if !nonces.Use(nonce, sent.Add(tolerance)) {
+			problem.Respond(c, http.StatusConflict, problem.CodeReplayedRequest, "callback nonce has already been used")
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,80 @@
+package middlewares
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestVerifySignature tests signature, timestamp and nonce checks on partner callbacks
+func TestVerifySignature(t *testing.T) {
+	secret := []byte("shared-secret")
+	now := time.Unix(1_700_000_000, 0)
+	fresh := strconv.FormatInt(now.Unix(), 10)
+	stale := strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10)
+	body := []byte(`{"payment":"paid"}`)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	var received []byte
+	clock := func() time.Time { return now }
+	nonces := &memoryNonceStore{nonces: map[string]time.Time{}, now: clock}
+	router.POST("/callbacks", verifySignature(secret, nonces, 5*time.Minute, clock), func(c *gin.Context) {
+		received, _ = io.ReadAll(c.Request.Body)
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name      string
+		timestamp string
+		nonce     string
+		signature string
+		expected  int
+	}{
+		{"unsigned", fresh, "n1", "", http.StatusUnauthorized},
+		{"wrong secret", fresh, "n1", SignCallback([]byte("other"), fresh, "n1", body), http.StatusUnauthorized},
+		{"signed for another nonce", fresh, "n1", SignCallback(secret, fresh, "n2", body), http.StatusUnauthorized},
+		{"stale timestamp", stale, "n1", SignCallback(secret, stale, "n1", body), http.StatusUnauthorized},
+		{"valid", fresh, "n1", SignCallback(secret, fresh, "n1", body), http.StatusOK},
+		{"replayed", fresh, "n1", SignCallback(secret, fresh, "n1", body), http.StatusConflict},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("POST", "/callbacks", bytes.NewReader(body))
+		req.Header.Set(TimestampHeader, tt.timestamp)
+		req.Header.Set(NonceHeader, tt.nonce)
+		if tt.signature != "" {
+			req.Header.Set(SignatureHeader, tt.signature)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.expected {
+			t.Errorf("%s: expected status code %d, got %d", tt.name, tt.expected, w.Code)
+		}
+	}
+	if !bytes.Equal(received, body) {
+		t.Errorf("Expected the handler to read the verified body, got %q", received)
+	}
+}
+
+// TestMemoryNonceStore tests that nonces are refused until they expire
+func TestMemoryNonceStore(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	store := &memoryNonceStore{nonces: map[string]time.Time{}, now: func() time.Time { return now }}
+
+	if !store.Use("n1", now.Add(time.Minute)) {
+		t.Fatal("Expected a new nonce to be accepted")
+	}
+	if store.Use("n1", now.Add(time.Minute)) {
+		t.Error("Expected a used nonce to be refused")
+	}
+	now = now.Add(2 * time.Minute)
+	if !store.Use("n1", now.Add(time.Minute)) {
+		t.Error("Expected an expired nonce to be forgotten")
+	}
+}
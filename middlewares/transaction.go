@@ -0,0 +1,119 @@
+package middlewares
+
+import (
+	"bytes"
+	"event_booking_restapi_golang/db"
+	"event_booking_restapi_golang/logging"
+	"event_booking_restapi_golang/problem"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Transaction returns a middleware that handles each mutating request, anything but GET,
+// HEAD and OPTIONS, within a database transaction carried by the request context (see
+// db.WithTx), so that the changes of all the model calls a handler makes take effect
+// together or not at all. The transaction is committed when the handler responds with a
+// 2xx status and rolled back otherwise. When the handler panics the transaction is
+// rolled back before the panic goes on to Recovery. The response is held back
+// until then, so that a failed commit is reported with HTTP 500 rather than a success.
+func Transaction() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		tx, err := db.DB.BeginTx(c.Request.Context(), nil)
+		if err != nil {
+			problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, "couldn't begin the transaction: "+err.Error())
+			c.Abort()
+			return
+		}
+		ctx, end := db.WithTx(c.Request.Context(), tx)
+		c.Request = c.Request.WithContext(ctx)
+		writer := &heldWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = writer
+		defer func() {
+			// A panicking handler's transaction is rolled back and its held response dropped,
+			// leaving the error response to Recovery
+			if r := recover(); r != nil {
+				c.Writer = writer.ResponseWriter
+				if err := end(false); err != nil {
+					logging.FromContext(ctx).Error("Couldn't roll back the request transaction", "error", err)
+				}
+				panic(r)
+			}
+		}()
+
+		c.Next()
+
+		c.Writer = writer.ResponseWriter
+		if writer.status < 200 || writer.status > 299 {
+			if err := end(false); err != nil {
+				logging.FromContext(ctx).Error("Couldn't roll back the request transaction", "error", err)
+			}
+			writer.release()
+			return
+		}
+		if err := end(true); err != nil {
+			problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, "couldn't commit the changes: "+err.Error())
+			return
+		}
+		writer.release()
+	}
+}
+
+// heldWriter holds back the status and body of a response until release writes them.
+type heldWriter struct {
+	gin.ResponseWriter
+	status  int
+	written bool
+	body    bytes.Buffer
+}
+
+func (w *heldWriter) WriteHeader(code int) {
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+func (w *heldWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *heldWriter) Write(data []byte) (int, error) {
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *heldWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *heldWriter) Status() int {
+	return w.status
+}
+
+func (w *heldWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *heldWriter) Written() bool {
+	return w.written
+}
+
+// Flush does nothing, since the response is only sent once the transaction ends.
+func (w *heldWriter) Flush() {}
+
+// release writes the held response.
+func (w *heldWriter) release() {
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.body.Len() > 0 {
+		w.ResponseWriter.Write(w.body.Bytes())
+	}
+}
@@ -0,0 +1,95 @@
+package middlewares
+
+import (
+	"database/sql"
+	"event_booking_restapi_golang/db"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestTransaction tests that the changes of a mutating request are committed together
+// when it succeeds, and rolled back when it fails or panics
+func TestTransaction(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	testDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	testDB.SetMaxOpenConns(1)
+	originalDB := db.DB
+	db.DB = testDB
+	t.Cleanup(func() {
+		db.DB = originalDB
+		testDB.Close()
+	})
+	if _, err := testDB.Exec("CREATE TABLE notes (body TEXT NOT NULL)"); err != nil {
+		t.Fatalf("Failed to create notes table: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(Recovery(), Transaction())
+	// Each handler writes two notes, the second in a transaction of its own as models do
+	write := func(c *gin.Context) {
+		ctx := c.Request.Context()
+		db.Conn(ctx).ExecContext(ctx, "INSERT INTO notes (body) VALUES (?)", c.Param("body"))
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			t.Errorf("Failed to begin: %v", err)
+			return
+		}
+		defer tx.Rollback()
+		tx.ExecContext(ctx, "INSERT INTO notes (body) VALUES (?)", c.Param("body"))
+		tx.Commit()
+	}
+	router.POST("/ok/:body", func(c *gin.Context) {
+		write(c)
+		c.JSON(http.StatusCreated, gin.H{"message": "created"})
+	})
+	router.POST("/fail/:body", func(c *gin.Context) {
+		write(c)
+		c.JSON(http.StatusConflict, gin.H{"message": "conflict"})
+	})
+	router.POST("/panic/:body", func(c *gin.Context) {
+		write(c)
+		panic("handler failed")
+	})
+	router.GET("/count", func(c *gin.Context) {
+		var n int
+		db.Conn(c.Request.Context()).QueryRowContext(c.Request.Context(), "SELECT COUNT(*) FROM notes").Scan(&n)
+		c.JSON(http.StatusOK, gin.H{"count": n})
+	})
+
+	tests := []struct {
+		path     string
+		status   int
+		expected int
+	}{
+		{"/ok/a", http.StatusCreated, 2},
+		{"/fail/b", http.StatusConflict, 2},
+		{"/panic/c", http.StatusInternalServerError, 2},
+		{"/ok/d", http.StatusCreated, 4},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("POST", tt.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Errorf("POST %s: expected status code %d, got %d", tt.path, tt.status, w.Code)
+		}
+		var n int
+		testDB.QueryRow("SELECT COUNT(*) FROM notes").Scan(&n)
+		if n != tt.expected {
+			t.Errorf("POST %s: expected %d notes, got %d", tt.path, tt.expected, n)
+		}
+	}
+
+	req, _ := http.NewRequest("GET", "/count", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != `{"count":4}` {
+		t.Errorf("Expected reads to run outside of transactions, got %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"context"
+	"event_booking_restapi_golang/db"
+	"time"
+)
+
+// RecordAlert records that the kind of alert is being sent for the event and
+// reports whether it should be: an alert is sent once per event, or again after
+// repeatAfter has passed since it was last sent when repeatAfter is positive.
+// Concurrent bookings can't both send the same alert.
+func RecordAlert(ctx context.Context, eventID, kind string, repeatAfter time.Duration) (bool, error) {
+	now := time.Now().UTC()
+	q := `
+	INSERT INTO event_alerts (event_id, kind, sent_at) VALUES (?, ?, ?)
+	ON CONFLICT (event_id, kind) DO UPDATE SET sent_at = excluded.sent_at
+	WHERE ? AND ` + db.Timestamp("event_alerts.sent_at") + ` <= ` + db.Timestamp("?")
+	res, err := db.Conn(ctx).ExecContext(ctx, db.Rebind(q), eventID, kind, now, repeatAfter > 0, now.Add(-repeatAfter))
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+// CountRegistrationsSince returns the number of registrations made for the event
+// at or after since that are still held.
+func (e Event) CountRegistrationsSince(ctx context.Context, since time.Time) (int, error) {
+	var count int
+	q := "SELECT COUNT(*) FROM registrations WHERE event_id=? AND " + db.Timestamp("created_at") + " >= " + db.Timestamp("?")
+	err := db.Conn(ctx).QueryRowContext(ctx, db.Rebind(q), e.ID, since.UTC()).Scan(&count)
+	return count, err
+}
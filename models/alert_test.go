@@ -0,0 +1,60 @@
+package models
+
+import (
+	"context"
+	"event_booking_restapi_golang/db"
+	"testing"
+	"time"
+)
+
+// TestRecordAlert tests that alerts are recorded once, or again after the repeat interval
+func TestRecordAlert(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+
+	for i, expected := range []bool{true, false} {
+		send, err := RecordAlert(ctx, "e1", "capacity_100", 0)
+		if err != nil || send != expected {
+			t.Errorf("Attempt %d: expected send %v, got %v (%v)", i+1, expected, send, err)
+		}
+	}
+	send, err := RecordAlert(ctx, "e2", "capacity_100", 0)
+	if err != nil || !send {
+		t.Errorf("Expected the alert to be sent for another event, got %v (%v)", send, err)
+	}
+
+	send, err = RecordAlert(ctx, "e1", "sales_velocity", time.Hour)
+	if err != nil || !send {
+		t.Fatalf("Expected the first velocity alert to be sent, got %v (%v)", send, err)
+	}
+	send, err = RecordAlert(ctx, "e1", "sales_velocity", time.Hour)
+	if err != nil || send {
+		t.Errorf("Expected no repeat within the hour, got %v (%v)", send, err)
+	}
+	_, err = db.DB.Exec("UPDATE event_alerts SET sent_at = ? WHERE kind = 'sales_velocity'", time.Now().Add(-61*time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to age the alert: %v", err)
+	}
+	send, err = RecordAlert(ctx, "e1", "sales_velocity", time.Hour)
+	if err != nil || !send {
+		t.Errorf("Expected a repeat after the hour, got %v (%v)", send, err)
+	}
+}
+
+// TestEvent_CountRegistrationsSince tests counting recent registrations
+func TestEvent_CountRegistrationsSince(t *testing.T) {
+	setupTestDatabase(t)
+	now := time.Now()
+	for i, at := range []time.Time{now.Add(-2 * time.Hour), now.Add(-30 * time.Minute), now} {
+		_, err := db.DB.Exec("INSERT INTO registrations (id, event_id, name, email, created_at) VALUES (?,?,?,?,?)",
+			string(rune('a'+i)), "e1", "Attendee", string(rune('a'+i))+"@example.com", at)
+		if err != nil {
+			t.Fatalf("Failed to insert registration: %v", err)
+		}
+	}
+
+	count, err := Event{ID: "e1"}.CountRegistrationsSince(context.Background(), now.Add(-time.Hour))
+	if err != nil || count != 2 {
+		t.Errorf("Expected 2 registrations in the last hour, got %d (%v)", count, err)
+	}
+}
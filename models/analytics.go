@@ -0,0 +1,36 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"event_booking_restapi_golang/db"
+	"time"
+)
+
+// OptOutOfAnalytics records that the session doesn't want its usage sent to the
+// analytics sink. Opting out again is a no-op.
+// Returns an error if the database operation fails.
+func OptOutOfAnalytics(ctx context.Context, sessionID string) error {
+	q := "INSERT INTO analytics_opt_outs (session_id, created_at) VALUES (?, ?) ON CONFLICT (session_id) DO NOTHING"
+	_, err := db.Conn(ctx).ExecContext(ctx, db.Rebind(q), sessionID, time.Now())
+	return err
+}
+
+// OptInToAnalytics removes the session's analytics opt-out, if it has one.
+// Returns an error if the database operation fails.
+func OptInToAnalytics(ctx context.Context, sessionID string) error {
+	_, err := db.Conn(ctx).ExecContext(ctx, db.Rebind("DELETE FROM analytics_opt_outs WHERE session_id=?"), sessionID)
+	return err
+}
+
+// AnalyticsOptedOut reports whether the session has opted out of analytics.
+// Returns an error if the database operation fails.
+func AnalyticsOptedOut(ctx context.Context, sessionID string) (bool, error) {
+	var id string
+	err := db.Conn(ctx).QueryRowContext(ctx, db.Rebind("SELECT session_id FROM analytics_opt_outs WHERE session_id=?"), sessionID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"context"
+	"testing"
+)
+
+// TestAnalyticsOptOut tests opting a session out of analytics and back in
+func TestAnalyticsOptOut(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+
+	optedOut, err := AnalyticsOptedOut(ctx, "session-1")
+	if err != nil || optedOut {
+		t.Fatalf("Expected a new session not to be opted out, got %v (%v)", optedOut, err)
+	}
+
+	// Opting out twice must not fail on the existing row
+	for i := 0; i < 2; i++ {
+		if err := OptOutOfAnalytics(ctx, "session-1"); err != nil {
+			t.Fatalf("Failed to opt out: %v", err)
+		}
+	}
+	optedOut, err = AnalyticsOptedOut(ctx, "session-1")
+	if err != nil || !optedOut {
+		t.Fatalf("Expected the session to be opted out, got %v (%v)", optedOut, err)
+	}
+	optedOut, _ = AnalyticsOptedOut(ctx, "session-2")
+	if optedOut {
+		t.Error("Expected other sessions not to be opted out")
+	}
+
+	if err := OptInToAnalytics(ctx, "session-1"); err != nil {
+		t.Fatalf("Failed to opt in: %v", err)
+	}
+	optedOut, _ = AnalyticsOptedOut(ctx, "session-1")
+	if optedOut {
+		t.Error("Expected the session to be opted back in")
+	}
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"event_booking_restapi_golang/db"
+	"event_booking_restapi_golang/ids"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Attachment kinds.
+const (
+	AttachmentAgenda = "agenda" // Event agenda or programme
+	AttachmentWaiver = "waiver" // Liability waiver attendees must accept
+	AttachmentOther  = "other"  // Any other supporting document
+)
+
+// AttachmentsDir is the directory attachment files are stored in, named by attachment ID.
+var AttachmentsDir = "attachments"
+
+// Attachment is a file attached to an event, such as an agenda or a waiver.
+type Attachment struct {
+	ID            string    // Unique identifier for the attachment
+	EventID       string    // ID of the event the file is attached to
+	Kind          string    // One of agenda, waiver or other
+	FileName      string    // Original name of the uploaded file
+	ContentType   string    // Detected MIME type of the file
+	Size          int64     // File size in bytes
+	AttendeesOnly bool      // Whether downloads are limited to registered attendees
+	CreatedAt     time.Time // Time the file was attached
+}
+
+// Path returns the location of the attachment's file on disk.
+func (a Attachment) Path() string {
+	return filepath.Join(AttachmentsDir, a.ID)
+}
+
+// AddAttachment stores the file content and records it as an attachment of the event.
+// The file is removed again if the database insert fails.
+// Returns the stored attachment with its generated ID.
+func (e Event) AddAttachment(ctx context.Context, a Attachment, content []byte) (Attachment, error) {
+	a.ID = ids.New()
+	a.EventID = e.ID
+	a.Size = int64(len(content))
+	a.CreatedAt = time.Now()
+
+	err := os.MkdirAll(AttachmentsDir, 0o755)
+	if err != nil {
+		return Attachment{}, err
+	}
+	err = os.WriteFile(a.Path(), content, 0o644)
+	if err != nil {
+		return Attachment{}, err
+	}
+
+	q := `
+	INSERT INTO attachments (id, event_id, kind, file_name, content_type, size, attendees_only, created_at)
+	VALUES (?,?,?,?,?,?,?,?)
+	`
+	_, err = db.Conn(ctx).ExecContext(ctx, db.Rebind(q), a.ID, a.EventID, a.Kind, a.FileName, a.ContentType, a.Size, a.AttendeesOnly, a.CreatedAt)
+	if err != nil {
+		os.Remove(a.Path())
+		return Attachment{}, err
+	}
+	return a, nil
+}
+
+// attachmentColumns lists the attachment columns in the order scanAttachment expects them.
+const attachmentColumns = "id, event_id, kind, file_name, content_type, size, attendees_only, created_at"
+
+// scanAttachment reads an attachment row selected with attachmentColumns.
+func scanAttachment(row rowScanner) (Attachment, error) {
+	var a Attachment
+	err := row.Scan(&a.ID, &a.EventID, &a.Kind, &a.FileName, &a.ContentType, &a.Size, &a.AttendeesOnly, &a.CreatedAt)
+	return a, err
+}
+
+// GetAttachments retrieves the event's attachments, oldest first.
+// Returns a slice of Attachment objects and any error encountered during the query.
+func (e Event) GetAttachments(ctx context.Context) ([]Attachment, error) {
+	q := "SELECT " + attachmentColumns + " FROM attachments WHERE event_id=? ORDER BY created_at, id"
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q), e.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []Attachment
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, nil
+}
+
+// GetAttachmentById retrieves a single attachment from the database by its ID.
+// Returns the Attachment if found, otherwise returns an empty Attachment and an error,
+// which is a NotFoundError when no attachment has the ID.
+func GetAttachmentById(ctx context.Context, id string) (Attachment, error) {
+	q := "SELECT " + attachmentColumns + " FROM attachments WHERE id=?"
+	a, err := scanAttachment(db.Conn(ctx).QueryRowContext(ctx, db.Rebind(q), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Attachment{}, NotFoundError{Message: fmt.Sprint("Couldn't find an attachment with the ID of ", id)}
+	}
+	if err != nil {
+		return Attachment{}, err
+	}
+	return a, nil
+}
+
+// Delete removes the attachment record and its file.
+// Returns an error if the database operation fails.
+func (a Attachment) Delete(ctx context.Context) error {
+	_, err := db.Conn(ctx).ExecContext(ctx, db.Rebind("DELETE FROM attachments WHERE id=?"), a.ID)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(a.Path())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
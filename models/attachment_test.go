@@ -0,0 +1,90 @@
+package models
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+// useTempAttachmentsDir stores attachment files in a temporary directory for the test
+func useTempAttachmentsDir(t *testing.T) {
+	originalDir := AttachmentsDir
+	AttachmentsDir = t.TempDir()
+	t.Cleanup(func() { AttachmentsDir = originalDir })
+}
+
+// TestEvent_AddAttachment tests storing, listing and deleting attachments
+func TestEvent_AddAttachment(t *testing.T) {
+	setupTestDatabase(t)
+	useTempAttachmentsDir(t)
+	event := saveEvent(t, Event{Title: "Conference"})
+
+	content := []byte("%PDF-1.7 agenda")
+	attachment, err := event.AddAttachment(context.Background(), Attachment{
+		Kind:          AttachmentWaiver,
+		FileName:      "waiver.pdf",
+		ContentType:   "application/pdf",
+		AttendeesOnly: true,
+	}, content)
+	if err != nil {
+		t.Fatalf("Failed to add attachment: %v", err)
+	}
+	if attachment.ID == "" || attachment.EventID != event.ID || attachment.Size != int64(len(content)) {
+		t.Errorf("Unexpected attachment %+v", attachment)
+	}
+
+	stored, err := os.ReadFile(attachment.Path())
+	if err != nil || string(stored) != string(content) {
+		t.Errorf("Expected file content to be stored, got %q (%v)", stored, err)
+	}
+
+	attachments, err := event.GetAttachments(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get attachments: %v", err)
+	}
+	if len(attachments) != 1 || !attachments[0].AttendeesOnly || attachments[0].Kind != AttachmentWaiver {
+		t.Errorf("Expected the waiver to be listed, got %+v", attachments)
+	}
+
+	err = attachment.Delete(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to delete attachment: %v", err)
+	}
+	if _, err = os.Stat(attachment.Path()); !os.IsNotExist(err) {
+		t.Error("Expected attachment file to be removed")
+	}
+	if _, err = GetAttachmentById(context.Background(), attachment.ID); err == nil {
+		t.Error("Expected attachment to be gone")
+	}
+}
+
+// TestEvent_IsRegistered tests matching registration IDs against an event
+func TestEvent_IsRegistered(t *testing.T) {
+	setupTestDatabase(t)
+	event := saveEvent(t, Event{Title: "Event A"})
+	other := saveEvent(t, Event{Title: "Event B"})
+
+	registration, err := event.Register(context.Background(), Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	tests := []struct {
+		event    Event
+		id       string
+		expected bool
+	}{
+		{event, registration.ID, true},
+		{other, registration.ID, false},
+		{event, "", false},
+	}
+	for _, tt := range tests {
+		registered, err := tt.event.IsRegistered(context.Background(), tt.id)
+		if err != nil {
+			t.Fatalf("Failed to check registration: %v", err)
+		}
+		if registered != tt.expected {
+			t.Errorf("Expected %v for registration %q on %s, got %v", tt.expected, tt.id, tt.event.Title, registered)
+		}
+	}
+}
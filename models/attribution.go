@@ -0,0 +1,61 @@
+package models
+
+import (
+	"context"
+	"event_booking_restapi_golang/db"
+)
+
+// DirectSource is the source reported for bookings that arrived without UTM or referral parameters.
+const DirectSource = "direct"
+
+// Attribution records the marketing channel a booking came from, as passed into the
+// booking flow with the utm_* and ref query parameters.
+type Attribution struct {
+	UTMSource   string // Referring site or newsletter, e.g. "newsletter"
+	UTMMedium   string // Marketing medium, e.g. "email" or "cpc"
+	UTMCampaign string // Campaign name
+	UTMTerm     string // Paid search keywords
+	UTMContent  string // Variant of the ad or link that was clicked
+	Ref         string // Free-form referral code
+}
+
+// attributionColumns lists the attribution columns of the registrations and waitlist tables
+// in Attribution field order.
+const attributionColumns = "utm_source, utm_medium, utm_campaign, utm_term, utm_content, ref"
+
+// SourceCount is the number of registrations attributed to one channel of an event.
+type SourceCount struct {
+	Source        string // utm_source, the referral code when it is unset, or "direct"
+	Medium        string // utm_medium, empty when unset
+	Campaign      string // utm_campaign, empty when unset
+	Registrations int    // Number of registrations from the channel
+}
+
+// GetSourceBreakdown counts the event's registrations per source, medium and campaign,
+// busiest channel first.
+// Returns a slice of SourceCount objects and any error encountered during the query.
+func (e Event) GetSourceBreakdown(ctx context.Context) ([]SourceCount, error) {
+	q := `
+	SELECT COALESCE(NULLIF(utm_source, ''), NULLIF(ref, ''), ?) AS source, utm_medium, utm_campaign, COUNT(*) AS registrations
+	FROM registrations
+	WHERE event_id=?
+	GROUP BY source, utm_medium, utm_campaign
+	ORDER BY registrations DESC, source, utm_medium, utm_campaign
+	`
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q), DirectSource, e.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sources []SourceCount
+	for rows.Next() {
+		var s SourceCount
+		err := rows.Scan(&s.Source, &s.Medium, &s.Campaign, &s.Registrations)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, s)
+	}
+	return sources, nil
+}
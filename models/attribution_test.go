@@ -0,0 +1,59 @@
+package models
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// TestEvent_GetSourceBreakdown tests counting registrations per channel, including promoted waitlist entries
+func TestEvent_GetSourceBreakdown(t *testing.T) {
+	setupTestDatabase(t)
+	event := saveEvent(t, Event{Title: "Attributed Event", Capacity: 3})
+
+	newsletter := Attribution{UTMSource: "newsletter", UTMMedium: "email", UTMCampaign: "spring"}
+	bookings := []Registration{
+		{Name: "Alice", Email: "alice@example.com", Attribution: newsletter},
+		{Name: "Bob", Email: "bob@example.com", Attribution: newsletter},
+		{Name: "Carol", Email: "carol@example.com"},
+	}
+	var cancelled Registration
+	for _, r := range bookings {
+		registration, err := event.Register(context.Background(), r)
+		if err != nil {
+			t.Fatalf("Failed to register: %v", err)
+		}
+		cancelled = registration
+	}
+	_, err := event.JoinWaitlist(context.Background(), WaitlistEntry{Name: "Dave", Email: "dave@example.com", Attribution: Attribution{Ref: "partner42"}})
+	if err != nil {
+		t.Fatalf("Failed to join waitlist: %v", err)
+	}
+	promoted, err := cancelled.Cancel(context.Background())
+	if err != nil || promoted == nil {
+		t.Fatalf("Expected Dave to be promoted, got %v, %v", promoted, err)
+	}
+	if promoted.Ref != "partner42" {
+		t.Errorf("Expected the referral code to carry over on promotion, got %+v", promoted.Attribution)
+	}
+
+	sources, err := event.GetSourceBreakdown(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get source breakdown: %v", err)
+	}
+	expected := []SourceCount{
+		{Source: "newsletter", Medium: "email", Campaign: "spring", Registrations: 2},
+		{Source: "partner42", Registrations: 1},
+	}
+	if !reflect.DeepEqual(sources, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, sources)
+	}
+
+	registrations, err := event.GetRegistrations(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get registrations: %v", err)
+	}
+	if registrations[0].Attribution != newsletter {
+		t.Errorf("Expected stored attribution %+v, got %+v", newsletter, registrations[0].Attribution)
+	}
+}
@@ -0,0 +1,197 @@
+package models
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"event_booking_restapi_golang/db"
+	"time"
+)
+
+// AuditEnabled turns on the audit log of ticket sales. It is off by default and set at startup.
+var AuditEnabled = false
+
+// Audited ticket sales actions.
+const (
+	AuditRegistered  = "registration.created"     // An attendee booked a place
+	AuditCancelled   = "registration.cancelled"   // A registration was cancelled
+	AuditPromoted    = "registration.promoted"    // A waitlisted attendee took a freed place
+	AuditRescheduled = "registration.rescheduled" // An attendee moved their booking to another occurrence of the series
+	AuditWaitlisted  = "waitlist.joined"          // An attendee joined the waitlist of a full event
+)
+
+// maxAuditPage caps the number of audit log entries read at once.
+const maxAuditPage = 1000
+
+// AuditEntry is a record in the append-only audit log. Each entry's hash covers its
+// content and the previous entry's hash, so changing, removing or reordering any
+// entry breaks the chain from that point on.
+type AuditEntry struct {
+	Seq       int64             // Position in the log, starting at 1 with no gaps
+	Action    string            // One of the Audit* actions
+	EventID   string            // Event the action concerns
+	SubjectID string            // Registration or waitlist entry the action concerns
+	Details   map[string]string // Action-specific values, such as the attendee email
+	CreatedAt time.Time         // Time the action happened
+	PrevHash  string            // Hash of the previous entry, empty for the first one
+	Hash      string            // Hex SHA-256 of this entry, see computeHash
+}
+
+// AuditVerification is the result of checking the audit log's hash chain.
+type AuditVerification struct {
+	Entries  int64  // Number of entries checked
+	LastHash string // Hash of the last entry, to compare against an exported copy
+	BrokenAt int64  // Seq of the first entry that doesn't verify, 0 when the chain is intact
+	Reason   string // Why BrokenAt doesn't verify
+}
+
+// computeHash returns the hex SHA-256 of the entry's content and PrevHash. The fields
+// are hashed as a JSON array so that values can't run into each other.
+func (a AuditEntry) computeHash() (string, error) {
+	content, err := json.Marshal([]any{
+		a.Seq, a.PrevHash, a.CreatedAt.UTC().Format(time.RFC3339Nano), a.Action, a.EventID, a.SubjectID, a.Details,
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// recordAudit appends an entry to the audit log inside tx, so it is committed
+// together with the change it records. The table is locked while the entry is
+// chained to the last one. Does nothing unless AuditEnabled is set.
+func recordAudit(ctx context.Context, tx *db.Tx, action, eventID, subjectID string, details map[string]string) error {
+	if !AuditEnabled {
+		return nil
+	}
+	if lock := db.LockTable("audit_log"); lock != "" {
+		_, err := tx.ExecContext(ctx, lock)
+		if err != nil {
+			return err
+		}
+	}
+
+	entry := AuditEntry{
+		Action:    action,
+		EventID:   eventID,
+		SubjectID: subjectID,
+		Details:   details,
+		// Truncated to what PostgreSQL stores, so the hash verifies after a round trip
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	err := tx.QueryRowContext(ctx, "SELECT seq, hash FROM audit_log ORDER BY seq DESC LIMIT 1").Scan(&entry.Seq, &entry.PrevHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	entry.Seq++
+	entry.Hash, err = entry.computeHash()
+	if err != nil {
+		return err
+	}
+
+	encoded, err := json.Marshal(entry.Details)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		db.Rebind("INSERT INTO audit_log (seq, action, event_id, subject_id, details, created_at, prev_hash, hash) VALUES (?,?,?,?,?,?,?,?)"),
+		entry.Seq, entry.Action, entry.EventID, entry.SubjectID, string(encoded), entry.CreatedAt, entry.PrevHash, entry.Hash,
+	)
+	return err
+}
+
+// attendeeDetails returns the audit details recorded for a booking.
+func attendeeDetails(name, email string) map[string]string {
+	return map[string]string{"name": name, "email": email}
+}
+
+// GetAuditEntries returns up to limit audit log entries with a seq greater than
+// afterSeq, in log order. The limit is capped at 1000.
+func GetAuditEntries(ctx context.Context, afterSeq int64, limit int) ([]AuditEntry, error) {
+	if limit <= 0 || limit > maxAuditPage {
+		limit = maxAuditPage
+	}
+	q := "SELECT seq, action, event_id, subject_id, details, created_at, prev_hash, hash FROM audit_log WHERE seq > ? ORDER BY seq LIMIT ?"
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q), afterSeq, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+	for rows.Next() {
+		var entry AuditEntry
+		var details string
+		err = rows.Scan(&entry.Seq, &entry.Action, &entry.EventID, &entry.SubjectID, &details, &entry.CreatedAt, &entry.PrevHash, &entry.Hash)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal([]byte(details), &entry.Details)
+		if err != nil {
+			return nil, err
+		}
+		entry.CreatedAt = entry.CreatedAt.UTC()
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// VerifyAuditLog walks the whole audit log and checks that the entries are numbered
+// without gaps, that each one links to the previous hash and that each hash matches
+// its content. A broken chain is reported in the result, not as an error.
+func VerifyAuditLog(ctx context.Context) (AuditVerification, error) {
+	var result AuditVerification
+	var after int64
+	for {
+		entries, err := GetAuditEntries(ctx, after, maxAuditPage)
+		if err != nil {
+			return result, err
+		}
+		if len(entries) == 0 {
+			return result, nil
+		}
+		for _, entry := range entries {
+			hash, err := entry.computeHash()
+			if err != nil {
+				return result, err
+			}
+			switch {
+			case entry.Seq != result.Entries+1:
+				result.BrokenAt, result.Reason = entry.Seq, "entries are missing before this one"
+			case entry.PrevHash != result.LastHash:
+				result.BrokenAt, result.Reason = entry.Seq, "previous hash doesn't match the preceding entry"
+			case entry.Hash != hash:
+				result.BrokenAt, result.Reason = entry.Seq, "hash doesn't match the entry's content"
+			}
+			if result.BrokenAt != 0 {
+				return result, nil
+			}
+			result.Entries++
+			result.LastHash = entry.Hash
+		}
+		after = entries[len(entries)-1].Seq
+	}
+}
+
+// GetAuditExportCursor returns the seq of the last entry exported to the named sink,
+// or 0 when nothing has been exported to it yet.
+func GetAuditExportCursor(ctx context.Context, sink string) (int64, error) {
+	var seq int64
+	err := db.Conn(ctx).QueryRowContext(ctx, db.Rebind("SELECT last_seq FROM audit_export WHERE sink=?"), sink).Scan(&seq)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return seq, err
+}
+
+// SetAuditExportCursor records seq as the last entry exported to the named sink.
+func SetAuditExportCursor(ctx context.Context, sink string, seq int64) error {
+	q := "INSERT INTO audit_export (sink, last_seq) VALUES (?, ?) ON CONFLICT (sink) DO UPDATE SET last_seq = excluded.last_seq"
+	_, err := db.Conn(ctx).ExecContext(ctx, db.Rebind(q), sink, seq)
+	return err
+}
@@ -0,0 +1,155 @@
+package models
+
+import (
+	"context"
+	"testing"
+)
+
+// enableAudit turns the audit log on for the duration of a test
+func enableAudit(t *testing.T) {
+	AuditEnabled = true
+	t.Cleanup(func() { AuditEnabled = false })
+}
+
+// recordTestSales books, waitlists and cancels on a one-place event, producing four audit entries
+func recordTestSales(t *testing.T) Event {
+	event := saveEvent(t, Event{Title: "Audited Event", Capacity: 1})
+	registration, err := event.Register(context.Background(), Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	_, err = event.JoinWaitlist(context.Background(), WaitlistEntry{Name: "Bob", Email: "bob@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to join waitlist: %v", err)
+	}
+	_, err = registration.Cancel(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to cancel registration: %v", err)
+	}
+	return event
+}
+
+// TestAuditLogDisabled tests that nothing is recorded unless the audit log is enabled
+func TestAuditLogDisabled(t *testing.T) {
+	setupTestDatabase(t)
+	recordTestSales(t)
+
+	entries, err := GetAuditEntries(context.Background(), 0, 0)
+	if err != nil {
+		t.Fatalf("Failed to get audit entries: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected no audit entries, got %d", len(entries))
+	}
+}
+
+// TestAuditLogRecordsSales tests that bookings are recorded as a hash chain in log order
+func TestAuditLogRecordsSales(t *testing.T) {
+	setupTestDatabase(t)
+	enableAudit(t)
+	event := recordTestSales(t)
+
+	entries, err := GetAuditEntries(context.Background(), 0, 0)
+	if err != nil {
+		t.Fatalf("Failed to get audit entries: %v", err)
+	}
+	expected := []struct{ action, email string }{
+		{AuditRegistered, "alice@example.com"},
+		{AuditWaitlisted, "bob@example.com"},
+		{AuditCancelled, "alice@example.com"},
+		{AuditPromoted, "bob@example.com"},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected %d audit entries, got %d", len(expected), len(entries))
+	}
+	prevHash := ""
+	for i, entry := range entries {
+		if entry.Seq != int64(i+1) || entry.Action != expected[i].action || entry.Details["email"] != expected[i].email {
+			t.Errorf("Entry %d: expected %s for %s, got %+v", i+1, expected[i].action, expected[i].email, entry)
+		}
+		if entry.EventID != event.ID || entry.SubjectID == "" {
+			t.Errorf("Entry %d: expected event %s and a subject, got %+v", i+1, event.ID, entry)
+		}
+		if entry.PrevHash != prevHash || len(entry.Hash) != 64 {
+			t.Errorf("Entry %d doesn't chain to the previous one: %+v", i+1, entry)
+		}
+		prevHash = entry.Hash
+	}
+	if entries[3].Details["waitlist_entry_id"] == "" {
+		t.Error("Expected the promotion to name the waitlist entry")
+	}
+
+	page, err := GetAuditEntries(context.Background(), 2, 1)
+	if err != nil {
+		t.Fatalf("Failed to get audit entries: %v", err)
+	}
+	if len(page) != 1 || page[0].Seq != 3 {
+		t.Errorf("Expected only entry 3, got %+v", page)
+	}
+
+	result, err := VerifyAuditLog(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to verify audit log: %v", err)
+	}
+	if result.BrokenAt != 0 || result.Entries != 4 || result.LastHash != prevHash {
+		t.Errorf("Expected an intact chain of 4 entries ending in %s, got %+v", prevHash, result)
+	}
+}
+
+// TestVerifyAuditLogDetectsTampering tests that changed and removed entries break the chain
+func TestVerifyAuditLogDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name     string
+		tamper   string
+		brokenAt int64
+	}{
+		{"changed details", `UPDATE audit_log SET details = '{"email":"mallory@example.com","name":"Alice"}' WHERE seq = 3`, 3},
+		{"changed hash", `UPDATE audit_log SET hash = 'beef' WHERE seq = 2`, 2},
+		{"removed entry", `DELETE FROM audit_log WHERE seq = 2`, 3},
+		{"removed first entry", `DELETE FROM audit_log WHERE seq = 1`, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestDatabase(t)
+			enableAudit(t)
+			recordTestSales(t)
+
+			_, err := testDB.Exec(tt.tamper)
+			if err != nil {
+				t.Fatalf("Failed to tamper with the audit log: %v", err)
+			}
+
+			result, err := VerifyAuditLog(context.Background())
+			if err != nil {
+				t.Fatalf("Failed to verify audit log: %v", err)
+			}
+			if result.BrokenAt != tt.brokenAt || result.Reason == "" {
+				t.Errorf("Expected the chain to break at %d, got %+v", tt.brokenAt, result)
+			}
+		})
+	}
+}
+
+// TestAuditExportCursor tests that export progress is stored per sink
+func TestAuditExportCursor(t *testing.T) {
+	setupTestDatabase(t)
+
+	seq, err := GetAuditExportCursor(context.Background(), "syslog")
+	if err != nil || seq != 0 {
+		t.Fatalf("Expected no progress yet, got %d, %v", seq, err)
+	}
+	for _, want := range []int64{5, 9} {
+		err = SetAuditExportCursor(context.Background(), "syslog", want)
+		if err != nil {
+			t.Fatalf("Failed to set export cursor: %v", err)
+		}
+		seq, err = GetAuditExportCursor(context.Background(), "syslog")
+		if err != nil || seq != want {
+			t.Errorf("Expected progress %d, got %d, %v", want, seq, err)
+		}
+	}
+	seq, _ = GetAuditExportCursor(context.Background(), "s3:bucket/")
+	if seq != 0 {
+		t.Errorf("Expected other sinks to keep their own progress, got %d", seq)
+	}
+}
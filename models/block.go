@@ -0,0 +1,102 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"event_booking_restapi_golang/db"
+	"strings"
+	"time"
+)
+
+// Who made a block. Organizers are identified by the user ID on their events and
+// attendees by the email address they register with.
+const (
+	BlockedByOrganizer = "organizer" // The organizer keeps the attendee out of their events
+	BlockedByAttendee  = "attendee"  // The attendee stops the organizer from contacting them
+)
+
+// ErrBlockedByOrganizer is returned when an attendee the organizer blocked tries to
+// book one of their events or message them.
+var ErrBlockedByOrganizer = errors.New("the organizer of this event has blocked you")
+
+// ErrBlockedByAttendee is returned when an organizer messages an attendee who blocked them.
+var ErrBlockedByAttendee = errors.New("this attendee has blocked messages from you")
+
+// Block keeps an attendee and an organizer apart. A block made by the organizer
+// stops the attendee from booking the organizer's events and messaging them; a block
+// made by the attendee stops the organizer from messaging the attendee.
+type Block struct {
+	OrganizerID string    // User ID of the organizer
+	Email       string    // Email address of the attendee, lower-cased
+	BlockedBy   string    // BlockedByOrganizer or BlockedByAttendee
+	CreatedAt   time.Time // When the block was made
+}
+
+// queryRower runs single-row queries on the database or within a transaction.
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// AddBlock records the block. Blocking again keeps the original block.
+func AddBlock(ctx context.Context, organizerID, email, blockedBy string) (Block, error) {
+	b := Block{OrganizerID: organizerID, Email: normalizeEmail(email), BlockedBy: blockedBy, CreatedAt: time.Now().UTC()}
+	q := `
+	INSERT INTO user_blocks (organizer_id, email, blocked_by, created_at) VALUES (?, ?, ?, ?)
+	ON CONFLICT (organizer_id, email, blocked_by) DO NOTHING
+	`
+	_, err := db.Conn(ctx).ExecContext(ctx, db.Rebind(q), b.OrganizerID, b.Email, b.BlockedBy, b.CreatedAt)
+	if err != nil {
+		return Block{}, err
+	}
+	return b, nil
+}
+
+// RemoveBlock lifts the block. Lifting a block that doesn't exist is not an error.
+func RemoveBlock(ctx context.Context, organizerID, email, blockedBy string) error {
+	q := "DELETE FROM user_blocks WHERE organizer_id=? AND email=? AND blocked_by=?"
+	_, err := db.Conn(ctx).ExecContext(ctx, db.Rebind(q), organizerID, normalizeEmail(email), blockedBy)
+	return err
+}
+
+// IsBlocked reports whether blockedBy has blocked the other side of the pair.
+func IsBlocked(ctx context.Context, organizerID, email, blockedBy string) (bool, error) {
+	return isBlocked(ctx, db.Conn(ctx), organizerID, email, blockedBy)
+}
+
+// isBlocked is IsBlocked on the database or within a transaction.
+func isBlocked(ctx context.Context, q queryRower, organizerID, email, blockedBy string) (bool, error) {
+	var count int
+	err := q.QueryRowContext(
+		ctx,
+		db.Rebind("SELECT COUNT(*) FROM user_blocks WHERE organizer_id=? AND email=? AND blocked_by=?"),
+		organizerID, normalizeEmail(email), blockedBy,
+	).Scan(&count)
+	return count > 0, err
+}
+
+// GetBlocks returns the blocks blockedBy made involving the organizer, newest first.
+func GetBlocks(ctx context.Context, organizerID, blockedBy string) ([]Block, error) {
+	q := "SELECT organizer_id, email, blocked_by, created_at FROM user_blocks WHERE organizer_id=? AND blocked_by=? ORDER BY created_at DESC, email"
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q), organizerID, blockedBy)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var blocks []Block
+	for rows.Next() {
+		var b Block
+		err := rows.Scan(&b.OrganizerID, &b.Email, &b.BlockedBy, &b.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, b)
+	}
+	return blocks, rows.Err()
+}
+
+// normalizeEmail lower-cases the address so blocks match however it is capitalized.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
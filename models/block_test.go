@@ -0,0 +1,125 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestBlocks tests adding, listing and lifting blocks
+func TestBlocks(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+
+	_, err := AddBlock(ctx, "organizer-1", " Mallory@Example.com ", BlockedByOrganizer)
+	if err != nil {
+		t.Fatalf("Failed to block: %v", err)
+	}
+	_, err = AddBlock(ctx, "organizer-1", "mallory@example.com", BlockedByOrganizer)
+	if err != nil {
+		t.Fatalf("Expected blocking again to succeed, got %v", err)
+	}
+
+	blocked, err := IsBlocked(ctx, "organizer-1", "MALLORY@example.com", BlockedByOrganizer)
+	if err != nil || !blocked {
+		t.Errorf("Expected the block to match regardless of case, got %v (%v)", blocked, err)
+	}
+	blocked, _ = IsBlocked(ctx, "organizer-1", "mallory@example.com", BlockedByAttendee)
+	if blocked {
+		t.Error("Expected the organizer's block not to count as the attendee's")
+	}
+	blocked, _ = IsBlocked(ctx, "organizer-2", "mallory@example.com", BlockedByOrganizer)
+	if blocked {
+		t.Error("Expected the block to apply to one organizer only")
+	}
+
+	blocks, err := GetBlocks(ctx, "organizer-1", BlockedByOrganizer)
+	if err != nil || len(blocks) != 1 || blocks[0].Email != "mallory@example.com" {
+		t.Fatalf("Expected one block, got %+v (%v)", blocks, err)
+	}
+
+	err = RemoveBlock(ctx, "organizer-1", "Mallory@example.com", BlockedByOrganizer)
+	if err != nil {
+		t.Fatalf("Failed to unblock: %v", err)
+	}
+	blocked, _ = IsBlocked(ctx, "organizer-1", "mallory@example.com", BlockedByOrganizer)
+	if blocked {
+		t.Error("Expected the block to be lifted")
+	}
+}
+
+// TestBlocksEnforcedOnBookings tests that blocked attendees can't book or be promoted
+func TestBlocksEnforcedOnBookings(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+	event := saveEvent(t, Event{Title: "Full Event", Capacity: 1})
+	_, err := AddBlock(ctx, event.UserID, "mallory@example.com", BlockedByOrganizer)
+	if err != nil {
+		t.Fatalf("Failed to block: %v", err)
+	}
+
+	_, err = event.Register(ctx, Registration{Name: "Mallory", Email: "Mallory@example.com"})
+	if !errors.Is(err, ErrBlockedByOrganizer) {
+		t.Errorf("Expected ErrBlockedByOrganizer, got %v", err)
+	}
+	registration, err := event.Register(ctx, Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	_, err = event.JoinWaitlist(ctx, WaitlistEntry{Name: "Mallory", Email: "mallory@example.com"})
+	if !errors.Is(err, ErrBlockedByOrganizer) {
+		t.Errorf("Expected ErrBlockedByOrganizer on the waitlist, got %v", err)
+	}
+
+	// Attendees blocked while waiting are passed over on promotion
+	for _, email := range []string{"bob@example.com", "carol@example.com"} {
+		_, err = event.JoinWaitlist(ctx, WaitlistEntry{Name: "Waiting", Email: email})
+		if err != nil {
+			t.Fatalf("Failed to join waitlist: %v", err)
+		}
+	}
+	_, err = AddBlock(ctx, event.UserID, "bob@example.com", BlockedByOrganizer)
+	if err != nil {
+		t.Fatalf("Failed to block: %v", err)
+	}
+	promoted, err := registration.Cancel(ctx)
+	if err != nil {
+		t.Fatalf("Failed to cancel: %v", err)
+	}
+	if promoted == nil || promoted.Email != "carol@example.com" {
+		t.Errorf("Expected carol to be promoted, got %+v", promoted)
+	}
+}
+
+// TestBlocksEnforcedOnMessages tests that blocks stop messages in each direction
+func TestBlocksEnforcedOnMessages(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+	event := saveEvent(t, Event{Title: "Jazz Night"})
+	registration, err := event.Register(ctx, Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	_, err = AddBlock(ctx, event.UserID, "alice@example.com", BlockedByAttendee)
+	if err != nil {
+		t.Fatalf("Failed to block: %v", err)
+	}
+	_, err = event.SendMessage(ctx, registration.ID, FromOrganizer, "Hello")
+	if !errors.Is(err, ErrBlockedByAttendee) {
+		t.Errorf("Expected ErrBlockedByAttendee, got %v", err)
+	}
+	_, err = event.SendMessage(ctx, registration.ID, FromAttendee, "Hello")
+	if err != nil {
+		t.Errorf("Expected the attendee to still be able to write, got %v", err)
+	}
+
+	_, err = AddBlock(ctx, event.UserID, "alice@example.com", BlockedByOrganizer)
+	if err != nil {
+		t.Fatalf("Failed to block: %v", err)
+	}
+	_, err = event.SendMessage(ctx, registration.ID, FromAttendee, "Hello again")
+	if !errors.Is(err, ErrBlockedByOrganizer) {
+		t.Errorf("Expected ErrBlockedByOrganizer, got %v", err)
+	}
+}
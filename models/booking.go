@@ -0,0 +1,109 @@
+package models
+
+import (
+	"context"
+	"event_booking_restapi_golang/db"
+	"time"
+)
+
+// Booking is a registration together with the event it is for, as listed to the
+// attendee who made it.
+type Booking struct {
+	Registration  Registration
+	Event         Event
+	RefundRequest *RefundRequest `json:",omitempty"` // The attendee's refund request for the booking, if any
+}
+
+// BookingFilter narrows down the bookings returned by GetRegistrationsByEmail.
+// Zero values leave the corresponding criterion unrestricted.
+type BookingFilter struct {
+	From   time.Time // Only bookings of events starting at or after this time
+	To     time.Time // Only bookings of events starting at or before this time
+	Status string    // Only bookings of events in this lifecycle state
+}
+
+// Ended reports whether the booked event is over at the given time: it has ended, or
+// started when it has no end.
+func (b Booking) Ended(now time.Time) bool {
+	if b.Event.EndDateTime != nil {
+		return b.Event.EndDateTime.Before(now)
+	}
+	return b.Event.DateTime.Before(now)
+}
+
+// WithoutCredentials returns the booking without its registration ID, ticket and
+// other values only its holder may see. Emails aren't verified, so the other bookings
+// of an address are listed this way to a caller who holds just one of them: the
+// registration ID is what cancels, reschedules or refunds a booking.
+func (b Booking) WithoutCredentials() Booking {
+	b.Registration.ID, b.Registration.Ticket = "", nil
+	b.Registration.ExternalID, b.Registration.Source = "", ""
+	b.Registration.WaiverIP = ""
+	if b.RefundRequest != nil {
+		refund := *b.RefundRequest
+		refund.ID, refund.RegistrationID, refund.ProviderReference = "", "", ""
+		b.RefundRequest = &refund
+	}
+	return b
+}
+
+// GetRegistrationsByEmail retrieves the bookings the email address holds on approved
+// events matching the filter, in event date order. The address is compared
+// case-insensitively, as registrations carry no user account.
+func GetRegistrationsByEmail(ctx context.Context, email string, filter BookingFilter) ([]Booking, error) {
+	where, args := EventFilter{From: filter.From, To: filter.To, Status: filter.Status}.where()
+	q := "SELECT " + eventColumns + " FROM events" + where +
+		" AND id IN (SELECT event_id FROM registrations WHERE LOWER(email)=LOWER(?)) ORDER BY " + db.Timestamp("datetime") + ", id"
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q), append(args, email)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []Event
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	q = "SELECT " + registrationColumns + " FROM registrations WHERE LOWER(email)=LOWER(?)"
+	rows, err = db.Conn(ctx).QueryContext(ctx, db.Rebind(q), email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	registrations := map[string]Registration{}
+	for rows.Next() {
+		r, err := scanRegistration(rows)
+		if err != nil {
+			return nil, err
+		}
+		registrations[r.EventID] = r
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	refunds, err := getRefundRequestsByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	bookings := []Booking{}
+	for _, event := range events {
+		// Registrations cancelled between the two queries are left out
+		if r, ok := registrations[event.ID]; ok {
+			booking := Booking{Registration: r, Event: event}
+			if refund, ok := refunds[r.ID]; ok {
+				booking.RefundRequest = &refund
+			}
+			bookings = append(bookings, booking)
+		}
+	}
+	return bookings, nil
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestGetRegistrationsByEmail tests listing an attendee's bookings with their events,
+// filtered by event status and date
+func TestGetRegistrationsByEmail(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+	past := saveEvent(t, Event{Title: "Past Event"})
+	upcoming := saveEvent(t, Event{Title: "Upcoming Event"})
+	cancelled := saveEvent(t, Event{Title: "Cancelled Event"})
+	saveEvent(t, Event{Title: "Unbooked Event"})
+	for _, event := range []Event{past, upcoming, cancelled} {
+		_, err := event.Register(ctx, Registration{Name: "Alice", Email: "alice@example.com"})
+		if err != nil {
+			t.Fatalf("Failed to register: %v", err)
+		}
+	}
+	_, err := upcoming.Register(ctx, Registration{Name: "Bob", Email: "bob@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	err = past.Patch(ctx, map[string]any{"DateTime": time.Now().Add(-48 * time.Hour)})
+	if err != nil {
+		t.Fatalf("Failed to move event: %v", err)
+	}
+	err = upcoming.Patch(ctx, map[string]any{"DateTime": time.Now().Add(48 * time.Hour)})
+	if err != nil {
+		t.Fatalf("Failed to move event: %v", err)
+	}
+	_, err = cancelled.Cancel(ctx)
+	if err != nil {
+		t.Fatalf("Failed to cancel event: %v", err)
+	}
+
+	bookings, err := GetRegistrationsByEmail(ctx, "Alice@Example.com", BookingFilter{})
+	if err != nil {
+		t.Fatalf("Failed to get bookings: %v", err)
+	}
+	if len(bookings) != 3 || bookings[0].Event.ID != past.ID || bookings[2].Event.ID != upcoming.ID {
+		t.Fatalf("Expected Alice's 3 bookings in date order, got %+v", bookings)
+	}
+	for _, b := range bookings {
+		if b.Registration.EventID != b.Event.ID || b.Registration.Email != "alice@example.com" {
+			t.Errorf("Expected Alice's registration for %q, got %+v", b.Event.Title, b.Registration)
+		}
+	}
+	if !bookings[0].Ended(time.Now()) || bookings[2].Ended(time.Now()) {
+		t.Errorf("Expected only the past event to have ended")
+	}
+
+	bookings, err = GetRegistrationsByEmail(ctx, "alice@example.com", BookingFilter{Status: StatusCancelled})
+	if err != nil || len(bookings) != 1 || bookings[0].Event.ID != cancelled.ID {
+		t.Errorf("Expected only the cancelled event, got %+v (%v)", bookings, err)
+	}
+	bookings, err = GetRegistrationsByEmail(ctx, "alice@example.com", BookingFilter{From: time.Now().Add(24 * time.Hour)})
+	if err != nil || len(bookings) != 1 || bookings[0].Event.ID != upcoming.ID {
+		t.Errorf("Expected only the upcoming event, got %+v (%v)", bookings, err)
+	}
+}
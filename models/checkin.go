@@ -0,0 +1,72 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"event_booking_restapi_golang/db"
+	"time"
+)
+
+// Errors returned by CheckIn.
+var (
+	ErrTicketWrongEvent = errors.New("ticket is for another event")
+	ErrAlreadyCheckedIn = duplicateError("attendee has already been checked in")
+	ErrTicketRevoked    = errors.New("ticket is no longer valid, its registration was cancelled")
+)
+
+// CheckIn admits the holder of the ticket code to the event, recording the time on the
+// registration. Each registration is checked in exactly once, even when the same
+// ticket is scanned at two doors at the same time.
+// Returns the checked in registration. Returns ErrTicketInvalid for forged codes,
+// ErrTicketRevoked when the registration was cancelled, ErrTicketWrongEvent for
+// tickets of other events, ErrEventCancelled when the event has been cancelled and
+// ErrAlreadyCheckedIn, together with the registration and its check-in time, when the
+// ticket has been used before.
+func (e Event) CheckIn(ctx context.Context, code string) (Registration, error) {
+	ticket, err := GetTicketByCode(ctx, code)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Registration{}, ErrTicketRevoked
+	}
+	if err != nil {
+		return Registration{}, err
+	}
+	if ticket.EventID != e.ID {
+		return Registration{}, ErrTicketWrongEvent
+	}
+	if e.Status == StatusCancelled {
+		return Registration{}, ErrEventCancelled
+	}
+
+	q := "UPDATE registrations SET checked_in_at=? WHERE id=? AND checked_in_at IS NULL"
+	result, err := db.Conn(ctx).ExecContext(ctx, db.Rebind(q), time.Now(), ticket.RegistrationID)
+	if err != nil {
+		return Registration{}, err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return Registration{}, err
+	}
+	registration, err := GetRegistrationById(ctx, ticket.RegistrationID)
+	if err != nil {
+		return Registration{}, err
+	}
+	if updated == 0 {
+		return registration, ErrAlreadyCheckedIn
+	}
+	return registration, nil
+}
+
+// Attendance is how many attendees booked an event and how many of them arrived.
+type Attendance struct {
+	Registered int // Registrations held for the event
+	CheckedIn  int // Registrations checked in at the door
+}
+
+// GetAttendance counts the event's registrations and check-ins.
+func (e Event) GetAttendance(ctx context.Context) (Attendance, error) {
+	var a Attendance
+	q := "SELECT COUNT(*), COUNT(checked_in_at) FROM registrations WHERE event_id=?"
+	err := db.Conn(ctx).QueryRowContext(ctx, db.Rebind(q), e.ID).Scan(&a.Registered, &a.CheckedIn)
+	return a, err
+}
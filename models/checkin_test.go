@@ -0,0 +1,99 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// TestEvent_CheckIn tests checking in attendees once and rejecting other events' tickets
+func TestEvent_CheckIn(t *testing.T) {
+	setupTestDatabase(t)
+	event := saveEvent(t, Event{Title: "Concert"})
+	other := saveEvent(t, Event{Title: "Other Concert"})
+
+	registration, err := event.Register(context.Background(), Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	code := registration.Ticket.Code
+
+	_, err = other.CheckIn(context.Background(), code)
+	if !errors.Is(err, ErrTicketWrongEvent) {
+		t.Errorf("Expected ErrTicketWrongEvent, got %v", err)
+	}
+	_, err = event.CheckIn(context.Background(), code+"x")
+	if !errors.Is(err, ErrTicketInvalid) {
+		t.Errorf("Expected ErrTicketInvalid for a forged code, got %v", err)
+	}
+
+	// Two doors scanning the same ticket at once admit the attendee only once
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	admitted, refused := 0, 0
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkedIn, err := event.CheckIn(context.Background(), code)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil && checkedIn.CheckedInAt != nil:
+				admitted++
+			case errors.Is(err, ErrAlreadyCheckedIn) && checkedIn.CheckedInAt != nil:
+				refused++
+			default:
+				t.Errorf("Unexpected check-in result %+v (%v)", checkedIn, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if admitted != 1 || refused != 1 {
+		t.Errorf("Expected one check-in and one refusal, got %d and %d", admitted, refused)
+	}
+
+	stored, err := GetRegistrationById(context.Background(), registration.ID)
+	if err != nil || stored.CheckedInAt == nil {
+		t.Errorf("Expected the check-in to be stored, got %+v (%v)", stored, err)
+	}
+
+	_, err = event.Register(context.Background(), Registration{Name: "Bob", Email: "bob@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	attendance, err := event.GetAttendance(context.Background())
+	if err != nil || attendance != (Attendance{Registered: 2, CheckedIn: 1}) {
+		t.Errorf("Expected 2 registrations and 1 check-in, got %+v (%v)", attendance, err)
+	}
+}
+
+// TestEvent_CheckInCancelled tests that cancelled registrations and events admit nobody
+func TestEvent_CheckInCancelled(t *testing.T) {
+	setupTestDatabase(t)
+	event := saveEvent(t, Event{Title: "Concert"})
+	alice, err := event.Register(context.Background(), Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	bob, err := event.Register(context.Background(), Registration{Name: "Bob", Email: "bob@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	_, err = alice.Cancel(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to cancel registration: %v", err)
+	}
+	_, err = event.CheckIn(context.Background(), alice.Ticket.Code)
+	if !errors.Is(err, ErrTicketRevoked) {
+		t.Errorf("Expected ErrTicketRevoked, got %v", err)
+	}
+
+	event.Status = StatusCancelled
+	_, err = event.CheckIn(context.Background(), bob.Ticket.Code)
+	if !errors.Is(err, ErrEventCancelled) {
+		t.Errorf("Expected ErrEventCancelled, got %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"context"
+	"event_booking_restapi_golang/db"
+	"event_booking_restapi_golang/ids"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Content policy actions, ordered from least to most severe.
+const (
+	PolicyRedact = "redact" // Replace matching text with asterisks
+	PolicyFlag   = "flag"   // Hold the event for moderator review
+	PolicyBlock  = "block"  // Refuse to save the event
+)
+
+// policySeverity ranks actions so the most severe matching rule wins.
+var policySeverity = map[string]int{
+	PolicyRedact: 1,
+	PolicyFlag:   2,
+	PolicyBlock:  3,
+}
+
+// redactedText replaces content matched by a redact rule.
+const redactedText = "***"
+
+// ContentRule is an admin-managed content policy rule applied to event titles and descriptions.
+type ContentRule struct {
+	ID        string    // Unique identifier for the rule
+	Pattern   string    `binding:"required"` // Keyword, or regular expression when IsRegex is set
+	IsRegex   bool      // Whether Pattern is a regular expression
+	Action    string    `binding:"required,oneof=redact flag block"` // What to do on a match
+	CreatedAt time.Time // Time the rule was created
+}
+
+// compile returns the case-insensitive regular expression the rule matches with.
+// Keywords match whole words only.
+func (r ContentRule) compile() (*regexp.Regexp, error) {
+	if r.IsRegex {
+		return regexp.Compile("(?i)" + r.Pattern)
+	}
+	return regexp.Compile(`(?i)\b` + regexp.QuoteMeta(r.Pattern) + `\b`)
+}
+
+// Save validates the rule's pattern and persists it to the database.
+// Returns the stored rule with its generated ID.
+func (r ContentRule) Save(ctx context.Context) (ContentRule, error) {
+	_, err := r.compile()
+	if err != nil {
+		return ContentRule{}, fmt.Errorf("invalid rule pattern: %w", err)
+	}
+
+	r.ID = ids.New()
+	r.CreatedAt = time.Now()
+	q := "INSERT INTO content_rules (id, pattern, is_regex, action, created_at) VALUES (?,?,?,?,?)"
+	_, err = db.Conn(ctx).ExecContext(ctx, db.Rebind(q), r.ID, r.Pattern, r.IsRegex, r.Action, r.CreatedAt)
+	if err != nil {
+		return ContentRule{}, err
+	}
+	return r, nil
+}
+
+// Delete removes the rule from the database.
+// Returns a NotFoundError if no rule with the ID exists, or an error if the operation fails.
+func (r ContentRule) Delete(ctx context.Context) error {
+	result, err := db.Conn(ctx).ExecContext(ctx, db.Rebind("DELETE FROM content_rules WHERE id=?"), r.ID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return NotFoundError{Message: fmt.Sprint("Couldn't find a content rule with the ID of ", r.ID)}
+	}
+	return nil
+}
+
+// GetAllContentRules retrieves every content policy rule, oldest first.
+// Returns a slice of ContentRule objects and any error encountered during the query.
+func GetAllContentRules(ctx context.Context) ([]ContentRule, error) {
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind("SELECT id, pattern, is_regex, action, created_at FROM content_rules ORDER BY created_at, id"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []ContentRule
+	for rows.Next() {
+		var r ContentRule
+		err = rows.Scan(&r.ID, &r.Pattern, &r.IsRegex, &r.Action, &r.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// ScreenResult describes the outcome of running an event through the content policy.
+type ScreenResult struct {
+	Action  string   // Most severe action among matching rules, empty when nothing matched
+	RuleIDs []string // IDs of all matching rules
+}
+
+// FlaggedReason is recorded on events held for review by the content policy.
+const FlaggedReason = "flagged by content policy"
+
+// ScreenEventContent checks the event's title and description against all content rules.
+// Text matched by redact rules is replaced in place; flag and block outcomes are
+// reported for the caller to act on.
+func ScreenEventContent(ctx context.Context, e *Event) (ScreenResult, error) {
+	rules, err := GetAllContentRules(ctx)
+	if err != nil {
+		return ScreenResult{}, err
+	}
+
+	var result ScreenResult
+	for _, rule := range rules {
+		re, err := rule.compile()
+		if err != nil {
+			// Patterns are validated on save, so this only happens for rows edited by hand.
+			continue
+		}
+		if !re.MatchString(e.Title) && !re.MatchString(e.Description) {
+			continue
+		}
+
+		result.RuleIDs = append(result.RuleIDs, rule.ID)
+		if policySeverity[rule.Action] > policySeverity[result.Action] {
+			result.Action = rule.Action
+		}
+		if rule.Action == PolicyRedact {
+			e.Title = re.ReplaceAllString(e.Title, redactedText)
+			e.Description = re.ReplaceAllString(e.Description, redactedText)
+		}
+	}
+	return result, nil
+}
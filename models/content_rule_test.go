@@ -0,0 +1,92 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestContentRule_Save tests rule persistence and pattern validation
+func TestContentRule_Save(t *testing.T) {
+	setupTestDatabase(t)
+
+	rule, err := ContentRule{Pattern: "casino", Action: PolicyFlag}.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to save content rule: %v", err)
+	}
+	if rule.ID == "" {
+		t.Error("Expected saved rule to have an ID")
+	}
+
+	_, err = ContentRule{Pattern: "([unclosed", IsRegex: true, Action: PolicyBlock}.Save(context.Background())
+	if err == nil {
+		t.Error("Expected an error for an invalid regular expression")
+	}
+
+	rules, err := GetAllContentRules(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get content rules: %v", err)
+	}
+	if len(rules) != 1 {
+		t.Fatalf("Expected 1 content rule, got %d", len(rules))
+	}
+
+	err = rules[0].Delete(context.Background())
+	if err != nil {
+		t.Errorf("Failed to delete content rule: %v", err)
+	}
+	err = rules[0].Delete(context.Background())
+	if err == nil {
+		t.Error("Expected an error when deleting a missing rule")
+	}
+}
+
+// TestScreenEventContent tests block, flag and redact outcomes of the content policy
+func TestScreenEventContent(t *testing.T) {
+	setupTestDatabase(t)
+
+	for _, rule := range []ContentRule{
+		{Pattern: "darn", Action: PolicyRedact},
+		{Pattern: "free money", Action: PolicyFlag},
+		{Pattern: `\bbuy (followers|likes)\b`, IsRegex: true, Action: PolicyBlock},
+	} {
+		_, err := rule.Save(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to save content rule: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		title       string
+		description string
+		action      string
+		rules       int
+		expected    string
+	}{
+		{"clean", "Book Club", "Monthly meetup", "", 0, "Monthly meetup"},
+		{"redacted", "Book Club", "That darn DARN plot", PolicyRedact, 1, "That *** *** plot"},
+		{"keyword needs whole word", "Book Club", "Darnell hosts", "", 0, "Darnell hosts"},
+		{"flagged", "Free Money Seminar", "Darn good", PolicyFlag, 2, "*** good"},
+		{"blocked", "Growth hacks", "Buy followers here", PolicyBlock, 1, "Buy followers here"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := Event{Title: tt.title, Description: tt.description, Location: "Online", DateTime: time.Now()}
+			result, err := ScreenEventContent(context.Background(), &event)
+			if err != nil {
+				t.Fatalf("Failed to screen content: %v", err)
+			}
+			if result.Action != tt.action {
+				t.Errorf("Expected action %q, got %q", tt.action, result.Action)
+			}
+			if len(result.RuleIDs) != tt.rules {
+				t.Errorf("Expected %d matching rules, got %d", tt.rules, len(result.RuleIDs))
+			}
+			if event.Description != tt.expected {
+				t.Errorf("Expected description %q, got %q", tt.expected, event.Description)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"context"
+	"event_booking_restapi_golang/db"
+)
+
+// dryRunKey is the context key under which WithDryRun marks dry runs.
+type dryRunKey struct{}
+
+// WithDryRun returns a context under which changes to events run in full, checks and
+// all, within their transactions, which are then rolled back instead of committed. The
+// functions report what they would have done, so callers can preview destructive
+// operations. Writes outside of event transactions, such as feed run reports, aren't
+// covered and must be skipped by the caller.
+func WithDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// IsDryRun reports whether the context was marked with WithDryRun.
+func IsDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return dryRun
+}
+
+// commit commits the transaction, or rolls it back under a dry run.
+func commit(ctx context.Context, tx *db.Tx) error {
+	if IsDryRun(ctx) {
+		return tx.Rollback()
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestDryRun tests that deleting, cancelling and importing events under a dry run
+// report their outcome without changing anything
+func TestDryRun(t *testing.T) {
+	setupTestDatabase(t)
+	event := saveEvent(t, Event{Title: "Dry Run Event"})
+	ctx := WithDryRun(context.Background())
+	if !IsDryRun(ctx) || IsDryRun(context.Background()) {
+		t.Fatal("Expected only the marked context to be a dry run")
+	}
+
+	cancelled, err := event.Cancel(ctx)
+	if err != nil || !cancelled {
+		t.Fatalf("Expected the dry run to report the cancellation, got %v (%v)", cancelled, err)
+	}
+	if err := event.Delete(ctx); err != nil {
+		t.Fatalf("Failed to dry-run the deletion: %v", err)
+	}
+	stored, err := GetEventById(context.Background(), event.ID)
+	if err != nil || stored.Status != StatusPublished {
+		t.Errorf("Expected the event to stay published, got %+v (%v)", stored, err)
+	}
+	version, err := GetEventVersionAt(context.Background(), event.ID, time.Now())
+	if err != nil || version.Version != 1 {
+		t.Errorf("Expected no versions to be recorded, got version %d (%v)", version.Version, err)
+	}
+
+	imported, _, created, err := ImportEvent(ctx, Event{Title: "Imported", Description: "Description", Location: "Location", DateTime: time.Now().Add(time.Hour)}, "https://source.example.com", "source-1", nil)
+	if err != nil || !created || imported.ID == "" {
+		t.Fatalf("Expected the dry run to report a new event, got %+v (created %v, %v)", imported, created, err)
+	}
+	if _, err := GetEventById(context.Background(), imported.ID); err == nil {
+		t.Error("Expected the imported event not to be stored")
+	}
+}
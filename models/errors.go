@@ -0,0 +1,84 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Kinds of domain errors. The errors returned by the models match one of them with
+// errors.Is when it applies, so callers can decide how to report an error by its kind
+// and check for the specific error, such as ErrEventFull, only when they need to.
+var (
+	// ErrEventNotFound is matched by the errors of event lookups that found no event.
+	ErrEventNotFound = errors.New("event not found")
+	// ErrDuplicate is matched by errors refusing to record something a second time,
+	// such as a second booking with the same email address.
+	ErrDuplicate = errors.New("already exists")
+	// ErrConflict is matched by errors refusing a change the current state of the
+	// record doesn't allow, such as booking a full event.
+	ErrConflict = errors.New("conflicts with the current state")
+)
+
+// ErrNotPatchable is wrapped by the error of a patch naming a field that can't be patched.
+var ErrNotPatchable = errors.New("can't be patched")
+
+// NotFoundError reports that a requested record doesn't exist.
+// It matches sql.ErrNoRows with errors.Is, so callers can tell it apart from query
+// failures, and its Kind, such as ErrEventNotFound, when it has one.
+type NotFoundError struct {
+	Message string
+	Kind    error
+}
+
+func (e NotFoundError) Error() string {
+	return e.Message
+}
+
+func (e NotFoundError) Unwrap() error {
+	return sql.ErrNoRows
+}
+
+func (e NotFoundError) Is(target error) bool {
+	return e.Kind != nil && target == e.Kind
+}
+
+// VersionConflictError is returned when updating an event that has changed since the
+// version the update was based on. It matches ErrConflict.
+type VersionConflictError struct {
+	Version int // Version the update was based on
+	Current int // Version of the stored event
+}
+
+func (e VersionConflictError) Error() string {
+	return fmt.Sprintf("the event was changed since version %d and is now at version %d; fetch it again and reapply the change", e.Version, e.Current)
+}
+
+func (e VersionConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// domainError is a sentinel error of one of the kinds, which it matches with errors.Is
+// besides itself.
+type domainError struct {
+	message string
+	kind    error
+}
+
+func (e *domainError) Error() string {
+	return e.message
+}
+
+func (e *domainError) Is(target error) bool {
+	return target == e.kind
+}
+
+// duplicateError returns a new sentinel error matching ErrDuplicate.
+func duplicateError(message string) error {
+	return &domainError{message: message, kind: ErrDuplicate}
+}
+
+// conflictError returns a new sentinel error matching ErrConflict.
+func conflictError(message string) error {
+	return &domainError{message: message, kind: ErrConflict}
+}
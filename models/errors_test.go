@@ -0,0 +1,43 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+// TestDomainErrors tests that the domain errors match their kinds with errors.Is, and
+// that lookups of missing records return NotFoundErrors
+func TestDomainErrors(t *testing.T) {
+	for _, err := range []error{ErrAlreadyRegistered, ErrAlreadyWaitlisted, ErrAlreadyCheckedIn, ErrRefundRequested} {
+		if !errors.Is(err, ErrDuplicate) || errors.Is(err, ErrConflict) {
+			t.Errorf("Expected %q to be a duplicate only", err)
+		}
+	}
+	for _, err := range []error{ErrEventFull, ErrEventNotFull, ErrEventCancelled, ErrRefundDecided, VersionConflictError{Version: 1, Current: 2}} {
+		if !errors.Is(err, ErrConflict) || errors.Is(err, ErrDuplicate) {
+			t.Errorf("Expected %q to be a conflict only", err)
+		}
+	}
+	if errors.Is(ErrEventFull, ErrEventCancelled) {
+		t.Error("Expected errors of the same kind to stay apart")
+	}
+
+	setupTestDatabase(t)
+	ctx := context.Background()
+	_, err := GetEventById(ctx, "missing")
+	if !errors.Is(err, ErrEventNotFound) || !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected an event NotFoundError, got %v", err)
+	}
+	lookups := map[string]error{}
+	_, lookups["registration"] = GetRegistrationById(ctx, "missing")
+	_, lookups["attachment"] = GetAttachmentById(ctx, "missing")
+	lookups["content rule"] = ContentRule{ID: "missing"}.Delete(ctx)
+	for name, err := range lookups {
+		var notFound NotFoundError
+		if !errors.As(err, &notFound) || errors.Is(err, ErrEventNotFound) {
+			t.Errorf("Expected a %s NotFoundError that isn't an event's, got %v", name, err)
+		}
+	}
+}
@@ -3,56 +3,237 @@
 package models
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
 	"event_booking_restapi_golang/db"
+	"event_booking_restapi_golang/ids"
+	"event_booking_restapi_golang/textnorm"
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 	"time"
-
-	"github.com/google/uuid"
 )
 
 // Event represents an event in the system with all its properties.
 // It includes basic event information like title, description, location,
 // as well as metadata like ID, date/time, and user ID.
 type Event struct {
-	ID          string    // Unique identifier for the event
-	Title       string    `binding:"required"` // Event title (required)
-	Description string    `binding:"required"` // Event description (required)
-	Location    string    `binding:"required"` // Event location (required)
-	DateTime    time.Time `binding:"required"` // Event date and time (required)
-	UserID      string    // ID of the user who created the event
+	ID           string     `json:"id"`                             // Unique identifier for the event
+	Title        string     `json:"title" binding:"required"`       // Event title (required)
+	Description  string     `json:"description" binding:"required"` // Event description (required)
+	Location     string     `json:"location" binding:"required"`    // Event location (required)
+	DateTime     time.Time  `json:"date_time" binding:"required"`   // Event date and time (required)
+	EndDateTime  *time.Time `json:"end_date_time"`                  // When the event ends, nil for events without a set end
+	UserID       string     `json:"user_id"`                        // ID of the user who created the event
+	Capacity     int        `json:"capacity" binding:"min=0"`       // Maximum number of registrations, 0 means unlimited
+	ReviewStatus string     `json:"review_status"`                  // Moderation state: pending_review, approved or rejected
+	ReviewReason string     `json:"review_reason"`                  // Why the event was rejected or held for review
+	CreatedAt    time.Time  `json:"created_at"`                     // Time the event was created
+
+	Status string `json:"status" binding:"omitempty,oneof=draft published cancelled"` // Lifecycle state: draft, published or cancelled
+	Slug   string `json:"slug"`                                                       // URL-friendly form of the title, set when the title is saved
+
+	ConfirmationURL     string `json:"confirmation_url"`     // Organizer page attendees are sent to after booking, empty for none
+	ConfirmationMessage string `json:"confirmation_message"` // Plain-text message shown to attendees after booking
+
+	License string `json:"license"` // Terms others may republish the event's details under, an SPDX identifier such as CC-BY-4.0 or a URL; empty reserves all rights
+
+	ExternalID string `json:"external_id"` // ID of the event in the external system it is synced from, empty for none
+	Source     string `json:"source"`      // External system the event is synced from; external IDs are unique per source
+
+	Version int `json:"version"` // Number of the event's latest change, from 1; updates sending it are refused once the event has changed
+
+	SeriesID string `json:"series_id" binding:"max=100"` // Groups the occurrences of a recurring event of the same organizer, chosen by the organizer; empty for one-off events
+}
+
+// UnmarshalJSON decodes an event from its snake_case JSON form. For one release it also
+// accepts the field names used before the JSON tags were added (Go field names such as
+// "DateTime" or "confirmationUrl", in any case). When both forms of a field are sent the
+// snake_case one wins.
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+	fields := make(map[string]json.RawMessage, len(raw))
+	for key, value := range raw {
+		name, ok := eventJSONNames[strings.ToLower(strings.ReplaceAll(key, "_", ""))]
+		if !ok {
+			continue
+		}
+		if _, set := fields[name]; set && key != name {
+			continue
+		}
+		fields[name] = value
+	}
+	data, err = json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+
+	type plainEvent Event
+	return json.Unmarshal(data, (*plainEvent)(e))
+}
+
+// eventJSONNames maps event JSON field names, lowercased and without underscores, to their
+// snake_case form. The keys also match the legacy Go-cased names.
+var eventJSONNames = map[string]string{}
+
+func init() {
+	t := reflect.TypeOf(Event{})
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Tag.Get("json")
+		eventJSONNames[strings.ReplaceAll(name, "_", "")] = name
+	}
+}
+
+// Moderation states of an event. Only approved events are listed publicly.
+const (
+	ReviewPending  = "pending_review"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+)
+
+// Lifecycle states of an event. Drafts are only visible to their organizer, and
+// cancelled events stay listed but take no more bookings.
+const (
+	StatusDraft     = "draft"
+	StatusPublished = "published"
+	StatusCancelled = "cancelled"
+)
+
+// ErrEventCancelled is returned when booking an event that has been cancelled.
+var ErrEventCancelled = conflictError("the event has been cancelled")
+
+// versionedEventColumns lists the events columns copied into event_versions, in the
+// order of eventColumns.
+const versionedEventColumns = "name, description, location, datetime, user_id, capacity, review_status, review_reason, created_at, confirmation_url, confirmation_message, external_id, external_source, end_datetime, status, slug, license, series_id"
+
+// eventColumns lists the events table columns in the order scanEvent expects them.
+// The event's version comes last, as event_versions reads have their own there.
+const eventColumns = "id, " + versionedEventColumns + ", version"
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
 }
 
-// events is a slice used to store events in memory (currently unused in database operations)
-var events = []Event{}
+// scanEvent reads a single events row selected with eventColumns into an Event.
+func scanEvent(row rowScanner) (Event, error) {
+	var event Event
+	err := row.Scan(&event.ID, &event.Title, &event.Description, &event.Location, &event.DateTime, &event.UserID, &event.Capacity, &event.ReviewStatus, &event.ReviewReason, &event.CreatedAt, &event.ConfirmationURL, &event.ConfirmationMessage, &event.ExternalID, &event.Source, &event.EndDateTime, &event.Status, &event.Slug, &event.License, &event.SeriesID, &event.Version)
+	return event, err
+}
 
 // Save persists the Event to the database.
-// It generates a new UUID for the event and inserts it into the events table.
-// Returns an error if the database operation fails.
-func (e Event) Save() error {
+// It inserts the event into the events table under its ID, generating one when it has none.
+// Events without a review status are stored as approved, events without a status
+// as published, and events without a creation time are stamped with the current time.
+// The slug is derived from the title. Published events complete their organizer's
+// OnboardingFirstEvent step.
+// Returns the event as stored, a QuotaError when a free user already has
+// FreeEventLimit active events, a VenueUnavailableError when the event falls on a
+// blackout date of its venue, or an error if the database operation fails.
+func (e Event) Save(ctx context.Context) (Event, error) {
+	if e.ID == "" {
+		e.ID = ids.New()
+	}
+	if e.ReviewStatus == "" {
+		e.ReviewStatus = ReviewApproved
+	}
+	if e.Status == "" {
+		e.Status = StatusPublished
+	}
+	e.Slug = textnorm.Slug(e.Title)
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
 	q := `
-	INSERT INTO events (id, name,description,datetime,user_id,location)
-	VALUES (?,?,?,?,?,?)
+	INSERT INTO events (id, name,description,datetime,user_id,location,capacity,review_status,created_at,confirmation_url,confirmation_message,external_id,external_source,end_datetime,status,slug,license,series_id)
+	VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)
 	`
-	stmt, err := db.DB.Prepare(q)
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return Event{}, err
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
 
-	_, err = stmt.Exec(uuid.NewString(), e.Title, e.Description, e.DateTime, e.UserID, e.Location)
+	if eventQuotaApplies(e) {
+		active, err := countActiveEvents(ctx, tx, e.UserID, time.Now())
+		if err != nil {
+			return Event{}, err
+		}
+		if active >= FreeEventLimit {
+			return Event{}, QuotaError{Usage: active, Limit: FreeEventLimit}
+		}
+	}
+	if e.Status != StatusCancelled {
+		err = checkVenueAvailable(ctx, tx, e.Location, e.DateTime, e.EndDateTime)
+		if err != nil {
+			return Event{}, err
+		}
+	}
+	_, err = tx.ExecContext(ctx, db.Rebind(q), e.ID, e.Title, e.Description, e.DateTime, e.UserID, e.Location, e.Capacity, e.ReviewStatus, e.CreatedAt, e.ConfirmationURL, e.ConfirmationMessage, e.ExternalID, e.Source, e.EndDateTime, e.Status, e.Slug, e.License, e.SeriesID)
 	if err != nil {
-		return err
+		return Event{}, err
+	}
+	err = completeFirstEventStep(ctx, tx, e.ID)
+	if err != nil {
+		return Event{}, err
 	}
+	e.Version, err = recordEventVersion(ctx, tx, e.ID, false)
+	if err != nil {
+		return Event{}, err
+	}
+	err = commit(ctx, tx)
+	if err != nil {
+		return Event{}, err
+	}
+	return e, nil
+}
 
-	return nil
+// SaveEvents saves the events as by Save, in one transaction: either every event is
+// stored or none is. An event that can't be saved, such as one over the free tier quota
+// or on a venue blackout, doesn't stop the others from being tried, so that all the
+// failures can be reported at once.
+// Returns the saved events and the error of each event at its index, nil for the events
+// that could be saved; when any event has an error, nothing is stored.
+func SaveEvents(ctx context.Context, events []Event) ([]Event, []error, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+	// Each Save runs in a savepoint of tx, so a failed event leaves the others in place
+	txCtx, _ := db.WithTx(ctx, tx.Tx)
+
+	saved := make([]Event, len(events))
+	errs := make([]error, len(events))
+	failed := false
+	for i, e := range events {
+		saved[i], errs[i] = e.Save(txCtx)
+		failed = failed || errs[i] != nil
+	}
+	if failed {
+		return nil, errs, nil
+	}
+	err = commit(ctx, tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return saved, errs, nil
 }
 
 // GetAllEvents retrieves all events from the database.
+// Each call builds a new slice, so results never carry over between calls.
 // Returns a slice of Event objects and any error encountered during the query.
-func GetAllEvents() ([]Event, error) {
-	q := `SELECT * FROM events`
-	rows, err := db.DB.Query(q)
+func GetAllEvents(ctx context.Context) ([]Event, error) {
+	q := "SELECT " + eventColumns + " FROM events"
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q))
 	if err != nil {
 		return nil, err
 	}
@@ -60,8 +241,7 @@ func GetAllEvents() ([]Event, error) {
 
 	var retrievedEvents []Event
 	for rows.Next() {
-		var event Event
-		err = rows.Scan(&event.ID, &event.Title, &event.Description, &event.Location, &event.DateTime, &event.UserID)
+		event, err := scanEvent(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -71,70 +251,250 @@ func GetAllEvents() ([]Event, error) {
 }
 
 // GetEventById retrieves a single event from the database by its ID.
-// Returns the Event object if found, otherwise returns an empty Event and an error.
-func GetEventById(id string) (Event, error) {
-	q := "SELECT * FROM events where id=?"
-	row := db.DB.QueryRow(q, id)
-	var event Event
-
-	err := row.Scan(&event.ID, &event.Title, &event.Description, &event.Location, &event.DateTime, &event.UserID)
+// Returns the Event object if found, otherwise returns an empty Event and an error,
+// which is a NotFoundError when no event has the ID.
+func GetEventById(ctx context.Context, id string) (Event, error) {
+	q := "SELECT " + eventColumns + " FROM events where id=?"
+	row := db.Conn(ctx).QueryRowContext(ctx, db.Rebind(q), id)
+	event, err := scanEvent(row)
 
+	if errors.Is(err, sql.ErrNoRows) {
+		return Event{}, NotFoundError{Message: fmt.Sprint("Couldn't find an event with the ID of ", id), Kind: ErrEventNotFound}
+	}
 	if err != nil {
-		return Event{}, errors.New(fmt.Sprint("Couldn't find an event with the ID of", id))
+		return Event{}, err
 	}
 
 	if event.ID == "" {
-		return Event{}, errors.New(fmt.Sprint("Couldn't find an event with the ID of", id))
+		return Event{}, NotFoundError{Message: fmt.Sprint("Couldn't find an event with the ID of ", id), Kind: ErrEventNotFound}
 	}
 
 	return event, nil
 }
 
-// Update updates an existing event in the database.
-// Returns an error if the database operation fails.
-func (e Event) Update() error {
+// Update updates an existing event in the database, deriving its slug from the title.
+// Publishing the event completes its organizer's OnboardingFirstEvent step.
+// Events with a Version are only updated while it is still the stored one, so that an
+// edit based on an outdated read doesn't overwrite the changes made since; a zero
+// Version updates unconditionally.
+// Returns a VersionConflictError when the event has changed since Version, a
+// VenueUnavailableError when the event is moved onto a blackout date of its venue, or
+// an error if the database operation fails.
+func (e Event) Update(ctx context.Context) error {
 	q := `
 	UPDATE events
-	SET name=?,description=?,datetime=?,location=?
+	SET name=?,description=?,datetime=?,end_datetime=?,location=?,capacity=?,confirmation_url=?,confirmation_message=?,status=?,slug=?,license=?,series_id=?
 	WHERE id=?
 	`
-	stmt, err := db.DB.Prepare(q)
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
 
-	_, err = stmt.Exec(e.Title, e.Description, e.DateTime, e.Location, e.ID)
+	var old Event
+	err = tx.QueryRowContext(ctx, db.Rebind("SELECT location, datetime, end_datetime, version FROM events WHERE id=?"+db.ForUpdate()), e.ID).
+		Scan(&old.Location, &old.DateTime, &old.EndDateTime, &old.Version)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	found := err == nil
+	if found && e.Version != 0 && e.Version != old.Version {
+		return VersionConflictError{Version: e.Version, Current: old.Version}
+	}
+	if found && e.Status != StatusCancelled && rescheduled(old, e) {
+		err = checkVenueAvailable(ctx, tx, e.Location, e.DateTime, e.EndDateTime)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.ExecContext(ctx, db.Rebind(q), e.Title, e.Description, e.DateTime, e.EndDateTime, e.Location, e.Capacity, e.ConfirmationURL, e.ConfirmationMessage, e.Status, textnorm.Slug(e.Title), e.License, e.SeriesID, e.ID)
+	if err != nil {
+		return err
+	}
+	err = completeFirstEventStep(ctx, tx, e.ID)
+	if err != nil {
+		return err
+	}
+	_, err = recordEventVersion(ctx, tx, e.ID, false)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return commit(ctx, tx)
 }
 
-// Delete removes an event from the database by its ID.
+// PatchableEventFields maps the Event fields that can be changed with Patch to their columns.
+var PatchableEventFields = map[string]string{
+	"Title":               "name",
+	"Description":         "description",
+	"Location":            "location",
+	"DateTime":            "datetime",
+	"EndDateTime":         "end_datetime",
+	"Capacity":            "capacity",
+	"ConfirmationURL":     "confirmation_url",
+	"ConfirmationMessage": "confirmation_message",
+	"Status":              "status",
+	"License":             "license",
+	"SeriesID":            "series_id",
+}
+
+// Patch updates only the given fields of an existing event. Fields are keyed by their
+// Event field name, as listed in PatchableEventFields; patching no fields does nothing.
+// A Version entry isn't a field to change: as for Update, the event is then only patched
+// while it is still at that version, checked in the same transaction.
+// Publishing the event completes its organizer's OnboardingFirstEvent step.
+// Returns a VersionConflictError when the event has changed since the Version entry, a
+// VenueUnavailableError when the patch moves the event onto a blackout date of its
+// venue, or an error for fields that can't be patched or if the database operation fails.
+func (e Event) Patch(ctx context.Context, fields map[string]any) error {
+	version, _ := fields["Version"].(int)
+	if len(fields) == 0 || (version != 0 && len(fields) == 1) {
+		return nil
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		if name == "Version" {
+			continue
+		}
+		if _, ok := PatchableEventFields[name]; !ok {
+			return fmt.Errorf("field %s %w", name, ErrNotPatchable)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	assignments := make([]string, len(names))
+	args := make([]any, 0, len(names)+1)
+	for i, name := range names {
+		assignments[i] = PatchableEventFields[name] + "=?"
+		args = append(args, fields[name])
+	}
+	if title, ok := fields["Title"].(string); ok {
+		assignments = append(assignments, "slug=?")
+		args = append(args, textnorm.Slug(title))
+	}
+	args = append(args, e.ID)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if version != 0 {
+		var current int
+		err = tx.QueryRowContext(ctx, db.Rebind("SELECT version FROM events WHERE id=?"+db.ForUpdate()), e.ID).Scan(&current)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if err == nil && current != version {
+			return VersionConflictError{Version: version, Current: current}
+		}
+	}
+	q := "UPDATE events SET " + strings.Join(assignments, ", ") + " WHERE id=?"
+	_, err = tx.ExecContext(ctx, db.Rebind(q), args...)
+	if err != nil {
+		return err
+	}
+	if reschedules(fields) {
+		err = checkPatchedVenue(ctx, tx, e.ID)
+		if err != nil {
+			return err
+		}
+	}
+	if _, ok := fields["Status"]; ok {
+		err = completeFirstEventStep(ctx, tx, e.ID)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = recordEventVersion(ctx, tx, e.ID, false)
+	if err != nil {
+		return err
+	}
+	return commit(ctx, tx)
+}
+
+// Delete removes an event from the database by its ID. Its history is kept, ending
+// with a version that records the deletion.
 // Returns an error if the database operation fails.
-func (e Event) Delete() error {
-	q := "DELETE FROM events WHERE id=?"
-	stmt, err := db.DB.Prepare(q)
+func (e Event) Delete(ctx context.Context) error {
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
 
-	_, err = stmt.Exec(e.ID)
+	_, err = recordEventVersion(ctx, tx, e.ID, true)
 	if err != nil {
 		return err
 	}
+	_, err = tx.ExecContext(ctx, db.Rebind("DELETE FROM events WHERE id=?"), e.ID)
+	if err != nil {
+		return err
+	}
+	// The cancellations and moves only serve the event's statistics
+	for _, table := range []string{"registration_cancellations", "registration_moves"} {
+		_, err = tx.ExecContext(ctx, db.Rebind("DELETE FROM "+table+" WHERE event_id=?"), e.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return commit(ctx, tx)
+}
+
+// UserEventFilter narrows down the events returned by GetEventsByUserId.
+// Zero values leave the corresponding criterion unrestricted.
+type UserEventFilter struct {
+	Status       string // Only events in this lifecycle state
+	ReviewStatus string // Only events in this moderation state
+}
+
+// GetEventsByUserId retrieves a page of the events created by a specific user ID,
+// including drafts and events held for review, in date order.
+// Returns the events and the total number of the user's events matching the filter.
+func GetEventsByUserId(ctx context.Context, userId string, filter UserEventFilter, limit, offset int) ([]Event, int, error) {
+	where := " WHERE user_id=?"
+	args := []any{userId}
+	if filter.Status != "" {
+		where += " AND status=?"
+		args = append(args, filter.Status)
+	}
+	if filter.ReviewStatus != "" {
+		where += " AND review_status=?"
+		args = append(args, filter.ReviewStatus)
+	}
+
+	var total int
+	err := db.Conn(ctx).QueryRowContext(ctx, db.Rebind("SELECT COUNT(*) FROM events"+where), args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+	q := "SELECT " + eventColumns + " FROM events" + where + " ORDER BY " + db.Timestamp("datetime") + ", id LIMIT ? OFFSET ?"
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q), append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
 
-	return nil
+	events := []Event{}
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		events = append(events, event)
+	}
+	return events, total, rows.Err()
 }
 
-// GetEventsByUserId retrieves all events associated with a specific user ID.
+// GetEventsByReviewStatus retrieves all events in the given moderation state, oldest first.
 // Returns a slice of Event objects and any error encountered during the query.
-func GetEventsByUserId(userId string) ([]Event, error) {
-	q := "SELECT * FROM events WHERE user_id=?"
-	rows, err := db.DB.Query(q, userId)
+func GetEventsByReviewStatus(ctx context.Context, status string) ([]Event, error) {
+	q := "SELECT " + eventColumns + " FROM events WHERE review_status=? ORDER BY created_at, id"
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q), status)
 	if err != nil {
 		return nil, err
 	}
@@ -142,8 +502,7 @@ func GetEventsByUserId(userId string) ([]Event, error) {
 
 	var events []Event
 	for rows.Next() {
-		var event Event
-		err = rows.Scan(&event.ID, &event.Title, &event.Description, &event.Location, &event.DateTime, &event.UserID)
+		event, err := scanEvent(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -152,3 +511,51 @@ func GetEventsByUserId(userId string) ([]Event, error) {
 
 	return events, nil
 }
+
+// SetReviewStatus records a moderation decision for the event.
+// Returns an error if the database operation fails.
+func (e Event) SetReviewStatus(ctx context.Context, status, reason string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := "UPDATE events SET review_status=?, review_reason=? WHERE id=?"
+	_, err = tx.ExecContext(ctx, db.Rebind(q), status, reason, e.ID)
+	if err != nil {
+		return err
+	}
+	_, err = recordEventVersion(ctx, tx, e.ID, false)
+	if err != nil {
+		return err
+	}
+
+	return commit(ctx, tx)
+}
+
+// Cancel marks the event cancelled, so that it takes no more bookings. The event stays
+// listed with its registrations. Cancelling an event that is already cancelled does
+// nothing, so concurrent cancellations are told apart.
+// Returns whether this call cancelled the event, or an error if the database operation fails.
+func (e Event) Cancel(ctx context.Context) (bool, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, db.Rebind("UPDATE events SET status=? WHERE id=? AND status<>?"), StatusCancelled, e.ID, StatusCancelled)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil || affected == 0 {
+		return false, err
+	}
+	_, err = recordEventVersion(ctx, tx, e.ID, false)
+	if err != nil {
+		return false, err
+	}
+	return true, commit(ctx, tx)
+}
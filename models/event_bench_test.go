@@ -0,0 +1,185 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"event_booking_restapi_golang/db"
+	"event_booking_restapi_golang/textnorm"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// benchSizes are the numbers of events in the benchmark datasets. The largest one is
+// skipped with -short.
+var benchSizes = []int{1_000, 100_000, 1_000_000}
+
+// benchDir holds the benchmark datasets, built once per run and removed by TestMain
+var benchDir string
+
+// benchDatasets caches the opened benchmark databases by size
+var benchDatasets = map[int]*sql.DB{}
+
+// Words the dataset titles and locations are made of. "workshop" is in one title out
+// of len(benchTopics), which the search benchmark looks for.
+var (
+	benchAdjectives = []string{"Monthly", "Annual", "Open", "Community", "Evening", "Weekend", "Intro", "Advanced"}
+	benchTopics     = []string{"Meetup", "Workshop", "Concert", "Conference", "Hackathon", "Tasting", "Screening", "Lecture", "Market", "Run"}
+	benchCities     = []string{"Berlin", "Cairo", "Lisbon", "Nairobi", "Osaka", "Toronto", "Lima", "Oslo"}
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	for _, conn := range benchDatasets {
+		conn.Close()
+	}
+	if benchDir != "" {
+		os.RemoveAll(benchDir)
+	}
+	os.Exit(code)
+}
+
+// formatSize names a dataset size in benchmark names, as 1k or 1M
+func formatSize(n int) string {
+	switch {
+	case n >= 1_000_000 && n%1_000_000 == 0:
+		return strconv.Itoa(n/1_000_000) + "M"
+	case n >= 1_000 && n%1_000 == 0:
+		return strconv.Itoa(n/1_000) + "k"
+	}
+	return strconv.Itoa(n)
+}
+
+// benchDataset makes a database with the application schema and n events the
+// current one for the rest of the benchmark, building it on first use. The events are the same on every
+// run: a few hundred organizers, times spread over two years around 2026-01-01, and
+// one in ten in draft or pending review, like a real listing.
+func benchDataset(b *testing.B, n int) {
+	b.Helper()
+	if n >= 1_000_000 && testing.Short() {
+		b.Skip("Skipping the 1M-row dataset in short mode")
+	}
+	originalDB, originalDriver := db.DB, db.Driver
+	b.Cleanup(func() { db.DB, db.Driver = originalDB, originalDriver })
+
+	if conn, ok := benchDatasets[n]; ok {
+		db.DB, db.Driver = conn, db.SQLite
+		return
+	}
+	var err error
+	if benchDir == "" {
+		benchDir, err = os.MkdirTemp("", "event-bench-")
+		if err != nil {
+			b.Fatalf("Failed to create the dataset directory: %v", err)
+		}
+	}
+	// The DSN of the default configuration, so that locking behaves as in production
+	dsn := filepath.Join(benchDir, "events-"+formatSize(n)+".sql") + "?_txlock=immediate&_busy_timeout=5000"
+	if err := db.InitDB(db.SQLite, dsn); err != nil {
+		b.Fatalf("Failed to set up the dataset: %v", err)
+	}
+	benchDatasets[n] = db.DB
+
+	start := time.Now()
+	tx, err := db.DB.Begin()
+	if err != nil {
+		b.Fatalf("Failed to begin: %v", err)
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(`INSERT INTO events (id, name, description, location, datetime, user_id, capacity, review_status, created_at, status, slug)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		b.Fatalf("Failed to prepare: %v", err)
+	}
+	defer stmt.Close()
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range n {
+		title := fmt.Sprintf("%s %s %d", benchAdjectives[i%len(benchAdjectives)], benchTopics[i%len(benchTopics)], i)
+		reviewStatus, status := ReviewApproved, StatusPublished
+		switch i % 20 {
+		case 7:
+			status = StatusDraft
+		case 13:
+			reviewStatus = ReviewPending
+		}
+		at := base.Add(time.Duration(i*7919%(2*365*24)) * time.Hour)
+		_, err = stmt.Exec(fmt.Sprintf("00000000-0000-7000-8000-%012d", i), title, "An event of the benchmark dataset",
+			benchCities[i%len(benchCities)]+" Hall", at, "user-"+strconv.Itoa(i%500), i%200, reviewStatus,
+			at.Add(-30*24*time.Hour), status, textnorm.Slug(title))
+		if err != nil {
+			b.Fatalf("Failed to insert event %d: %v", i, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		b.Fatalf("Failed to commit the dataset: %v", err)
+	}
+	if _, err := db.DB.Exec("ANALYZE"); err != nil {
+		b.Fatalf("Failed to analyze the dataset: %v", err)
+	}
+	b.Logf("Built the %s dataset in %v", formatSize(n), time.Since(start).Round(time.Millisecond))
+}
+
+// BenchmarkGetAllEvents measures loading every event, which the unpaginated
+// listings do. The dataset only grows, through BenchmarkEventSave.
+func BenchmarkGetAllEvents(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(formatSize(n), func(b *testing.B) {
+			benchDataset(b, n)
+			ctx := context.Background()
+			b.ReportAllocs()
+			for b.Loop() {
+				events, err := GetAllEvents(ctx)
+				if err != nil || len(events) < n {
+					b.Fatalf("Expected at least %d events, got %d (%v)", n, len(events), err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkEventSave measures creating an event as the API does, with the quota and
+// venue checks and the first version. Each iteration adds an event to the dataset.
+func BenchmarkEventSave(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(formatSize(n), func(b *testing.B) {
+			benchDataset(b, n)
+			ctx := context.Background()
+			at := time.Date(2026, 6, 1, 19, 0, 0, 0, time.UTC)
+			b.ReportAllocs()
+			for b.Loop() {
+				_, err := Event{
+					Title: "Monthly Meetup", Description: "Talks and pizza", Location: "Berlin Hall",
+					DateTime: at, UserID: "user-1", Capacity: 50,
+				}.Save(ctx)
+				if err != nil {
+					b.Fatalf("Failed to save: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkSearchEvents measures a search page as GET /events serves it: the first
+// 20 upcoming events with a title word, and the count of all of them
+func BenchmarkSearchEvents(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(formatSize(n), func(b *testing.B) {
+			benchDataset(b, n)
+			ctx := context.Background()
+			filter := EventFilter{Search: "workshop", From: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Limit: 20}
+			b.ReportAllocs()
+			for b.Loop() {
+				events, err := QueryEvents(ctx, filter)
+				if err != nil || len(events) == 0 {
+					b.Fatalf("Expected matching events, got %d (%v)", len(events), err)
+				}
+				if _, err := CountEvents(ctx, filter); err != nil {
+					b.Fatalf("Failed to count: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"event_booking_restapi_golang/db"
+	"event_booking_restapi_golang/ids"
+	"event_booking_restapi_golang/textnorm"
+	"fmt"
+	"time"
+)
+
+// MediaReference points at a file attached to an event on the instance it was imported
+// from. Files aren't copied; clients download them from URL.
+type MediaReference struct {
+	SourceID    string // ID of the attachment on the source instance
+	Kind        string // One of agenda, waiver or other
+	FileName    string // Original name of the uploaded file
+	ContentType string // Detected MIME type of the file
+	Size        int64  // File size in bytes
+	URL         string // Where the file can be downloaded from the source instance
+}
+
+// EventImport maps an event imported from another instance to the local event it created.
+type EventImport struct {
+	EventID       string           // ID of the local event
+	Source        string           // Base URL of the instance the event was exported from
+	SourceEventID string           // ID of the event on the source instance
+	Media         []MediaReference // Attachments of the source event
+	ImportedAt    time.Time        // When the event was last imported
+}
+
+// ImportEvent stores an event exported from another instance. The first import of a
+// source event creates a new local event with a new ID; importing it again updates
+// that event and its media references instead of creating a duplicate. Events imported
+// without a status are created as published and keep their status when imported again.
+// Returns the stored event, its mapping and whether the event was created.
+func ImportEvent(ctx context.Context, e Event, source, sourceEventID string, media []MediaReference) (Event, EventImport, bool, error) {
+	encoded, err := json.Marshal(media)
+	if err != nil {
+		return Event{}, EventImport{}, false, err
+	}
+	if e.ReviewStatus == "" {
+		e.ReviewStatus = ReviewApproved
+	}
+	status := e.Status
+	if status == "" {
+		status = StatusPublished
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return Event{}, EventImport{}, false, err
+	}
+	defer tx.Rollback()
+
+	imp := EventImport{Source: source, SourceEventID: sourceEventID, Media: media, ImportedAt: time.Now().UTC()}
+	err = tx.QueryRowContext(
+		ctx,
+		db.Rebind("SELECT event_id FROM event_imports WHERE source=? AND source_event_id=?"),
+		source, sourceEventID,
+	).Scan(&imp.EventID)
+	created := errors.Is(err, sql.ErrNoRows)
+	if err != nil && !created {
+		return Event{}, EventImport{}, false, err
+	}
+
+	if created {
+		e.ID = ids.New()
+		e.CreatedAt = time.Now()
+		imp.EventID = e.ID
+		_, err = tx.ExecContext(
+			ctx,
+			db.Rebind("INSERT INTO events (id, name, description, datetime, user_id, location, capacity, review_status, review_reason, created_at, confirmation_url, confirmation_message, end_datetime, status, slug, license) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)"),
+			e.ID, e.Title, e.Description, e.DateTime, e.UserID, e.Location, e.Capacity, e.ReviewStatus, e.ReviewReason, e.CreatedAt, e.ConfirmationURL, e.ConfirmationMessage, e.EndDateTime, status, textnorm.Slug(e.Title), e.License,
+		)
+		if err != nil {
+			return Event{}, EventImport{}, false, err
+		}
+		_, err = tx.ExecContext(
+			ctx,
+			db.Rebind("INSERT INTO event_imports (event_id, source, source_event_id, media, imported_at) VALUES (?,?,?,?,?)"),
+			imp.EventID, imp.Source, imp.SourceEventID, string(encoded), imp.ImportedAt,
+		)
+	} else {
+		e.ID = imp.EventID
+		err = tx.QueryRowContext(ctx, db.Rebind("SELECT user_id, created_at FROM events WHERE id=?"), e.ID).Scan(&e.UserID, &e.CreatedAt)
+		if err != nil {
+			return Event{}, EventImport{}, false, err
+		}
+		_, err = tx.ExecContext(
+			ctx,
+			db.Rebind("UPDATE events SET name=?, description=?, datetime=?, end_datetime=?, location=?, capacity=?, review_status=?, review_reason=?, confirmation_url=?, confirmation_message=?, status=COALESCE(NULLIF(?, ''), status), slug=?, license=? WHERE id=?"),
+			e.Title, e.Description, e.DateTime, e.EndDateTime, e.Location, e.Capacity, e.ReviewStatus, e.ReviewReason, e.ConfirmationURL, e.ConfirmationMessage, e.Status, textnorm.Slug(e.Title), e.License, e.ID,
+		)
+		if err != nil {
+			return Event{}, EventImport{}, false, err
+		}
+		_, err = tx.ExecContext(
+			ctx,
+			db.Rebind("UPDATE event_imports SET media=?, imported_at=? WHERE event_id=?"),
+			string(encoded), imp.ImportedAt, imp.EventID,
+		)
+	}
+	if err != nil {
+		return Event{}, EventImport{}, false, err
+	}
+	e.Version, err = recordEventVersion(ctx, tx, e.ID, false)
+	if err != nil {
+		return Event{}, EventImport{}, false, err
+	}
+
+	err = commit(ctx, tx)
+	if err != nil {
+		return Event{}, EventImport{}, false, err
+	}
+	return e, imp, created, nil
+}
+
+// GetImport returns how the event was imported from another instance.
+// Returns a NotFoundError when the event wasn't imported.
+func (e Event) GetImport(ctx context.Context) (EventImport, error) {
+	imp := EventImport{EventID: e.ID}
+	var media string
+	err := db.Conn(ctx).QueryRowContext(
+		ctx,
+		db.Rebind("SELECT source, source_event_id, media, imported_at FROM event_imports WHERE event_id=?"),
+		e.ID,
+	).Scan(&imp.Source, &imp.SourceEventID, &media, &imp.ImportedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return EventImport{}, NotFoundError{Message: fmt.Sprint("The event with the ID of ", e.ID, " wasn't imported")}
+	}
+	if err != nil {
+		return EventImport{}, err
+	}
+	err = json.Unmarshal([]byte(media), &imp.Media)
+	if err != nil {
+		return EventImport{}, err
+	}
+	return imp, nil
+}
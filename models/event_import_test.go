@@ -0,0 +1,62 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestImportEvent tests that importing a source event twice updates the event it created
+func TestImportEvent(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+	event := Event{Title: "Jazz Night", Description: "Live jazz", Location: "Club", DateTime: time.Now().Add(24 * time.Hour), UserID: "organizer-1"}
+	media := []MediaReference{{SourceID: "a1", Kind: AttachmentAgenda, FileName: "agenda.pdf", URL: "https://source.example.com/attachments/a1"}}
+
+	imported, imp, created, err := ImportEvent(ctx, event, "https://source.example.com", "source-event-1", media)
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	if !created || imported.ID == "" || imported.ID == "source-event-1" || imp.EventID != imported.ID {
+		t.Fatalf("Expected a new local event, got %+v %+v (created %v)", imported, imp, created)
+	}
+	stored, err := GetEventById(ctx, imported.ID)
+	if err != nil || stored.Title != "Jazz Night" || stored.ReviewStatus != ReviewApproved {
+		t.Errorf("Expected the imported event to be stored, got %+v (%v)", stored, err)
+	}
+
+	event.Title = "Jazz Night (late show)"
+	again, imp, created, err := ImportEvent(ctx, event, "https://source.example.com", "source-event-1", nil)
+	if err != nil {
+		t.Fatalf("Failed to import again: %v", err)
+	}
+	if created || again.ID != imported.ID {
+		t.Errorf("Expected the existing event %s to be updated, got %s (created %v)", imported.ID, again.ID, created)
+	}
+	stored, _ = GetEventById(ctx, imported.ID)
+	if stored.Title != "Jazz Night (late show)" {
+		t.Errorf("Expected the title to be updated, got %q", stored.Title)
+	}
+
+	imp, err = stored.GetImport(ctx)
+	if err != nil || imp.SourceEventID != "source-event-1" || imp.Source != "https://source.example.com" || len(imp.Media) != 0 {
+		t.Errorf("Expected the updated mapping, got %+v (%v)", imp, err)
+	}
+
+	other, _, created, err := ImportEvent(ctx, event, "https://other.example.com", "source-event-1", media)
+	if err != nil || !created || other.ID == imported.ID {
+		t.Errorf("Expected the same ID from another source to create a new event, got %+v (%v)", other, err)
+	}
+}
+
+// TestGetImportNotImported tests looking up the mapping of a local event
+func TestGetImportNotImported(t *testing.T) {
+	setupTestDatabase(t)
+	event := saveEvent(t, Event{Title: "Local Event"})
+	_, err := event.GetImport(context.Background())
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"event_booking_restapi_golang/db"
+	"event_booking_restapi_golang/textnorm"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSort is returned when an EventFilter asks for an unsupported sort column or order.
+var ErrInvalidSort = errors.New("sort must be one of datetime, title, created_at and order must be asc or desc")
+
+// sortableEventColumns maps the sort keys accepted from clients to the SQL expressions
+// they order by. Only keys listed here ever reach the query, so sort input cannot inject SQL.
+var sortableEventColumns = map[string]func() string{
+	"datetime":   func() string { return db.Timestamp("datetime") },
+	"title":      func() string { return "lower(name)" },
+	"created_at": func() string { return db.Timestamp("created_at") },
+}
+
+// EventFilter narrows down the events returned by QueryEvents and CountEvents.
+// Zero values leave the corresponding criterion unrestricted.
+type EventFilter struct {
+	From     time.Time // Only events starting at or after this time
+	To       time.Time // Only events starting at or before this time
+	Overlaps Window    // Only events running at some point within the window
+	Location string    // Case-insensitive substring of the event location
+	Search   string    // Words that must all occur in the event title, compared in their folded form
+	UserID   string    // Only events created by this user
+	SeriesID string    // Only the occurrences of this series
+	Status   string    // Only events in this lifecycle state
+	ViewerID string    // User whose own drafts are included; drafts are left out otherwise
+	Limit    int       // Maximum number of events to return, 0 means no limit
+	Offset   int       // Number of matching events to skip
+	Sort     string    // Column to sort by, one of datetime, title or created_at; defaults to datetime
+	Order    string    // Sort direction, asc or desc; defaults to asc
+}
+
+// Window is a span of time with inclusive bounds. A zero bound leaves that side open.
+type Window struct {
+	Start time.Time
+	End   time.Time
+}
+
+// where builds the parameterized WHERE clause for the filter.
+// Only approved events are ever matched, since the filter backs public listings, and
+// drafts only when they belong to the viewer.
+func (f EventFilter) where() (string, []any) {
+	conditions := []string{"review_status = ?"}
+	args := []any{ReviewApproved}
+
+	if f.ViewerID != "" {
+		conditions = append(conditions, "(status <> ? OR user_id = ?)")
+		args = append(args, StatusDraft, f.ViewerID)
+	} else {
+		conditions = append(conditions, "status <> ?")
+		args = append(args, StatusDraft)
+	}
+	if f.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, f.Status)
+	}
+
+	if !f.From.IsZero() {
+		conditions = append(conditions, db.Timestamp("datetime")+" >= "+db.Timestamp("?"))
+		args = append(args, f.From)
+	}
+	if !f.To.IsZero() {
+		conditions = append(conditions, db.Timestamp("datetime")+" <= "+db.Timestamp("?"))
+		args = append(args, f.To)
+	}
+	if !f.Overlaps.Start.IsZero() {
+		// Events without an end are treated as lasting an instant
+		conditions = append(conditions, db.Timestamp("COALESCE(end_datetime, datetime)")+" >= "+db.Timestamp("?"))
+		args = append(args, f.Overlaps.Start)
+	}
+	if !f.Overlaps.End.IsZero() {
+		conditions = append(conditions, db.Timestamp("datetime")+" <= "+db.Timestamp("?"))
+		args = append(args, f.Overlaps.End)
+	}
+	if f.Location != "" {
+		conditions = append(conditions, db.Contains("location"))
+		args = append(args, f.Location)
+	}
+	// Titles are matched through their slugs, which hold the folded title words
+	for _, word := range textnorm.Words(f.Search) {
+		conditions = append(conditions, db.Contains("slug"))
+		args = append(args, word)
+	}
+	if f.UserID != "" {
+		conditions = append(conditions, "user_id = ?")
+		args = append(args, f.UserID)
+	}
+	if f.SeriesID != "" {
+		conditions = append(conditions, "series_id = ?")
+		args = append(args, f.SeriesID)
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// orderBy builds the ORDER BY clause for the filter from the sortable column whitelist.
+// The event ID breaks ties so that pagination is stable.
+// Returns ErrInvalidSort for unknown sort keys or directions.
+func (f EventFilter) orderBy() (string, error) {
+	sort := f.Sort
+	if sort == "" {
+		sort = "datetime"
+	}
+	column, ok := sortableEventColumns[sort]
+	if !ok {
+		return "", ErrInvalidSort
+	}
+
+	direction := "ASC"
+	switch strings.ToLower(f.Order) {
+	case "", "asc":
+	case "desc":
+		direction = "DESC"
+	default:
+		return "", ErrInvalidSort
+	}
+	return " ORDER BY " + column() + " " + direction + ", id " + direction, nil
+}
+
+// QueryEvents retrieves the publicly visible events matching the filter,
+// sorted by the filter's Sort and Order and paginated by its Limit and Offset.
+// Returns a slice of Event objects and any error encountered during the query.
+func QueryEvents(ctx context.Context, filter EventFilter) ([]Event, error) {
+	orderBy, err := filter.orderBy()
+	if err != nil {
+		return nil, err
+	}
+	where, args := filter.where()
+	q := "SELECT " + eventColumns + " FROM events" + where + orderBy
+	if filter.Limit > 0 {
+		q += " LIMIT ? OFFSET ?"
+		args = append(args, filter.Limit, filter.Offset)
+	}
+
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// CountEvents returns the number of publicly visible events matching the filter,
+// ignoring its Limit and Offset.
+func CountEvents(ctx context.Context, filter EventFilter) (int, error) {
+	where, args := filter.where()
+	var count int
+	err := db.Conn(ctx).QueryRowContext(ctx, db.Rebind("SELECT COUNT(*) FROM events"+where), args...).Scan(&count)
+	return count, err
+}
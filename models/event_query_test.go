@@ -0,0 +1,289 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// seedQueryEvents inserts a set of events spread over days, locations and users.
+// Later events were created earlier, so creation order is the reverse of date order.
+func seedQueryEvents(t *testing.T) time.Time {
+	base := time.Date(2030, 6, 1, 18, 0, 0, 0, time.UTC)
+	seeds := []struct {
+		location string
+		user     string
+		day      int
+	}{
+		{"Berlin Arena", "user1", 0},
+		{"Cairo Opera House", "user1", 1},
+		{"berlin club", "user2", 2},
+		{"Paris", "user2", 3},
+		{"Berlin Park", "user3", 4},
+	}
+	for i, seed := range seeds {
+		event := Event{
+			Title:       fmt.Sprintf("Event %d", i),
+			Description: "Description",
+			Location:    seed.location,
+			DateTime:    base.AddDate(0, 0, seed.day),
+			UserID:      seed.user,
+			CreatedAt:   base.AddDate(0, 0, -10-i),
+		}
+		event, err := event.Save(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to insert test event: %v", err)
+		}
+	}
+	return base
+}
+
+// TestQueryEvents tests filtering events by date range, location and creator
+func TestQueryEvents(t *testing.T) {
+	setupTestDatabase(t)
+	base := seedQueryEvents(t)
+
+	tests := []struct {
+		name     string
+		filter   EventFilter
+		expected []string
+	}{
+		{"no filter", EventFilter{}, []string{"Event 0", "Event 1", "Event 2", "Event 3", "Event 4"}},
+		{"from", EventFilter{From: base.AddDate(0, 0, 3)}, []string{"Event 3", "Event 4"}},
+		{"to", EventFilter{To: base.AddDate(0, 0, 1)}, []string{"Event 0", "Event 1"}},
+		{"range", EventFilter{From: base.AddDate(0, 0, 1), To: base.AddDate(0, 0, 2)}, []string{"Event 1", "Event 2"}},
+		{"location", EventFilter{Location: "BERLIN"}, []string{"Event 0", "Event 2", "Event 4"}},
+		{"user", EventFilter{UserID: "user2"}, []string{"Event 2", "Event 3"}},
+		{"combined", EventFilter{Location: "berlin", UserID: "user2"}, []string{"Event 2"}},
+		{"paginated", EventFilter{Limit: 2, Offset: 2}, []string{"Event 2", "Event 3"}},
+		{"datetime desc", EventFilter{Sort: "datetime", Order: "desc"}, []string{"Event 4", "Event 3", "Event 2", "Event 1", "Event 0"}},
+		{"title desc", EventFilter{Sort: "title", Order: "DESC", Limit: 2}, []string{"Event 4", "Event 3"}},
+		{"created_at", EventFilter{Sort: "created_at"}, []string{"Event 4", "Event 3", "Event 2", "Event 1", "Event 0"}},
+		{"created_at desc", EventFilter{Sort: "created_at", Order: "desc", UserID: "user2"}, []string{"Event 2", "Event 3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := QueryEvents(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatalf("Failed to query events: %v", err)
+			}
+			if len(events) != len(tt.expected) {
+				t.Fatalf("Expected %d events, got %d", len(tt.expected), len(events))
+			}
+			for i, title := range tt.expected {
+				if events[i].Title != title {
+					t.Errorf("Expected %s at position %d, got %s", title, i, events[i].Title)
+				}
+			}
+		})
+	}
+}
+
+// TestCountEvents tests that counting ignores pagination but honors criteria
+func TestCountEvents(t *testing.T) {
+	setupTestDatabase(t)
+	seedQueryEvents(t)
+
+	total, err := CountEvents(context.Background(), EventFilter{Location: "berlin", Limit: 1})
+	if err != nil {
+		t.Fatalf("Failed to count events: %v", err)
+	}
+	if total != 3 {
+		t.Errorf("Expected 3 matching events, got %d", total)
+	}
+}
+
+// TestQueryEventsOverlaps tests finding events running within a window, by their start
+// and end times
+func TestQueryEventsOverlaps(t *testing.T) {
+	setupTestDatabase(t)
+	base := time.Date(2030, 6, 1, 18, 0, 0, 0, time.UTC)
+	end := base.Add(3 * time.Hour)
+	festivalEnd := base.AddDate(0, 0, 3)
+	for _, event := range []Event{
+		{Title: "Concert", DateTime: base, EndDateTime: &end},
+		{Title: "Festival", DateTime: base.AddDate(0, 0, 1), EndDateTime: &festivalEnd},
+		{Title: "Talk", DateTime: base.AddDate(0, 0, 2)},
+	} {
+		event.Description, event.Location = "Description", "Location"
+		_, err := event.Save(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to insert test event: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		window   Window
+		expected []string
+	}{
+		{"during the concert", Window{Start: base.Add(time.Hour), End: base.Add(2 * time.Hour)}, []string{"Concert"}},
+		{"the festival's last day", Window{Start: base.AddDate(0, 0, 3).Add(-time.Hour), End: base.AddDate(0, 0, 4)}, []string{"Festival"}},
+		{"instant event", Window{Start: base.AddDate(0, 0, 2), End: base.AddDate(0, 0, 2)}, []string{"Festival", "Talk"}},
+		{"open start", Window{End: base.AddDate(0, 0, 1)}, []string{"Concert", "Festival"}},
+		{"open end", Window{Start: base.AddDate(0, 0, 2).Add(time.Hour)}, []string{"Festival"}},
+		{"between events", Window{Start: base.Add(4 * time.Hour), End: base.Add(5 * time.Hour)}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := QueryEvents(context.Background(), EventFilter{Overlaps: tt.window})
+			if err != nil {
+				t.Fatalf("Failed to query events: %v", err)
+			}
+			if len(events) != len(tt.expected) {
+				t.Fatalf("Expected %d events, got %d", len(tt.expected), len(events))
+			}
+			for i, title := range tt.expected {
+				if events[i].Title != title {
+					t.Errorf("Expected %s at position %d, got %s", title, i, events[i].Title)
+				}
+			}
+		})
+	}
+
+	events, _ := QueryEvents(context.Background(), EventFilter{})
+	if events[0].EndDateTime == nil || !events[0].EndDateTime.Equal(end) {
+		t.Errorf("Expected the concert to end at %s, got %v", end, events[0].EndDateTime)
+	}
+	if events[2].EndDateTime != nil {
+		t.Errorf("Expected the talk to have no end, got %v", events[2].EndDateTime)
+	}
+}
+
+// TestQueryEventsStatus tests that drafts are only listed for their organizer and that
+// events can be filtered by status
+func TestQueryEventsStatus(t *testing.T) {
+	setupTestDatabase(t)
+	base := time.Date(2030, 6, 1, 18, 0, 0, 0, time.UTC)
+	for i, event := range []Event{
+		{Title: "Published", UserID: "user1"},
+		{Title: "Draft", UserID: "user1", Status: StatusDraft},
+		{Title: "Other Draft", UserID: "user2", Status: StatusDraft},
+		{Title: "Cancelled", UserID: "user2", Status: StatusCancelled},
+	} {
+		event.Description, event.Location, event.DateTime = "Description", "Location", base.AddDate(0, 0, i)
+		_, err := event.Save(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to insert test event: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		filter   EventFilter
+		expected []string
+	}{
+		{"public", EventFilter{}, []string{"Published", "Cancelled"}},
+		{"organizer", EventFilter{ViewerID: "user1"}, []string{"Published", "Draft", "Cancelled"}},
+		{"organizer's drafts", EventFilter{ViewerID: "user2", Status: StatusDraft}, []string{"Other Draft"}},
+		{"public drafts", EventFilter{Status: StatusDraft}, nil},
+		{"cancelled", EventFilter{Status: StatusCancelled}, []string{"Cancelled"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := QueryEvents(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatalf("Failed to query events: %v", err)
+			}
+			if len(events) != len(tt.expected) {
+				t.Fatalf("Expected %d events, got %d", len(tt.expected), len(events))
+			}
+			for i, title := range tt.expected {
+				if events[i].Title != title {
+					t.Errorf("Expected %s at position %d, got %s", title, i, events[i].Title)
+				}
+			}
+			total, _ := CountEvents(context.Background(), tt.filter)
+			if total != len(tt.expected) {
+				t.Errorf("Expected a count of %d, got %d", len(tt.expected), total)
+			}
+		})
+	}
+
+	events, _ := QueryEvents(context.Background(), EventFilter{})
+	if events[0].Status != StatusPublished {
+		t.Errorf("Expected events saved without a status to be published, got %q", events[0].Status)
+	}
+}
+
+// TestQueryEventsSearch tests matching titles by their folded words through slugs
+func TestQueryEventsSearch(t *testing.T) {
+	setupTestDatabase(t)
+	base := time.Date(2030, 6, 1, 18, 0, 0, 0, time.UTC)
+	var saved []Event
+	for i, title := range []string{"Café Zürich 🎉", "Zurich Jazz Night", "Straße Fest"} {
+		event := Event{Title: title, Description: "Description", Location: "Location", DateTime: base.AddDate(0, 0, i), UserID: "user1"}
+		event, err := event.Save(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to insert test event: %v", err)
+		}
+		saved = append(saved, event)
+	}
+	if saved[0].Slug != "cafe-zurich" {
+		t.Errorf("Expected slug cafe-zurich, got %q", saved[0].Slug)
+	}
+
+	tests := []struct {
+		search   string
+		expected []string
+	}{
+		{"cafe zurich", []string{"Café Zürich 🎉"}},
+		{"ZÜRICH", []string{"Café Zürich 🎉", "Zurich Jazz Night"}},
+		{"strasse", []string{"Straße Fest"}},
+		{"zurich opera", nil},
+		{"🎉", []string{"Café Zürich 🎉", "Zurich Jazz Night", "Straße Fest"}},
+	}
+	for _, tt := range tests {
+		events, err := QueryEvents(context.Background(), EventFilter{Search: tt.search})
+		if err != nil {
+			t.Fatalf("Failed to query events: %v", err)
+		}
+		if len(events) != len(tt.expected) {
+			t.Fatalf("Expected %d events for %q, got %d", len(tt.expected), tt.search, len(events))
+		}
+		for i, title := range tt.expected {
+			if events[i].Title != title {
+				t.Errorf("Expected %s at position %d for %q, got %s", title, i, tt.search, events[i].Title)
+			}
+		}
+	}
+
+	event := saved[2]
+	event.Title = "Crème Brûlée Tasting"
+	if err := event.Update(context.Background()); err != nil {
+		t.Fatalf("Failed to update event: %v", err)
+	}
+	if err := saved[1].Patch(context.Background(), map[string]any{"Title": "Blues Night"}); err != nil {
+		t.Fatalf("Failed to patch event: %v", err)
+	}
+	for id, slug := range map[string]string{saved[1].ID: "blues-night", saved[2].ID: "creme-brulee-tasting"} {
+		stored, err := GetEventById(context.Background(), id)
+		if err != nil {
+			t.Fatalf("Failed to get event: %v", err)
+		}
+		if stored.Slug != slug {
+			t.Errorf("Expected slug %s after changing the title, got %q", slug, stored.Slug)
+		}
+	}
+}
+
+// TestQueryEventsInvalidSort tests that sort keys outside the whitelist are rejected
+func TestQueryEventsInvalidSort(t *testing.T) {
+	setupTestDatabase(t)
+	seedQueryEvents(t)
+
+	filters := []EventFilter{
+		{Sort: "user_id"},
+		{Sort: "name; DROP TABLE events"},
+		{Order: "sideways"},
+	}
+	for _, filter := range filters {
+		_, err := QueryEvents(context.Background(), filter)
+		if !errors.Is(err, ErrInvalidSort) {
+			t.Errorf("Expected ErrInvalidSort for %+v, got %v", filter, err)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// EventRepository is the storage of events used by the HTTP handlers.
+// It lets handlers be tested against an in-memory implementation instead of a database.
+type EventRepository interface {
+	// GetEventById retrieves a single event by its ID, returning an error if it doesn't exist.
+	GetEventById(ctx context.Context, id string) (Event, error)
+	// GetEventByExternalID retrieves the event synced from source under the external ID,
+	// returning an error if it doesn't exist.
+	GetEventByExternalID(ctx context.Context, source, externalID string) (Event, error)
+	// QueryEvents retrieves a page of events matching the filter.
+	QueryEvents(ctx context.Context, filter EventFilter) ([]Event, error)
+	// CountEvents counts the events matching the filter, ignoring its pagination.
+	CountEvents(ctx context.Context, filter EventFilter) (int, error)
+	// GetEventsByReviewStatus retrieves all events in the given moderation state, oldest first.
+	GetEventsByReviewStatus(ctx context.Context, status string) ([]Event, error)
+	// GetEventsByUserId retrieves a page of the user's events matching the filter and
+	// their total number.
+	GetEventsByUserId(ctx context.Context, userID string, filter UserEventFilter, limit, offset int) ([]Event, int, error)
+	// GetEventVersionAt retrieves the state of an event at the given time from its
+	// history, returning an error if it had no recorded state then.
+	GetEventVersionAt(ctx context.Context, id string, at time.Time) (EventVersion, error)
+	// Save stores a new event under its ID, generating one when it has none, and
+	// returns the stored event.
+	Save(ctx context.Context, e Event) (Event, error)
+	// UpsertByExternalID creates or updates the event synced under its source and
+	// external ID, reporting whether it was created.
+	UpsertByExternalID(ctx context.Context, e Event) (Event, bool, error)
+	// Update stores the editable fields of an existing event.
+	Update(ctx context.Context, e Event) error
+	// Patch stores only the given fields of an existing event, keyed as for Event.Patch.
+	Patch(ctx context.Context, e Event, fields map[string]any) error
+	// Delete removes an event.
+	Delete(ctx context.Context, e Event) error
+	// SetReviewStatus records a moderation decision for an event.
+	SetReviewStatus(ctx context.Context, e Event, status, reason string) error
+	// Cancel marks an event cancelled, reporting whether it wasn't already.
+	Cancel(ctx context.Context, e Event) (bool, error)
+}
+
+// sqlEventRepository is the EventRepository backed by the database opened with db.InitDB.
+type sqlEventRepository struct{}
+
+// NewSQLEventRepository returns an EventRepository that stores events in the database.
+func NewSQLEventRepository() EventRepository {
+	return sqlEventRepository{}
+}
+
+func (sqlEventRepository) GetEventById(ctx context.Context, id string) (Event, error) {
+	return GetEventById(ctx, id)
+}
+
+func (sqlEventRepository) GetEventByExternalID(ctx context.Context, source, externalID string) (Event, error) {
+	return GetEventByExternalID(ctx, source, externalID)
+}
+
+func (sqlEventRepository) QueryEvents(ctx context.Context, filter EventFilter) ([]Event, error) {
+	return QueryEvents(ctx, filter)
+}
+
+func (sqlEventRepository) CountEvents(ctx context.Context, filter EventFilter) (int, error) {
+	return CountEvents(ctx, filter)
+}
+
+func (sqlEventRepository) GetEventsByReviewStatus(ctx context.Context, status string) ([]Event, error) {
+	return GetEventsByReviewStatus(ctx, status)
+}
+
+func (sqlEventRepository) GetEventsByUserId(ctx context.Context, userID string, filter UserEventFilter, limit, offset int) ([]Event, int, error) {
+	return GetEventsByUserId(ctx, userID, filter, limit, offset)
+}
+
+func (sqlEventRepository) GetEventVersionAt(ctx context.Context, id string, at time.Time) (EventVersion, error) {
+	return GetEventVersionAt(ctx, id, at)
+}
+
+func (sqlEventRepository) Save(ctx context.Context, e Event) (Event, error) {
+	return e.Save(ctx)
+}
+
+func (sqlEventRepository) UpsertByExternalID(ctx context.Context, e Event) (Event, bool, error) {
+	return e.UpsertByExternalID(ctx)
+}
+
+func (sqlEventRepository) Update(ctx context.Context, e Event) error {
+	return e.Update(ctx)
+}
+
+func (sqlEventRepository) Patch(ctx context.Context, e Event, fields map[string]any) error {
+	return e.Patch(ctx, fields)
+}
+
+func (sqlEventRepository) Delete(ctx context.Context, e Event) error {
+	return e.Delete(ctx)
+}
+
+func (sqlEventRepository) SetReviewStatus(ctx context.Context, e Event, status, reason string) error {
+	return e.SetReviewStatus(ctx, status, reason)
+}
+
+func (sqlEventRepository) Cancel(ctx context.Context, e Event) (bool, error) {
+	return e.Cancel(ctx)
+}
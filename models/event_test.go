@@ -2,8 +2,12 @@
 package models
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"event_booking_restapi_golang/db"
+	"event_booking_restapi_golang/ids"
 	"testing"
 	"time"
 
@@ -20,7 +24,7 @@ func setupTestDatabase(t *testing.T) {
 		t.Fatalf("Failed to create test database: %v", err)
 	}
 
-	// Create events table for testing
+	// Create the application tables for testing
 	createTableSQL := `
 	CREATE TABLE IF NOT EXISTS events (
 		id TEXT PRIMARY KEY,
@@ -28,7 +32,268 @@ func setupTestDatabase(t *testing.T) {
 		description TEXT NOT NULL,
 		location TEXT NOT NULL,
 		datetime DATETIME NOT NULL,
-		user_id TEXT
+		user_id TEXT,
+		capacity INTEGER NOT NULL DEFAULT 0,
+		review_status TEXT NOT NULL DEFAULT 'approved',
+		review_reason TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		confirmation_url TEXT NOT NULL DEFAULT '',
+		confirmation_message TEXT NOT NULL DEFAULT '',
+		external_id TEXT NOT NULL DEFAULT '',
+		external_source TEXT NOT NULL DEFAULT '',
+		end_datetime DATETIME,
+		status TEXT NOT NULL DEFAULT 'published',
+		slug TEXT NOT NULL DEFAULT '',
+		license TEXT NOT NULL DEFAULT '',
+		version INTEGER NOT NULL DEFAULT 0,
+		series_id TEXT NOT NULL DEFAULT ''
+	);
+	CREATE TABLE IF NOT EXISTS registrations (
+		id TEXT PRIMARY KEY,
+		event_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		email TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		waiver_id TEXT NOT NULL DEFAULT '',
+		waiver_accepted_at DATETIME,
+		waiver_ip TEXT NOT NULL DEFAULT '',
+		utm_source TEXT NOT NULL DEFAULT '',
+		utm_medium TEXT NOT NULL DEFAULT '',
+		utm_campaign TEXT NOT NULL DEFAULT '',
+		utm_term TEXT NOT NULL DEFAULT '',
+		utm_content TEXT NOT NULL DEFAULT '',
+		ref TEXT NOT NULL DEFAULT '',
+		external_id TEXT NOT NULL DEFAULT '',
+		external_source TEXT NOT NULL DEFAULT '',
+		checked_in_at DATETIME,
+		moved_at DATETIME,
+		UNIQUE (event_id, email)
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS events_external_id ON events (external_source, external_id) WHERE external_id <> '';
+	CREATE UNIQUE INDEX IF NOT EXISTS registrations_external_id ON registrations (external_source, external_id) WHERE external_id <> '';
+	CREATE TABLE IF NOT EXISTS waitlist (
+		id TEXT PRIMARY KEY,
+		event_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		email TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		waiver_id TEXT NOT NULL DEFAULT '',
+		waiver_accepted_at DATETIME,
+		waiver_ip TEXT NOT NULL DEFAULT '',
+		utm_source TEXT NOT NULL DEFAULT '',
+		utm_medium TEXT NOT NULL DEFAULT '',
+		utm_campaign TEXT NOT NULL DEFAULT '',
+		utm_term TEXT NOT NULL DEFAULT '',
+		utm_content TEXT NOT NULL DEFAULT '',
+		ref TEXT NOT NULL DEFAULT '',
+		UNIQUE (event_id, email)
+	);
+	CREATE TABLE IF NOT EXISTS content_rules (
+		id TEXT PRIMARY KEY,
+		pattern TEXT NOT NULL,
+		is_regex INTEGER NOT NULL DEFAULT 0,
+		action TEXT NOT NULL,
+		created_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS attachments (
+		id TEXT PRIMARY KEY,
+		event_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		file_name TEXT NOT NULL,
+		content_type TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		attendees_only BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS experiment_events (
+		id TEXT PRIMARY KEY,
+		experiment TEXT NOT NULL,
+		variant TEXT NOT NULL,
+		session_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		event_id TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS audit_log (
+		seq INTEGER PRIMARY KEY,
+		action TEXT NOT NULL,
+		event_id TEXT NOT NULL DEFAULT '',
+		subject_id TEXT NOT NULL DEFAULT '',
+		details TEXT NOT NULL DEFAULT '{}',
+		created_at DATETIME NOT NULL,
+		prev_hash TEXT NOT NULL,
+		hash TEXT NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS audit_export (
+		sink TEXT PRIMARY KEY,
+		last_seq INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE TABLE IF NOT EXISTS analytics_opt_outs (
+		session_id TEXT PRIMARY KEY,
+		created_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS registration_daily_summary (
+		event_id TEXT NOT NULL,
+		day TEXT NOT NULL,
+		registrations INTEGER NOT NULL,
+		PRIMARY KEY (event_id, day)
+	);
+	CREATE TABLE IF NOT EXISTS event_view_daily_summary (
+		event_id TEXT NOT NULL,
+		day TEXT NOT NULL,
+		views INTEGER NOT NULL,
+		PRIMARY KEY (event_id, day)
+	);
+	CREATE TABLE IF NOT EXISTS summary_refresh (
+		name TEXT PRIMARY KEY,
+		refreshed_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS event_alerts (
+		event_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		sent_at DATETIME NOT NULL,
+		PRIMARY KEY (event_id, kind)
+	);
+	CREATE TABLE IF NOT EXISTS event_senders (
+		event_id TEXT PRIMARY KEY,
+		sender_name TEXT NOT NULL DEFAULT '',
+		reply_to TEXT NOT NULL DEFAULT '',
+		reply_to_verified_at DATETIME,
+		verification_hash TEXT NOT NULL DEFAULT '',
+		verification_expires_at DATETIME
+	);
+	CREATE TABLE IF NOT EXISTS event_messages (
+		id TEXT PRIMARY KEY,
+		event_id TEXT NOT NULL,
+		registration_id TEXT NOT NULL,
+		sender TEXT NOT NULL,
+		body TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		read_at DATETIME
+	);
+	CREATE TABLE IF NOT EXISTS user_blocks (
+		organizer_id TEXT NOT NULL,
+		email TEXT NOT NULL,
+		blocked_by TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		PRIMARY KEY (organizer_id, email, blocked_by)
+	);
+	CREATE TABLE IF NOT EXISTS event_imports (
+		event_id TEXT PRIMARY KEY,
+		source TEXT NOT NULL,
+		source_event_id TEXT NOT NULL,
+		media TEXT NOT NULL,
+		imported_at DATETIME NOT NULL,
+		UNIQUE (source, source_event_id)
+	);
+	CREATE TABLE IF NOT EXISTS feed_runs (
+		id TEXT PRIMARY KEY,
+		feed TEXT NOT NULL,
+		started_at DATETIME NOT NULL,
+		finished_at DATETIME NOT NULL,
+		added INTEGER NOT NULL DEFAULT 0,
+		updated INTEGER NOT NULL DEFAULT 0,
+		skipped INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		skips TEXT NOT NULL DEFAULT '[]'
+	);
+	CREATE TABLE IF NOT EXISTS tickets (
+		id TEXT PRIMARY KEY,
+		registration_id TEXT NOT NULL UNIQUE,
+		event_id TEXT NOT NULL,
+		code TEXT NOT NULL UNIQUE,
+		created_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS event_versions (
+		event_id TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		recorded_at DATETIME NOT NULL,
+		deleted BOOLEAN NOT NULL DEFAULT FALSE,
+		name TEXT NOT NULL,
+		description TEXT NOT NULL,
+		location TEXT NOT NULL,
+		datetime DATETIME NOT NULL,
+		user_id TEXT,
+		capacity INTEGER NOT NULL DEFAULT 0,
+		review_status TEXT NOT NULL DEFAULT 'approved',
+		review_reason TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		confirmation_url TEXT NOT NULL DEFAULT '',
+		confirmation_message TEXT NOT NULL DEFAULT '',
+		external_id TEXT NOT NULL DEFAULT '',
+		external_source TEXT NOT NULL DEFAULT '',
+		end_datetime DATETIME,
+		status TEXT NOT NULL DEFAULT 'published',
+		slug TEXT NOT NULL DEFAULT '',
+		license TEXT NOT NULL DEFAULT '',
+		series_id TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (event_id, version)
+	);
+	CREATE TABLE IF NOT EXISTS jobs (
+		id TEXT PRIMARY KEY,
+		kind TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		run_at DATETIME NOT NULL,
+		locked_at DATETIME,
+		last_error TEXT NOT NULL DEFAULT '',
+		request_id TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS refund_requests (
+		id TEXT PRIMARY KEY,
+		registration_id TEXT NOT NULL UNIQUE,
+		event_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		email TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'requested',
+		auto_approved BOOLEAN NOT NULL DEFAULT FALSE,
+		decision_reason TEXT NOT NULL DEFAULT '',
+		provider_reference TEXT NOT NULL DEFAULT '',
+		error TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		decided_at DATETIME,
+		refunded_at DATETIME
+	);
+	CREATE TABLE IF NOT EXISTS venue_blackouts (
+		id TEXT PRIMARY KEY,
+		location TEXT NOT NULL,
+		blackout_date TEXT NOT NULL,
+		reason TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS organizer_onboarding (
+		user_id TEXT NOT NULL,
+		step TEXT NOT NULL,
+		completed_at DATETIME NOT NULL,
+		PRIMARY KEY (user_id, step)
+	);
+	CREATE TABLE IF NOT EXISTS status_probes (
+		id TEXT PRIMARY KEY,
+		component TEXT NOT NULL,
+		checked_at DATETIME NOT NULL,
+		state TEXT NOT NULL,
+		detail TEXT NOT NULL DEFAULT ''
+	);
+	CREATE TABLE IF NOT EXISTS registration_cancellations (
+		registration_id TEXT PRIMARY KEY,
+		event_id TEXT NOT NULL,
+		registered_at DATETIME NOT NULL,
+		cancelled_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS registration_moves (
+		id TEXT PRIMARY KEY,
+		registration_id TEXT NOT NULL,
+		event_id TEXT NOT NULL,
+		to_event_id TEXT NOT NULL,
+		registered_at DATETIME NOT NULL,
+		moved_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER PRIMARY KEY,
+		migrated_at DATETIME NOT NULL
 	)
 	`
 	_, err = testDB.Exec(createTableSQL)
@@ -45,6 +310,29 @@ func setupTestDatabase(t *testing.T) {
 	})
 }
 
+// saveEvent saves the event, with a title, description and location and starting now
+// unless it has its own, and returns it as stored
+func saveEvent(t *testing.T, event Event) Event {
+	t.Helper()
+	if event.Title == "" {
+		event.Title = "Test Event"
+	}
+	if event.Description == "" {
+		event.Description = "Test Description"
+	}
+	if event.Location == "" {
+		event.Location = "Test Location"
+	}
+	if event.DateTime.IsZero() {
+		event.DateTime = time.Now()
+	}
+	event, err := event.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to save test event: %v", err)
+	}
+	return event
+}
+
 // TestEvent_Save tests the Save method of the Event model
 func TestEvent_Save(t *testing.T) {
 	setupTestDatabase(t)
@@ -57,7 +345,7 @@ func TestEvent_Save(t *testing.T) {
 		UserID:      "test-user-123",
 	}
 
-	err := event.Save()
+	saved, err := event.Save(context.Background())
 	if err != nil {
 		t.Errorf("Failed to save event: %v", err)
 	}
@@ -71,6 +359,41 @@ func TestEvent_Save(t *testing.T) {
 	if count != 1 {
 		t.Errorf("Expected 1 event to be saved, got %d", count)
 	}
+
+	var id string
+	err = testDB.QueryRow("SELECT id FROM events WHERE name = ?", event.Title).Scan(&id)
+	if err != nil {
+		t.Fatalf("Failed to get event ID: %v", err)
+	}
+	if _, ok := ids.Time(id); !ok {
+		t.Errorf("Expected a time-ordered ID, got %s", id)
+	}
+	if saved.ID != id {
+		t.Errorf("Expected the returned event to carry the stored ID %s, got %s", id, saved.ID)
+	}
+}
+
+// TestEvent_SaveKeepsID tests that an event is stored under the ID it was given
+func TestEvent_SaveKeepsID(t *testing.T) {
+	setupTestDatabase(t)
+
+	event := Event{ID: "event-1", Title: "Test Event", Description: "Test Description", Location: "Test Location", DateTime: time.Now()}
+	saved, err := event.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to save event: %v", err)
+	}
+	if saved.ID != "event-1" {
+		t.Errorf("Expected the given ID to be kept, got %s", saved.ID)
+	}
+	stored, err := GetEventById(context.Background(), "event-1")
+	if err != nil || stored.Title != "Test Event" {
+		t.Errorf("Expected the event stored under its ID, got %+v (%v)", stored, err)
+	}
+
+	_, err = event.Save(context.Background())
+	if err == nil {
+		t.Error("Expected an error when saving a second event with the same ID")
+	}
 }
 
 // TestGetAllEvents tests the GetAllEvents function
@@ -96,13 +419,13 @@ func TestGetAllEvents(t *testing.T) {
 	}
 
 	for _, event := range events {
-		err := event.Save()
+		_, err := event.Save(context.Background())
 		if err != nil {
 			t.Fatalf("Failed to insert test event: %v", err)
 		}
 	}
 
-	retrievedEvents, err := GetAllEvents()
+	retrievedEvents, err := GetAllEvents(context.Background())
 	if err != nil {
 		t.Errorf("Failed to get all events: %v", err)
 	}
@@ -110,6 +433,15 @@ func TestGetAllEvents(t *testing.T) {
 	if len(retrievedEvents) != 2 {
 		t.Errorf("Expected 2 events, got %d", len(retrievedEvents))
 	}
+
+	// Repeated calls must not accumulate results
+	retrievedEvents, err = GetAllEvents(context.Background())
+	if err != nil {
+		t.Errorf("Failed to get all events again: %v", err)
+	}
+	if len(retrievedEvents) != 2 {
+		t.Errorf("Expected 2 events on the second call, got %d", len(retrievedEvents))
+	}
 }
 
 // TestGetEventById tests the GetEventById function
@@ -125,7 +457,7 @@ func TestGetEventById(t *testing.T) {
 		UserID:      "test-user-123",
 	}
 
-	err := event.Save()
+	event, err := event.Save(context.Background())
 	if err != nil {
 		t.Fatalf("Failed to save test event: %v", err)
 	}
@@ -138,7 +470,7 @@ func TestGetEventById(t *testing.T) {
 	}
 
 	// Test retrieving the event
-	retrievedEvent, err := GetEventById(id)
+	retrievedEvent, err := GetEventById(context.Background(), id)
 	if err != nil {
 		t.Errorf("Failed to get event by ID: %v", err)
 	}
@@ -148,7 +480,7 @@ func TestGetEventById(t *testing.T) {
 	}
 
 	// Test with non-existent ID
-	_, err = GetEventById("non-existent-id")
+	_, err = GetEventById(context.Background(), "non-existent-id")
 	if err == nil {
 		t.Error("Expected error when getting non-existent event")
 	}
@@ -167,7 +499,7 @@ func TestEvent_Update(t *testing.T) {
 		UserID:      "test-user-123",
 	}
 
-	err := event.Save()
+	event, err := event.Save(context.Background())
 	if err != nil {
 		t.Fatalf("Failed to save test event: %v", err)
 	}
@@ -185,7 +517,7 @@ func TestEvent_Update(t *testing.T) {
 	event.Description = "Updated Description"
 	event.Location = "Updated Location"
 
-	err = event.Update()
+	err = event.Update(context.Background())
 	if err != nil {
 		t.Errorf("Failed to update event: %v", err)
 	}
@@ -202,6 +534,103 @@ func TestEvent_Update(t *testing.T) {
 	}
 }
 
+// TestEvent_UpdateVersion tests that every change bumps the event's version and that
+// updates based on an outdated version are refused
+func TestEvent_UpdateVersion(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+
+	event, err := Event{Title: "Meetup", Description: "Talks", Location: "Hall", DateTime: time.Now().Add(time.Hour)}.Save(ctx)
+	if err != nil {
+		t.Fatalf("Failed to save test event: %v", err)
+	}
+	if event.Version != 1 {
+		t.Errorf("Expected a new event at version 1, got %d", event.Version)
+	}
+
+	edit := event
+	edit.Location = "Garden"
+	if err := edit.Update(ctx); err != nil {
+		t.Fatalf("Failed to update at the current version: %v", err)
+	}
+	if err := event.SetReviewStatus(ctx, ReviewApproved, ""); err != nil {
+		t.Fatalf("Failed to set the review status: %v", err)
+	}
+	stored, err := GetEventById(ctx, event.ID)
+	if err != nil || stored.Version != 3 {
+		t.Errorf("Expected version 3 after two changes, got %d (%v)", stored.Version, err)
+	}
+
+	edit.Location = "Rooftop"
+	var conflict VersionConflictError
+	if err := edit.Update(ctx); !errors.As(err, &conflict) || conflict.Version != 1 || conflict.Current != 3 {
+		t.Errorf("Expected a conflict between versions 1 and 3, got %v", err)
+	}
+	edit.Version = 0
+	if err := edit.Update(ctx); err != nil {
+		t.Errorf("Expected an update without a version to go through, got %v", err)
+	}
+
+	history, err := GetEventVersionAt(ctx, event.ID, time.Now())
+	if err != nil || history.Version != 4 || history.Event.Version != 4 || history.Location != "Rooftop" {
+		t.Errorf("Expected the history to number the changes like the event, got %+v (%v)", history, err)
+	}
+}
+
+// TestEvent_Patch tests that Patch changes only the given fields
+func TestEvent_Patch(t *testing.T) {
+	setupTestDatabase(t)
+
+	event := Event{
+		Title:       "Original Title",
+		Description: "Original Description",
+		Location:    "Original Location",
+		DateTime:    time.Now(),
+		UserID:      "test-user-123",
+	}
+	event, err := event.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to save test event: %v", err)
+	}
+	err = testDB.QueryRow("SELECT id FROM events WHERE name = ?", event.Title).Scan(&event.ID)
+	if err != nil {
+		t.Fatalf("Failed to get event ID: %v", err)
+	}
+
+	// Stale values in the struct must not be written for fields that aren't patched
+	event.Description = "Stale Description"
+	err = event.Patch(context.Background(), map[string]any{"Title": "Patched Title", "Capacity": 25})
+	if err != nil {
+		t.Fatalf("Failed to patch event: %v", err)
+	}
+
+	patched, err := GetEventById(context.Background(), event.ID)
+	if err != nil {
+		t.Fatalf("Failed to get patched event: %v", err)
+	}
+	if patched.Title != "Patched Title" || patched.Capacity != 25 || patched.Description != "Original Description" || patched.Location != "Original Location" {
+		t.Errorf("Unexpected patched event %+v", patched)
+	}
+
+	if err := event.Patch(context.Background(), map[string]any{"ReviewStatus": ReviewApproved}); err == nil {
+		t.Error("Expected an error when patching a field that isn't patchable")
+	}
+	if err := event.Patch(context.Background(), nil); err != nil {
+		t.Errorf("Expected patching no fields to succeed, got %v", err)
+	}
+
+	// A Version entry only lets the patch through while the event is at that version
+	var conflict VersionConflictError
+	err = event.Patch(context.Background(), map[string]any{"Location": "Elsewhere", "Version": event.Version})
+	if !errors.As(err, &conflict) || conflict.Current != patched.Version {
+		t.Errorf("Expected a version conflict at version %d, got %v", patched.Version, err)
+	}
+	err = event.Patch(context.Background(), map[string]any{"Location": "Elsewhere", "Version": patched.Version})
+	if err != nil {
+		t.Errorf("Expected the patch at the current version to succeed, got %v", err)
+	}
+}
+
 // TestEvent_Delete tests the Delete method of the Event model
 func TestEvent_Delete(t *testing.T) {
 	setupTestDatabase(t)
@@ -215,7 +644,7 @@ func TestEvent_Delete(t *testing.T) {
 		UserID:      "test-user-123",
 	}
 
-	err := event.Save()
+	event, err := event.Save(context.Background())
 	if err != nil {
 		t.Fatalf("Failed to save test event: %v", err)
 	}
@@ -229,7 +658,7 @@ func TestEvent_Delete(t *testing.T) {
 
 	// Delete the event
 	event.ID = id
-	err = event.Delete()
+	err = event.Delete(context.Background())
 	if err != nil {
 		t.Errorf("Failed to delete event: %v", err)
 	}
@@ -277,20 +706,20 @@ func TestGetEventsByUserId(t *testing.T) {
 	}
 
 	for _, event := range events {
-		err := event.Save()
+		_, err := event.Save(context.Background())
 		if err != nil {
 			t.Fatalf("Failed to insert test event: %v", err)
 		}
 	}
 
 	// Get events for the specific user
-	userEvents, err := GetEventsByUserId(userId)
+	userEvents, total, err := GetEventsByUserId(context.Background(), userId, UserEventFilter{}, 10, 0)
 	if err != nil {
 		t.Errorf("Failed to get events by user ID: %v", err)
 	}
 
-	if len(userEvents) != 2 {
-		t.Errorf("Expected 2 events for user %s, got %d", userId, len(userEvents))
+	if len(userEvents) != 2 || total != 2 {
+		t.Errorf("Expected 2 events for user %s, got %d of %d", userId, len(userEvents), total)
 	}
 
 	// Verify all returned events belong to the correct user
@@ -299,6 +728,48 @@ func TestGetEventsByUserId(t *testing.T) {
 			t.Errorf("Expected user ID %s, got %s", userId, event.UserID)
 		}
 	}
+
+	// Page through the events in date order
+	userEvents, total, err = GetEventsByUserId(context.Background(), userId, UserEventFilter{}, 1, 1)
+	if err != nil || total != 2 || len(userEvents) != 1 || userEvents[0].Title != "User Event 2" {
+		t.Errorf("Expected the second page to hold User Event 2 of 2, got %+v of %d (%v)", userEvents, total, err)
+	}
+}
+
+// TestGetEventsByUserIdFilters tests that drafts and held events are included and can
+// be filtered by status
+func TestGetEventsByUserIdFilters(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+	userId := "test-user-123"
+	for _, e := range []Event{
+		{Title: "Published", Status: StatusPublished},
+		{Title: "Draft", Status: StatusDraft},
+		{Title: "Pending", Status: StatusPublished, ReviewStatus: ReviewPending},
+	} {
+		e.Description, e.Location, e.DateTime, e.UserID = "Description", "Location", time.Now(), userId
+		_, err := e.Save(ctx)
+		if err != nil {
+			t.Fatalf("Failed to insert test event: %v", err)
+		}
+	}
+
+	tests := []struct {
+		filter   UserEventFilter
+		expected int
+	}{
+		{UserEventFilter{}, 3},
+		{UserEventFilter{Status: StatusDraft}, 1},
+		{UserEventFilter{Status: StatusPublished}, 2},
+		{UserEventFilter{ReviewStatus: ReviewPending}, 1},
+		{UserEventFilter{Status: StatusCancelled}, 0},
+	}
+	for _, tt := range tests {
+		events, total, err := GetEventsByUserId(ctx, userId, tt.filter, 10, 0)
+		if err != nil || len(events) != tt.expected || total != tt.expected {
+			t.Errorf("%+v: expected %d events, got %d of %d (%v)", tt.filter, tt.expected, len(events), total, err)
+		}
+	}
 }
 
 // TestEventValidation tests the validation tags on the Event struct
@@ -317,3 +788,119 @@ func TestEventValidation(t *testing.T) {
 	// This is a placeholder for when validation is implemented
 	_ = event
 }
+
+// TestEvent_SetReviewStatus tests moderation state changes and their effect on listings
+func TestEvent_SetReviewStatus(t *testing.T) {
+	setupTestDatabase(t)
+
+	event := Event{
+		Title:        "Pending Event",
+		Description:  "Description",
+		Location:     "Location",
+		DateTime:     time.Now(),
+		ReviewStatus: ReviewPending,
+	}
+	event, err := event.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to save test event: %v", err)
+	}
+
+	pending, err := GetEventsByReviewStatus(context.Background(), ReviewPending)
+	if err != nil {
+		t.Fatalf("Failed to get pending events: %v", err)
+	}
+	if len(pending) != 1 {
+		t.Fatalf("Expected 1 pending event, got %d", len(pending))
+	}
+
+	total, err := CountEvents(context.Background(), EventFilter{})
+	if err != nil {
+		t.Fatalf("Failed to count events: %v", err)
+	}
+	if total != 0 {
+		t.Errorf("Expected pending events to be excluded from the public count, got %d", total)
+	}
+
+	err = pending[0].SetReviewStatus(context.Background(), ReviewRejected, "Missing details")
+	if err != nil {
+		t.Fatalf("Failed to set review status: %v", err)
+	}
+
+	rejected, err := GetEventById(context.Background(), pending[0].ID)
+	if err != nil {
+		t.Fatalf("Failed to get event: %v", err)
+	}
+	if rejected.ReviewStatus != ReviewRejected || rejected.ReviewReason != "Missing details" {
+		t.Errorf("Expected rejected event with reason, got %s and %q", rejected.ReviewStatus, rejected.ReviewReason)
+	}
+}
+
+// TestContextCancellation tests that a cancelled request context aborts model queries
+func TestContextCancellation(t *testing.T) {
+	setupTestDatabase(t)
+	saveEvent(t, Event{Title: "Cancelled Lookup"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := GetAllEvents(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled from GetAllEvents, got %v", err)
+	}
+	_, err = Event{Title: "Too Late", Description: "d", Location: "l", DateTime: time.Now()}.Save(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled from Save, got %v", err)
+	}
+	_, err = Event{ID: "any"}.Register(ctx, Registration{Name: "A", Email: "a@example.com"})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled from Register, got %v", err)
+	}
+}
+
+// TestGetEventByIdNotFound tests that a missing event is reported as a NotFoundError matching sql.ErrNoRows
+func TestGetEventByIdNotFound(t *testing.T) {
+	setupTestDatabase(t)
+
+	_, err := GetEventById(context.Background(), "missing")
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected an error matching sql.ErrNoRows, got %v", err)
+	}
+	var notFound NotFoundError
+	if !errors.As(err, &notFound) {
+		t.Errorf("Expected a NotFoundError, got %T", err)
+	}
+}
+
+// TestEventJSON tests the snake_case JSON names and the legacy names still accepted on input
+func TestEventJSON(t *testing.T) {
+	data, err := json.Marshal(Event{Title: "Concert", ConfirmationURL: "https://example.com"})
+	if err != nil {
+		t.Fatalf("Failed to marshal event: %v", err)
+	}
+	var fields map[string]interface{}
+	json.Unmarshal(data, &fields)
+	for _, name := range []string{"id", "title", "date_time", "user_id", "review_status", "created_at", "confirmation_url"} {
+		if _, ok := fields[name]; !ok {
+			t.Errorf("Expected JSON field %s in %s", name, data)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"snake_case", `{"title": "Concert", "date_time": "2030-01-02T15:04:05Z", "confirmation_url": "https://example.com"}`},
+		{"legacy", `{"Title": "Concert", "DateTime": "2030-01-02T15:04:05Z", "confirmationUrl": "https://example.com"}`},
+		{"snake_case wins", `{"title": "Concert", "Title": "Old", "datetime": "2000-01-01T00:00:00Z", "date_time": "2030-01-02T15:04:05Z", "confirmation_url": "https://example.com"}`},
+	}
+	expectedTime := time.Date(2030, 1, 2, 15, 4, 5, 0, time.UTC)
+	for _, tt := range tests {
+		var e Event
+		if err := json.Unmarshal([]byte(tt.input), &e); err != nil {
+			t.Fatalf("%s: failed to unmarshal event: %v", tt.name, err)
+		}
+		if e.Title != "Concert" || !e.DateTime.Equal(expectedTime) || e.ConfirmationURL != "https://example.com" {
+			t.Errorf("%s: unexpected event %+v", tt.name, e)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"event_booking_restapi_golang/db"
+	"fmt"
+	"time"
+)
+
+// EventVersion is the state of an event after one of its changes. Every change to an
+// event is kept in the event_versions table, so that its past states can be looked up,
+// for instance to settle disputes about what an event page said at the time of a booking.
+type EventVersion struct {
+	Event                // The event as it was after the change
+	Version    int       // Number of the change, counting from 1 for each event, as in Event.Version
+	RecordedAt time.Time // When the change was made
+	Deleted    bool      // Whether the change deleted the event
+}
+
+// recordEventVersion copies the current state of the event into event_versions as its
+// next version, and stores the version number in the event's version column. It runs
+// within the transaction that changes the event, after the change, whose row lock keeps
+// concurrent changes from taking the same version number. Numbers carry on from the
+// history, which outlives deleted events, so events re-created under the same ID don't
+// restart at 1. Deletions are recorded before the row is deleted, with the event's final state.
+// Returns the version number.
+func recordEventVersion(ctx context.Context, tx *db.Tx, eventID string, deleted bool) (int, error) {
+	var version int
+	err := tx.QueryRowContext(ctx, db.Rebind("SELECT COALESCE(MAX(version), 0) + 1 FROM event_versions WHERE event_id=?"), eventID).Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.ExecContext(ctx, db.Rebind("UPDATE events SET version=? WHERE id=?"), version, eventID)
+	if err != nil {
+		return 0, err
+	}
+	q := "INSERT INTO event_versions (event_id, version, recorded_at, deleted, " + versionedEventColumns + ") " +
+		"SELECT id, version, ?, ?, " + versionedEventColumns + " FROM events WHERE id=?"
+	_, err = tx.ExecContext(ctx, db.Rebind(q), time.Now(), deleted, eventID)
+	if err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// versionScanner scans an event row, whose version column is the number of the change,
+// followed by the other version columns.
+type versionScanner struct {
+	row     rowScanner
+	version *EventVersion
+}
+
+func (s versionScanner) Scan(dest ...any) error {
+	return s.row.Scan(append(dest, &s.version.RecordedAt, &s.version.Deleted)...)
+}
+
+// GetEventVersionAt retrieves the state of the event at the given time, which is its
+// latest version recorded at or before then. Deleted events keep their history; the
+// version recording a deletion has Deleted set.
+// Returns a NotFoundError if the event had no recorded state at that time.
+func GetEventVersionAt(ctx context.Context, id string, at time.Time) (EventVersion, error) {
+	q := "SELECT event_id, " + versionedEventColumns + ", version, recorded_at, deleted FROM event_versions " +
+		"WHERE event_id=? AND " + db.Timestamp("recorded_at") + " <= " + db.Timestamp("?") + " ORDER BY version DESC LIMIT 1"
+	var version EventVersion
+	var err error
+	version.Event, err = scanEvent(versionScanner{db.Conn(ctx).QueryRowContext(ctx, db.Rebind(q), id, at), &version})
+	if errors.Is(err, sql.ErrNoRows) {
+		return EventVersion{}, NotFoundError{Message: fmt.Sprint("Couldn't find an event with the ID of ", id, " as of ", at.Format(time.RFC3339)), Kind: ErrEventNotFound}
+	}
+	if err != nil {
+		return EventVersion{}, err
+	}
+	version.Version = version.Event.Version
+	return version, nil
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+// step waits long enough for changes to be told apart by their recording time and
+// returns the time between them
+func step() time.Time {
+	time.Sleep(5 * time.Millisecond)
+	at := time.Now()
+	time.Sleep(5 * time.Millisecond)
+	return at
+}
+
+// TestEventVersions tests reading the state of an event at points of its history
+func TestEventVersions(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+	before := step()
+
+	event, err := Event{Title: "Café Zürich", Description: "Original", Location: "Zürich", DateTime: time.Now().Add(24 * time.Hour), UserID: "user1"}.Save(ctx)
+	if err != nil {
+		t.Fatalf("Failed to save event: %v", err)
+	}
+	afterSave := step()
+
+	event.Description = "Updated"
+	if err := event.Update(ctx); err != nil {
+		t.Fatalf("Failed to update event: %v", err)
+	}
+	afterUpdate := step()
+
+	if err := event.Patch(ctx, map[string]any{"Title": "Café Basel"}); err != nil {
+		t.Fatalf("Failed to patch event: %v", err)
+	}
+	afterPatch := step()
+
+	if _, err := event.Cancel(ctx); err != nil {
+		t.Fatalf("Failed to cancel event: %v", err)
+	}
+	afterCancel := step()
+
+	if err := event.Delete(ctx); err != nil {
+		t.Fatalf("Failed to delete event: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		at          time.Time
+		version     int
+		title       string
+		description string
+		status      string
+	}{
+		{"after save", afterSave, 1, "Café Zürich", "Original", StatusPublished},
+		{"after update", afterUpdate, 2, "Café Zürich", "Updated", StatusPublished},
+		{"after patch", afterPatch, 3, "Café Basel", "Updated", StatusPublished},
+		{"after cancel", afterCancel, 4, "Café Basel", "Updated", StatusCancelled},
+	}
+	for _, tt := range tests {
+		version, err := GetEventVersionAt(ctx, event.ID, tt.at)
+		if err != nil {
+			t.Fatalf("%s: failed to get version: %v", tt.name, err)
+		}
+		if version.Version != tt.version || version.Title != tt.title || version.Description != tt.description || version.Status != tt.status || version.Deleted {
+			t.Errorf("%s: expected version %d %q/%q/%s, got %d %q/%q/%s (deleted %v)", tt.name,
+				tt.version, tt.title, tt.description, tt.status,
+				version.Version, version.Title, version.Description, version.Status, version.Deleted)
+		}
+		if version.ID != event.ID || version.UserID != "user1" {
+			t.Errorf("%s: expected the event's ID and organizer, got %q and %q", tt.name, version.ID, version.UserID)
+		}
+	}
+
+	deleted, err := GetEventVersionAt(ctx, event.ID, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to get version after deletion: %v", err)
+	}
+	if !deleted.Deleted || deleted.Version != 5 || deleted.Title != "Café Basel" {
+		t.Errorf("Expected version 5 to record the deletion with the final state, got %+v", deleted)
+	}
+
+	_, err = GetEventVersionAt(ctx, event.ID, before)
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected not found before the event was created, got %v", err)
+	}
+}
+
+// TestEventVersionsUnchangedCancel tests that cancelling a cancelled event records no version
+func TestEventVersionsUnchangedCancel(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+	event := saveEvent(t, Event{Title: "Cancelled Twice"})
+	for i := 0; i < 2; i++ {
+		if _, err := event.Cancel(ctx); err != nil {
+			t.Fatalf("Failed to cancel event: %v", err)
+		}
+	}
+	if err := event.SetReviewStatus(ctx, ReviewRejected, "spam"); err != nil {
+		t.Fatalf("Failed to set review status: %v", err)
+	}
+
+	version, err := GetEventVersionAt(ctx, event.ID, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to get version: %v", err)
+	}
+	if version.Version != 3 || version.ReviewStatus != ReviewRejected || version.ReviewReason != "spam" {
+		t.Errorf("Expected version 3 with the rejection, got version %d in %s (%q)", version.Version, version.ReviewStatus, version.ReviewReason)
+	}
+}
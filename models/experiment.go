@@ -0,0 +1,87 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"event_booking_restapi_golang/db"
+	"event_booking_restapi_golang/ids"
+	"time"
+)
+
+// Kinds of experiment log entries.
+const (
+	ExposureKind   = "exposure"   // A session was shown its variant
+	ConversionKind = "conversion" // An exposed session went on to book an event
+)
+
+// VariantResult summarizes how the sessions assigned to one experiment variant behaved.
+type VariantResult struct {
+	Variant        string  // Variant name, e.g. control or treatment
+	Sessions       int     // Number of distinct sessions exposed to the variant
+	Exposures      int     // Number of times the variant was served
+	Conversions    int     // Number of sessions that booked after being exposed
+	ConversionRate float64 // Conversions divided by Sessions
+}
+
+// LogExposure records that the session was served the given experiment variant.
+// Returns an error if the database operation fails.
+func LogExposure(ctx context.Context, experiment, variant, sessionID string) error {
+	q := "INSERT INTO experiment_events (id, experiment, variant, session_id, kind, created_at) VALUES (?,?,?,?,?,?)"
+	_, err := db.Conn(ctx).ExecContext(ctx, db.Rebind(q), ids.New(), experiment, variant, sessionID, ExposureKind, time.Now())
+	return err
+}
+
+// LogConversion records that the session booked the event, under the variant it was
+// last exposed to. Sessions that were never exposed to the experiment are ignored.
+// Returns an error if the database operation fails.
+func LogConversion(ctx context.Context, experiment, sessionID, eventID string) error {
+	var variant string
+	q := "SELECT variant FROM experiment_events WHERE experiment=? AND session_id=? AND kind=? ORDER BY created_at DESC LIMIT 1"
+	err := db.Conn(ctx).QueryRowContext(ctx, db.Rebind(q), experiment, sessionID, ExposureKind).Scan(&variant)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	q = "INSERT INTO experiment_events (id, experiment, variant, session_id, kind, event_id, created_at) VALUES (?,?,?,?,?,?,?)"
+	_, err = db.Conn(ctx).ExecContext(ctx, db.Rebind(q), ids.New(), experiment, variant, sessionID, ConversionKind, eventID, time.Now())
+	return err
+}
+
+// GetExperimentResults counts the sessions, exposures and converted sessions of each
+// variant of the experiment, ordered by variant name.
+// Returns a slice of VariantResult objects and any error encountered during the query.
+func GetExperimentResults(ctx context.Context, experiment string) ([]VariantResult, error) {
+	q := `
+	SELECT variant,
+		COUNT(DISTINCT CASE WHEN kind=? THEN session_id END),
+		COUNT(CASE WHEN kind=? THEN 1 END),
+		COUNT(DISTINCT CASE WHEN kind=? THEN session_id END)
+	FROM experiment_events
+	WHERE experiment=?
+	GROUP BY variant
+	ORDER BY variant
+	`
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q), ExposureKind, ExposureKind, ConversionKind, experiment)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []VariantResult
+	for rows.Next() {
+		var r VariantResult
+		err := rows.Scan(&r.Variant, &r.Sessions, &r.Exposures, &r.Conversions)
+		if err != nil {
+			return nil, err
+		}
+		if r.Sessions > 0 {
+			r.ConversionRate = float64(r.Conversions) / float64(r.Sessions)
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"context"
+	"testing"
+)
+
+// TestExperimentResults tests logging exposures and conversions and summarizing them per variant
+func TestExperimentResults(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+
+	exposures := []struct{ variant, session string }{
+		{"control", "a"}, {"control", "a"}, {"control", "b"},
+		{"treatment", "c"}, {"treatment", "d"},
+	}
+	for _, e := range exposures {
+		if err := LogExposure(ctx, "ranking", e.variant, e.session); err != nil {
+			t.Fatalf("Failed to log exposure: %v", err)
+		}
+	}
+	for _, session := range []string{"a", "c", "d", "never-exposed"} {
+		if err := LogConversion(ctx, "ranking", session, "event-1"); err != nil {
+			t.Fatalf("Failed to log conversion: %v", err)
+		}
+	}
+
+	results, err := GetExperimentResults(ctx, "ranking")
+	if err != nil {
+		t.Fatalf("Failed to get experiment results: %v", err)
+	}
+	expected := []VariantResult{
+		{Variant: "control", Sessions: 2, Exposures: 3, Conversions: 1, ConversionRate: 0.5},
+		{Variant: "treatment", Sessions: 2, Exposures: 2, Conversions: 2, ConversionRate: 1},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d variants, got %+v", len(expected), results)
+	}
+	for i := range expected {
+		if results[i] != expected[i] {
+			t.Errorf("Expected %+v, got %+v", expected[i], results[i])
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"context"
+	"event_booking_restapi_golang/db"
+)
+
+// eachRow runs the query and calls fn with each row as scan reads it, so that
+// exports of any size hold one row in memory at a time.
+// Stops at and returns the first error of the query, scan or fn.
+func eachRow[T any](ctx context.Context, q string, scan func(rowScanner) (T, error), fn func(T) error) error {
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scan(rows)
+		if err != nil {
+			return err
+		}
+		err = fn(item)
+		if err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// EachEvent calls fn with every stored event in ID order, drafts, cancelled events
+// and events under review included, reading them one at a time.
+// Stops at and returns the first error of the query or fn.
+func EachEvent(ctx context.Context, fn func(Event) error) error {
+	return eachRow(ctx, "SELECT "+eventColumns+" FROM events ORDER BY id", scanEvent, fn)
+}
+
+// EachRegistration calls fn with every registration, ordered by event and then in
+// booking order, reading them one at a time.
+// Stops at and returns the first error of the query or fn.
+func EachRegistration(ctx context.Context, fn func(Registration) error) error {
+	return eachRow(ctx, "SELECT "+registrationColumns+" FROM registrations ORDER BY event_id, created_at, id", scanRegistration, fn)
+}
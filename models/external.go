@@ -0,0 +1,134 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"event_booking_restapi_golang/db"
+	"event_booking_restapi_golang/ids"
+	"event_booking_restapi_golang/textnorm"
+	"fmt"
+	"time"
+)
+
+// GetEventByExternalID retrieves the event synced from source under the given external ID.
+// Returns a NotFoundError when no event has it.
+func GetEventByExternalID(ctx context.Context, source, externalID string) (Event, error) {
+	q := "SELECT " + eventColumns + " FROM events WHERE external_source=? AND external_id=?"
+	event, err := scanEvent(db.Conn(ctx).QueryRowContext(ctx, db.Rebind(q), source, externalID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Event{}, NotFoundError{Message: fmt.Sprint("Couldn't find an event with the external ID of ", externalID, " from ", source), Kind: ErrEventNotFound}
+	}
+	if err != nil {
+		return Event{}, err
+	}
+	return event, nil
+}
+
+// UpsertByExternalID stores the event under its Source and ExternalID: it creates the
+// event when the source hasn't synced it before and otherwise updates the existing one,
+// keeping its ID, organizer and creation time. Events synced without a status are created
+// as published and keep their status on updates. The insert gives way to a concurrent sync
+// of the same event through the unique index, so two syncs never create duplicates.
+// Returns the stored event and whether it was created.
+func (e Event) UpsertByExternalID(ctx context.Context) (Event, bool, error) {
+	if e.ID == "" {
+		e.ID = ids.New()
+	}
+	if e.ReviewStatus == "" {
+		e.ReviewStatus = ReviewApproved
+	}
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+	status := e.Status
+	if status == "" {
+		status = StatusPublished
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return Event{}, false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(
+		ctx,
+		db.Rebind("INSERT INTO events (id, name, description, datetime, user_id, location, capacity, review_status, review_reason, created_at, confirmation_url, confirmation_message, external_id, external_source, end_datetime, status, slug, license) "+
+			"VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?) ON CONFLICT (external_source, external_id) WHERE external_id <> '' DO NOTHING"),
+		e.ID, e.Title, e.Description, e.DateTime, e.UserID, e.Location, e.Capacity, e.ReviewStatus, e.ReviewReason, e.CreatedAt,
+		e.ConfirmationURL, e.ConfirmationMessage, e.ExternalID, e.Source, e.EndDateTime, status, textnorm.Slug(e.Title), e.License,
+	)
+	if err != nil {
+		return Event{}, false, err
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return Event{}, false, err
+	}
+	if inserted == 0 {
+		_, err = tx.ExecContext(
+			ctx,
+			db.Rebind("UPDATE events SET name=?, description=?, datetime=?, end_datetime=?, location=?, capacity=?, review_status=?, review_reason=?, confirmation_url=?, confirmation_message=?, status=COALESCE(NULLIF(?, ''), status), slug=?, license=? "+
+				"WHERE external_source=? AND external_id=?"),
+			e.Title, e.Description, e.DateTime, e.EndDateTime, e.Location, e.Capacity, e.ReviewStatus, e.ReviewReason, e.ConfirmationURL, e.ConfirmationMessage, e.Status, textnorm.Slug(e.Title), e.License,
+			e.Source, e.ExternalID,
+		)
+		if err != nil {
+			return Event{}, false, err
+		}
+	}
+	q := "SELECT " + eventColumns + " FROM events WHERE external_source=? AND external_id=?"
+	stored, err := scanEvent(tx.QueryRowContext(ctx, db.Rebind(q), e.Source, e.ExternalID))
+	if err != nil {
+		return Event{}, false, err
+	}
+	stored.Version, err = recordEventVersion(ctx, tx, stored.ID, false)
+	if err != nil {
+		return Event{}, false, err
+	}
+
+	err = commit(ctx, tx)
+	if err != nil {
+		return Event{}, false, err
+	}
+	return stored, inserted > 0, nil
+}
+
+// GetRegistrationByExternalID retrieves the registration synced from source under the
+// given external ID. Returns a NotFoundError when no registration has it.
+func GetRegistrationByExternalID(ctx context.Context, source, externalID string) (Registration, error) {
+	q := "SELECT " + registrationColumns + " FROM registrations WHERE external_source=? AND external_id=?"
+	r, err := scanRegistration(db.Conn(ctx).QueryRowContext(ctx, db.Rebind(q), source, externalID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Registration{}, NotFoundError{Message: fmt.Sprint("Couldn't find a registration with the external ID of ", externalID, " from ", source)}
+	}
+	if err != nil {
+		return Registration{}, err
+	}
+	return r, nil
+}
+
+// UpdateAttendee stores a new name and email address for the registration.
+// Returns ErrAlreadyRegistered when another registration for the event holds the email address.
+func (r Registration) UpdateAttendee(ctx context.Context, name, email string) (Registration, error) {
+	var existing int
+	err := db.Conn(ctx).QueryRowContext(
+		ctx,
+		db.Rebind("SELECT COUNT(*) FROM registrations WHERE event_id=? AND email=? AND id<>?"),
+		r.EventID, email, r.ID,
+	).Scan(&existing)
+	if err != nil {
+		return Registration{}, err
+	}
+	if existing > 0 {
+		return Registration{}, ErrAlreadyRegistered
+	}
+
+	_, err = db.Conn(ctx).ExecContext(ctx, db.Rebind("UPDATE registrations SET name=?, email=? WHERE id=?"), name, email, r.ID)
+	if err != nil {
+		return Registration{}, err
+	}
+	r.Name, r.Email = name, email
+	return r, nil
+}
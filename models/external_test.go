@@ -0,0 +1,97 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"event_booking_restapi_golang/db"
+	"testing"
+	"time"
+)
+
+// TestExternalIDs tests looking up synced events and registrations by their external IDs
+func TestExternalIDs(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+	event := Event{Title: "Jazz Night", Description: "Live jazz", Location: "Club", DateTime: time.Now(), ExternalID: "evt-1", Source: "tickets"}
+	event, err := event.Save(ctx)
+	if err != nil {
+		t.Fatalf("Failed to save event: %v", err)
+	}
+	event, err = GetEventByExternalID(ctx, "tickets", "evt-1")
+	if err != nil || event.Title != "Jazz Night" || event.ExternalID != "evt-1" || event.Source != "tickets" {
+		t.Fatalf("Expected the synced event, got %+v (%v)", event, err)
+	}
+	_, err = GetEventByExternalID(ctx, "other", "evt-1")
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected external IDs to be looked up per source, got %v", err)
+	}
+
+	duplicate := Event{Title: "Copy", Description: "Live jazz", Location: "Club", DateTime: time.Now(), ExternalID: "evt-1", Source: "tickets"}
+	if _, err := duplicate.Save(ctx); err == nil {
+		t.Error("Expected a second event with the same external ID from the source to be refused")
+	}
+	for _, title := range []string{"Local One", "Local Two"} {
+		local := Event{Title: title, Description: "Live jazz", Location: "Club", DateTime: time.Now()}
+		if _, err := local.Save(ctx); err != nil {
+			t.Errorf("Expected events without an external ID to be saved, got %v", err)
+		}
+	}
+
+	registration, err := event.Register(ctx, Registration{Name: "Alice", Email: "alice@example.com", ExternalID: "tkt-1", Source: "tickets"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	found, err := GetRegistrationByExternalID(ctx, "tickets", "tkt-1")
+	if err != nil || found.ID != registration.ID || found.Source != "tickets" {
+		t.Fatalf("Expected the synced registration, got %+v (%v)", found, err)
+	}
+
+	_, err = event.Register(ctx, Registration{Name: "Bob", Email: "bob@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	_, err = found.UpdateAttendee(ctx, "Alice Smith", "bob@example.com")
+	if !errors.Is(err, ErrAlreadyRegistered) {
+		t.Errorf("Expected ErrAlreadyRegistered, got %v", err)
+	}
+	updated, err := found.UpdateAttendee(ctx, "Alice Smith", "alice.smith@example.com")
+	if err != nil || updated.Name != "Alice Smith" {
+		t.Fatalf("Failed to update the attendee: %v", err)
+	}
+	stored, _ := GetRegistrationById(ctx, registration.ID)
+	if stored.Email != "alice.smith@example.com" || stored.ExternalID != "tkt-1" {
+		t.Errorf("Expected the new email with the external ID kept, got %+v", stored)
+	}
+}
+
+// TestUpsertByExternalID tests creating and then updating an event by its external ID
+func TestUpsertByExternalID(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+	event := Event{Title: "Jazz Night", Description: "Live jazz", Location: "Club", DateTime: time.Now(), UserID: "organizer-1", ExternalID: "evt-1", Source: "tickets"}
+
+	created, wasCreated, err := event.UpsertByExternalID(ctx)
+	if err != nil || !wasCreated {
+		t.Fatalf("Expected the event to be created, got %v (%v)", wasCreated, err)
+	}
+	if created.ID == "" || created.ReviewStatus != ReviewApproved {
+		t.Errorf("Unexpected stored event %+v", created)
+	}
+
+	event.ID, event.UserID = "", "organizer-2"
+	event.Title, event.Capacity = "Jazz Night (late show)", 50
+	updated, wasCreated, err := event.UpsertByExternalID(ctx)
+	if err != nil || wasCreated {
+		t.Fatalf("Expected the event to be updated, got %v (%v)", wasCreated, err)
+	}
+	if updated.ID != created.ID || updated.UserID != "organizer-1" || updated.Title != "Jazz Night (late show)" || updated.Capacity != 50 {
+		t.Errorf("Expected the event to be updated in place, got %+v", updated)
+	}
+
+	var count int
+	db.DB.QueryRow("SELECT COUNT(*) FROM events").Scan(&count)
+	if count != 1 {
+		t.Errorf("Expected 1 event, got %d", count)
+	}
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/db"
+	"event_booking_restapi_golang/ids"
+	"time"
+)
+
+// maxFeedRunSkips caps the skipped items recorded per run, so a broken feed can't
+// store an unbounded report.
+const maxFeedRunSkips = 100
+
+// FeedRun is the report of one import of an event feed.
+type FeedRun struct {
+	ID         string     // Unique identifier for the run
+	Feed       string     // Name of the configured feed
+	StartedAt  time.Time  // When the import started
+	FinishedAt time.Time  // When the import finished
+	Added      int        // Events created from the feed
+	Updated    int        // Existing events changed by the feed
+	Skipped    int        // Items left out because they were unchanged or invalid
+	Error      string     // Why the feed couldn't be imported, empty when it was
+	Skips      []FeedSkip // Invalid items and why they were left out, the first 100
+}
+
+// FeedSkip records an invalid feed item that wasn't imported.
+type FeedSkip struct {
+	Item       int    // Position of the item in the feed, starting at 1
+	ExternalID string // ID of the item in the feed, empty when it has none
+	Reason     string
+}
+
+// AddSkip counts an invalid item and records why it was left out.
+func (r *FeedRun) AddSkip(item int, externalID, reason string) {
+	r.Skipped++
+	if len(r.Skips) < maxFeedRunSkips {
+		r.Skips = append(r.Skips, FeedSkip{Item: item, ExternalID: externalID, Reason: reason})
+	}
+}
+
+// Save stores the run report, generating its ID.
+// Returns the stored report.
+func (r FeedRun) Save(ctx context.Context) (FeedRun, error) {
+	if r.Skips == nil {
+		r.Skips = []FeedSkip{}
+	}
+	encoded, err := json.Marshal(r.Skips)
+	if err != nil {
+		return FeedRun{}, err
+	}
+	r.ID = ids.New()
+	_, err = db.Conn(ctx).ExecContext(
+		ctx,
+		db.Rebind("INSERT INTO feed_runs (id, feed, started_at, finished_at, added, updated, skipped, error, skips) VALUES (?,?,?,?,?,?,?,?,?)"),
+		r.ID, r.Feed, r.StartedAt.UTC(), r.FinishedAt.UTC(), r.Added, r.Updated, r.Skipped, r.Error, string(encoded),
+	)
+	if err != nil {
+		return FeedRun{}, err
+	}
+	return r, nil
+}
+
+// GetFeedRuns returns a page of run reports, newest first, of the named feed or of all
+// feeds when feed is empty.
+func GetFeedRuns(ctx context.Context, feed string, limit, offset int) ([]FeedRun, error) {
+	q := "SELECT id, feed, started_at, finished_at, added, updated, skipped, error, skips FROM feed_runs"
+	args := []any{}
+	if feed != "" {
+		q += " WHERE feed=?"
+		args = append(args, feed)
+	}
+	q += " ORDER BY " + db.Timestamp("started_at") + " DESC, id LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []FeedRun{}
+	for rows.Next() {
+		var run FeedRun
+		var skips string
+		err = rows.Scan(&run.ID, &run.Feed, &run.StartedAt, &run.FinishedAt, &run.Added, &run.Updated, &run.Skipped, &run.Error, &skips)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal([]byte(skips), &run.Skips)
+		if err != nil {
+			return nil, err
+		}
+		run.StartedAt, run.FinishedAt = run.StartedAt.UTC(), run.FinishedAt.UTC()
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestFeedRuns tests storing feed import reports and listing them newest first
+func TestFeedRuns(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+	start := time.Date(2030, 6, 1, 3, 0, 0, 0, time.UTC)
+
+	for i, feed := range []string{"city", "portal", "city"} {
+		run := FeedRun{Feed: feed, StartedAt: start.Add(time.Duration(i) * time.Hour), Added: i}
+		run.FinishedAt = run.StartedAt.Add(time.Minute)
+		if i == 2 {
+			run.AddSkip(4, "e4", "the item has no title")
+			run.Skipped++
+		}
+		_, err := run.Save(ctx)
+		if err != nil {
+			t.Fatalf("Failed to save feed run: %v", err)
+		}
+	}
+
+	runs, err := GetFeedRuns(ctx, "city", 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get feed runs: %v", err)
+	}
+	if len(runs) != 2 || runs[0].Added != 2 || runs[1].Added != 0 {
+		t.Fatalf("Expected the city runs newest first, got %+v", runs)
+	}
+	latest := runs[0]
+	if latest.Skipped != 2 || len(latest.Skips) != 1 || latest.Skips[0] != (FeedSkip{Item: 4, ExternalID: "e4", Reason: "the item has no title"}) {
+		t.Errorf("Expected one listed and one unlisted skip, got %d and %+v", latest.Skipped, latest.Skips)
+	}
+	if !latest.StartedAt.Equal(start.Add(2*time.Hour)) || !latest.FinishedAt.Equal(start.Add(2*time.Hour+time.Minute)) {
+		t.Errorf("Unexpected run times %s and %s", latest.StartedAt, latest.FinishedAt)
+	}
+	if runs[1].Skips == nil {
+		t.Error("Expected an empty skip list rather than nil")
+	}
+
+	runs, err = GetFeedRuns(ctx, "", 1, 1)
+	if err != nil || len(runs) != 1 || runs[0].Feed != "portal" {
+		t.Errorf("Expected the second newest run of all feeds, got %+v (%v)", runs, err)
+	}
+}
+
+// TestFeedRunAddSkipCap tests that only the first skipped items are listed
+func TestFeedRunAddSkipCap(t *testing.T) {
+	var run FeedRun
+	for i := 1; i <= maxFeedRunSkips+5; i++ {
+		run.AddSkip(i, "", "the item has no ID")
+	}
+	if run.Skipped != maxFeedRunSkips+5 || len(run.Skips) != maxFeedRunSkips {
+		t.Errorf("Expected %d skips with %d listed, got %d with %d", maxFeedRunSkips+5, maxFeedRunSkips, run.Skipped, len(run.Skips))
+	}
+}
@@ -0,0 +1,208 @@
+package models
+
+import (
+	"context"
+	"event_booking_restapi_golang/db"
+	"math"
+	"time"
+)
+
+// Forecast methods, from the most to the least informed.
+const (
+	ForecastRegression = "regression" // Fitted on the sales of similar past events
+	ForecastVelocity   = "velocity"   // Current sales velocity carried forward, too few past events to compare with
+	ForecastFinal      = "final"      // The event has started, so its bookings are final
+)
+
+const (
+	// forecastWindow is how far back bookings are counted for the sales velocity.
+	forecastWindow = 7 * 24 * time.Hour
+	// forecastHistory is how far back past events are compared with.
+	forecastHistory = 2 * 365 * 24 * time.Hour
+	// minComparableEvents is how many past events a regression needs. With fewer, the
+	// forecast falls back to the sales velocity.
+	minComparableEvents = 3
+)
+
+// tQuantiles are the 97.5% quantiles of Student's t distribution by degrees of freedom,
+// for 95% prediction intervals. More degrees of freedom use the normal quantile.
+var tQuantiles = []float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+// Forecast is the projected final attendance of an event, with a 95% prediction interval.
+type Forecast struct {
+	Registered       int     // Bookings held now
+	Capacity         int     // Capacity of the event, 0 for unlimited
+	Velocity         float64 // Bookings per day over the last 7 days
+	DaysLeft         float64 // Days until the event starts
+	Projected        int     // Projected bookings when the event starts
+	Lower            int     // Lower bound of the projection
+	Upper            int     // Upper bound of the projection
+	Method           string  // How the projection was made, see ForecastRegression
+	ComparableEvents int     // Past events the regression was fitted on
+}
+
+// pastSales are the bookings of a past event as the summary tables saw them at the
+// lead time of the forecast event.
+type pastSales struct {
+	organizerID string
+	velocity    float64 // Bookings per day in the 7 days before the lead time
+	remaining   float64 // Bookings made after the lead time
+}
+
+// Forecast projects the event's final attendance at now. The bookings still to come
+// are regressed on the sales velocity over similar past events, those that started
+// within the last two years and weren't cancelled, preferring the organizer's own
+// when there are enough of them. Past sales are read from registration_daily_summary,
+// so they are as of the last summary refresh.
+// Without enough past events the velocity is carried forward to the start of the event.
+// Projections never fall below the bookings held or exceed the capacity.
+func (e Event) Forecast(ctx context.Context, now time.Time) (Forecast, error) {
+	f := Forecast{Capacity: e.Capacity, Method: ForecastFinal}
+	var err error
+	f.Registered, err = e.CountRegistrations(ctx)
+	if err != nil {
+		return Forecast{}, err
+	}
+	if !e.DateTime.After(now) {
+		f.Projected, f.Lower, f.Upper = f.Registered, f.Registered, f.Registered
+		return f, nil
+	}
+	recent, err := e.CountRegistrationsSince(ctx, now.Add(-forecastWindow))
+	if err != nil {
+		return Forecast{}, err
+	}
+	f.Velocity = float64(recent) / (forecastWindow.Hours() / 24)
+	f.DaysLeft = e.DateTime.Sub(now).Hours() / 24
+
+	past, err := e.comparableSales(ctx, now, int(math.Ceil(f.DaysLeft)))
+	if err != nil {
+		return Forecast{}, err
+	}
+	var remaining, margin float64
+	if len(past) >= minComparableEvents {
+		f.Method, f.ComparableEvents = ForecastRegression, len(past)
+		remaining, margin = regress(past, f.Velocity)
+	} else {
+		f.Method = ForecastVelocity
+		remaining = f.Velocity * f.DaysLeft
+		// Bookings arriving at a steady rate are roughly Poisson distributed
+		margin = 1.96 * math.Sqrt(remaining)
+	}
+
+	bound := func(v float64) int {
+		n := f.Registered + int(math.Round(math.Max(v, 0)))
+		if f.Capacity > 0 && n > f.Capacity {
+			n = f.Capacity
+		}
+		return n
+	}
+	f.Projected, f.Lower, f.Upper = bound(remaining), bound(remaining-margin), bound(remaining+margin)
+	return f, nil
+}
+
+// comparableSales reads the sales of the past events to compare the event with, at
+// leadDays days before each started. The organizer's own events are used when there
+// are enough of them, otherwise all past events.
+func (e Event) comparableSales(ctx context.Context, now time.Time, leadDays int) ([]pastSales, error) {
+	q := `
+	SELECT e.id, e.user_id, e.datetime, s.day, s.registrations
+	FROM events e JOIN registration_daily_summary s ON s.event_id = e.id
+	WHERE e.id <> ? AND e.status <> ? AND ` + db.Timestamp("e.datetime") + ` < ` + db.Timestamp("?") + ` AND ` + db.Timestamp("e.datetime") + ` >= ` + db.Timestamp("?") + `
+	ORDER BY e.id, s.day
+	`
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q), e.ID, StatusCancelled, now.UTC(), now.Add(-forecastHistory).UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var all []pastSales
+	var current *pastSales
+	currentID := ""
+	for rows.Next() {
+		var id, day string
+		var organizerID *string
+		var start time.Time
+		var registrations int
+		err := rows.Scan(&id, &organizerID, &start, &day, &registrations)
+		if err != nil {
+			return nil, err
+		}
+		if id != currentID {
+			all = append(all, pastSales{})
+			current, currentID = &all[len(all)-1], id
+			if organizerID != nil {
+				current.organizerID = *organizerID
+			}
+		}
+		// Days are compared as YYYY-MM-DD strings, which sort by date
+		cutoff := start.UTC().AddDate(0, 0, -leadDays).Format(time.DateOnly)
+		windowStart := start.UTC().AddDate(0, 0, -leadDays-7).Format(time.DateOnly)
+		switch {
+		case day >= cutoff:
+			current.remaining += float64(registrations)
+		case day >= windowStart:
+			current.velocity += float64(registrations) / 7
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var own []pastSales
+	for _, p := range all {
+		if e.UserID != "" && p.organizerID == e.UserID {
+			own = append(own, p)
+		}
+	}
+	if len(own) >= minComparableEvents {
+		return own, nil
+	}
+	return all, nil
+}
+
+// regress fits the bookings still to come on the sales velocity of the past events by
+// least squares, and returns the prediction at velocity with the half-width of its 95%
+// prediction interval. When all past events sold at the same velocity, their mean is used.
+func regress(past []pastSales, velocity float64) (float64, float64) {
+	n := float64(len(past))
+	var meanX, meanY float64
+	for _, p := range past {
+		meanX += p.velocity / n
+		meanY += p.remaining / n
+	}
+	var sxx, sxy float64
+	for _, p := range past {
+		sxx += (p.velocity - meanX) * (p.velocity - meanX)
+		sxy += (p.velocity - meanX) * (p.remaining - meanY)
+	}
+	slope := 0.0
+	if sxx > 0 {
+		slope = sxy / sxx
+	}
+	intercept := meanY - slope*meanX
+
+	var residuals float64
+	for _, p := range past {
+		r := p.remaining - intercept - slope*p.velocity
+		residuals += r * r
+	}
+	df := len(past) - 2
+	if sxx == 0 {
+		df = len(past) - 1
+	}
+	stderr := math.Sqrt(residuals / float64(df))
+	leverage := 1 + 1/n
+	if sxx > 0 {
+		leverage += (velocity - meanX) * (velocity - meanX) / sxx
+	}
+	t := 1.96
+	if df <= len(tQuantiles) {
+		t = tQuantiles[df-1]
+	}
+	return intercept + slope*velocity, t * stderr * math.Sqrt(leverage)
+}
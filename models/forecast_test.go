@@ -0,0 +1,89 @@
+package models
+
+import (
+	"context"
+	"event_booking_restapi_golang/db"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestForecast tests projecting attendance from the sales velocity, first alone and
+// then by regression over similar past events
+func TestForecast(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	event, err := Event{Title: "Upcoming", Location: "Hall", DateTime: now.Add(10 * 24 * time.Hour), UserID: "org-1"}.Save(ctx)
+	if err != nil {
+		t.Fatalf("Failed to save event: %v", err)
+	}
+	for i := 0; i < 14; i++ {
+		_, err := event.Register(ctx, Registration{Name: "Attendee", Email: fmt.Sprintf("attendee%d@example.com", i)})
+		if err != nil {
+			t.Fatalf("Failed to register: %v", err)
+		}
+	}
+
+	forecast, err := event.Forecast(ctx, now)
+	if err != nil {
+		t.Fatalf("Failed to forecast: %v", err)
+	}
+	// 2 bookings a day for the 10 days left
+	if forecast.Method != ForecastVelocity || forecast.Registered != 14 || forecast.Velocity != 2 || forecast.Projected != 34 {
+		t.Errorf("Expected the velocity to be carried forward, got %+v", forecast)
+	}
+	if forecast.Lower >= forecast.Projected || forecast.Upper <= forecast.Projected {
+		t.Errorf("Expected bounds around the projection, got %+v", forecast)
+	}
+
+	// Past events selling 1 to 4 bookings a day ten days out, and 10 to 40 after that
+	for i, remaining := range []int{10, 22, 28, 40} {
+		start := now.Add(-time.Duration(i+1) * 30 * 24 * time.Hour)
+		past, err := Event{Title: fmt.Sprint("Past ", i), Location: "Hall", DateTime: start, UserID: "org-1"}.Save(ctx)
+		if err != nil {
+			t.Fatalf("Failed to save past event: %v", err)
+		}
+		for _, sales := range []struct {
+			daysBefore, registrations int
+		}{{14, (i + 1) * 7}, {5, remaining}} {
+			day := start.UTC().AddDate(0, 0, -sales.daysBefore).Format(time.DateOnly)
+			_, err := db.DB.Exec("INSERT INTO registration_daily_summary (event_id, day, registrations) VALUES (?, ?, ?)", past.ID, day, sales.registrations)
+			if err != nil {
+				t.Fatalf("Failed to insert summary: %v", err)
+			}
+		}
+	}
+	// Cancelled events aren't compared with
+	cancelled, err := Event{Title: "Cancelled", Location: "Hall", DateTime: now.Add(-24 * time.Hour), UserID: "org-1", Status: StatusCancelled}.Save(ctx)
+	if err != nil {
+		t.Fatalf("Failed to save cancelled event: %v", err)
+	}
+	_, err = db.DB.Exec("INSERT INTO registration_daily_summary (event_id, day, registrations) VALUES (?, ?, ?)", cancelled.ID, now.UTC().Format(time.DateOnly), 500)
+	if err != nil {
+		t.Fatalf("Failed to insert summary: %v", err)
+	}
+
+	forecast, err = event.Forecast(ctx, now)
+	if err != nil {
+		t.Fatalf("Failed to forecast: %v", err)
+	}
+	// The fitted line is 1 + 9.6 bookings per unit of velocity, so 20 more at 2 a day
+	if forecast.Method != ForecastRegression || forecast.ComparableEvents != 4 || forecast.Projected != 34 {
+		t.Errorf("Expected a regression over the 4 past events, got %+v", forecast)
+	}
+	if forecast.Lower < forecast.Registered || forecast.Lower >= forecast.Projected || forecast.Upper <= forecast.Projected {
+		t.Errorf("Expected bounds around the projection, got %+v", forecast)
+	}
+
+	event.Capacity = 30
+	forecast, err = event.Forecast(ctx, now)
+	if err != nil || forecast.Projected != 30 || forecast.Upper != 30 {
+		t.Errorf("Expected the projection to stop at the capacity, got %+v (%v)", forecast, err)
+	}
+	forecast, err = event.Forecast(ctx, event.DateTime.Add(time.Hour))
+	if err != nil || forecast.Method != ForecastFinal || forecast.Projected != 14 || forecast.Lower != 14 || forecast.Upper != 14 {
+		t.Errorf("Expected the bookings to be final once the event started, got %+v (%v)", forecast, err)
+	}
+}
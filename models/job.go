@@ -0,0 +1,161 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"event_booking_restapi_golang/db"
+	"event_booking_restapi_golang/ids"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Job statuses. Jobs that succeed are deleted, so only the ones still to run and the
+// ones that gave up are kept.
+const (
+	JobPending = "pending" // Waiting for its run time and a free worker
+	JobRunning = "running" // Claimed by a worker
+	JobFailed  = "failed"  // Gave up after its last attempt failed
+)
+
+// maxClaimTries bounds how often ClaimJob looks for another job when the one it picked
+// was claimed by a concurrent worker first.
+const maxClaimTries = 5
+
+// Job is a unit of background work, such as an email to deliver, kept in the jobs
+// table until it succeeds so it survives restarts.
+type Job struct {
+	ID        string    // Unique identifier for the job
+	Kind      string    // Which handler runs the job, e.g. "email"
+	Payload   []byte    // JSON the handler runs the job with
+	Status    string    // One of the job statuses, e.g. JobPending
+	Attempts  int       // Times a worker claimed the job
+	RunAt     time.Time // When the job is due, later for retries
+	LastError string    // Why the last attempt failed, empty if none did
+	RequestID string    // ID of the request that enqueued the job, for logs
+	CreatedAt time.Time
+}
+
+// EnqueueJob stores a pending job of the kind, due at runAt.
+// Returns the stored job.
+func EnqueueJob(ctx context.Context, kind string, payload []byte, runAt time.Time, requestID string) (Job, error) {
+	job := Job{
+		ID:        ids.New(),
+		Kind:      kind,
+		Payload:   payload,
+		Status:    JobPending,
+		RunAt:     runAt.UTC(),
+		RequestID: requestID,
+		CreatedAt: time.Now().UTC(),
+	}
+	_, err := db.Conn(ctx).ExecContext(
+		ctx,
+		db.Rebind("INSERT INTO jobs (id, kind, payload, status, run_at, request_id, created_at) VALUES (?,?,?,?,?,?,?)"),
+		job.ID, job.Kind, string(job.Payload), job.Status, job.RunAt, job.RequestID, job.CreatedAt,
+	)
+	if err != nil {
+		return Job{}, err
+	}
+	return job, nil
+}
+
+// ClaimJob marks the earliest pending job of one of the kinds that is due at now as
+// running and counts the attempt, so no other worker runs it.
+// Returns false if no job is due.
+func ClaimJob(ctx context.Context, kinds []string, now time.Time) (Job, bool, error) {
+	if len(kinds) == 0 {
+		return Job{}, false, nil
+	}
+	q := "SELECT id FROM jobs WHERE status=? AND " + db.Timestamp("run_at") + " <= " + db.Timestamp("?") + " AND kind IN (?" + strings.Repeat(",?", len(kinds)-1) + ")" +
+		" ORDER BY " + db.Timestamp("run_at") + ", id LIMIT 1"
+	args := []any{JobPending, now.UTC()}
+	for _, kind := range kinds {
+		args = append(args, kind)
+	}
+	for try := 0; try < maxClaimTries; try++ {
+		var id string
+		err := db.Conn(ctx).QueryRowContext(ctx, db.Rebind(q), args...).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return Job{}, false, nil
+		}
+		if err != nil {
+			return Job{}, false, err
+		}
+		res, err := db.Conn(ctx).ExecContext(ctx, db.Rebind("UPDATE jobs SET status=?, attempts=attempts+1, locked_at=? WHERE id=? AND status=?"),
+			JobRunning, now.UTC(), id, JobPending)
+		if err != nil {
+			return Job{}, false, err
+		}
+		if claimed, err := res.RowsAffected(); err != nil || claimed == 0 {
+			continue
+		}
+		job, err := GetJob(ctx, id)
+		return job, err == nil, err
+	}
+	return Job{}, false, nil
+}
+
+// GetJob returns the job with the ID.
+// Returns a NotFoundError if there is no such job, e.g. because it succeeded.
+func GetJob(ctx context.Context, id string) (Job, error) {
+	var job Job
+	var payload string
+	err := db.Conn(ctx).QueryRowContext(ctx, db.Rebind("SELECT id, kind, payload, status, attempts, run_at, last_error, request_id, created_at FROM jobs WHERE id=?"), id).
+		Scan(&job.ID, &job.Kind, &payload, &job.Status, &job.Attempts, &job.RunAt, &job.LastError, &job.RequestID, &job.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Job{}, NotFoundError{Message: fmt.Sprint("Couldn't find a job with the ID of ", id)}
+	}
+	job.Payload = []byte(payload)
+	return job, err
+}
+
+// CompleteJob deletes the job once it succeeded.
+func CompleteJob(ctx context.Context, id string) error {
+	_, err := db.Conn(ctx).ExecContext(ctx, db.Rebind("DELETE FROM jobs WHERE id=?"), id)
+	return err
+}
+
+// RetryJob records why the job's attempt failed and makes it pending again, due at runAt.
+func RetryJob(ctx context.Context, id string, runAt time.Time, reason string) error {
+	_, err := db.Conn(ctx).ExecContext(ctx, db.Rebind("UPDATE jobs SET status=?, run_at=?, locked_at=NULL, last_error=? WHERE id=?"),
+		JobPending, runAt.UTC(), reason, id)
+	return err
+}
+
+// FailJob records why the job's last attempt failed and keeps it as JobFailed.
+func FailJob(ctx context.Context, id string, reason string) error {
+	_, err := db.Conn(ctx).ExecContext(ctx, db.Rebind("UPDATE jobs SET status=?, locked_at=NULL, last_error=? WHERE id=?"),
+		JobFailed, reason, id)
+	return err
+}
+
+// ReleaseStaleJobs makes the jobs claimed before lockedBefore pending again, as their
+// worker stopped before finishing them, e.g. because the server was restarted.
+// Returns the number of jobs released.
+func ReleaseStaleJobs(ctx context.Context, lockedBefore time.Time) (int, error) {
+	q := "UPDATE jobs SET status=?, locked_at=NULL WHERE status=? AND " + db.Timestamp("locked_at") + " < " + db.Timestamp("?")
+	res, err := db.Conn(ctx).ExecContext(ctx, db.Rebind(q), JobPending, JobRunning, lockedBefore.UTC())
+	if err != nil {
+		return 0, err
+	}
+	released, err := res.RowsAffected()
+	return int(released), err
+}
+
+// OldestDueJob returns the time the pending job that has been due the longest was due
+// to run, for telling whether the workers keep up.
+// Returns false when no pending job is due before now.
+func OldestDueJob(ctx context.Context, now time.Time) (time.Time, bool, error) {
+	q := "SELECT run_at FROM jobs WHERE status=? AND " + db.Timestamp("run_at") + " <= " + db.Timestamp("?") +
+		" ORDER BY " + db.Timestamp("run_at") + " LIMIT 1"
+	var runAt time.Time
+	err := db.Conn(ctx).QueryRowContext(ctx, db.Rebind(q), JobPending, now.UTC()).Scan(&runAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return runAt, true, nil
+}
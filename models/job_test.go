@@ -0,0 +1,75 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestClaimJob tests that due jobs of the given kinds are claimed once, earliest first
+func TestClaimJob(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+	now := time.Now()
+	later, err := EnqueueJob(ctx, "email", []byte(`"later"`), now.Add(time.Minute), "req-1")
+	if err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+	first, _ := EnqueueJob(ctx, "email", []byte(`"first"`), now.Add(-time.Minute), "req-2")
+	EnqueueJob(ctx, "alert", []byte(`"alert"`), now.Add(-time.Hour), "")
+
+	job, ok, err := ClaimJob(ctx, []string{"email"}, now)
+	if err != nil || !ok || job.ID != first.ID || job.Status != JobRunning || job.Attempts != 1 || string(job.Payload) != `"first"` || job.RequestID != "req-2" {
+		t.Fatalf("Expected the due email job to be claimed, got %+v, %v, %v", job, ok, err)
+	}
+	if _, ok, _ := ClaimJob(ctx, []string{"email"}, now); ok {
+		t.Error("Expected the claimed job and the job not yet due to be left alone")
+	}
+	if job, ok, _ := ClaimJob(ctx, []string{"email"}, now.Add(2*time.Minute)); !ok || job.ID != later.ID {
+		t.Errorf("Expected the later job once due, got %+v", job)
+	}
+	if _, ok, _ := ClaimJob(ctx, nil, now); ok {
+		t.Error("Expected nothing to be claimed without kinds")
+	}
+
+	if err := CompleteJob(ctx, first.ID); err != nil {
+		t.Fatalf("Failed to complete the job: %v", err)
+	}
+	if _, err := GetJob(ctx, first.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected the completed job to be deleted, got %v", err)
+	}
+}
+
+// TestRetryAndReleaseJobs tests retries, failures and releasing jobs of workers that stopped
+func TestRetryAndReleaseJobs(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+	now := time.Now()
+	job, _ := EnqueueJob(ctx, "email", []byte(`{}`), now, "")
+	ClaimJob(ctx, []string{"email"}, now)
+
+	if released, err := ReleaseStaleJobs(ctx, now.Add(-time.Minute)); err != nil || released != 0 {
+		t.Errorf("Expected a recently claimed job to be kept, got %d, %v", released, err)
+	}
+	if released, err := ReleaseStaleJobs(ctx, now.Add(time.Minute)); err != nil || released != 1 {
+		t.Errorf("Expected the stale job to be released, got %d, %v", released, err)
+	}
+
+	ClaimJob(ctx, []string{"email"}, now)
+	if err := RetryJob(ctx, job.ID, now.Add(time.Hour), "relay down"); err != nil {
+		t.Fatalf("Failed to retry the job: %v", err)
+	}
+	got, _ := GetJob(ctx, job.ID)
+	if got.Status != JobPending || got.Attempts != 2 || got.LastError != "relay down" || !got.RunAt.Equal(now.Add(time.Hour).UTC()) {
+		t.Errorf("Expected the job to wait for its retry, got %+v", got)
+	}
+
+	if err := FailJob(ctx, job.ID, "gave up"); err != nil {
+		t.Fatalf("Failed to fail the job: %v", err)
+	}
+	if got, _ := GetJob(ctx, job.ID); got.Status != JobFailed || got.LastError != "gave up" {
+		t.Errorf("Expected the job to be kept as failed, got %+v", got)
+	}
+}
@@ -0,0 +1,225 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"event_booking_restapi_golang/db"
+	"event_booking_restapi_golang/ids"
+	"strings"
+	"time"
+)
+
+// Message senders.
+const (
+	FromAttendee  = "attendee"  // Sent by the attendee holding the registration
+	FromOrganizer = "organizer" // Sent by the event's organizer
+)
+
+// MaxMessageLength caps the length of a message body in characters.
+const MaxMessageLength = 2000
+
+// Message is one message in the conversation between an attendee and the organizer
+// of an event. Each registration has its own conversation.
+type Message struct {
+	ID             string     // Unique identifier for the message
+	EventID        string     // Event the conversation is about
+	RegistrationID string     // Registration of the attendee in the conversation
+	Sender         string     // FromAttendee or FromOrganizer
+	Body           string     // Text of the message
+	CreatedAt      time.Time  // When the message was sent
+	ReadAt         *time.Time // When the recipient first read it, nil while unread
+}
+
+// MessageThread summarizes the conversation with one attendee for the organizer's inbox.
+type MessageThread struct {
+	RegistrationID string    // Registration of the attendee
+	AttendeeName   string    // Name on the registration, empty once it is cancelled
+	Messages       int       // Messages in the conversation
+	Unread         int       // Attendee messages the organizer hasn't read
+	LastMessageAt  time.Time // When the latest message was sent
+}
+
+// messageColumns lists the message columns in the order scanMessage expects them.
+const messageColumns = "id, event_id, registration_id, sender, body, created_at, read_at"
+
+// scanMessage reads a message row selected with messageColumns.
+func scanMessage(row rowScanner) (Message, error) {
+	var m Message
+	var readAt sql.NullTime
+	err := row.Scan(&m.ID, &m.EventID, &m.RegistrationID, &m.Sender, &m.Body, &m.CreatedAt, &readAt)
+	if readAt.Valid {
+		m.ReadAt = &readAt.Time
+	}
+	return m, err
+}
+
+// scanMessages reads all message rows selected with messageColumns.
+func scanMessages(rows *sql.Rows) ([]Message, error) {
+	defer rows.Close()
+	var messages []Message
+	for rows.Next() {
+		m, err := scanMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+	return messages, rows.Err()
+}
+
+// SendMessage adds a message from sender to the conversation of the registration.
+// The caller checks that the registration belongs to the event. Returns
+// ErrBlockedByOrganizer when the organizer blocked the attendee and an attendee
+// sends, and ErrBlockedByAttendee when the attendee blocked the organizer and the
+// organizer sends.
+func (e Event) SendMessage(ctx context.Context, registrationID, sender, body string) (Message, error) {
+	err := e.checkMessageBlocks(ctx, registrationID, sender)
+	if err != nil {
+		return Message{}, err
+	}
+	m := Message{
+		ID:             ids.New(),
+		EventID:        e.ID,
+		RegistrationID: registrationID,
+		Sender:         sender,
+		Body:           body,
+		CreatedAt:      time.Now().UTC(),
+	}
+	q := "INSERT INTO event_messages (" + messageColumns + ") VALUES (?, ?, ?, ?, ?, ?, NULL)"
+	_, err = db.Conn(ctx).ExecContext(ctx, db.Rebind(q), m.ID, m.EventID, m.RegistrationID, m.Sender, m.Body, m.CreatedAt)
+	if err != nil {
+		return Message{}, err
+	}
+	return m, nil
+}
+
+// checkMessageBlocks returns the block error when the recipient of a message from
+// sender in the registration's conversation has blocked them.
+func (e Event) checkMessageBlocks(ctx context.Context, registrationID, sender string) error {
+	var email string
+	err := db.Conn(ctx).QueryRowContext(ctx, db.Rebind("SELECT email FROM registrations WHERE id=?"), registrationID).Scan(&email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	blockedBy, blockErr := BlockedByOrganizer, ErrBlockedByOrganizer
+	if sender == FromOrganizer {
+		blockedBy, blockErr = BlockedByAttendee, ErrBlockedByAttendee
+	}
+	blocked, err := IsBlocked(ctx, e.UserID, email, blockedBy)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return blockErr
+	}
+	return nil
+}
+
+// GetConversation returns the messages of the registration's conversation, oldest first.
+func (e Event) GetConversation(ctx context.Context, registrationID string) ([]Message, error) {
+	q := "SELECT " + messageColumns + " FROM event_messages WHERE event_id=? AND registration_id=? ORDER BY created_at, id"
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q), e.ID, registrationID)
+	if err != nil {
+		return nil, err
+	}
+	return scanMessages(rows)
+}
+
+// CountUnreadMessages returns the number of messages in the registration's
+// conversation that reader, FromAttendee or FromOrganizer, hasn't read.
+func (e Event) CountUnreadMessages(ctx context.Context, registrationID, reader string) (int, error) {
+	var count int
+	q := "SELECT COUNT(*) FROM event_messages WHERE event_id=? AND registration_id=? AND sender<>? AND read_at IS NULL"
+	err := db.Conn(ctx).QueryRowContext(ctx, db.Rebind(q), e.ID, registrationID, reader).Scan(&count)
+	return count, err
+}
+
+// MarkConversationRead marks the messages reader, FromAttendee or FromOrganizer,
+// received in the registration's conversation as read.
+func (e Event) MarkConversationRead(ctx context.Context, registrationID, reader string) error {
+	q := "UPDATE event_messages SET read_at=? WHERE event_id=? AND registration_id=? AND sender<>? AND read_at IS NULL"
+	_, err := db.Conn(ctx).ExecContext(ctx, db.Rebind(q), time.Now().UTC(), e.ID, registrationID, reader)
+	return err
+}
+
+// GetMessageThreads returns the event's conversations for the organizer's inbox,
+// most recently active first.
+func (e Event) GetMessageThreads(ctx context.Context) ([]MessageThread, error) {
+	q := `
+	SELECT m.registration_id, COALESCE(r.name, ''), m.sender, m.created_at, m.read_at
+	FROM event_messages m LEFT JOIN registrations r ON r.id = m.registration_id
+	WHERE m.event_id = ?
+	ORDER BY m.created_at DESC, m.id
+	`
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q), e.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Rows come newest first, so threads are appended in order of their latest message
+	var threads []MessageThread
+	index := map[string]int{}
+	for rows.Next() {
+		var registrationID, name, sender string
+		var createdAt time.Time
+		var readAt sql.NullTime
+		err := rows.Scan(&registrationID, &name, &sender, &createdAt, &readAt)
+		if err != nil {
+			return nil, err
+		}
+		i, ok := index[registrationID]
+		if !ok {
+			i = len(threads)
+			index[registrationID] = i
+			threads = append(threads, MessageThread{RegistrationID: registrationID, AttendeeName: name, LastMessageAt: createdAt})
+		}
+		threads[i].Messages++
+		if sender == FromAttendee && !readAt.Valid {
+			threads[i].Unread++
+		}
+	}
+	return threads, rows.Err()
+}
+
+// MessageFilter narrows the messages returned by GetMessages. Empty fields match everything.
+type MessageFilter struct {
+	EventID        string // Only messages about this event
+	RegistrationID string // Only messages in this registration's conversation
+}
+
+// GetMessages returns a page of all messages matching the filter, newest first,
+// and the total number of matching messages. It is meant for reviewing reported abuse.
+func GetMessages(ctx context.Context, f MessageFilter, limit, offset int) ([]Message, int, error) {
+	var conditions []string
+	var args []any
+	if f.EventID != "" {
+		conditions = append(conditions, "event_id = ?")
+		args = append(args, f.EventID)
+	}
+	if f.RegistrationID != "" {
+		conditions = append(conditions, "registration_id = ?")
+		args = append(args, f.RegistrationID)
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	err := db.Conn(ctx).QueryRowContext(ctx, db.Rebind("SELECT COUNT(*) FROM event_messages"+where), args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+	q := "SELECT " + messageColumns + " FROM event_messages" + where + " ORDER BY created_at DESC, id LIMIT ? OFFSET ?"
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q), append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	messages, err := scanMessages(rows)
+	return messages, total, err
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"context"
+	"testing"
+)
+
+// TestEvent_Messages tests a conversation between an attendee and the organizer
+func TestEvent_Messages(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+	event := saveEvent(t, Event{Title: "Jazz Night"})
+	alice, err := event.Register(ctx, Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	bob, err := event.Register(ctx, Registration{Name: "Bob", Email: "bob@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	for _, send := range []struct{ registrationID, sender, body string }{
+		{alice.ID, FromAttendee, "Is there parking?"},
+		{alice.ID, FromAttendee, "And a cloakroom?"},
+		{bob.ID, FromAttendee, "Can I bring a friend?"},
+		{alice.ID, FromOrganizer, "Yes to both."},
+	} {
+		_, err := event.SendMessage(ctx, send.registrationID, send.sender, send.body)
+		if err != nil {
+			t.Fatalf("Failed to send message: %v", err)
+		}
+	}
+
+	messages, err := event.GetConversation(ctx, alice.ID)
+	if err != nil || len(messages) != 3 {
+		t.Fatalf("Expected 3 messages with Alice, got %d (%v)", len(messages), err)
+	}
+	if messages[0].Body != "Is there parking?" || messages[2].Sender != FromOrganizer || messages[0].ReadAt != nil {
+		t.Errorf("Unexpected conversation %+v", messages)
+	}
+
+	threads, err := event.GetMessageThreads(ctx)
+	if err != nil || len(threads) != 2 {
+		t.Fatalf("Expected 2 threads, got %+v (%v)", threads, err)
+	}
+	if threads[0].RegistrationID != alice.ID || threads[0].AttendeeName != "Alice" || threads[0].Messages != 3 || threads[0].Unread != 2 {
+		t.Errorf("Expected Alice's thread first with 2 unread, got %+v", threads[0])
+	}
+	if threads[1].RegistrationID != bob.ID || threads[1].Unread != 1 {
+		t.Errorf("Expected Bob's thread with 1 unread, got %+v", threads[1])
+	}
+
+	unread, err := event.CountUnreadMessages(ctx, alice.ID, FromAttendee)
+	if err != nil || unread != 1 {
+		t.Errorf("Expected Alice to have 1 unread reply, got %d (%v)", unread, err)
+	}
+	err = event.MarkConversationRead(ctx, alice.ID, FromOrganizer)
+	if err != nil {
+		t.Fatalf("Failed to mark conversation read: %v", err)
+	}
+	unread, _ = event.CountUnreadMessages(ctx, alice.ID, FromOrganizer)
+	if unread != 0 {
+		t.Errorf("Expected the organizer to have read Alice's messages, got %d unread", unread)
+	}
+	// Reading as the organizer leaves the attendee's unread replies alone
+	unread, _ = event.CountUnreadMessages(ctx, alice.ID, FromAttendee)
+	if unread != 1 {
+		t.Errorf("Expected Alice to still have 1 unread reply, got %d", unread)
+	}
+}
+
+// TestGetMessages tests paging through all messages with filters
+func TestGetMessages(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+	jazz := saveEvent(t, Event{Title: "Jazz Night"})
+	folk := saveEvent(t, Event{Title: "Folk Night"})
+	for i, event := range []Event{jazz, jazz, folk} {
+		_, err := event.SendMessage(ctx, "registration-"+string(rune('a'+i)), FromAttendee, "Hello")
+		if err != nil {
+			t.Fatalf("Failed to send message: %v", err)
+		}
+	}
+
+	messages, total, err := GetMessages(ctx, MessageFilter{}, 2, 0)
+	if err != nil || total != 3 || len(messages) != 2 {
+		t.Fatalf("Expected 2 of 3 messages, got %d of %d (%v)", len(messages), total, err)
+	}
+	if messages[0].RegistrationID != "registration-c" {
+		t.Errorf("Expected the newest message first, got %+v", messages[0])
+	}
+	messages, total, _ = GetMessages(ctx, MessageFilter{EventID: jazz.ID}, 10, 0)
+	if total != 2 || len(messages) != 2 {
+		t.Errorf("Expected 2 messages about the jazz night, got %d of %d", len(messages), total)
+	}
+	messages, total, _ = GetMessages(ctx, MessageFilter{EventID: jazz.ID, RegistrationID: "registration-b"}, 10, 0)
+	if total != 1 || len(messages) != 1 || messages[0].RegistrationID != "registration-b" {
+		t.Errorf("Expected 1 message in the conversation, got %+v", messages)
+	}
+}
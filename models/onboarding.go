@@ -0,0 +1,107 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"event_booking_restapi_golang/db"
+	"time"
+)
+
+// Onboarding steps of an organizer.
+const (
+	OnboardingProfile    = "profile_completed"     // Sent emails under a name with a verified reply-to address
+	OnboardingPayout     = "payout_configured"     // Set up where ticket revenue is paid out
+	OnboardingFirstEvent = "first_event_published" // Published an event
+	OnboardingStripe     = "stripe_connected"      // Connected a Stripe account for payments
+)
+
+// ErrUnknownOnboardingStep is returned for steps that aren't in OnboardingSteps.
+var ErrUnknownOnboardingStep = errors.New("unknown onboarding step")
+
+// OnboardingSteps lists the onboarding steps in the order organizers are guided through
+// them, with their descriptions.
+var OnboardingSteps = []struct {
+	Step        string
+	Description string
+}{
+	{OnboardingProfile, "Set a sender name and verify a reply-to address for your event emails"},
+	{OnboardingPayout, "Configure where your ticket revenue is paid out"},
+	{OnboardingStripe, "Connect your Stripe account"},
+	{OnboardingFirstEvent, "Publish your first event"},
+}
+
+// OnboardingStep is an organizer's progress on one onboarding step.
+type OnboardingStep struct {
+	Step        string     `json:"step"`                   // One of the onboarding steps, e.g. OnboardingProfile
+	Description string     `json:"description"`            // What the organizer has to do
+	Completed   bool       `json:"completed"`              // Whether the step is done
+	CompletedAt *time.Time `json:"completed_at,omitempty"` // When the step was done
+}
+
+// GetOnboarding returns the organizer's progress on every onboarding step, in the
+// order of OnboardingSteps.
+func GetOnboarding(ctx context.Context, userID string) ([]OnboardingStep, error) {
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind("SELECT step, completed_at FROM organizer_onboarding WHERE user_id=?"), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	completed := map[string]time.Time{}
+	for rows.Next() {
+		var step string
+		var at time.Time
+		err := rows.Scan(&step, &at)
+		if err != nil {
+			return nil, err
+		}
+		completed[step] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	steps := make([]OnboardingStep, len(OnboardingSteps))
+	for i, s := range OnboardingSteps {
+		steps[i] = OnboardingStep{Step: s.Step, Description: s.Description}
+		if at, ok := completed[s.Step]; ok {
+			steps[i].Completed, steps[i].CompletedAt = true, &at
+		}
+	}
+	return steps, nil
+}
+
+// CompleteOnboardingStep records that the organizer completed the step at the given
+// time, for steps done outside this service, such as connecting a payment account.
+// Completing a step again keeps the original time.
+// Returns ErrUnknownOnboardingStep for steps that aren't in OnboardingSteps.
+func CompleteOnboardingStep(ctx context.Context, userID, step string, at time.Time) error {
+	known := false
+	for _, s := range OnboardingSteps {
+		known = known || s.Step == step
+	}
+	if !known {
+		return ErrUnknownOnboardingStep
+	}
+	q := "INSERT INTO organizer_onboarding (user_id, step, completed_at) VALUES (?, ?, ?) ON CONFLICT (user_id, step) DO NOTHING"
+	_, err := db.Conn(ctx).ExecContext(ctx, db.Rebind(q), userID, step, at.UTC())
+	return err
+}
+
+// completeEventOnboardingStep records the step as completed for the organizer of the
+// event when the event matches condition, a filter on its events row. Events without
+// an organizer complete nothing, and steps already completed keep their original time.
+func completeEventOnboardingStep(ctx context.Context, x execer, eventID, step string, at time.Time, condition string, args ...any) error {
+	q := `
+	INSERT INTO organizer_onboarding (user_id, step, completed_at)
+	SELECT user_id, ?, ? FROM events WHERE id = ? AND user_id <> '' AND ` + condition + `
+	ON CONFLICT (user_id, step) DO NOTHING`
+	_, err := x.ExecContext(ctx, db.Rebind(q), append([]any{step, at.UTC(), eventID}, args...)...)
+	return err
+}
+
+// completeFirstEventStep completes OnboardingFirstEvent for the organizer of the event
+// if it is published.
+func completeFirstEventStep(ctx context.Context, tx *db.Tx, eventID string) error {
+	return completeEventOnboardingStep(ctx, tx, eventID, OnboardingFirstEvent, time.Now(), "status = ?", StatusPublished)
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// completedSteps returns the onboarding steps the organizer completed
+func completedSteps(t *testing.T, userID string) map[string]bool {
+	t.Helper()
+	steps, err := GetOnboarding(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("Failed to get onboarding: %v", err)
+	}
+	if len(steps) != len(OnboardingSteps) {
+		t.Fatalf("Expected every onboarding step, got %+v", steps)
+	}
+	completed := map[string]bool{}
+	for _, s := range steps {
+		if s.Completed != (s.CompletedAt != nil) {
+			t.Errorf("Expected completed steps to have a time, got %+v", s)
+		}
+		if s.Completed {
+			completed[s.Step] = true
+		}
+	}
+	return completed
+}
+
+// TestOnboarding tests that publishing an event and verifying a sender complete
+// onboarding steps, and that the other steps are completed when reported
+func TestOnboarding(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+
+	if completed := completedSteps(t, "org-1"); len(completed) != 0 {
+		t.Errorf("Expected a new organizer to have completed nothing, got %v", completed)
+	}
+
+	draft, err := Event{Title: "Draft", Location: "Hall", DateTime: time.Now().Add(time.Hour), UserID: "org-1", Status: StatusDraft}.Save(ctx)
+	if err != nil {
+		t.Fatalf("Failed to save event: %v", err)
+	}
+	if completedSteps(t, "org-1")[OnboardingFirstEvent] {
+		t.Error("Expected a draft not to complete the first event step")
+	}
+	err = draft.Patch(ctx, map[string]any{"Status": StatusPublished})
+	if err != nil {
+		t.Fatalf("Failed to publish event: %v", err)
+	}
+	if !completedSteps(t, "org-1")[OnboardingFirstEvent] {
+		t.Error("Expected publishing to complete the first event step")
+	}
+	_, err = Event{Title: "Published", Location: "Hall", DateTime: time.Now().Add(time.Hour), UserID: "org-2"}.Save(ctx)
+	if err != nil {
+		t.Fatalf("Failed to save event: %v", err)
+	}
+	if completed := completedSteps(t, "org-2"); !completed[OnboardingFirstEvent] || len(completed) != 1 {
+		t.Errorf("Expected saving a published event to complete only the first event step, got %v", completed)
+	}
+
+	// Verifying a reply-to address without a sender name doesn't complete the profile
+	_, code, err := draft.SetSender(ctx, "", "hello@example.com")
+	if err != nil {
+		t.Fatalf("Failed to set sender: %v", err)
+	}
+	if _, err := draft.VerifyReplyTo(ctx, code); err != nil {
+		t.Fatalf("Failed to verify reply-to: %v", err)
+	}
+	if completedSteps(t, "org-1")[OnboardingProfile] {
+		t.Error("Expected the profile to need a sender name")
+	}
+	_, code, err = draft.SetSender(ctx, "Hall Events", "events@example.com")
+	if err != nil {
+		t.Fatalf("Failed to set sender: %v", err)
+	}
+	if _, err := draft.VerifyReplyTo(ctx, code); err != nil {
+		t.Fatalf("Failed to verify reply-to: %v", err)
+	}
+	if !completedSteps(t, "org-1")[OnboardingProfile] {
+		t.Error("Expected the verified sender to complete the profile step")
+	}
+
+	first := time.Now().Add(-time.Hour)
+	for _, at := range []time.Time{first, time.Now()} {
+		if err := CompleteOnboardingStep(ctx, "org-1", OnboardingStripe, at); err != nil {
+			t.Fatalf("Failed to complete step: %v", err)
+		}
+	}
+	steps, err := GetOnboarding(ctx, "org-1")
+	if err != nil {
+		t.Fatalf("Failed to get onboarding: %v", err)
+	}
+	for _, s := range steps {
+		if s.Step == OnboardingStripe && (s.CompletedAt == nil || !s.CompletedAt.Equal(first.UTC())) {
+			t.Errorf("Expected completing again to keep the original time, got %+v", s)
+		}
+	}
+	if err := CompleteOnboardingStep(ctx, "org-1", "bake_cake", time.Now()); !errors.Is(err, ErrUnknownOnboardingStep) {
+		t.Errorf("Expected an unknown step to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package models
+
+import (
+	"context"
+	"event_booking_restapi_golang/db"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Kinds of pending items on an organizer's checklist.
+const (
+	PendingQuestions    = "unanswered_questions" // Attendees wait for a reply to their message
+	PendingRefunds      = "refund_requests"      // Refund requests wait for the organizer's decision
+	PendingLowCheckIn   = "low_check_in"         // Few attendees of an event that started were checked in
+	PendingMissingImage = "missing_image"        // An upcoming event has no image attached
+)
+
+// LowCheckInPercent is the share of the registrations checked in below which an event
+// that started within LowCheckInWindow is listed as PendingLowCheckIn.
+const LowCheckInPercent = 50
+
+// LowCheckInWindow is how long after it started an event's check-in rate is reviewed,
+// so a daily digest reports each event once.
+const LowCheckInWindow = 24 * time.Hour
+
+// PendingItem is an action waiting for an organizer on one of their events.
+type PendingItem struct {
+	Kind       string    `json:"kind"`            // One of the pending item kinds, e.g. PendingQuestions
+	EventID    string    `json:"event_id"`        // Event the item is about
+	EventTitle string    `json:"event_title"`     // Title of the event
+	EventStart time.Time `json:"event_date_time"` // When the event starts
+	Count      int       `json:"count,omitempty"` // Unanswered conversations or refund requests, or attendees checked in for PendingLowCheckIn
+	Total      int       `json:"total,omitempty"` // Registrations, for PendingLowCheckIn
+	Message    string    `json:"message"`         // Human-readable description
+}
+
+// GetPendingItems returns the organizer's checklist of pending actions at now: events
+// with unanswered attendee questions or undecided refund requests, events that
+// started within LowCheckInWindow with fewer than LowCheckInPercent of their attendees
+// checked in, and upcoming events without an image attachment. Cancelled events are left out. Items are in
+// the order of their event's start, questions and refunds first for each event.
+func GetPendingItems(ctx context.Context, userID string, now time.Time) ([]PendingItem, error) {
+	byUser, err := pendingItems(ctx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	return byUser[userID], nil
+}
+
+// GetPendingItemsByOrganizer returns the checklist of every organizer with pending
+// actions at now, keyed by user ID, for sending digests.
+func GetPendingItemsByOrganizer(ctx context.Context, now time.Time) (map[string][]PendingItem, error) {
+	return pendingItems(ctx, "", now)
+}
+
+// pendingItems collects the pending items of the organizer, or of all organizers when
+// userID is empty, keyed by user ID.
+func pendingItems(ctx context.Context, userID string, now time.Time) (map[string][]PendingItem, error) {
+	userFilter, args := "", []any{}
+	if userID != "" {
+		userFilter, args = " AND e.user_id = ?", []any{userID}
+	}
+	// Each query selects the organizer, the event and the item's counts
+	queries := []struct {
+		kind string
+		q    string
+		args []any
+	}{
+		{PendingQuestions, `
+		SELECT e.user_id, e.id, e.name, e.datetime, COUNT(DISTINCT m.registration_id), 0
+		FROM event_messages m JOIN events e ON e.id = m.event_id
+		WHERE e.status <> ? AND e.user_id <> ''` + userFilter + ` AND m.sender = ?
+		AND m.created_at = (SELECT MAX(l.created_at) FROM event_messages l WHERE l.event_id = m.event_id AND l.registration_id = m.registration_id)
+		GROUP BY e.user_id, e.id, e.name, e.datetime`,
+			append(append([]any{StatusCancelled}, args...), FromAttendee)},
+		{PendingRefunds, `
+		SELECT e.user_id, e.id, e.name, e.datetime, COUNT(*), 0
+		FROM refund_requests rr JOIN events e ON e.id = rr.event_id
+		WHERE e.status <> ? AND e.user_id <> ''` + userFilter + ` AND rr.status = ?
+		GROUP BY e.user_id, e.id, e.name, e.datetime`,
+			append(append([]any{StatusCancelled}, args...), RefundRequested)},
+		{PendingLowCheckIn, `
+		SELECT e.user_id, e.id, e.name, e.datetime, COUNT(r.checked_in_at), COUNT(r.id)
+		FROM events e JOIN registrations r ON r.event_id = e.id
+		WHERE e.status <> ? AND e.user_id <> ''` + userFilter + `
+		AND ` + db.Timestamp("e.datetime") + ` <= ` + db.Timestamp("?") + ` AND ` + db.Timestamp("e.datetime") + ` > ` + db.Timestamp("?") + `
+		GROUP BY e.user_id, e.id, e.name, e.datetime
+		HAVING COUNT(r.checked_in_at) * 100 < COUNT(r.id) * ?`,
+			append(append(append([]any{StatusCancelled}, args...), now, now.Add(-LowCheckInWindow)), LowCheckInPercent)},
+		{PendingMissingImage, `
+		SELECT e.user_id, e.id, e.name, e.datetime, 0, 0
+		FROM events e
+		WHERE e.status <> ? AND e.user_id <> ''` + userFilter + ` AND ` + db.Timestamp("e.datetime") + ` > ` + db.Timestamp("?") + `
+		AND NOT EXISTS (SELECT 1 FROM attachments a WHERE a.event_id = e.id AND a.content_type LIKE 'image/%')`,
+			append(append([]any{StatusCancelled}, args...), now)},
+	}
+
+	byUser := map[string][]PendingItem{}
+	for _, query := range queries {
+		rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(query.q), query.args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var user string
+			item := PendingItem{Kind: query.kind}
+			err := rows.Scan(&user, &item.EventID, &item.EventTitle, &item.EventStart, &item.Count, &item.Total)
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+			item.Message = item.describe()
+			byUser[user] = append(byUser[user], item)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	// Kinds are collected in checklist order, which the stable sort keeps for each event
+	for _, items := range byUser {
+		sort.SliceStable(items, func(i, j int) bool {
+			if !items[i].EventStart.Equal(items[j].EventStart) {
+				return items[i].EventStart.Before(items[j].EventStart)
+			}
+			return items[i].EventID < items[j].EventID
+		})
+	}
+	return byUser, nil
+}
+
+// describe returns the human-readable description of the item.
+func (p PendingItem) describe() string {
+	switch p.Kind {
+	case PendingQuestions:
+		if p.Count == 1 {
+			return fmt.Sprintf("1 attendee of %s is waiting for a reply", p.EventTitle)
+		}
+		return fmt.Sprintf("%d attendees of %s are waiting for a reply", p.Count, p.EventTitle)
+	case PendingRefunds:
+		if p.Count == 1 {
+			return fmt.Sprintf("1 refund request for %s is waiting for your decision", p.EventTitle)
+		}
+		return fmt.Sprintf("%d refund requests for %s are waiting for your decision", p.Count, p.EventTitle)
+	case PendingLowCheckIn:
+		return fmt.Sprintf("Only %d of %d attendees of %s were checked in", p.Count, p.Total, p.EventTitle)
+	case PendingMissingImage:
+		return fmt.Sprintf("%s has no image; attach one to make the listing stand out", p.EventTitle)
+	}
+	return p.EventTitle
+}
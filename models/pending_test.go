@@ -0,0 +1,103 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// registerPending registers attendees for the event, checking in the first checkedIn of them
+func registerPending(t *testing.T, event Event, names []string, checkedIn int) []Registration {
+	t.Helper()
+	var registrations []Registration
+	for i, name := range names {
+		registration, err := event.Register(context.Background(), Registration{Name: name, Email: name + "@example.com"})
+		if err != nil {
+			t.Fatalf("Failed to register: %v", err)
+		}
+		if i < checkedIn {
+			_, err = event.CheckIn(context.Background(), registration.Ticket.Code)
+			if err != nil {
+				t.Fatalf("Failed to check in: %v", err)
+			}
+		}
+		registrations = append(registrations, registration)
+	}
+	return registrations
+}
+
+// TestGetPendingItems tests the organizer's checklist of unanswered questions, low check-in and missing images
+func TestGetPendingItems(t *testing.T) {
+	setupTestDatabase(t)
+	originalDir := AttachmentsDir
+	AttachmentsDir = t.TempDir()
+	t.Cleanup(func() { AttachmentsDir = originalDir })
+	ctx := context.Background()
+	now := time.Now()
+
+	pastGig := saveEvent(t, Event{Title: "Past Gig", UserID: "org-1", DateTime: now.Add(-2 * time.Hour)})
+	registerPending(t, pastGig, []string{"ann", "ben", "cat"}, 1)
+	wellAttended := saveEvent(t, Event{Title: "Well Attended", UserID: "org-1", DateTime: now.Add(-2 * time.Hour)})
+	registerPending(t, wellAttended, []string{"dan", "eve"}, 2)
+	oldGig := saveEvent(t, Event{Title: "Old Gig", UserID: "org-1", DateTime: now.Add(-72 * time.Hour)})
+	registerPending(t, oldGig, []string{"fay", "gus"}, 0)
+
+	jazzNight := saveEvent(t, Event{Title: "Jazz Night", UserID: "org-1", DateTime: now.Add(48 * time.Hour)})
+	attendees := registerPending(t, jazzNight, []string{"alice", "bob"}, 0)
+	for _, message := range []struct {
+		registration Registration
+		sender       string
+	}{
+		{attendees[0], FromAttendee},
+		{attendees[1], FromAttendee},
+		{attendees[1], FromOrganizer},
+	} {
+		_, err := jazzNight.SendMessage(ctx, message.registration.ID, message.sender, "Is there parking?")
+		if err != nil {
+			t.Fatalf("Failed to send message: %v", err)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	photoNight := saveEvent(t, Event{Title: "Photo Night", UserID: "org-1", DateTime: now.Add(72 * time.Hour)})
+	_, err := photoNight.AddAttachment(ctx, Attachment{Kind: "other", FileName: "poster.png", ContentType: "image/png"}, []byte("png"))
+	if err != nil {
+		t.Fatalf("Failed to attach image: %v", err)
+	}
+	cancelled := saveEvent(t, Event{Title: "Cancelled Show", UserID: "org-1", DateTime: now.Add(24 * time.Hour)})
+	if _, err := cancelled.Cancel(ctx); err != nil {
+		t.Fatalf("Failed to cancel: %v", err)
+	}
+	saveEvent(t, Event{Title: "Other Organizer", UserID: "org-2", DateTime: now.Add(24 * time.Hour)})
+
+	items, err := GetPendingItems(ctx, "org-1", now)
+	if err != nil {
+		t.Fatalf("Failed to get pending items: %v", err)
+	}
+	expected := []PendingItem{
+		{Kind: PendingLowCheckIn, EventID: pastGig.ID, Count: 1, Total: 3, Message: "Only 1 of 3 attendees of Past Gig were checked in"},
+		{Kind: PendingQuestions, EventID: jazzNight.ID, Count: 1, Message: "1 attendee of Jazz Night is waiting for a reply"},
+		{Kind: PendingMissingImage, EventID: jazzNight.ID, Message: "Jazz Night has no image; attach one to make the listing stand out"},
+	}
+	if len(items) != len(expected) {
+		t.Fatalf("Expected %d items, got %+v", len(expected), items)
+	}
+	for i, item := range items {
+		want := expected[i]
+		if item.Kind != want.Kind || item.EventID != want.EventID || item.Count != want.Count || item.Total != want.Total || item.Message != want.Message {
+			t.Errorf("Expected item %d to be %+v, got %+v", i, want, item)
+		}
+	}
+
+	byOrganizer, err := GetPendingItemsByOrganizer(ctx, now)
+	if err != nil {
+		t.Fatalf("Failed to get pending items by organizer: %v", err)
+	}
+	if len(byOrganizer) != 2 || len(byOrganizer["org-1"]) != 3 || len(byOrganizer["org-2"]) != 1 || byOrganizer["org-2"][0].Kind != PendingMissingImage {
+		t.Errorf("Expected the checklists of org-1 and org-2, got %+v", byOrganizer)
+	}
+
+	if items, _ := GetPendingItems(ctx, "org-3", now); len(items) != 0 {
+		t.Errorf("Expected nothing pending for an organizer without events, got %+v", items)
+	}
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"context"
+	"event_booking_restapi_golang/db"
+	"event_booking_restapi_golang/ids"
+	"time"
+)
+
+// ProbeDown is the state of the checks that found their component unavailable; checks
+// in any other state count towards its uptime.
+const ProbeDown = "down"
+
+// Probe is the outcome of one check of a component the API depends on, kept so the
+// status page can report its uptime.
+type Probe struct {
+	Component string    // Name of the component, such as database or payments
+	CheckedAt time.Time // When it was checked
+	State     string    // How it was doing, such as operational or ProbeDown
+	Detail    string    // What was wrong, empty when nothing was
+}
+
+// RecordProbes stores the outcomes of checks, all of them or none.
+func RecordProbes(ctx context.Context, probes []Probe) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, p := range probes {
+		_, err = tx.ExecContext(ctx, db.Rebind("INSERT INTO status_probes (id, component, checked_at, state, detail) VALUES (?,?,?,?,?)"),
+			ids.New(), p.Component, p.CheckedAt.UTC(), p.State, p.Detail)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ProbeUptime returns the uptime of each component checked since the given time: the
+// percentage of its checks that didn't find it down. Components without checks in
+// that time are left out.
+func ProbeUptime(ctx context.Context, since time.Time) (map[string]float64, error) {
+	q := "SELECT component, COUNT(*), SUM(CASE WHEN state <> ? THEN 1 ELSE 0 END) FROM status_probes WHERE " +
+		db.Timestamp("checked_at") + " >= " + db.Timestamp("?") + " GROUP BY component"
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q), ProbeDown, since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	uptime := map[string]float64{}
+	for rows.Next() {
+		var component string
+		var checks, up int
+		err = rows.Scan(&component, &checks, &up)
+		if err != nil {
+			return nil, err
+		}
+		uptime[component] = 100 * float64(up) / float64(checks)
+	}
+	return uptime, rows.Err()
+}
+
+// PruneProbes deletes the outcomes of the checks made before the given time, which no
+// longer count towards any reported uptime.
+// Returns the number of checks deleted.
+func PruneProbes(ctx context.Context, before time.Time) (int, error) {
+	q := "DELETE FROM status_probes WHERE " + db.Timestamp("checked_at") + " < " + db.Timestamp("?")
+	res, err := db.Conn(ctx).ExecContext(ctx, db.Rebind(q), before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := res.RowsAffected()
+	return int(deleted), err
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"context"
+	"event_booking_restapi_golang/db"
+	"fmt"
+	"time"
+)
+
+// FreeEventLimit caps the active events of each user who isn't in PaidUsers, so the
+// hosted free tier can't be used to flood the listing; 0 disables the cap. It is set by
+// main from FREE_EVENT_LIMIT.
+var FreeEventLimit int
+
+// PaidUsers holds the IDs of the paying users, who aren't subject to FreeEventLimit.
+// It is set by main from PAID_USER_IDS.
+var PaidUsers map[string]bool
+
+// QuotaError is returned when a new event would take a free user over FreeEventLimit.
+type QuotaError struct {
+	Usage int // Number of active events the user has
+	Limit int // Number of active events free users may have
+}
+
+func (e QuotaError) Error() string {
+	return fmt.Sprintf("free accounts can have up to %d active events and you have %d; cancel or finish one, or upgrade to create more", e.Limit, e.Usage)
+}
+
+// eventQuotaApplies reports whether FreeEventLimit applies to a new event of the user.
+// Cancelled events, events without a user and those of paying users are exempt.
+func eventQuotaApplies(e Event) bool {
+	return FreeEventLimit > 0 && e.UserID != "" && !PaidUsers[e.UserID] && e.Status != StatusCancelled
+}
+
+// countActiveEvents counts the user's events, drafts included, that aren't cancelled
+// and haven't ended at the given time. Events without an end count until they start.
+func countActiveEvents(ctx context.Context, x queryRower, userID string, now time.Time) (int, error) {
+	q := "SELECT COUNT(*) FROM events WHERE user_id=? AND status<>? AND " +
+		db.Timestamp("COALESCE(end_datetime, datetime)") + " >= " + db.Timestamp("?")
+	var count int
+	err := x.QueryRowContext(ctx, db.Rebind(q), userID, StatusCancelled, now).Scan(&count)
+	return count, err
+}
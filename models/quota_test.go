@@ -0,0 +1,69 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// setFreeEventLimit sets the quota for the test and restores it afterwards
+func setFreeEventLimit(t *testing.T, limit int, paid ...string) {
+	t.Helper()
+	oldLimit, oldPaid := FreeEventLimit, PaidUsers
+	t.Cleanup(func() { FreeEventLimit, PaidUsers = oldLimit, oldPaid })
+	FreeEventLimit, PaidUsers = limit, map[string]bool{}
+	for _, id := range paid {
+		PaidUsers[id] = true
+	}
+}
+
+// TestEventQuota tests that free users can't go over the active event limit
+func TestEventQuota(t *testing.T) {
+	setupTestDatabase(t)
+	setFreeEventLimit(t, 2)
+	upcoming := time.Now().Add(24 * time.Hour)
+
+	for i := 0; i < 2; i++ {
+		saveEvent(t, Event{UserID: "free-user", DateTime: upcoming, Status: StatusDraft})
+	}
+
+	_, err := Event{Title: "Over Quota", Location: "Test Location", DateTime: upcoming, UserID: "free-user"}.Save(context.Background())
+	var quotaErr QuotaError
+	if !errors.As(err, &quotaErr) {
+		t.Fatalf("Expected QuotaError, got %v", err)
+	}
+	if quotaErr.Usage != 2 || quotaErr.Limit != 2 {
+		t.Errorf("Expected usage 2 of 2, got %d of %d", quotaErr.Usage, quotaErr.Limit)
+	}
+
+	saveEvent(t, Event{UserID: "other-user", DateTime: upcoming})
+}
+
+// TestEventQuotaInactive tests that cancelled and past events don't count towards the limit
+func TestEventQuotaInactive(t *testing.T) {
+	setupTestDatabase(t)
+	setFreeEventLimit(t, 1)
+	upcoming := time.Now().Add(24 * time.Hour)
+
+	saveEvent(t, Event{UserID: "free-user", DateTime: time.Now().Add(-48 * time.Hour)})
+	saveEvent(t, Event{UserID: "free-user", DateTime: upcoming, Status: StatusCancelled})
+	saveEvent(t, Event{UserID: "free-user", DateTime: upcoming})
+	saveEvent(t, Event{UserID: "free-user", DateTime: upcoming, Status: StatusCancelled})
+}
+
+// TestEventQuotaExempt tests that paying users and a zero limit aren't capped
+func TestEventQuotaExempt(t *testing.T) {
+	setupTestDatabase(t)
+	setFreeEventLimit(t, 1, "paid-user")
+	upcoming := time.Now().Add(24 * time.Hour)
+
+	for i := 0; i < 3; i++ {
+		saveEvent(t, Event{UserID: "paid-user", DateTime: upcoming})
+	}
+
+	FreeEventLimit = 0
+	for i := 0; i < 3; i++ {
+		saveEvent(t, Event{UserID: "free-user", DateTime: upcoming})
+	}
+}
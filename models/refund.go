@@ -0,0 +1,262 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"event_booking_restapi_golang/db"
+	"event_booking_restapi_golang/ids"
+	"fmt"
+	"time"
+)
+
+// Refund request statuses.
+const (
+	RefundRequested = "requested" // Waiting for the organizer's decision
+	RefundApproved  = "approved"  // The booking is cancelled and the refund waits for the payment provider
+	RefundDenied    = "denied"    // The organizer turned the request down; the booking stands
+	RefundRefunded  = "refunded"  // The payment provider refunded the attendee
+	RefundFailed    = "failed"    // The payment provider couldn't refund the attendee
+)
+
+// RefundAutoApproveBefore is how long before the event starts a refund request must be
+// made to be approved at once, without waiting for the organizer; 0 disables automatic
+// approval. It is set by main from REFUND_AUTO_APPROVE_BEFORE.
+var RefundAutoApproveBefore time.Duration
+
+// ErrRefundRequested is returned when a refund was already requested for the booking.
+var ErrRefundRequested = duplicateError("a refund has already been requested for this booking")
+
+// ErrRefundDecided is returned when the organizer decides on a refund request that
+// was already approved or denied.
+var ErrRefundDecided = conflictError("the refund request has already been decided")
+
+// RefundRequest is an attendee's request to cancel their booking and get their money
+// back, tracked from the request through the organizer's decision to the refund by the
+// payment provider. It outlives the registration, which is cancelled on approval.
+type RefundRequest struct {
+	ID                string     // Unique identifier for the request
+	RegistrationID    string     // Booking the refund is requested for
+	EventID           string     // Event of the booking
+	Name              string     // Attendee name
+	Email             string     // Attendee email
+	Reason            string     // Why the attendee asks for a refund
+	Status            string     // One of the refund request statuses, e.g. RefundRequested
+	AutoApproved      bool       // Whether the request was approved by RefundAutoApproveBefore rather than the organizer
+	DecisionReason    string     // The organizer's explanation of their decision, optional
+	ProviderReference string     // ID of the refund at the payment provider, once refunded
+	Error             string     // Why the payment provider couldn't refund, for RefundFailed
+	CreatedAt         time.Time  // When the refund was requested
+	DecidedAt         *time.Time // When the request was approved or denied, nil until then
+	RefundedAt        *time.Time // When the payment provider refunded the attendee, nil until then
+}
+
+// refundRequestColumns lists the refund_requests columns in the order scanRefundRequest expects them.
+const refundRequestColumns = "id, registration_id, event_id, name, email, reason, status, auto_approved, decision_reason, provider_reference, error, created_at, decided_at, refunded_at"
+
+// scanRefundRequest reads a refund_requests row selected with refundRequestColumns.
+func scanRefundRequest(row rowScanner) (RefundRequest, error) {
+	var r RefundRequest
+	err := row.Scan(&r.ID, &r.RegistrationID, &r.EventID, &r.Name, &r.Email, &r.Reason, &r.Status, &r.AutoApproved,
+		&r.DecisionReason, &r.ProviderReference, &r.Error, &r.CreatedAt, &r.DecidedAt, &r.RefundedAt)
+	return r, err
+}
+
+// RequestRefund records the attendee's request for a refund of the booking at now. When
+// it is made at least RefundAutoApproveBefore before the event starts, it is approved
+// at once and the booking is cancelled in the same transaction, promoting the next
+// attendee from the waitlist.
+// Returns the request and the promoted registration, if any. Returns
+// ErrRefundRequested if a refund was already requested for the booking.
+func (r Registration) RequestRefund(ctx context.Context, reason string, now time.Time) (RefundRequest, *Registration, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return RefundRequest{}, nil, err
+	}
+	defer tx.Rollback()
+
+	var start time.Time
+	err = tx.QueryRowContext(ctx, db.Rebind("SELECT datetime FROM events WHERE id=?"+db.ForUpdate()), r.EventID).Scan(&start)
+	if err != nil {
+		return RefundRequest{}, nil, err
+	}
+	var existing int
+	err = tx.QueryRowContext(ctx, db.Rebind("SELECT COUNT(*) FROM refund_requests WHERE registration_id=?"), r.ID).Scan(&existing)
+	if err != nil {
+		return RefundRequest{}, nil, err
+	}
+	if existing > 0 {
+		return RefundRequest{}, nil, ErrRefundRequested
+	}
+
+	request := RefundRequest{
+		ID:             ids.New(),
+		RegistrationID: r.ID,
+		EventID:        r.EventID,
+		Name:           r.Name,
+		Email:          r.Email,
+		Reason:         reason,
+		Status:         RefundRequested,
+		CreatedAt:      now.UTC(),
+	}
+	var promoted *Registration
+	if RefundAutoApproveBefore > 0 && start.Sub(now) >= RefundAutoApproveBefore {
+		decidedAt := now.UTC()
+		request.Status, request.AutoApproved, request.DecidedAt = RefundApproved, true, &decidedAt
+		promoted, err = r.cancel(ctx, tx)
+		if err != nil {
+			return RefundRequest{}, nil, err
+		}
+	}
+	_, err = tx.ExecContext(ctx, db.Rebind("INSERT INTO refund_requests ("+refundRequestColumns+") VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?)"),
+		request.ID, request.RegistrationID, request.EventID, request.Name, request.Email, request.Reason, request.Status,
+		request.AutoApproved, request.DecisionReason, request.ProviderReference, request.Error, request.CreatedAt, request.DecidedAt, request.RefundedAt)
+	if err != nil {
+		return RefundRequest{}, nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return RefundRequest{}, nil, err
+	}
+	return request, promoted, nil
+}
+
+// GetRefundRequest retrieves a refund request by its ID.
+// Returns a NotFoundError if there is no such request.
+func GetRefundRequest(ctx context.Context, id string) (RefundRequest, error) {
+	q := "SELECT " + refundRequestColumns + " FROM refund_requests WHERE id=?"
+	request, err := scanRefundRequest(db.Conn(ctx).QueryRowContext(ctx, db.Rebind(q), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return RefundRequest{}, NotFoundError{Message: fmt.Sprint("Couldn't find a refund request with the ID of ", id)}
+	}
+	return request, err
+}
+
+// GetRefundRequestByRegistration retrieves the refund request made for the booking,
+// which may since have been cancelled.
+// Returns a NotFoundError if no refund was requested for it.
+func GetRefundRequestByRegistration(ctx context.Context, registrationID string) (RefundRequest, error) {
+	q := "SELECT " + refundRequestColumns + " FROM refund_requests WHERE registration_id=?"
+	request, err := scanRefundRequest(db.Conn(ctx).QueryRowContext(ctx, db.Rebind(q), registrationID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return RefundRequest{}, NotFoundError{Message: fmt.Sprint("No refund was requested for the registration with the ID of ", registrationID)}
+	}
+	return request, err
+}
+
+// GetRefundRequests retrieves the refund requests for the event in the order they
+// were made, only those in the given status unless it is empty.
+func (e Event) GetRefundRequests(ctx context.Context, status string) ([]RefundRequest, error) {
+	q := "SELECT " + refundRequestColumns + " FROM refund_requests WHERE event_id=?"
+	args := []any{e.ID}
+	if status != "" {
+		q += " AND status=?"
+		args = append(args, status)
+	}
+	q += " ORDER BY " + db.Timestamp("created_at") + ", id"
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	requests := []RefundRequest{}
+	for rows.Next() {
+		request, err := scanRefundRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, request)
+	}
+	return requests, rows.Err()
+}
+
+// getRefundRequestsByEmail retrieves the refund requests the email address made, keyed
+// by registration ID. The address is compared case-insensitively.
+func getRefundRequestsByEmail(ctx context.Context, email string) (map[string]RefundRequest, error) {
+	q := "SELECT " + refundRequestColumns + " FROM refund_requests WHERE LOWER(email)=LOWER(?)"
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q), email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	requests := map[string]RefundRequest{}
+	for rows.Next() {
+		request, err := scanRefundRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		requests[request.RegistrationID] = request
+	}
+	return requests, rows.Err()
+}
+
+// DecideRefundRequest records the organizer's decision on the refund request at now,
+// with an optional explanation. Approving it cancels the booking, unless the attendee
+// already did, in the same transaction, promoting the next attendee from the waitlist.
+// Returns the decided request and the promoted registration, if any. Returns a
+// NotFoundError if there is no such request and ErrRefundDecided if it was already
+// approved or denied.
+func DecideRefundRequest(ctx context.Context, id string, approve bool, reason string, now time.Time) (RefundRequest, *Registration, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return RefundRequest{}, nil, err
+	}
+	defer tx.Rollback()
+
+	q := "SELECT " + refundRequestColumns + " FROM refund_requests WHERE id=?" + db.ForUpdate()
+	request, err := scanRefundRequest(tx.QueryRowContext(ctx, db.Rebind(q), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return RefundRequest{}, nil, NotFoundError{Message: fmt.Sprint("Couldn't find a refund request with the ID of ", id)}
+	}
+	if err != nil {
+		return RefundRequest{}, nil, err
+	}
+	if request.Status != RefundRequested {
+		return RefundRequest{}, nil, ErrRefundDecided
+	}
+
+	var promoted *Registration
+	decidedAt := now.UTC()
+	request.Status, request.DecisionReason, request.DecidedAt = RefundDenied, reason, &decidedAt
+	if approve {
+		request.Status = RefundApproved
+		q := "SELECT " + registrationColumns + " FROM registrations WHERE id=?"
+		registration, err := scanRegistration(tx.QueryRowContext(ctx, db.Rebind(q), request.RegistrationID))
+		switch {
+		case err == nil:
+			promoted, err = registration.cancel(ctx, tx)
+			if err != nil {
+				return RefundRequest{}, nil, err
+			}
+		case !errors.Is(err, sql.ErrNoRows):
+			return RefundRequest{}, nil, err
+		}
+	}
+	_, err = tx.ExecContext(ctx, db.Rebind("UPDATE refund_requests SET status=?, decision_reason=?, decided_at=? WHERE id=?"),
+		request.Status, request.DecisionReason, request.DecidedAt, request.ID)
+	if err != nil {
+		return RefundRequest{}, nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return RefundRequest{}, nil, err
+	}
+	return request, promoted, nil
+}
+
+// CompleteRefund records that the payment provider refunded the approved request at
+// now under its reference.
+func CompleteRefund(ctx context.Context, id, reference string, now time.Time) error {
+	_, err := db.Conn(ctx).ExecContext(ctx, db.Rebind("UPDATE refund_requests SET status=?, provider_reference=?, error='', refunded_at=? WHERE id=? AND status=?"),
+		RefundRefunded, reference, now.UTC(), id, RefundApproved)
+	return err
+}
+
+// FailRefund records why the payment provider couldn't refund the approved request.
+func FailRefund(ctx context.Context, id, reason string) error {
+	_, err := db.Conn(ctx).ExecContext(ctx, db.Rebind("UPDATE refund_requests SET status=?, error=? WHERE id=? AND status=?"),
+		RefundFailed, reason, id, RefundApproved)
+	return err
+}
@@ -0,0 +1,149 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestRequestRefund tests that refunds requested early enough are approved at once,
+// cancelling the booking, and later ones wait for the organizer
+func TestRequestRefund(t *testing.T) {
+	setupTestDatabase(t)
+	original := RefundAutoApproveBefore
+	RefundAutoApproveBefore = 7 * 24 * time.Hour
+	t.Cleanup(func() { RefundAutoApproveBefore = original })
+	ctx := context.Background()
+	now := time.Now()
+
+	early := saveEvent(t, Event{Title: "Early Event", Capacity: 1})
+	err := early.Patch(ctx, map[string]any{"DateTime": now.Add(30 * 24 * time.Hour)})
+	if err != nil {
+		t.Fatalf("Failed to move event: %v", err)
+	}
+	alice, err := early.Register(ctx, Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	_, err = early.JoinWaitlist(ctx, WaitlistEntry{Name: "Bob", Email: "bob@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to join waitlist: %v", err)
+	}
+
+	request, promoted, err := alice.RequestRefund(ctx, "Can't make it", now)
+	if err != nil {
+		t.Fatalf("Failed to request refund: %v", err)
+	}
+	if request.Status != RefundApproved || !request.AutoApproved || request.DecidedAt == nil || request.Reason != "Can't make it" {
+		t.Errorf("Expected the request to be approved automatically, got %+v", request)
+	}
+	if promoted == nil || promoted.Email != "bob@example.com" {
+		t.Errorf("Expected Bob to be promoted, got %+v", promoted)
+	}
+	if _, err := GetRegistrationById(ctx, alice.ID); err == nil {
+		t.Error("Expected Alice's booking to be cancelled")
+	}
+	stored, err := GetRefundRequestByRegistration(ctx, alice.ID)
+	if err != nil || stored.ID != request.ID || stored.Status != RefundApproved {
+		t.Errorf("Expected the approved request to outlive the booking, got %+v (%v)", stored, err)
+	}
+
+	late := saveEvent(t, Event{Title: "Late Event", UserID: "org-1", DateTime: now.Add(24 * time.Hour)})
+	carol, err := late.Register(ctx, Registration{Name: "Carol", Email: "carol@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	request, promoted, err = carol.RequestRefund(ctx, "Sick", now)
+	if err != nil || request.Status != RefundRequested || request.AutoApproved || promoted != nil {
+		t.Errorf("Expected the request to wait for the organizer, got %+v, %v (%v)", request, promoted, err)
+	}
+	if _, err := GetRegistrationById(ctx, carol.ID); err != nil {
+		t.Errorf("Expected Carol's booking to stand until the decision: %v", err)
+	}
+	if _, _, err := carol.RequestRefund(ctx, "Still sick", now); !errors.Is(err, ErrRefundRequested) {
+		t.Errorf("Expected ErrRefundRequested for a second request, got %v", err)
+	}
+
+	bookings, err := GetRegistrationsByEmail(ctx, "carol@example.com", BookingFilter{})
+	if err != nil || len(bookings) != 1 || bookings[0].RefundRequest == nil || bookings[0].RefundRequest.ID != request.ID {
+		t.Errorf("Expected Carol's booking to show the refund request, got %+v (%v)", bookings, err)
+	}
+	items, err := GetPendingItems(ctx, "org-1", now)
+	if err != nil {
+		t.Fatalf("Failed to get pending items: %v", err)
+	}
+	found := false
+	for _, item := range items {
+		found = found || item.Kind == PendingRefunds && item.EventID == late.ID && item.Count == 1
+	}
+	if !found {
+		t.Errorf("Expected the refund request on the organizer's checklist, got %+v", items)
+	}
+}
+
+// TestDecideRefundRequest tests approving and denying refund requests and tracking
+// the refund through the payment provider
+func TestDecideRefundRequest(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+	now := time.Now()
+	event := saveEvent(t, Event{Title: "Jazz Night", UserID: "org-1", DateTime: now.Add(24 * time.Hour)})
+	var requests []RefundRequest
+	for _, name := range []string{"alice", "bob"} {
+		registration, err := event.Register(ctx, Registration{Name: name, Email: name + "@example.com"})
+		if err != nil {
+			t.Fatalf("Failed to register: %v", err)
+		}
+		request, _, err := registration.RequestRefund(ctx, "Sick", now)
+		if err != nil {
+			t.Fatalf("Failed to request refund: %v", err)
+		}
+		requests = append(requests, request)
+	}
+
+	approved, _, err := DecideRefundRequest(ctx, requests[0].ID, true, "Get well", now)
+	if err != nil || approved.Status != RefundApproved || approved.AutoApproved || approved.DecisionReason != "Get well" || approved.DecidedAt == nil {
+		t.Errorf("Expected the request to be approved, got %+v (%v)", approved, err)
+	}
+	if _, err := GetRegistrationById(ctx, approved.RegistrationID); err == nil {
+		t.Error("Expected the approved booking to be cancelled")
+	}
+	denied, _, err := DecideRefundRequest(ctx, requests[1].ID, false, "Too late", now)
+	if err != nil || denied.Status != RefundDenied {
+		t.Errorf("Expected the request to be denied, got %+v (%v)", denied, err)
+	}
+	if _, err := GetRegistrationById(ctx, denied.RegistrationID); err != nil {
+		t.Errorf("Expected the denied booking to stand: %v", err)
+	}
+	if _, _, err := DecideRefundRequest(ctx, requests[1].ID, true, "", now); !errors.Is(err, ErrRefundDecided) {
+		t.Errorf("Expected ErrRefundDecided, got %v", err)
+	}
+	var notFound NotFoundError
+	if _, _, err := DecideRefundRequest(ctx, "missing", true, "", now); !errors.As(err, &notFound) {
+		t.Errorf("Expected a NotFoundError, got %v", err)
+	}
+
+	pending, err := event.GetRefundRequests(ctx, RefundRequested)
+	if err != nil || len(pending) != 0 {
+		t.Errorf("Expected no undecided requests, got %+v (%v)", pending, err)
+	}
+	all, err := event.GetRefundRequests(ctx, "")
+	if err != nil || len(all) != 2 || all[0].ID != requests[0].ID {
+		t.Errorf("Expected both requests in order, got %+v (%v)", all, err)
+	}
+
+	// Only approved requests can be refunded
+	if err := CompleteRefund(ctx, denied.ID, "re_1", now); err != nil {
+		t.Fatalf("Failed to complete refund: %v", err)
+	}
+	if r, _ := GetRefundRequest(ctx, denied.ID); r.Status != RefundDenied {
+		t.Errorf("Expected the denied request to stay denied, got %s", r.Status)
+	}
+	if err := FailRefund(ctx, approved.ID, "card expired"); err != nil {
+		t.Fatalf("Failed to fail refund: %v", err)
+	}
+	if r, _ := GetRefundRequest(ctx, approved.ID); r.Status != RefundFailed || r.Error != "card expired" {
+		t.Errorf("Expected the refund to have failed, got %+v", r)
+	}
+}
@@ -0,0 +1,346 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"event_booking_restapi_golang/db"
+	"event_booking_restapi_golang/ids"
+	"fmt"
+	"time"
+)
+
+// ErrEventFull is returned when a registration would exceed an event's capacity.
+var ErrEventFull = conflictError("event is fully booked")
+
+// ErrAlreadyRegistered is returned when the email address is already registered for the event.
+var ErrAlreadyRegistered = duplicateError("this email is already registered for the event")
+
+// Registration represents an attendee's booking for an event.
+type Registration struct {
+	ID         string    // Unique identifier for the registration
+	EventID    string    // ID of the event the attendee registered for
+	Name       string    `binding:"required"`       // Attendee name (required)
+	Email      string    `binding:"required,email"` // Attendee email (required)
+	CreatedAt  time.Time // Time the registration was made
+	ExternalID string    // ID of the booking in the external ticketing system it is synced from, empty for none
+	Source     string    // External system the booking is synced from; external IDs are unique per source
+	Ticket     *Ticket   `json:",omitempty"` // Ticket issued with the booking, only set on new registrations
+
+	CheckedInAt *time.Time // Time the attendee was checked in at the door, nil until then
+
+	WaiverAcceptance
+	Attribution
+}
+
+// Register books a place on the event for the given registration.
+// The capacity check and the insert run in a single transaction, which takes
+// SQLite's write lock up front or locks the event row on PostgreSQL, so
+// concurrent registrations cannot overbook.
+// Returns ErrBlockedByOrganizer when the event's organizer blocked the email address,
+// ErrEventCancelled when the event has been cancelled, ErrEventFull when no places
+// are left and ErrAlreadyRegistered when the email address already holds a place.
+func (e Event) Register(ctx context.Context, r Registration) (Registration, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return Registration{}, err
+	}
+	defer tx.Rollback()
+
+	blocked, err := isBlocked(ctx, tx, e.UserID, r.Email, BlockedByOrganizer)
+	if err != nil {
+		return Registration{}, err
+	}
+	if blocked {
+		return Registration{}, ErrBlockedByOrganizer
+	}
+
+	var capacity, taken int
+	var status string
+	err = tx.QueryRowContext(ctx, db.Rebind("SELECT capacity, status FROM events WHERE id=?"+db.ForUpdate()), e.ID).Scan(&capacity, &status)
+	if err != nil {
+		return Registration{}, err
+	}
+	if status == StatusCancelled {
+		return Registration{}, ErrEventCancelled
+	}
+	err = tx.QueryRowContext(ctx, db.Rebind("SELECT COUNT(*) FROM registrations WHERE event_id=?"), e.ID).Scan(&taken)
+	if err != nil {
+		return Registration{}, err
+	}
+	if capacity > 0 && taken >= capacity {
+		return Registration{}, ErrEventFull
+	}
+
+	var existing int
+	err = tx.QueryRowContext(ctx, db.Rebind("SELECT COUNT(*) FROM registrations WHERE event_id=? AND email=?"), e.ID, r.Email).Scan(&existing)
+	if err != nil {
+		return Registration{}, err
+	}
+	if existing > 0 {
+		return Registration{}, ErrAlreadyRegistered
+	}
+
+	r.ID = ids.New()
+	r.EventID = e.ID
+	r.CreatedAt = time.Now()
+	_, err = tx.ExecContext(
+		ctx,
+		db.Rebind("INSERT INTO registrations (id, event_id, name, email, created_at, waiver_id, waiver_accepted_at, waiver_ip, "+attributionColumns+", external_id, external_source) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)"),
+		r.ID, r.EventID, r.Name, r.Email, r.CreatedAt, r.WaiverID, r.WaiverAcceptedAt, r.WaiverIP,
+		r.UTMSource, r.UTMMedium, r.UTMCampaign, r.UTMTerm, r.UTMContent, r.Ref, r.ExternalID, r.Source,
+	)
+	if err != nil {
+		return Registration{}, err
+	}
+	ticket, err := issueTicket(ctx, tx, r)
+	if err != nil {
+		return Registration{}, err
+	}
+	r.Ticket = &ticket
+	err = recordAudit(ctx, tx, AuditRegistered, r.EventID, r.ID, attendeeDetails(r.Name, r.Email))
+	if err != nil {
+		return Registration{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return Registration{}, err
+	}
+
+	return r, nil
+}
+
+// CountRegistrations returns the number of registrations held for the event.
+func (e Event) CountRegistrations(ctx context.Context) (int, error) {
+	var count int
+	err := db.Conn(ctx).QueryRowContext(ctx, db.Rebind("SELECT COUNT(*) FROM registrations WHERE event_id=?"), e.ID).Scan(&count)
+	return count, err
+}
+
+// IsRegistered reports whether the registration ID holds a place on the event.
+func (e Event) IsRegistered(ctx context.Context, registrationID string) (bool, error) {
+	var count int
+	q := "SELECT COUNT(*) FROM registrations WHERE id=? AND event_id=?"
+	err := db.Conn(ctx).QueryRowContext(ctx, db.Rebind(q), registrationID, e.ID).Scan(&count)
+	return count > 0, err
+}
+
+// registrationColumns lists the registration columns in the order scanRegistration expects them.
+const registrationColumns = "id, event_id, name, email, created_at, external_id, external_source, checked_in_at, waiver_id, waiver_accepted_at, waiver_ip, " + attributionColumns
+
+// scanRegistration reads a registration row selected with registrationColumns.
+func scanRegistration(row rowScanner) (Registration, error) {
+	var r Registration
+	err := row.Scan(
+		&r.ID, &r.EventID, &r.Name, &r.Email, &r.CreatedAt, &r.ExternalID, &r.Source, &r.CheckedInAt, &r.WaiverID, &r.WaiverAcceptedAt, &r.WaiverIP,
+		&r.UTMSource, &r.UTMMedium, &r.UTMCampaign, &r.UTMTerm, &r.UTMContent, &r.Ref,
+	)
+	return r, err
+}
+
+// GetRegistrations retrieves all registrations of the event in booking order.
+// Returns a slice of Registration objects and any error encountered during the query.
+func (e Event) GetRegistrations(ctx context.Context) ([]Registration, error) {
+	q := "SELECT " + registrationColumns + " FROM registrations WHERE event_id=? ORDER BY created_at, id"
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q), e.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var registrations []Registration
+	for rows.Next() {
+		r, err := scanRegistration(rows)
+		if err != nil {
+			return nil, err
+		}
+		registrations = append(registrations, r)
+	}
+	return registrations, nil
+}
+
+// GetRegistrationsPage retrieves a page of the event's registrations in booking order
+// and the total number of registrations.
+func (e Event) GetRegistrationsPage(ctx context.Context, limit, offset int) ([]Registration, int, error) {
+	total, err := e.CountRegistrations(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	q := "SELECT " + registrationColumns + " FROM registrations WHERE event_id=? ORDER BY created_at, id LIMIT ? OFFSET ?"
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q), e.ID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	registrations := []Registration{}
+	for rows.Next() {
+		r, err := scanRegistration(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		registrations = append(registrations, r)
+	}
+	return registrations, total, rows.Err()
+}
+
+// GetRegistrationById retrieves a single registration from the database by its ID.
+// Returns the Registration if found, otherwise returns an empty Registration and an error,
+// which is a NotFoundError when no registration has the ID.
+func GetRegistrationById(ctx context.Context, id string) (Registration, error) {
+	q := "SELECT " + registrationColumns + " FROM registrations WHERE id=?"
+	r, err := scanRegistration(db.Conn(ctx).QueryRowContext(ctx, db.Rebind(q), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Registration{}, NotFoundError{Message: fmt.Sprint("Couldn't find a registration with the ID of ", id)}
+	}
+	if err != nil {
+		return Registration{}, err
+	}
+	return r, nil
+}
+
+// GetBookedEvents retrieves the approved events the email address holds a place on,
+// in date order. The address is compared case-insensitively.
+func GetBookedEvents(ctx context.Context, email string) ([]Event, error) {
+	q := "SELECT " + eventColumns + " FROM events WHERE review_status=? AND id IN " +
+		"(SELECT event_id FROM registrations WHERE LOWER(email)=LOWER(?)) ORDER BY datetime, id"
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q), ReviewApproved, email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// Cancel removes the registration and its ticket and, in the same transaction, promotes the
+// longest waiting attendee on the event's waitlist into the freed place.
+// The registration must be loaded in full so the audit log can name the attendee.
+// Returns the promoted registration, or nil when nobody was waiting.
+func (r Registration) Cancel(ctx context.Context) (*Registration, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	promoted, err := r.cancel(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+	return promoted, nil
+}
+
+// cancel removes the registration and its ticket and promotes from the waitlist
+// within tx, as Cancel does. The cancellation is kept in registration_cancellations
+// for the event's statistics, as made when the booking was made or moved onto the event.
+func (r Registration) cancel(ctx context.Context, tx *db.Tx) (*Registration, error) {
+	q := `
+	INSERT INTO registration_cancellations (registration_id, event_id, registered_at, cancelled_at)
+	SELECT id, event_id, COALESCE(moved_at, created_at), ? FROM registrations WHERE id=?
+	ON CONFLICT (registration_id) DO NOTHING
+	`
+	_, err := tx.ExecContext(ctx, db.Rebind(q), time.Now().UTC(), r.ID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, db.Rebind("DELETE FROM tickets WHERE registration_id=?"), r.ID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, db.Rebind("DELETE FROM registrations WHERE id=?"), r.ID)
+	if err != nil {
+		return nil, err
+	}
+	err = recordAudit(ctx, tx, AuditCancelled, r.EventID, r.ID, attendeeDetails(r.Name, r.Email))
+	if err != nil {
+		return nil, err
+	}
+	return promoteFromWaitlist(ctx, tx, r.EventID)
+}
+
+// promoteFromWaitlist moves the oldest waitlist entry of the event into the
+// registrations table if the event has a free place and hasn't been cancelled, keeping
+// its waiver acceptance and attribution.
+func promoteFromWaitlist(ctx context.Context, tx *db.Tx, eventID string) (*Registration, error) {
+	var capacity, taken int
+	var status string
+	err := tx.QueryRowContext(ctx, db.Rebind("SELECT capacity, status FROM events WHERE id=?"+db.ForUpdate()), eventID).Scan(&capacity, &status)
+	if err != nil {
+		return nil, err
+	}
+	if status == StatusCancelled {
+		return nil, nil
+	}
+	err = tx.QueryRowContext(ctx, db.Rebind("SELECT COUNT(*) FROM registrations WHERE event_id=?"), eventID).Scan(&taken)
+	if err != nil {
+		return nil, err
+	}
+	if capacity > 0 && taken >= capacity {
+		return nil, nil
+	}
+
+	// Attendees the organizer blocked after they joined the waitlist are passed over
+	var entryID string
+	var promoted Registration
+	err = tx.QueryRowContext(
+		ctx,
+		db.Rebind("SELECT id, name, email, waiver_id, waiver_accepted_at, waiver_ip, "+attributionColumns+" FROM waitlist w WHERE event_id=? AND NOT EXISTS ("+
+			"SELECT 1 FROM user_blocks b JOIN events ev ON ev.user_id = b.organizer_id WHERE ev.id = w.event_id AND b.email = LOWER(w.email) AND b.blocked_by = ?"+
+			") ORDER BY created_at, id LIMIT 1"),
+		eventID, BlockedByOrganizer,
+	).Scan(
+		&entryID, &promoted.Name, &promoted.Email, &promoted.WaiverID, &promoted.WaiverAcceptedAt, &promoted.WaiverIP,
+		&promoted.UTMSource, &promoted.UTMMedium, &promoted.UTMCampaign, &promoted.UTMTerm, &promoted.UTMContent, &promoted.Ref,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	promoted.ID = ids.New()
+	promoted.EventID = eventID
+	promoted.CreatedAt = time.Now()
+	_, err = tx.ExecContext(
+		ctx,
+		db.Rebind("INSERT INTO registrations (id, event_id, name, email, created_at, waiver_id, waiver_accepted_at, waiver_ip, "+attributionColumns+") VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?)"),
+		promoted.ID, promoted.EventID, promoted.Name, promoted.Email, promoted.CreatedAt,
+		promoted.WaiverID, promoted.WaiverAcceptedAt, promoted.WaiverIP,
+		promoted.UTMSource, promoted.UTMMedium, promoted.UTMCampaign, promoted.UTMTerm, promoted.UTMContent, promoted.Ref,
+	)
+	if err != nil {
+		return nil, err
+	}
+	ticket, err := issueTicket(ctx, tx, promoted)
+	if err != nil {
+		return nil, err
+	}
+	promoted.Ticket = &ticket
+	_, err = tx.ExecContext(ctx, db.Rebind("DELETE FROM waitlist WHERE id=?"), entryID)
+	if err != nil {
+		return nil, err
+	}
+	details := attendeeDetails(promoted.Name, promoted.Email)
+	details["waitlist_entry_id"] = entryID
+	err = recordAudit(ctx, tx, AuditPromoted, eventID, promoted.ID, details)
+	if err != nil {
+		return nil, err
+	}
+	return &promoted, nil
+}
@@ -0,0 +1,328 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"event_booking_restapi_golang/db"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestEvent_Register tests registering attendees up to the event capacity
+func TestEvent_Register(t *testing.T) {
+	setupTestDatabase(t)
+	event := saveEvent(t, Event{Title: "Small Event", Capacity: 2})
+
+	registration, err := event.Register(context.Background(), Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	if registration.ID == "" || registration.EventID != event.ID {
+		t.Errorf("Expected registration with ID for event %s, got %+v", event.ID, registration)
+	}
+
+	_, err = event.Register(context.Background(), Registration{Name: "Alice", Email: "alice@example.com"})
+	if !errors.Is(err, ErrAlreadyRegistered) {
+		t.Errorf("Expected ErrAlreadyRegistered, got %v", err)
+	}
+
+	_, err = event.Register(context.Background(), Registration{Name: "Bob", Email: "bob@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	_, err = event.Register(context.Background(), Registration{Name: "Carol", Email: "carol@example.com"})
+	if !errors.Is(err, ErrEventFull) {
+		t.Errorf("Expected ErrEventFull, got %v", err)
+	}
+
+	count, err := event.CountRegistrations(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to count registrations: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 registrations, got %d", count)
+	}
+}
+
+// TestEvent_RegisterUnlimited tests that a zero capacity does not limit registrations
+func TestEvent_RegisterUnlimited(t *testing.T) {
+	setupTestDatabase(t)
+	event := saveEvent(t, Event{Title: "Open Event"})
+
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		_, err := event.Register(context.Background(), Registration{Name: "Attendee", Email: email})
+		if err != nil {
+			t.Errorf("Failed to register %s: %v", email, err)
+		}
+	}
+}
+
+// TestEvent_RegisterCancelled tests that cancelled events take no bookings
+func TestEvent_RegisterCancelled(t *testing.T) {
+	setupTestDatabase(t)
+	event := saveEvent(t, Event{Title: "Cancelled Event"})
+	err := event.Patch(context.Background(), map[string]any{"Status": StatusCancelled})
+	if err != nil {
+		t.Fatalf("Failed to cancel event: %v", err)
+	}
+
+	_, err = event.Register(context.Background(), Registration{Name: "Alice", Email: "alice@example.com"})
+	if !errors.Is(err, ErrEventCancelled) {
+		t.Errorf("Expected ErrEventCancelled, got %v", err)
+	}
+	_, err = event.JoinWaitlist(context.Background(), WaitlistEntry{Name: "Alice", Email: "alice@example.com"})
+	if !errors.Is(err, ErrEventCancelled) {
+		t.Errorf("Expected ErrEventCancelled for the waitlist, got %v", err)
+	}
+}
+
+// TestEvent_Cancel tests that cancelling is idempotent and doesn't promote the waitlist
+func TestEvent_Cancel(t *testing.T) {
+	setupTestDatabase(t)
+	event := saveEvent(t, Event{Title: "Full Event", Capacity: 1})
+	registration, err := event.Register(context.Background(), Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	_, err = event.JoinWaitlist(context.Background(), WaitlistEntry{Name: "Bob", Email: "bob@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to join waitlist: %v", err)
+	}
+
+	cancelled, err := event.Cancel(context.Background())
+	if err != nil || !cancelled {
+		t.Fatalf("Expected the event to be cancelled, got %v (%v)", cancelled, err)
+	}
+	cancelled, err = event.Cancel(context.Background())
+	if err != nil || cancelled {
+		t.Errorf("Expected a repeated cancellation to change nothing, got %v (%v)", cancelled, err)
+	}
+
+	promoted, err := registration.Cancel(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to cancel registration: %v", err)
+	}
+	if promoted != nil {
+		t.Errorf("Expected nobody to be promoted for a cancelled event, got %+v", promoted)
+	}
+}
+
+// TestEvent_RegisterConcurrent tests that concurrent registrations cannot exceed capacity
+func TestEvent_RegisterConcurrent(t *testing.T) {
+	// A file database is needed so that every pooled connection sees the same data
+	fileDB, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.sql")+"?_txlock=immediate&_busy_timeout=5000")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	originalDB := db.DB
+	db.DB = fileDB
+	t.Cleanup(func() {
+		db.DB = originalDB
+		fileDB.Close()
+	})
+
+	_, err = fileDB.Exec(`
+	CREATE TABLE events (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		description TEXT NOT NULL,
+		location TEXT NOT NULL,
+		datetime DATETIME NOT NULL,
+		user_id TEXT,
+		capacity INTEGER NOT NULL DEFAULT 0,
+		review_status TEXT NOT NULL DEFAULT 'approved',
+		review_reason TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		confirmation_url TEXT NOT NULL DEFAULT '',
+		confirmation_message TEXT NOT NULL DEFAULT '',
+		external_id TEXT NOT NULL DEFAULT '',
+		external_source TEXT NOT NULL DEFAULT '',
+		end_datetime DATETIME,
+		status TEXT NOT NULL DEFAULT 'published',
+		slug TEXT NOT NULL DEFAULT '',
+		license TEXT NOT NULL DEFAULT '',
+		version INTEGER NOT NULL DEFAULT 0,
+		series_id TEXT NOT NULL DEFAULT ''
+	);
+	CREATE TABLE registrations (
+		id TEXT PRIMARY KEY,
+		event_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		email TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		waiver_id TEXT NOT NULL DEFAULT '',
+		waiver_accepted_at DATETIME,
+		waiver_ip TEXT NOT NULL DEFAULT '',
+		utm_source TEXT NOT NULL DEFAULT '',
+		utm_medium TEXT NOT NULL DEFAULT '',
+		utm_campaign TEXT NOT NULL DEFAULT '',
+		utm_term TEXT NOT NULL DEFAULT '',
+		utm_content TEXT NOT NULL DEFAULT '',
+		ref TEXT NOT NULL DEFAULT '',
+		external_id TEXT NOT NULL DEFAULT '',
+		external_source TEXT NOT NULL DEFAULT '',
+		checked_in_at DATETIME,
+		UNIQUE (event_id, email)
+	);
+	CREATE UNIQUE INDEX events_external_id ON events (external_source, external_id) WHERE external_id <> '';
+	CREATE UNIQUE INDEX registrations_external_id ON registrations (external_source, external_id) WHERE external_id <> '';
+	CREATE TABLE user_blocks (
+		organizer_id TEXT NOT NULL,
+		email TEXT NOT NULL,
+		blocked_by TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		PRIMARY KEY (organizer_id, email, blocked_by)
+	);
+	CREATE TABLE event_versions (
+		event_id TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		recorded_at DATETIME NOT NULL,
+		deleted BOOLEAN NOT NULL DEFAULT FALSE,
+		name TEXT NOT NULL,
+		description TEXT NOT NULL,
+		location TEXT NOT NULL,
+		datetime DATETIME NOT NULL,
+		user_id TEXT,
+		capacity INTEGER NOT NULL DEFAULT 0,
+		review_status TEXT NOT NULL DEFAULT 'approved',
+		review_reason TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		confirmation_url TEXT NOT NULL DEFAULT '',
+		confirmation_message TEXT NOT NULL DEFAULT '',
+		external_id TEXT NOT NULL DEFAULT '',
+		external_source TEXT NOT NULL DEFAULT '',
+		end_datetime DATETIME,
+		status TEXT NOT NULL DEFAULT 'published',
+		slug TEXT NOT NULL DEFAULT '',
+		license TEXT NOT NULL DEFAULT '',
+		series_id TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (event_id, version)
+	);
+	CREATE TABLE tickets (
+		id TEXT PRIMARY KEY,
+		registration_id TEXT NOT NULL UNIQUE,
+		event_id TEXT NOT NULL,
+		code TEXT NOT NULL UNIQUE,
+		created_at DATETIME NOT NULL
+	);
+	CREATE TABLE venue_blackouts (
+		id TEXT PRIMARY KEY,
+		location TEXT NOT NULL,
+		blackout_date TEXT NOT NULL,
+		reason TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL
+	);
+	CREATE TABLE organizer_onboarding (
+		user_id TEXT NOT NULL,
+		step TEXT NOT NULL,
+		completed_at DATETIME NOT NULL,
+		PRIMARY KEY (user_id, step)
+	);
+	CREATE TABLE schema_version (
+		version INTEGER PRIMARY KEY,
+		migrated_at DATETIME NOT NULL
+	)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create test tables: %v", err)
+	}
+
+	const capacity = 5
+	event := saveEvent(t, Event{Title: "Popular Event", Capacity: capacity})
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	booked := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := event.Register(context.Background(), Registration{Name: "Attendee", Email: fmt.Sprintf("attendee%d@example.com", i)})
+			if err == nil {
+				mu.Lock()
+				booked++
+				mu.Unlock()
+			} else if !errors.Is(err, ErrEventFull) {
+				t.Errorf("Unexpected registration error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if booked != capacity {
+		t.Errorf("Expected %d successful registrations, got %d", capacity, booked)
+	}
+	count, err := event.CountRegistrations(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to count registrations: %v", err)
+	}
+	if count != capacity {
+		t.Errorf("Expected %d registrations stored, got %d", capacity, count)
+	}
+}
+
+// TestEvent_GetRegistrationsPage tests paging through registrations in booking order
+func TestEvent_GetRegistrationsPage(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+	event := saveEvent(t, Event{Title: "Busy Event"})
+	other := saveEvent(t, Event{Title: "Other Event"})
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		_, err := event.Register(ctx, Registration{Name: name, Email: strings.ToLower(name) + "@example.com"})
+		if err != nil {
+			t.Fatalf("Failed to register: %v", err)
+		}
+	}
+	_, err := other.Register(ctx, Registration{Name: "Dave", Email: "dave@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	registrations, total, err := event.GetRegistrationsPage(ctx, 2, 1)
+	if err != nil {
+		t.Fatalf("Failed to get registrations: %v", err)
+	}
+	if total != 3 {
+		t.Errorf("Expected 3 registrations in total, got %d", total)
+	}
+	if len(registrations) != 2 || registrations[0].Name != "Bob" || registrations[1].Name != "Carol" {
+		t.Errorf("Expected Bob and Carol, got %+v", registrations)
+	}
+}
+
+// TestGetBookedEvents tests listing the events an email address booked
+func TestGetBookedEvents(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+	first := saveEvent(t, Event{Title: "First Event"})
+	second := saveEvent(t, Event{Title: "Second Event"})
+	saveEvent(t, Event{Title: "Other Event"})
+	for _, event := range []Event{first, second} {
+		_, err := event.Register(ctx, Registration{Name: "Alice", Email: "alice@example.com"})
+		if err != nil {
+			t.Fatalf("Failed to register: %v", err)
+		}
+	}
+	_, err := second.Register(ctx, Registration{Name: "Bob", Email: "bob@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	events, err := GetBookedEvents(ctx, "Alice@Example.com")
+	if err != nil {
+		t.Fatalf("Failed to get booked events: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 booked events, got %d", len(events))
+	}
+	for _, event := range events {
+		if event.ID != first.ID && event.ID != second.ID {
+			t.Errorf("Unexpected event %q", event.Title)
+		}
+	}
+}
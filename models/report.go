@@ -0,0 +1,109 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"event_booking_restapi_golang/db"
+	"sort"
+	"time"
+)
+
+// DailyRegistrations is the number of registrations made on one day.
+type DailyRegistrations struct {
+	Day           string // UTC day, YYYY-MM-DD
+	Registrations int    // Registrations made that day
+	Events        int    // Distinct events booked that day
+}
+
+// GetRegistrationsByDay counts the registrations made from from (inclusive) to to
+// (exclusive) per UTC day, optionally only for one event, oldest day first. Days
+// without registrations are left out. Cancelled registrations are not counted.
+// The counts come from registration_daily_summary, so they are as of its last refresh.
+// Returns a slice of DailyRegistrations and any error encountered during the query.
+func GetRegistrationsByDay(ctx context.Context, from, to time.Time, eventID string) ([]DailyRegistrations, error) {
+	q := `
+	SELECT day, SUM(registrations), COUNT(DISTINCT event_id)
+	FROM registration_daily_summary
+	WHERE day >= ? AND day < ?
+	AND (? = '' OR event_id = ?)
+	GROUP BY day
+	ORDER BY day
+	`
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q), from.UTC().Format(time.DateOnly), to.UTC().Format(time.DateOnly), eventID, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var days []DailyRegistrations
+	for rows.Next() {
+		var d DailyRegistrations
+		err := rows.Scan(&d.Day, &d.Registrations, &d.Events)
+		if err != nil {
+			return nil, err
+		}
+		days = append(days, d)
+	}
+	return days, rows.Err()
+}
+
+// ChurnedOrganizer is an organizer who hasn't created an event recently.
+type ChurnedOrganizer struct {
+	UserID        string    // Organizer, from the events' user_id
+	Events        int       // Events the organizer created in total
+	LastCreatedAt time.Time // When the organizer's latest event was created
+	LastEventAt   time.Time // Start of the organizer's latest event
+}
+
+// GetChurnedOrganizers lists the organizers whose latest event was created before
+// inactiveSince, least recently active first. Events from before creation times were
+// recorded count by their start time instead.
+// Returns a slice of ChurnedOrganizer objects and any error encountered during the query.
+func GetChurnedOrganizers(ctx context.Context, inactiveSince time.Time) ([]ChurnedOrganizer, error) {
+	// The latest timestamps are picked here rather than with MAX, which SQLite
+	// returns as text that can't be scanned into a time.Time
+	q := "SELECT user_id, created_at, datetime FROM events WHERE user_id IS NOT NULL AND user_id <> '' ORDER BY user_id"
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var organizers []ChurnedOrganizer
+	var current *ChurnedOrganizer
+	for rows.Next() {
+		var userID string
+		var createdAt sql.NullTime
+		var eventAt time.Time
+		err := rows.Scan(&userID, &createdAt, &eventAt)
+		if err != nil {
+			return nil, err
+		}
+		if !createdAt.Valid {
+			createdAt.Time = eventAt
+		}
+		if current == nil || current.UserID != userID {
+			organizers = append(organizers, ChurnedOrganizer{UserID: userID})
+			current = &organizers[len(organizers)-1]
+		}
+		current.Events++
+		if createdAt.Time.After(current.LastCreatedAt) {
+			current.LastCreatedAt = createdAt.Time
+		}
+		if eventAt.After(current.LastEventAt) {
+			current.LastEventAt = eventAt
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	churned := organizers[:0]
+	for _, o := range organizers {
+		if o.LastCreatedAt.Before(inactiveSince) {
+			churned = append(churned, o)
+		}
+	}
+	sort.SliceStable(churned, func(i, j int) bool { return churned[i].LastCreatedAt.Before(churned[j].LastCreatedAt) })
+	return churned, nil
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"context"
+	"event_booking_restapi_golang/db"
+	"testing"
+	"time"
+)
+
+// TestGetRegistrationsByDay tests counting registrations per UTC day within a range
+func TestGetRegistrationsByDay(t *testing.T) {
+	setupTestDatabase(t)
+	day := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	bookings := []struct {
+		id, event string
+		at        time.Time
+	}{
+		{"r1", "e1", day.Add(1 * time.Hour)},
+		{"r2", "e1", day.Add(23 * time.Hour)},
+		{"r3", "e2", day.Add(2 * time.Hour)},
+		{"r4", "e1", day.Add(26 * time.Hour)},
+		{"r5", "e1", day.Add(-time.Hour)}, // Before the range
+		{"r6", "e2", day.Add(72 * time.Hour)},
+	}
+	for _, b := range bookings {
+		// Stored in a non-UTC zone to check that days are UTC days
+		_, err := db.DB.Exec("INSERT INTO registrations (id, event_id, name, email, created_at) VALUES (?,?,?,?,?)",
+			b.id, b.event, "Attendee", b.id+"@example.com", b.at.In(time.FixedZone("CEST", 2*60*60)))
+		if err != nil {
+			t.Fatalf("Failed to insert registration: %v", err)
+		}
+	}
+
+	err := RefreshRegistrationSummary(context.Background(), time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to refresh the registration summary: %v", err)
+	}
+
+	days, err := GetRegistrationsByDay(context.Background(), day, day.Add(72*time.Hour), "")
+	if err != nil {
+		t.Fatalf("Failed to get registrations by day: %v", err)
+	}
+	expected := []DailyRegistrations{
+		{Day: "2026-03-10", Registrations: 3, Events: 2},
+		{Day: "2026-03-11", Registrations: 1, Events: 1},
+	}
+	if len(days) != len(expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, days)
+	}
+	for i := range expected {
+		if days[i] != expected[i] {
+			t.Errorf("Expected %+v, got %+v", expected[i], days[i])
+		}
+	}
+
+	days, err = GetRegistrationsByDay(context.Background(), day, day.Add(72*time.Hour), "e2")
+	if err != nil || len(days) != 1 || days[0].Registrations != 1 {
+		t.Errorf("Expected one registration for e2, got %+v (%v)", days, err)
+	}
+}
+
+// TestGetChurnedOrganizers tests listing organizers without recent events
+func TestGetChurnedOrganizers(t *testing.T) {
+	setupTestDatabase(t)
+	now := time.Now()
+	events := []struct {
+		id, user  string
+		createdAt time.Time
+		at        time.Time
+	}{
+		{"e1", "active", now.Add(-24 * time.Hour), now},
+		{"e2", "active", now.Add(-200 * 24 * time.Hour), now},
+		{"e3", "lapsed", now.Add(-100 * 24 * time.Hour), now.Add(-90 * 24 * time.Hour)},
+		{"e4", "lapsed", now.Add(-120 * 24 * time.Hour), now.Add(-110 * 24 * time.Hour)},
+		{"e5", "", now.Add(-300 * 24 * time.Hour), now},
+	}
+	for _, e := range events {
+		_, err := db.DB.Exec("INSERT INTO events (id, name, description, location, datetime, user_id, created_at) VALUES (?,?,?,?,?,?,?)",
+			e.id, "Event", "Description", "Hall", e.at, e.user, e.createdAt)
+		if err != nil {
+			t.Fatalf("Failed to insert event: %v", err)
+		}
+	}
+
+	churned, err := GetChurnedOrganizers(context.Background(), now.Add(-90*24*time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to get churned organizers: %v", err)
+	}
+	if len(churned) != 1 || churned[0].UserID != "lapsed" {
+		t.Fatalf("Expected only the lapsed organizer, got %+v", churned)
+	}
+	lapsed := churned[0]
+	if lapsed.Events != 2 || !lapsed.LastCreatedAt.Equal(events[2].createdAt) || !lapsed.LastEventAt.Equal(events[2].at) {
+		t.Errorf("Unexpected lapsed organizer %+v", lapsed)
+	}
+}
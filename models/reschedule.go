@@ -0,0 +1,180 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"event_booking_restapi_golang/db"
+	"event_booking_restapi_golang/ids"
+	"fmt"
+	"time"
+)
+
+// RescheduleCutoff is how long before an occurrence starts bookings can no longer be
+// moved off or onto it; 0 allows moving them until it starts. It is set by main from
+// RESCHEDULE_CUTOFF.
+var RescheduleCutoff = 24 * time.Hour
+
+// ErrNotInSeries is returned when moving a booking to an event that isn't another
+// occurrence of the series of the booked event.
+var ErrNotInSeries = errors.New("the event isn't another occurrence of the booked event's series")
+
+// ErrRescheduleClosed is returned when moving a booking off or onto an occurrence that
+// starts within RescheduleCutoff.
+var ErrRescheduleClosed = conflictError("bookings can no longer be moved off or onto an occurrence starting this soon")
+
+// occurrence is the state of an event that decides whether bookings can be moved onto it.
+type occurrence struct {
+	userID       string
+	seriesID     string
+	start        time.Time
+	status       string
+	reviewStatus string
+	capacity     int
+}
+
+// lockOccurrence reads the event within tx, locking its row on PostgreSQL.
+// Returns a NotFoundError when there is no event with the ID.
+func lockOccurrence(ctx context.Context, tx *db.Tx, id string) (occurrence, error) {
+	var o occurrence
+	err := tx.QueryRowContext(ctx, db.Rebind("SELECT user_id, series_id, datetime, status, review_status, capacity FROM events WHERE id=?"+db.ForUpdate()), id).
+		Scan(&o.userID, &o.seriesID, &o.start, &o.status, &o.reviewStatus, &o.capacity)
+	if errors.Is(err, sql.ErrNoRows) {
+		return occurrence{}, NotFoundError{Message: fmt.Sprint("Couldn't find an event with the ID of ", id), Kind: ErrEventNotFound}
+	}
+	return o, err
+}
+
+// Reschedule moves the booking to another occurrence of its event's series, the event
+// with the given ID, at now. Occurrences are the events of the same organizer sharing a
+// SeriesID. The booking keeps its ID, attendee details and booking time and gets a new
+// ticket for the new occurrence; the old ticket stops admitting. For the statistics the
+// move is kept in registration_moves, counting as a booking lost by the occurrence left,
+// and the booking counts as made at now on the new one. Both occurrences are
+// locked in one transaction, so the place is freed on one and taken on the other
+// together, and the freed place goes to the longest waiting attendee on the waitlist of
+// the occurrence left.
+// As on booking, the attendee must accept the current waiver of the new occurrence, if it
+// has one, by its ID in acceptance, which is recorded with the IP address given and now.
+// The registration must be loaded in full so the audit log can name the attendee.
+// Returns the moved registration and the promoted registration, if any. Returns a
+// NotFoundError when the occurrence doesn't exist or isn't public, ErrNotInSeries when
+// it isn't another occurrence of the series, ErrRescheduleClosed when either occurrence
+// starts within RescheduleCutoff, ErrAlreadyCheckedIn when the attendee was checked in,
+// ErrEventCancelled when the occurrence has been cancelled, a WaiverRequiredError when
+// its waiver wasn't accepted, ErrEventFull when it has no places left and
+// ErrAlreadyRegistered when the email address already holds a place on it.
+func (r Registration) Reschedule(ctx context.Context, eventID string, acceptance WaiverAcceptance, now time.Time) (Registration, *Registration, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return Registration{}, nil, err
+	}
+	defer tx.Rollback()
+
+	// Both rows are locked in ID order, so opposite moves between two occurrences can't deadlock
+	order := []string{r.EventID, eventID}
+	if eventID < r.EventID {
+		order[0], order[1] = eventID, r.EventID
+	}
+	occurrences := map[string]occurrence{}
+	for _, id := range order {
+		occurrences[id], err = lockOccurrence(ctx, tx, id)
+		if err != nil {
+			return Registration{}, nil, err
+		}
+	}
+	from, to := occurrences[r.EventID], occurrences[eventID]
+
+	if to.status == StatusDraft || to.reviewStatus != ReviewApproved {
+		return Registration{}, nil, NotFoundError{Message: fmt.Sprint("Couldn't find an event with the ID of ", eventID), Kind: ErrEventNotFound}
+	}
+	if eventID == r.EventID || from.seriesID == "" || to.seriesID != from.seriesID || to.userID != from.userID {
+		return Registration{}, nil, ErrNotInSeries
+	}
+	if from.start.Sub(now) < RescheduleCutoff || to.start.Sub(now) < RescheduleCutoff {
+		return Registration{}, nil, ErrRescheduleClosed
+	}
+	if r.CheckedInAt != nil {
+		return Registration{}, nil, ErrAlreadyCheckedIn
+	}
+	if to.status == StatusCancelled {
+		return Registration{}, nil, ErrEventCancelled
+	}
+	waiver, err := currentWaiver(ctx, tx, eventID)
+	if err != nil {
+		return Registration{}, nil, err
+	}
+	if waiver == nil {
+		acceptance = WaiverAcceptance{}
+	} else if acceptance.WaiverID != waiver.ID {
+		return Registration{}, nil, WaiverRequiredError{Waiver: *waiver}
+	} else {
+		acceptedAt := now.UTC()
+		acceptance.WaiverAcceptedAt = &acceptedAt
+	}
+	var taken, existing int
+	err = tx.QueryRowContext(ctx, db.Rebind("SELECT COUNT(*) FROM registrations WHERE event_id=?"), eventID).Scan(&taken)
+	if err != nil {
+		return Registration{}, nil, err
+	}
+	if to.capacity > 0 && taken >= to.capacity {
+		return Registration{}, nil, ErrEventFull
+	}
+	err = tx.QueryRowContext(ctx, db.Rebind("SELECT COUNT(*) FROM registrations WHERE event_id=? AND email=?"), eventID, r.Email).Scan(&existing)
+	if err != nil {
+		return Registration{}, nil, err
+	}
+	if existing > 0 {
+		return Registration{}, nil, ErrAlreadyRegistered
+	}
+
+	q := `
+	INSERT INTO registration_moves (id, registration_id, event_id, to_event_id, registered_at, moved_at)
+	SELECT ?, id, event_id, ?, COALESCE(moved_at, created_at), ? FROM registrations WHERE id=? AND event_id=?
+	`
+	_, err = tx.ExecContext(ctx, db.Rebind(q), ids.New(), eventID, now.UTC(), r.ID, r.EventID)
+	if err != nil {
+		return Registration{}, nil, err
+	}
+	q = "UPDATE registrations SET event_id=?, moved_at=?, waiver_id=?, waiver_accepted_at=?, waiver_ip=? WHERE id=? AND event_id=?"
+	moved, err := tx.ExecContext(ctx, db.Rebind(q), eventID, now.UTC(), acceptance.WaiverID, acceptance.WaiverAcceptedAt, acceptance.WaiverIP, r.ID, r.EventID)
+	if err != nil {
+		return Registration{}, nil, err
+	}
+	n, err := moved.RowsAffected()
+	if err != nil {
+		return Registration{}, nil, err
+	}
+	if n == 0 {
+		// Cancelled or moved since it was loaded
+		return Registration{}, nil, NotFoundError{Message: fmt.Sprint("Couldn't find a registration with the ID of ", r.ID)}
+	}
+	_, err = tx.ExecContext(ctx, db.Rebind("DELETE FROM tickets WHERE registration_id=?"), r.ID)
+	if err != nil {
+		return Registration{}, nil, err
+	}
+	previous := r.EventID
+	r.EventID = eventID
+	r.WaiverAcceptance = acceptance
+	ticket, err := issueTicket(ctx, tx, r)
+	if err != nil {
+		return Registration{}, nil, err
+	}
+	r.Ticket = &ticket
+	details := attendeeDetails(r.Name, r.Email)
+	details["from_event_id"] = previous
+	err = recordAudit(ctx, tx, AuditRescheduled, eventID, r.ID, details)
+	if err != nil {
+		return Registration{}, nil, err
+	}
+	promoted, err := promoteFromWaitlist(ctx, tx, previous)
+	if err != nil {
+		return Registration{}, nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return Registration{}, nil, err
+	}
+	return r, promoted, nil
+}
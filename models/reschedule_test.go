@@ -0,0 +1,218 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"event_booking_restapi_golang/db"
+	"testing"
+	"time"
+)
+
+// TestReschedule tests that bookings move between the occurrences of a series, taking
+// the place on one and freeing it on the other
+func TestReschedule(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	monday := saveEvent(t, Event{Title: "Yoga Monday", UserID: "org-1", SeriesID: "yoga", DateTime: now.Add(3 * 24 * time.Hour), Capacity: 1})
+	tuesday := saveEvent(t, Event{Title: "Yoga Tuesday", UserID: "org-1", SeriesID: "yoga", DateTime: now.Add(4 * 24 * time.Hour), Capacity: 1})
+	alice, err := monday.Register(ctx, Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	_, err = monday.JoinWaitlist(ctx, WaitlistEntry{Name: "Bob", Email: "bob@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to join waitlist: %v", err)
+	}
+
+	moved, promoted, err := alice.Reschedule(ctx, tuesday.ID, WaiverAcceptance{}, now)
+	if err != nil {
+		t.Fatalf("Failed to reschedule: %v", err)
+	}
+	if moved.ID != alice.ID || moved.EventID != tuesday.ID || moved.Ticket == nil || moved.Ticket.EventID != tuesday.ID {
+		t.Errorf("Expected the booking to move to Tuesday with a new ticket, got %+v", moved)
+	}
+	if promoted == nil || promoted.Email != "bob@example.com" || promoted.EventID != monday.ID {
+		t.Errorf("Expected Bob to take the freed place on Monday, got %+v", promoted)
+	}
+	if _, err := GetTicketByCode(ctx, alice.Ticket.Code); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected the old ticket to stop admitting, got %v", err)
+	}
+	if count, _ := tuesday.CountRegistrations(ctx); count != 1 {
+		t.Errorf("Expected Tuesday to hold 1 booking, got %d", count)
+	}
+
+	// Bob holds the freed place, so there is no way back
+	if _, _, err := moved.Reschedule(ctx, monday.ID, WaiverAcceptance{}, now); !errors.Is(err, ErrEventFull) {
+		t.Errorf("Expected ErrEventFull moving onto a full occurrence, got %v", err)
+	}
+}
+
+// TestRescheduleStats tests that a move counts as a booking lost by the occurrence left
+// and as one made at the move on the other, and that deleting an event drops its history
+func TestRescheduleStats(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	booked := now.AddDate(0, 0, -2)
+
+	monday := saveEvent(t, Event{Title: "Yoga Monday", UserID: "org-1", SeriesID: "yoga", DateTime: now.Add(3 * 24 * time.Hour)})
+	tuesday := saveEvent(t, Event{Title: "Yoga Tuesday", UserID: "org-1", SeriesID: "yoga", DateTime: now.Add(4 * 24 * time.Hour)})
+	alice, err := monday.Register(ctx, Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	_, err = db.DB.Exec("UPDATE registrations SET created_at=? WHERE id=?", booked, alice.ID)
+	if err != nil {
+		t.Fatalf("Failed to backdate the booking: %v", err)
+	}
+	_, _, err = alice.Reschedule(ctx, tuesday.ID, WaiverAcceptance{}, now)
+	if err != nil {
+		t.Fatalf("Failed to reschedule: %v", err)
+	}
+
+	day := func(t time.Time) string { return t.Format(time.DateOnly) }
+	left, err := monday.GetStats(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get the stats: %v", err)
+	}
+	if left.Registered != 0 || left.MovedOut != 1 || left.CancellationRate != 100 ||
+		len(left.RegistrationsByDay) != 3 || left.RegistrationsByDay[0] != (EventDay{day(booked), 1, 0, 1}) || left.RegistrationsByDay[2] != (EventDay{day(now), 0, 1, 0}) {
+		t.Errorf("Expected the move to count as a booking lost by Monday, got %+v", left)
+	}
+	joined, err := tuesday.GetStats(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get the stats: %v", err)
+	}
+	if joined.Registered != 1 || joined.MovedOut != 0 || len(joined.RegistrationsByDay) != 1 || joined.RegistrationsByDay[0] != (EventDay{day(now), 1, 0, 1}) {
+		t.Errorf("Expected the booking to count on Tuesday from the move, got %+v", joined)
+	}
+
+	carol, err := monday.Register(ctx, Registration{Name: "Carol", Email: "carol@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	if _, err := carol.Cancel(ctx); err != nil {
+		t.Fatalf("Failed to cancel: %v", err)
+	}
+	if err := monday.Delete(ctx); err != nil {
+		t.Fatalf("Failed to delete the event: %v", err)
+	}
+	for _, table := range []string{"registration_cancellations", "registration_moves"} {
+		var count int
+		db.DB.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE event_id=?", monday.ID).Scan(&count)
+		if count != 0 {
+			t.Errorf("Expected the deleted event's rows of %s to go, got %d", table, count)
+		}
+	}
+}
+
+// TestRescheduleRefused tests the occurrences bookings can't be moved to
+func TestRescheduleRefused(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+	now := time.Now()
+	original := RescheduleCutoff
+	RescheduleCutoff = 24 * time.Hour
+	t.Cleanup(func() { RescheduleCutoff = original })
+
+	first := saveEvent(t, Event{Title: "Class 1", UserID: "org-1", SeriesID: "class", DateTime: now.Add(3 * 24 * time.Hour)})
+	second := saveEvent(t, Event{Title: "Class 2", UserID: "org-1", SeriesID: "class", DateTime: now.Add(4 * 24 * time.Hour)})
+	soon := saveEvent(t, Event{Title: "Class 3", UserID: "org-1", SeriesID: "class", DateTime: now.Add(time.Hour)})
+	otherSeries := saveEvent(t, Event{Title: "Workshop", UserID: "org-1", SeriesID: "workshop", DateTime: now.Add(4 * 24 * time.Hour)})
+	otherOrganizer := saveEvent(t, Event{Title: "Copycat Class", UserID: "org-2", SeriesID: "class", DateTime: now.Add(4 * 24 * time.Hour)})
+	oneOff := saveEvent(t, Event{Title: "Gala", UserID: "org-1", DateTime: now.Add(4 * 24 * time.Hour)})
+	alice, err := first.Register(ctx, Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	_, err = second.Register(ctx, Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	gala, err := oneOff.Register(ctx, Registration{Name: "Bob", Email: "bob@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	cases := []struct {
+		name         string
+		registration Registration
+		eventID      string
+		expected     error
+	}{
+		{"same occurrence", alice, first.ID, ErrNotInSeries},
+		{"other series", alice, otherSeries.ID, ErrNotInSeries},
+		{"other organizer", alice, otherOrganizer.ID, ErrNotInSeries},
+		{"one-off event", gala, second.ID, ErrNotInSeries},
+		{"within the cutoff", alice, soon.ID, ErrRescheduleClosed},
+		{"already booked", alice, second.ID, ErrAlreadyRegistered},
+		{"missing event", alice, "no-such-event", ErrEventNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, _, err := tc.registration.Reschedule(ctx, tc.eventID, WaiverAcceptance{}, now); !errors.Is(err, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+
+	third := saveEvent(t, Event{Title: "Class 4", UserID: "org-1", SeriesID: "class", DateTime: now.Add(5 * 24 * time.Hour)})
+	if _, err := third.Cancel(ctx); err != nil {
+		t.Fatalf("Failed to cancel event: %v", err)
+	}
+	if _, _, err := alice.Reschedule(ctx, third.ID, WaiverAcceptance{}, now); !errors.Is(err, ErrEventCancelled) {
+		t.Errorf("Expected ErrEventCancelled for a cancelled occurrence, got %v", err)
+	}
+	if _, _, err := alice.Reschedule(ctx, third.ID, WaiverAcceptance{}, now.Add(3*24*time.Hour)); !errors.Is(err, ErrRescheduleClosed) {
+		t.Errorf("Expected ErrRescheduleClosed once the booked occurrence is close, got %v", err)
+	}
+	if stored, err := GetRegistrationById(ctx, alice.ID); err != nil || stored.EventID != first.ID {
+		t.Errorf("Expected refused moves to leave the booking alone, got %+v (%v)", stored, err)
+	}
+}
+
+// TestRescheduleWaiver tests that bookings are only moved onto an occurrence with a
+// waiver once the attendee accepted it, recording the acceptance as on booking
+func TestRescheduleWaiver(t *testing.T) {
+	setupTestDatabase(t)
+	useTempAttachmentsDir(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	monday := saveEvent(t, Event{Title: "Climbing Monday", UserID: "org-1", SeriesID: "climbing", DateTime: now.Add(3 * 24 * time.Hour)})
+	tuesday := saveEvent(t, Event{Title: "Climbing Tuesday", UserID: "org-1", SeriesID: "climbing", DateTime: now.Add(4 * 24 * time.Hour)})
+	waiver, err := tuesday.AddAttachment(ctx, Attachment{Kind: AttachmentWaiver, FileName: "waiver.pdf", ContentType: "application/pdf"}, []byte("%PDF"))
+	if err != nil {
+		t.Fatalf("Failed to add waiver: %v", err)
+	}
+	alice, err := monday.Register(ctx, Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	for _, acceptance := range []WaiverAcceptance{{}, {WaiverID: "outdated-waiver"}} {
+		_, _, err = alice.Reschedule(ctx, tuesday.ID, acceptance, now)
+		var required WaiverRequiredError
+		if !errors.As(err, &required) || required.Waiver.ID != waiver.ID {
+			t.Errorf("Expected a WaiverRequiredError naming the waiver for %+v, got %v", acceptance, err)
+		}
+	}
+	if stored, err := GetRegistrationById(ctx, alice.ID); err != nil || stored.EventID != monday.ID {
+		t.Errorf("Expected the refused move to leave the booking alone, got %+v (%v)", stored, err)
+	}
+
+	moved, _, err := alice.Reschedule(ctx, tuesday.ID, WaiverAcceptance{WaiverID: waiver.ID, WaiverIP: "192.0.2.1"}, now)
+	if err != nil {
+		t.Fatalf("Failed to reschedule: %v", err)
+	}
+	stored, err := GetRegistrationById(ctx, moved.ID)
+	if err != nil {
+		t.Fatalf("Failed to load registration: %v", err)
+	}
+	if stored.EventID != tuesday.ID || stored.WaiverID != waiver.ID || stored.WaiverIP != "192.0.2.1" || stored.WaiverAcceptedAt == nil {
+		t.Errorf("Expected the move with the waiver acceptance recorded, got %+v", stored)
+	}
+}
@@ -0,0 +1,144 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"event_booking_restapi_golang/db"
+	"time"
+)
+
+// ErrInvalidVerificationCode is returned when a reply-to verification code is
+// wrong or has expired.
+var ErrInvalidVerificationCode = errors.New("the verification code is invalid or has expired")
+
+// ReplyToVerificationTTL is how long a reply-to verification code stays valid.
+const ReplyToVerificationTTL = 24 * time.Hour
+
+// EventSender is how attendee-facing emails about an event are sent: under the
+// organizer's display name, with replies going to the organizer's address.
+type EventSender struct {
+	EventID         string     // Event the settings belong to
+	SenderName      string     // Display name of the sender, empty for the default
+	ReplyTo         string     // Address replies go to, empty for none
+	ReplyToVerified bool       // Whether the organizer proved they receive mail at ReplyTo
+	VerifiedAt      *time.Time // When ReplyTo was verified
+}
+
+// ReplyToAddress returns the address attendee emails should set as Reply-To: the
+// reply-to address once it is verified, otherwise "".
+func (s EventSender) ReplyToAddress() string {
+	if !s.ReplyToVerified {
+		return ""
+	}
+	return s.ReplyTo
+}
+
+// GetSender returns the event's sender settings, which are empty when the
+// organizer hasn't set any.
+func (e Event) GetSender(ctx context.Context) (EventSender, error) {
+	sender := EventSender{EventID: e.ID}
+	var verifiedAt sql.NullTime
+	q := "SELECT sender_name, reply_to, reply_to_verified_at FROM event_senders WHERE event_id=?"
+	err := db.Conn(ctx).QueryRowContext(ctx, db.Rebind(q), e.ID).Scan(&sender.SenderName, &sender.ReplyTo, &verifiedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return sender, nil
+	}
+	if err != nil {
+		return EventSender{}, err
+	}
+	if verifiedAt.Valid {
+		sender.ReplyToVerified = true
+		sender.VerifiedAt = &verifiedAt.Time
+	}
+	return sender, nil
+}
+
+// SetSender stores the event's sender name and reply-to address. A new or still
+// unverified reply-to address is unverified until VerifyReplyTo is called with the
+// returned code, which must be sent to that address; a new code replaces earlier
+// ones. When the reply-to address is empty or already verified the code is empty.
+func (e Event) SetSender(ctx context.Context, senderName, replyTo string) (EventSender, string, error) {
+	current, err := e.GetSender(ctx)
+	if err != nil {
+		return EventSender{}, "", err
+	}
+	if replyTo == current.ReplyTo && (replyTo == "" || current.ReplyToVerified) {
+		q := `
+		INSERT INTO event_senders (event_id, sender_name, reply_to) VALUES (?, ?, ?)
+		ON CONFLICT (event_id) DO UPDATE SET sender_name = excluded.sender_name
+		`
+		_, err = db.Conn(ctx).ExecContext(ctx, db.Rebind(q), e.ID, senderName, replyTo)
+		if err != nil {
+			return EventSender{}, "", err
+		}
+		current.SenderName = senderName
+		return current, "", nil
+	}
+
+	code := ""
+	var codeHash string
+	var expiresAt *time.Time
+	if replyTo != "" {
+		secret := make([]byte, 16)
+		_, err = rand.Read(secret)
+		if err != nil {
+			return EventSender{}, "", err
+		}
+		code = hex.EncodeToString(secret)
+		codeHash = hashVerificationCode(code)
+		expires := time.Now().UTC().Add(ReplyToVerificationTTL)
+		expiresAt = &expires
+	}
+	q := `
+	INSERT INTO event_senders (event_id, sender_name, reply_to, reply_to_verified_at, verification_hash, verification_expires_at)
+	VALUES (?, ?, ?, NULL, ?, ?)
+	ON CONFLICT (event_id) DO UPDATE SET sender_name = excluded.sender_name, reply_to = excluded.reply_to,
+	reply_to_verified_at = NULL, verification_hash = excluded.verification_hash,
+	verification_expires_at = excluded.verification_expires_at
+	`
+	_, err = db.Conn(ctx).ExecContext(ctx, db.Rebind(q), e.ID, senderName, replyTo, codeHash, expiresAt)
+	if err != nil {
+		return EventSender{}, "", err
+	}
+	return EventSender{EventID: e.ID, SenderName: senderName, ReplyTo: replyTo}, code, nil
+}
+
+// VerifyReplyTo marks the event's reply-to address as verified when code is the
+// one issued by SetSender for it and hasn't expired. A code can only be used once.
+// With a sender name set, verifying completes the organizer's OnboardingProfile step.
+// Returns ErrInvalidVerificationCode otherwise.
+func (e Event) VerifyReplyTo(ctx context.Context, code string) (EventSender, error) {
+	now := time.Now().UTC()
+	q := `
+	UPDATE event_senders SET reply_to_verified_at = ?, verification_hash = ''
+	WHERE event_id = ? AND reply_to <> '' AND verification_hash <> '' AND verification_hash = ?
+	AND ` + db.Timestamp("verification_expires_at") + ` > ` + db.Timestamp("?")
+	res, err := db.Conn(ctx).ExecContext(ctx, db.Rebind(q), now, e.ID, hashVerificationCode(code), now)
+	if err != nil {
+		return EventSender{}, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return EventSender{}, err
+	}
+	if affected == 0 {
+		return EventSender{}, ErrInvalidVerificationCode
+	}
+	err = completeEventOnboardingStep(ctx, db.Conn(ctx), e.ID, OnboardingProfile, now,
+		"EXISTS (SELECT 1 FROM event_senders s WHERE s.event_id = events.id AND s.sender_name <> '')")
+	if err != nil {
+		return EventSender{}, err
+	}
+	return e.GetSender(ctx)
+}
+
+// hashVerificationCode returns the hex SHA-256 of a verification code; only the
+// hash is stored, so the codes can't be read from the database.
+func hashVerificationCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"event_booking_restapi_golang/db"
+	"testing"
+	"time"
+)
+
+// TestEvent_SetSender tests storing sender settings and verifying the reply-to address
+func TestEvent_SetSender(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+	event := saveEvent(t, Event{Title: "Jazz Night"})
+
+	sender, err := event.GetSender(ctx)
+	if err != nil || sender.SenderName != "" || sender.ReplyTo != "" || sender.ReplyToAddress() != "" {
+		t.Fatalf("Expected no sender settings, got %+v (%v)", sender, err)
+	}
+
+	sender, code, err := event.SetSender(ctx, "Jazz Club", "bookings@jazz.example.com")
+	if err != nil || code == "" || sender.ReplyToVerified {
+		t.Fatalf("Expected an unverified reply-to with a code, got %+v %q (%v)", sender, code, err)
+	}
+	if sender.ReplyToAddress() != "" {
+		t.Error("Expected an unverified reply-to address not to be used")
+	}
+
+	// Setting the same unverified address again issues a new code that replaces the old one
+	_, newCode, err := event.SetSender(ctx, "Jazz Club", "bookings@jazz.example.com")
+	if err != nil || newCode == "" || newCode == code {
+		t.Fatalf("Expected a new code, got %q (%v)", newCode, err)
+	}
+	_, err = event.VerifyReplyTo(ctx, code)
+	if !errors.Is(err, ErrInvalidVerificationCode) {
+		t.Errorf("Expected the replaced code to be rejected, got %v", err)
+	}
+
+	sender, err = event.VerifyReplyTo(ctx, newCode)
+	if err != nil || !sender.ReplyToVerified || sender.VerifiedAt == nil || sender.ReplyToAddress() != "bookings@jazz.example.com" {
+		t.Fatalf("Expected a verified reply-to, got %+v (%v)", sender, err)
+	}
+	_, err = event.VerifyReplyTo(ctx, newCode)
+	if !errors.Is(err, ErrInvalidVerificationCode) {
+		t.Errorf("Expected a used code to be rejected, got %v", err)
+	}
+
+	// Renaming keeps the verification, changing the address drops it
+	sender, code, err = event.SetSender(ctx, "The Jazz Club", "bookings@jazz.example.com")
+	if err != nil || code != "" || !sender.ReplyToVerified || sender.SenderName != "The Jazz Club" {
+		t.Errorf("Expected the verified address to be kept, got %+v %q (%v)", sender, code, err)
+	}
+	sender, code, err = event.SetSender(ctx, "The Jazz Club", "hello@jazz.example.com")
+	if err != nil || code == "" || sender.ReplyToVerified {
+		t.Errorf("Expected the new address to need verification, got %+v %q (%v)", sender, code, err)
+	}
+	sender, err = event.GetSender(ctx)
+	if err != nil || sender.ReplyTo != "hello@jazz.example.com" || sender.ReplyToVerified {
+		t.Errorf("Expected the stored address to be unverified, got %+v (%v)", sender, err)
+	}
+
+	sender, code, err = event.SetSender(ctx, "", "")
+	if err != nil || code != "" || sender.ReplyTo != "" {
+		t.Errorf("Expected the settings to be cleared, got %+v %q (%v)", sender, code, err)
+	}
+}
+
+// TestEvent_VerifyReplyToExpired tests that codes expire
+func TestEvent_VerifyReplyToExpired(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+	event := saveEvent(t, Event{Title: "Jazz Night"})
+
+	_, code, err := event.SetSender(ctx, "", "bookings@jazz.example.com")
+	if err != nil {
+		t.Fatalf("Failed to set sender: %v", err)
+	}
+	_, err = db.DB.Exec("UPDATE event_senders SET verification_expires_at = ?", time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to expire the code: %v", err)
+	}
+	_, err = event.VerifyReplyTo(ctx, code)
+	if !errors.Is(err, ErrInvalidVerificationCode) {
+		t.Errorf("Expected the expired code to be rejected, got %v", err)
+	}
+}
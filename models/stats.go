@@ -0,0 +1,206 @@
+package models
+
+import (
+	"context"
+	"event_booking_restapi_golang/db"
+	"time"
+)
+
+// StatsTotals counts what the platform holds.
+type StatsTotals struct {
+	Events        int `json:"events"`        // Events of every status, drafts and cancelled ones included
+	Users         int `json:"users"`         // Distinct organizers with events; attendees have no user IDs
+	Registrations int `json:"registrations"` // Current bookings; cancelled ones are deleted
+}
+
+// MonthlyEvents is the number of events created in one month.
+type MonthlyEvents struct {
+	Month  string `json:"month"`  // UTC month, YYYY-MM
+	Events int    `json:"events"` // Events created that month
+}
+
+// TopEvent is an event ranked by its bookings.
+type TopEvent struct {
+	EventID  string    `json:"event_id"`
+	Title    string    `json:"title"`
+	DateTime time.Time `json:"date_time"` // When the event starts
+	Bookings int       `json:"bookings"`  // Current registrations
+}
+
+// GetStatsTotals counts the events, their organizers and the registrations.
+func GetStatsTotals(ctx context.Context) (StatsTotals, error) {
+	q := `
+	SELECT
+		(SELECT COUNT(*) FROM events),
+		(SELECT COUNT(DISTINCT user_id) FROM events WHERE user_id IS NOT NULL AND user_id <> ''),
+		(SELECT COUNT(*) FROM registrations)
+	`
+	var totals StatsTotals
+	err := db.Conn(ctx).QueryRowContext(ctx, q).Scan(&totals.Events, &totals.Users, &totals.Registrations)
+	return totals, err
+}
+
+// GetEventsPerMonth counts the events created per UTC month, from the month of from
+// through the month of to, oldest first. Months without new events are included with
+// a count of 0, so the months are contiguous.
+func GetEventsPerMonth(ctx context.Context, from, to time.Time) ([]MonthlyEvents, error) {
+	first := time.Date(from.UTC().Year(), from.UTC().Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(to.UTC().Year(), to.UTC().Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0)
+	q := "SELECT " + db.Month("created_at") + ", COUNT(*) FROM events WHERE " +
+		db.Timestamp("created_at") + " >= " + db.Timestamp("?") + " AND " + db.Timestamp("created_at") + " < " + db.Timestamp("?") +
+		" GROUP BY " + db.Month("created_at")
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q), first, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var month string
+		var events int
+		err := rows.Scan(&month, &events)
+		if err != nil {
+			return nil, err
+		}
+		counts[month] = events
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var months []MonthlyEvents
+	for month := first; month.Before(end); month = month.AddDate(0, 1, 0) {
+		key := month.Format("2006-01")
+		months = append(months, MonthlyEvents{Month: key, Events: counts[key]})
+	}
+	return months, nil
+}
+
+// GetTopEventsByBookings lists the events with the most registrations, at most limit
+// of them, the most booked first. Events without registrations are left out.
+func GetTopEventsByBookings(ctx context.Context, limit int) ([]TopEvent, error) {
+	q := `
+	SELECT e.id, e.name, e.datetime, COUNT(*) AS bookings
+	FROM events e
+	JOIN registrations r ON r.event_id = e.id
+	GROUP BY e.id, e.name, e.datetime
+	ORDER BY bookings DESC, e.id
+	LIMIT ?
+	`
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []TopEvent{}
+	for rows.Next() {
+		var e TopEvent
+		err := rows.Scan(&e.EventID, &e.Title, &e.DateTime, &e.Bookings)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// EventStats is how an event's bookings went, for its organizer to gauge demand.
+type EventStats struct {
+	Registered          int        `json:"registered"`           // Bookings held now
+	Cancelled           int        `json:"cancelled"`            // Bookings cancelled since they were made
+	MovedOut            int        `json:"moved_out"`            // Bookings moved to another occurrence of the series
+	CheckedIn           int        `json:"checked_in"`           // Bookings checked in at the door
+	Capacity            int        `json:"capacity"`             // Capacity of the event, 0 for unlimited
+	CheckInRate         float64    `json:"check_in_rate"`        // Percentage of the bookings held that checked in
+	CancellationRate    float64    `json:"cancellation_rate"`    // Percentage of the bookings ever made that were cancelled or moved out
+	CapacityUtilization *float64   `json:"capacity_utilization"` // Percentage of the capacity booked, nil when unlimited
+	RegistrationsByDay  []EventDay `json:"registrations_by_day"` // Bookings over time, oldest day first
+}
+
+// EventDay is the bookings of an event on one UTC day.
+type EventDay struct {
+	Day           string `json:"day"`           // UTC day, YYYY-MM-DD
+	Registrations int    `json:"registrations"` // Bookings made or moved in that day, those lost since included
+	Cancellations int    `json:"cancellations"` // Bookings cancelled or moved out that day
+	Total         int    `json:"total"`         // Bookings held at the end of the day
+}
+
+// GetStats counts the event's bookings, check-ins, cancellations and bookings moved to
+// another occurrence, and its bookings per UTC day from the first day with a booking
+// through the last day with a booking or a cancellation. Bookings moved in count from
+// the day they were moved, and moves out count as cancellations. Days without either
+// are included, so the days are contiguous. Rates are 0 when there is nothing to
+// divide by.
+func (e Event) GetStats(ctx context.Context) (EventStats, error) {
+	stats := EventStats{Capacity: e.Capacity, RegistrationsByDay: []EventDay{}}
+	attendance, err := e.GetAttendance(ctx)
+	if err != nil {
+		return EventStats{}, err
+	}
+	stats.Registered, stats.CheckedIn = attendance.Registered, attendance.CheckedIn
+	err = db.Conn(ctx).QueryRowContext(ctx, db.Rebind("SELECT COUNT(*) FROM registration_cancellations WHERE event_id=?"), e.ID).Scan(&stats.Cancelled)
+	if err != nil {
+		return EventStats{}, err
+	}
+	err = db.Conn(ctx).QueryRowContext(ctx, db.Rebind("SELECT COUNT(*) FROM registration_moves WHERE event_id=?"), e.ID).Scan(&stats.MovedOut)
+	if err != nil {
+		return EventStats{}, err
+	}
+	if stats.Registered > 0 {
+		stats.CheckInRate = 100 * float64(stats.CheckedIn) / float64(stats.Registered)
+	}
+	if lost := stats.Cancelled + stats.MovedOut; stats.Registered+lost > 0 {
+		stats.CancellationRate = 100 * float64(lost) / float64(stats.Registered+lost)
+	}
+	if e.Capacity > 0 {
+		utilization := 100 * float64(stats.Registered) / float64(e.Capacity)
+		stats.CapacityUtilization = &utilization
+	}
+
+	// Cancelled and moved bookings count on the day they were made as well as the day they were lost
+	q := `
+	SELECT day, SUM(made), SUM(cancelled) FROM (
+		SELECT ` + db.Date("COALESCE(moved_at, created_at)") + ` AS day, 1 AS made, 0 AS cancelled FROM registrations WHERE event_id=?
+		UNION ALL
+		SELECT ` + db.Date("registered_at") + `, 1, 0 FROM registration_cancellations WHERE event_id=?
+		UNION ALL
+		SELECT ` + db.Date("cancelled_at") + `, 0, 1 FROM registration_cancellations WHERE event_id=?
+		UNION ALL
+		SELECT ` + db.Date("registered_at") + `, 1, 0 FROM registration_moves WHERE event_id=?
+		UNION ALL
+		SELECT ` + db.Date("moved_at") + `, 0, 1 FROM registration_moves WHERE event_id=?
+	) bookings
+	GROUP BY day
+	ORDER BY day
+	`
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q), e.ID, e.ID, e.ID, e.ID, e.ID)
+	if err != nil {
+		return EventStats{}, err
+	}
+	defer rows.Close()
+
+	total := 0
+	var last time.Time
+	for rows.Next() {
+		var day EventDay
+		err := rows.Scan(&day.Day, &day.Registrations, &day.Cancellations)
+		if err != nil {
+			return EventStats{}, err
+		}
+		date, err := time.Parse(time.DateOnly, day.Day)
+		if err != nil {
+			return EventStats{}, err
+		}
+		// Days without bookings or cancellations hold the previous day's total
+		for gap := last.AddDate(0, 0, 1); !last.IsZero() && gap.Before(date); gap = gap.AddDate(0, 0, 1) {
+			stats.RegistrationsByDay = append(stats.RegistrationsByDay, EventDay{Day: gap.Format(time.DateOnly), Total: total})
+		}
+		total += day.Registrations - day.Cancellations
+		day.Total = total
+		stats.RegistrationsByDay = append(stats.RegistrationsByDay, day)
+		last = date
+	}
+	return stats, rows.Err()
+}
@@ -0,0 +1,135 @@
+package models
+
+import (
+	"context"
+	"event_booking_restapi_golang/db"
+	"testing"
+	"time"
+)
+
+// TestStats tests the platform totals, events per month and most booked events
+func TestStats(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+	march := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	events := []struct {
+		id, user string
+		created  time.Time
+	}{
+		{"e1", "alice", march},
+		{"e2", "alice", march.AddDate(0, 0, 10)},
+		{"e3", "bob", march.AddDate(0, 2, 0)},
+		{"e4", "", march.AddDate(-1, 0, 0)}, // Before the range
+	}
+	for _, e := range events {
+		// Stored in a non-UTC zone to check that months are UTC months
+		_, err := db.DB.Exec("INSERT INTO events (id, name, description, location, datetime, user_id, created_at) VALUES (?,?,?,?,?,?,?)",
+			e.id, "Event "+e.id, "Description", "Hall", e.created.AddDate(0, 1, 0), e.user, e.created.In(time.FixedZone("CEST", 2*60*60)))
+		if err != nil {
+			t.Fatalf("Failed to insert event: %v", err)
+		}
+	}
+	for _, r := range []struct{ id, event string }{{"r1", "e1"}, {"r2", "e3"}, {"r3", "e3"}} {
+		_, err := db.DB.Exec("INSERT INTO registrations (id, event_id, name, email, created_at) VALUES (?,?,?,?,?)",
+			r.id, r.event, "Attendee", r.id+"@example.com", march)
+		if err != nil {
+			t.Fatalf("Failed to insert registration: %v", err)
+		}
+	}
+
+	totals, err := GetStatsTotals(ctx)
+	if err != nil {
+		t.Fatalf("Failed to count the totals: %v", err)
+	}
+	if totals != (StatsTotals{Events: 4, Users: 2, Registrations: 3}) {
+		t.Errorf("Expected 4 events by 2 organizers with 3 registrations, got %+v", totals)
+	}
+
+	months, err := GetEventsPerMonth(ctx, march, march.AddDate(0, 2, 0))
+	if err != nil {
+		t.Fatalf("Failed to count the events per month: %v", err)
+	}
+	expected := []MonthlyEvents{{"2026-03", 2}, {"2026-04", 0}, {"2026-05", 1}}
+	if len(months) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, months)
+	}
+	for i := range expected {
+		if months[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], months[i])
+		}
+	}
+
+	top, err := GetTopEventsByBookings(ctx, 1)
+	if err != nil {
+		t.Fatalf("Failed to rank the events: %v", err)
+	}
+	if len(top) != 1 || top[0].EventID != "e3" || top[0].Bookings != 2 || top[0].Title != "Event e3" || top[0].DateTime.IsZero() {
+		t.Errorf("Expected e3 with 2 bookings, got %+v", top)
+	}
+}
+
+// TestEvent_GetStats tests an event's booking rates and its bookings per day, cancelled ones included
+func TestEvent_GetStats(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+	event, err := Event{Title: "Jazz Night", Description: "Live music", Location: "Club", DateTime: time.Now().Add(24 * time.Hour), Capacity: 4}.Save(ctx)
+	if err != nil {
+		t.Fatalf("Failed to save test event: %v", err)
+	}
+
+	stats, err := event.GetStats(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get the stats: %v", err)
+	}
+	if stats.Registered != 0 || stats.CheckInRate != 0 || stats.CancellationRate != 0 || len(stats.RegistrationsByDay) != 0 {
+		t.Errorf("Expected empty stats without bookings, got %+v", stats)
+	}
+
+	today := time.Now().UTC()
+	first := today.AddDate(0, 0, -3)
+	for _, r := range []struct {
+		id      string
+		created time.Time
+	}{{"r1", first}, {"r2", first}, {"r3", first.AddDate(0, 0, 2)}} {
+		_, err := db.DB.Exec("INSERT INTO registrations (id, event_id, name, email, created_at) VALUES (?,?,?,?,?)",
+			r.id, event.ID, "Attendee", r.id+"@example.com", r.created)
+		if err != nil {
+			t.Fatalf("Failed to insert registration: %v", err)
+		}
+	}
+	_, err = db.DB.Exec("UPDATE registrations SET checked_in_at=? WHERE id='r1'", today)
+	if err != nil {
+		t.Fatalf("Failed to check in: %v", err)
+	}
+	registration, err := GetRegistrationById(ctx, "r2")
+	if err != nil {
+		t.Fatalf("Failed to get registration: %v", err)
+	}
+	if _, err := registration.Cancel(ctx); err != nil {
+		t.Fatalf("Failed to cancel registration: %v", err)
+	}
+
+	stats, err = event.GetStats(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get the stats: %v", err)
+	}
+	if stats.Registered != 2 || stats.Cancelled != 1 || stats.CheckedIn != 1 || stats.CheckInRate != 50 ||
+		stats.CapacityUtilization == nil || *stats.CapacityUtilization != 50 || int(stats.CancellationRate) != 33 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+	day := func(t time.Time) string { return t.Format(time.DateOnly) }
+	expected := []EventDay{
+		{day(first), 2, 0, 2},
+		{day(first.AddDate(0, 0, 1)), 0, 0, 2},
+		{day(first.AddDate(0, 0, 2)), 1, 0, 3},
+		{day(today), 0, 1, 2},
+	}
+	if len(stats.RegistrationsByDay) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, stats.RegistrationsByDay)
+	}
+	for i := range expected {
+		if stats.RegistrationsByDay[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], stats.RegistrationsByDay[i])
+		}
+	}
+}
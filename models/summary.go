@@ -0,0 +1,128 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"event_booking_restapi_golang/db"
+	"time"
+)
+
+// RegistrationSummary names the registration_daily_summary table in summary_refresh.
+const RegistrationSummary = "registration_daily_summary"
+
+// viewKey identifies the views of an event on one UTC day.
+type viewKey struct {
+	eventID string
+	day     string
+}
+
+// eventViews buffers the counted views until they are added to event_view_daily_summary.
+var eventViews = db.NewBatch("event views", func(pending, added int) int { return pending + added },
+	func(ctx context.Context, tx *sql.Tx, key viewKey, views int) error {
+		q := `
+		INSERT INTO event_view_daily_summary (event_id, day, views) VALUES (?, ?, ?)
+		ON CONFLICT (event_id, day) DO UPDATE SET views = event_view_daily_summary.views + excluded.views
+		`
+		_, err := tx.ExecContext(ctx, db.Rebind(q), key.eventID, key.day, views)
+		return err
+	})
+
+// RecordEventView counts a view of the event. Views are kept in memory and added to
+// event_view_daily_summary when the buffered writes are flushed (see db.Batch), so
+// viewing an event never writes to the database.
+func RecordEventView(eventID string) {
+	eventViews.Add(viewKey{eventID, time.Now().UTC().Format(time.DateOnly)}, 1)
+}
+
+// FlushEventViews adds the views counted since the last flush to
+// event_view_daily_summary in one transaction. Views that couldn't be written are kept
+// for the next flush.
+func FlushEventViews(ctx context.Context) error {
+	return eventViews.Flush(ctx)
+}
+
+// RefreshRegistrationSummary recomputes registration_daily_summary from the
+// registrations made since the start of since's UTC day. Earlier days are left as
+// they are, so a zero since rebuilds the whole table.
+func RefreshRegistrationSummary(ctx context.Context, since time.Time) error {
+	since = since.UTC().Truncate(24 * time.Hour)
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, db.Rebind("DELETE FROM registration_daily_summary WHERE day >= ?"), since.Format(time.DateOnly))
+	if err != nil {
+		return err
+	}
+	day := db.Date("created_at")
+	q := `
+	INSERT INTO registration_daily_summary (event_id, day, registrations)
+	SELECT event_id, ` + day + `, COUNT(*)
+	FROM registrations
+	WHERE ` + db.Timestamp("created_at") + ` >= ` + db.Timestamp("?") + `
+	GROUP BY event_id, ` + day
+	_, err = tx.ExecContext(ctx, db.Rebind(q), since)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetSummaryRefreshedAt returns when the named summary table was last refreshed, or
+// the zero time when it never was.
+func GetSummaryRefreshedAt(ctx context.Context, name string) (time.Time, error) {
+	var refreshedAt time.Time
+	err := db.Conn(ctx).QueryRowContext(ctx, db.Rebind("SELECT refreshed_at FROM summary_refresh WHERE name=?"), name).Scan(&refreshedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	return refreshedAt, err
+}
+
+// SetSummaryRefreshedAt records when the named summary table was last refreshed.
+func SetSummaryRefreshedAt(ctx context.Context, name string, refreshedAt time.Time) error {
+	q := "INSERT INTO summary_refresh (name, refreshed_at) VALUES (?, ?) ON CONFLICT (name) DO UPDATE SET refreshed_at = excluded.refreshed_at"
+	_, err := db.Conn(ctx).ExecContext(ctx, db.Rebind(q), name, refreshedAt.UTC())
+	return err
+}
+
+// EventDailySummary is the activity of an event on one day, from the summary tables.
+type EventDailySummary struct {
+	Day           string // UTC day, YYYY-MM-DD
+	Registrations int    // Registrations made that day and not cancelled since
+	Views         int    // Times the event was viewed that day
+}
+
+// GetDailySummary returns the event's registrations and views per UTC day, oldest
+// day first, as of the last summary refresh. Days without either are left out.
+// Returns a slice of EventDailySummary and any error encountered during the query.
+func (e *Event) GetDailySummary(ctx context.Context) ([]EventDailySummary, error) {
+	q := `
+	SELECT day, SUM(registrations), SUM(views) FROM (
+		SELECT day, registrations, 0 AS views FROM registration_daily_summary WHERE event_id = ?
+		UNION ALL
+		SELECT day, 0 AS registrations, views FROM event_view_daily_summary WHERE event_id = ?
+	) activity
+	GROUP BY day
+	ORDER BY day
+	`
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q), e.ID, e.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var days []EventDailySummary
+	for rows.Next() {
+		var d EventDailySummary
+		err := rows.Scan(&d.Day, &d.Registrations, &d.Views)
+		if err != nil {
+			return nil, err
+		}
+		days = append(days, d)
+	}
+	return days, rows.Err()
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"context"
+	"event_booking_restapi_golang/db"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestFlushEventViews tests that counted views are added to the daily view summary
+func TestFlushEventViews(t *testing.T) {
+	setupTestDatabase(t)
+	event := saveEvent(t, Event{Title: "Viewed Event", Capacity: 10})
+
+	RecordEventView(event.ID)
+	RecordEventView(event.ID)
+	err := FlushEventViews(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to flush views: %v", err)
+	}
+	RecordEventView(event.ID)
+	err = FlushEventViews(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to flush views: %v", err)
+	}
+
+	days, err := event.GetDailySummary(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get the daily summary: %v", err)
+	}
+	expected := []EventDailySummary{{Day: time.Now().UTC().Format(time.DateOnly), Views: 3}}
+	if !reflect.DeepEqual(days, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, days)
+	}
+}
+
+// TestFlushEventViewsFailure tests that views are kept for the next flush when they can't be stored
+func TestFlushEventViewsFailure(t *testing.T) {
+	setupTestDatabase(t)
+	RecordEventView("e1")
+	_, err := db.DB.Exec("DROP TABLE event_view_daily_summary")
+	if err != nil {
+		t.Fatalf("Failed to drop the view summary: %v", err)
+	}
+	err = FlushEventViews(context.Background())
+	if err == nil {
+		t.Fatal("Expected an error without the view summary table")
+	}
+
+	RecordEventView("e1")
+	if pending := eventViews.Len(); pending != 1 {
+		t.Errorf("Expected the view to be kept, got %d pending writes", pending)
+	}
+
+	_, err = db.DB.Exec("CREATE TABLE event_view_daily_summary (event_id TEXT NOT NULL, day TEXT NOT NULL, views INTEGER NOT NULL, PRIMARY KEY (event_id, day))")
+	if err != nil {
+		t.Fatalf("Failed to recreate the view summary: %v", err)
+	}
+	err = FlushEventViews(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to flush views: %v", err)
+	}
+	var views int
+	err = db.DB.QueryRow("SELECT views FROM event_view_daily_summary WHERE event_id = 'e1'").Scan(&views)
+	if err != nil || views != 2 {
+		t.Errorf("Expected the kept view to be merged with the new one, got %d (%v)", views, err)
+	}
+}
+
+// TestRefreshRegistrationSummary tests that an incremental refresh only recomputes days from since
+func TestRefreshRegistrationSummary(t *testing.T) {
+	setupTestDatabase(t)
+	day := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	insert := func(id string, at time.Time) {
+		_, err := db.DB.Exec("INSERT INTO registrations (id, event_id, name, email, created_at) VALUES (?,?,?,?,?)",
+			id, "e1", "Attendee", id+"@example.com", at)
+		if err != nil {
+			t.Fatalf("Failed to insert registration: %v", err)
+		}
+	}
+	insert("r1", day.Add(time.Hour))
+	insert("r2", day.Add(25*time.Hour))
+	err := RefreshRegistrationSummary(context.Background(), time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+
+	// Cancelling r1 and booking r3 is only picked up for the days being refreshed
+	_, err = db.DB.Exec("DELETE FROM registrations WHERE id = 'r1'")
+	if err != nil {
+		t.Fatalf("Failed to cancel registration: %v", err)
+	}
+	insert("r3", day.Add(26*time.Hour))
+	err = RefreshRegistrationSummary(context.Background(), day.Add(30*time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+
+	days, err := GetRegistrationsByDay(context.Background(), day, day.Add(48*time.Hour), "")
+	if err != nil {
+		t.Fatalf("Failed to get registrations by day: %v", err)
+	}
+	expected := []DailyRegistrations{
+		{Day: "2026-03-10", Registrations: 1, Events: 1},
+		{Day: "2026-03-11", Registrations: 2, Events: 1},
+	}
+	if !reflect.DeepEqual(days, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, days)
+	}
+}
+
+// TestSummaryRefreshedAt tests recording when a summary was last refreshed
+func TestSummaryRefreshedAt(t *testing.T) {
+	setupTestDatabase(t)
+	refreshedAt, err := GetSummaryRefreshedAt(context.Background(), RegistrationSummary)
+	if err != nil || !refreshedAt.IsZero() {
+		t.Fatalf("Expected no refresh yet, got %v (%v)", refreshedAt, err)
+	}
+
+	at := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	for _, when := range []time.Time{at.Add(-time.Hour), at} {
+		err = SetSummaryRefreshedAt(context.Background(), RegistrationSummary, when)
+		if err != nil {
+			t.Fatalf("Failed to record the refresh: %v", err)
+		}
+	}
+	refreshedAt, err = GetSummaryRefreshedAt(context.Background(), RegistrationSummary)
+	if err != nil || !refreshedAt.Equal(at) {
+		t.Errorf("Expected %v, got %v (%v)", at, refreshedAt, err)
+	}
+}
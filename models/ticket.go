@@ -0,0 +1,106 @@
+package models
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"event_booking_restapi_golang/db"
+	"event_booking_restapi_golang/ids"
+	"strings"
+	"time"
+)
+
+// TicketSecret signs ticket codes, so that codes can't be guessed from ticket IDs and
+// forged codes are rejected before the database is queried. It is set by main from
+// TICKET_SECRET.
+var TicketSecret []byte
+
+// ErrTicketInvalid is returned for ticket codes that weren't signed with TicketSecret.
+var ErrTicketInvalid = errors.New("ticket code is invalid")
+
+// Ticket is the proof of a registration shown at the door, typically as a QR code of
+// its Code. Every registration holds exactly one ticket.
+type Ticket struct {
+	ID             string    // Unique identifier for the ticket
+	RegistrationID string    // ID of the registration the ticket was issued for
+	EventID        string    // ID of the event the ticket admits to
+	Code           string    // Signed code to present at check-in: the ticket ID and its signature
+	CreatedAt      time.Time // Time the ticket was issued
+}
+
+// execer runs statements on the database or within a transaction.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// ticketColumns lists the ticket columns in the order scanTicket expects them.
+const ticketColumns = "id, registration_id, event_id, code, created_at"
+
+// scanTicket reads a ticket row selected with ticketColumns.
+func scanTicket(row rowScanner) (Ticket, error) {
+	var t Ticket
+	err := row.Scan(&t.ID, &t.RegistrationID, &t.EventID, &t.Code, &t.CreatedAt)
+	return t, err
+}
+
+// signTicket returns the signed code of the ticket ID.
+func signTicket(id string) string {
+	mac := hmac.New(sha256.New, TicketSecret)
+	mac.Write([]byte(id))
+	return id + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// VerifyTicketCode checks the signature of a ticket code.
+// Returns the ID of the ticket, or ErrTicketInvalid when the code is malformed or
+// wasn't signed with TicketSecret.
+func VerifyTicketCode(code string) (string, error) {
+	id, _, found := strings.Cut(code, ".")
+	if !found || id == "" || !hmac.Equal([]byte(signTicket(id)), []byte(code)) {
+		return "", ErrTicketInvalid
+	}
+	return id, nil
+}
+
+// issueTicket stores a new ticket for the registration, using the transaction of the
+// booking when there is one.
+func issueTicket(ctx context.Context, x execer, r Registration) (Ticket, error) {
+	t := Ticket{ID: ids.New(), RegistrationID: r.ID, EventID: r.EventID, CreatedAt: time.Now()}
+	t.Code = signTicket(t.ID)
+	q := "INSERT INTO tickets (" + ticketColumns + ") VALUES (?,?,?,?,?)"
+	_, err := x.ExecContext(ctx, db.Rebind(q), t.ID, t.RegistrationID, t.EventID, t.Code, t.CreatedAt)
+	if err != nil {
+		return Ticket{}, err
+	}
+	return t, nil
+}
+
+// GetTicket retrieves the ticket of the registration. Registrations booked before
+// tickets were introduced are issued one on first request.
+func (r Registration) GetTicket(ctx context.Context) (Ticket, error) {
+	q := "SELECT " + ticketColumns + " FROM tickets WHERE registration_id=?"
+	t, err := scanTicket(db.Conn(ctx).QueryRowContext(ctx, db.Rebind(q), r.ID))
+	if !errors.Is(err, sql.ErrNoRows) {
+		return t, err
+	}
+	t, err = issueTicket(ctx, db.Conn(ctx), r)
+	if err != nil {
+		// A concurrent request may have issued the ticket first
+		return scanTicket(db.Conn(ctx).QueryRowContext(ctx, db.Rebind(q), r.ID))
+	}
+	return t, nil
+}
+
+// GetTicketByCode retrieves the ticket presented with the code.
+// Returns ErrTicketInvalid for forged codes and sql.ErrNoRows for tickets that no
+// longer exist, because their registration was cancelled.
+func GetTicketByCode(ctx context.Context, code string) (Ticket, error) {
+	id, err := VerifyTicketCode(code)
+	if err != nil {
+		return Ticket{}, err
+	}
+	q := "SELECT " + ticketColumns + " FROM tickets WHERE id=?"
+	return scanTicket(db.Conn(ctx).QueryRowContext(ctx, db.Rebind(q), id))
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestTicketIssuedWithRegistration tests that registrations come with a ticket that can
+// be looked up by its code until the registration is cancelled
+func TestTicketIssuedWithRegistration(t *testing.T) {
+	setupTestDatabase(t)
+	event := saveEvent(t, Event{Title: "Ticketed Event"})
+
+	registration, err := event.Register(context.Background(), Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	if registration.Ticket == nil || registration.Ticket.RegistrationID != registration.ID || registration.Ticket.EventID != event.ID {
+		t.Fatalf("Expected a ticket for the registration, got %+v", registration.Ticket)
+	}
+
+	ticket, err := GetTicketByCode(context.Background(), registration.Ticket.Code)
+	if err != nil || ticket.ID != registration.Ticket.ID {
+		t.Errorf("Expected to find the ticket by its code, got %+v (%v)", ticket, err)
+	}
+
+	_, err = registration.Cancel(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to cancel registration: %v", err)
+	}
+	_, err = GetTicketByCode(context.Background(), registration.Ticket.Code)
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected the ticket to be removed with the registration, got %v", err)
+	}
+}
+
+// TestTicketPromotedFromWaitlist tests that attendees promoted from the waitlist get a ticket
+func TestTicketPromotedFromWaitlist(t *testing.T) {
+	setupTestDatabase(t)
+	event := saveEvent(t, Event{Title: "Full Event", Capacity: 1})
+	registration, err := event.Register(context.Background(), Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	_, err = event.JoinWaitlist(context.Background(), WaitlistEntry{Name: "Bob", Email: "bob@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to join waitlist: %v", err)
+	}
+
+	promoted, err := registration.Cancel(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to cancel registration: %v", err)
+	}
+	if promoted == nil || promoted.Ticket == nil || promoted.Ticket.RegistrationID != promoted.ID {
+		t.Errorf("Expected the promoted attendee to get a ticket, got %+v", promoted)
+	}
+}
+
+// TestVerifyTicketCode tests that only codes signed with the ticket secret are accepted
+func TestVerifyTicketCode(t *testing.T) {
+	code := signTicket("ticket-1")
+	id, err := VerifyTicketCode(code)
+	if err != nil || id != "ticket-1" {
+		t.Errorf("Expected ticket-1, got %q (%v)", id, err)
+	}
+
+	tampered := strings.Replace(code, "ticket-1", "ticket-2", 1)
+	for _, forged := range []string{tampered, "ticket-1", "ticket-1.", "." + strings.SplitN(code, ".", 2)[1], ""} {
+		if _, err := VerifyTicketCode(forged); !errors.Is(err, ErrTicketInvalid) {
+			t.Errorf("Expected ErrTicketInvalid for %q, got %v", forged, err)
+		}
+	}
+
+	original := TicketSecret
+	TicketSecret = []byte("rotated")
+	defer func() { TicketSecret = original }()
+	if _, err := VerifyTicketCode(code); !errors.Is(err, ErrTicketInvalid) {
+		t.Errorf("Expected codes signed with another secret to be rejected, got %v", err)
+	}
+}
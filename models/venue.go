@@ -0,0 +1,162 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"event_booking_restapi_golang/db"
+	"event_booking_restapi_golang/ids"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// blackoutDateLayout is the format of VenueBlackout dates.
+const blackoutDateLayout = "2006-01-02"
+
+// VenueBlackout is a day a venue is unavailable. No event at the venue may be scheduled
+// on it; events are matched to venues by their location, ignoring case.
+type VenueBlackout struct {
+	ID        string    // Unique identifier for the blackout
+	Location  string    `binding:"required"` // Venue, as given in the location of its events
+	Date      string    `binding:"required"` // Unavailable day, as YYYY-MM-DD in UTC
+	Reason    string    // Why the venue is unavailable, shown to organizers
+	CreatedAt time.Time // Time the blackout was added
+}
+
+// VenueUnavailableError is returned when an event would be scheduled at a venue on
+// one of its blackout dates.
+type VenueUnavailableError struct {
+	Location string // Venue of the event
+	Date     string // Blackout date the event falls on
+	Reason   string // Why the venue is unavailable
+}
+
+func (e VenueUnavailableError) Error() string {
+	msg := fmt.Sprintf("%s is unavailable on %s", e.Location, e.Date)
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+// Save validates the blackout's date and persists it to the database.
+// Returns the stored blackout with its generated ID.
+func (b VenueBlackout) Save(ctx context.Context) (VenueBlackout, error) {
+	b.Location = strings.TrimSpace(b.Location)
+	if b.Location == "" {
+		return VenueBlackout{}, errors.New("the blackout needs a location")
+	}
+	date, err := time.Parse(blackoutDateLayout, b.Date)
+	if err != nil {
+		return VenueBlackout{}, fmt.Errorf("invalid blackout date %q, use YYYY-MM-DD", b.Date)
+	}
+	b.Date = date.Format(blackoutDateLayout)
+	b.ID = ids.New()
+	b.CreatedAt = time.Now()
+	q := "INSERT INTO venue_blackouts (id, location, blackout_date, reason, created_at) VALUES (?,?,?,?,?)"
+	_, err = db.Conn(ctx).ExecContext(ctx, db.Rebind(q), b.ID, b.Location, b.Date, b.Reason, b.CreatedAt)
+	if err != nil {
+		return VenueBlackout{}, err
+	}
+	return b, nil
+}
+
+// Delete removes the blackout from the database.
+// Returns a NotFoundError if no blackout with the ID exists.
+func (b VenueBlackout) Delete(ctx context.Context) error {
+	result, err := db.Conn(ctx).ExecContext(ctx, db.Rebind("DELETE FROM venue_blackouts WHERE id=?"), b.ID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return NotFoundError{Message: fmt.Sprint("Couldn't find a venue blackout with the ID of ", b.ID)}
+	}
+	return nil
+}
+
+// GetVenueBlackouts retrieves the blackouts in date order, only those of the location,
+// ignoring case, unless it is empty.
+func GetVenueBlackouts(ctx context.Context, location string) ([]VenueBlackout, error) {
+	q := "SELECT id, location, blackout_date, reason, created_at FROM venue_blackouts"
+	var args []any
+	if location != "" {
+		q += " WHERE LOWER(location)=LOWER(?)"
+		args = append(args, strings.TrimSpace(location))
+	}
+	q += " ORDER BY blackout_date, location, id"
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blackouts := []VenueBlackout{}
+	for rows.Next() {
+		var b VenueBlackout
+		err = rows.Scan(&b.ID, &b.Location, &b.Date, &b.Reason, &b.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		blackouts = append(blackouts, b)
+	}
+	return blackouts, rows.Err()
+}
+
+// checkVenueAvailable returns a VenueUnavailableError if the location has a blackout
+// on any UTC day from start to end, or on the day of start for events without an end.
+func checkVenueAvailable(ctx context.Context, x queryRower, location string, start time.Time, end *time.Time) error {
+	last := start
+	if end != nil {
+		last = *end
+	}
+	q := "SELECT blackout_date, reason FROM venue_blackouts WHERE LOWER(location)=LOWER(?) AND blackout_date >= ? AND blackout_date <= ? ORDER BY blackout_date LIMIT 1"
+	var blackout VenueUnavailableError
+	err := x.QueryRowContext(ctx, db.Rebind(q), strings.TrimSpace(location),
+		start.UTC().Format(blackoutDateLayout), last.UTC().Format(blackoutDateLayout)).Scan(&blackout.Date, &blackout.Reason)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	blackout.Location = location
+	return blackout
+}
+
+// rescheduled reports whether the update moves the event to another venue or time.
+func rescheduled(old, updated Event) bool {
+	sameEnd := old.EndDateTime == nil && updated.EndDateTime == nil ||
+		old.EndDateTime != nil && updated.EndDateTime != nil && old.EndDateTime.Equal(*updated.EndDateTime)
+	return !strings.EqualFold(strings.TrimSpace(old.Location), strings.TrimSpace(updated.Location)) ||
+		!old.DateTime.Equal(updated.DateTime) || !sameEnd
+}
+
+// reschedules reports whether the patched fields move the event to another venue or time.
+func reschedules(fields map[string]any) bool {
+	for _, name := range []string{"Location", "DateTime", "EndDateTime"} {
+		if _, ok := fields[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPatchedVenue checks the venue availability of the event as patched within tx,
+// unless it is cancelled.
+func checkPatchedVenue(ctx context.Context, tx queryRower, id string) error {
+	var e Event
+	err := tx.QueryRowContext(ctx, db.Rebind("SELECT location, datetime, end_datetime, status FROM events WHERE id=?"), id).
+		Scan(&e.Location, &e.DateTime, &e.EndDateTime, &e.Status)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && e.Status == StatusCancelled {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return checkVenueAvailable(ctx, tx, e.Location, e.DateTime, e.EndDateTime)
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestVenueBlackouts tests adding, listing and removing venue blackouts
+func TestVenueBlackouts(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+
+	for _, invalid := range []VenueBlackout{
+		{Location: " ", Date: "2030-06-01"},
+		{Location: "Town Hall", Date: "01.06.2030"},
+	} {
+		if _, err := invalid.Save(ctx); err == nil {
+			t.Errorf("Expected an error saving %+v", invalid)
+		}
+	}
+	later, err := VenueBlackout{Location: " Town Hall ", Date: "2030-06-02", Reason: "Renovation"}.Save(ctx)
+	if err != nil {
+		t.Fatalf("Failed to save blackout: %v", err)
+	}
+	if later.ID == "" || later.Location != "Town Hall" {
+		t.Errorf("Expected a stored blackout with a trimmed location, got %+v", later)
+	}
+	for _, b := range []VenueBlackout{{Location: "Town Hall", Date: "2030-06-01"}, {Location: "Club", Date: "2030-05-01"}} {
+		if _, err := b.Save(ctx); err != nil {
+			t.Fatalf("Failed to save blackout: %v", err)
+		}
+	}
+
+	blackouts, err := GetVenueBlackouts(ctx, "town hall")
+	if err != nil {
+		t.Fatalf("Failed to get blackouts: %v", err)
+	}
+	if len(blackouts) != 2 || blackouts[0].Date != "2030-06-01" || blackouts[1].Reason != "Renovation" {
+		t.Errorf("Expected the Town Hall blackouts in date order, got %+v", blackouts)
+	}
+	if all, _ := GetVenueBlackouts(ctx, ""); len(all) != 3 || all[0].Location != "Club" {
+		t.Errorf("Expected all blackouts in date order, got %+v", all)
+	}
+
+	if err := later.Delete(ctx); err != nil {
+		t.Fatalf("Failed to delete blackout: %v", err)
+	}
+	var notFound NotFoundError
+	if err := later.Delete(ctx); !errors.As(err, &notFound) {
+		t.Errorf("Expected a NotFoundError deleting twice, got %v", err)
+	}
+}
+
+// TestVenueBlackoutScheduling tests that events can't be created or moved onto a
+// blackout date of their venue
+func TestVenueBlackoutScheduling(t *testing.T) {
+	setupTestDatabase(t)
+	ctx := context.Background()
+	_, err := VenueBlackout{Location: "Town Hall", Date: "2030-06-01", Reason: "Elections"}.Save(ctx)
+	if err != nil {
+		t.Fatalf("Failed to save blackout: %v", err)
+	}
+	blackoutDay := time.Date(2030, 6, 1, 18, 0, 0, 0, time.UTC)
+	dayBefore := blackoutDay.AddDate(0, 0, -1)
+
+	var unavailable VenueUnavailableError
+	_, err = Event{Title: "Gala", Location: "town hall", DateTime: blackoutDay}.Save(ctx)
+	if !errors.As(err, &unavailable) || unavailable.Date != "2030-06-01" || unavailable.Reason != "Elections" {
+		t.Errorf("Expected the blackout to block the event, got %v", err)
+	}
+	// Multi-day events are blocked by a blackout on any of their days
+	end := blackoutDay.Add(24 * time.Hour)
+	_, err = Event{Title: "Festival", Location: "Town Hall", DateTime: dayBefore, EndDateTime: &end}.Save(ctx)
+	if !errors.As(err, &unavailable) {
+		t.Errorf("Expected the blackout to block the festival, got %v", err)
+	}
+	_, err = Event{Title: "Vigil", Location: "Town Hall", DateTime: blackoutDay, Status: StatusCancelled}.Save(ctx)
+	if err != nil {
+		t.Errorf("Expected cancelled events to ignore blackouts, got %v", err)
+	}
+
+	event, err := Event{Title: "Concert", Location: "Town Hall", DateTime: dayBefore}.Save(ctx)
+	if err != nil {
+		t.Fatalf("Failed to save event: %v", err)
+	}
+	moved := event
+	moved.DateTime = blackoutDay
+	if err := moved.Update(ctx); !errors.As(err, &unavailable) {
+		t.Errorf("Expected the blackout to block the update, got %v", err)
+	}
+	if err := event.Patch(ctx, map[string]any{"DateTime": blackoutDay}); !errors.As(err, &unavailable) {
+		t.Errorf("Expected the blackout to block the patch, got %v", err)
+	}
+	stored, err := GetEventById(ctx, event.ID)
+	if err != nil || !stored.DateTime.Equal(dayBefore) {
+		t.Errorf("Expected the event to stay on its day, got %+v (%v)", stored, err)
+	}
+	if err := event.Patch(ctx, map[string]any{"Location": "Club", "DateTime": blackoutDay}); err != nil {
+		t.Errorf("Expected the event to move to another venue on the day, got %v", err)
+	}
+}
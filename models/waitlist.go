@@ -0,0 +1,124 @@
+package models
+
+import (
+	"context"
+	"event_booking_restapi_golang/db"
+	"event_booking_restapi_golang/ids"
+	"time"
+)
+
+// ErrEventNotFull is returned when joining the waitlist of an event that still has places.
+var ErrEventNotFull = conflictError("event still has places available, register instead")
+
+// ErrAlreadyWaitlisted is returned when the email address is already on the event's waitlist.
+var ErrAlreadyWaitlisted = duplicateError("this email is already on the waitlist for the event")
+
+// WaitlistEntry represents an attendee waiting for a place on a full event.
+type WaitlistEntry struct {
+	ID        string    // Unique identifier for the waitlist entry
+	EventID   string    // ID of the event the attendee is waiting for
+	Name      string    `binding:"required"`       // Attendee name (required)
+	Email     string    `binding:"required,email"` // Attendee email (required)
+	CreatedAt time.Time // Time the attendee joined the waitlist
+	WaiverAcceptance
+	Attribution
+}
+
+// JoinWaitlist adds the attendee to the end of the event's waitlist.
+// Only full events accept waitlist entries; ErrEventNotFull is returned otherwise.
+// Returns ErrAlreadyRegistered or ErrAlreadyWaitlisted for duplicate emails and
+// ErrBlockedByOrganizer when the event's organizer blocked the email address, and
+// ErrEventCancelled when the event has been cancelled.
+func (e Event) JoinWaitlist(ctx context.Context, w WaitlistEntry) (WaitlistEntry, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return WaitlistEntry{}, err
+	}
+	defer tx.Rollback()
+
+	blocked, err := isBlocked(ctx, tx, e.UserID, w.Email, BlockedByOrganizer)
+	if err != nil {
+		return WaitlistEntry{}, err
+	}
+	if blocked {
+		return WaitlistEntry{}, ErrBlockedByOrganizer
+	}
+
+	var capacity, taken, registered, waiting int
+	var status string
+	err = tx.QueryRowContext(ctx, db.Rebind("SELECT capacity, status FROM events WHERE id=?"+db.ForUpdate()), e.ID).Scan(&capacity, &status)
+	if err != nil {
+		return WaitlistEntry{}, err
+	}
+	if status == StatusCancelled {
+		return WaitlistEntry{}, ErrEventCancelled
+	}
+	err = tx.QueryRowContext(ctx, db.Rebind("SELECT COUNT(*) FROM registrations WHERE event_id=?"), e.ID).Scan(&taken)
+	if err != nil {
+		return WaitlistEntry{}, err
+	}
+	if capacity == 0 || taken < capacity {
+		return WaitlistEntry{}, ErrEventNotFull
+	}
+
+	err = tx.QueryRowContext(ctx, db.Rebind("SELECT COUNT(*) FROM registrations WHERE event_id=? AND email=?"), e.ID, w.Email).Scan(&registered)
+	if err != nil {
+		return WaitlistEntry{}, err
+	}
+	if registered > 0 {
+		return WaitlistEntry{}, ErrAlreadyRegistered
+	}
+	err = tx.QueryRowContext(ctx, db.Rebind("SELECT COUNT(*) FROM waitlist WHERE event_id=? AND email=?"), e.ID, w.Email).Scan(&waiting)
+	if err != nil {
+		return WaitlistEntry{}, err
+	}
+	if waiting > 0 {
+		return WaitlistEntry{}, ErrAlreadyWaitlisted
+	}
+
+	w.ID = ids.New()
+	w.EventID = e.ID
+	w.CreatedAt = time.Now()
+	_, err = tx.ExecContext(
+		ctx,
+		db.Rebind("INSERT INTO waitlist (id, event_id, name, email, created_at, waiver_id, waiver_accepted_at, waiver_ip, "+attributionColumns+") VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?)"),
+		w.ID, w.EventID, w.Name, w.Email, w.CreatedAt, w.WaiverID, w.WaiverAcceptedAt, w.WaiverIP,
+		w.UTMSource, w.UTMMedium, w.UTMCampaign, w.UTMTerm, w.UTMContent, w.Ref,
+	)
+	if err != nil {
+		return WaitlistEntry{}, err
+	}
+	err = recordAudit(ctx, tx, AuditWaitlisted, w.EventID, w.ID, attendeeDetails(w.Name, w.Email))
+	if err != nil {
+		return WaitlistEntry{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return WaitlistEntry{}, err
+	}
+	return w, nil
+}
+
+// GetWaitlist retrieves the event's waitlist in promotion order.
+// Waiver acceptance and attribution details are left out since the waitlist is public.
+// Returns a slice of WaitlistEntry objects and any error encountered during the query.
+func (e Event) GetWaitlist(ctx context.Context) ([]WaitlistEntry, error) {
+	q := "SELECT id, event_id, name, email, created_at FROM waitlist WHERE event_id=? ORDER BY created_at, id"
+	rows, err := db.Conn(ctx).QueryContext(ctx, db.Rebind(q), e.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []WaitlistEntry
+	for rows.Next() {
+		var w WaitlistEntry
+		err = rows.Scan(&w.ID, &w.EventID, &w.Name, &w.Email, &w.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, w)
+	}
+	return entries, nil
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestEvent_JoinWaitlist tests joining the waitlist of full and open events
+func TestEvent_JoinWaitlist(t *testing.T) {
+	setupTestDatabase(t)
+	event := saveEvent(t, Event{Title: "Full Event", Capacity: 1})
+
+	_, err := event.JoinWaitlist(context.Background(), WaitlistEntry{Name: "Early", Email: "early@example.com"})
+	if !errors.Is(err, ErrEventNotFull) {
+		t.Errorf("Expected ErrEventNotFull, got %v", err)
+	}
+
+	_, err = event.Register(context.Background(), Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	_, err = event.JoinWaitlist(context.Background(), WaitlistEntry{Name: "Alice", Email: "alice@example.com"})
+	if !errors.Is(err, ErrAlreadyRegistered) {
+		t.Errorf("Expected ErrAlreadyRegistered, got %v", err)
+	}
+
+	entry, err := event.JoinWaitlist(context.Background(), WaitlistEntry{Name: "Bob", Email: "bob@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to join waitlist: %v", err)
+	}
+	if entry.ID == "" || entry.EventID != event.ID {
+		t.Errorf("Expected waitlist entry with ID for event %s, got %+v", event.ID, entry)
+	}
+
+	_, err = event.JoinWaitlist(context.Background(), WaitlistEntry{Name: "Bob", Email: "bob@example.com"})
+	if !errors.Is(err, ErrAlreadyWaitlisted) {
+		t.Errorf("Expected ErrAlreadyWaitlisted, got %v", err)
+	}
+}
+
+// TestRegistration_CancelPromotesWaitlist tests that cancelling a registration promotes the next waiting attendee
+func TestRegistration_CancelPromotesWaitlist(t *testing.T) {
+	setupTestDatabase(t)
+	event := saveEvent(t, Event{Title: "Full Event", Capacity: 1})
+
+	registration, err := event.Register(context.Background(), Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	for _, email := range []string{"bob@example.com", "carol@example.com"} {
+		_, err = event.JoinWaitlist(context.Background(), WaitlistEntry{Name: "Waiting", Email: email})
+		if err != nil {
+			t.Fatalf("Failed to join waitlist: %v", err)
+		}
+	}
+
+	promoted, err := registration.Cancel(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to cancel registration: %v", err)
+	}
+	if promoted == nil || promoted.Email != "bob@example.com" {
+		t.Fatalf("Expected bob@example.com to be promoted, got %+v", promoted)
+	}
+
+	_, err = GetRegistrationById(context.Background(), promoted.ID)
+	if err != nil {
+		t.Errorf("Promoted registration should be stored: %v", err)
+	}
+
+	waitlist, err := event.GetWaitlist(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get waitlist: %v", err)
+	}
+	if len(waitlist) != 1 || waitlist[0].Email != "carol@example.com" {
+		t.Errorf("Expected only carol@example.com to remain waiting, got %+v", waitlist)
+	}
+
+	count, err := event.CountRegistrations(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to count registrations: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 registration after promotion, got %d", count)
+	}
+}
+
+// TestRegistration_CancelWithoutWaitlist tests cancelling when nobody is waiting
+func TestRegistration_CancelWithoutWaitlist(t *testing.T) {
+	setupTestDatabase(t)
+	event := saveEvent(t, Event{Title: "Quiet Event", Capacity: 5})
+
+	registration, err := event.Register(context.Background(), Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	promoted, err := registration.Cancel(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to cancel registration: %v", err)
+	}
+	if promoted != nil {
+		t.Errorf("Expected nobody to be promoted, got %+v", promoted)
+	}
+
+	_, err = GetRegistrationById(context.Background(), registration.ID)
+	if err == nil {
+		t.Error("Expected cancelled registration to be removed")
+	}
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"event_booking_restapi_golang/db"
+	"time"
+)
+
+// Waiver acceptance states reported in attendee exports.
+const (
+	WaiverNotRequired = "not_required" // The event has no waiver
+	WaiverAccepted    = "accepted"     // The attendee accepted the current waiver
+	WaiverOutdated    = "outdated"     // The attendee accepted an earlier version of the waiver
+	WaiverMissing     = "missing"      // The attendee booked before a waiver was attached
+)
+
+// WaiverAcceptance records an attendee's acceptance of an event's liability waiver.
+// The waiver attachment ID serves as the version, since replacing a waiver uploads a new file.
+type WaiverAcceptance struct {
+	WaiverID         string     // ID of the waiver attachment that was accepted, empty if none
+	WaiverAcceptedAt *time.Time // Time the waiver was accepted
+	WaiverIP         string     // IP address the waiver was accepted from
+}
+
+// WaiverStatus describes the acceptance relative to the event's current waiver,
+// which is nil when the event has none.
+func (w WaiverAcceptance) WaiverStatus(current *Attachment) string {
+	switch {
+	case current == nil:
+		return WaiverNotRequired
+	case w.WaiverID == current.ID:
+		return WaiverAccepted
+	case w.WaiverID != "":
+		return WaiverOutdated
+	default:
+		return WaiverMissing
+	}
+}
+
+// WaiverRequiredError is returned when a booking is made on an event without accepting
+// its current waiver.
+type WaiverRequiredError struct {
+	Waiver Attachment // The event's current waiver
+}
+
+func (e WaiverRequiredError) Error() string {
+	return "the event waiver must be accepted by sending its ID as WaiverID"
+}
+
+// GetWaiver retrieves the event's current waiver, the most recently attached one.
+// Returns nil when the event has no waiver.
+func (e Event) GetWaiver(ctx context.Context) (*Attachment, error) {
+	return currentWaiver(ctx, db.Conn(ctx), e.ID)
+}
+
+// currentWaiver retrieves the current waiver of the event with the ID through q, nil
+// when it has none.
+func currentWaiver(ctx context.Context, q queryRower, eventID string) (*Attachment, error) {
+	query := "SELECT " + attachmentColumns + " FROM attachments WHERE event_id=? AND kind=? ORDER BY created_at DESC, id DESC LIMIT 1"
+	waiver, err := scanAttachment(q.QueryRowContext(ctx, db.Rebind(query), eventID, AttachmentWaiver))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &waiver, nil
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestEvent_GetWaiver tests that the most recently attached waiver is current
+func TestEvent_GetWaiver(t *testing.T) {
+	setupTestDatabase(t)
+	useTempAttachmentsDir(t)
+	event := saveEvent(t, Event{Title: "Climbing Day"})
+
+	waiver, err := event.GetWaiver(context.Background())
+	if err != nil || waiver != nil {
+		t.Fatalf("Expected no waiver, got %v (%v)", waiver, err)
+	}
+
+	_, err = event.AddAttachment(context.Background(), Attachment{Kind: AttachmentAgenda, FileName: "agenda.pdf", ContentType: "application/pdf"}, []byte("%PDF"))
+	if err != nil {
+		t.Fatalf("Failed to add agenda: %v", err)
+	}
+	first, err := event.AddAttachment(context.Background(), Attachment{Kind: AttachmentWaiver, FileName: "v1.pdf", ContentType: "application/pdf"}, []byte("%PDF"))
+	if err != nil {
+		t.Fatalf("Failed to add waiver: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	second, err := event.AddAttachment(context.Background(), Attachment{Kind: AttachmentWaiver, FileName: "v2.pdf", ContentType: "application/pdf"}, []byte("%PDF"))
+	if err != nil {
+		t.Fatalf("Failed to add waiver: %v", err)
+	}
+
+	waiver, err = event.GetWaiver(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get waiver: %v", err)
+	}
+	if waiver == nil || waiver.ID != second.ID {
+		t.Errorf("Expected waiver %s to be current, got %+v", second.ID, waiver)
+	}
+
+	tests := []struct {
+		acceptance WaiverAcceptance
+		current    *Attachment
+		expected   string
+	}{
+		{WaiverAcceptance{}, nil, WaiverNotRequired},
+		{WaiverAcceptance{WaiverID: second.ID}, waiver, WaiverAccepted},
+		{WaiverAcceptance{WaiverID: first.ID}, waiver, WaiverOutdated},
+		{WaiverAcceptance{}, waiver, WaiverMissing},
+	}
+	for _, tt := range tests {
+		if status := tt.acceptance.WaiverStatus(tt.current); status != tt.expected {
+			t.Errorf("Expected %s for %+v, got %s", tt.expected, tt.acceptance, status)
+		}
+	}
+}
+
+// TestPromotionKeepsWaiverAcceptance tests that acceptance given on the waitlist carries over
+func TestPromotionKeepsWaiverAcceptance(t *testing.T) {
+	setupTestDatabase(t)
+	event := saveEvent(t, Event{Title: "Via Ferrata", Capacity: 1})
+
+	accepted := time.Now()
+	acceptance := WaiverAcceptance{WaiverID: "waiver-1", WaiverAcceptedAt: &accepted, WaiverIP: "203.0.113.7"}
+	first, err := event.Register(context.Background(), Registration{Name: "Alice", Email: "alice@example.com", WaiverAcceptance: acceptance})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	_, err = event.JoinWaitlist(context.Background(), WaitlistEntry{Name: "Bob", Email: "bob@example.com", WaiverAcceptance: acceptance})
+	if err != nil {
+		t.Fatalf("Failed to join waitlist: %v", err)
+	}
+
+	promoted, err := first.Cancel(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to cancel: %v", err)
+	}
+	stored, err := GetRegistrationById(context.Background(), promoted.ID)
+	if err != nil {
+		t.Fatalf("Failed to get promoted registration: %v", err)
+	}
+	if stored.WaiverID != "waiver-1" || stored.WaiverIP != "203.0.113.7" || stored.WaiverAcceptedAt == nil {
+		t.Errorf("Expected waiver acceptance to carry over, got %+v", stored.WaiverAcceptance)
+	}
+
+	registrations, err := event.GetRegistrations(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get registrations: %v", err)
+	}
+	if len(registrations) != 1 || registrations[0].Email != "bob@example.com" {
+		t.Errorf("Expected Bob to hold the only place, got %+v", registrations)
+	}
+}
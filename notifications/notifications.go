@@ -0,0 +1,108 @@
+// Package notifications writes the emails the API sends to attendees and organizers.
+// Each kind of email is a Template rendered from its data; a Notifier renders emails
+// and hands them to a mail.Mailer, through the job queue for emails that must not
+// hold up a request, so a slow or failing mail provider never affects a booking.
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/breaker"
+	"event_booking_restapi_golang/jobs"
+	"event_booking_restapi_golang/mail"
+	"time"
+)
+
+// emailJob is the kind of the jobs that deliver emails.
+const emailJob = "email"
+
+// Emails stop going to the mail provider after breakerThreshold consecutive failures,
+// until breakerCooldown has passed; queued emails are retried meanwhile.
+const (
+	breakerThreshold = 5
+	breakerCooldown  = 30 * time.Second
+)
+
+// Email is an email to render and send.
+type Email struct {
+	To       string    // Recipient address
+	FromName string    // Display name shown with the sender address, optional
+	ReplyTo  string    // Address replies go to, optional
+	Template *Template // Template the subject and body are rendered from
+	Data     any       // Data for the template, of the type its documentation names
+}
+
+// emailPayload is a rendered email waiting in the job queue.
+type emailPayload struct {
+	Template string       `json:"template"`
+	Message  mail.Message `json:"message"`
+}
+
+// Notifier renders emails and sends them through a mailer. A nil *Notifier is valid
+// and sends nothing, which is how email is disabled.
+type Notifier struct {
+	mailer  mail.Mailer
+	queue   jobs.Queue
+	breaker *breaker.Breaker
+}
+
+// New returns a notifier sending through mailer, delivering background emails as jobs
+// on queue. Returns nil when mailer is nil.
+func New(mailer mail.Mailer, queue jobs.Queue) *Notifier {
+	if mailer == nil {
+		return nil
+	}
+	n := &Notifier{mailer: mailer, queue: queue, breaker: breaker.New(breakerThreshold, breakerCooldown)}
+	queue.Handle(emailJob, n.deliver)
+	return n
+}
+
+// Breaker returns the circuit breaker guarding the calls to the mail provider, nil
+// when email is disabled.
+func (n *Notifier) Breaker() *breaker.Breaker {
+	if n == nil {
+		return nil
+	}
+	return n.breaker
+}
+
+// Enabled reports whether emails are sent anywhere.
+func (n *Notifier) Enabled() bool {
+	return n != nil
+}
+
+// Send renders the email and queues it for delivery in the background. Delivery is
+// retried by the job queue; failures are logged with the ID of the request in ctx.
+// Returns an error if the email can't be rendered or queued.
+func (n *Notifier) Send(ctx context.Context, email Email) error {
+	if n == nil {
+		return nil
+	}
+	msg, err := email.Render()
+	if err != nil {
+		return err
+	}
+	return n.queue.Enqueue(ctx, emailJob, emailPayload{Template: email.Template.Name, Message: msg})
+}
+
+// SendNow renders the email and delivers it before returning, for emails whose
+// failure the caller reports, such as verification codes.
+func (n *Notifier) SendNow(ctx context.Context, email Email) error {
+	if n == nil {
+		return nil
+	}
+	msg, err := email.Render()
+	if err != nil {
+		return err
+	}
+	return n.breaker.Call(func() error { return n.mailer.Send(ctx, msg) }, nil)
+}
+
+// deliver sends the email of a queued job.
+func (n *Notifier) deliver(ctx context.Context, payload []byte) error {
+	var email emailPayload
+	if err := json.Unmarshal(payload, &email); err != nil {
+		return jobs.Permanent(err)
+	}
+	return n.breaker.Call(func() error { return n.mailer.Send(ctx, email.Message) }, nil)
+}
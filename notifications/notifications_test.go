@@ -0,0 +1,176 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"event_booking_restapi_golang/jobs"
+	"event_booking_restapi_golang/mail"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/testutils"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeMailer records the messages it is asked to send, or fails with err when set
+type fakeMailer struct {
+	mu   sync.Mutex
+	sent []mail.Message
+	err  error
+}
+
+func (m *fakeMailer) Send(ctx context.Context, msg mail.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func (m *fakeMailer) messages() []mail.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]mail.Message(nil), m.sent...)
+}
+
+var jazzNight = EventDetails{
+	ID:       "event-1",
+	Title:    "Jazz Night",
+	Location: "The Hall",
+	Start:    time.Date(2026, 3, 10, 20, 0, 0, 0, time.UTC),
+}
+
+// TestTemplates tests rendering every template with and without its optional data
+func TestTemplates(t *testing.T) {
+	tests := []struct {
+		name     string
+		template *Template
+		data     any
+		subject  string
+		contains []string
+		excludes []string
+	}{
+		{"booking", BookingConfirmation,
+			Booking{Event: jazzNight, RegistrationID: "reg-1", Name: "Alice", Ticket: "T-123", Message: "Doors open at 7."},
+			"You're booked for Jazz Night",
+			[]string{"Hi Alice,", "10 March 2026 at 20:00 UTC at The Hall", "ticket code is: T-123", "Doors open at 7.", "DELETE /registrations/reg-1"}, nil},
+		{"booking without ticket", BookingConfirmation, Booking{Event: jazzNight, Name: "Alice"},
+			"You're booked for Jazz Night", []string{"is confirmed.\n\nTo cancel"}, []string{"ticket code"}},
+		{"booking cancelled", BookingCancellation, Booking{Event: jazzNight, Name: "Alice"},
+			"Your booking for Jazz Night is cancelled", []string{"place released"}, nil},
+		{"event cancelled", EventCancellation, Cancellation{Event: jazzNight, Name: "Alice", Reason: "The band is ill."},
+			"Jazz Night has been cancelled", []string{"by the organizer:\n\nThe band is ill.\n\nYou don't need"}, nil},
+		{"event cancelled without reason", EventCancellation, Cancellation{Event: jazzNight, Name: "Alice"},
+			"Jazz Night has been cancelled", []string{"by the organizer.\n\nYou don't need"}, nil},
+		{"reply", MessageReply, Reply{Event: jazzNight, Name: "Alice", Body: "Yes, behind the hall."},
+			"New message about Jazz Night", []string{"replied to you:\n\nYes, behind the hall.", "GET /events/event-1/messages"}, nil},
+		{"verification", SenderVerification, Verification{Event: jazzNight, Code: "abc123"},
+			"Confirm the reply-to address for Jazz Night", []string{"code is: abc123", "POST /events/event-1/sender/verify"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject, body, err := tt.template.Render(tt.data)
+			if err != nil {
+				t.Fatalf("Failed to render: %v", err)
+			}
+			if subject != tt.subject {
+				t.Errorf("Expected subject %q, got %q", tt.subject, subject)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(body, s) {
+					t.Errorf("Expected %q in %q", s, body)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(body, s) {
+					t.Errorf("Expected no %q in %q", s, body)
+				}
+			}
+		})
+	}
+}
+
+// TestRenderErrors tests that emails without a template or with the wrong data are rejected
+func TestRenderErrors(t *testing.T) {
+	if _, err := (Email{To: "alice@example.com"}).Render(); err == nil {
+		t.Error("Expected an email without a template to be rejected")
+	}
+	if _, err := (Email{To: "alice@example.com", Template: MessageReply, Data: Verification{}}).Render(); err == nil {
+		t.Error("Expected data of the wrong type to be rejected")
+	}
+}
+
+// TestNotifierSend tests that emails are queued as jobs and delivered by the job queue,
+// and that failed deliveries are retried
+func TestNotifierSend(t *testing.T) {
+	tdb := testutils.SetupTestDatabase(t)
+	defer tdb.Cleanup()
+	mailer := &fakeMailer{}
+	pool := jobs.New(1)
+	n := New(mailer, pool)
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		err := n.Send(context.Background(), Email{
+			To:       strings.ToLower(name) + "@example.com",
+			FromName: "Jazz Club",
+			Template: EventCancellation,
+			Data:     Cancellation{Event: jazzNight, Name: name},
+		})
+		if err != nil {
+			t.Fatalf("Failed to queue the email: %v", err)
+		}
+	}
+	if len(mailer.messages()) != 0 {
+		t.Fatal("Expected Send not to wait for delivery")
+	}
+	for {
+		ran, err := pool.RunNext(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to run the job: %v", err)
+		}
+		if !ran {
+			break
+		}
+	}
+	sent := mailer.messages()
+	if len(sent) != 3 || sent[0].FromName != "Jazz Club" || sent[0].Subject != "Jazz Night has been cancelled" {
+		t.Errorf("Expected the 3 emails to be delivered, got %+v", sent)
+	}
+
+	mailer.err = errors.New("relay down")
+	if err := n.Send(context.Background(), Email{To: "dan@example.com", Template: EventCancellation, Data: Cancellation{Event: jazzNight}}); err != nil {
+		t.Fatalf("Failed to queue the email: %v", err)
+	}
+	if ran, err := pool.RunNext(context.Background()); !ran || err != nil {
+		t.Fatalf("Expected the email to be tried, got %v, %v", ran, err)
+	}
+	var status, lastError string
+	err := tdb.DB.QueryRow("SELECT status, last_error FROM jobs").Scan(&status, &lastError)
+	if err != nil || status != models.JobPending || lastError != "relay down" {
+		t.Errorf("Expected the email to wait for a retry, got %q %q, %v", status, lastError, err)
+	}
+}
+
+// TestNotifierSendNow tests that synchronous sends report delivery failures
+func TestNotifierSendNow(t *testing.T) {
+	mailer := &fakeMailer{err: errors.New("relay down")}
+	n := New(mailer, jobs.New(1))
+	err := n.SendNow(context.Background(), Email{To: "owner@example.com", Template: SenderVerification, Data: Verification{Event: jazzNight, Code: "abc"}})
+	if err == nil || err.Error() != "relay down" {
+		t.Errorf("Expected the delivery failure, got %v", err)
+	}
+}
+
+// TestNilNotifier tests that a nil notifier is disabled and sends nothing
+func TestNilNotifier(t *testing.T) {
+	n := New(nil, jobs.New(1))
+	if n.Enabled() {
+		t.Error("Expected a notifier without a mailer to be disabled")
+	}
+	email := Email{To: "alice@example.com", Template: MessageReply, Data: Reply{}}
+	if n.Send(context.Background(), email) != nil || n.SendNow(context.Background(), email) != nil {
+		t.Error("Expected a nil notifier to accept and drop emails")
+	}
+}
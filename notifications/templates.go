@@ -0,0 +1,172 @@
+package notifications
+
+import (
+	"errors"
+	"event_booking_restapi_golang/mail"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Template renders the subject and body of one kind of email with text/template.
+type Template struct {
+	Name    string // Name of the template, used in logs
+	subject *template.Template
+	body    *template.Template
+}
+
+// MustTemplate parses the subject and body of a template and panics if either is
+// invalid, for templates defined at package level.
+func MustTemplate(name, subject, body string) *Template {
+	return &Template{
+		Name:    name,
+		subject: template.Must(template.New(name + ".subject").Option("missingkey=error").Parse(subject)),
+		body:    template.Must(template.New(name + ".body").Option("missingkey=error").Parse(body)),
+	}
+}
+
+// Render returns the subject and body of the template for data.
+func (t *Template) Render(data any) (subject, body string, err error) {
+	var b strings.Builder
+	err = t.subject.Execute(&b, data)
+	if err != nil {
+		return "", "", err
+	}
+	subject = b.String()
+	b.Reset()
+	err = t.body.Execute(&b, data)
+	if err != nil {
+		return "", "", err
+	}
+	return subject, b.String(), nil
+}
+
+// Render renders the email into the message to send.
+func (e Email) Render() (mail.Message, error) {
+	if e.Template == nil {
+		return mail.Message{}, errors.New("the email has no template")
+	}
+	subject, body, err := e.Template.Render(e.Data)
+	if err != nil {
+		return mail.Message{}, err
+	}
+	return mail.Message{
+		To:       e.To,
+		FromName: e.FromName,
+		ReplyTo:  e.ReplyTo,
+		Subject:  subject,
+		Body:     body,
+	}, nil
+}
+
+// EventDetails describes the event an email is about.
+type EventDetails struct {
+	ID       string
+	Title    string
+	Location string
+	Start    time.Time
+}
+
+// When returns the start of the event as written in emails.
+func (e EventDetails) When() string {
+	return e.Start.UTC().Format("2 January 2006 at 15:04 UTC")
+}
+
+// Booking is the data of BookingConfirmation and BookingCancellation.
+type Booking struct {
+	Event          EventDetails
+	RegistrationID string
+	Name           string // Attendee name
+	Ticket         string // Ticket code to present at check-in, empty for none
+	Message        string // Organizer's confirmation message, empty for none
+}
+
+// Cancellation is the data of EventCancellation.
+type Cancellation struct {
+	Event  EventDetails
+	Name   string // Attendee name
+	Reason string // Reason the organizer gave, empty for none
+}
+
+// Reply is the data of MessageReply.
+type Reply struct {
+	Event EventDetails
+	Name  string // Attendee name
+	Body  string // The organizer's reply
+}
+
+// Verification is the data of SenderVerification.
+type Verification struct {
+	Event EventDetails
+	Code  string // Code confirming the reply-to address
+}
+
+// BookingConfirmation confirms a booking to the attendee, with their ticket. It is
+// also sent to attendees promoted from the waitlist. Its data is a Booking.
+var BookingConfirmation = MustTemplate("booking_confirmation",
+	`You're booked for {{.Event.Title}}`,
+	`Hi {{.Name}},
+
+Your place at {{.Event.Title}} on {{.Event.When}} at {{.Event.Location}} is confirmed.
+{{- if .Ticket}}
+
+Your ticket code is: {{.Ticket}}
+Show it at the door to check in.
+{{- end}}
+{{- if .Message}}
+
+{{.Message}}
+{{- end}}
+
+To cancel, send DELETE /registrations/{{.RegistrationID}}.
+`)
+
+// BookingCancellation confirms to the attendee that they cancelled their booking.
+// Its data is a Booking.
+var BookingCancellation = MustTemplate("booking_cancellation",
+	`Your booking for {{.Event.Title}} is cancelled`,
+	`Hi {{.Name}},
+
+Your booking for {{.Event.Title}} on {{.Event.When}} at {{.Event.Location}} has been cancelled and your place released.
+If you didn't ask for this, contact the organizer.
+`)
+
+// EventCancellation tells a registered attendee the organizer cancelled the event.
+// Its data is a Cancellation.
+var EventCancellation = MustTemplate("event_cancellation",
+	`{{.Event.Title}} has been cancelled`,
+	`Hi {{.Name}},
+
+{{.Event.Title}} on {{.Event.When}} at {{.Event.Location}} has been cancelled by the organizer
+{{- if .Reason}}:
+
+{{.Reason}}
+{{- else}}.
+{{- end}}
+
+You don't need to do anything to cancel your registration.
+`)
+
+// MessageReply tells an attendee the organizer replied to their message. Its data
+// is a Reply.
+var MessageReply = MustTemplate("message_reply",
+	`New message about {{.Event.Title}}`,
+	`Hi {{.Name}},
+
+The organizer of {{.Event.Title}} replied to you:
+
+{{.Body}}
+
+Read the conversation with GET /events/{{.Event.ID}}/messages.
+`)
+
+// SenderVerification sends the code confirming an event's reply-to address. Its
+// data is a Verification.
+var SenderVerification = MustTemplate("sender_verification",
+	`Confirm the reply-to address for {{.Event.Title}}`,
+	`Replies to emails about {{.Event.Title}} will be sent to this address once you confirm it.
+
+Your verification code is: {{.Code}}
+
+Send it to POST /events/{{.Event.ID}}/sender/verify within 24 hours. If you didn't ask for this, ignore this email.
+`)
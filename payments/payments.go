@@ -0,0 +1,144 @@
+// Package payments hands approved refunds to the payment provider that took the
+// attendees' payments. The API doesn't take payments itself, so it reaches the
+// provider through a signed webhook to the service that integrates with it.
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"event_booking_restapi_golang/breaker"
+	"event_booking_restapi_golang/jobs"
+	"event_booking_restapi_golang/logging"
+	"event_booking_restapi_golang/models"
+	"os"
+	"time"
+)
+
+// refundJob is the kind of the jobs that carry out approved refunds.
+const refundJob = "refund"
+
+// refundTimeout bounds one refund call to the provider.
+const refundTimeout = 20 * time.Second
+
+// Refunds stop calling the provider after breakerThreshold consecutive failures, until
+// breakerCooldown has passed; the refunds in between are retried like other failures.
+const (
+	breakerThreshold = 5
+	breakerCooldown  = 30 * time.Second
+)
+
+// ErrRejected is returned when the provider refuses a refund, such as for a payment
+// that was already refunded; retrying won't help.
+var ErrRejected = errors.New("the payment provider rejected the refund")
+
+// Refund is an approved refund request to carry out.
+type Refund struct {
+	RequestID      string // ID of the refund request, which the provider can use to deduplicate
+	RegistrationID string // Booking the attendee paid for
+	EventID        string // Event of the booking
+	Name           string // Attendee name
+	Email          string // Attendee email
+	Reason         string // Why the attendee asked for the refund
+}
+
+// Refunder refunds attendees through a payment provider.
+type Refunder interface {
+	// Name identifies the refunder in logs.
+	Name() string
+	// Refund refunds the attendee's payment for the booking.
+	// Returns the provider's reference for the refund, or an error wrapping
+	// ErrRejected if the provider refused it.
+	Refund(ctx context.Context, refund Refund) (string, error)
+}
+
+// FromEnv builds the refunder from REFUND_WEBHOOK_URL and REFUND_WEBHOOK_SECRET, the
+// key the webhook requests are signed with. Returns nil when no webhook is
+// configured, in which case approved refunds are made outside of the API.
+// Returns an error if the URL is invalid or the secret is missing.
+func FromEnv() (Refunder, error) {
+	url := os.Getenv("REFUND_WEBHOOK_URL")
+	if url == "" {
+		return nil, nil
+	}
+	secret := os.Getenv("REFUND_WEBHOOK_SECRET")
+	if secret == "" {
+		return nil, errors.New("REFUND_WEBHOOK_URL needs REFUND_WEBHOOK_SECRET to sign the refund requests")
+	}
+	return NewWebhookRefunder(url, []byte(secret))
+}
+
+// Processor carries out approved refund requests through a refunder, as jobs on a
+// queue so that refunds the provider fails are retried. A nil *Processor is valid
+// and refunds nothing, leaving approved requests to be refunded outside of the API.
+type Processor struct {
+	refunder Refunder
+	queue    jobs.Queue
+	breaker  *breaker.Breaker
+}
+
+// NewProcessor returns a processor refunding through refunder, with the refunds run
+// as jobs on queue. Returns nil when refunder is nil.
+func NewProcessor(refunder Refunder, queue jobs.Queue) *Processor {
+	if refunder == nil {
+		return nil
+	}
+	p := &Processor{refunder: refunder, queue: queue, breaker: breaker.New(breakerThreshold, breakerCooldown)}
+	queue.Handle(refundJob, p.refund)
+	return p
+}
+
+// Enqueue queues the refund of the approved request. The request is marked refunded
+// once the provider made the refund, or failed when it rejected the refund or every
+// attempt failed.
+func (p *Processor) Enqueue(ctx context.Context, request models.RefundRequest) error {
+	if p == nil {
+		return nil
+	}
+	return p.queue.Enqueue(ctx, refundJob, Refund{
+		RequestID:      request.ID,
+		RegistrationID: request.RegistrationID,
+		EventID:        request.EventID,
+		Name:           request.Name,
+		Email:          request.Email,
+		Reason:         request.Reason,
+	})
+}
+
+// Breaker returns the circuit breaker guarding the calls to the provider, nil when
+// refunds are made outside of the API.
+func (p *Processor) Breaker() *breaker.Breaker {
+	if p == nil {
+		return nil
+	}
+	return p.breaker
+}
+
+// refund carries out the refund of a queued job and records the outcome on its request.
+func (p *Processor) refund(ctx context.Context, payload []byte) error {
+	var refund Refund
+	if err := json.Unmarshal(payload, &refund); err != nil {
+		return jobs.Permanent(err)
+	}
+	refundCtx, cancel := context.WithTimeout(ctx, refundTimeout)
+	var reference string
+	// Rejections are answers from a working provider, so they don't open the circuit
+	err := p.breaker.Call(func() error {
+		var err error
+		reference, err = p.refunder.Refund(refundCtx, refund)
+		return err
+	}, func(err error) bool { return !errors.Is(err, ErrRejected) })
+	cancel()
+	if err == nil {
+		return models.CompleteRefund(ctx, refund.RequestID, reference, time.Now())
+	}
+	logging.FromContext(ctx).Warn("Couldn't refund the attendee", "refunder", p.refunder.Name(),
+		"refund_request_id", refund.RequestID, "error", err)
+	if errors.Is(err, ErrRejected) || jobs.LastAttempt(ctx) {
+		if failErr := models.FailRefund(ctx, refund.RequestID, err.Error()); failErr != nil {
+			return failErr
+		}
+		return jobs.Permanent(err)
+	}
+	return err
+}
@@ -0,0 +1,108 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"event_booking_restapi_golang/jobs"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/testutils"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// fakeRefunder records refunds and answers with the error set for the attendee's email
+type fakeRefunder struct {
+	refunded []Refund
+	errs     map[string]error
+}
+
+func (f *fakeRefunder) Name() string {
+	return "fake"
+}
+
+func (f *fakeRefunder) Refund(ctx context.Context, refund Refund) (string, error) {
+	if err := f.errs[refund.Email]; err != nil {
+		return "", err
+	}
+	f.refunded = append(f.refunded, refund)
+	return "re_" + refund.Name, nil
+}
+
+// approvedRefund books the attendee on the event and approves their refund request
+func approvedRefund(t *testing.T, event models.Event, name string) models.RefundRequest {
+	t.Helper()
+	ctx := context.Background()
+	registration, err := event.Register(ctx, models.Registration{Name: name, Email: name + "@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	request, _, err := registration.RequestRefund(ctx, "Sick", time.Now())
+	if err != nil {
+		t.Fatalf("Failed to request refund: %v", err)
+	}
+	request, _, err = models.DecideRefundRequest(ctx, request.ID, true, "", time.Now())
+	if err != nil {
+		t.Fatalf("Failed to approve refund: %v", err)
+	}
+	return request
+}
+
+// TestProcessor tests that approved refunds are carried out as jobs and their outcome
+// is recorded on the requests
+func TestProcessor(t *testing.T) {
+	tdb := testutils.SetupTestDatabase(t)
+	defer tdb.Cleanup()
+	ctx := context.Background()
+	event, err := models.Event{Title: "Jazz Night", Description: "Live music", Location: "Club", DateTime: time.Now().Add(24 * time.Hour)}.Save(ctx)
+	if err != nil {
+		t.Fatalf("Failed to save event: %v", err)
+	}
+	refunder := &fakeRefunder{errs: map[string]error{
+		"bob@example.com":   fmt.Errorf("%w: already refunded", ErrRejected),
+		"carol@example.com": errors.New("provider down"),
+	}}
+	queue := jobs.New(1)
+	processor := NewProcessor(refunder, queue)
+
+	requests := map[string]models.RefundRequest{}
+	for _, name := range []string{"alice", "bob", "carol"} {
+		requests[name] = approvedRefund(t, event, name)
+		if err := processor.Enqueue(ctx, requests[name]); err != nil {
+			t.Fatalf("Failed to enqueue refund: %v", err)
+		}
+	}
+	for range requests {
+		if _, err := queue.RunNext(ctx); err != nil {
+			t.Fatalf("Failed to run the refund: %v", err)
+		}
+	}
+
+	status := func(name string) models.RefundRequest {
+		request, err := models.GetRefundRequest(ctx, requests[name].ID)
+		if err != nil {
+			t.Fatalf("Failed to get refund request: %v", err)
+		}
+		return request
+	}
+	if r := status("alice"); r.Status != models.RefundRefunded || r.ProviderReference != "re_alice" || r.RefundedAt == nil {
+		t.Errorf("Expected Alice to be refunded, got %+v", r)
+	}
+	if len(refunder.refunded) != 1 || refunder.refunded[0].RequestID != requests["alice"].ID {
+		t.Errorf("Expected only Alice's refund to be made, got %+v", refunder.refunded)
+	}
+	if r := status("bob"); r.Status != models.RefundFailed || r.Error == "" {
+		t.Errorf("Expected Bob's rejected refund to fail, got %+v", r)
+	}
+	if r := status("carol"); r.Status != models.RefundApproved {
+		t.Errorf("Expected Carol's refund to wait for a retry, got %+v", r)
+	}
+
+	if NewProcessor(nil, jobs.New(1)) != nil {
+		t.Error("Expected no processor without a refunder")
+	}
+	var disabled *Processor
+	if err := disabled.Enqueue(ctx, requests["alice"]); err != nil {
+		t.Errorf("Expected a disabled processor to refund nothing, got %v", err)
+	}
+}
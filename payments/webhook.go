@@ -0,0 +1,98 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/logging"
+	"event_booking_restapi_golang/middlewares"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// webhookRefunder posts refunds as signed JSON to the service that integrates with
+// the payment provider.
+type webhookRefunder struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// webhookPayload is the JSON body of a refund webhook.
+type webhookPayload struct {
+	RefundRequestID string `json:"refund_request_id"`
+	RegistrationID  string `json:"registration_id"`
+	EventID         string `json:"event_id"`
+	Name            string `json:"name"`
+	Email           string `json:"email"`
+	Reason          string `json:"reason"`
+}
+
+// webhookResponse is the JSON body the webhook answers a refund with.
+type webhookResponse struct {
+	Reference string `json:"reference"`
+}
+
+// NewWebhookRefunder returns a refunder that posts each refund to rawURL, which must
+// be an http or https URL. Requests are signed with secret like partner callbacks
+// (see middlewares.SignCallback) and carry the ID of the request that approved the
+// refund in X-Request-ID. The webhook answers 2xx with the provider's reference for
+// the refund, or 4xx when the provider rejected it.
+func NewWebhookRefunder(rawURL string, secret []byte) (Refunder, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("refund webhook URL %q must be an http or https URL", rawURL)
+	}
+	return &webhookRefunder{url: rawURL, secret: secret, client: &http.Client{Timeout: refundTimeout}}, nil
+}
+
+func (r *webhookRefunder) Name() string {
+	return "webhook"
+}
+
+func (r *webhookRefunder) Refund(ctx context.Context, refund Refund) (string, error) {
+	body, err := json.Marshal(webhookPayload{
+		RefundRequestID: refund.RequestID,
+		RegistrationID:  refund.RegistrationID,
+		EventID:         refund.EventID,
+		Name:            refund.Name,
+		Email:           refund.Email,
+		Reason:          refund.Reason,
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := uuid.NewString()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(middlewares.TimestampHeader, timestamp)
+	req.Header.Set(middlewares.NonceHeader, nonce)
+	req.Header.Set(middlewares.SignatureHeader, middlewares.SignCallback(r.secret, timestamp, nonce, body))
+	logging.SetRequestID(req)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 400 && resp.StatusCode <= 499:
+		return "", fmt.Errorf("%w: refund webhook answered %s", ErrRejected, resp.Status)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return "", fmt.Errorf("refund webhook answered %s", resp.Status)
+	}
+	var answer webhookResponse
+	err = json.NewDecoder(resp.Body).Decode(&answer)
+	if err != nil {
+		return "", fmt.Errorf("refund webhook answered an invalid body: %w", err)
+	}
+	return answer.Reference, nil
+}
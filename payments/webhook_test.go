@@ -0,0 +1,92 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"event_booking_restapi_golang/logging"
+	"event_booking_restapi_golang/middlewares"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestWebhookRefunder tests that refunds are posted as signed JSON and the provider's answer is reported
+func TestWebhookRefunder(t *testing.T) {
+	secret := []byte("refund-secret")
+	var received map[string]any
+	var requestID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		signature := middlewares.SignCallback(secret, r.Header.Get(middlewares.TimestampHeader), r.Header.Get(middlewares.NonceHeader), body)
+		if r.Header.Get(middlewares.SignatureHeader) != signature {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		requestID = r.Header.Get(logging.RequestIDHeader)
+		json.Unmarshal(body, &received)
+		switch received["registration_id"] {
+		case "refunded-before":
+			w.WriteHeader(http.StatusConflict)
+		case "provider-down":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.Write([]byte(`{"reference":"re_123"}`))
+		}
+	}))
+	defer server.Close()
+
+	refunder, err := NewWebhookRefunder(server.URL, secret)
+	if err != nil {
+		t.Fatalf("Failed to create refunder: %v", err)
+	}
+	refund := Refund{RequestID: "rr-1", RegistrationID: "r1", EventID: "e1", Name: "Alice", Email: "alice@example.com", Reason: "Sick"}
+	reference, err := refunder.Refund(logging.WithRequestID(context.Background(), "req-1"), refund)
+	if err != nil || reference != "re_123" {
+		t.Fatalf("Expected the provider's reference, got %q, %v", reference, err)
+	}
+	if received["refund_request_id"] != "rr-1" || received["email"] != "alice@example.com" || received["reason"] != "Sick" || requestID != "req-1" {
+		t.Errorf("Unexpected webhook %v with request ID %q", received, requestID)
+	}
+
+	refund.RegistrationID = "refunded-before"
+	if _, err := refunder.Refund(context.Background(), refund); !errors.Is(err, ErrRejected) {
+		t.Errorf("Expected ErrRejected for a 4xx answer, got %v", err)
+	}
+	refund.RegistrationID = "provider-down"
+	if _, err := refunder.Refund(context.Background(), refund); err == nil || errors.Is(err, ErrRejected) {
+		t.Errorf("Expected a retryable error for a 5xx answer, got %v", err)
+	}
+
+	wrong, _ := NewWebhookRefunder(server.URL, []byte("other"))
+	if _, err := wrong.Refund(context.Background(), refund); !errors.Is(err, ErrRejected) {
+		t.Errorf("Expected a wrongly signed refund to be rejected, got %v", err)
+	}
+}
+
+// TestFromEnv tests building the refunder from the environment
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		secret  string
+		enabled bool
+		wantErr bool
+	}{
+		{"disabled", "", "", false, false},
+		{"webhook", "https://payments.example.com/refunds", "s3cret", true, false},
+		{"no secret", "https://payments.example.com/refunds", "", false, true},
+		{"invalid URL", "payments.example.com", "s3cret", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("REFUND_WEBHOOK_URL", tt.url)
+			t.Setenv("REFUND_WEBHOOK_SECRET", tt.secret)
+			refunder, err := FromEnv()
+			if (err != nil) != tt.wantErr || (refunder != nil) != tt.enabled {
+				t.Errorf("Expected enabled=%v and error=%v, got %v, %v", tt.enabled, tt.wantErr, refunder, err)
+			}
+		})
+	}
+}
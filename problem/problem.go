@@ -0,0 +1,129 @@
+// Package problem writes API errors as RFC 7807 problem details.
+// Every error response has the application/problem+json content type and carries
+// the type, title, status and detail members plus a stable machine-readable code
+// that clients can switch on instead of parsing the human-readable detail.
+package problem
+
+import (
+	"encoding/json"
+	"event_booking_restapi_golang/logging"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentType is the media type of problem detail responses.
+const ContentType = "application/problem+json"
+
+// Machine-readable error codes. They are part of the API contract and must not change.
+const (
+	CodeInvalidRequest     = "invalid_request"      // The request is malformed or fails validation
+	CodeNotFound           = "not_found"            // The resource doesn't exist or isn't visible
+	CodeInternal           = "internal_error"       // The server failed to handle the request
+	CodeUnauthorized       = "unauthorized"         // Valid credentials are required
+	CodeForbidden          = "forbidden"            // The caller may not access the resource
+	CodeAdminDisabled      = "admin_disabled"       // Admin endpoints are turned off
+	CodeClientRequired     = "client_required"      // The X-Client header is missing
+	CodeClientBlocked      = "client_blocked"       // The client version is no longer supported
+	CodeCaptchaRequired    = "captcha_required"     // The X-Captcha-Token header is missing
+	CodeCaptchaFailed      = "captcha_failed"       // The CAPTCHA token was rejected
+	CodeCaptchaUnavailable = "captcha_unavailable"  // The CAPTCHA provider couldn't be reached
+	CodeEventFull          = "event_full"           // The event has no places left
+	CodeEventNotFull       = "event_not_full"       // The event still has places, so there is no waitlist
+	CodeEventCancelled     = "event_cancelled"      // The event has been cancelled and takes no more bookings
+	CodeAlreadyRegistered  = "already_registered"   // The email address already holds a place
+	CodeAlreadyWaitlisted  = "already_waitlisted"   // The email address is already on the waitlist
+	CodeWaiverRequired     = "waiver_required"      // The current event waiver wasn't accepted
+	CodeContentBlocked     = "content_blocked"      // The content policy blocks the event
+	CodeAttachmentInfected = "attachment_infected"  // The virus scanner rejected the upload
+	CodeSignatureInvalid   = "signature_invalid"    // A partner callback is unsigned or wrongly signed
+	CodeSignatureExpired   = "signature_expired"    // A partner callback timestamp is too old or in the future
+	CodeReplayedRequest    = "replayed_request"     // A partner callback nonce was already used
+	CodeNotReady           = "not_ready"            // The service can't serve requests yet, e.g. the database is down
+	CodeMailUnavailable    = "mail_unavailable"     // The request needs to send email, which isn't configured
+	CodeUserBlocked        = "user_blocked"         // The organizer or attendee on the other side blocked the caller
+	CodePackagesDisabled   = "packages_disabled"    // Event export and import are turned off because no signing secret is set
+	CodeExternalIDConflict = "external_id_conflict" // The external ID from the source belongs to a booking for another event
+	CodeReadOnly           = "read_only"            // The deployment is a read-only public mirror that only serves public reads
+	CodeTicketInvalid      = "ticket_invalid"       // The ticket code is forged or its registration was cancelled
+	CodeTicketWrongEvent   = "ticket_wrong_event"   // The ticket is for another event
+	CodeAlreadyCheckedIn   = "already_checked_in"   // The ticket has already been used to check in
+	CodeQuotaExceeded      = "quota_exceeded"       // The free tier's limit on active events is reached
+	CodeRefundRequested    = "refund_requested"     // A refund was already requested for the booking
+	CodeRefundDecided      = "refund_decided"       // The refund request was already approved or denied
+	CodeVenueUnavailable   = "venue_unavailable"    // The venue has a blackout on a day of the event
+	CodeReuseDisabled      = "reuse_disabled"       // The platform doesn't expose the event for reuse, or not without a license
+	CodePreconditionFailed = "precondition_failed"  // The If-Match header names a version of the resource that is no longer current
+	CodeVersionConflict    = "version_conflict"     // The update was based on a version of the event that is no longer current
+	CodeNotInSeries        = "not_in_series"        // The event a booking is moved to isn't another occurrence of its series
+	CodeRescheduleClosed   = "reschedule_closed"    // An occurrence starts too soon to move bookings off or onto it
+	CodeConflict           = "conflict"             // The request duplicates a record or conflicts with its current state
+	CodeImportInvalid      = "import_invalid"       // Events of an uploaded file can't be imported, so none was
+	CodeTokensDisabled     = "tokens_disabled"      // Organizer tokens are turned off because no secret is set
+)
+
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	Type       string         // URI reference identifying the problem type, derived from the code
+	Title      string         // Short summary of the problem type
+	Status     int            // HTTP status code
+	Detail     string         // Explanation specific to this occurrence
+	Code       string         // Stable machine-readable error code
+	Extensions map[string]any // Additional members, such as the offending field
+}
+
+// New creates a problem with the given status, code and detail. The title is the
+// standard text of the status and the type is the relative URI /problems/<code>.
+func New(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   "/problems/" + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// With adds an extension member to the problem and returns it for chaining.
+func (p *Problem) With(key string, value any) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = map[string]any{}
+	}
+	p.Extensions[key] = value
+	return p
+}
+
+// MarshalJSON encodes the problem with its extension members alongside the standard ones.
+// Extensions can't override the standard members.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	members := make(map[string]any, len(p.Extensions)+5)
+	for key, value := range p.Extensions {
+		members[key] = value
+	}
+	members["type"] = p.Type
+	members["title"] = p.Title
+	members["status"] = p.Status
+	members["code"] = p.Code
+	if p.Detail != "" {
+		members["detail"] = p.Detail
+	}
+	return json.Marshal(members)
+}
+
+// Write sends the problem as the response and aborts the remaining handlers. The
+// request ID, when the request has one, is added as the request_id member so
+// clients can quote it to support.
+func Write(c *gin.Context, p *Problem) {
+	if requestID := logging.RequestID(c.Request.Context()); requestID != "" {
+		if _, ok := p.Extensions["request_id"]; !ok {
+			p.With("request_id", requestID)
+		}
+	}
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(p.Status, p)
+}
+
+// Respond sends a problem with the given status, code and detail.
+func Respond(c *gin.Context, status int, code, detail string) {
+	Write(c, New(status, code, detail))
+}
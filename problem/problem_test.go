@@ -0,0 +1,89 @@
+package problem
+
+import (
+	"encoding/json"
+	"event_booking_restapi_golang/logging"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestWrite tests that problems are sent as application/problem+json with their extensions
+func TestWrite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", func(c *gin.Context) {
+		Write(c, New(http.StatusBadRequest, CodeInvalidRequest, "email is invalid").
+			With("field", "email").
+			With("status", "overridden"))
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w.Header().Get("Content-Type") != ContentType {
+		t.Errorf("Expected content type %s, got %s", ContentType, w.Header().Get("Content-Type"))
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	expected := map[string]interface{}{
+		"type":   "/problems/invalid_request",
+		"title":  "Bad Request",
+		"status": float64(http.StatusBadRequest),
+		"detail": "email is invalid",
+		"code":   CodeInvalidRequest,
+		"field":  "email",
+	}
+	for key, value := range expected {
+		if body[key] != value {
+			t.Errorf("Expected %s to be %v, got %v", key, value, body[key])
+		}
+	}
+}
+
+// TestRespondWithoutDetail tests that an empty detail is left out
+func TestRespondWithoutDetail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", func(c *gin.Context) {
+		Respond(c, http.StatusNotFound, CodeNotFound, "")
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var body map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if _, ok := body["detail"]; ok || body["code"] != CodeNotFound {
+		t.Errorf("Unexpected problem %v", body)
+	}
+}
+
+// TestWriteRequestID tests that the request ID is included in problems
+func TestWriteRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", func(c *gin.Context) {
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), "req-1"))
+		Respond(c, http.StatusInternalServerError, CodeInternal, "database is down")
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var body map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if body["request_id"] != "req-1" {
+		t.Errorf("Expected the request ID in the problem, got %v", body)
+	}
+}
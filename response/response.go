@@ -0,0 +1,126 @@
+// Package response writes successful API responses in the shape every JSON endpoint
+// shares: the requested data in the data member, listing and request metadata in meta,
+// and problems that didn't stop the request, such as an event falling on a public
+// holiday, in errors. Failed requests are answered with problem details instead, see
+// the problem package.
+package response
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Body is the envelope of a successful JSON response.
+type Body struct {
+	Data   any     `json:"data"`             // The resource, the page of a listing or the outcome of the request
+	Meta   *Meta   `json:"meta,omitempty"`   // Set on listings and dry runs
+	Errors []Error `json:"errors,omitempty"` // Problems that didn't stop the request
+}
+
+// Meta holds what a response says about its data rather than the data itself.
+type Meta struct {
+	*Pagination      // Set on listings
+	DryRun      bool `json:"dry_run,omitempty"` // Nothing was stored, the request asked for a dry run
+}
+
+// Pagination places a page of a listing within all its items.
+type Pagination struct {
+	Page   int `json:"page"`            // Number of the page, starting at 1
+	Total  int `json:"total"`           // Number of items on all the pages
+	Limit  int `json:"limit,omitempty"` // Number of items per page, left out when everything is on one page
+	Offset int `json:"offset"`          // Number of items before the page
+}
+
+// Error is a problem that didn't stop the request from succeeding. Like problem
+// details it has a stable machine-readable code and a human-readable detail, plus
+// members specific to the code.
+type Error struct {
+	Code       string
+	Detail     string
+	Extensions map[string]any
+}
+
+// NewError creates an error with the given code and detail.
+func NewError(code, detail string) Error {
+	return Error{Code: code, Detail: detail}
+}
+
+// With adds an extension member to the error and returns it for chaining.
+func (e Error) With(key string, value any) Error {
+	extensions := make(map[string]any, len(e.Extensions)+1)
+	for k, v := range e.Extensions {
+		extensions[k] = v
+	}
+	extensions[key] = value
+	e.Extensions = extensions
+	return e
+}
+
+// MarshalJSON encodes the error with its extension members alongside the code and
+// detail, which extensions can't override.
+func (e Error) MarshalJSON() ([]byte, error) {
+	members := make(map[string]any, len(e.Extensions)+2)
+	for key, value := range e.Extensions {
+		members[key] = value
+	}
+	members["code"] = e.Code
+	members["detail"] = e.Detail
+	return json.Marshal(members)
+}
+
+// New creates a response body holding data.
+func New(data any) *Body {
+	return &Body{Data: data}
+}
+
+// List creates a response body holding all the items of a listing, on a single page.
+func List(items any, total int) *Body {
+	return Page(items, total, 0, 0)
+}
+
+// Page creates a response body holding a page of a listing of total items, the one
+// that starts after offset items and holds up to limit of them. A limit of 0 means
+// the page holds every item after offset. Nil slices are sent as empty arrays, so
+// clients always get one.
+func Page(items any, total, limit, offset int) *Body {
+	if v := reflect.ValueOf(items); v.Kind() == reflect.Slice && v.IsNil() {
+		items = reflect.MakeSlice(v.Type(), 0, 0).Interface()
+	}
+	page := 1
+	if limit > 0 {
+		page = offset/limit + 1
+	}
+	return &Body{Data: items, Meta: &Meta{Pagination: &Pagination{Page: page, Total: total, Limit: limit, Offset: offset}}}
+}
+
+// WithErrors adds problems that didn't stop the request to the body and returns it
+// for chaining.
+func (b *Body) WithErrors(errs ...Error) *Body {
+	b.Errors = append(b.Errors, errs...)
+	return b
+}
+
+// WithDryRun marks the body as the outcome of a dry run, when dryRun is true, and
+// returns it for chaining.
+func (b *Body) WithDryRun(dryRun bool) *Body {
+	if !dryRun {
+		return b
+	}
+	if b.Meta == nil {
+		b.Meta = &Meta{}
+	}
+	b.Meta.DryRun = true
+	return b
+}
+
+// Write sends the body as the response with the given status.
+func Write(c *gin.Context, status int, b *Body) {
+	c.JSON(status, b)
+}
+
+// Respond sends a response body holding data with the given status.
+func Respond(c *gin.Context, status int, data any) {
+	Write(c, status, New(data))
+}
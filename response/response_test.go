@@ -0,0 +1,127 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serve answers a request with the given handler and returns the decoded body
+func serve(t *testing.T, handler gin.HandlerFunc) (*httptest.ResponseRecorder, map[string]interface{}) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", handler)
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	return w, body
+}
+
+// TestRespond tests that data is sent in the data member, without meta or errors
+func TestRespond(t *testing.T) {
+	w, body := serve(t, func(c *gin.Context) {
+		Respond(c, http.StatusCreated, gin.H{"id": "42"})
+	})
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status code %d, got %d", http.StatusCreated, w.Code)
+	}
+	data, _ := body["data"].(map[string]interface{})
+	if data["id"] != "42" {
+		t.Errorf("Expected the data to be sent, got %v", body)
+	}
+	for _, member := range []string{"meta", "errors"} {
+		if _, ok := body[member]; ok {
+			t.Errorf("Expected no %s member, got %v", member, body[member])
+		}
+	}
+}
+
+// TestPage tests that listings carry their pagination in meta and send nil slices as empty arrays
+func TestPage(t *testing.T) {
+	_, body := serve(t, func(c *gin.Context) {
+		var items []string
+		Write(c, http.StatusOK, Page(items, 45, 20, 40))
+	})
+
+	if data, ok := body["data"].([]interface{}); !ok || len(data) != 0 {
+		t.Errorf("Expected an empty array, got %v", body["data"])
+	}
+	meta, _ := body["meta"].(map[string]interface{})
+	expected := map[string]interface{}{
+		"page":   float64(3),
+		"total":  float64(45),
+		"limit":  float64(20),
+		"offset": float64(40),
+	}
+	for key, value := range expected {
+		if meta[key] != value {
+			t.Errorf("Expected %s to be %v, got %v", key, value, meta[key])
+		}
+	}
+	if _, ok := meta["dry_run"]; ok {
+		t.Error("Expected dry_run to be left out")
+	}
+
+	_, body = serve(t, func(c *gin.Context) {
+		Write(c, http.StatusOK, List([]string{"a", "b"}, 2))
+	})
+	meta, _ = body["meta"].(map[string]interface{})
+	if meta["page"] != float64(1) || meta["total"] != float64(2) {
+		t.Errorf("Expected a single page of 2 items, got %v", meta)
+	}
+	if _, ok := meta["limit"]; ok {
+		t.Error("Expected the limit of an unpaged listing to be left out")
+	}
+}
+
+// TestWithErrorsAndDryRun tests that errors keep their code and detail and dry runs are marked in meta
+func TestWithErrorsAndDryRun(t *testing.T) {
+	_, body := serve(t, func(c *gin.Context) {
+		Write(c, http.StatusOK, New("event").
+			WithErrors(NewError("holiday", "the event falls on a holiday").
+				With("date", "2030-07-04").
+				With("code", "overridden")).
+			WithDryRun(true))
+	})
+
+	errs, _ := body["errors"].([]interface{})
+	if len(errs) != 1 {
+		t.Fatalf("Expected 1 error, got %v", body["errors"])
+	}
+	expected := map[string]interface{}{
+		"code":   "holiday",
+		"detail": "the event falls on a holiday",
+		"date":   "2030-07-04",
+	}
+	first, _ := errs[0].(map[string]interface{})
+	for key, value := range expected {
+		if first[key] != value {
+			t.Errorf("Expected %s to be %v, got %v", key, value, first[key])
+		}
+	}
+	meta, _ := body["meta"].(map[string]interface{})
+	if meta["dry_run"] != true {
+		t.Errorf("Expected dry_run in meta, got %v", body["meta"])
+	}
+	if _, ok := meta["page"]; ok {
+		t.Error("Expected no pagination outside listings")
+	}
+
+	_, body = serve(t, func(c *gin.Context) {
+		Write(c, http.StatusOK, New("event").WithDryRun(false))
+	})
+	if _, ok := body["meta"]; ok {
+		t.Errorf("Expected no meta without a dry run, got %v", body["meta"])
+	}
+}
@@ -0,0 +1,92 @@
+package routes
+
+import (
+	"event_booking_restapi_golang/analytics"
+	"event_booking_restapi_golang/logging"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Analytics receives the usage events emitted by the handlers. It is set up by main
+// from ANALYTICS_SINK; the nil default disables analytics.
+var Analytics *analytics.Pipeline
+
+// trackUsage emits a usage event for the client's session, unless analytics is
+// disabled, the request carries a Do Not Track or Global Privacy Control signal, or
+// the session has opted out. When the opt-out can't be checked the event is dropped.
+func trackUsage(c *gin.Context, name string, properties map[string]any) {
+	if !Analytics.Enabled() || c.GetHeader("DNT") == "1" || c.GetHeader("Sec-GPC") == "1" {
+		return
+	}
+	session := sessionID(c, false)
+	if session != "" {
+		optedOut, err := models.AnalyticsOptedOut(c.Request.Context(), session)
+		if err != nil {
+			logging.FromContext(c.Request.Context()).Error("Couldn't check analytics opt-out", "error", err)
+			return
+		}
+		if optedOut {
+			return
+		}
+	}
+	Analytics.Track(name, session, properties)
+}
+
+// optOutOfAnalytics handles POST requests to /analytics/opt-out endpoint.
+// It stops usage events from being emitted for the client's session, starting a
+// session when the client has none so the opt-out sticks to its cookie.
+// Returns HTTP 500 if the opt-out can't be stored, otherwise HTTP 200 with the session ID.
+func optOutOfAnalytics(c *gin.Context) {
+	session := sessionID(c, true)
+	err := models.OptOutOfAnalytics(c.Request.Context(), session)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	response.Respond(c, http.StatusOK, gin.H{
+		"session_id": session,
+		"opted_out":  true,
+	})
+}
+
+// optInToAnalytics handles DELETE requests to /analytics/opt-out endpoint.
+// It removes the opt-out of the client's session, if any.
+// Returns HTTP 500 if the opt-out can't be removed, otherwise HTTP 200.
+func optInToAnalytics(c *gin.Context) {
+	session := sessionID(c, false)
+	if session != "" {
+		err := models.OptInToAnalytics(c.Request.Context(), session)
+		if err != nil {
+			problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+			return
+		}
+	}
+	response.Respond(c, http.StatusOK, gin.H{
+		"session_id": session,
+		"opted_out":  false,
+	})
+}
+
+// getAnalyticsOptOut handles GET requests to /analytics/opt-out endpoint.
+// It reports whether the client's session has opted out of analytics.
+// Returns HTTP 500 if the lookup fails, otherwise HTTP 200.
+func getAnalyticsOptOut(c *gin.Context) {
+	session := sessionID(c, false)
+	optedOut := false
+	if session != "" {
+		var err error
+		optedOut, err = models.AnalyticsOptedOut(c.Request.Context(), session)
+		if err != nil {
+			problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+			return
+		}
+	}
+	response.Respond(c, http.StatusOK, gin.H{
+		"session_id": session,
+		"opted_out":  optedOut,
+	})
+}
@@ -0,0 +1,140 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/analytics"
+	"event_booking_restapi_golang/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// recordingSink keeps the usage events written to it
+type recordingSink struct {
+	events []analytics.Event
+}
+
+func (s *recordingSink) Name() string {
+	return "recording"
+}
+
+func (s *recordingSink) Write(ctx context.Context, events []analytics.Event) error {
+	s.events = append(s.events, events...)
+	return nil
+}
+
+// enableAnalytics routes usage events to a recording sink for the test
+func enableAnalytics(t *testing.T) (*analytics.Pipeline, *recordingSink) {
+	sink := &recordingSink{}
+	original := Analytics
+	Analytics = analytics.NewPipeline(sink, "salt")
+	t.Cleanup(func() { Analytics = original })
+	return Analytics, sink
+}
+
+// TestUsageAnalytics tests that searches, views and bookings are emitted without personal data
+func TestUsageAnalytics(t *testing.T) {
+	setupTestDatabase(t)
+	pipeline, sink := enableAnalytics(t)
+
+	event := saveEvent(t, models.Event{Title: "Concert", DateTime: time.Now().Add(time.Hour), Capacity: 5})
+
+	router := setupTestRouter()
+	router.GET("/events", testHandler.getEvents)
+	router.GET("/events/:id", testHandler.getEvent)
+	router.POST("/events/:id/register", testHandler.registerForEvent)
+
+	send := func(method, path, body string) {
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set(SessionHeader, "session-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code/100 != 2 {
+			t.Fatalf("%s %s failed with %d: %s", method, path, w.Code, w.Body.String())
+		}
+	}
+	send("GET", "/events?location=jane@example.com", "")
+	send("GET", "/events/"+event.ID, "")
+	send("POST", "/events/"+event.ID+"/register?utm_source=newsletter", `{"Name":"Jane","Email":"jane@example.com"}`)
+	pipeline.Flush(context.Background())
+
+	if len(sink.events) != 3 {
+		t.Fatalf("Expected 3 usage events, got %+v", sink.events)
+	}
+	expected := []string{analytics.SearchPerformed, analytics.EventViewed, analytics.BookingCompleted}
+	for i, e := range sink.events {
+		if e.Name != expected[i] {
+			t.Errorf("Expected event %d to be %s, got %s", i, expected[i], e.Name)
+		}
+		if e.AnonymousID != pipeline.AnonymousID("session-1") {
+			t.Errorf("Expected the hashed session as anonymous ID, got %s", e.AnonymousID)
+		}
+		for key, value := range e.Properties {
+			if s, ok := value.(string); ok && bytes.Contains([]byte(s), []byte("jane")) {
+				t.Errorf("Expected personal data scrubbed, got %s=%q", key, s)
+			}
+		}
+	}
+	if sink.events[2].Properties["event_id"] != event.ID || sink.events[2].Properties["utm_source"] != "newsletter" {
+		t.Errorf("Unexpected booking properties %v", sink.events[2].Properties)
+	}
+}
+
+// TestAnalyticsOptOut tests that opted-out sessions and privacy signals suppress usage events
+func TestAnalyticsOptOut(t *testing.T) {
+	setupTestDatabase(t)
+	pipeline, sink := enableAnalytics(t)
+
+	router := setupTestRouter()
+	router.GET("/events", testHandler.getEvents)
+	router.GET("/analytics/opt-out", getAnalyticsOptOut)
+	router.POST("/analytics/opt-out", optOutOfAnalytics)
+	router.DELETE("/analytics/opt-out", optInToAnalytics)
+
+	send := func(method, path string, headers map[string]string) map[string]any {
+		req, _ := http.NewRequest(method, path, nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s %s failed with %d: %s", method, path, w.Code, w.Body.String())
+		}
+		var response struct{ Data map[string]any }
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response.Data
+	}
+	session := map[string]string{SessionHeader: "session-1"}
+	countEvents := func() int {
+		pipeline.Flush(context.Background())
+		return len(sink.events)
+	}
+
+	response := send("POST", "/analytics/opt-out", session)
+	if response["opted_out"] != true || response["session_id"] != "session-1" {
+		t.Fatalf("Unexpected opt-out response %v", response)
+	}
+	if response = send("GET", "/analytics/opt-out", session); response["opted_out"] != true {
+		t.Errorf("Expected the session to be reported as opted out, got %v", response)
+	}
+	send("GET", "/events", session)
+	if n := countEvents(); n != 0 {
+		t.Errorf("Expected no events for an opted-out session, got %d", n)
+	}
+
+	send("GET", "/events", map[string]string{SessionHeader: "session-2", "DNT": "1"})
+	send("GET", "/events", map[string]string{SessionHeader: "session-2", "Sec-GPC": "1"})
+	if n := countEvents(); n != 0 {
+		t.Errorf("Expected no events with privacy signals, got %d", n)
+	}
+
+	send("DELETE", "/analytics/opt-out", session)
+	send("GET", "/events", session)
+	if n := countEvents(); n != 1 {
+		t.Errorf("Expected tracking to resume after opting in, got %d events", n)
+	}
+}
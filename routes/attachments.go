@@ -0,0 +1,184 @@
+package routes
+
+import (
+	"errors"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"event_booking_restapi_golang/validation"
+	"io"
+	"net/http"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegistrationHeader carries the registration ID that unlocks attendee-only attachments.
+const RegistrationHeader = "X-Registration-ID"
+
+// attachmentScanner checks uploads for malware; nil when ATTACHMENT_SCAN_URL is unset.
+// It is set up when the routes are registered.
+var attachmentScanner validation.AttachmentScanner
+
+// attachmentKinds lists the accepted values of the kind form field.
+var attachmentKinds = map[string]bool{
+	models.AttachmentAgenda: true,
+	models.AttachmentWaiver: true,
+	models.AttachmentOther:  true,
+}
+
+// addAttachment handles POST requests to /events/:id/attachments endpoint.
+// It stores the multipart "file" field as an attachment of the event. The optional
+// "kind" field is agenda, waiver or other (the default), and "attendees_only=true"
+// limits downloads to registered attendees. Waivers must stay public so that attendees
+// can read them before accepting them at booking; the latest waiver is the one in force. Only PDF, PNG and JPEG files up to 10 MB
+// are accepted, and files are passed to the virus scanner when one is configured.
+// Returns HTTP 404 if the event is not found, HTTP 400 if the upload is invalid,
+// HTTP 422 if the virus scanner rejects the file, HTTP 500 if storing it fails,
+// or HTTP 201 with the attachment on success.
+func (h *EventHandler) addAttachment(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	event, err := h.events.GetEventById(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, validation.MaxAttachmentSize+1<<20)
+	header, err := c.FormFile("file")
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, "a file is required in the \"file\" form field")
+		return
+	}
+	kind := c.DefaultPostForm("kind", models.AttachmentOther)
+	if !attachmentKinds[kind] {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, "kind must be one of agenda, waiver or other")
+		return
+	}
+	attendeesOnly := c.PostForm("attendees_only") == "true"
+	if kind == models.AttachmentWaiver && attendeesOnly {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, "waivers must be readable before booking and can't be attendees-only")
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+	defer file.Close()
+	content, err := io.ReadAll(io.LimitReader(file, validation.MaxAttachmentSize+1))
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+	contentType, err := validation.ValidateAttachment(content)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	fileName := filepath.Base(header.Filename)
+	if attachmentScanner != nil {
+		err = attachmentScanner.Scan(c.Request.Context(), fileName, content)
+		if errors.Is(err, validation.ErrAttachmentInfected) {
+			problem.Respond(c, http.StatusUnprocessableEntity, problem.CodeAttachmentInfected, err.Error())
+			return
+		}
+		if err != nil {
+			problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, "couldn't scan attachment: "+err.Error())
+			return
+		}
+	}
+
+	attachment, err := event.AddAttachment(c.Request.Context(), models.Attachment{
+		Kind:          kind,
+		FileName:      fileName,
+		ContentType:   contentType,
+		AttendeesOnly: attendeesOnly,
+	}, content)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	response.Respond(c, http.StatusCreated, attachment)
+}
+
+// getAttachments handles GET requests to /events/:id/attachments endpoint.
+// It lists the attachments of a public event, including attendee-only ones,
+// whose downloads are gated separately.
+// Returns HTTP 404 if the event is not found, HTTP 500 if the query fails,
+// otherwise HTTP 200 with the attachments.
+func (h *EventHandler) getAttachments(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	event, err := h.getPublicEvent(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	attachments, err := event.GetAttachments(c.Request.Context())
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	response.Write(c, http.StatusOK, response.List(attachments, len(attachments)))
+}
+
+// downloadAttachment handles GET requests to /attachments/:id endpoint.
+// It sends the attachment file. Attendee-only attachments require the ID of a
+// registration for the event in the X-Registration-ID header.
+// Returns HTTP 404 if the attachment or its event is not found, HTTP 403 if the
+// attachment is limited to attendees and no valid registration was given,
+// otherwise HTTP 200 with the file.
+func (h *EventHandler) downloadAttachment(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	attachment, err := models.GetAttachmentById(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	event, err := h.getPublicEvent(c.Request.Context(), attachment.EventID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if attachment.AttendeesOnly {
+		registered, err := event.IsRegistered(c.Request.Context(), c.GetHeader(RegistrationHeader))
+		if err != nil {
+			problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+			return
+		}
+		if !registered {
+			problem.Respond(c, http.StatusForbidden, problem.CodeForbidden, "this attachment is only available to registered attendees")
+			return
+		}
+	}
+
+	c.Header("Content-Type", attachment.ContentType)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.FileAttachment(attachment.Path(), attachment.FileName)
+}
+
+// deleteAttachment handles DELETE requests to /attachments/:id endpoint.
+// It removes the attachment and its file.
+// Returns HTTP 404 if the attachment is not found, HTTP 500 if deletion fails,
+// or HTTP 200 with a success message on success.
+func deleteAttachment(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	attachment, err := models.GetAttachmentById(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	err = attachment.Delete(c.Request.Context())
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	response.Respond(c, http.StatusOK, gin.H{
+		"message": "Attachment deleted successfully",
+	})
+}
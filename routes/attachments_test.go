@@ -0,0 +1,126 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/models"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newAttachmentRequest builds a multipart upload for the addAttachment handler
+func newAttachmentRequest(eventID, fileName string, content []byte, fields map[string]string) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, value := range fields {
+		writer.WriteField(name, value)
+	}
+	part, _ := writer.CreateFormFile("file", fileName)
+	part.Write(content)
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", "/events/"+eventID+"/attachments", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+// TestAttachmentsFlow tests uploading, listing, gated downloading and deleting attachments
+func TestAttachmentsFlow(t *testing.T) {
+	setupTestDatabase(t)
+	originalDir := models.AttachmentsDir
+	models.AttachmentsDir = t.TempDir()
+	defer func() { models.AttachmentsDir = originalDir }()
+
+	router := setupTestRouter()
+	router.POST("/events/:id/attachments", testHandler.addAttachment)
+	router.GET("/events/:id/attachments", testHandler.getAttachments)
+	router.GET("/attachments/:id", testHandler.downloadAttachment)
+	router.DELETE("/attachments/:id", deleteAttachment)
+
+	event := models.Event{
+		Title:       "Trail Run",
+		Description: "Test Description",
+		Location:    "Test Location",
+		DateTime:    time.Now(),
+	}
+	event, err := event.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to save test event: %v", err)
+	}
+
+	pdf := []byte("%PDF-1.7 route map")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newAttachmentRequest(event.ID, "../map.pdf", pdf, map[string]string{"kind": "agenda", "attendees_only": "true"}))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created struct {
+		Data models.Attachment
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.Data.FileName != "map.pdf" || created.Data.ContentType != "application/pdf" {
+		t.Errorf("Unexpected attachment %+v", created.Data)
+	}
+
+	for name, req := range map[string]*http.Request{
+		"text file":     newAttachmentRequest(event.ID, "notes.pdf", []byte("plain text"), nil),
+		"unknown kind":  newAttachmentRequest(event.ID, "a.pdf", pdf, map[string]string{"kind": "poster"}),
+		"hidden waiver": newAttachmentRequest(event.ID, "w.pdf", pdf, map[string]string{"kind": "waiver", "attendees_only": "true"}),
+	} {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d for %s, got %d", http.StatusBadRequest, name, w.Code)
+		}
+	}
+
+	req, _ := http.NewRequest("GET", "/events/"+event.ID+"/attachments", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var listed struct {
+		Data []models.Attachment
+	}
+	json.Unmarshal(w.Body.Bytes(), &listed)
+	if w.Code != http.StatusOK || len(listed.Data) != 1 {
+		t.Errorf("Expected one listed attachment, got %d (%d)", len(listed.Data), w.Code)
+	}
+
+	download := func(registrationID string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/attachments/"+created.Data.ID, nil)
+		if registrationID != "" {
+			req.Header.Set(RegistrationHeader, registrationID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w = download(""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d without registration, got %d", http.StatusForbidden, w.Code)
+	}
+	registration, err := event.Register(context.Background(), models.Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	w = download(registration.ID)
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), pdf) {
+		t.Errorf("Expected the file for a registered attendee, got %d", w.Code)
+	}
+	if w.Header().Get("Content-Type") != "application/pdf" {
+		t.Errorf("Expected application/pdf, got %s", w.Header().Get("Content-Type"))
+	}
+
+	req, _ = http.NewRequest("DELETE", "/attachments/"+created.Data.ID, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if w = download(registration.ID); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d after deletion, got %d", http.StatusNotFound, w.Code)
+	}
+}
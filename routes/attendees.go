@@ -0,0 +1,131 @@
+package routes
+
+import (
+	"encoding/csv"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// acceptWaiver checks that the attendee accepted the event's current waiver, if it
+// has one, and records the acceptance with the current time and the client's IP.
+// Acceptance details sent by the client other than the waiver ID are discarded.
+// Writes HTTP 400 with the current waiver and returns false if it was not accepted.
+func acceptWaiver(c *gin.Context, event models.Event, acceptance *models.WaiverAcceptance) bool {
+	acceptedID := acceptance.WaiverID
+	*acceptance = models.WaiverAcceptance{}
+
+	waiver, err := event.GetWaiver(c.Request.Context())
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return false
+	}
+	if waiver == nil {
+		return true
+	}
+	if acceptedID != waiver.ID {
+		respondWaiverRequired(c, models.WaiverRequiredError{Waiver: *waiver})
+		return false
+	}
+
+	now := time.Now()
+	*acceptance = models.WaiverAcceptance{
+		WaiverID:         waiver.ID,
+		WaiverAcceptedAt: &now,
+		WaiverIP:         c.ClientIP(),
+	}
+	return true
+}
+
+// respondWaiverRequired responds to a booking that didn't accept the event's current
+// waiver with HTTP 400 and the waiver, so the client can show it to the attendee.
+func respondWaiverRequired(c *gin.Context, required models.WaiverRequiredError) {
+	problem.Write(c, problem.New(http.StatusBadRequest, problem.CodeWaiverRequired, required.Error()).
+		With("field", "WaiverID").
+		With("waiver", required.Waiver))
+}
+
+// attendee is a registration together with its waiver acceptance status.
+type attendee struct {
+	models.Registration
+	WaiverStatus string // One of not_required, accepted, outdated or missing
+}
+
+// exportAttendees handles GET requests to /admin/events/:id/attendees endpoint.
+// It lists everyone registered for the event with their waiver acceptance (waiver
+// version, time and IP address), its status against the current waiver, and the
+// channel the booking came from.
+// With ?format=csv the list is downloaded as a CSV file instead of JSON.
+// Returns HTTP 404 if the event is not found, HTTP 400 for an unknown format,
+// HTTP 500 if the query fails, otherwise HTTP 200 with the attendees.
+func (h *EventHandler) exportAttendees(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	event, err := h.events.GetEventById(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, "format must be json or csv")
+		return
+	}
+
+	waiver, err := event.GetWaiver(c.Request.Context())
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	registrations, err := event.GetRegistrations(c.Request.Context())
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	attendees := make([]attendee, 0, len(registrations))
+	for _, r := range registrations {
+		attendees = append(attendees, attendee{Registration: r, WaiverStatus: r.WaiverStatus(waiver)})
+	}
+
+	if format == "json" {
+		response.Respond(c, http.StatusOK, gin.H{
+			"attendees": attendees,
+			"waiver":    waiver,
+		})
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="attendees-`+event.ID+`.csv"`)
+	c.Status(http.StatusOK)
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{
+		"registration_id", "name", "email", "registered_at", "waiver_status", "waiver_id", "waiver_accepted_at", "waiver_ip",
+		"utm_source", "utm_medium", "utm_campaign", "ref",
+	})
+	for _, a := range attendees {
+		acceptedAt := ""
+		if a.WaiverAcceptedAt != nil {
+			acceptedAt = a.WaiverAcceptedAt.UTC().Format(time.RFC3339)
+		}
+		w.Write([]string{
+			a.ID, csvSafe(a.Name), csvSafe(a.Email), a.CreatedAt.UTC().Format(time.RFC3339),
+			a.WaiverStatus, a.WaiverID, acceptedAt, a.WaiverIP,
+			csvSafe(a.UTMSource), csvSafe(a.UTMMedium), csvSafe(a.UTMCampaign), csvSafe(a.Ref),
+		})
+	}
+	w.Flush()
+}
+
+// csvSafe neutralizes attendee-supplied text that spreadsheet applications
+// would otherwise evaluate as a formula.
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,128 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"event_booking_restapi_golang/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestWaiverAcceptance tests that registration requires the current waiver and that the export reports it
+func TestWaiverAcceptance(t *testing.T) {
+	setupTestDatabase(t)
+	originalDir := models.AttachmentsDir
+	models.AttachmentsDir = t.TempDir()
+	defer func() { models.AttachmentsDir = originalDir }()
+
+	router := setupTestRouter()
+	router.POST("/events/:id/register", testHandler.registerForEvent)
+	router.GET("/admin/events/:id/attendees", testHandler.exportAttendees)
+
+	event := models.Event{
+		Title:       "Kayak Tour",
+		Description: "Test Description",
+		Location:    "Test Location",
+		DateTime:    time.Now(),
+	}
+	event, err := event.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to save test event: %v", err)
+	}
+
+	register := func(body map[string]interface{}) *httptest.ResponseRecorder {
+		jsonData, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", "/events/"+event.ID+"/register", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = "203.0.113.7:5555"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Booked before the waiver existed
+	if w := register(map[string]interface{}{"name": "Early", "email": "early@example.com"}); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d without a waiver, got %d", http.StatusCreated, w.Code)
+	}
+
+	waiver, err := event.AddAttachment(context.Background(), models.Attachment{Kind: models.AttachmentWaiver, FileName: "waiver.pdf", ContentType: "application/pdf"}, []byte("%PDF"))
+	if err != nil {
+		t.Fatalf("Failed to add waiver: %v", err)
+	}
+
+	w := register(map[string]interface{}{"name": "Alice", "email": "alice@example.com"})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d without accepting the waiver, got %d", http.StatusBadRequest, w.Code)
+	}
+	w = register(map[string]interface{}{"name": "Alice", "email": "alice@example.com", "waiverId": "stale"})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for another waiver, got %d", http.StatusBadRequest, w.Code)
+	}
+	w = register(map[string]interface{}{"name": "Alice", "email": "alice@example.com", "waiverId": waiver.ID, "waiverIp": "spoofed"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d after accepting the waiver, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	req, _ := http.NewRequest("GET", "/admin/events/"+event.ID+"/attendees", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var exported struct {
+		Data struct {
+			Attendees []struct {
+				Email            string
+				WaiverID         string
+				WaiverIP         string
+				WaiverAcceptedAt *time.Time
+				WaiverStatus     string
+			}
+		}
+	}
+	json.Unmarshal(w.Body.Bytes(), &exported)
+	if w.Code != http.StatusOK || len(exported.Data.Attendees) != 2 {
+		t.Fatalf("Expected 2 attendees, got %d (%d)", len(exported.Data.Attendees), w.Code)
+	}
+	if exported.Data.Attendees[0].WaiverStatus != models.WaiverMissing {
+		t.Errorf("Expected missing waiver for the early booking, got %s", exported.Data.Attendees[0].WaiverStatus)
+	}
+	alice := exported.Data.Attendees[1]
+	if alice.WaiverStatus != models.WaiverAccepted || alice.WaiverID != waiver.ID || alice.WaiverIP != "203.0.113.7" || alice.WaiverAcceptedAt == nil {
+		t.Errorf("Expected recorded acceptance for Alice, got %+v", alice)
+	}
+
+	req, _ = http.NewRequest("GET", "/admin/events/"+event.ID+"/attendees?format=csv", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+	if len(records) != 3 || records[0][4] != "waiver_status" || records[2][4] != models.WaiverAccepted || records[2][7] != "203.0.113.7" {
+		t.Errorf("Unexpected CSV export %v", records)
+	}
+
+	req, _ = http.NewRequest("GET", "/admin/events/"+event.ID+"/attendees?format=xml", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an unknown format, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestCSVSafe tests that formula-like cells are neutralized
+func TestCSVSafe(t *testing.T) {
+	tests := map[string]string{
+		"Alice":             "Alice",
+		"=HYPERLINK(\"x\")": "'=HYPERLINK(\"x\")",
+		"+1":                "'+1",
+		"":                  "",
+	}
+	for input, expected := range tests {
+		if got := csvSafe(input); got != expected {
+			t.Errorf("Expected %q for %q, got %q", expected, input, got)
+		}
+	}
+}
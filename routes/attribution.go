@@ -0,0 +1,82 @@
+package routes
+
+import (
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxAttributionLength caps each stored attribution value, since they come from arbitrary links.
+const maxAttributionLength = 100
+
+// captureAttribution fills the booking's attribution from the utm_source, utm_medium,
+// utm_campaign, utm_term, utm_content and ref query parameters. Query parameters take
+// precedence over values sent in the request body, and every value is trimmed and
+// truncated to maxAttributionLength characters.
+func captureAttribution(c *gin.Context, a *models.Attribution) {
+	fields := []struct {
+		param string
+		value *string
+	}{
+		{"utm_source", &a.UTMSource},
+		{"utm_medium", &a.UTMMedium},
+		{"utm_campaign", &a.UTMCampaign},
+		{"utm_term", &a.UTMTerm},
+		{"utm_content", &a.UTMContent},
+		{"ref", &a.Ref},
+	}
+	for _, f := range fields {
+		if v, ok := c.GetQuery(f.param); ok {
+			*f.value = v
+		}
+		*f.value = strings.TrimSpace(*f.value)
+		if utf8.RuneCountInString(*f.value) > maxAttributionLength {
+			*f.value = string([]rune(*f.value)[:maxAttributionLength])
+		}
+	}
+}
+
+// getEventAnalytics handles GET requests to /admin/events/:id/analytics endpoint.
+// It reports the event's registration count with a breakdown by source, medium and
+// campaign, so organizers can see which channels sell tickets, along with its
+// registrations and views per day from the summary tables.
+// Returns HTTP 404 if the event is not found, HTTP 500 if the query fails,
+// otherwise HTTP 200 with the analytics.
+func (h *EventHandler) getEventAnalytics(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	event, err := h.events.GetEventById(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	sources, err := event.GetSourceBreakdown(c.Request.Context())
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	total := 0
+	for _, s := range sources {
+		total += s.Registrations
+	}
+	daily, err := event.GetDailySummary(c.Request.Context())
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	views := 0
+	for _, d := range daily {
+		views += d.Views
+	}
+	response.Respond(c, http.StatusOK, gin.H{
+		"registrations": total,
+		"sources":       sources,
+		"views":         views,
+		"daily":         daily,
+	})
+}
@@ -0,0 +1,90 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/summaries"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRegistrationAttribution tests that utm_* and ref parameters are stored and reported in the analytics
+func TestRegistrationAttribution(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.POST("/events/:id/register", testHandler.registerForEvent)
+	router.GET("/admin/events/:id/analytics", testHandler.getEventAnalytics)
+
+	event := models.Event{
+		Title:       "Attributed Event",
+		Description: "Test Description",
+		Location:    "Test Location",
+		DateTime:    time.Now(),
+	}
+	event, err := event.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to save test event: %v", err)
+	}
+
+	register := func(query string, body map[string]interface{}) {
+		jsonData, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", "/events/"+event.ID+"/register"+query, bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+	}
+	register("?utm_source=newsletter&utm_medium=email", map[string]interface{}{"name": "Alice", "email": "alice@example.com"})
+	register("?utm_source=newsletter&utm_medium=email", map[string]interface{}{"name": "Bob", "email": "bob@example.com", "utmSource": "ignored"})
+	register("", map[string]interface{}{"name": "Carol", "email": "carol@example.com"})
+	register("?ref="+strings.Repeat("x", 150), map[string]interface{}{"name": "Dave", "email": "dave@example.com"})
+
+	models.RecordEventView(event.ID)
+	models.RecordEventView(event.ID)
+	_, err = summaries.Refresh(context.Background(), true)
+	if err != nil {
+		t.Fatalf("Failed to refresh the summaries: %v", err)
+	}
+
+	req, _ := http.NewRequest("GET", "/admin/events/"+event.ID+"/analytics", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var response struct {
+		Data struct {
+			Registrations int
+			Sources       []models.SourceCount
+			Views         int
+			Daily         []models.EventDailySummary
+		}
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusOK || response.Data.Registrations != 4 || len(response.Data.Sources) != 3 {
+		t.Fatalf("Unexpected analytics %d: %s", w.Code, w.Body.String())
+	}
+	if top := response.Data.Sources[0]; top.Source != "newsletter" || top.Medium != "email" || top.Registrations != 2 {
+		t.Errorf("Expected 2 newsletter registrations first, got %+v", top)
+	}
+	today := time.Now().UTC().Format(time.DateOnly)
+	if response.Data.Views != 2 || len(response.Data.Daily) != 1 || response.Data.Daily[0] != (models.EventDailySummary{Day: today, Registrations: 4, Views: 2}) {
+		t.Errorf("Expected 4 registrations and 2 views today, got %d views %+v", response.Data.Views, response.Data.Daily)
+	}
+	for _, s := range response.Data.Sources[1:] {
+		if s.Source != models.DirectSource && s.Source != strings.Repeat("x", maxAttributionLength) {
+			t.Errorf("Unexpected source %+v", s)
+		}
+	}
+
+	req, _ = http.NewRequest("GET", "/admin/events/missing/analytics", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for a missing event, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,63 @@
+package routes
+
+import (
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getAuditLog handles GET requests to /admin/audit endpoint.
+// It pages through the ticket sales audit log in log order. The optional after
+// query parameter skips entries up to that seq, so clients resume from the
+// next_after value of the previous page; limit caps the page at 1000 entries.
+// Returns HTTP 400 if a parameter is invalid, HTTP 500 if the query fails,
+// otherwise HTTP 200 with the entries.
+func getAuditLog(c *gin.Context) {
+	after, err := strconv.ParseInt(c.DefaultQuery("after", "0"), 10, 64)
+	if err != nil || after < 0 {
+		problem.Write(c, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "after must be zero or a positive number").With("field", "after"))
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "1000"))
+	if err != nil || limit < 1 {
+		problem.Write(c, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "limit must be a positive number").With("field", "limit"))
+		return
+	}
+
+	entries, err := models.GetAuditEntries(c.Request.Context(), after, limit)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	if len(entries) > 0 {
+		after = entries[len(entries)-1].Seq
+	}
+	response.Respond(c, http.StatusOK, gin.H{
+		"entries":    entries,
+		"next_after": after,
+	})
+}
+
+// verifyAuditLog handles GET requests to /admin/audit/verify endpoint.
+// It recomputes the audit log's hash chain and reports whether it is intact and,
+// if not, the first entry that doesn't verify. The last hash can be compared with
+// an exported copy to detect entries rewritten together with all later hashes.
+// Returns HTTP 500 if the log can't be read, otherwise HTTP 200 with the result.
+func verifyAuditLog(c *gin.Context) {
+	result, err := models.VerifyAuditLog(c.Request.Context())
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	response.Respond(c, http.StatusOK, gin.H{
+		"valid":     result.BrokenAt == 0,
+		"entries":   result.Entries,
+		"last_hash": result.LastHash,
+		"broken_at": result.BrokenAt,
+		"reason":    result.Reason,
+	})
+}
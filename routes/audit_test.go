@@ -0,0 +1,92 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestAuditLogEndpoints tests paging through the audit log of registrations and verifying its chain
+func TestAuditLogEndpoints(t *testing.T) {
+	setupTestDatabase(t)
+	models.AuditEnabled = true
+	t.Cleanup(func() { models.AuditEnabled = false })
+
+	router := setupTestRouter()
+	router.POST("/events/:id/register", testHandler.registerForEvent)
+	router.GET("/admin/audit", getAuditLog)
+	router.GET("/admin/audit/verify", verifyAuditLog)
+
+	event := models.Event{Title: "Audited", Description: "Test Description", Location: "Test Location", DateTime: time.Now()}
+	if _, err := event.Save(context.Background()); err != nil {
+		t.Fatalf("Failed to save test event: %v", err)
+	}
+	events, _ := models.GetAllEvents(context.Background())
+	for _, email := range []string{"alice@example.com", "bob@example.com", "carol@example.com"} {
+		body, _ := json.Marshal(map[string]string{"name": "Attendee", "email": email})
+		req, _ := http.NewRequest("POST", "/events/"+events[0].ID+"/register", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Failed to register %s: %d %s", email, w.Code, w.Body.String())
+		}
+	}
+
+	page := func(query string) (int, []models.AuditEntry, int64) {
+		req, _ := http.NewRequest("GET", "/admin/audit"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response struct {
+			Data struct {
+				Entries   []models.AuditEntry
+				NextAfter int64 `json:"next_after"`
+			}
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response.Data.Entries, response.Data.NextAfter
+	}
+
+	code, entries, next := page("?limit=2")
+	if code != http.StatusOK || len(entries) != 2 || next != 2 || entries[0].Details["email"] != "alice@example.com" {
+		t.Fatalf("Expected the first two entries, got %d %+v next %d", code, entries, next)
+	}
+	_, entries, next = page("?after=2")
+	if len(entries) != 1 || entries[0].Seq != 3 || next != 3 {
+		t.Errorf("Expected only entry 3, got %+v next %d", entries, next)
+	}
+	_, entries, next = page("?after=3")
+	if len(entries) != 0 || next != 3 {
+		t.Errorf("Expected no entries after the end, got %+v next %d", entries, next)
+	}
+	for _, query := range []string{"?after=-1", "?limit=0", "?after=abc"} {
+		if code, _, _ = page(query); code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, query, code)
+		}
+	}
+
+	verify := func() map[string]any {
+		req, _ := http.NewRequest("GET", "/admin/audit/verify", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response struct{ Data map[string]any }
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response.Data
+	}
+	if result := verify(); result["valid"] != true || result["entries"] != float64(3) {
+		t.Errorf("Expected an intact chain of 3 entries, got %v", result)
+	}
+
+	_, err := testDB.Exec(`UPDATE audit_log SET details = '{"email":"mallory@example.com","name":"Attendee"}' WHERE seq = 2`)
+	if err != nil {
+		t.Fatalf("Failed to tamper with the audit log: %v", err)
+	}
+	if result := verify(); result["valid"] != false || result["broken_at"] != float64(2) {
+		t.Errorf("Expected the chain to break at entry 2, got %v", result)
+	}
+}
@@ -0,0 +1,139 @@
+package routes
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"event_booking_restapi_golang/middlewares"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Events belong to the organizer named by their UserID, and each organizer's data
+// (attendees, waitlists, messages, blocks, sender settings and attachments) is reached
+// through their events. The endpoints that read or change that data run behind
+// requireOrganizer or requireAttachmentOrganizer, so that organizers can't reach each
+// other's events by ID. Attendees prove themselves with a registration ID instead,
+// checked by the handlers against the event in the path.
+//
+// User IDs aren't secret, as events publish theirs as user_id, so authenticateUser
+// only takes X-User-ID together with the organizer token issued for it. Without
+// ORGANIZER_TOKEN_SECRET no token can be issued and every X-User-ID is refused.
+
+// OrganizerTokenHeader carries the organizer token issued for the user ID in the
+// X-User-ID header, see authenticateUser.
+const OrganizerTokenHeader = "X-Organizer-Token"
+
+// organizerTokenSecret is the ORGANIZER_TOKEN_SECRET organizer tokens are derived
+// from. It is set by RegisterRoutes; while it is empty organizer tokens are disabled.
+var organizerTokenSecret []byte
+
+// authenticatedUserKey is the Gin context key authenticateUser keeps the verified
+// X-User-ID under.
+const authenticatedUserKey = "user_id"
+
+// authenticatedUser returns the user ID authenticateUser verified for the request, or
+// "" when the request names none. Handlers read the organizer from here, never from
+// the X-User-ID header itself.
+func authenticatedUser(c *gin.Context) string {
+	return c.GetString(authenticatedUserKey)
+}
+
+// organizerToken returns the organizer token of the user ID: the HMAC-SHA256 of the ID
+// under organizerTokenSecret, base64url-encoded.
+func organizerToken(userID string) string {
+	mac := hmac.New(sha256.New, organizerTokenSecret)
+	mac.Write([]byte(userID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// authenticateUser is middleware refusing requests whose X-User-ID header doesn't
+// come with the organizer token issued for it in the X-Organizer-Token header, so
+// nobody can act as an organizer by copying their published user ID. The verified
+// user ID is kept for authenticatedUser.
+// Responds HTTP 401 when the token is missing or wrong, or when ORGANIZER_TOKEN_SECRET
+// isn't set and no token can be checked.
+func authenticateUser(c *gin.Context) {
+	userID := c.GetHeader(UserHeader)
+	if userID == "" {
+		c.Next()
+		return
+	}
+	if len(organizerTokenSecret) == 0 {
+		problem.Respond(c, http.StatusUnauthorized, problem.CodeUnauthorized,
+			"organizers can't be authenticated until ORGANIZER_TOKEN_SECRET is set")
+		return
+	}
+	if !hmac.Equal([]byte(c.GetHeader(OrganizerTokenHeader)), []byte(organizerToken(userID))) {
+		problem.Respond(c, http.StatusUnauthorized, problem.CodeUnauthorized,
+			"the "+UserHeader+" header requires the organizer token issued for it in the "+OrganizerTokenHeader+" header")
+		return
+	}
+	c.Set(authenticatedUserKey, userID)
+	c.Next()
+}
+
+// issueOrganizerToken handles POST requests to /admin/users/:user_id/token endpoint.
+// It returns the organizer token of the user ID, for the account system or an admin to
+// hand to the organizer. Tokens don't expire; changing ORGANIZER_TOKEN_SECRET revokes
+// all of them.
+// Returns HTTP 403 with code tokens_disabled while ORGANIZER_TOKEN_SECRET
+// isn't set, otherwise HTTP 200 with the user ID and its token.
+func issueOrganizerToken(c *gin.Context) {
+	if len(organizerTokenSecret) == 0 {
+		problem.Respond(c, http.StatusForbidden, problem.CodeTokensDisabled, "organizer tokens are disabled, set ORGANIZER_TOKEN_SECRET to enable them")
+		return
+	}
+	userID := c.Param("user_id")
+	response.Respond(c, http.StatusOK, gin.H{"user_id": userID, "token": organizerToken(userID)})
+}
+
+// isOrganizer reports whether the request comes from the event's organizer, as
+// authenticated by authenticateUser, or from an admin. Events without an organizer,
+// such as those synced from partner systems, can only be managed by admins.
+func isOrganizer(c *gin.Context, event models.Event) bool {
+	userID := authenticatedUser(c)
+	return userID != "" && userID == event.UserID || middlewares.IsAdmin(c, adminAPIKey)
+}
+
+// requireOrganizer is middleware for the endpoints that manage the event given by the
+// :id path parameter. It only lets through the event's organizer and admins.
+// Responds HTTP 404 if the event is not found and HTTP 403 to other callers.
+func (h *EventHandler) requireOrganizer(c *gin.Context) {
+	event, err := h.events.GetEventById(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !isOrganizer(c, event) {
+		problem.Respond(c, http.StatusForbidden, problem.CodeForbidden, "only the organizer of the event can do this")
+		return
+	}
+	c.Next()
+}
+
+// requireAttachmentOrganizer is requireOrganizer for the endpoints that manage the
+// attachment given by the :id path parameter, which belongs to its event's organizer.
+// Responds HTTP 404 if the attachment or its event is not found and HTTP 403 to other
+// callers.
+func (h *EventHandler) requireAttachmentOrganizer(c *gin.Context) {
+	attachment, err := models.GetAttachmentById(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	event, err := h.events.GetEventById(c.Request.Context(), attachment.EventID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !isOrganizer(c, event) {
+		problem.Respond(c, http.StatusForbidden, problem.CodeForbidden, "only the organizer of the event can do this")
+		return
+	}
+	c.Next()
+}
@@ -0,0 +1,413 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/ids"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestRequireOrganizer tests that only the event's organizer and admins get past the
+// middleware
+func TestRequireOrganizer(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/events/:id", testHandler.requireOrganizer, func(c *gin.Context) { c.Status(http.StatusOK) })
+	originalKey := adminAPIKey
+	adminAPIKey = "admin-secret"
+	t.Cleanup(func() { adminAPIKey = originalKey })
+
+	event, err := models.Event{Title: "Jazz Night", Description: "Live music", Location: "Club", DateTime: time.Now(), UserID: "organizer-1"}.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to save event: %v", err)
+	}
+	synced, err := models.Event{Title: "Synced Night", Description: "Live music", Location: "Club", DateTime: time.Now()}.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to save event: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		eventID  string
+		header   string
+		value    string
+		expected int
+	}{
+		{"organizer", event.ID, UserHeader, "organizer-1", http.StatusOK},
+		{"admin", event.ID, "Authorization", "Bearer admin-secret", http.StatusOK},
+		{"other organizer", event.ID, UserHeader, "organizer-2", http.StatusForbidden},
+		{"anonymous", event.ID, "", "", http.StatusForbidden},
+		{"wrong admin key", event.ID, "Authorization", "Bearer wrong", http.StatusForbidden},
+		{"event without organizer", synced.ID, UserHeader, "", http.StatusForbidden},
+		{"admin for event without organizer", synced.ID, "Authorization", "Bearer admin-secret", http.StatusOK},
+		{"unknown event", "missing", UserHeader, "organizer-1", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", "/events/"+tt.eventID, nil)
+		if tt.header != "" {
+			req.Header.Set(tt.header, tt.value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.expected {
+			t.Errorf("%s: expected status code %d, got %d: %s", tt.name, tt.expected, w.Code, w.Body.String())
+		}
+	}
+}
+
+// TestOrganizerTokens tests that X-User-ID is only trusted together with the token
+// issued for it, and never without ORGANIZER_TOKEN_SECRET
+func TestOrganizerTokens(t *testing.T) {
+	setupTestDatabase(t)
+	originalSecret := organizerTokenSecret
+	t.Cleanup(func() { organizerTokenSecret = originalSecret })
+	router := setupTestRouter()
+	router.POST("/admin/users/:user_id/token", issueOrganizerToken)
+	event, err := models.Event{Title: "Jazz Night", Description: "Live music", Location: "Club", DateTime: time.Now(), UserID: "organizer-1"}.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to save event: %v", err)
+	}
+	authenticated := gin.New()
+	authenticated.Use(authenticateUser)
+	authenticated.GET("/events/:id", testHandler.requireOrganizer, func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	organizerTokenSecret = nil
+	req, _ := http.NewRequest("POST", "/admin/users/organizer-1/token", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), problem.CodeTokensDisabled) {
+		t.Errorf("Expected tokens to be disabled without a secret, got %d: %s", w.Code, w.Body.String())
+	}
+	req, _ = http.NewRequest("GET", "/events/"+event.ID, nil)
+	req.Header.Set(UserHeader, "organizer-1")
+	req.Header.Set(OrganizerTokenHeader, organizerToken("organizer-1"))
+	w = httptest.NewRecorder()
+	authenticated.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected X-User-ID to be refused without a secret, got %d: %s", w.Code, w.Body.String())
+	}
+
+	organizerTokenSecret = []byte("token secret")
+	req, _ = http.NewRequest("POST", "/admin/users/organizer-1/token", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var issued struct{ Data struct{ Token string } }
+	json.Unmarshal(w.Body.Bytes(), &issued)
+	if w.Code != http.StatusOK || issued.Data.Token == "" || issued.Data.Token == organizerToken("organizer-2") {
+		t.Fatalf("Expected a token of the user ID, got %d: %s", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		name     string
+		userID   string
+		token    string
+		expected int
+	}{
+		{"issued token", "organizer-1", issued.Data.Token, http.StatusOK},
+		{"copied user ID", "organizer-1", "", http.StatusUnauthorized},
+		{"token of another user", "organizer-1", organizerToken("organizer-2"), http.StatusUnauthorized},
+		{"own token for another event", "organizer-2", organizerToken("organizer-2"), http.StatusForbidden},
+		{"anonymous", "", "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", "/events/"+event.ID, nil)
+		req.Header.Set(UserHeader, tt.userID)
+		req.Header.Set(OrganizerTokenHeader, tt.token)
+		w := httptest.NewRecorder()
+		authenticated.ServeHTTP(w, req)
+		if w.Code != tt.expected {
+			t.Errorf("%s: expected status code %d, got %d: %s", tt.name, tt.expected, w.Code, w.Body.String())
+		}
+	}
+}
+
+// Access levels of the API's routes, for TestTenantIsolation.
+const (
+	accessOrganizer  = "organizer"  // Manages an event or attachment of the organizer in X-User-ID
+	accessAttendee   = "attendee"   // Needs a registration for the event in X-Registration-ID
+	accessPublic     = "public"     // Public events only; other organizers' drafts are hidden
+	accessOrganizers = "organizers" // The public events of the organizer with the user ID in the path, never drafts
+	accessSelf       = "self"       // The bookings of the attendee in X-Registration-ID
+	accessCapability = "capability" // The registration ID in the path is the attendee's credential
+	accessAdmin      = "admin"      // Needs the ADMIN_API_KEY
+	accessPartner    = "partner"    // Needs the key of the partner source in the path, or the ADMIN_API_KEY
+	accessUnscoped   = "unscoped"   // Holds no organizer's or attendee's data
+)
+
+// routeAccess classifies every route of RegisterRoutes. New routes must be added, so
+// that their isolation is tested.
+var routeAccess = map[string]string{
+	"GET /events":                                     accessPublic,
+	"POST /event":                                     accessUnscoped,
+	"PUT /events/:id":                                 accessOrganizer,
+	"PATCH /events/:id":                               accessOrganizer,
+	"GET /events/:id":                                 accessPublic,
+	"DELETE /events/:id":                              accessOrganizer,
+	"POST /events/:id/publish":                        accessOrganizer,
+	"POST /events/:id/cancel":                         accessOrganizer,
+	"GET /events/:id/ical":                            accessPublic,
+	"GET /organizers/:id/calendar.ics":                accessOrganizers,
+	"GET /users/me/calendar.ics":                      accessSelf,
+	"GET /users/me/registrations":                     accessSelf,
+	"GET /users/me/events":                            accessSelf,
+	"GET /users/me/pending":                           accessSelf,
+	"GET /users/me/onboarding":                        accessSelf,
+	"GET /events/:id/export":                          accessPublic,
+	"POST /events/import-package":                     accessUnscoped,
+	"POST /events/import":                             accessUnscoped,
+	"GET /events/:id/import":                          accessPublic,
+	"PUT /events/by-external-id/:source/:external_id": accessPartner,
+	"PUT /events/by-external-id/:source/:external_id/registrations/:registration_id": accessPartner,
+	"POST /events/:id/register":                            accessPublic,
+	"DELETE /registrations/:id":                            accessCapability,
+	"GET /registrations/:id/ticket":                        accessCapability,
+	"POST /registrations/:id/reschedule":                   accessCapability,
+	"POST /registrations/:id/refund-request":               accessCapability,
+	"GET /registrations/:id/refund-request":                accessCapability,
+	"GET /events/:id/refund-requests":                      accessOrganizer,
+	"POST /events/:id/refund-requests/:request_id/approve": accessOrganizer,
+	"POST /events/:id/refund-requests/:request_id/deny":    accessOrganizer,
+	"POST /events/:id/checkin":                             accessOrganizer,
+	"GET /events/:id/registrations":                        accessOrganizer,
+	"GET /events/:id/forecast":                             accessOrganizer,
+	"GET /events/:id/stats":                                accessOrganizer,
+	"GET /events/:id/live":                                 accessOrganizer,
+	"POST /events/:id/waitlist":                            accessPublic,
+	"GET /events/:id/waitlist":                             accessOrganizer,
+	"POST /events/:id/messages":                            accessAttendee,
+	"GET /events/:id/messages":                             accessAttendee,
+	"GET /events/:id/inbox":                                accessOrganizer,
+	"GET /events/:id/inbox/:registration_id":               accessOrganizer,
+	"POST /events/:id/inbox/:registration_id":              accessOrganizer,
+	"POST /events/:id/messages/block":                      accessAttendee,
+	"DELETE /events/:id/messages/block":                    accessAttendee,
+	"GET /events/:id/blocks":                               accessOrganizer,
+	"POST /events/:id/blocks":                              accessOrganizer,
+	"DELETE /events/:id/blocks/:email":                     accessOrganizer,
+	"GET /events/:id/sender":                               accessOrganizer,
+	"PUT /events/:id/sender":                               accessOrganizer,
+	"POST /events/:id/sender/verify":                       accessOrganizer,
+	"POST /events/:id/attachments":                         accessOrganizer,
+	"GET /events/:id/attachments":                          accessPublic,
+	"GET /attachments/:id":                                 accessAttendee,
+	"DELETE /attachments/:id":                              accessOrganizer,
+	"GET /analytics/opt-out":                               accessUnscoped,
+	"POST /analytics/opt-out":                              accessUnscoped,
+	"DELETE /analytics/opt-out":                            accessUnscoped,
+	"GET /healthz":                                         accessUnscoped,
+	"GET /readyz":                                          accessUnscoped,
+	"GET /status":                                          accessUnscoped,
+	"GET /metrics":                                         accessUnscoped,
+	"GET /docs":                                            accessUnscoped,
+	"GET /docs/openapi.json":                               accessUnscoped,
+	"GET /admin/moderation/events":                         accessAdmin,
+	"POST /admin/moderation/events/:id/approve":            accessAdmin,
+	"POST /admin/moderation/events/:id/reject":             accessAdmin,
+	"GET /admin/content-rules":                             accessAdmin,
+	"POST /admin/content-rules":                            accessAdmin,
+	"DELETE /admin/content-rules/:id":                      accessAdmin,
+	"GET /admin/venue-blackouts":                           accessAdmin,
+	"POST /admin/venue-blackouts":                          accessAdmin,
+	"DELETE /admin/venue-blackouts/:id":                    accessAdmin,
+	"PUT /admin/users/:user_id/onboarding/:step":           accessAdmin,
+	"POST /admin/users/:user_id/token":                     accessAdmin,
+	"GET /admin/events/:id/attendees":                      accessAdmin,
+	"GET /admin/events/:id/analytics":                      accessAdmin,
+	"GET /admin/experiments/:name":                         accessAdmin,
+	"GET /admin/audit":                                     accessAdmin,
+	"GET /admin/audit/verify":                              accessAdmin,
+	"GET /admin/reports":                                   accessAdmin,
+	"GET /admin/reports/:name":                             accessAdmin,
+	"GET /admin/export/events":                             accessAdmin,
+	"GET /admin/stats":                                     accessAdmin,
+	"POST /admin/summaries/refresh":                        accessAdmin,
+	"GET /admin/messages":                                  accessAdmin,
+	"GET /admin/feeds/runs":                                accessAdmin,
+	"POST /admin/feeds/:name/run":                          accessAdmin,
+	"GET /admin/recordings":                                accessAdmin,
+	"DELETE /admin/recordings":                             accessAdmin,
+	"GET /admin/clients/stats":                             accessAdmin,
+}
+
+// tenant is an organizer with a published and a draft event, an attendee and an
+// attendee-only attachment.
+type tenant struct {
+	organizerID  string
+	event        models.Event
+	draft        models.Event
+	registration models.Registration
+	attachment   models.Attachment
+}
+
+// saveTenant stores the data of a tenant named name.
+func saveTenant(t *testing.T, name string) tenant {
+	ctx := context.Background()
+	tn := tenant{organizerID: name + "-organizer"}
+	tn.event = saveEvent(t, models.Event{Title: name + " Night", DateTime: time.Now().Add(24 * time.Hour), UserID: tn.organizerID})
+	tn.draft = saveEvent(t, models.Event{Title: name + " Draft", DateTime: time.Now().Add(24 * time.Hour), UserID: tn.organizerID, Status: models.StatusDraft})
+	var err error
+	tn.registration, err = tn.event.Register(ctx, models.Registration{Name: name, Email: name + "@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	_, err = tn.event.SendMessage(ctx, tn.registration.ID, models.FromAttendee, "Is there parking?")
+	if err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+	tn.attachment, err = tn.event.AddAttachment(ctx, models.Attachment{Kind: models.AttachmentWaiver, FileName: "waiver.pdf", ContentType: "application/pdf", AttendeesOnly: true}, []byte("%PDF"))
+	if err != nil {
+		t.Fatalf("Failed to add attachment: %v", err)
+	}
+	return tn
+}
+
+// TestTenantIsolation sends every route the IDs of another organizer's events,
+// registrations and attachments, and random IDs, as an organizer and attendee of one
+// event, and expects to be refused without anything changing
+func TestTenantIsolation(t *testing.T) {
+	setupTestDatabase(t)
+	originalDir, originalKey := models.AttachmentsDir, adminAPIKey
+	models.AttachmentsDir = t.TempDir()
+	t.Setenv("ADMIN_API_KEY", "admin-secret")
+	t.Setenv("EVENT_PACKAGE_SECRET", "package-secret")
+	t.Setenv("ORGANIZER_TOKEN_SECRET", "token-secret")
+	originalSecret := organizerTokenSecret
+	t.Cleanup(func() {
+		models.AttachmentsDir, adminAPIKey, organizerTokenSecret = originalDir, originalKey, originalSecret
+	})
+	router := setupTestRouter()
+	RegisterRoutes(router, models.NewSQLEventRepository())
+
+	own, other := saveTenant(t, "Alice"), saveTenant(t, "Bob")
+
+	tables := []string{"events", "registrations", "tickets", "attachments", "waitlist", "event_messages", "user_blocks", "event_senders", "refund_requests"}
+	countRows := func() map[string]int {
+		counts := map[string]int{}
+		for _, table := range tables {
+			var n int
+			err := testDB.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n)
+			if err != nil {
+				t.Fatalf("Failed to count %s: %v", table, err)
+			}
+			counts[table] = n
+		}
+		return counts
+	}
+	before := countRows()
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString("{}"))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(UserHeader, own.organizerID)
+		req.Header.Set(OrganizerTokenHeader, organizerToken(own.organizerID))
+		req.Header.Set(RegistrationHeader, own.registration.ID)
+		req.Header.Set("Authorization", "Bearer wrong-key")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	// fill replaces the path parameters, using id for :id and the other tenant's
+	// registration and email for the rest
+	fill := func(path, id string) string {
+		return pathParam.ReplaceAllStringFunc(path, func(param string) string {
+			switch param {
+			case ":id":
+				return id
+			case ":registration_id":
+				return other.registration.ID
+			case ":email":
+				return other.registration.Email
+			}
+			return "x"
+		})
+	}
+	refused := func(code int) bool {
+		return code == http.StatusForbidden || code == http.StatusNotFound
+	}
+
+	for _, route := range router.Routes() {
+		endpoint := route.Method + " " + route.Path
+		access, ok := routeAccess[endpoint]
+		if !ok {
+			t.Errorf("%s has no access level in routeAccess", endpoint)
+			continue
+		}
+		foreignIDs := []string{other.event.ID, other.draft.ID, other.registration.ID, ids.New()}
+		if strings.HasPrefix(route.Path, "/attachments/") {
+			foreignIDs = []string{other.attachment.ID, ids.New()}
+		}
+
+		switch access {
+		case accessOrganizer, accessAttendee:
+			for _, id := range foreignIDs {
+				if w := serve(route.Method, fill(route.Path, id)); !refused(w.Code) {
+					t.Errorf("%s with another tenant's ID %s: expected 403 or 404, got %d: %s", endpoint, id, w.Code, w.Body.String())
+				}
+			}
+			if strings.Contains(route.Path, ":registration_id") {
+				if w := serve(route.Method, fill(route.Path, own.event.ID)); w.Code != http.StatusNotFound {
+					t.Errorf("%s with another tenant's registration on the own event: expected 404, got %d: %s", endpoint, w.Code, w.Body.String())
+				}
+			}
+		case accessPublic:
+			if !strings.Contains(route.Path, ":id") {
+				if w := serve(route.Method, route.Path); strings.Contains(w.Body.String(), other.draft.Title) {
+					t.Errorf("%s: expected another organizer's draft to be left out, got %s", endpoint, w.Body.String())
+				}
+				continue
+			}
+			for _, id := range []string{other.draft.ID, ids.New()} {
+				if w := serve(route.Method, fill(route.Path, id)); w.Code != http.StatusNotFound {
+					t.Errorf("%s with another organizer's draft or an unknown ID %s: expected 404, got %d: %s", endpoint, id, w.Code, w.Body.String())
+				}
+			}
+		case accessOrganizers:
+			w := serve(route.Method, fill(route.Path, other.organizerID))
+			if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), other.event.Title) || strings.Contains(w.Body.String(), other.draft.Title) {
+				t.Errorf("%s: expected the other organizer's public events only, got %d: %s", endpoint, w.Code, w.Body.String())
+			}
+		case accessSelf:
+			w := serve(route.Method, route.Path)
+			if w.Code != http.StatusOK || strings.Contains(w.Body.String(), other.event.Title) {
+				t.Errorf("%s: expected only the own bookings, got %d: %s", endpoint, w.Code, w.Body.String())
+			}
+		case accessCapability:
+			for _, id := range []string{other.event.ID, ids.New()} {
+				if w := serve(route.Method, fill(route.Path, id)); w.Code != http.StatusNotFound {
+					t.Errorf("%s with an ID that isn't a registration's %s: expected 404, got %d: %s", endpoint, id, w.Code, w.Body.String())
+				}
+			}
+		case accessAdmin, accessPartner:
+			if w := serve(route.Method, fill(route.Path, other.event.ID)); w.Code != http.StatusUnauthorized {
+				t.Errorf("%s without the admin or partner key: expected 401, got %d: %s", endpoint, w.Code, w.Body.String())
+			}
+		}
+	}
+
+	after := countRows()
+	for _, table := range tables {
+		if before[table] != after[table] {
+			t.Errorf("Expected %s to keep %d rows, got %d", table, before[table], after[table])
+		}
+	}
+	event, err := models.GetEventById(context.Background(), other.event.ID)
+	if err != nil || event.Status != models.StatusPublished || event.Title != other.event.Title {
+		t.Errorf("Expected the other tenant's event to be unchanged, got %+v (%v)", event, err)
+	}
+
+	// The own data stays reachable
+	for _, path := range []string{"/events/" + own.event.ID + "/registrations", "/events/" + own.event.ID + "/inbox", "/events/" + own.event.ID + "/messages"} {
+		if w := serve("GET", path); w.Code != http.StatusOK {
+			t.Errorf("GET %s: expected status code %d for the own event, got %d: %s", path, http.StatusOK, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package routes
+
+import (
+	"errors"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondIfBlocked writes HTTP 403 with code user_blocked and returns true when err
+// is a block error.
+func respondIfBlocked(c *gin.Context, err error) bool {
+	if errors.Is(err, models.ErrBlockedByOrganizer) || errors.Is(err, models.ErrBlockedByAttendee) {
+		problem.Respond(c, http.StatusForbidden, problem.CodeUserBlocked, err.Error())
+		return true
+	}
+	return false
+}
+
+// organizerEvent loads the event and checks it has an organizer to hold blocks,
+// writing a problem response and returning false otherwise.
+func (h *EventHandler) organizerEvent(c *gin.Context) (models.Event, bool) {
+	id, _ := c.Params.Get("id")
+	event, err := h.events.GetEventById(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return models.Event{}, false
+	}
+	if event.UserID == "" {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, "the event has no organizer")
+		return models.Event{}, false
+	}
+	return event, true
+}
+
+// getBlocks handles GET requests to /events/:id/blocks endpoint.
+// It returns the attendees the event's organizer blocked, newest first. Blocks
+// apply to all of the organizer's events.
+// Returns HTTP 404 if the event is not found, HTTP 400 if it has no organizer,
+// HTTP 500 if the lookup fails, otherwise HTTP 200 with the blocks.
+func (h *EventHandler) getBlocks(c *gin.Context) {
+	event, ok := h.organizerEvent(c)
+	if !ok {
+		return
+	}
+	blocks, err := models.GetBlocks(c.Request.Context(), event.UserID, models.BlockedByOrganizer)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	response.Write(c, http.StatusOK, response.List(blocks, len(blocks)))
+}
+
+// blockAttendee handles POST requests to /events/:id/blocks endpoint.
+// It blocks an attendee, given by email or by the ID of one of their registrations
+// for the event, from booking the organizer's events and messaging the organizer.
+// Existing registrations are kept; waitlist entries are passed over.
+// Returns HTTP 404 if the event or registration is not found, HTTP 400 if neither
+// or an invalid email is given, HTTP 500 if storing fails, otherwise HTTP 201 with the block.
+func (h *EventHandler) blockAttendee(c *gin.Context) {
+	event, ok := h.organizerEvent(c)
+	if !ok {
+		return
+	}
+	var input struct {
+		Email          string `binding:"omitempty,email"`
+		RegistrationID string `json:"registration_id"`
+	}
+	err := c.ShouldBindJSON(&input)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+	email := input.Email
+	switch {
+	case input.RegistrationID != "":
+		registration, err := models.GetRegistrationById(c.Request.Context(), input.RegistrationID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		if registration.EventID != event.ID {
+			problem.Respond(c, http.StatusNotFound, problem.CodeNotFound, "Couldn't find a registration for the event with the ID of "+input.RegistrationID)
+			return
+		}
+		email = registration.Email
+	case email == "":
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, "email or registration_id is required")
+		return
+	}
+
+	block, err := models.AddBlock(c.Request.Context(), event.UserID, email, models.BlockedByOrganizer)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	response.Respond(c, http.StatusCreated, block)
+}
+
+// unblockAttendee handles DELETE requests to /events/:id/blocks/:email endpoint.
+// It lifts the organizer's block of the attendee.
+// Returns HTTP 404 if the event is not found, HTTP 400 if it has no organizer,
+// HTTP 500 if the update fails, otherwise HTTP 200.
+func (h *EventHandler) unblockAttendee(c *gin.Context) {
+	event, ok := h.organizerEvent(c)
+	if !ok {
+		return
+	}
+	err := models.RemoveBlock(c.Request.Context(), event.UserID, c.Param("email"), models.BlockedByOrganizer)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	response.Respond(c, http.StatusOK, gin.H{"message": "Attendee unblocked successfully"})
+}
+
+// blockOrganizer handles POST requests to /events/:id/messages/block endpoint.
+// It stops the event's organizer from messaging the attendee whose registration is
+// given in the X-Registration-ID header, at any of the organizer's events.
+// Returns HTTP 404 if the event is not found, HTTP 403 without a registration for
+// the event, HTTP 500 if storing fails, otherwise HTTP 201 with the block.
+func (h *EventHandler) blockOrganizer(c *gin.Context) {
+	event, registration, ok := h.attendeeRegistration(c)
+	if !ok {
+		return
+	}
+	block, err := models.AddBlock(c.Request.Context(), event.UserID, registration.Email, models.BlockedByAttendee)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	response.Respond(c, http.StatusCreated, block)
+}
+
+// unblockOrganizer handles DELETE requests to /events/:id/messages/block endpoint.
+// It lets the event's organizer message the attendee again.
+// Returns HTTP 404 if the event is not found, HTTP 403 without a registration for
+// the event, HTTP 500 if the update fails, otherwise HTTP 200.
+func (h *EventHandler) unblockOrganizer(c *gin.Context) {
+	event, registration, ok := h.attendeeRegistration(c)
+	if !ok {
+		return
+	}
+	err := models.RemoveBlock(c.Request.Context(), event.UserID, registration.Email, models.BlockedByAttendee)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	response.Respond(c, http.StatusOK, gin.H{"message": "Organizer unblocked successfully"})
+}
+
+// attendeeRegistration is attendeeConversation returning the attendee's registration,
+// writing HTTP 404 when it was cancelled meanwhile.
+func (h *EventHandler) attendeeRegistration(c *gin.Context) (models.Event, models.Registration, bool) {
+	event, registrationID, ok := h.attendeeConversation(c)
+	if !ok {
+		return models.Event{}, models.Registration{}, false
+	}
+	registration, err := models.GetRegistrationById(c.Request.Context(), registrationID)
+	if err != nil {
+		respondError(c, err)
+		return models.Event{}, models.Registration{}, false
+	}
+	return event, registration, true
+}
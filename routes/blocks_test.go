@@ -0,0 +1,144 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestBlocks tests an organizer blocking an attendee from booking and messaging
+func TestBlocks(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/events/:id/blocks", testHandler.getBlocks)
+	router.POST("/events/:id/blocks", testHandler.blockAttendee)
+	router.DELETE("/events/:id/blocks/:email", testHandler.unblockAttendee)
+	router.POST("/events/:id/register", testHandler.registerForEvent)
+	router.POST("/events/:id/messages", testHandler.sendMessage)
+
+	event := models.Event{Title: "Jazz Night", Description: "Live jazz", Location: "Club", DateTime: time.Now().Add(24 * time.Hour), UserID: "organizer-1"}
+	event, err := event.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to save event: %v", err)
+	}
+	registration, err := event.Register(context.Background(), models.Registration{Name: "Mallory", Email: "mallory@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	send := func(method, path, registrationID, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, "/events/"+event.ID+path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		if registrationID != "" {
+			req.Header.Set(RegistrationHeader, registrationID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("POST", "/blocks", "", `{"registration_id":"`+registration.ID+`"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	w = send("POST", "/blocks", "", `{"email":"Eve@example.com"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	w = send("GET", "/blocks", "", "")
+	var listed struct {
+		Data []models.Block
+	}
+	json.Unmarshal(w.Body.Bytes(), &listed)
+	if w.Code != http.StatusOK || len(listed.Data) != 2 {
+		t.Errorf("Expected 2 blocks, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = send("POST", "/register", "", `{"name":"Eve","email":"eve@example.com"}`)
+	var response problem.Problem
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusForbidden || response.Code != problem.CodeUserBlocked {
+		t.Errorf("Expected a blocked booking to be refused, got %d %s", w.Code, w.Body.String())
+	}
+	w = send("POST", "/messages", registration.ID, `{"body":"Let me in"}`)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected a blocked attendee's message to be refused, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = send("DELETE", "/blocks/eve@example.com", "", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	w = send("POST", "/register", "", `{"name":"Eve","email":"eve@example.com"}`)
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected the unblocked attendee to book, got %d %s", w.Code, w.Body.String())
+	}
+
+	for _, body := range []string{`{}`, `{"email":"not-an-email"}`} {
+		w = send("POST", "/blocks", "", body)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status code %d, got %d", body, http.StatusBadRequest, w.Code)
+		}
+	}
+	w = send("POST", "/blocks", "", `{"registration_id":"nope"}`)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for an unknown registration, got %d", http.StatusNotFound, w.Code)
+	}
+	// A failed lookup isn't taken for a missing registration
+	_, err = testDB.Exec("DROP TABLE registrations")
+	if err != nil {
+		t.Fatalf("Failed to drop registrations: %v", err)
+	}
+	w = send("POST", "/blocks", "", `{"registration_id":"`+registration.ID+`"}`)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status code %d when the lookup fails, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
+
+// TestBlockOrganizer tests an attendee stopping the organizer from messaging them
+func TestBlockOrganizer(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.POST("/events/:id/messages/block", testHandler.blockOrganizer)
+	router.DELETE("/events/:id/messages/block", testHandler.unblockOrganizer)
+	router.POST("/events/:id/inbox/:registration_id", testHandler.replyToMessage)
+	event := saveEvent(t, models.Event{Title: "Jazz Night"})
+	registration, err := event.Register(context.Background(), models.Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	send := func(method, path, registrationID, body string) int {
+		req, _ := http.NewRequest(method, "/events/"+event.ID+path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		if registrationID != "" {
+			req.Header.Set(RegistrationHeader, registrationID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := send("POST", "/messages/block", "", ""); code != http.StatusForbidden {
+		t.Errorf("Expected status code %d without a registration, got %d", http.StatusForbidden, code)
+	}
+	if code := send("POST", "/messages/block", registration.ID, ""); code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d", http.StatusCreated, code)
+	}
+	if code := send("POST", "/inbox/"+registration.ID, "", `{"body":"Hello"}`); code != http.StatusForbidden {
+		t.Errorf("Expected the organizer's reply to be refused, got %d", code)
+	}
+	if code := send("DELETE", "/messages/block", registration.ID, ""); code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, code)
+	}
+	if code := send("POST", "/inbox/"+registration.ID, "", `{"body":"Hello"}`); code != http.StatusCreated {
+		t.Errorf("Expected the organizer's reply to be sent, got %d", code)
+	}
+}
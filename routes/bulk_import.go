@@ -0,0 +1,405 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"event_booking_restapi_golang/ical"
+	"event_booking_restapi_golang/ids"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"event_booking_restapi_golang/validation"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Limits of a bulk import, so one upload can't hold a transaction open for long.
+const (
+	maxImportSize   = 5 << 20 // Largest file accepted, in bytes
+	maxImportEvents = 1000    // Most events accepted in one file
+)
+
+// importColumns are the CSV columns an import sets, by the event's JSON field names.
+var importColumns = []string{
+	"title", "description", "location", "date_time", "end_date_time", "capacity", "status",
+	"series_id", "license", "confirmation_url", "confirmation_message",
+}
+
+// requiredImportColumns are the columns every CSV file needs.
+var requiredImportColumns = []string{"title", "description", "location", "date_time"}
+
+// ignoredImportColumns are the columns of GET /events in CSV that an import leaves out,
+// as they are set by the API, so an exported file can be imported as is.
+var ignoredImportColumns = map[string]bool{
+	"id": true, "user_id": true, "review_status": true, "slug": true, "version": true, "created_at": true,
+}
+
+// importTimeLayouts are the layouts of the CSV start and end times, first RFC 3339 and
+// then date and time without an offset, which are in the import's time zone.
+var importTimeLayouts = []string{
+	time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04",
+}
+
+// importedEvent is an event read from an uploaded file, or why it can't be imported.
+type importedEvent struct {
+	line  int             // Line of the event in the file, starting at 1
+	event models.Event    // The event, when it could be read
+	err   *response.Error // Why it can't be imported, nil when it can
+}
+
+// importError returns the error of an event at a line of the file, about the given
+// field when there is one.
+func importError(line int, field, code, detail string) *response.Error {
+	err := response.NewError(code, detail).With("line", line)
+	if field != "" {
+		err = err.With("field", field)
+	}
+	return &err
+}
+
+// importEvents handles POST requests to /events/import endpoint.
+// It creates the events of the CSV or iCalendar (.ics) file in the multipart "file"
+// field for the organizer in the X-User-ID header, so organizers moving from other
+// tools can load their events at once. CSV files have a header row naming the columns,
+// the event's JSON field names; title, description, location and date_time are required.
+// Times without an offset, and all-day dates in calendars, are in the IANA time zone
+// of the timezone query parameter, UTC by default. Every event is validated and checked
+// against the content policy, free tier quota and venue blackouts as on creation, and
+// they are stored in one transaction: when any of them can't be imported, none is.
+// With dry_run=true the events are validated and nothing is stored.
+// Returns HTTP 403 without X-User-ID, HTTP 400 if the file is missing, too large, of
+// another format or unreadable, HTTP 422 with code import_invalid and the error of each
+// event, by the line it starts on, if any event can't be imported, HTTP 500 if storing
+// fails, otherwise HTTP 201 with the created events and their holiday warnings, or HTTP
+// 200 for a dry run.
+func (h *EventHandler) importEvents(c *gin.Context) {
+	userID := authenticatedUser(c)
+	if userID == "" {
+		problem.Respond(c, http.StatusForbidden, problem.CodeForbidden, "importing events requires your user ID in the "+UserHeader+" header")
+		return
+	}
+	loc, err := time.LoadLocation(c.DefaultQuery("timezone", "UTC"))
+	if err != nil {
+		problem.Write(c, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "timezone must be an IANA time zone such as Europe/Berlin").
+			With("field", "timezone"))
+		return
+	}
+	region, ok := holidayRegion(c)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize+1<<20)
+	header, err := c.FormFile("file")
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, "a CSV or .ics file is required in the \"file\" form field")
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+	defer file.Close()
+	content, err := io.ReadAll(io.LimitReader(file, maxImportSize+1))
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+	if len(content) > maxImportSize {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, fmt.Sprintf("the file is larger than %d MB", maxImportSize>>20))
+		return
+	}
+
+	var imported []importedEvent
+	mediaType, _, _ := mime.ParseMediaType(header.Header.Get("Content-Type"))
+	switch ext := strings.ToLower(filepath.Ext(header.Filename)); {
+	case ext == ".csv" || mediaType == mediaCSV:
+		imported, err = readCSVEvents(content, loc)
+	case ext == ".ics" || mediaType == "text/calendar":
+		imported, err = readCalendarEvents(content, loc)
+	default:
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, "unsupported file, upload a .csv or .ics file")
+		return
+	}
+	if err == nil && len(imported) == 0 {
+		err = errors.New("the file has no events")
+	}
+	if err == nil && len(imported) > maxImportEvents {
+		err = fmt.Errorf("the file has more than %d events, split it into smaller files", maxImportEvents)
+	}
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	var errs []response.Error
+	events := make([]models.Event, len(imported))
+	for i := range imported {
+		if imported[i].err == nil {
+			imported[i].err = prepareImportedEvent(c, &imported[i], userID)
+		}
+		if imported[i].err != nil {
+			errs = append(errs, *imported[i].err)
+		}
+		events[i] = imported[i].event
+	}
+	if len(errs) > 0 {
+		respondImportInvalid(c, errs, len(imported))
+		return
+	}
+
+	saved, saveErrs, err := models.SaveEvents(c.Request.Context(), events)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	for i, err := range saveErrs {
+		if err == nil {
+			continue
+		}
+		var quotaErr models.QuotaError
+		var unavailable models.VenueUnavailableError
+		switch {
+		case errors.As(err, &quotaErr):
+			errs = append(errs, importError(imported[i].line, "", problem.CodeQuotaExceeded, err.Error()).
+				With("usage", quotaErr.Usage).
+				With("limit", quotaErr.Limit))
+		case errors.As(err, &unavailable):
+			errs = append(errs, importError(imported[i].line, "date_time", problem.CodeVenueUnavailable, err.Error()).
+				With("location", unavailable.Location).
+				With("date", unavailable.Date))
+		default:
+			problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+			return
+		}
+	}
+	if len(errs) > 0 {
+		respondImportInvalid(c, errs, len(imported))
+		return
+	}
+
+	var warnings []response.Error
+	for i, e := range saved {
+		for _, warning := range holidayWarnings(region, e) {
+			warnings = append(warnings, warning.With("line", imported[i].line))
+		}
+	}
+	status := http.StatusCreated
+	if models.IsDryRun(c.Request.Context()) {
+		status = http.StatusOK
+	}
+	response.Write(c, status, withDryRun(c, response.List(saved, len(saved)).WithErrors(warnings...)))
+}
+
+// respondImportInvalid writes HTTP 422 with the errors of the events that can't be imported.
+func respondImportInvalid(c *gin.Context, errs []response.Error, total int) {
+	problem.Write(c, problem.New(http.StatusUnprocessableEntity, problem.CodeImportInvalid,
+		fmt.Sprintf("%d of the %d events can't be imported, so none was", len(errs), total)).
+		With("errors", errs))
+}
+
+// prepareImportedEvent validates an event read from a file and sets what the API sets
+// on creation, as createEvent does.
+// Returns why the event can't be imported, nil when it can.
+func prepareImportedEvent(c *gin.Context, imported *importedEvent, userID string) *response.Error {
+	e := &imported.event
+	e.ID, e.UserID, e.CreatedAt = ids.New(), userID, time.Now()
+	e.ReviewStatus, e.ReviewReason = models.ReviewApproved, ""
+	if e.Status == "" {
+		e.Status = models.StatusPublished
+	}
+
+	invalid := func(field, detail string) *response.Error {
+		return importError(imported.line, field, problem.CodeInvalidRequest, detail)
+	}
+	for field, value := range map[string]string{"title": e.Title, "description": e.Description, "location": e.Location} {
+		if strings.TrimSpace(value) == "" {
+			return invalid(field, field+" is required")
+		}
+	}
+	if e.Status != models.StatusDraft && e.Status != models.StatusPublished && e.Status != models.StatusCancelled {
+		return invalid("status", "status must be draft, published or cancelled")
+	}
+	if err := binding.Validator.ValidateStruct(e); err != nil {
+		return invalid("", err.Error())
+	}
+	if e.EndDateTime != nil && !e.EndDateTime.After(e.DateTime) {
+		return invalid("end_date_time", "end_date_time must be after date_time")
+	}
+	var err error
+	if e.ConfirmationURL, err = validation.ValidateRedirectURL(e.ConfirmationURL); err != nil {
+		return invalid("confirmation_url", err.Error())
+	}
+	if e.ConfirmationMessage, err = validation.SanitizeConfirmationMessage(e.ConfirmationMessage); err != nil {
+		return invalid("confirmation_message", err.Error())
+	}
+	if e.License, err = validation.ValidateLicense(e.License); err != nil {
+		return invalid("license", err.Error())
+	}
+	if moderationEnabled {
+		e.ReviewStatus = models.ReviewPending
+	}
+
+	result, err := models.ScreenEventContent(c.Request.Context(), e)
+	if err != nil {
+		return importError(imported.line, "", problem.CodeInternal, "couldn't check the content policy: "+err.Error())
+	}
+	switch result.Action {
+	case models.PolicyBlock:
+		blocked := importError(imported.line, "", problem.CodeContentBlocked, "the event violates the content policy").With("rules", result.RuleIDs)
+		return &blocked
+	case models.PolicyFlag:
+		e.ReviewStatus, e.ReviewReason = models.ReviewPending, models.FlaggedReason
+	}
+	return nil
+}
+
+// readCSVEvents reads the events of a CSV file, one per row after the header row.
+// Returns an error when the file isn't valid CSV or its header is unusable.
+func readCSVEvents(content []byte, loc *time.Location) ([]importedEvent, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(content, []byte("\ufeff"))))
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the CSV header row: %w", err)
+	}
+	known := map[string]bool{}
+	for _, name := range importColumns {
+		known[name] = true
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch {
+		case ignoredImportColumns[name]:
+			continue
+		case !known[name]:
+			return nil, fmt.Errorf("unknown CSV column %q, use %s", name, strings.Join(importColumns, ", "))
+		}
+		if _, duplicate := columns[name]; duplicate {
+			return nil, fmt.Errorf("the CSV column %q appears twice", name)
+		}
+		columns[name] = i
+	}
+	for _, name := range requiredImportColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("the CSV file needs a %q column", name)
+		}
+	}
+
+	var imported []importedEvent
+	for len(imported) <= maxImportEvents {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		imported = append(imported, csvEvent(record, columns, line, loc))
+	}
+	return imported, nil
+}
+
+// csvEvent reads the event of a CSV row starting at line.
+func csvEvent(record []string, columns map[string]int, line int, loc *time.Location) importedEvent {
+	value := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	imported := importedEvent{line: line, event: models.Event{
+		Title:               value("title"),
+		Description:         value("description"),
+		Location:            value("location"),
+		Status:              strings.ToLower(value("status")),
+		SeriesID:            value("series_id"),
+		License:             value("license"),
+		ConfirmationURL:     value("confirmation_url"),
+		ConfirmationMessage: value("confirmation_message"),
+	}}
+
+	var err error
+	imported.event.DateTime, err = parseImportTime(value("date_time"), loc)
+	if err != nil {
+		imported.err = importError(line, "date_time", problem.CodeInvalidRequest, "date_time "+err.Error())
+		return imported
+	}
+	if raw := value("end_date_time"); raw != "" {
+		end, err := parseImportTime(raw, loc)
+		if err != nil {
+			imported.err = importError(line, "end_date_time", problem.CodeInvalidRequest, "end_date_time "+err.Error())
+			return imported
+		}
+		imported.event.EndDateTime = &end
+	}
+	if raw := value("capacity"); raw != "" {
+		imported.event.Capacity, err = strconv.Atoi(raw)
+		if err != nil || imported.event.Capacity < 0 {
+			imported.err = importError(line, "capacity", problem.CodeInvalidRequest, "capacity must be a number of places, 0 for unlimited")
+			return imported
+		}
+	}
+	return imported
+}
+
+// parseImportTime parses a CSV start or end time with the first of importTimeLayouts
+// that matches. Times without an offset are in loc.
+func parseImportTime(value string, loc *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, errors.New("is required")
+	}
+	for _, layout := range importTimeLayouts {
+		t, err := time.ParseInLocation(layout, value, loc)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("must be an RFC 3339 timestamp or a date and time such as 2030-01-31 19:30")
+}
+
+// readCalendarEvents reads the VEVENTs of an iCalendar file. Cancelled events are
+// imported as cancelled, and the UID is left out like other external IDs on creation.
+// Returns an error when the file isn't an iCalendar file.
+func readCalendarEvents(content []byte, loc *time.Location) ([]importedEvent, error) {
+	entries, err := ical.Parse(bytes.NewReader(content), loc)
+	if err != nil {
+		return nil, err
+	}
+	imported := make([]importedEvent, len(entries))
+	for i, entry := range entries {
+		imported[i].line = entry.Line
+		if entry.Err != nil {
+			imported[i].err = importError(entry.Line, "", problem.CodeInvalidRequest, entry.Err.Error())
+			continue
+		}
+		e := models.Event{
+			Title:       entry.Summary,
+			Description: entry.Description,
+			Location:    entry.Location,
+			DateTime:    entry.Start,
+		}
+		if !entry.End.IsZero() {
+			e.EndDateTime = &entry.End
+		}
+		if entry.Cancelled {
+			e.Status = models.StatusCancelled
+		}
+		imported[i].event = e
+	}
+	return imported, nil
+}
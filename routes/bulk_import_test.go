@@ -0,0 +1,110 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestImportEvents tests creating the events of uploaded CSV and iCalendar files, all or none
+func TestImportEvents(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.POST("/events/import", dryRun, testHandler.importEvents)
+
+	upload := func(query, fileName, content string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		part, _ := writer.CreateFormFile("file", fileName)
+		part.Write([]byte(content))
+		writer.Close()
+		req, _ := http.NewRequest("POST", "/events/import"+query, &body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.Header.Set(UserHeader, "organizer-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	count := func() int {
+		var n int
+		testDB.QueryRow("SELECT COUNT(*) FROM events").Scan(&n)
+		return n
+	}
+
+	csv := "title,description,location,date_time,end_date_time,capacity\n" +
+		"Jazz Night,Live music,Club,2030-01-31 19:30,2030-01-31 22:00,50\n" +
+		"\"Poetry, Slam\",\"Open mic\nBring poems\",Library,2030-02-01T18:00:00Z,,\n"
+	w := upload("?timezone=Europe/Berlin", "events.csv", csv)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created struct{ Data []models.Event }
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if len(created.Data) != 2 || created.Data[0].UserID != "organizer-1" || created.Data[0].Capacity != 50 ||
+		!created.Data[0].DateTime.Equal(time.Date(2030, 1, 31, 18, 30, 0, 0, time.UTC)) || created.Data[1].Title != "Poetry, Slam" {
+		t.Errorf("Unexpected created events %+v", created.Data)
+	}
+
+	invalid := "title,description,location,date_time,capacity\n" +
+		"Fine,Description,Hall,2030-03-01 10:00,\n" +
+		",Description,Hall,2030-03-01 10:00,\n" +
+		"Party,Description,Hall,next friday,\n" +
+		"Fair,Description,Hall,2030-03-02 10:00,lots\n"
+	w = upload("", "events.csv", invalid)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusUnprocessableEntity, w.Code, w.Body.String())
+	}
+	var rejected struct {
+		Code   string
+		Errors []map[string]any
+	}
+	json.Unmarshal(w.Body.Bytes(), &rejected)
+	if rejected.Code != problem.CodeImportInvalid || len(rejected.Errors) != 3 {
+		t.Fatalf("Expected an error for each of the 3 invalid rows, got %s", w.Body.String())
+	}
+	for i, expected := range []struct {
+		line  float64
+		field string
+	}{{3, "title"}, {4, "date_time"}, {5, "capacity"}} {
+		if rejected.Errors[i]["line"] != expected.line || rejected.Errors[i]["field"] != expected.field {
+			t.Errorf("Expected the %s of line %v to be reported, got %v", expected.field, expected.line, rejected.Errors[i])
+		}
+	}
+	if n := count(); n != 2 {
+		t.Errorf("Expected no event of a file with invalid rows to be stored, %d events", n)
+	}
+
+	calendar := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nUID:a@example.com\r\nDTSTART:20300401T180000Z\r\nSUMMARY:Lecture\r\nDESCRIPTION:Talk\r\nLOCATION:Hall\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	w = upload("?dry_run=true", "events.ics", calendar)
+	if w.Code != http.StatusOK || count() != 2 {
+		t.Errorf("Expected a dry run to store nothing, got %d with %d events: %s", w.Code, count(), w.Body.String())
+	}
+	w = upload("", "calendar.ics", calendar)
+	if w.Code != http.StatusCreated || count() != 3 {
+		t.Errorf("Expected the calendar's event to be created, got %d with %d events: %s", w.Code, count(), w.Body.String())
+	}
+
+	oldLimit := models.FreeEventLimit
+	t.Cleanup(func() { models.FreeEventLimit = oldLimit })
+	models.FreeEventLimit = 4
+	w = upload("", "events.csv", csv)
+	json.Unmarshal(w.Body.Bytes(), &rejected)
+	if w.Code != http.StatusUnprocessableEntity || len(rejected.Errors) != 1 || rejected.Errors[0]["code"] != problem.CodeQuotaExceeded || count() != 3 {
+		t.Errorf("Expected the row over the quota to roll back the import, got %d with %d events: %s", w.Code, count(), w.Body.String())
+	}
+
+	if w := upload("", "events.txt", csv); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an unsupported file, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := upload("", "events.csv", "name,when\nParty,2030-01-01\n"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for unknown columns, got %d", http.StatusBadRequest, w.Code)
+	}
+}
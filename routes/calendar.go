@@ -0,0 +1,123 @@
+package routes
+
+import (
+	"event_booking_restapi_golang/ical"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// calendarUIDDomain makes event IDs globally unique calendar UIDs. It is fixed so
+// calendars recognise the same event whichever host served the file.
+const calendarUIDDomain = "@event-booking-api"
+
+// organizerCalendarRefresh is how often calendars subscribed to an organizer's events
+// are asked to fetch them again.
+const organizerCalendarRefresh = time.Hour
+
+// calendarEvent converts an event to a calendar entry. Its version is the entry's
+// sequence number, so calendars holding an older copy replace it.
+func calendarEvent(e models.Event) ical.Event {
+	entry := ical.Event{
+		UID:         e.ID + calendarUIDDomain,
+		Summary:     e.Title,
+		Description: e.Description,
+		Location:    e.Location,
+		Start:       e.DateTime,
+		Created:     e.CreatedAt,
+		Sequence:    e.Version,
+		Cancelled:   e.Status == models.StatusCancelled,
+	}
+	if e.EndDateTime != nil {
+		entry.End = *e.EndDateTime
+	}
+	return entry
+}
+
+// writeCalendar writes the calendar as an .ics file download named fileName.
+func writeCalendar(c *gin.Context, cal ical.Calendar, fileName string) {
+	c.Header("Content-Type", ical.ContentType)
+	c.Header("Content-Disposition", `attachment; filename="`+fileName+`"`)
+	c.Status(http.StatusOK)
+	cal.Write(c.Writer, time.Now())
+}
+
+// getEventICal handles GET requests to /events/:id/ical endpoint.
+// It returns the event as an iCalendar file with a single VEVENT, for adding it to
+// Google, Apple or Outlook calendars.
+// Returns HTTP 404 if the event is not found or not public, HTTP 403 if ContentReuse
+// doesn't let the event be exported, otherwise HTTP 200 with the text/calendar file.
+func (h *EventHandler) getEventICal(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	event, err := h.getPublicEvent(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !checkReusable(c, event) {
+		return
+	}
+	writeCalendar(c, ical.Calendar{Events: []ical.Event{calendarEvent(event)}}, "event-"+event.ID+".ics")
+}
+
+// getOrganizerCalendar handles GET requests to /organizers/:id/calendar.ics endpoint.
+// It returns the public upcoming events of the organizer with the user ID, including
+// those under way, as an iCalendar feed to subscribe to or embed in a website. Entries
+// keep their UID and carry their version as SEQUENCE, so subscribed calendars apply
+// edits to the copy they hold; cancelled events stay listed as cancelled. Drafts and
+// events awaiting moderation are left out, as are events ContentReuse doesn't let be
+// exported. Unknown organizers get an empty calendar.
+// Returns HTTP 403 if ContentReuse exports no events, HTTP 500 if the query fails,
+// otherwise HTTP 200 with the text/calendar file.
+func (h *EventHandler) getOrganizerCalendar(c *gin.Context) {
+	if ContentReuse == ReuseNone {
+		problem.Respond(c, http.StatusForbidden, problem.CodeReuseDisabled, "this platform doesn't expose events for reuse")
+		return
+	}
+	filter := models.EventFilter{UserID: c.Param("id"), Overlaps: models.Window{Start: time.Now()}}
+	events, err := h.events.QueryEvents(c.Request.Context(), filter)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	cal := ical.Calendar{Name: "Upcoming events", RefreshInterval: organizerCalendarRefresh}
+	for _, event := range events {
+		if reusable(event) {
+			cal.Events = append(cal.Events, calendarEvent(event))
+		}
+	}
+	writeCalendar(c, cal, "organizer-calendar.ics")
+}
+
+// getMyCalendar handles GET requests to /users/me/calendar.ics endpoint.
+// It returns every event the attendee holds a place on as an iCalendar file. The
+// attendee is identified by the email address of one of their registrations, given in
+// the X-Registration-ID header or, for calendar subscriptions that can't send headers,
+// the registration_id query parameter.
+// Returns HTTP 403 without a valid registration ID, HTTP 500 if the lookup fails,
+// otherwise HTTP 200 with the text/calendar file.
+func getMyCalendar(c *gin.Context) {
+	registrationID := c.GetHeader(RegistrationHeader)
+	if registrationID == "" {
+		registrationID = c.Query("registration_id")
+	}
+	registration, err := models.GetRegistrationById(c.Request.Context(), registrationID)
+	if err != nil {
+		problem.Respond(c, http.StatusForbidden, problem.CodeForbidden,
+			"the calendar requires one of your registration IDs in the "+RegistrationHeader+" header or the registration_id query parameter")
+		return
+	}
+	events, err := models.GetBookedEvents(c.Request.Context(), registration.Email)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	cal := ical.Calendar{Name: "My bookings"}
+	for _, event := range events {
+		cal.Events = append(cal.Events, calendarEvent(event))
+	}
+	writeCalendar(c, cal, "calendar.ics")
+}
@@ -0,0 +1,163 @@
+package routes
+
+import (
+	"context"
+	"event_booking_restapi_golang/ical"
+	"event_booking_restapi_golang/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestEventICal tests downloading a single event as an iCalendar file
+func TestEventICal(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/events/:id/ical", testHandler.getEventICal)
+	event := saveEvent(t, models.Event{Title: "Jazz Night"})
+
+	req, _ := http.NewRequest("GET", "/events/"+event.ID+"/ical", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if w.Header().Get("Content-Type") != ical.ContentType {
+		t.Errorf("Expected content type %q, got %q", ical.ContentType, w.Header().Get("Content-Type"))
+	}
+	body := w.Body.String()
+	if strings.Count(body, "BEGIN:VEVENT") != 1 || !strings.Contains(body, "UID:"+event.ID+calendarUIDDomain) || !strings.Contains(body, "SUMMARY:Jazz Night") {
+		t.Errorf("Unexpected calendar:\n%s", body)
+	}
+
+	req, _ = http.NewRequest("GET", "/events/nope/ical", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// TestCalendarEventEnd tests that the event's end time becomes the calendar entry's end
+func TestCalendarEventEnd(t *testing.T) {
+	start := time.Date(2030, 6, 1, 18, 0, 0, 0, time.UTC)
+	if entry := calendarEvent(models.Event{DateTime: start}); !entry.End.IsZero() {
+		t.Errorf("Expected no end for an event without one, got %s", entry.End)
+	}
+	end := start.Add(2 * time.Hour)
+	if entry := calendarEvent(models.Event{DateTime: start, EndDateTime: &end}); !entry.End.Equal(end) {
+		t.Errorf("Expected the entry to end at %s, got %s", end, entry.End)
+	}
+}
+
+// TestOrganizerCalendar tests the subscribable feed of an organizer's public upcoming
+// events, whose entries carry their version so edits and cancellations propagate
+func TestOrganizerCalendar(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/organizers/:id/calendar.ics", testHandler.getOrganizerCalendar)
+	t.Cleanup(func() { ContentReuse = ReuseAll })
+	ctx := context.Background()
+	save := func(e models.Event) models.Event {
+		t.Helper()
+		e.Description, e.Location = "Live music", "Club"
+		if e.UserID == "" {
+			e.UserID = "organizer-1"
+		}
+		saved, err := e.Save(ctx)
+		if err != nil {
+			t.Fatalf("Failed to save event: %v", err)
+		}
+		return saved
+	}
+	upcoming := save(models.Event{Title: "Jazz Night", DateTime: time.Now().Add(24 * time.Hour)})
+	cancelled := save(models.Event{Title: "Blues Night", DateTime: time.Now().Add(48 * time.Hour), License: "CC-BY-4.0"})
+	save(models.Event{Title: "Past Night", DateTime: time.Now().Add(-48 * time.Hour)})
+	save(models.Event{Title: "Draft Night", DateTime: time.Now().Add(24 * time.Hour), Status: models.StatusDraft})
+	save(models.Event{Title: "Other Night", DateTime: time.Now().Add(24 * time.Hour), UserID: "organizer-2"})
+	if _, err := cancelled.Cancel(ctx); err != nil {
+		t.Fatalf("Failed to cancel: %v", err)
+	}
+	upcoming.Location = "Rooftop"
+	if err := upcoming.Update(ctx); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	serve := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/organizers/organizer-1/calendar.ics", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve()
+	body := w.Body.String()
+	if w.Code != http.StatusOK || strings.Count(body, "BEGIN:VEVENT") != 2 {
+		t.Fatalf("Expected the 2 upcoming public events, got %d:\n%s", w.Code, body)
+	}
+	for _, expected := range []string{
+		"UID:" + upcoming.ID + calendarUIDDomain + "\r\n",
+		"SEQUENCE:2\r\n",
+		"LOCATION:Rooftop\r\n",
+		"SUMMARY:Blues Night\r\nDESCRIPTION:Live music\r\nLOCATION:Club\r\nSTATUS:CANCELLED\r\n",
+		"REFRESH-INTERVAL;VALUE=DURATION:PT60M\r\n",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected %q in\n%s", expected, body)
+		}
+	}
+
+	ContentReuse = ReuseLicensed
+	if body := serve().Body.String(); strings.Count(body, "BEGIN:VEVENT") != 1 || !strings.Contains(body, "Blues Night") {
+		t.Errorf("Expected only the licensed event, got\n%s", body)
+	}
+	ContentReuse = ReuseNone
+	if w := serve(); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d with reuse turned off, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+// TestMyCalendar tests downloading all of an attendee's bookings as an iCalendar file
+func TestMyCalendar(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/users/me/calendar.ics", getMyCalendar)
+
+	ctx := context.Background()
+	var registrationID string
+	for _, title := range []string{"Jazz Night", "Blues Night", "Rock Night"} {
+		event := saveEvent(t, models.Event{Title: title, DateTime: time.Now().Add(24 * time.Hour)})
+		if title == "Rock Night" {
+			continue
+		}
+		registration, err := event.Register(ctx, models.Registration{Name: "Alice", Email: "alice@example.com"})
+		if err != nil {
+			t.Fatalf("Failed to register: %v", err)
+		}
+		registrationID = registration.ID
+	}
+
+	for _, path := range []string{"/users/me/calendar.ics", "/users/me/calendar.ics?registration_id=" + registrationID} {
+		req, _ := http.NewRequest("GET", path, nil)
+		if !strings.Contains(path, "?") {
+			req.Header.Set(RegistrationHeader, registrationID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status code %d, got %d: %s", path, http.StatusOK, w.Code, w.Body.String())
+		}
+		body := w.Body.String()
+		if strings.Count(body, "BEGIN:VEVENT") != 2 || strings.Contains(body, "Rock Night") {
+			t.Errorf("%s: expected the 2 booked events, got:\n%s", path, body)
+		}
+	}
+
+	req, _ := http.NewRequest("GET", "/users/me/calendar.ics", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d without a registration, got %d", http.StatusForbidden, w.Code)
+	}
+}
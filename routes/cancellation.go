@@ -0,0 +1,104 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"event_booking_restapi_golang/logging"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/notifications"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cancelEvent handles POST requests to /events/:id/cancel endpoint.
+// It marks the event cancelled instead of deleting it, so that it takes no more
+// registrations or waitlist entries, and emails every registered attendee about the
+// cancellation with the optional Reason from the JSON request body. Cancelling an event
+// that is already cancelled doesn't notify the attendees again. With dry_run=true nothing
+// is changed or sent, and the response tells how many attendees would be notified.
+// Returns HTTP 404 if the event is not found, HTTP 400 if the body is invalid, HTTP 500
+// if the update fails, or HTTP 200 with the cancelled event and the number of attendees
+// notified on success.
+func (h *EventHandler) cancelEvent(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	event, err := h.events.GetEventById(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var body struct {
+		Reason string
+	}
+	// The body is optional
+	if c.Request.Body != nil && c.Request.ContentLength != 0 {
+		err = c.ShouldBindJSON(&body)
+		if err != nil && !errors.Is(err, io.EOF) {
+			problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+			return
+		}
+	}
+
+	cancelled, err := h.events.Cancel(c.Request.Context(), event)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	event.Status = models.StatusCancelled
+	notified := 0
+	if cancelled {
+		notified = emailCancellation(c.Request.Context(), event, body.Reason)
+	}
+	message := "Event cancelled successfully"
+	if models.IsDryRun(c.Request.Context()) {
+		message = "Event would be cancelled"
+	}
+	response.Write(c, http.StatusOK, withDryRun(c, response.New(gin.H{
+		"message":  message,
+		"event":    event,
+		"notified": notified,
+	})))
+}
+
+// emailCancellation emails every registered attendee that the event was cancelled,
+// under the event's sender name and verified reply-to address. The emails are queued
+// as jobs in the request's transaction, so they are only sent once the cancellation is
+// committed and never for one that is rolled back. It does nothing when email isn't
+// configured, and sends nothing under a dry run; failures are logged and never affect
+// the cancellation.
+// Returns the number of attendees an email is sent to, or would be under a dry run.
+func emailCancellation(ctx context.Context, event models.Event, reason string) int {
+	if !Notifications.Enabled() {
+		return 0
+	}
+	registrations, err := event.GetRegistrations(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("Couldn't load the attendees to notify of the cancellation", "event_id", event.ID, "error", err)
+		return 0
+	}
+	sender, err := event.GetSender(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("Couldn't load the event's sender settings", "event_id", event.ID, "error", err)
+		return 0
+	}
+	if models.IsDryRun(ctx) {
+		return len(registrations)
+	}
+	for _, registration := range registrations {
+		err := Notifications.Send(ctx, notifications.Email{
+			To:       registration.Email,
+			FromName: sender.SenderName,
+			ReplyTo:  sender.ReplyToAddress(),
+			Template: notifications.EventCancellation,
+			Data:     notifications.Cancellation{Event: eventDetails(event), Name: registration.Name, Reason: reason},
+		})
+		if err != nil {
+			logging.FromContext(ctx).Error("Couldn't queue the cancellation email", "event_id", event.ID, "registration_id", registration.ID, "error", err)
+		}
+	}
+	return len(registrations)
+}
@@ -0,0 +1,154 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/db"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestCancelEvent tests cancelling an event, emailing its attendees once and refusing
+// new bookings
+func TestCancelEvent(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.POST("/events/:id/cancel", testHandler.cancelEvent)
+	router.POST("/events/:id/register", testHandler.registerForEvent)
+	router.GET("/events/:id", testHandler.getEvent)
+	mailer := useFakeMailer(t)
+
+	event := saveEvent(t, models.Event{Title: "Jazz Night"})
+	for _, name := range []string{"Alice", "Bob"} {
+		_, err := event.Register(context.Background(), models.Registration{Name: name, Email: strings.ToLower(name) + "@example.com"})
+		if err != nil {
+			t.Fatalf("Failed to register: %v", err)
+		}
+	}
+
+	send := func(path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/events/"+event.ID+path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("/cancel", `{"reason":"The band is ill."}`)
+	var response struct {
+		Data struct {
+			Event    models.Event `json:"event"`
+			Notified int          `json:"notified"`
+		}
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusOK || response.Data.Event.Status != models.StatusCancelled || response.Data.Notified != 2 {
+		t.Fatalf("Expected the event cancelled with 2 attendees notified, got %d: %s", w.Code, w.Body.String())
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(mailer.messages()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	sent := mailer.messages()
+	if len(sent) != 2 || sent[0].To != "alice@example.com" && sent[1].To != "alice@example.com" {
+		t.Fatalf("Expected both attendees to be emailed, got %+v", sent)
+	}
+	if !strings.Contains(sent[0].Subject, "Jazz Night") || !strings.Contains(sent[0].Body, "The band is ill.") {
+		t.Errorf("Expected the email to name the event and the reason, got %+v", sent[0])
+	}
+
+	w = send("/cancel", "")
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusOK || response.Data.Notified != 0 {
+		t.Errorf("Expected a repeated cancellation to notify nobody, got %d: %s", w.Code, w.Body.String())
+	}
+	time.Sleep(20 * time.Millisecond)
+	if len(mailer.messages()) != 2 {
+		t.Errorf("Expected no more emails, got %d", len(mailer.messages()))
+	}
+
+	w = send("/register", `{"name":"Carol","email":"carol@example.com"}`)
+	var p problem.Problem
+	json.Unmarshal(w.Body.Bytes(), &p)
+	if w.Code != http.StatusConflict || p.Code != problem.CodeEventCancelled {
+		t.Errorf("Expected status code %d with code %s, got %d: %s", http.StatusConflict, problem.CodeEventCancelled, w.Code, w.Body.String())
+	}
+	req, _ := http.NewRequest("GET", "/events/"+event.ID, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected the cancelled event to stay visible, got %d", w.Code)
+	}
+
+	req, _ = http.NewRequest("POST", "/events/missing/cancel", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for an unknown event, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// TestCancelEventWithoutEmail tests that events can be cancelled when email isn't configured
+func TestCancelEventWithoutEmail(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.POST("/events/:id/cancel", testHandler.cancelEvent)
+
+	event := saveEvent(t, models.Event{Title: "Jazz Night"})
+	req, _ := http.NewRequest("POST", "/events/"+event.ID+"/cancel", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"notified":0`) {
+		t.Errorf("Expected the event cancelled without notifications, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestCancelEventRolledBack tests that the cancellation emails are queued in the request's
+// transaction, so a cancellation that is rolled back notifies nobody
+func TestCancelEventRolledBack(t *testing.T) {
+	setupTestDatabase(t)
+	// The transaction holds the only connection, as the in-memory database has no other
+	testDB.SetMaxOpenConns(1)
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		tx, err := db.DB.BeginTx(c.Request.Context(), nil)
+		if err != nil {
+			t.Fatalf("Failed to begin the request transaction: %v", err)
+		}
+		ctx, end := db.WithTx(c.Request.Context(), tx)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+		end(false)
+	})
+	router.POST("/events/:id/cancel", testHandler.cancelEvent)
+	mailer := useFakeMailer(t)
+
+	event := saveEvent(t, models.Event{Title: "Jazz Night"})
+	_, err := event.Register(context.Background(), models.Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	req, _ := http.NewRequest("POST", "/events/"+event.ID+"/cancel", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"notified":1`) {
+		t.Fatalf("Expected the cancellation to notify 1 attendee, got %d: %s", w.Code, w.Body.String())
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if sent := mailer.messages(); len(sent) != 0 {
+		t.Errorf("Expected no email for a rolled back cancellation, got %d", len(sent))
+	}
+	stored, _ := models.GetEventById(context.Background(), event.ID)
+	if stored.Status == models.StatusCancelled {
+		t.Errorf("Expected the cancellation to be rolled back")
+	}
+}
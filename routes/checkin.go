@@ -0,0 +1,61 @@
+package routes
+
+import (
+	"errors"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// checkInAttendee handles POST requests to /events/:id/checkin endpoint.
+// It checks in the holder of the ticket whose code, scanned from the ticket's QR code,
+// is sent as Code in the JSON request body, and returns the attendee's details for the
+// organizer's scanner app. A ticket can be used only once.
+// Returns HTTP 404 if the event is not found, HTTP 400 if the body is invalid, HTTP 422
+// if the code is forged, its registration was cancelled or it is for another event,
+// HTTP 409 if the event is cancelled or the ticket was used before, with the attendee
+// and the time of the first check-in, HTTP 500 if the update fails, otherwise HTTP 200
+// with the attendee.
+func (h *EventHandler) checkInAttendee(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	event, err := h.events.GetEventById(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	var body struct {
+		Code string `binding:"required"`
+	}
+	err = c.ShouldBindJSON(&body)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	registration, err := event.CheckIn(c.Request.Context(), body.Code)
+	if errors.Is(err, models.ErrAlreadyCheckedIn) {
+		problem.Write(c, problem.New(http.StatusConflict, problem.CodeAlreadyCheckedIn, err.Error()).
+			With("attendee", checkedInAttendee(registration)))
+		return
+	}
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	Live.Notify(event.ID)
+	response.Respond(c, http.StatusOK, checkedInAttendee(registration))
+}
+
+// checkedInAttendee returns the attendee details shown by scanner apps, leaving out the
+// registration's attribution and waiver records.
+func checkedInAttendee(r models.Registration) gin.H {
+	return gin.H{
+		"registration_id": r.ID,
+		"name":            r.Name,
+		"email":           r.Email,
+		"checked_in_at":   r.CheckedInAt,
+	}
+}
@@ -0,0 +1,67 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestCheckInAttendee tests checking in a ticket once and the problems reported for
+// reused, forged and invalid codes
+func TestCheckInAttendee(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.POST("/events/:id/checkin", testHandler.checkInAttendee)
+
+	event := saveEvent(t, models.Event{Title: "Jazz Night"})
+	registration, err := event.Register(context.Background(), models.Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	checkIn := func(eventID, body string) (*httptest.ResponseRecorder, map[string]any) {
+		req, _ := http.NewRequest("POST", "/events/"+eventID+"/checkin", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response map[string]any
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+	code := `{"code":"` + registration.Ticket.Code + `"}`
+
+	w, response := checkIn(event.ID, code)
+	attendee, _ := response["data"].(map[string]any)
+	if w.Code != http.StatusOK || attendee["name"] != "Alice" || attendee["registration_id"] != registration.ID || attendee["checked_in_at"] == nil {
+		t.Fatalf("Expected Alice to be checked in, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w, response = checkIn(event.ID, code)
+	attendee, _ = response["attendee"].(map[string]any)
+	if w.Code != http.StatusConflict || response["code"] != problem.CodeAlreadyCheckedIn || attendee["name"] != "Alice" {
+		t.Errorf("Expected a reused ticket to be refused with the attendee, got %d: %s", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		name     string
+		eventID  string
+		body     string
+		expected int
+		code     string
+	}{
+		{"forged code", event.ID, `{"code":"forged.code"}`, http.StatusUnprocessableEntity, problem.CodeTicketInvalid},
+		{"missing code", event.ID, `{}`, http.StatusBadRequest, problem.CodeInvalidRequest},
+		{"unknown event", "missing", code, http.StatusNotFound, problem.CodeNotFound},
+	}
+	for _, tt := range tests {
+		w, response := checkIn(tt.eventID, tt.body)
+		if w.Code != tt.expected || response["code"] != tt.code {
+			t.Errorf("%s: expected status code %d with code %s, got %d: %s", tt.name, tt.expected, tt.code, w.Code, w.Body.String())
+		}
+	}
+}
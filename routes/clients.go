@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"event_booking_restapi_golang/middlewares"
+	"event_booking_restapi_golang/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getClientStats handles GET requests to /admin/clients/stats endpoint.
+// It returns the request and error counts recorded for each known client, with the
+// other clients and the requests without client headers counted together.
+func getClientStats(c *gin.Context) {
+	response.Respond(c, http.StatusOK, middlewares.GetClientStats())
+}
@@ -0,0 +1,76 @@
+package routes
+
+import (
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// screenContent runs the event through the content policy before it is saved.
+// Redactions are applied to the event and flagged events are put into review.
+// When the event is blocked, or screening fails, the response is written and
+// false is returned so the caller can stop handling the request.
+func screenContent(c *gin.Context, e *models.Event) bool {
+	result, err := models.ScreenEventContent(c.Request.Context(), e)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return false
+	}
+
+	switch result.Action {
+	case models.PolicyBlock:
+		problem.Write(c, problem.New(http.StatusUnprocessableEntity, problem.CodeContentBlocked, "the event violates the content policy").
+			With("rules", result.RuleIDs))
+		return false
+	case models.PolicyFlag:
+		e.ReviewStatus, e.ReviewReason = models.ReviewPending, models.FlaggedReason
+	}
+	return true
+}
+
+// getContentRules handles GET requests to /admin/content-rules endpoint.
+// Returns HTTP 500 if the query fails, otherwise HTTP 200 with all content policy rules.
+func getContentRules(c *gin.Context) {
+	rules, err := models.GetAllContentRules(c.Request.Context())
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	response.Write(c, http.StatusOK, response.List(rules, len(rules)))
+}
+
+// createContentRule handles POST requests to /admin/content-rules endpoint.
+// It adds a keyword or regular expression rule from the JSON request body.
+// Returns HTTP 400 if the request or pattern is invalid, or HTTP 201 with the rule on success.
+func createContentRule(c *gin.Context) {
+	var rule models.ContentRule
+	err := c.ShouldBindJSON(&rule)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	rule, err = rule.Save(c.Request.Context())
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+	response.Respond(c, http.StatusCreated, rule)
+}
+
+// deleteContentRule handles DELETE requests to /admin/content-rules/:id endpoint.
+// Returns HTTP 404 if the rule is not found, otherwise HTTP 200 with a success message.
+func deleteContentRule(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	err := models.ContentRule{ID: id}.Delete(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	response.Respond(c, http.StatusOK, gin.H{
+		"message": "Content rule deleted successfully",
+	})
+}
@@ -0,0 +1,101 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestContentPolicyOnCreate tests that createEvent blocks and flags events per the content policy
+func TestContentPolicyOnCreate(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.POST("/event", testHandler.createEvent)
+	router.POST("/admin/content-rules", createContentRule)
+
+	for _, body := range []string{
+		`{"pattern":"scam","action":"block"}`,
+		`{"pattern":"crypto","action":"flag"}`,
+	} {
+		req, _ := http.NewRequest("POST", "/admin/content-rules", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status code %d creating rule, got %d", http.StatusCreated, w.Code)
+		}
+	}
+
+	create := func(title string) *httptest.ResponseRecorder {
+		jsonData, _ := json.Marshal(map[string]interface{}{
+			"title":       title,
+			"description": "Description",
+			"location":    "Online",
+			"date_time":   time.Now().Format(time.RFC3339),
+		})
+		req, _ := http.NewRequest("POST", "/event", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := create("Totally not a scam")
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status code %d for blocked content, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+
+	w = create("Crypto meetup")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d for flagged content, got %d", http.StatusCreated, w.Code)
+	}
+	pending, err := models.GetEventsByReviewStatus(context.Background(), models.ReviewPending)
+	if err != nil {
+		t.Fatalf("Failed to get pending events: %v", err)
+	}
+	if len(pending) != 1 || pending[0].Title != "Crypto meetup" {
+		t.Errorf("Expected the flagged event to be pending review, got %+v", pending)
+	}
+}
+
+// TestContentRuleValidation tests the content rule admin handlers with bad input
+func TestContentRuleValidation(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.POST("/admin/content-rules", createContentRule)
+	router.GET("/admin/content-rules", getContentRules)
+	router.DELETE("/admin/content-rules/:id", deleteContentRule)
+
+	for _, body := range []string{
+		`{"pattern":"x","action":"delete"}`,
+		`{"pattern":"(","isRegex":true,"action":"block"}`,
+		`{"action":"block"}`,
+	} {
+		req, _ := http.NewRequest("POST", "/admin/content-rules", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d for %s, got %d", http.StatusBadRequest, body, w.Code)
+		}
+	}
+
+	req, _ := http.NewRequest("GET", "/admin/content-rules", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	req, _ = http.NewRequest("DELETE", "/admin/content-rules/missing", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
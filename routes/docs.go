@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"event_booking_restapi_golang/docs"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getDocs handles GET requests to /docs endpoint.
+// It serves the Swagger UI page for exploring the API.
+func getDocs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", docs.SwaggerUI)
+}
+
+// getOpenAPISpec handles GET requests to /docs/openapi.json endpoint.
+// It serves the OpenAPI 3 specification of the API.
+func getOpenAPISpec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json", docs.Spec)
+}
@@ -0,0 +1,67 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestGetDocs tests that the Swagger UI page points at the served specification
+func TestGetDocs(t *testing.T) {
+	router := setupTestRouter()
+	router.GET("/docs", getDocs)
+
+	req, _ := http.NewRequest("GET", "/docs", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+		t.Errorf("Expected an HTML page, got %q", w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Body.String(), "/docs/openapi.json") {
+		t.Error("Expected the page to load /docs/openapi.json")
+	}
+}
+
+// pathParam matches gin path parameters such as :id.
+var pathParam = regexp.MustCompile(`:(\w+)`)
+
+// TestOpenAPISpecCoversRoutes tests that every registered route is documented in the specification
+func TestOpenAPISpecCoversRoutes(t *testing.T) {
+	router := setupTestRouter()
+	RegisterRoutes(router, &mockEventRepository{})
+
+	req, _ := http.NewRequest("GET", "/docs/openapi.json", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var spec struct {
+		OpenAPI string
+		Paths   map[string]map[string]json.RawMessage
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("Failed to parse the specification: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		t.Errorf("Expected an OpenAPI 3 specification, got version %q", spec.OpenAPI)
+	}
+
+	for _, route := range router.Routes() {
+		if strings.HasPrefix(route.Path, "/docs") {
+			continue
+		}
+		path := pathParam.ReplaceAllString(route.Path, "{$1}")
+		if _, ok := spec.Paths[path][strings.ToLower(route.Method)]; !ok {
+			t.Errorf("%s %s is missing from the specification", route.Method, path)
+		}
+	}
+}
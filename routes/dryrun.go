@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dryRun is middleware for the destructive endpoints that accept the dry_run query
+// parameter: deleting, cancelling and importing events. With dry_run=true the request
+// runs under models.WithDryRun, so its changes are validated and made in transactions
+// that are rolled back, and no emails are sent or reports stored. The handlers respond
+// as usual with "dry_run": true in their meta, describing what would have changed.
+// Responds HTTP 400 if dry_run isn't a boolean.
+func dryRun(c *gin.Context) {
+	raw := c.Query("dry_run")
+	if raw == "" {
+		c.Next()
+		return
+	}
+	enabled, err := strconv.ParseBool(raw)
+	if err != nil {
+		problem.Write(c, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "dry_run must be true or false").With("field", "dry_run"))
+		return
+	}
+	if enabled {
+		c.Request = c.Request.WithContext(models.WithDryRun(c.Request.Context()))
+	}
+	c.Next()
+}
+
+// withDryRun marks a response body as the outcome of a dry run when the request is one.
+func withDryRun(c *gin.Context, body *response.Body) *response.Body {
+	return body.WithDryRun(models.IsDryRun(c.Request.Context()))
+}
@@ -0,0 +1,71 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDryRunEndpoints tests that dry runs of deleting and cancelling an event report
+// the outcome without changing the event or emailing attendees
+func TestDryRunEndpoints(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.DELETE("/events/:id", dryRun, testHandler.deleteEvent)
+	router.POST("/events/:id/cancel", dryRun, testHandler.cancelEvent)
+	mailer := useFakeMailer(t)
+
+	event := saveEvent(t, models.Event{Title: "Jazz Night"})
+	for _, name := range []string{"Alice", "Bob"} {
+		_, err := event.Register(context.Background(), models.Registration{Name: name, Email: strings.ToLower(name) + "@example.com"})
+		if err != nil {
+			t.Fatalf("Failed to register: %v", err)
+		}
+	}
+	send := func(method, path string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		req, _ := http.NewRequest(method, "/events/"+event.ID+path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+
+	w, response := send("POST", "/cancel?dry_run=true")
+	data, _ := response["data"].(map[string]interface{})
+	meta, _ := response["meta"].(map[string]interface{})
+	if w.Code != http.StatusOK || meta["dry_run"] != true || data["notified"] != float64(2) {
+		t.Errorf("Expected a dry run notifying 2 attendees, got %d: %s", w.Code, w.Body.String())
+	}
+	w, response = send("DELETE", "?dry_run=1")
+	data, _ = response["data"].(map[string]interface{})
+	meta, _ = response["meta"].(map[string]interface{})
+	if w.Code != http.StatusOK || meta["dry_run"] != true || data["id"] != event.ID {
+		t.Errorf("Expected a dry run with the event, got %d: %s", w.Code, w.Body.String())
+	}
+
+	stored, err := models.GetEventById(context.Background(), event.ID)
+	if err != nil || stored.Status != models.StatusPublished {
+		t.Errorf("Expected the event to be unchanged, got %+v (%v)", stored, err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if len(mailer.messages()) != 0 {
+		t.Errorf("Expected no emails, got %d", len(mailer.messages()))
+	}
+
+	if w, _ := send("DELETE", "?dry_run=maybe"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an invalid dry_run, got %d", http.StatusBadRequest, w.Code)
+	}
+	w, response = send("DELETE", "?dry_run=false")
+	if _, ok := response["meta"]; w.Code != http.StatusOK || ok {
+		t.Errorf("Expected a real deletion, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := models.GetEventById(context.Background(), event.ID); err == nil {
+		t.Error("Expected the event to be deleted")
+	}
+}
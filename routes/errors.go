@@ -0,0 +1,66 @@
+package routes
+
+import (
+	"database/sql"
+	"errors"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// domainProblems maps the domain errors of the models to the status and problem code
+// they are reported with. Specific errors come before the kinds they match, which
+// catch the errors without a code of their own.
+var domainProblems = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{models.ErrEventFull, http.StatusConflict, problem.CodeEventFull},
+	{models.ErrEventNotFull, http.StatusConflict, problem.CodeEventNotFull},
+	{models.ErrEventCancelled, http.StatusConflict, problem.CodeEventCancelled},
+	{models.ErrAlreadyRegistered, http.StatusConflict, problem.CodeAlreadyRegistered},
+	{models.ErrAlreadyWaitlisted, http.StatusConflict, problem.CodeAlreadyWaitlisted},
+	{models.ErrAlreadyCheckedIn, http.StatusConflict, problem.CodeAlreadyCheckedIn},
+	{models.ErrRefundRequested, http.StatusConflict, problem.CodeRefundRequested},
+	{models.ErrRefundDecided, http.StatusConflict, problem.CodeRefundDecided},
+	{models.ErrTicketInvalid, http.StatusUnprocessableEntity, problem.CodeTicketInvalid},
+	{models.ErrTicketRevoked, http.StatusUnprocessableEntity, problem.CodeTicketInvalid},
+	{models.ErrTicketWrongEvent, http.StatusUnprocessableEntity, problem.CodeTicketWrongEvent},
+	{models.ErrNotInSeries, http.StatusUnprocessableEntity, problem.CodeNotInSeries},
+	{models.ErrRescheduleClosed, http.StatusConflict, problem.CodeRescheduleClosed},
+	{models.ErrBlockedByOrganizer, http.StatusForbidden, problem.CodeUserBlocked},
+	{models.ErrBlockedByAttendee, http.StatusForbidden, problem.CodeUserBlocked},
+	{models.ErrInvalidSort, http.StatusBadRequest, problem.CodeInvalidRequest},
+	{models.ErrNotPatchable, http.StatusBadRequest, problem.CodeInvalidRequest},
+	{models.ErrEventNotFound, http.StatusNotFound, problem.CodeNotFound},
+	{sql.ErrNoRows, http.StatusNotFound, problem.CodeNotFound},
+	{models.ErrDuplicate, http.StatusConflict, problem.CodeConflict},
+	{models.ErrConflict, http.StatusConflict, problem.CodeConflict},
+}
+
+// respondError responds with the problem err is reported as, found with errors.Is and
+// errors.As rather than by its text: HTTP 404 for records that don't exist, HTTP 409 for
+// duplicates and conflicts, the status of the domain error for the others and HTTP 500
+// for failures, such as a query error.
+func respondError(c *gin.Context, err error) {
+	var versionConflict models.VersionConflictError
+	if errors.As(err, &versionConflict) {
+		respondVersionConflict(c, versionConflict)
+		return
+	}
+	var waiverRequired models.WaiverRequiredError
+	if errors.As(err, &waiverRequired) {
+		respondWaiverRequired(c, waiverRequired)
+		return
+	}
+	for _, p := range domainProblems {
+		if errors.Is(err, p.err) {
+			problem.Respond(c, p.status, p.code, err.Error())
+			return
+		}
+	}
+	problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+}
@@ -0,0 +1,48 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestRespondError tests that model errors are reported by what they are, wrapped or
+// not, rather than by their text
+func TestRespondError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"event not found", models.NotFoundError{Message: "no event", Kind: models.ErrEventNotFound}, http.StatusNotFound, problem.CodeNotFound},
+		{"record not found", models.NotFoundError{Message: "no registration"}, http.StatusNotFound, problem.CodeNotFound},
+		{"wrapped booking error", fmt.Errorf("booking: %w", models.ErrAlreadyRegistered), http.StatusConflict, problem.CodeAlreadyRegistered},
+		{"ticket error", models.ErrTicketWrongEvent, http.StatusUnprocessableEntity, problem.CodeTicketWrongEvent},
+		{"version conflict", models.VersionConflictError{Version: 1, Current: 2}, http.StatusConflict, problem.CodeVersionConflict},
+		{"other conflict", fmt.Errorf("held: %w", models.ErrConflict), http.StatusConflict, problem.CodeConflict},
+		{"unpatchable field", fmt.Errorf("field id %w", models.ErrNotPatchable), http.StatusBadRequest, problem.CodeInvalidRequest},
+		{"failure mentioning not found", errors.New("table not found"), http.StatusInternalServerError, problem.CodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/events/e1", nil)
+			respondError(c, tt.err)
+			var body map[string]any
+			json.Unmarshal(w.Body.Bytes(), &body)
+			if w.Code != tt.status || body["code"] != tt.code {
+				t.Errorf("Expected %d %s, got %d %v", tt.status, tt.code, w.Code, body["code"])
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package routes
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// weakETag returns a weak entity tag for a JSON response body, a hash of its encoding.
+// Bodies holding an event change with every stored change to it, so the tag stands for
+// the event's version without exposing it. The tag is weak because it vouches for the
+// content rather than the exact bytes sent, which compression may change.
+func weakETag(body any) string {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(encoded)
+	return `W/"` + base64.RawURLEncoding.EncodeToString(sum[:18]) + `"`
+}
+
+// etagMatches reports whether a list of entity tags from an If-None-Match or If-Match
+// header holds etag, or is "*". Tags are compared weakly, ignoring the W/ prefix, as
+// RFC 9110 does for If-None-Match; If-Match asks for a strong comparison, which weak
+// tags never pass, so it is weak here too or the header could never succeed.
+func etagMatches(header, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// respondWithETag responds with the JSON body and its weak entity tag in the ETag
+// header, or with HTTP 304 and no body when the request's If-None-Match already holds
+// the tag, so clients can revalidate their cached copy cheaply.
+func respondWithETag(c *gin.Context, body *response.Body) {
+	etag := weakETag(body)
+	if etag != "" {
+		c.Header("ETag", etag)
+	}
+	if header := c.GetHeader("If-None-Match"); header != "" && etagMatches(header, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	response.Write(c, http.StatusOK, body)
+}
+
+// checkIfMatch checks the request's If-Match header against the current state of a
+// resource, given as the body a read of it responds with, so that a client replacing
+// it doesn't overwrite a change made since it read it. Requests without the header
+// always pass.
+// Responds with HTTP 412 and returns false when the header doesn't hold the current tag.
+func checkIfMatch(c *gin.Context, current any) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" || etagMatches(header, weakETag(current)) {
+		return true
+	}
+	problem.Respond(c, http.StatusPreconditionFailed, problem.CodePreconditionFailed,
+		"the resource changed since the version in If-Match, fetch it again and retry")
+	return false
+}
@@ -0,0 +1,85 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestEventETags tests that event reads carry ETags that revalidate cached copies, and
+// that If-Match keeps an update from overwriting a change made since the read
+func TestEventETags(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/events", testHandler.getEvents)
+	router.GET("/events/:id", testHandler.getEvent)
+	router.PUT("/events/:id", testHandler.updateEvent)
+
+	event, err := models.Event{Title: "Jazz Night", Description: "Live music", Location: "Club", DateTime: time.Now().Add(time.Hour)}.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to save event: %v", err)
+	}
+	request := func(method, path string, body any, header, value string) *httptest.ResponseRecorder {
+		var encoded []byte
+		if body != nil {
+			encoded, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest(method, path, bytes.NewReader(encoded))
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, path := range []string{"/events/" + event.ID, "/events"} {
+		w := request("GET", path, nil, "", "")
+		etag := w.Header().Get("ETag")
+		if w.Code != http.StatusOK || len(etag) < 4 || etag[:3] != `W/"` {
+			t.Fatalf("Expected a weak ETag on %s, got %d %q", path, w.Code, etag)
+		}
+		w = request("GET", path, nil, "If-None-Match", `"stale", `+etag)
+		if w.Code != http.StatusNotModified || w.Body.Len() != 0 || w.Header().Get("ETag") != etag {
+			t.Errorf("Expected 304 without a body on %s, got %d %q", path, w.Code, w.Body.String())
+		}
+		w = request("GET", path, nil, "If-None-Match", `W/"stale"`)
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected 200 for a stale ETag on %s, got %d", path, w.Code)
+		}
+	}
+
+	etag := request("GET", "/events/"+event.ID, nil, "", "").Header().Get("ETag")
+	update := map[string]any{"title": "Jazz Night", "description": "Live music", "location": "Basement", "date_time": event.DateTime}
+	w := request("PUT", "/events/"+event.ID, update, "If-Match", etag)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected the update with the current ETag to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+	if request("GET", "/events/"+event.ID, nil, "If-None-Match", etag).Code != http.StatusOK {
+		t.Error("Expected the update to change the ETag")
+	}
+
+	update["location"] = "Rooftop"
+	w = request("PUT", "/events/"+event.ID, update, "If-Match", etag)
+	if w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("Expected 412 for an outdated ETag, got %d", w.Code)
+	}
+	var body map[string]any
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if body["code"] != problem.CodePreconditionFailed {
+		t.Errorf("Expected code %s, got %v", problem.CodePreconditionFailed, body["code"])
+	}
+	stored, _ := models.GetEventById(context.Background(), event.ID)
+	if stored.Location != "Basement" {
+		t.Errorf("Expected the refused update to leave the event alone, got %q", stored.Location)
+	}
+	if w = request("PUT", "/events/"+event.ID, update, "", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected updates without If-Match to go through, got %d", w.Code)
+	}
+}
@@ -3,85 +3,371 @@
 package routes
 
 import (
+	"encoding/json"
+	"errors"
+	"event_booking_restapi_golang/analytics"
+	"event_booking_restapi_golang/ids"
 	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"event_booking_restapi_golang/validation"
+	"fmt"
+	"io"
 	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
+	"github.com/gin-gonic/gin/binding"
 )
 
+// UserHeader identifies the organizer making the request, so that their own draft events
+// are included in listings and can be fetched by ID.
+const UserHeader = "X-User-ID"
+
 // getEvents handles GET requests to /events endpoint.
-// It retrieves all events from the database and returns them as JSON.
-// Returns HTTP 500 if there's an error fetching events, otherwise HTTP 200 with events data.
-func getEvents(context *gin.Context) {
-	events, err := models.GetAllEvents()
+// It retrieves a page of events from the database, narrowed down by the optional
+// from, to, overlaps, location, q, user_id, series_id and status query parameters, sorted by sort and order,
+// paginated with limit and offset, and returns them as JSON with the total number
+// of matching events. Drafts are left out except for the organizer's own, identified by the
+// X-User-ID header. The page is sent as JSON, or as CSV or XML when the Accept header
+// prefers them. Listings without an explicit sort take part in the ranking
+// experiment when it is enabled, which may serve them in an alternative order.
+// Each listing is reported to the analytics pipeline as a search.
+// Returns HTTP 400 for invalid query parameters, HTTP 500 if there's an error
+// fetching events, HTTP 304 if If-None-Match holds the listing's ETag, otherwise
+// HTTP 200 with events data.
+func (h *EventHandler) getEvents(context *gin.Context) {
+	filter, err := parseEventFilter(context)
 	if err != nil {
-		context.JSON(http.StatusInternalServerError, gin.H{"error": err, "where": "couldn't fetch events"})
+		problem.Respond(context, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+	session, variant := applyRankingExperiment(context, &filter)
+	events, err := h.events.QueryEvents(context.Request.Context(), filter)
+	if errors.Is(err, models.ErrInvalidSort) {
+		problem.Respond(context, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
 		return
 	}
-	context.JSON(http.StatusOK, gin.H{
-		"events": events,
+	if err != nil {
+		problem.Respond(context, http.StatusInternalServerError, problem.CodeInternal, "couldn't fetch events: "+err.Error())
+		return
+	}
+	total, err := h.events.CountEvents(context.Request.Context(), filter)
+	if err != nil {
+		problem.Respond(context, http.StatusInternalServerError, problem.CodeInternal, "couldn't count events: "+err.Error())
+		return
+	}
+	logExposure(context, session, variant)
+	trackUsage(context, analytics.SearchPerformed, map[string]any{
+		"location":   filter.Location,
+		"query":      filter.Search != "",
+		"date_range": !filter.From.IsZero() || !filter.To.IsZero(),
+		"sort":       filter.Sort,
+		"order":      filter.Order,
+		"limit":      filter.Limit,
+		"offset":     filter.Offset,
+		"results":    total,
 	})
+	body := response.Page(events, total, filter.Limit, filter.Offset)
+	if mediaType := negotiate(context, mediaJSON, mediaCSV, mediaXML); mediaType != mediaJSON {
+		writeTable(context, http.StatusOK, mediaType, eventTable(events), body.Meta.Pagination)
+		return
+	}
+	respondWithETag(context, body)
+}
+
+// eventTable lays out an event listing for the CSV and XML formats.
+func eventTable(events []models.Event) table {
+	t := table{
+		name: "events",
+		item: "event",
+		columns: []string{
+			"id", "title", "description", "location", "date_time", "end_date_time", "user_id", "capacity",
+			"status", "review_status", "slug", "series_id", "license", "version", "created_at",
+		},
+	}
+	for _, e := range events {
+		t.rows = append(t.rows, []string{
+			e.ID, e.Title, e.Description, e.Location, formatTime(&e.DateTime), formatTime(e.EndDateTime), e.UserID,
+			strconv.Itoa(e.Capacity), e.Status, e.ReviewStatus, e.Slug, e.SeriesID, e.License, strconv.Itoa(e.Version),
+			formatTime(&e.CreatedAt),
+		})
+	}
+	return t
+}
+
+// parseEventFilter builds an EventFilter from the listing query parameters.
+// Dates may be given as RFC 3339 timestamps or as plain YYYY-MM-DD days,
+// in which case "to" covers the whole day.
+func parseEventFilter(c *gin.Context) (models.EventFilter, error) {
+	var filter models.EventFilter
+	var err error
+
+	filter.Limit, filter.Offset, err = parsePagination(c)
+	if err != nil {
+		return filter, err
+	}
+	if raw := c.Query("from"); raw != "" {
+		filter.From, err = parseTimeParam(raw, false)
+		if err != nil {
+			return filter, errors.New("from must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+		}
+	}
+	if raw := c.Query("to"); raw != "" {
+		filter.To, err = parseTimeParam(raw, true)
+		if err != nil {
+			return filter, errors.New("to must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+		}
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return filter, errors.New("to must not be before from")
+	}
+	if raw := c.Query("overlaps"); raw != "" {
+		filter.Overlaps, err = parseWindowParam(raw)
+		if err != nil {
+			return filter, errors.New("overlaps must be a start/end interval of RFC 3339 timestamps or YYYY-MM-DD dates, or a single one")
+		}
+		if !filter.Overlaps.Start.IsZero() && !filter.Overlaps.End.IsZero() && filter.Overlaps.End.Before(filter.Overlaps.Start) {
+			return filter, errors.New("overlaps must not end before it starts")
+		}
+	}
+	filter.Location = c.Query("location")
+	filter.Search = c.Query("q")
+	filter.UserID = c.Query("user_id")
+	filter.SeriesID = c.Query("series_id")
+	filter.Status = c.Query("status")
+	switch filter.Status {
+	case "", models.StatusDraft, models.StatusPublished, models.StatusCancelled:
+	default:
+		return filter, errors.New("status must be one of draft, published or cancelled")
+	}
+	filter.ViewerID = authenticatedUser(c)
+	filter.Sort = c.Query("sort")
+	filter.Order = c.Query("order")
+	return filter, nil
+}
+
+// parseTimeParam parses an RFC 3339 timestamp or a YYYY-MM-DD date.
+// With endOfDay set, a plain date resolves to the last instant of that day.
+func parseTimeParam(raw string, endOfDay bool) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, raw)
+	if err == nil {
+		return t, nil
+	}
+	t, err = time.Parse(time.DateOnly, raw)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+// parseWindowParam parses a time window written as start/end, where either side may be
+// left empty for an open window, or as a single timestamp or date covering just that
+// instant or day. Each side is an RFC 3339 timestamp or a YYYY-MM-DD date; a date as the
+// end covers the whole day.
+func parseWindowParam(raw string) (models.Window, error) {
+	var window models.Window
+	start, end, interval := strings.Cut(raw, "/")
+	if !interval {
+		end = start
+	}
+	if start == "" && end == "" {
+		return window, errors.New("empty window")
+	}
+	var err error
+	if start != "" {
+		window.Start, err = parseTimeParam(start, false)
+		if err != nil {
+			return window, err
+		}
+	}
+	if end != "" {
+		window.End, err = parseTimeParam(end, true)
+	}
+	return window, err
 }
 
 // getEvent handles GET requests to /events/:id endpoint.
-// It retrieves a specific event by its ID from the database.
-// Returns HTTP 404 if the event is not found, otherwise HTTP 302 with the event data.
-func getEvent(c *gin.Context) {
+// It retrieves a specific approved event by its ID from the database and reports the
+// view to the analytics pipeline. Drafts are only returned to their organizer, identified
+// by the X-User-ID header. With an as_of query parameter or an Accept-Datetime header
+// it returns the event as it was at that time instead, see getEventAsOf.
+// Current reads carry a weak ETag of the event, see weakETag.
+// Returns HTTP 404 if the event is not found or not visible to the caller, HTTP 500 if the
+// lookup fails, HTTP 304 if If-None-Match holds the event's ETag, otherwise HTTP 200 with
+// the event data.
+func (h *EventHandler) getEvent(c *gin.Context) {
 	id, _ := c.Params.Get("id")
-	event, err := models.GetEventById(id)
+	asOf, historical, err := parseAsOf(c)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+	if historical {
+		h.getEventAsOf(c, id, asOf)
+		return
+	}
+	event, err := h.getViewableEvent(c.Request.Context(), id, authenticatedUser(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	models.RecordEventView(event.ID)
+	trackUsage(c, analytics.EventViewed, map[string]any{"event_id": event.ID})
+	respondWithETag(c, response.New(event))
+}
+
+// getEventAsOf responds to getEvent requests for a past state of the event, rebuilt
+// from its history. Past states are only shown to the event's organizer and admins,
+// since they may hold drafts or content that was since rejected or removed. The response
+// has the event with its version number and when that version was recorded, which is
+// also sent in the Memento-Datetime header.
+// Returns HTTP 404 if the event didn't exist or had been deleted at that time, HTTP 403 if
+// the caller isn't its organizer, HTTP 500 if the lookup fails, otherwise HTTP 200.
+func (h *EventHandler) getEventAsOf(c *gin.Context, id string, asOf time.Time) {
+	version, err := h.events.GetEventVersionAt(c.Request.Context(), id, asOf)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
-	c.JSON(http.StatusFound, gin.H{
-		"event": event,
+	if !isOrganizer(c, version.Event) {
+		problem.Respond(c, http.StatusForbidden, problem.CodeForbidden, "only the organizer of the event can read its history")
+		return
+	}
+	if version.Deleted {
+		problem.Respond(c, http.StatusNotFound, problem.CodeNotFound, "the event was deleted at "+version.RecordedAt.UTC().Format(time.RFC3339))
+		return
+	}
+	c.Header("Memento-Datetime", version.RecordedAt.UTC().Format(http.TimeFormat))
+	c.Header("Vary", "Accept-Datetime")
+	response.Respond(c, http.StatusOK, gin.H{
+		"event":       version.Event,
+		"version":     version.Version,
+		"recorded_at": version.RecordedAt,
 	})
+}
 
+// parseAsOf reads the time a historical read asks for, from the as_of query parameter
+// (an RFC 3339 timestamp or a YYYY-MM-DD date, meaning the end of that day) or from the
+// Accept-Datetime header (an HTTP date, as in RFC 7089). The query parameter wins.
+// Reports false for requests for the current state.
+func parseAsOf(c *gin.Context) (time.Time, bool, error) {
+	if raw := c.Query("as_of"); raw != "" {
+		asOf, err := parseTimeParam(raw, true)
+		if err != nil {
+			return time.Time{}, false, errors.New("as_of must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+		}
+		return asOf, true, nil
+	}
+	if raw := c.GetHeader("Accept-Datetime"); raw != "" {
+		asOf, err := http.ParseTime(raw)
+		if err != nil {
+			return time.Time{}, false, errors.New("Accept-Datetime must be an HTTP date such as Mon, 02 Jun 2030 18:00:00 GMT")
+		}
+		return asOf, true, nil
+	}
+	return time.Time{}, false, nil
 }
 
 // createEvent handles POST requests to /event endpoint.
 // It creates a new event from the JSON request body and saves it to the database.
-// Returns HTTP 400 if the request is invalid or save fails, otherwise HTTP 201 with the created event.
-func createEvent(context *gin.Context) {
+// Events are published unless the body sets their status, e.g. to draft.
+// When moderation mode is enabled, or the content policy flags the event, it is held
+// for review before it is listed. Returns HTTP 422 if the content policy blocks the event.
+// The event belongs to the organizer in the X-User-ID header, or to a new user ID when
+// none is sent. With FREE_EVENT_LIMIT set, the header is required so that the active
+// events of free users can be counted.
+// Returns HTTP 403 without a user ID when the limit is set, HTTP 402 with the usage and
+// the limit when a free user has reached it, HTTP 400 if the request or confirmation
+// settings are invalid or save fails, HTTP 409 if the venue has a blackout on a day of
+// the event, otherwise HTTP 201 with the created event and warnings for any holidays of
+// the region query parameter, or HOLIDAY_REGION, that it falls on.
+func (h *EventHandler) createEvent(context *gin.Context) {
 	var newEvent models.Event
 	err := context.ShouldBindJSON(&newEvent)
 	if err != nil {
-		context.JSON(
-			http.StatusBadRequest,
-			gin.H{"message": "something went wrong", "error": err},
-		)
+		problem.Respond(context, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+	newEvent.ID = ids.New()
+	newEvent.UserID = authenticatedUser(context)
+	if newEvent.UserID == "" {
+		if models.FreeEventLimit > 0 {
+			problem.Respond(context, http.StatusForbidden, problem.CodeForbidden, "creating events requires your user ID in the "+UserHeader+" header")
+			return
+		}
+		newEvent.UserID = ids.New()
+	}
+	newEvent.ExternalID, newEvent.Source = "", ""
+	newEvent.CreatedAt = time.Now()
+	newEvent.ReviewStatus, newEvent.ReviewReason = models.ReviewApproved, ""
+	if newEvent.Status == "" {
+		newEvent.Status = models.StatusPublished
+	}
+	if !checkEndTime(context, newEvent) {
+		return
+	}
+	region, ok := holidayRegion(context)
+	if !ok {
+		return
+	}
+	if !cleanConfirmation(context, &newEvent) {
+		return
+	}
+	if !cleanLicense(context, &newEvent) {
+		return
+	}
+	if moderationEnabled {
+		newEvent.ReviewStatus = models.ReviewPending
+	}
+	if !screenContent(context, &newEvent) {
+		return
+	}
+	newEvent, err = h.events.Save(context.Request.Context(), newEvent)
+	var quotaErr models.QuotaError
+	if errors.As(err, &quotaErr) {
+		problem.Write(context, problem.New(http.StatusPaymentRequired, problem.CodeQuotaExceeded, err.Error()).
+			With("usage", quotaErr.Usage).
+			With("limit", quotaErr.Limit))
+		return
+	}
+	if respondIfVenueUnavailable(context, err) {
 		return
 	}
-	newEvent.ID = uuid.NewString()
-	newEvent.UserID = uuid.NewString()
-	err = newEvent.Save()
 	if err != nil {
-		context.JSON(
-			http.StatusBadRequest,
-			gin.H{"message": "something went wrong", "error": err},
-		)
+		problem.Respond(context, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
 		return
 	}
-	context.JSON(
-		http.StatusCreated,
-		gin.H{"message": "A new event has been created successfully", "event": newEvent},
-	)
+	response.Write(context, http.StatusCreated, response.New(newEvent).WithErrors(holidayWarnings(region, newEvent)...))
 }
 
 // updateEvent handles PUT requests to /events/:id endpoint.
 // It updates an existing event with the provided ID using the JSON request body.
-// Returns HTTP 404 if the event is not found, HTTP 400 if the request is invalid,
-// or HTTP 200 with the updated event on success.
-func updateEvent(c *gin.Context) {
+// The content policy is applied as on creation, so edits can put an event back into review.
+// Events keep their status unless the body sets it. With an If-Match header, the event is
+// only replaced if it still has one of the ETags given, as returned by getEvent, and with
+// a version in the body only if it is still at that version.
+// Returns HTTP 404 if the event is not found, HTTP 412 if it changed since the If-Match
+// ETag, HTTP 409 if it changed since the version or while being saved, HTTP 400 if the
+// request or confirmation settings are invalid, HTTP 409 if the status change cancels the
+// event or brings it back from cancellation, HTTP 409 if the event is moved onto a
+// blackout date of its venue, HTTP 422 if the content policy blocks the change, or HTTP 200
+// with the updated event and its holiday warnings, as on creation, on success.
+func (h *EventHandler) updateEvent(c *gin.Context) {
 	id, _ := c.Params.Get("id")
-	event, err := models.GetEventById(id)
+	event, err := h.events.GetEventById(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		respondError(c, err)
+		return
+	}
+
+	if !checkIfMatch(c, response.New(event)) {
 		return
 	}
 
@@ -89,47 +375,337 @@ func updateEvent(c *gin.Context) {
 	err = c.ShouldBindJSON(&updatedEvent)
 
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+	if updatedEvent.Version != 0 && updatedEvent.Version != event.Version {
+		respondVersionConflict(c, models.VersionConflictError{Version: updatedEvent.Version, Current: event.Version})
+		return
+	}
+	// The event is only replaced while still at the version the preconditions were checked
+	// against, so a concurrent change in between is refused rather than overwritten
+	updatedEvent.Version = event.Version
+	updatedEvent.ID, updatedEvent.CreatedAt = event.ID, event.CreatedAt
+	updatedEvent.ExternalID, updatedEvent.Source = event.ExternalID, event.Source
+	updatedEvent.ReviewStatus, updatedEvent.ReviewReason = event.ReviewStatus, event.ReviewReason
+	if updatedEvent.Status == "" {
+		updatedEvent.Status = event.Status
+	}
+	if !checkStatusChange(c, event.Status, updatedEvent.Status) {
+		return
+	}
+	if !checkEndTime(c, updatedEvent) {
+		return
+	}
+	region, ok := holidayRegion(c)
+	if !ok {
+		return
+	}
+	if !cleanConfirmation(c, &updatedEvent) {
+		return
+	}
+	if !cleanLicense(c, &updatedEvent) {
+		return
+	}
+	if !screenContent(c, &updatedEvent) {
+		return
+	}
+	err = h.events.Update(c.Request.Context(), updatedEvent)
+	if respondIfVenueUnavailable(c, err) {
 		return
 	}
-	updatedEvent.ID = event.ID
-	err = updatedEvent.Update()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		respondError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Event updated successfully",
-		"event":   updatedEvent,
-	})
+	if updatedEvent.ReviewStatus != event.ReviewStatus {
+		err = h.events.SetReviewStatus(c.Request.Context(), updatedEvent, updatedEvent.ReviewStatus, updatedEvent.ReviewReason)
+		if err != nil {
+			problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+			return
+		}
+	}
+	// The response carries the version to send with the next update
+	if stored, err := h.events.GetEventById(c.Request.Context(), id); err == nil {
+		updatedEvent.Version = stored.Version
+	}
+	response.Write(c, http.StatusOK, response.New(updatedEvent).WithErrors(holidayWarnings(region, updatedEvent)...))
+
+}
+
+// respondVersionConflict responds to an update based on an outdated version of the event
+// with HTTP 409 and the current version, so the client can fetch the event again.
+func respondVersionConflict(c *gin.Context, conflict models.VersionConflictError) {
+	problem.Write(c, problem.New(http.StatusConflict, problem.CodeVersionConflict, conflict.Error()).
+		With("version", conflict.Current))
+}
+
+// patchEvent handles PATCH requests to /events/:id endpoint.
+// It merges the fields present in the JSON request body into the stored event and
+// saves only those fields, so clients don't have to resend the whole event. Field names
+// are the event's JSON names, with the legacy names accepted as for PUT; fields that
+// can't be changed are refused.
+// The content policy is applied to the merged event as on update. As for PUT, with an
+// If-Match header the event is only patched if it still has one of the ETags given, and
+// with a version in the body only if it is still at that version.
+// Returns HTTP 404 if the event is not found, HTTP 412 if it changed since the If-Match
+// ETag, HTTP 409 if it changed since the version or while being saved, HTTP 400 if the
+// body is invalid, names a field that can't be patched or leaves the event invalid, HTTP
+// 409 if the status change cancels the event or brings it back from cancellation, HTTP
+// 409 if the event is moved onto a blackout date of its venue, HTTP 422 if the content
+// policy blocks the change, or HTTP 200 with the updated event and its holiday warnings,
+// as on creation, on success.
+func (h *EventHandler) patchEvent(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	event, err := h.events.GetEventById(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if !checkIfMatch(c, response.New(event)) {
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+	var raw map[string]json.RawMessage
+	err = json.Unmarshal(body, &raw)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+	names, err := patchedFieldNames(raw)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	patchedEvent := event
+	err = json.Unmarshal(body, &patchedEvent)
+	if err == nil {
+		err = binding.Validator.ValidateStruct(patchedEvent)
+	}
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+	if patchedEvent.Version != event.Version {
+		respondVersionConflict(c, models.VersionConflictError{Version: patchedEvent.Version, Current: event.Version})
+		return
+	}
+	if patchedEvent.Status == "" {
+		patchedEvent.Status = event.Status
+	}
+	if !checkStatusChange(c, event.Status, patchedEvent.Status) {
+		return
+	}
+	if !checkEndTime(c, patchedEvent) {
+		return
+	}
+	region, ok := holidayRegion(c)
+	if !ok {
+		return
+	}
+	if !cleanConfirmation(c, &patchedEvent) {
+		return
+	}
+	if !cleanLicense(c, &patchedEvent) {
+		return
+	}
+	if !screenContent(c, &patchedEvent) {
+		return
+	}
+
+	fields := make(map[string]any, len(names)+1)
+	for _, name := range names {
+		fields[name] = reflect.ValueOf(patchedEvent).FieldByName(name).Interface()
+	}
+	// The version the preconditions were checked against is checked again as the patch is
+	// written, so a concurrent change in between is refused rather than overwritten
+	fields["Version"] = event.Version
+	err = h.events.Patch(c.Request.Context(), patchedEvent, fields)
+	if respondIfVenueUnavailable(c, err) {
+		return
+	}
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if patchedEvent.ReviewStatus != event.ReviewStatus {
+		err = h.events.SetReviewStatus(c.Request.Context(), patchedEvent, patchedEvent.ReviewStatus, patchedEvent.ReviewReason)
+		if err != nil {
+			problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+			return
+		}
+	}
+	// The response carries the version to send with the next update
+	if stored, err := h.events.GetEventById(c.Request.Context(), id); err == nil {
+		patchedEvent.Version = stored.Version
+	}
+	response.Write(c, http.StatusOK, response.New(patchedEvent).WithErrors(holidayWarnings(region, patchedEvent)...))
+}
+
+// patchedFieldNames resolves the keys of a PATCH body to the patchable Event field names.
+// Keys are matched case-insensitively and ignoring underscores, so both the snake_case
+// JSON names and the legacy Go-cased names are accepted. The version is the one the
+// patch is based on rather than a field to change, so it is skipped. Returns an error
+// naming the first key that isn't patchable.
+func patchedFieldNames(raw map[string]json.RawMessage) ([]string, error) {
+	names := make([]string, 0, len(raw))
+	for key := range raw {
+		if strings.EqualFold(key, "version") {
+			continue
+		}
+		name := ""
+		for field := range models.PatchableEventFields {
+			if strings.EqualFold(strings.ReplaceAll(key, "_", ""), field) {
+				name = field
+				break
+			}
+		}
+		if name == "" {
+			return nil, fmt.Errorf("field %s %w", key, models.ErrNotPatchable)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// cleanConfirmation validates the event's post-registration redirect URL and reduces its
+// confirmation message to plain text. Writes HTTP 400 and returns false if either is invalid.
+func cleanConfirmation(c *gin.Context, e *models.Event) bool {
+	var err error
+	e.ConfirmationURL, err = validation.ValidateRedirectURL(e.ConfirmationURL)
+	if err != nil {
+		problem.Write(c, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, err.Error()).With("field", "confirmation_url"))
+		return false
+	}
+	e.ConfirmationMessage, err = validation.SanitizeConfirmationMessage(e.ConfirmationMessage)
+	if err != nil {
+		problem.Write(c, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, err.Error()).With("field", "confirmation_message"))
+		return false
+	}
+	return true
+}
+
+// checkStatusChange validates that an update doesn't cancel the event or bring it back
+// from cancellation, which only POST /events/:id/cancel does, with its notifications, and
+// which can't be undone. Writes HTTP 409 and returns false otherwise.
+func checkStatusChange(c *gin.Context, from, to string) bool {
+	if from == models.StatusCancelled && to != models.StatusCancelled {
+		problem.Write(c, problem.New(http.StatusConflict, problem.CodeEventCancelled, "cancelled events can't be published again, not even with POST /events/:id/publish").With("field", "status"))
+		return false
+	}
+	if from != models.StatusCancelled && to == models.StatusCancelled {
+		problem.Write(c, problem.New(http.StatusConflict, problem.CodeConflict, "cancel events with POST /events/:id/cancel, which notifies the attendees").With("field", "status"))
+		return false
+	}
+	return true
+}
 
+// checkEndTime validates that an event with an end time ends after it starts.
+// Writes HTTP 400 and returns false otherwise.
+func checkEndTime(c *gin.Context, e models.Event) bool {
+	if e.EndDateTime != nil && !e.EndDateTime.After(e.DateTime) {
+		problem.Write(c, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "end_date_time must be after date_time").With("field", "end_date_time"))
+		return false
+	}
+	return true
 }
 
 // deleteEvent handles DELETE requests to /events/:id endpoint.
-// It deletes the event with the provided ID from the database.
+// It deletes the event with the provided ID from the database. With dry_run=true the
+// deletion is rolled back, and the response has the event that would be deleted.
 // Returns HTTP 404 if the event is not found, HTTP 500 if deletion fails,
 // or HTTP 200 with a success message on success.
-func deleteEvent(c *gin.Context) {
+func (h *EventHandler) deleteEvent(c *gin.Context) {
 	id, _ := c.Params.Get("id")
-	event, err := models.GetEventById(id)
+	event, err := h.events.GetEventById(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		respondError(c, err)
 		return
 	}
-	err = event.Delete()
+	err = h.events.Delete(c.Request.Context(), event)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	if models.IsDryRun(c.Request.Context()) {
+		response.Write(c, http.StatusOK, withDryRun(c, response.New(event)))
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{
+	response.Respond(c, http.StatusOK, gin.H{
 		"message": "Event deleted successfully",
 	})
 }
+
+// publishEvent handles POST requests to /events/:id/publish endpoint.
+// It publishes a draft event, so that it is listed and takes bookings. Publishing an
+// event that is already published does nothing.
+// Returns HTTP 404 if the event is not found, HTTP 409 if it has been cancelled, HTTP 500
+// if the update fails, or HTTP 200 with the published event on success.
+func (h *EventHandler) publishEvent(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	event, err := h.events.GetEventById(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if event.Status == models.StatusCancelled {
+		problem.Respond(c, http.StatusConflict, problem.CodeEventCancelled, models.ErrEventCancelled.Error())
+		return
+	}
+	if event.Status != models.StatusPublished {
+		event.Status = models.StatusPublished
+		err = h.events.Patch(c.Request.Context(), event, map[string]any{"Status": event.Status})
+		if err != nil {
+			problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+			return
+		}
+	}
+	response.Respond(c, http.StatusOK, event)
+}
+
+// getMyEvents handles GET requests to /users/me/events endpoint.
+// It returns a page of the events created by the organizer identified by the X-User-ID
+// header, in date order and including drafts and events held for review, narrowed down
+// by the optional status and review_status query parameters and paginated with limit
+// and offset.
+// Returns HTTP 403 without a user ID, HTTP 400 for invalid query parameters, HTTP 500 if
+// the lookup fails, otherwise HTTP 200 with the events and their total number.
+func (h *EventHandler) getMyEvents(c *gin.Context) {
+	userID := authenticatedUser(c)
+	if userID == "" {
+		problem.Respond(c, http.StatusForbidden, problem.CodeForbidden, "listing your events requires your user ID in the "+UserHeader+" header")
+		return
+	}
+	limit, offset, err := parsePagination(c)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+	filter := models.UserEventFilter{Status: c.Query("status"), ReviewStatus: c.Query("review_status")}
+	switch filter.Status {
+	case "", models.StatusDraft, models.StatusPublished, models.StatusCancelled:
+	default:
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, "status must be one of draft, published or cancelled")
+		return
+	}
+	switch filter.ReviewStatus {
+	case "", models.ReviewPending, models.ReviewApproved, models.ReviewRejected:
+	default:
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, "review_status must be one of pending_review, approved or rejected")
+		return
+	}
+
+	events, total, err := h.events.GetEventsByUserId(c.Request.Context(), userID, filter, limit, offset)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	response.Write(c, http.StatusOK, response.Page(events, total, limit, offset))
+}
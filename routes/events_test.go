@@ -3,12 +3,16 @@ package routes
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"event_booking_restapi_golang/db"
 	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -18,6 +22,9 @@ import (
 
 var testDB *sql.DB
 
+// testHandler serves the event endpoints from the test database set up by setupTestDatabase
+var testHandler = NewEventHandler(models.NewSQLEventRepository())
+
 // setupTestDatabase creates a fresh in-memory SQLite database for testing
 func setupTestDatabase(t *testing.T) {
 	var err error
@@ -26,7 +33,7 @@ func setupTestDatabase(t *testing.T) {
 		t.Fatalf("Failed to create test database: %v", err)
 	}
 
-	// Create events table for testing
+	// Create the application tables for testing
 	createTableSQL := `
 	CREATE TABLE IF NOT EXISTS events (
 		id TEXT PRIMARY KEY,
@@ -34,7 +41,268 @@ func setupTestDatabase(t *testing.T) {
 		description TEXT NOT NULL,
 		location TEXT NOT NULL,
 		datetime DATETIME NOT NULL,
-		user_id TEXT
+		user_id TEXT,
+		capacity INTEGER NOT NULL DEFAULT 0,
+		review_status TEXT NOT NULL DEFAULT 'approved',
+		review_reason TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		confirmation_url TEXT NOT NULL DEFAULT '',
+		confirmation_message TEXT NOT NULL DEFAULT '',
+		external_id TEXT NOT NULL DEFAULT '',
+		external_source TEXT NOT NULL DEFAULT '',
+		end_datetime DATETIME,
+		status TEXT NOT NULL DEFAULT 'published',
+		slug TEXT NOT NULL DEFAULT '',
+		license TEXT NOT NULL DEFAULT '',
+		version INTEGER NOT NULL DEFAULT 0,
+		series_id TEXT NOT NULL DEFAULT ''
+	);
+	CREATE TABLE IF NOT EXISTS registrations (
+		id TEXT PRIMARY KEY,
+		event_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		email TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		waiver_id TEXT NOT NULL DEFAULT '',
+		waiver_accepted_at DATETIME,
+		waiver_ip TEXT NOT NULL DEFAULT '',
+		utm_source TEXT NOT NULL DEFAULT '',
+		utm_medium TEXT NOT NULL DEFAULT '',
+		utm_campaign TEXT NOT NULL DEFAULT '',
+		utm_term TEXT NOT NULL DEFAULT '',
+		utm_content TEXT NOT NULL DEFAULT '',
+		ref TEXT NOT NULL DEFAULT '',
+		external_id TEXT NOT NULL DEFAULT '',
+		external_source TEXT NOT NULL DEFAULT '',
+		checked_in_at DATETIME,
+		moved_at DATETIME,
+		UNIQUE (event_id, email)
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS events_external_id ON events (external_source, external_id) WHERE external_id <> '';
+	CREATE UNIQUE INDEX IF NOT EXISTS registrations_external_id ON registrations (external_source, external_id) WHERE external_id <> '';
+	CREATE TABLE IF NOT EXISTS waitlist (
+		id TEXT PRIMARY KEY,
+		event_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		email TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		waiver_id TEXT NOT NULL DEFAULT '',
+		waiver_accepted_at DATETIME,
+		waiver_ip TEXT NOT NULL DEFAULT '',
+		utm_source TEXT NOT NULL DEFAULT '',
+		utm_medium TEXT NOT NULL DEFAULT '',
+		utm_campaign TEXT NOT NULL DEFAULT '',
+		utm_term TEXT NOT NULL DEFAULT '',
+		utm_content TEXT NOT NULL DEFAULT '',
+		ref TEXT NOT NULL DEFAULT '',
+		UNIQUE (event_id, email)
+	);
+	CREATE TABLE IF NOT EXISTS content_rules (
+		id TEXT PRIMARY KEY,
+		pattern TEXT NOT NULL,
+		is_regex INTEGER NOT NULL DEFAULT 0,
+		action TEXT NOT NULL,
+		created_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS attachments (
+		id TEXT PRIMARY KEY,
+		event_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		file_name TEXT NOT NULL,
+		content_type TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		attendees_only BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS experiment_events (
+		id TEXT PRIMARY KEY,
+		experiment TEXT NOT NULL,
+		variant TEXT NOT NULL,
+		session_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		event_id TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS audit_log (
+		seq INTEGER PRIMARY KEY,
+		action TEXT NOT NULL,
+		event_id TEXT NOT NULL DEFAULT '',
+		subject_id TEXT NOT NULL DEFAULT '',
+		details TEXT NOT NULL DEFAULT '{}',
+		created_at DATETIME NOT NULL,
+		prev_hash TEXT NOT NULL,
+		hash TEXT NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS audit_export (
+		sink TEXT PRIMARY KEY,
+		last_seq INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE TABLE IF NOT EXISTS analytics_opt_outs (
+		session_id TEXT PRIMARY KEY,
+		created_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS registration_daily_summary (
+		event_id TEXT NOT NULL,
+		day TEXT NOT NULL,
+		registrations INTEGER NOT NULL,
+		PRIMARY KEY (event_id, day)
+	);
+	CREATE TABLE IF NOT EXISTS event_view_daily_summary (
+		event_id TEXT NOT NULL,
+		day TEXT NOT NULL,
+		views INTEGER NOT NULL,
+		PRIMARY KEY (event_id, day)
+	);
+	CREATE TABLE IF NOT EXISTS summary_refresh (
+		name TEXT PRIMARY KEY,
+		refreshed_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS event_alerts (
+		event_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		sent_at DATETIME NOT NULL,
+		PRIMARY KEY (event_id, kind)
+	);
+	CREATE TABLE IF NOT EXISTS event_senders (
+		event_id TEXT PRIMARY KEY,
+		sender_name TEXT NOT NULL DEFAULT '',
+		reply_to TEXT NOT NULL DEFAULT '',
+		reply_to_verified_at DATETIME,
+		verification_hash TEXT NOT NULL DEFAULT '',
+		verification_expires_at DATETIME
+	);
+	CREATE TABLE IF NOT EXISTS event_messages (
+		id TEXT PRIMARY KEY,
+		event_id TEXT NOT NULL,
+		registration_id TEXT NOT NULL,
+		sender TEXT NOT NULL,
+		body TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		read_at DATETIME
+	);
+	CREATE TABLE IF NOT EXISTS user_blocks (
+		organizer_id TEXT NOT NULL,
+		email TEXT NOT NULL,
+		blocked_by TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		PRIMARY KEY (organizer_id, email, blocked_by)
+	);
+	CREATE TABLE IF NOT EXISTS event_imports (
+		event_id TEXT PRIMARY KEY,
+		source TEXT NOT NULL,
+		source_event_id TEXT NOT NULL,
+		media TEXT NOT NULL,
+		imported_at DATETIME NOT NULL,
+		UNIQUE (source, source_event_id)
+	);
+	CREATE TABLE IF NOT EXISTS feed_runs (
+		id TEXT PRIMARY KEY,
+		feed TEXT NOT NULL,
+		started_at DATETIME NOT NULL,
+		finished_at DATETIME NOT NULL,
+		added INTEGER NOT NULL DEFAULT 0,
+		updated INTEGER NOT NULL DEFAULT 0,
+		skipped INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		skips TEXT NOT NULL DEFAULT '[]'
+	);
+	CREATE TABLE IF NOT EXISTS tickets (
+		id TEXT PRIMARY KEY,
+		registration_id TEXT NOT NULL UNIQUE,
+		event_id TEXT NOT NULL,
+		code TEXT NOT NULL UNIQUE,
+		created_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS event_versions (
+		event_id TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		recorded_at DATETIME NOT NULL,
+		deleted BOOLEAN NOT NULL DEFAULT FALSE,
+		name TEXT NOT NULL,
+		description TEXT NOT NULL,
+		location TEXT NOT NULL,
+		datetime DATETIME NOT NULL,
+		user_id TEXT,
+		capacity INTEGER NOT NULL DEFAULT 0,
+		review_status TEXT NOT NULL DEFAULT 'approved',
+		review_reason TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		confirmation_url TEXT NOT NULL DEFAULT '',
+		confirmation_message TEXT NOT NULL DEFAULT '',
+		external_id TEXT NOT NULL DEFAULT '',
+		external_source TEXT NOT NULL DEFAULT '',
+		end_datetime DATETIME,
+		status TEXT NOT NULL DEFAULT 'published',
+		slug TEXT NOT NULL DEFAULT '',
+		license TEXT NOT NULL DEFAULT '',
+		series_id TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (event_id, version)
+	);
+	CREATE TABLE IF NOT EXISTS jobs (
+		id TEXT PRIMARY KEY,
+		kind TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		run_at DATETIME NOT NULL,
+		locked_at DATETIME,
+		last_error TEXT NOT NULL DEFAULT '',
+		request_id TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS refund_requests (
+		id TEXT PRIMARY KEY,
+		registration_id TEXT NOT NULL UNIQUE,
+		event_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		email TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'requested',
+		auto_approved BOOLEAN NOT NULL DEFAULT FALSE,
+		decision_reason TEXT NOT NULL DEFAULT '',
+		provider_reference TEXT NOT NULL DEFAULT '',
+		error TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		decided_at DATETIME,
+		refunded_at DATETIME
+	);
+	CREATE TABLE IF NOT EXISTS venue_blackouts (
+		id TEXT PRIMARY KEY,
+		location TEXT NOT NULL,
+		blackout_date TEXT NOT NULL,
+		reason TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS organizer_onboarding (
+		user_id TEXT NOT NULL,
+		step TEXT NOT NULL,
+		completed_at DATETIME NOT NULL,
+		PRIMARY KEY (user_id, step)
+	);
+	CREATE TABLE IF NOT EXISTS status_probes (
+		id TEXT PRIMARY KEY,
+		component TEXT NOT NULL,
+		checked_at DATETIME NOT NULL,
+		state TEXT NOT NULL,
+		detail TEXT NOT NULL DEFAULT ''
+	);
+	CREATE TABLE IF NOT EXISTS registration_cancellations (
+		registration_id TEXT PRIMARY KEY,
+		event_id TEXT NOT NULL,
+		registered_at DATETIME NOT NULL,
+		cancelled_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS registration_moves (
+		id TEXT PRIMARY KEY,
+		registration_id TEXT NOT NULL,
+		event_id TEXT NOT NULL,
+		to_event_id TEXT NOT NULL,
+		registered_at DATETIME NOT NULL,
+		moved_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER PRIMARY KEY,
+		migrated_at DATETIME NOT NULL
 	)
 	`
 	_, err = testDB.Exec(createTableSQL)
@@ -51,18 +319,51 @@ func setupTestDatabase(t *testing.T) {
 	})
 }
 
+// saveEvent saves the event, with a title, description and location and starting now
+// unless it has its own, and returns it as stored
+func saveEvent(t *testing.T, event models.Event) models.Event {
+	t.Helper()
+	if event.Title == "" {
+		event.Title = "Test Event"
+	}
+	if event.Description == "" {
+		event.Description = "Test Description"
+	}
+	if event.Location == "" {
+		event.Location = "Test Location"
+	}
+	if event.DateTime.IsZero() {
+		event.DateTime = time.Now()
+	}
+	event, err := event.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to save test event: %v", err)
+	}
+	return event
+}
+
 // setupTestRouter creates a Gin router for testing
 func setupTestRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
+	router.Use(trustUserHeader)
 	return router
 }
 
+// trustUserHeader stands in for authenticateUser in handler tests, taking the
+// X-User-ID header as verified
+func trustUserHeader(c *gin.Context) {
+	if userID := c.GetHeader(UserHeader); userID != "" {
+		c.Set(authenticatedUserKey, userID)
+	}
+	c.Next()
+}
+
 // TestGetEvents tests the getEvents handler
 func TestGetEvents(t *testing.T) {
 	setupTestDatabase(t)
 	router := setupTestRouter()
-	router.GET("/events", getEvents)
+	router.GET("/events", testHandler.getEvents)
 
 	// Insert test events
 	events := []models.Event{
@@ -83,7 +384,7 @@ func TestGetEvents(t *testing.T) {
 	}
 
 	for _, event := range events {
-		err := event.Save()
+		_, err := event.Save(context.Background())
 		if err != nil {
 			t.Fatalf("Failed to insert test event: %v", err)
 		}
@@ -103,9 +404,9 @@ func TestGetEvents(t *testing.T) {
 		t.Errorf("Failed to parse response JSON: %v", err)
 	}
 
-	eventsData, ok := response["events"].([]interface{})
+	eventsData, ok := response["data"].([]interface{})
 	if !ok {
-		t.Error("Response should contain 'events' array")
+		t.Error("Response should contain a 'data' array")
 	}
 
 	if len(eventsData) != 2 {
@@ -117,7 +418,7 @@ func TestGetEvents(t *testing.T) {
 func TestGetEventsEmpty(t *testing.T) {
 	setupTestDatabase(t)
 	router := setupTestRouter()
-	router.GET("/events", getEvents)
+	router.GET("/events", testHandler.getEvents)
 
 	req, _ := http.NewRequest("GET", "/events", nil)
 	w := httptest.NewRecorder()
@@ -135,14 +436,9 @@ func TestGetEventsEmpty(t *testing.T) {
 
 	t.Logf("Response body: %s", w.Body.String())
 
-	eventsData, ok := response["events"]
+	eventsData, ok := response["data"]
 	if !ok {
-		t.Error("Response should contain 'events' array")
-	}
-
-	if eventsData == nil {
-		t.Log("Events data is null, treating as empty array")
-		return // This is actually correct behavior for empty database
+		t.Error("Response should contain a 'data' array")
 	}
 
 	eventsSlice, ok := eventsData.([]interface{})
@@ -155,11 +451,232 @@ func TestGetEventsEmpty(t *testing.T) {
 	}
 }
 
+// TestGetEventsPagination tests the limit and offset parameters of the getEvents handler
+func TestGetEventsPagination(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/events", testHandler.getEvents)
+
+	for i := 0; i < 3; i++ {
+		event := models.Event{
+			Title:       "Event",
+			Description: "Description",
+			Location:    "Location",
+			DateTime:    time.Now().Add(time.Duration(i) * time.Hour),
+			UserID:      "user1",
+		}
+		event, err := event.Save(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to insert test event: %v", err)
+		}
+	}
+
+	req, _ := http.NewRequest("GET", "/events?limit=2&offset=2", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	if err != nil {
+		t.Errorf("Failed to parse response JSON: %v", err)
+	}
+
+	eventsData, _ := response["data"].([]interface{})
+	if len(eventsData) != 1 {
+		t.Errorf("Expected 1 event on the second page, got %d", len(eventsData))
+	}
+	meta, _ := response["meta"].(map[string]interface{})
+	if meta["total"] != float64(3) || meta["page"] != float64(2) {
+		t.Errorf("Expected total of 3 on page 2, got %v", meta)
+	}
+	if meta["limit"] != float64(2) || meta["offset"] != float64(2) {
+		t.Errorf("Expected limit 2 and offset 2, got %v and %v", meta["limit"], meta["offset"])
+	}
+
+	for _, query := range []string{"limit=0", "limit=abc", "offset=-1"} {
+		req, _ = http.NewRequest("GET", "/events?"+query, nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d for %s, got %d", http.StatusBadRequest, query, w.Code)
+		}
+	}
+}
+
+// TestGetEventsFilters tests the from, to, location and user_id parameters of the getEvents handler
+func TestGetEventsFilters(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/events", testHandler.getEvents)
+
+	base := time.Date(2030, 6, 1, 18, 0, 0, 0, time.UTC)
+	for i, location := range []string{"Berlin", "Cairo", "Berlin"} {
+		event := models.Event{
+			Title:       "Event",
+			Description: "Description",
+			Location:    location,
+			DateTime:    base.AddDate(0, 0, i),
+			UserID:      "user1",
+		}
+		event, err := event.Save(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to insert test event: %v", err)
+		}
+	}
+
+	tests := []struct {
+		query    string
+		expected int
+	}{
+		{"from=2030-06-02", 2},
+		{"to=2030-06-02", 2},
+		{"from=2030-06-02T00:00:00Z&to=2030-06-02", 1},
+		{"location=berlin", 2},
+		{"user_id=user1&location=cairo", 1},
+		{"user_id=someone-else", 0},
+		{"overlaps=2030-06-02", 1},
+		{"overlaps=2030-06-02/2030-06-03", 2},
+		{"overlaps=2030-06-02T18:00:00Z", 1},
+		{"overlaps=2030-06-02T12:00:00Z/", 2},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", "/events?"+tt.query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status code %d for %s, got %d", http.StatusOK, tt.query, w.Code)
+		}
+		var response struct {
+			Meta struct{ Total int }
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response.Meta.Total != tt.expected {
+			t.Errorf("Expected %d events for %s, got %v", tt.expected, tt.query, response.Meta.Total)
+		}
+	}
+
+	for _, query := range []string{"from=yesterday", "to=2030-13-01", "from=2030-06-03&to=2030-06-01", "overlaps=soon", "overlaps=/", "overlaps=2030-06-03/2030-06-01"} {
+		req, _ := http.NewRequest("GET", "/events?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d for %s, got %d", http.StatusBadRequest, query, w.Code)
+		}
+	}
+}
+
+// TestGetEventsSearch tests the q parameter of the getEvents handler
+func TestGetEventsSearch(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/events", testHandler.getEvents)
+
+	event := models.Event{
+		Title:       "Café Zürich 🎉",
+		Description: "Description",
+		Location:    "Zürich",
+		DateTime:    time.Date(2030, 6, 1, 18, 0, 0, 0, time.UTC),
+		UserID:      "user1",
+	}
+	if _, err := event.Save(context.Background()); err != nil {
+		t.Fatalf("Failed to insert test event: %v", err)
+	}
+
+	for query, expected := range map[string]int{"q=cafe+zurich": 1, "q=Caf%C3%A9": 1, "q=tokyo": 0} {
+		req, _ := http.NewRequest("GET", "/events?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Data []models.Event
+			Meta struct{ Total int }
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if w.Code != http.StatusOK || response.Meta.Total != expected {
+			t.Errorf("Expected %d events for %s, got status %d and %d events", expected, query, w.Code, response.Meta.Total)
+		}
+		if expected == 1 && response.Data[0].Slug != "cafe-zurich" {
+			t.Errorf("Expected slug cafe-zurich, got %q", response.Data[0].Slug)
+		}
+	}
+}
+
+// TestGetEventsSort tests the sort and order parameters of the getEvents handler
+func TestGetEventsSort(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/events", testHandler.getEvents)
+
+	base := time.Date(2030, 6, 1, 18, 0, 0, 0, time.UTC)
+	for i, title := range []string{"Bravo", "alpha", "Charlie"} {
+		event := models.Event{
+			Title:       title,
+			Description: "Description",
+			Location:    "Location",
+			DateTime:    base.AddDate(0, 0, i),
+			UserID:      "user1",
+			CreatedAt:   base.AddDate(0, 0, -i),
+		}
+		event, err := event.Save(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to insert test event: %v", err)
+		}
+	}
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{"", []string{"Bravo", "alpha", "Charlie"}},
+		{"sort=datetime&order=desc", []string{"Charlie", "alpha", "Bravo"}},
+		{"sort=title", []string{"alpha", "Bravo", "Charlie"}},
+		{"sort=created_at", []string{"Charlie", "alpha", "Bravo"}},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", "/events?"+tt.query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d for %s, got %d", http.StatusOK, tt.query, w.Code)
+		}
+		var response struct {
+			Data []models.Event
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if len(response.Data) != len(tt.expected) {
+			t.Fatalf("Expected %d events for %s, got %d", len(tt.expected), tt.query, len(response.Data))
+		}
+		for i, title := range tt.expected {
+			if response.Data[i].Title != title {
+				t.Errorf("Expected %s at position %d for %s, got %s", title, i, tt.query, response.Data[i].Title)
+			}
+		}
+	}
+
+	for _, query := range []string{"sort=user_id", "sort=name%3B%20DROP%20TABLE%20events", "order=up"} {
+		req, _ := http.NewRequest("GET", "/events?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d for %s, got %d", http.StatusBadRequest, query, w.Code)
+		}
+	}
+}
+
 // TestGetEvent tests the getEvent handler
 func TestGetEvent(t *testing.T) {
 	setupTestDatabase(t)
 	router := setupTestRouter()
-	router.GET("/events/:id", getEvent)
+	router.GET("/events/:id", testHandler.getEvent)
 
 	// Insert a test event
 	event := models.Event{
@@ -170,7 +687,7 @@ func TestGetEvent(t *testing.T) {
 		UserID:      "test-user-123",
 	}
 
-	err := event.Save()
+	event, err := event.Save(context.Background())
 	if err != nil {
 		t.Fatalf("Failed to save test event: %v", err)
 	}
@@ -186,8 +703,8 @@ func TestGetEvent(t *testing.T) {
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
-	if w.Code != http.StatusFound {
-		t.Errorf("Expected status code %d, got %d", http.StatusFound, w.Code)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
 	}
 
 	var response map[string]interface{}
@@ -198,9 +715,9 @@ func TestGetEvent(t *testing.T) {
 
 	t.Logf("Response body: %s", w.Body.String())
 
-	eventData, ok := response["event"]
+	eventData, ok := response["data"]
 	if !ok {
-		t.Error("Response should contain 'event' object")
+		t.Error("Response should contain a 'data' object")
 	}
 
 	eventMap, ok := eventData.(map[string]interface{})
@@ -208,8 +725,8 @@ func TestGetEvent(t *testing.T) {
 		t.Errorf("Expected event to be an object, got %T", eventData)
 	}
 
-	if eventMap["Title"] != event.Title {
-		t.Errorf("Expected title %s, got %v", event.Title, eventMap["Title"])
+	if eventMap["title"] != event.Title {
+		t.Errorf("Expected title %s, got %v", event.Title, eventMap["title"])
 	}
 }
 
@@ -217,7 +734,7 @@ func TestGetEvent(t *testing.T) {
 func TestGetEventNotFound(t *testing.T) {
 	setupTestDatabase(t)
 	router := setupTestRouter()
-	router.GET("/events/:id", getEvent)
+	router.GET("/events/:id", testHandler.getEvent)
 
 	req, _ := http.NewRequest("GET", "/events/non-existent-id", nil)
 	w := httptest.NewRecorder()
@@ -233,8 +750,119 @@ func TestGetEventNotFound(t *testing.T) {
 		t.Errorf("Failed to parse response JSON: %v", err)
 	}
 
-	if _, ok := response["error"]; !ok {
-		t.Error("Response should contain 'error' field")
+	if w.Header().Get("Content-Type") != problem.ContentType {
+		t.Errorf("Expected content type %s, got %s", problem.ContentType, w.Header().Get("Content-Type"))
+	}
+	if response["code"] != problem.CodeNotFound || response["status"] != float64(http.StatusNotFound) {
+		t.Errorf("Expected a not_found problem, got %v", response)
+	}
+	if _, ok := response["detail"]; !ok {
+		t.Error("Response should contain 'detail' field")
+	}
+}
+
+// TestGetEventAsOf tests historical reads of an event with as_of and Accept-Datetime
+func TestGetEventAsOf(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/events/:id", testHandler.getEvent)
+
+	event, err := models.Event{Title: "Original Title", Description: "Description", Location: "Location", DateTime: time.Now().Add(24 * time.Hour), UserID: "organizer-1"}.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to save event: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	original := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	event.Title = "Changed Title"
+	if err := event.Update(context.Background()); err != nil {
+		t.Fatalf("Failed to update event: %v", err)
+	}
+
+	get := func(query, userID, acceptDatetime string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/events/"+event.ID+query, nil)
+		if userID != "" {
+			req.Header.Set(UserHeader, userID)
+		}
+		if acceptDatetime != "" {
+			req.Header.Set("Accept-Datetime", acceptDatetime)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		acceptDatetime string
+		title          string
+		version        float64
+	}{
+		{"as_of", "?as_of=" + url.QueryEscape(original.Format(time.RFC3339Nano)), "", "Original Title", 1},
+		{"as_of now", "?as_of=" + url.QueryEscape(time.Now().Format(time.RFC3339Nano)), "", "Changed Title", 2},
+		{"Accept-Datetime", "", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), "Changed Title", 2},
+	}
+	for _, tt := range tests {
+		w := get(tt.query, "organizer-1", tt.acceptDatetime)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status code %d, got %d: %s", tt.name, http.StatusOK, w.Code, w.Body.String())
+		}
+		var response struct {
+			Data struct {
+				Event   models.Event `json:"event"`
+				Version float64      `json:"version"`
+			}
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response.Data.Event.Title != tt.title || response.Data.Version != tt.version {
+			t.Errorf("%s: expected %q at version %v, got %q at version %v", tt.name, tt.title, tt.version, response.Data.Event.Title, response.Data.Version)
+		}
+		if w.Header().Get("Memento-Datetime") == "" {
+			t.Errorf("%s: expected a Memento-Datetime header", tt.name)
+		}
+	}
+
+	if w := get("?as_of="+url.QueryEscape(original.Format(time.RFC3339Nano)), "someone-else", ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d for another user, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := get("?as_of=2000-01-01", "organizer-1", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d before the event existed, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := get("?as_of=yesterday", "organizer-1", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an invalid as_of, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := get("", "organizer-1", "Sat, 01 Jan 2000 00:00:00 GMT"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for an Accept-Datetime before the event existed, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := get("", "organizer-1", "yesterday"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an invalid Accept-Datetime, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	if err := event.Delete(context.Background()); err != nil {
+		t.Fatalf("Failed to delete event: %v", err)
+	}
+	if w := get("?as_of="+url.QueryEscape(time.Now().Format(time.RFC3339Nano)), "organizer-1", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d after deletion, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := get("?as_of="+url.QueryEscape(original.Format(time.RFC3339Nano)), "organizer-1", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected the history to outlive the event, got status code %d", w.Code)
+	}
+}
+
+// TestGetEventDatabaseError tests that getEvent reports lookup failures as server errors rather than 404
+func TestGetEventDatabaseError(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/events/:id", testHandler.getEvent)
+	testDB.Close()
+
+	req, _ := http.NewRequest("GET", "/events/some-id", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status code %d, got %d", http.StatusInternalServerError, w.Code)
 	}
 }
 
@@ -242,13 +870,13 @@ func TestGetEventNotFound(t *testing.T) {
 func TestCreateEvent(t *testing.T) {
 	setupTestDatabase(t)
 	router := setupTestRouter()
-	router.POST("/event", createEvent)
+	router.POST("/event", testHandler.createEvent)
 
 	eventData := map[string]interface{}{
 		"title":       "New Event",
 		"description": "New Description",
 		"location":    "New Location",
-		"datetime":    time.Now().Format(time.RFC3339),
+		"date_time":   time.Now().Format(time.RFC3339),
 	}
 
 	jsonData, _ := json.Marshal(eventData)
@@ -268,12 +896,188 @@ func TestCreateEvent(t *testing.T) {
 		t.Errorf("Failed to parse response JSON: %v", err)
 	}
 
-	if _, ok := response["message"]; !ok {
-		t.Error("Response should contain 'message' field")
+	if _, ok := response["data"]; !ok {
+		t.Error("Response should contain 'data' field")
 	}
 
-	if _, ok := response["event"]; !ok {
-		t.Error("Response should contain 'event' field")
+	var created struct{ Data models.Event }
+	json.Unmarshal(w.Body.Bytes(), &created)
+	var storedID string
+	err = testDB.QueryRow("SELECT id FROM events WHERE name = ?", "New Event").Scan(&storedID)
+	if err != nil {
+		t.Fatalf("Failed to get event ID: %v", err)
+	}
+	if created.Data.ID != storedID {
+		t.Errorf("Expected the response to carry the stored ID %s, got %s", storedID, created.Data.ID)
+	}
+}
+
+// TestCreateEventEndTime tests that events must end after they start
+func TestCreateEventEndTime(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.POST("/event", testHandler.createEvent)
+
+	start := time.Date(2030, 6, 1, 18, 0, 0, 0, time.UTC)
+	tests := []struct {
+		end      time.Time
+		expected int
+	}{
+		{start.Add(2 * time.Hour), http.StatusCreated},
+		{start, http.StatusBadRequest},
+		{start.Add(-time.Hour), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		jsonData, _ := json.Marshal(map[string]interface{}{
+			"title":         "New Event",
+			"description":   "New Description",
+			"location":      "New Location",
+			"date_time":     start.Format(time.RFC3339),
+			"end_date_time": tt.end.Format(time.RFC3339),
+		})
+		req, _ := http.NewRequest("POST", "/event", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.expected {
+			t.Errorf("Expected status code %d for an end at %s, got %d", tt.expected, tt.end, w.Code)
+		}
+		if tt.expected == http.StatusBadRequest && !strings.Contains(w.Body.String(), "end_date_time") {
+			t.Errorf("Expected the problem to name end_date_time, got %s", w.Body.String())
+		}
+	}
+}
+
+// TestEventStatus tests that drafts stay hidden from everyone but their organizer until
+// they are published, and that cancelled events take no bookings
+func TestEventStatus(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.POST("/event", testHandler.createEvent)
+	router.GET("/events", testHandler.getEvents)
+	router.GET("/events/:id", testHandler.getEvent)
+	router.PUT("/events/:id", testHandler.updateEvent)
+	router.PATCH("/events/:id", testHandler.patchEvent)
+	router.POST("/events/:id/publish", testHandler.publishEvent)
+	router.POST("/events/:id/cancel", testHandler.cancelEvent)
+	router.POST("/events/:id/register", testHandler.registerForEvent)
+
+	serve := func(method, path, userID string, body any) *httptest.ResponseRecorder {
+		jsonData, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		if userID != "" {
+			req.Header.Set(UserHeader, userID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	total := func(userID string) int {
+		var response struct {
+			Meta struct{ Total int }
+		}
+		json.Unmarshal(serve("GET", "/events", userID, nil).Body.Bytes(), &response)
+		return response.Meta.Total
+	}
+
+	w := serve("POST", "/event", "", map[string]interface{}{
+		"title":       "Draft Event",
+		"description": "Description",
+		"location":    "Location",
+		"date_time":   "2030-06-01T18:00:00Z",
+		"status":      "draft",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created struct{ Data models.Event }
+	json.Unmarshal(w.Body.Bytes(), &created)
+	event := created.Data
+	if event.Status != models.StatusDraft {
+		t.Fatalf("Expected a draft, got %q", event.Status)
+	}
+
+	if total("") != 0 || total("someone-else") != 0 {
+		t.Errorf("Expected the draft to be hidden from listings")
+	}
+	var listed struct{ Data []models.Event }
+	json.Unmarshal(serve("GET", "/events", event.UserID, nil).Body.Bytes(), &listed)
+	if len(listed.Data) != 1 {
+		t.Fatalf("Expected the draft to be listed for its organizer, got %d events", len(listed.Data))
+	}
+	event = listed.Data[0]
+	if w := serve("GET", "/events/"+event.ID, "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for a draft, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := serve("GET", "/events/"+event.ID, event.UserID, nil); w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d for the organizer, got %d", http.StatusOK, w.Code)
+	}
+	booking := map[string]interface{}{"name": "Alice", "email": "alice@example.com"}
+	if w := serve("POST", "/events/"+event.ID+"/register", "", booking); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d when booking a draft, got %d", http.StatusNotFound, w.Code)
+	}
+
+	for i := 0; i < 2; i++ {
+		w = serve("POST", "/events/"+event.ID+"/publish", "", nil)
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"status":"published"`) {
+			t.Errorf("Expected the event to be published, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+	if total("") != 1 {
+		t.Errorf("Expected the published event to be listed")
+	}
+
+	replacement := map[string]interface{}{
+		"title": event.Title, "description": event.Description, "location": event.Location,
+		"date_time": event.DateTime.Format(time.RFC3339),
+	}
+	statusChange := func(status string) []*httptest.ResponseRecorder {
+		replacement["status"] = status
+		return []*httptest.ResponseRecorder{
+			serve("PUT", "/events/"+event.ID, "", replacement),
+			serve("PATCH", "/events/"+event.ID, "", map[string]interface{}{"status": status}),
+		}
+	}
+	for _, w := range statusChange(models.StatusCancelled) {
+		var p problem.Problem
+		json.Unmarshal(w.Body.Bytes(), &p)
+		if w.Code != http.StatusConflict || p.Code != problem.CodeConflict {
+			t.Errorf("Expected status code %d when cancelling by update, got %d: %s", http.StatusConflict, w.Code, w.Body.String())
+		}
+	}
+	w = serve("POST", "/events/"+event.ID+"/cancel", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	for _, w := range statusChange(models.StatusPublished) {
+		var p problem.Problem
+		json.Unmarshal(w.Body.Bytes(), &p)
+		if w.Code != http.StatusConflict || p.Code != problem.CodeEventCancelled {
+			t.Errorf("Expected status code %d when uncancelling by update, got %d: %s", http.StatusConflict, w.Code, w.Body.String())
+		}
+	}
+	for _, path := range []string{"/register", "/publish"} {
+		w = serve("POST", "/events/"+event.ID+path, "", booking)
+		var p problem.Problem
+		json.Unmarshal(w.Body.Bytes(), &p)
+		if w.Code != http.StatusConflict || p.Code != problem.CodeEventCancelled {
+			t.Errorf("%s: expected status code %d with code %s, got %d: %s", path, http.StatusConflict, problem.CodeEventCancelled, w.Code, w.Body.String())
+		}
+	}
+	if w := serve("GET", "/events/"+event.ID, "", nil); w.Code != http.StatusOK {
+		t.Errorf("Expected cancelled events to stay visible, got %d", w.Code)
+	}
+
+	if w := serve("GET", "/events?status=postponed", "", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an unknown status filter, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := serve("PATCH", "/events/"+event.ID, "", map[string]interface{}{"status": "postponed"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an unknown status, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := serve("POST", "/events/missing/publish", "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for an unknown event, got %d", http.StatusNotFound, w.Code)
 	}
 }
 
@@ -281,7 +1085,7 @@ func TestCreateEvent(t *testing.T) {
 func TestCreateEventInvalidJSON(t *testing.T) {
 	setupTestDatabase(t)
 	router := setupTestRouter()
-	router.POST("/event", createEvent)
+	router.POST("/event", testHandler.createEvent)
 
 	invalidJSON := `{"title": "Test"}` // Missing required fields
 	req, _ := http.NewRequest("POST", "/event", bytes.NewBufferString(invalidJSON))
@@ -295,11 +1099,58 @@ func TestCreateEventInvalidJSON(t *testing.T) {
 	}
 }
 
+// TestCreateEventQuota tests that free users get a quota error at the active event limit
+func TestCreateEventQuota(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.POST("/event", testHandler.createEvent)
+
+	oldLimit := models.FreeEventLimit
+	t.Cleanup(func() { models.FreeEventLimit = oldLimit })
+	models.FreeEventLimit = 1
+
+	create := func(userID string) *httptest.ResponseRecorder {
+		jsonData, _ := json.Marshal(map[string]interface{}{
+			"title":       "New Event",
+			"description": "New Description",
+			"location":    "New Location",
+			"date_time":   time.Now().Add(24 * time.Hour).Format(time.RFC3339),
+		})
+		req, _ := http.NewRequest("POST", "/event", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		if userID != "" {
+			req.Header.Set(UserHeader, userID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := create(""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d without a user ID, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := create("free-user"); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d within the limit, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	w := create("free-user")
+	if w.Code != http.StatusPaymentRequired {
+		t.Fatalf("Expected status code %d over the limit, got %d", http.StatusPaymentRequired, w.Code)
+	}
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if response["code"] != problem.CodeQuotaExceeded || response["usage"] != float64(1) || response["limit"] != float64(1) {
+		t.Errorf("Expected quota_exceeded with usage 1 and limit 1, got %v", response)
+	}
+}
+
 // TestUpdateEvent tests the updateEvent handler
 func TestUpdateEvent(t *testing.T) {
 	setupTestDatabase(t)
 	router := setupTestRouter()
-	router.PUT("/events/:id", updateEvent)
+	router.PUT("/events/:id", testHandler.updateEvent)
 
 	// Insert a test event
 	event := models.Event{
@@ -310,7 +1161,7 @@ func TestUpdateEvent(t *testing.T) {
 		UserID:      "test-user-123",
 	}
 
-	err := event.Save()
+	event, err := event.Save(context.Background())
 	if err != nil {
 		t.Fatalf("Failed to save test event: %v", err)
 	}
@@ -327,7 +1178,7 @@ func TestUpdateEvent(t *testing.T) {
 		"title":       "Updated Title",
 		"description": "Updated Description",
 		"location":    "Updated Location",
-		"datetime":    time.Now().Format(time.RFC3339),
+		"date_time":   time.Now().Format(time.RFC3339),
 	}
 
 	jsonData, _ := json.Marshal(updateData)
@@ -347,12 +1198,251 @@ func TestUpdateEvent(t *testing.T) {
 		t.Errorf("Failed to parse response JSON: %v", err)
 	}
 
-	if _, ok := response["message"]; !ok {
-		t.Error("Response should contain 'message' field")
+	if _, ok := response["data"]; !ok {
+		t.Error("Response should contain 'data' field")
+	}
+}
+
+// TestUpdateEventVersion tests that updates sending an outdated version are refused
+func TestUpdateEventVersion(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.PUT("/events/:id", testHandler.updateEvent)
+
+	event, err := models.Event{Title: "Meetup", Description: "Talks", Location: "Hall", DateTime: time.Now().Add(time.Hour)}.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to save test event: %v", err)
+	}
+	update := func(location string, version int) *httptest.ResponseRecorder {
+		jsonData, _ := json.Marshal(map[string]interface{}{
+			"title": "Meetup", "description": "Talks", "location": location,
+			"date_time": event.DateTime.Format(time.RFC3339), "version": version,
+		})
+		req, _ := http.NewRequest("PUT", "/events/"+event.ID, bytes.NewBuffer(jsonData))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := update("Garden", event.Version)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response struct {
+		Data models.Event
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Data.Version != event.Version+1 {
+		t.Errorf("Expected the response to carry version %d, got %d", event.Version+1, response.Data.Version)
+	}
+
+	w = update("Rooftop", event.Version)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Expected status code %d for a stale version, got %d", http.StatusConflict, w.Code)
+	}
+	var body map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if body["code"] != problem.CodeVersionConflict || body["version"] != float64(response.Data.Version) {
+		t.Errorf("Expected a version conflict naming version %d, got %v", response.Data.Version, body)
+	}
+	stored, _ := models.GetEventById(context.Background(), event.ID)
+	if stored.Location != "Garden" {
+		t.Errorf("Expected the stale update to be refused, got location %q", stored.Location)
+	}
+}
+
+// TestPatchEventPreconditions tests that patches based on an outdated version or ETag
+// are refused, as updates are
+func TestPatchEventPreconditions(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/events/:id", testHandler.getEvent)
+	router.PATCH("/events/:id", testHandler.patchEvent)
+
+	event, err := models.Event{Title: "Meetup", Description: "Talks", Location: "Hall", DateTime: time.Now().Add(time.Hour)}.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to save test event: %v", err)
+	}
+	request := func(method string, body map[string]interface{}, ifMatch string) *httptest.ResponseRecorder {
+		jsonData, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, "/events/"+event.ID, bytes.NewBuffer(jsonData))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	etag := request("GET", nil, "").Header().Get("ETag")
+	w := request("PATCH", map[string]interface{}{"location": "Garden", "version": event.Version}, etag)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response struct {
+		Data models.Event
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Data.Version != event.Version+1 {
+		t.Errorf("Expected the response to carry version %d, got %d", event.Version+1, response.Data.Version)
+	}
+
+	w = request("PATCH", map[string]interface{}{"location": "Rooftop", "version": event.Version}, "")
+	var body map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if w.Code != http.StatusConflict || body["code"] != problem.CodeVersionConflict || body["version"] != float64(response.Data.Version) {
+		t.Errorf("Expected a version conflict naming version %d, got %d %v", response.Data.Version, w.Code, body)
+	}
+	w = request("PATCH", map[string]interface{}{"location": "Rooftop"}, etag)
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if w.Code != http.StatusPreconditionFailed || body["code"] != problem.CodePreconditionFailed {
+		t.Errorf("Expected 412 for an outdated ETag, got %d %v", w.Code, body)
+	}
+	stored, _ := models.GetEventById(context.Background(), event.ID)
+	if stored.Location != "Garden" {
+		t.Errorf("Expected the outdated patches to be refused, got location %q", stored.Location)
+	}
+}
+
+// racingEventRepository changes each event just before an update or patch is written,
+// as a concurrent request would between the handler's checks and the write
+type racingEventRepository struct {
+	models.EventRepository
+}
+
+func (r racingEventRepository) race(ctx context.Context, id string) error {
+	return models.Event{ID: id}.Patch(ctx, map[string]any{"Description": "Changed meanwhile"})
+}
+
+func (r racingEventRepository) Update(ctx context.Context, e models.Event) error {
+	if err := r.race(ctx, e.ID); err != nil {
+		return err
+	}
+	return r.EventRepository.Update(ctx, e)
+}
+
+func (r racingEventRepository) Patch(ctx context.Context, e models.Event, fields map[string]any) error {
+	if err := r.race(ctx, e.ID); err != nil {
+		return err
+	}
+	return r.EventRepository.Patch(ctx, e, fields)
+}
+
+// TestUpdateEventRace tests that updates and patches passing their preconditions are
+// refused when the event changes before they are written, rather than overwriting the change
+func TestUpdateEventRace(t *testing.T) {
+	setupTestDatabase(t)
+	h := NewEventHandler(racingEventRepository{models.NewSQLEventRepository()})
+	router := setupTestRouter()
+	router.GET("/events/:id", h.getEvent)
+	router.PUT("/events/:id", h.updateEvent)
+	router.PATCH("/events/:id", h.patchEvent)
+
+	event, err := models.Event{Title: "Meetup", Description: "Talks", Location: "Hall", DateTime: time.Now().Add(time.Hour)}.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to save test event: %v", err)
+	}
+	request := func(method string, body map[string]interface{}, ifMatch string) *httptest.ResponseRecorder {
+		jsonData, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, "/events/"+event.ID, bytes.NewBuffer(jsonData))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		method string
+		body   map[string]interface{}
+	}{
+		{"PUT", map[string]interface{}{
+			"title": "Meetup", "description": "Talks", "location": "Garden",
+			"date_time": event.DateTime.Format(time.RFC3339),
+		}},
+		{"PATCH", map[string]interface{}{"location": "Garden"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			etag := request("GET", nil, "").Header().Get("ETag")
+			w := request(tt.method, tt.body, etag)
+			var body map[string]interface{}
+			json.Unmarshal(w.Body.Bytes(), &body)
+			if w.Code != http.StatusConflict || body["code"] != problem.CodeVersionConflict {
+				t.Fatalf("Expected a version conflict, got %d: %s", w.Code, w.Body.String())
+			}
+			stored, _ := models.GetEventById(context.Background(), event.ID)
+			if stored.Location != "Hall" || stored.Description != "Changed meanwhile" {
+				t.Errorf("Expected the concurrent change to be kept, got location %q and description %q", stored.Location, stored.Description)
+			}
+		})
+	}
+}
+
+// TestPatchEvent tests the patchEvent handler with partial bodies
+func TestPatchEvent(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.PATCH("/events/:id", testHandler.patchEvent)
+
+	event := models.Event{
+		Title:       "Original Title",
+		Description: "Original Description",
+		Location:    "Original Location",
+		DateTime:    time.Now(),
+		UserID:      "test-user-123",
+	}
+	event, err := event.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to save test event: %v", err)
+	}
+	var id string
+	err = testDB.QueryRow("SELECT id FROM events WHERE name = ?", event.Title).Scan(&id)
+	if err != nil {
+		t.Fatalf("Failed to get event ID: %v", err)
 	}
 
-	if _, ok := response["event"]; !ok {
-		t.Error("Response should contain 'event' field")
+	patch := func(id string, body map[string]interface{}) *httptest.ResponseRecorder {
+		jsonData, _ := json.Marshal(body)
+		req, _ := http.NewRequest("PATCH", "/events/"+id, bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := patch(id, map[string]interface{}{"capacity": 40, "location": "New Location", "license": " CC-BY-4.0 "})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	patched, err := models.GetEventById(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Failed to get patched event: %v", err)
+	}
+	if patched.Capacity != 40 || patched.Location != "New Location" || patched.Title != "Original Title" || patched.License != "CC-BY-4.0" {
+		t.Errorf("Unexpected patched event %+v", patched)
+	}
+
+	tests := []struct {
+		name string
+		body map[string]interface{}
+	}{
+		{"empty required field", map[string]interface{}{"title": ""}},
+		{"negative capacity", map[string]interface{}{"capacity": -1}},
+		{"wrong type", map[string]interface{}{"capacity": "many"}},
+		{"protected field", map[string]interface{}{"review_status": models.ReviewApproved}},
+		{"invalid confirmation URL", map[string]interface{}{"confirmation_url": "javascript:alert(1)"}},
+		{"invalid license", map[string]interface{}{"license": "free to copy"}},
+	}
+	for _, tt := range tests {
+		if w := patch(id, tt.body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status code %d, got %d", tt.name, http.StatusBadRequest, w.Code)
+		}
+	}
+
+	if w := patch("non-existent-id", map[string]interface{}{"title": "Title"}); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
 	}
 }
 
@@ -360,13 +1450,13 @@ func TestUpdateEvent(t *testing.T) {
 func TestUpdateEventNotFound(t *testing.T) {
 	setupTestDatabase(t)
 	router := setupTestRouter()
-	router.PUT("/events/:id", updateEvent)
+	router.PUT("/events/:id", testHandler.updateEvent)
 
 	updateData := map[string]interface{}{
 		"title":       "Updated Title",
 		"description": "Updated Description",
 		"location":    "Updated Location",
-		"datetime":    time.Now().Format(time.RFC3339),
+		"date_time":   time.Now().Format(time.RFC3339),
 	}
 
 	jsonData, _ := json.Marshal(updateData)
@@ -385,7 +1475,7 @@ func TestUpdateEventNotFound(t *testing.T) {
 func TestDeleteEvent(t *testing.T) {
 	setupTestDatabase(t)
 	router := setupTestRouter()
-	router.DELETE("/events/:id", deleteEvent)
+	router.DELETE("/events/:id", testHandler.deleteEvent)
 
 	// Insert a test event
 	event := models.Event{
@@ -396,7 +1486,7 @@ func TestDeleteEvent(t *testing.T) {
 		UserID:      "test-user-123",
 	}
 
-	err := event.Save()
+	event, err := event.Save(context.Background())
 	if err != nil {
 		t.Fatalf("Failed to save test event: %v", err)
 	}
@@ -422,7 +1512,7 @@ func TestDeleteEvent(t *testing.T) {
 		t.Errorf("Failed to parse response JSON: %v", err)
 	}
 
-	if _, ok := response["message"]; !ok {
+	if data, _ := response["data"].(map[string]interface{}); data["message"] == nil {
 		t.Error("Response should contain 'message' field")
 	}
 
@@ -441,7 +1531,7 @@ func TestDeleteEvent(t *testing.T) {
 func TestDeleteEventNotFound(t *testing.T) {
 	setupTestDatabase(t)
 	router := setupTestRouter()
-	router.DELETE("/events/:id", deleteEvent)
+	router.DELETE("/events/:id", testHandler.deleteEvent)
 
 	req, _ := http.NewRequest("DELETE", "/events/non-existent-id", nil)
 	w := httptest.NewRecorder()
@@ -451,3 +1541,68 @@ func TestDeleteEventNotFound(t *testing.T) {
 		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
 	}
 }
+
+// TestGetMyEvents tests that organizers list only their own events, drafts included,
+// with the status filters and pagination
+func TestGetMyEvents(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/users/me/events", testHandler.getMyEvents)
+
+	ctx := context.Background()
+	for i, e := range []models.Event{
+		{Title: "Published", Status: models.StatusPublished, UserID: "organizer-1"},
+		{Title: "Draft", Status: models.StatusDraft, UserID: "organizer-1"},
+		{Title: "Held", Status: models.StatusPublished, ReviewStatus: models.ReviewPending, UserID: "organizer-1"},
+		{Title: "Someone Else's", Status: models.StatusPublished, UserID: "organizer-2"},
+	} {
+		e.Description, e.Location, e.DateTime = "Description", "Location", time.Now().Add(time.Duration(i)*time.Hour)
+		_, err := e.Save(ctx)
+		if err != nil {
+			t.Fatalf("Failed to save event: %v", err)
+		}
+	}
+
+	list := func(query, userID string) (*httptest.ResponseRecorder, []string, float64) {
+		req, _ := http.NewRequest("GET", "/users/me/events"+query, nil)
+		if userID != "" {
+			req.Header.Set(UserHeader, userID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response struct {
+			Data []models.Event
+			Meta struct{ Total float64 }
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		var titles []string
+		for _, e := range response.Data {
+			titles = append(titles, e.Title)
+		}
+		return w, titles, response.Meta.Total
+	}
+
+	tests := []struct {
+		query    string
+		expected string
+		total    float64
+	}{
+		{"", "Published,Draft,Held", 3},
+		{"?status=draft", "Draft", 1},
+		{"?review_status=pending_review", "Held", 1},
+		{"?limit=1&offset=1", "Draft", 3},
+	}
+	for _, tt := range tests {
+		w, titles, total := list(tt.query, "organizer-1")
+		if w.Code != http.StatusOK || strings.Join(titles, ",") != tt.expected || total != tt.total {
+			t.Errorf("%q: expected %s of %v, got %d %v of %v: %s", tt.query, tt.expected, tt.total, w.Code, titles, total, w.Body.String())
+		}
+	}
+
+	if w, _, _ := list("?status=archived", "organizer-1"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an invalid status, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w, _, _ := list("", ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d without a user ID, got %d", http.StatusForbidden, w.Code)
+	}
+}
@@ -0,0 +1,101 @@
+package routes
+
+import (
+	"event_booking_restapi_golang/experiments"
+	"event_booking_restapi_golang/logging"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// SessionHeader carries the anonymous session ID used to bucket clients into experiments.
+// Clients that don't send it are identified by the session cookie instead.
+const SessionHeader = "X-Session-ID"
+
+// sessionCookie stores the anonymous session ID for browser clients.
+const sessionCookie = "session_id"
+
+// rankingExperiment serves an alternative event listing order to part of the sessions.
+// It is set up from RANKING_EXPERIMENT_PERCENT and RANKING_EXPERIMENT_SORT when the routes are registered.
+var rankingExperiment experiments.Ranking
+
+// sessionID returns the client's anonymous session ID from the X-Session-ID header or the
+// session cookie. With create set, a new session is started and its cookie sent when the
+// client has none; otherwise an empty string is returned.
+func sessionID(c *gin.Context, create bool) string {
+	if id := c.GetHeader(SessionHeader); id != "" {
+		return id
+	}
+	if id, err := c.Cookie(sessionCookie); err == nil && id != "" {
+		return id
+	}
+	if !create {
+		return ""
+	}
+	id := uuid.NewString()
+	c.SetCookie(sessionCookie, id, 30*24*60*60, "/", "", false, true)
+	return id
+}
+
+// applyRankingExperiment assigns the session to a variant of the listing order experiment
+// and switches the filter to the alternative order for the treatment group. Listings with an
+// explicit sort are left alone and not counted. The variant is sent in the X-Experiment-Variant
+// header. Returns the session and variant, which are empty when the experiment doesn't apply.
+func applyRankingExperiment(c *gin.Context, filter *models.EventFilter) (string, string) {
+	if !rankingExperiment.Enabled() || filter.Sort != "" {
+		return "", ""
+	}
+	session := sessionID(c, true)
+	variant := rankingExperiment.Variant(session)
+	if variant == experiments.Treatment {
+		filter.Sort, filter.Order = rankingExperiment.Sort, rankingExperiment.Order
+	}
+	c.Header("X-Experiment-Variant", variant)
+	return session, variant
+}
+
+// logExposure records that the session was served its listing order variant.
+// Failures are logged rather than failing the listing.
+func logExposure(c *gin.Context, session, variant string) {
+	if variant == "" {
+		return
+	}
+	err := models.LogExposure(c.Request.Context(), rankingExperiment.Name, variant, session)
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error("Couldn't log experiment exposure", "error", err)
+	}
+}
+
+// logConversion records a booking by the client's session against the listing order
+// experiment, if the session was exposed to it. Failures are logged rather than failing the booking.
+func logConversion(c *gin.Context, eventID string) {
+	session := sessionID(c, false)
+	if session == "" {
+		return
+	}
+	err := models.LogConversion(c.Request.Context(), experiments.RankingExperimentName, session, eventID)
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error("Couldn't log experiment conversion", "error", err)
+	}
+}
+
+// getExperimentResults handles GET requests to /admin/experiments/:name endpoint.
+// It reports the sessions, exposures, conversions and conversion rate of each variant,
+// so a ranking change can be evaluated before it is rolled out.
+// Returns HTTP 500 if the query fails, otherwise HTTP 200 with the results.
+func getExperimentResults(c *gin.Context) {
+	name, _ := c.Params.Get("name")
+	results, err := models.GetExperimentResults(c.Request.Context(), name)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	response.Respond(c, http.StatusOK, gin.H{
+		"experiment": name,
+		"variants":   results,
+	})
+}
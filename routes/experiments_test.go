@@ -0,0 +1,107 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/experiments"
+	"event_booking_restapi_golang/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestRankingExperiment tests that treatment sessions get the alternative order and that bookings count as conversions
+func TestRankingExperiment(t *testing.T) {
+	setupTestDatabase(t)
+	original := rankingExperiment
+	rankingExperiment = experiments.Ranking{
+		Experiment: experiments.Experiment{Name: experiments.RankingExperimentName, Percent: 100},
+		Sort:       "title",
+	}
+	defer func() { rankingExperiment = original }()
+
+	router := setupTestRouter()
+	router.GET("/events", testHandler.getEvents)
+	router.POST("/events/:id/register", testHandler.registerForEvent)
+	router.GET("/admin/experiments/:name", getExperimentResults)
+
+	for i, title := range []string{"Zumba", "Aerobics"} {
+		event := models.Event{
+			Title:       title,
+			Description: "Test Description",
+			Location:    "Test Location",
+			DateTime:    time.Now().Add(time.Duration(i) * time.Hour),
+		}
+		if _, err := event.Save(context.Background()); err != nil {
+			t.Fatalf("Failed to save test event: %v", err)
+		}
+	}
+
+	list := func(query string) (*httptest.ResponseRecorder, []models.Event) {
+		req, _ := http.NewRequest("GET", "/events"+query, nil)
+		req.Header.Set(SessionHeader, "session-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response struct{ Data []models.Event }
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response.Data
+	}
+
+	w, events := list("")
+	if w.Header().Get("X-Experiment-Variant") != experiments.Treatment || len(events) != 2 || events[0].Title != "Aerobics" {
+		t.Fatalf("Expected the treatment order by title, got %q and %+v", w.Header().Get("X-Experiment-Variant"), events)
+	}
+	w, events = list("?sort=datetime")
+	if w.Header().Get("X-Experiment-Variant") != "" || events[0].Title != "Zumba" {
+		t.Errorf("Expected an explicit sort to bypass the experiment, got %q and %+v", w.Header().Get("X-Experiment-Variant"), events)
+	}
+
+	jsonData, _ := json.Marshal(map[string]interface{}{"name": "Attendee", "email": "attendee@example.com"})
+	req, _ := http.NewRequest("POST", "/events/"+events[0].ID+"/register", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SessionHeader, "session-1")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	req, _ = http.NewRequest("GET", "/admin/experiments/"+experiments.RankingExperimentName, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var results struct {
+		Data struct{ Variants []models.VariantResult }
+	}
+	json.Unmarshal(w.Body.Bytes(), &results)
+	if len(results.Data.Variants) != 1 || results.Data.Variants[0].Variant != experiments.Treatment || results.Data.Variants[0].Exposures != 1 || results.Data.Variants[0].Conversions != 1 {
+		t.Errorf("Unexpected experiment results %s", w.Body.String())
+	}
+}
+
+// TestSessionCookie tests that clients without a session get one in a cookie
+func TestSessionCookie(t *testing.T) {
+	router := setupTestRouter()
+	router.GET("/session", func(c *gin.Context) {
+		c.String(http.StatusOK, sessionID(c, true))
+	})
+
+	req, _ := http.NewRequest("GET", "/session", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != sessionCookie || cookies[0].Value != w.Body.String() {
+		t.Fatalf("Expected a session cookie matching %q, got %v", w.Body.String(), cookies)
+	}
+
+	req, _ = http.NewRequest("GET", "/session", nil)
+	req.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Body.String() != cookies[0].Value || len(w.Result().Cookies()) != 0 {
+		t.Errorf("Expected the cookie session to be reused, got %q", w.Body.String())
+	}
+}
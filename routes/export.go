@@ -0,0 +1,99 @@
+package routes
+
+import (
+	"encoding/json"
+	"event_booking_restapi_golang/ids"
+	"event_booking_restapi_golang/logging"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportFlushEvery is the number of lines written between flushes of a bulk export,
+// so consumers get a steady stream without a flush per line.
+const exportFlushEvery = 100
+
+// exportLine is a line of a bulk export: an event, a registration, or the error that
+// cut the export short.
+type exportLine struct {
+	Type   string `json:"type"`             // event, registration or error
+	Data   any    `json:"data,omitempty"`   // The event or registration
+	Detail string `json:"detail,omitempty"` // What went wrong, for errors
+}
+
+// exportWriter writes the lines of a bulk export as newline-delimited JSON.
+type exportWriter struct {
+	c       *gin.Context
+	written int
+}
+
+// write encodes the line, with entity IDs encoded as public identifiers as in other
+// JSON responses, and flushes the response every exportFlushEvery lines.
+func (w *exportWriter) write(line exportLine) error {
+	encoded, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+	if _, plain := ids.Public.(ids.Plain); !plain {
+		encoded, err = rewriteIDs(encoded, ids.Public.Encode)
+		if err != nil {
+			return err
+		}
+	}
+	if w.written == 0 {
+		w.c.Header("Content-Type", "application/x-ndjson")
+		w.c.Status(http.StatusOK)
+	}
+	_, err = w.c.Writer.Write(append(encoded, '\n'))
+	if err != nil {
+		return err
+	}
+	w.written++
+	if w.written%exportFlushEvery == 0 {
+		w.c.Writer.Flush()
+	}
+	return nil
+}
+
+// exportEvents handles GET requests to /admin/export/events endpoint.
+// It streams every event, drafts and events under review included, as
+// newline-delimited JSON for analytics pipelines, one {"type": "event", "data": ...}
+// object per line. With ?include=registrations every registration follows as a
+// "registration" line. Rows are read from the database as they are written, so the
+// export never holds more than one of them in memory.
+// Returns HTTP 400 for an unknown include, HTTP 500 if the export fails before its
+// first line, otherwise HTTP 200 with the lines. Failures later on can't change the
+// status any more, so they end the stream with an "error" line instead.
+func exportEvents(c *gin.Context) {
+	include := c.Query("include")
+	if include != "" && include != "registrations" {
+		problem.Write(c, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "include must be registrations").With("field", "include"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	w := &exportWriter{c: c}
+	err := models.EachEvent(ctx, func(event models.Event) error {
+		return w.write(exportLine{Type: "event", Data: event})
+	})
+	if err == nil && include == "registrations" {
+		err = models.EachRegistration(ctx, func(r models.Registration) error {
+			return w.write(exportLine{Type: "registration", Data: r})
+		})
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Couldn't finish the bulk export", "lines", w.written, "error", err)
+		if w.written == 0 {
+			problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, "couldn't export events: "+err.Error())
+			return
+		}
+		w.write(exportLine{Type: "error", Detail: "the export stopped early: " + err.Error()})
+		return
+	}
+	if w.written == 0 {
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Status(http.StatusOK)
+	}
+}
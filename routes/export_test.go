@@ -0,0 +1,76 @@
+package routes
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestExportEvents tests streaming events and registrations as newline-delimited JSON
+func TestExportEvents(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/admin/export/events", exportEvents)
+
+	ctx := context.Background()
+	published, err := models.Event{Title: "Jazz Night", Description: "Live music", Location: "Club", DateTime: time.Now().Add(24 * time.Hour)}.Save(ctx)
+	if err != nil {
+		t.Fatalf("Failed to save test event: %v", err)
+	}
+	_, err = models.Event{Title: "Draft", Description: "Not yet", Location: "Club", DateTime: time.Now().Add(48 * time.Hour), Status: models.StatusDraft}.Save(ctx)
+	if err != nil {
+		t.Fatalf("Failed to save test event: %v", err)
+	}
+	_, err = published.Register(ctx, models.Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	export := func(query string) (*httptest.ResponseRecorder, map[string]int) {
+		req, _ := http.NewRequest("GET", "/admin/export/events"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		types := map[string]int{}
+		if w.Code != http.StatusOK {
+			return w, types
+		}
+		scanner := bufio.NewScanner(w.Body)
+		for scanner.Scan() {
+			var line struct {
+				Type string
+				Data map[string]any
+			}
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				t.Fatalf("Expected a JSON object per line, got %q: %v", scanner.Text(), err)
+			}
+			if line.Data == nil {
+				t.Errorf("Expected the %s in data, got %q", line.Type, scanner.Text())
+			}
+			types[line.Type]++
+		}
+		return w, types
+	}
+
+	w, types := export("")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("Expected an NDJSON stream, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if types["event"] != 2 || types["registration"] != 0 {
+		t.Errorf("Expected both events, the draft included, and no registrations, got %v", types)
+	}
+
+	_, types = export("?include=registrations")
+	if types["event"] != 2 || types["registration"] != 1 {
+		t.Errorf("Expected both events and the registration, got %v", types)
+	}
+
+	w, _ = export("?include=tickets")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an unknown include, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,165 @@
+package routes
+
+import (
+	"database/sql"
+	"errors"
+	"event_booking_restapi_golang/ids"
+	"event_booking_restapi_golang/middlewares"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SyncKeys are the keys of the partner systems that sync events and bookings by
+// external ID, by source, from EXTERNAL_SYNC_KEYS. It is set by main.
+var SyncKeys map[string]string
+
+// requireSyncKey is middleware for the endpoints syncing by external ID. It only lets
+// through the partner system of the :source path parameter, with its key from SyncKeys
+// as a bearer token, and admins, so nobody else can rewrite synced events and bookings.
+// Responds HTTP 401 to other callers.
+func requireSyncKey(c *gin.Context) {
+	if !middlewares.HasBearerToken(c, SyncKeys[c.Param("source")]) && !middlewares.IsAdmin(c, adminAPIKey) {
+		problem.Respond(c, http.StatusUnauthorized, problem.CodeUnauthorized,
+			"syncing by external ID requires the key of the source or the admin API key as a bearer token")
+		return
+	}
+	c.Next()
+}
+
+// upsertExternalEvent handles PUT requests to /events/by-external-id/:source/:external_id endpoint.
+// It creates or updates, atomically, the event a partner system such as a ticketing
+// platform knows under the external ID, so nightly syncs don't create duplicates. The
+// body is the event as for POST /event; the content policy and moderation apply as on
+// creation and update. The response's created field tells which one happened.
+// Returns HTTP 400 if the request or confirmation settings are invalid, HTTP 422 if the
+// content policy blocks the event, HTTP 500 if storing fails, otherwise HTTP 201 with a
+// new event or HTTP 200 with the updated one.
+func (h *EventHandler) upsertExternalEvent(c *gin.Context) {
+	source, externalID := c.Param("source"), c.Param("external_id")
+	var input models.Event
+	err := c.ShouldBindJSON(&input)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+	input.ExternalID, input.Source = externalID, source
+
+	// The event is prepared as a new or updated one from its current state; the upsert
+	// below decides atomically which one it becomes.
+	existing, err := h.events.GetEventByExternalID(c.Request.Context(), source, externalID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	if err != nil {
+		input.ID = ids.New()
+		input.UserID = ids.New()
+		input.CreatedAt = time.Now()
+		input.ReviewStatus, input.ReviewReason = models.ReviewApproved, ""
+		if moderationEnabled {
+			input.ReviewStatus = models.ReviewPending
+		}
+	} else {
+		input.ID, input.UserID, input.CreatedAt = existing.ID, existing.UserID, existing.CreatedAt
+		input.ReviewStatus, input.ReviewReason = existing.ReviewStatus, existing.ReviewReason
+	}
+	if !checkEndTime(c, input) {
+		return
+	}
+	if !cleanConfirmation(c, &input) {
+		return
+	}
+	if !cleanLicense(c, &input) {
+		return
+	}
+	if !screenContent(c, &input) {
+		return
+	}
+
+	event, created, err := h.events.UpsertByExternalID(c.Request.Context(), input)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	status, message := http.StatusOK, "Event updated successfully"
+	if created {
+		status, message = http.StatusCreated, "A new event has been created successfully"
+	}
+	response.Respond(c, status, gin.H{
+		"message": message,
+		"event":   event,
+		"created": created,
+	})
+}
+
+// upsertExternalRegistration handles PUT requests to
+// /events/by-external-id/:source/:external_id/registrations/:registration_id endpoint.
+// It books a place on the synced event for the attendee the partner system knows under
+// the registration's external ID, or updates their name and email address when it was
+// synced before. The body is the attendee's Name and Email; the response's created
+// field tells which one happened.
+// Capacity and blocks apply to new bookings; the CAPTCHA and waiver checks don't, as
+// the booking was made in the external system.
+// Returns HTTP 404 if the event is not found, HTTP 400 if the request is invalid, HTTP 403
+// if the organizer blocked the attendee, HTTP 409 if the event is full, the email address
+// already holds another place or the external ID belongs to a booking for another event,
+// HTTP 500 if storing fails, otherwise HTTP 201 with a new registration or HTTP 200 with
+// the updated one.
+func (h *EventHandler) upsertExternalRegistration(c *gin.Context) {
+	source := c.Param("source")
+	event, err := h.events.GetEventByExternalID(c.Request.Context(), source, c.Param("external_id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	var input models.Registration
+	err = c.ShouldBindJSON(&input)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	externalID := c.Param("registration_id")
+	existing, err := models.GetRegistrationByExternalID(c.Request.Context(), source, externalID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	status := http.StatusOK
+	registration := existing
+	if err != nil {
+		status = http.StatusCreated
+		registration, err = event.Register(c.Request.Context(), models.Registration{
+			Name:       input.Name,
+			Email:      input.Email,
+			ExternalID: externalID,
+			Source:     source,
+		})
+	} else if existing.EventID != event.ID {
+		problem.Respond(c, http.StatusConflict, problem.CodeExternalIDConflict,
+			"the external ID "+externalID+" belongs to a registration for another event")
+		return
+	} else {
+		registration, err = existing.UpdateAttendee(c.Request.Context(), input.Name, input.Email)
+	}
+	if respondIfBlocked(c, err) {
+		return
+	}
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if status == http.StatusCreated {
+		Live.Notify(event.ID)
+		Alerts.Check(c.Request.Context(), event)
+	}
+	response.Respond(c, status, gin.H{
+		"registration": registration,
+		"created":      status == http.StatusCreated,
+	})
+}
@@ -0,0 +1,163 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestUpsertExternalEvent tests that syncing an event twice updates it instead of duplicating it
+func TestUpsertExternalEvent(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.PUT("/events/by-external-id/:source/:external_id", testHandler.upsertExternalEvent)
+
+	put := func(title string) (*httptest.ResponseRecorder, models.Event, bool) {
+		body := `{"title":"` + title + `","description":"Live jazz","location":"Club","date_time":"2030-01-01T20:00:00Z"}`
+		req, _ := http.NewRequest("PUT", "/events/by-external-id/tickets/evt-1", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response struct {
+			Data struct {
+				Event   models.Event
+				Created bool `json:"created"`
+			}
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response.Data.Event, response.Data.Created
+	}
+
+	w, created, wasCreated := put("Jazz Night")
+	if w.Code != http.StatusCreated || !wasCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	if created.ID == "" || created.ExternalID != "evt-1" || created.Source != "tickets" {
+		t.Errorf("Expected the stored event with its external ID, got %+v", created)
+	}
+
+	w, updated, wasCreated := put("Jazz Night (late show)")
+	if w.Code != http.StatusOK || wasCreated || updated.ID != created.ID {
+		t.Fatalf("Expected event %s to be updated, got %d %s", created.ID, w.Code, w.Body.String())
+	}
+	var count int
+	testDB.QueryRow("SELECT COUNT(*) FROM events").Scan(&count)
+	if count != 1 {
+		t.Errorf("Expected 1 event after syncing twice, got %d", count)
+	}
+	stored, _ := models.GetEventById(context.Background(), created.ID)
+	if stored.Title != "Jazz Night (late show)" {
+		t.Errorf("Expected the title to be updated, got %q", stored.Title)
+	}
+
+	req, _ := http.NewRequest("PUT", "/events/by-external-id/tickets/evt-2", bytes.NewBufferString(`{"title":"No date"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestUpsertExternalRegistration tests syncing bookings by their external IDs
+func TestUpsertExternalRegistration(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.PUT("/events/by-external-id/:source/:external_id", testHandler.upsertExternalEvent)
+	router.PUT("/events/by-external-id/:source/:external_id/registrations/:registration_id", testHandler.upsertExternalRegistration)
+
+	send := func(path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("PUT", path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	for _, id := range []string{"evt-1", "evt-2"} {
+		w := send("/events/by-external-id/tickets/"+id, `{"title":"Event `+id+`","description":"Live jazz","location":"Club","date_time":"2030-01-01T20:00:00Z"}`)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+	}
+
+	w := send("/events/by-external-id/tickets/evt-1/registrations/tkt-1", `{"Name":"Alice","Email":"alice@example.com"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created struct {
+		Data struct {
+			Registration models.Registration
+		}
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.Data.Registration.ExternalID != "tkt-1" || created.Data.Registration.Source != "tickets" {
+		t.Errorf("Expected the registration with its external ID, got %+v", created.Data.Registration)
+	}
+
+	w = send("/events/by-external-id/tickets/evt-1/registrations/tkt-1", `{"Name":"Alice Smith","Email":"alice@example.com"}`)
+	var updated struct {
+		Data struct {
+			Registration models.Registration
+		}
+	}
+	json.Unmarshal(w.Body.Bytes(), &updated)
+	if w.Code != http.StatusOK || updated.Data.Registration.ID != created.Data.Registration.ID || updated.Data.Registration.Name != "Alice Smith" {
+		t.Errorf("Expected the registration to be updated, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = send("/events/by-external-id/tickets/evt-2/registrations/tkt-1", `{"Name":"Alice","Email":"alice@example.com"}`)
+	var response problem.Problem
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusConflict || response.Code != problem.CodeExternalIDConflict {
+		t.Errorf("Expected an external ID of another event's booking to conflict, got %d %s", w.Code, w.Body.String())
+	}
+	w = send("/events/by-external-id/tickets/evt-1/registrations/tkt-2", `{"Name":"Alice","Email":"alice@example.com"}`)
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected a second booking for the email address to conflict, got %d", w.Code)
+	}
+	w = send("/events/by-external-id/tickets/nope/registrations/tkt-3", `{"Name":"Alice","Email":"alice@example.com"}`)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for an unknown event, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// TestRequireSyncKey tests that only the source's partner system and admins can sync by external ID
+func TestRequireSyncKey(t *testing.T) {
+	setupTestDatabase(t)
+	originalKeys, originalAdminKey := SyncKeys, adminAPIKey
+	SyncKeys, adminAPIKey = map[string]string{"tickets": "tickets-key", "crm": "crm-key"}, "admin-secret"
+	t.Cleanup(func() { SyncKeys, adminAPIKey = originalKeys, originalAdminKey })
+	router := setupTestRouter()
+	router.PUT("/events/by-external-id/:source/:external_id", requireSyncKey, testHandler.upsertExternalEvent)
+
+	tests := []struct {
+		name     string
+		source   string
+		token    string
+		expected int
+	}{
+		{"source key", "tickets", "tickets-key", http.StatusCreated},
+		{"admin key", "tickets", "admin-secret", http.StatusOK},
+		{"another source's key", "tickets", "crm-key", http.StatusUnauthorized},
+		{"no key", "tickets", "", http.StatusUnauthorized},
+		{"source without a key", "other", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		body := `{"title":"Jazz Night","description":"Live jazz","location":"Club","date_time":"2030-01-01T20:00:00Z"}`
+		req, _ := http.NewRequest("PUT", "/events/by-external-id/"+tt.source+"/evt-1", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.expected {
+			t.Errorf("%s: expected status code %d, got %d: %s", tt.name, tt.expected, w.Code, w.Body.String())
+		}
+	}
+}
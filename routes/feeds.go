@@ -0,0 +1,56 @@
+package routes
+
+import (
+	"event_booking_restapi_golang/feeds"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Feeds are the XML event feeds imported on a schedule. They are set up by main from
+// EVENT_FEEDS_FILE; the nil default configures none.
+var Feeds []feeds.Feed
+
+// getFeedRuns handles GET requests to /admin/feeds/runs endpoint.
+// It pages through the reports of feed imports, newest first, with the events each
+// run added, updated and skipped. The optional feed query parameter narrows them
+// down to one feed.
+// Returns HTTP 400 for invalid pagination parameters, HTTP 500 if the query fails,
+// otherwise HTTP 200 with the reports.
+func getFeedRuns(c *gin.Context) {
+	limit, offset, err := parsePagination(c)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+	runs, err := models.GetFeedRuns(c.Request.Context(), c.Query("feed"), limit, offset)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	response.Write(c, http.StatusOK, response.List(runs, len(runs)))
+}
+
+// runFeed handles POST requests to /admin/feeds/:name/run endpoint.
+// It imports the configured feed now instead of waiting for its schedule. A feed
+// that can't be fetched or parsed still produces a report, with its Error set. With
+// dry_run=true the changes are rolled back and the report isn't stored, so it tells
+// what the run would add, update and skip.
+// Returns HTTP 404 if no feed has the name, HTTP 500 if the report can't be stored,
+// otherwise HTTP 200 with the report.
+func runFeed(c *gin.Context) {
+	feed, ok := feeds.Find(Feeds, c.Param("name"))
+	if !ok {
+		problem.Respond(c, http.StatusNotFound, problem.CodeNotFound, "no feed is configured with the name "+c.Param("name"))
+		return
+	}
+	run, err := feeds.Import(c.Request.Context(), feed)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	response.Write(c, http.StatusOK, withDryRun(c, response.New(run)))
+}
@@ -0,0 +1,63 @@
+package routes
+
+import (
+	"encoding/json"
+	"event_booking_restapi_golang/feeds"
+	"event_booking_restapi_golang/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestRunFeed tests importing a configured feed on demand and listing the reports
+func TestRunFeed(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.POST("/admin/feeds/:name/run", runFeed)
+	router.GET("/admin/feeds/runs", getFeedRuns)
+
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<events><event id="1"><title>Concert</title><location>Park</location><start>2030-06-01T18:00:00Z</start></event></events>`))
+	}))
+	defer feed.Close()
+	Feeds = []feeds.Feed{{Name: "city", URL: feed.URL, Interval: time.Hour, Mapping: feeds.DefaultMapping}}
+	defer func() { Feeds = nil }()
+
+	req, _ := http.NewRequest("POST", "/admin/feeds/city/run", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response struct{ Data models.FeedRun }
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Data.Feed != "city" || response.Data.Added != 1 || response.Data.ID == "" {
+		t.Errorf("Unexpected report %s", w.Body.String())
+	}
+
+	req, _ = http.NewRequest("POST", "/admin/feeds/unknown/run", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for an unknown feed, got %d", http.StatusNotFound, w.Code)
+	}
+
+	for query, expected := range map[string]int{"": 1, "?feed=city": 1, "?feed=portal": 0} {
+		req, _ = http.NewRequest("GET", "/admin/feeds/runs"+query, nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var runs struct{ Data []models.FeedRun }
+		json.Unmarshal(w.Body.Bytes(), &runs)
+		if w.Code != http.StatusOK || len(runs.Data) != expected {
+			t.Errorf("%s: expected %d reports, got %d: %s", query, expected, w.Code, w.Body.String())
+		}
+	}
+
+	req, _ = http.NewRequest("GET", "/admin/feeds/runs?limit=none", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an invalid limit, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getEventForecast handles GET requests to /events/:id/forecast endpoint.
+// It projects the event's final attendance from its current sales velocity, compared
+// with the sales of similar past events in the summary tables, with a 95% prediction
+// interval. It runs behind requireOrganizer.
+// Returns HTTP 404 if the event is not found, HTTP 500 if the lookup fails, otherwise
+// HTTP 200 with the forecast.
+func (h *EventHandler) getEventForecast(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	event, err := h.events.GetEventById(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	forecast, err := event.Forecast(c.Request.Context(), time.Now())
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	response.Respond(c, http.StatusOK, forecast)
+}
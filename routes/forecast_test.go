@@ -0,0 +1,52 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestGetEventForecast tests that organizers get the projected attendance of their event
+func TestGetEventForecast(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/events/:id/forecast", testHandler.requireOrganizer, testHandler.getEventForecast)
+
+	ctx := context.Background()
+	event, err := models.Event{Title: "Jazz Night", Description: "Live music", Location: "Club", DateTime: time.Now().Add(7 * 24 * time.Hour), UserID: "organizer-1", Capacity: 50}.Save(ctx)
+	if err != nil {
+		t.Fatalf("Failed to save event: %v", err)
+	}
+	_, err = event.Register(ctx, models.Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	serve := func(path, userID string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set(UserHeader, userID)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("/events/"+event.ID+"/forecast", "organizer-1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	var response struct{ Data models.Forecast }
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if f := response.Data; f.Registered != 1 || f.Capacity != 50 || f.Method != models.ForecastVelocity || f.Projected < 1 {
+		t.Errorf("Expected a velocity forecast for one booking, got %+v", f)
+	}
+	if w := serve("/events/"+event.ID+"/forecast", "someone-else"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d for another user, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := serve("/events/missing/forecast", "organizer-1"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for an unknown event, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,151 @@
+package routes
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"event_booking_restapi_golang/ids"
+	"event_booking_restapi_golang/response"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Media types listings can be sent in. JSON is the usual response envelope; CSV and
+// XML render the listing as a table, so it can be pulled into spreadsheets directly.
+const (
+	mediaJSON = "application/json"
+	mediaCSV  = "text/csv"
+	mediaXML  = "application/xml"
+)
+
+// table is a listing as rows of text under named columns, the form the CSV and XML
+// formatters render.
+type table struct {
+	name    string     // Plural name of the items, such as "events": the XML root element and the CSV file name
+	item    string     // Singular name of the items, the XML element of each row
+	columns []string   // Column names, the CSV header and the XML elements within each row
+	rows    [][]string // Values of the items, one per column
+}
+
+// negotiate picks the media type of the response among the offered ones from the
+// request's Accept header: the one it gives the highest quality, the first offered on a
+// tie. Requests without Accept, or accepting none of the offered types, get the first
+// one, as listings are never refused for their Accept header.
+func negotiate(c *gin.Context, offered ...string) string {
+	c.Header("Vary", "Accept")
+	header := c.GetHeader("Accept")
+	best, bestQ := offered[0], 0.0
+	if header == "" {
+		return best
+	}
+	for _, mediaType := range offered {
+		if q := acceptQuality(header, mediaType); q > bestQ {
+			best, bestQ = mediaType, q
+		}
+	}
+	return best
+}
+
+// acceptQuality returns the quality an Accept header gives the media type, from the
+// most specific media range matching it, or 0 when none does.
+func acceptQuality(header, mediaType string) float64 {
+	kind, _, _ := strings.Cut(mediaType, "/")
+	q, specificity := 0.0, -1
+	for _, part := range strings.Split(header, ",") {
+		mediaRange, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		s := -1
+		switch mediaRange {
+		case mediaType:
+			s = 2
+		case kind + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		}
+		if s <= specificity {
+			continue
+		}
+		specificity, q = s, 1
+		if raw, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(raw, 64)
+			if err != nil {
+				q = 0
+			}
+		}
+	}
+	return q
+}
+
+// writeTable sends a page of a listing as CSV or XML with the given status. The total
+// number of items is sent in the X-Total-Count header, and XML also places the page
+// in the listing with attributes of its root element. Entity IDs are encoded as
+// public identifiers, as in JSON responses, and CSV values that spreadsheet
+// applications would evaluate as formulas are neutralized.
+func writeTable(c *gin.Context, status int, mediaType string, t table, page *response.Pagination) {
+	for _, row := range t.rows {
+		for i, column := range t.columns {
+			if isIDKey(column) && row[i] != "" {
+				row[i] = ids.Public.Encode(row[i])
+			}
+		}
+	}
+	if page != nil {
+		c.Header("X-Total-Count", strconv.Itoa(page.Total))
+	}
+
+	if mediaType == mediaCSV {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="`+t.name+`.csv"`)
+		c.Status(status)
+		w := csv.NewWriter(c.Writer)
+		w.Write(t.columns)
+		for _, row := range t.rows {
+			for i := range row {
+				row[i] = csvSafe(row[i])
+			}
+			w.Write(row)
+		}
+		w.Flush()
+		return
+	}
+
+	c.Header("Content-Type", "application/xml; charset=utf-8")
+	c.Status(status)
+	c.Writer.WriteString(xml.Header)
+	enc := xml.NewEncoder(c.Writer)
+	root := xml.StartElement{Name: xml.Name{Local: t.name}}
+	if page != nil {
+		root.Attr = []xml.Attr{
+			{Name: xml.Name{Local: "page"}, Value: strconv.Itoa(page.Page)},
+			{Name: xml.Name{Local: "total"}, Value: strconv.Itoa(page.Total)},
+			{Name: xml.Name{Local: "limit"}, Value: strconv.Itoa(page.Limit)},
+			{Name: xml.Name{Local: "offset"}, Value: strconv.Itoa(page.Offset)},
+		}
+	}
+	enc.EncodeToken(root)
+	for _, row := range t.rows {
+		item := xml.StartElement{Name: xml.Name{Local: t.item}}
+		enc.EncodeToken(item)
+		for i, column := range t.columns {
+			enc.EncodeElement(row[i], xml.StartElement{Name: xml.Name{Local: column}})
+		}
+		enc.EncodeToken(item.End())
+	}
+	enc.EncodeToken(root.End())
+	enc.Flush()
+}
+
+// formatTime renders an optional time in tables, as an RFC 3339 timestamp in UTC or
+// empty when it is missing.
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,103 @@
+package routes
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"event_booking_restapi_golang/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestNegotiate tests picking the listing format from the Accept header
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept, expected string
+	}{
+		{"", mediaJSON},
+		{"*/*", mediaJSON},
+		{"application/json", mediaJSON},
+		{"text/csv", mediaCSV},
+		{"text/*", mediaCSV},
+		{"application/xml", mediaXML},
+		{"text/csv;q=0.5, application/xml", mediaXML},
+		{"text/csv, */*;q=0.1", mediaCSV},
+		{"text/*;q=0.9, text/csv;q=0", mediaJSON},
+		{"image/png", mediaJSON},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", mediaXML},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request, _ = http.NewRequest("GET", "/events", nil)
+		c.Request.Header.Set("Accept", tt.accept)
+		if got := negotiate(c, mediaJSON, mediaCSV, mediaXML); got != tt.expected {
+			t.Errorf("Accept %q: expected %s, got %s", tt.accept, tt.expected, got)
+		}
+	}
+}
+
+// TestGetEventsFormats tests the event listing as CSV and XML
+func TestGetEventsFormats(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/events", testHandler.getEvents)
+
+	start := time.Date(2030, 6, 1, 19, 0, 0, 0, time.UTC)
+	for _, title := range []string{"=HYPERLINK(\"x\")", "Jazz Night", "Poetry Slam"} {
+		_, err := models.Event{Title: title, Description: "Live", Location: "Club", DateTime: start, UserID: "user1"}.Save(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to insert test event: %v", err)
+		}
+	}
+	get := func(accept string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/events?limit=2&sort=title", nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("text/csv")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("Expected a CSV listing, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if w.Header().Get("X-Total-Count") != "3" || w.Header().Get("Vary") != "Accept" {
+		t.Errorf("Expected X-Total-Count 3 and Vary: Accept, got %v", w.Header())
+	}
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil || len(records) != 3 {
+		t.Fatalf("Expected a header and 2 rows, got %v (%v)", records, err)
+	}
+	if records[0][1] != "title" || records[1][1] != `'=HYPERLINK("x")` || records[2][1] != "Jazz Night" {
+		t.Errorf("Expected the titles in order with formulas neutralized, got %v", records)
+	}
+	if records[1][4] != "2030-06-01T19:00:00Z" {
+		t.Errorf("Expected the date_time in RFC 3339, got %q", records[1][4])
+	}
+
+	w = get("application/xml")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/xml") {
+		t.Fatalf("Expected an XML listing, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	var listing struct {
+		XMLName xml.Name `xml:"events"`
+		Total   int      `xml:"total,attr"`
+		Limit   int      `xml:"limit,attr"`
+		Events  []struct {
+			ID    string `xml:"id"`
+			Title string `xml:"title"`
+		} `xml:"event"`
+	}
+	if err := xml.Unmarshal(w.Body.Bytes(), &listing); err != nil {
+		t.Fatalf("Failed to parse the XML listing: %v\n%s", err, w.Body.String())
+	}
+	if listing.Total != 3 || listing.Limit != 2 || len(listing.Events) != 2 || listing.Events[0].Title != `=HYPERLINK("x")` || listing.Events[0].ID == "" {
+		t.Errorf("Expected 2 of 3 events with their raw titles, got %+v", listing)
+	}
+}
@@ -0,0 +1,61 @@
+package routes
+
+import (
+	"context"
+	"event_booking_restapi_golang/db"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"event_booking_restapi_golang/statuspage"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout bounds the database check of the readiness probe, so a hung
+// database fails the probe instead of stalling it.
+const readinessTimeout = 2 * time.Second
+
+// Status checks the components the API depends on for the public status page. It is
+// set up by main; while nil the status page isn't ready.
+var Status *statuspage.Monitor
+
+// getHealthz handles GET requests to /healthz endpoint.
+// It is the liveness probe: it answers HTTP 200 as long as the process can serve
+// requests, without touching the database.
+func getHealthz(c *gin.Context) {
+	response.Respond(c, http.StatusOK, gin.H{
+		"status": "ok",
+	})
+}
+
+// getReadyz handles GET requests to /readyz endpoint.
+// It is the readiness probe: it checks that the database is reachable and its
+// migrations are applied. Returns HTTP 503 if either check fails, otherwise HTTP 200.
+func getReadyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	err := db.Ping(ctx)
+	if err != nil {
+		problem.Respond(c, http.StatusServiceUnavailable, problem.CodeNotReady, err.Error())
+		return
+	}
+	response.Respond(c, http.StatusOK, gin.H{
+		"status": "ready",
+	})
+}
+
+// getStatus handles GET requests to /status endpoint.
+// It is the public status page: how the database, the job queue and the email and
+// payment providers did in their latest check, the overall status, and the uptime of
+// each over the past day, week and month. Read-only mirrors serve it too. Returns HTTP
+// 503 if components aren't checked in this deployment, otherwise HTTP 200 whatever
+// their status.
+func getStatus(c *gin.Context) {
+	if Status == nil {
+		problem.Respond(c, http.StatusServiceUnavailable, problem.CodeNotReady, "the status of the components isn't checked")
+		return
+	}
+	response.Respond(c, http.StatusOK, Status.Report())
+}
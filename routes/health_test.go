@@ -0,0 +1,98 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/db"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/statuspage"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestGetHealthz tests that the liveness probe answers without a database
+func TestGetHealthz(t *testing.T) {
+	originalDB := db.DB
+	db.DB = nil
+	t.Cleanup(func() { db.DB = originalDB })
+
+	router := setupTestRouter()
+	router.GET("/healthz", getHealthz)
+
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+// TestGetReadyz tests that the readiness probe follows the database state
+func TestGetReadyz(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/readyz", getReadyz)
+
+	req, _ := http.NewRequest("GET", "/readyz", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response struct{ Data map[string]string }
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Data["status"] != "ready" {
+		t.Errorf("Expected status ready, got %q", response.Data["status"])
+	}
+
+	db.DB.Close()
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	var problemResponse map[string]any
+	json.Unmarshal(w.Body.Bytes(), &problemResponse)
+	if problemResponse["code"] != problem.CodeNotReady {
+		t.Errorf("Expected code %s, got %v", problem.CodeNotReady, problemResponse["code"])
+	}
+}
+
+// TestGetStatus tests that the status page reports the latest checks of the components
+func TestGetStatus(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/status", getStatus)
+	t.Cleanup(func() { Status = nil })
+
+	req, _ := http.NewRequest("GET", "/status", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d without a monitor, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+
+	Status = statuspage.New(time.Minute)
+	Status.Add("database", statuspage.DatabaseCheck())
+	Status.Add("queue", statuspage.QueueCheck())
+	Status.Run(context.Background(), time.Now())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response struct{ Data statuspage.Report }
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Data.Status != statuspage.Operational || len(response.Data.Components) != 2 {
+		t.Fatalf("Expected both components operational, got %+v", response.Data)
+	}
+	if database := response.Data.Components[0]; database.Name != "database" || database.Uptime["24h"] != 100 || database.CheckedAt == nil {
+		t.Errorf("Expected the database checked and up, got %+v", database)
+	}
+}
@@ -0,0 +1,162 @@
+package routes
+
+import (
+	"event_booking_restapi_golang/ids"
+	"event_booking_restapi_golang/live"
+	"event_booking_restapi_golang/logging"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// Live tells the connections following an event's attendance when bookings or
+// check-ins change it.
+var Live = live.NewHub()
+
+// liveRefreshInterval is how often live attendance connections re-read the counts, to
+// pick up changes made by other instances or by background jobs.
+var liveRefreshInterval = 5 * time.Second
+
+// liveAllowedOrigins are the origins, besides the API's own, whose pages may follow
+// live attendance, from the comma separated LIVE_ALLOWED_ORIGINS. It is set by
+// RegisterRoutes.
+var liveAllowedOrigins map[string]bool
+
+// parseOrigins parses a comma separated list of origins such as
+// https://dashboard.example into a set, lower-cased and without trailing slashes.
+func parseOrigins(list string) map[string]bool {
+	origins := map[string]bool{}
+	for _, origin := range strings.Split(list, ",") {
+		origin = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(origin), "/"))
+		if origin != "" {
+			origins[origin] = true
+		}
+	}
+	return origins
+}
+
+// liveCredentials is middleware letting browsers, which can't set headers on a
+// WebSocket handshake, send the credentials for getLiveAttendance in the query: the
+// organizer's user_id with their organizer token as token, or the admin key alone as
+// token. authenticateUser runs after it to check them; credentials sent in headers take
+// precedence.
+func liveCredentials(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" || c.GetHeader(UserHeader) != "" || c.GetHeader("Authorization") != "" {
+		c.Next()
+		return
+	}
+	if userID := c.Query("user_id"); userID != "" {
+		c.Request.Header.Set(UserHeader, userID)
+		c.Request.Header.Set(OrganizerTokenHeader, token)
+	} else {
+		c.Request.Header.Set("Authorization", "Bearer "+token)
+	}
+	c.Next()
+}
+
+// liveOriginAllowed reports whether the page opening the connection may do so. Browsers
+// send its Origin, which must be the API's own or one of liveAllowedOrigins, so other
+// sites' pages can't use credentials they got hold of; clients that aren't browsers send
+// none.
+func liveOriginAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || liveAllowedOrigins[strings.ToLower(origin)] {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host != "" && strings.EqualFold(u.Host, r.Host)
+}
+
+// liveAttendance is the message pushed to live attendance connections.
+type liveAttendance struct {
+	EventID    string    `json:"event_id"`
+	Registered int       `json:"registered"` // Registrations held for the event
+	CheckedIn  int       `json:"checked_in"` // Registrations checked in at the door
+	Capacity   int       `json:"capacity"`   // Capacity of the event, 0 for unlimited
+	UpdatedAt  time.Time `json:"updated_at"` // When the counts were read
+}
+
+// getLiveAttendance handles GET requests to /events/:id/live endpoint.
+// It upgrades the connection to a WebSocket and pushes the event's registration and
+// check-in counts as JSON messages: once on connecting, then whenever they change.
+// Messages from the client are ignored. It runs behind liveCredentials and
+// requireOrganizer, so clients send X-User-ID and the organizer token or the admin key
+// with the handshake, in headers or, from browsers, in the query. Browsers may only
+// connect from the API's own origin or one in LIVE_ALLOWED_ORIGINS.
+// Returns HTTP 404 if the event is not found, HTTP 426 for requests that aren't a
+// WebSocket handshake, or HTTP 403 for pages from other origins.
+func (h *EventHandler) getLiveAttendance(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	event, err := h.events.GetEventById(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !strings.EqualFold(c.GetHeader("Upgrade"), "websocket") {
+		c.Header("Upgrade", "websocket")
+		problem.Respond(c, http.StatusUpgradeRequired, problem.CodeInvalidRequest, "follow the attendance over a WebSocket connection")
+		return
+	}
+
+	if !liveOriginAllowed(c.Request) {
+		problem.Respond(c, http.StatusForbidden, problem.CodeForbidden, "pages from "+c.GetHeader("Origin")+" can't follow the attendance, see LIVE_ALLOWED_ORIGINS")
+		return
+	}
+
+	// The origin is checked above, so the server's handshake accepts any
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		streamAttendance(ws, event)
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// streamAttendance pushes the event's counts to the connection until the client
+// disconnects or a message can't be sent.
+func streamAttendance(ws *websocket.Conn, event models.Event) {
+	ctx := ws.Request().Context()
+	changed, stop := Live.Watch(event.ID)
+	defer stop()
+	closed := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, ws)
+		close(closed)
+	}()
+	ticker := time.NewTicker(liveRefreshInterval)
+	defer ticker.Stop()
+
+	var last *models.Attendance
+	for {
+		attendance, err := event.GetAttendance(ctx)
+		if err != nil {
+			logging.FromContext(ctx).Error("Couldn't count the live attendance", "event_id", event.ID, "error", err)
+			return
+		}
+		if last == nil || attendance != *last {
+			err = websocket.JSON.Send(ws, liveAttendance{
+				EventID:    ids.Public.Encode(event.ID), // Hijacked connections bypass publicIDs
+				Registered: attendance.Registered,
+				CheckedIn:  attendance.CheckedIn,
+				Capacity:   event.Capacity,
+				UpdatedAt:  time.Now().UTC(),
+			})
+			if err != nil {
+				return
+			}
+			last = &attendance
+		}
+		select {
+		case <-changed:
+		case <-ticker.C:
+		case <-closed:
+			return
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package routes
+
+import (
+	"context"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// TestGetLiveAttendance tests that organizers following an event over a WebSocket get
+// its counts on connecting and again when they change
+func TestGetLiveAttendance(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/events/:id/live", testHandler.requireOrganizer, testHandler.getLiveAttendance)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	ctx := context.Background()
+	event, err := models.Event{Title: "Jazz Night", Description: "Live music", Location: "Club", DateTime: time.Now().Add(24 * time.Hour), UserID: "organizer-1", Capacity: 50}.Save(ctx)
+	if err != nil {
+		t.Fatalf("Failed to save event: %v", err)
+	}
+	_, err = event.Register(ctx, models.Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/events/" + event.ID + "/live"
+	config, err := websocket.NewConfig(url, server.URL)
+	if err != nil {
+		t.Fatalf("Failed to configure the connection: %v", err)
+	}
+	config.Header.Set(UserHeader, "organizer-1")
+	ws, err := websocket.DialConfig(config)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer ws.Close()
+
+	receive := func() liveAttendance {
+		t.Helper()
+		ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var message liveAttendance
+		if err := websocket.JSON.Receive(ws, &message); err != nil {
+			t.Fatalf("Failed to receive counts: %v", err)
+		}
+		return message
+	}
+	if m := receive(); m.EventID != event.ID || m.Registered != 1 || m.CheckedIn != 0 || m.Capacity != 50 {
+		t.Errorf("Expected the counts on connecting, got %+v", m)
+	}
+
+	if _, err := event.Register(ctx, models.Registration{Name: "Bob", Email: "bob@example.com"}); err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	Live.Notify(event.ID)
+	if m := receive(); m.Registered != 2 || m.CheckedIn != 0 {
+		t.Errorf("Expected the new registration to be pushed, got %+v", m)
+	}
+
+	// Another organizer is refused during the handshake
+	config.Header.Set(UserHeader, "someone-else")
+	if _, err := websocket.DialConfig(config); err == nil {
+		t.Error("Expected another user to be refused")
+	}
+
+	// Plain requests are told to upgrade
+	req, _ := http.NewRequest("GET", "/events/"+event.ID+"/live", nil)
+	req.Header.Set(UserHeader, "organizer-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUpgradeRequired || w.Header().Get("Upgrade") != "websocket" {
+		t.Errorf("Expected status code %d with an Upgrade header, got %d", http.StatusUpgradeRequired, w.Code)
+	}
+}
+
+// TestLiveAttendanceFromBrowsers tests that browsers can send the credentials in the
+// query, and may only connect from the API's own origin or an allowed one
+func TestLiveAttendanceFromBrowsers(t *testing.T) {
+	setupTestDatabase(t)
+	originalSecret, originalKey, originalOrigins := organizerTokenSecret, adminAPIKey, liveAllowedOrigins
+	t.Cleanup(func() {
+		organizerTokenSecret, adminAPIKey, liveAllowedOrigins = originalSecret, originalKey, originalOrigins
+	})
+	organizerTokenSecret, adminAPIKey = []byte("token secret"), "admin-secret"
+	liveAllowedOrigins = parseOrigins(" https://dashboard.example/ ,")
+	router := gin.New()
+	router.Use(authenticateUser)
+	router.GET("/events/:id/live", liveCredentials, authenticateUser, testHandler.requireOrganizer, testHandler.getLiveAttendance)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	event, err := models.Event{Title: "Jazz Night", Description: "Live music", Location: "Club", DateTime: time.Now().Add(24 * time.Hour), UserID: "organizer-1", Capacity: 50}.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to save event: %v", err)
+	}
+
+	base := "ws" + strings.TrimPrefix(server.URL, "http") + "/events/" + event.ID + "/live"
+	tests := []struct {
+		name   string
+		query  string
+		origin string
+		ok     bool
+	}{
+		{"organizer token", "?user_id=organizer-1&token=" + organizerToken("organizer-1"), server.URL, true},
+		{"admin key", "?token=admin-secret", server.URL, true},
+		{"allowed origin", "?token=admin-secret", "https://dashboard.example", true},
+		{"wrong token", "?user_id=organizer-1&token=" + organizerToken("someone-else"), server.URL, false},
+		{"no credentials", "", server.URL, false},
+		{"other origin", "?token=admin-secret", "https://evil.example", false},
+	}
+	for _, tt := range tests {
+		config, err := websocket.NewConfig(base+tt.query, tt.origin)
+		if err != nil {
+			t.Fatalf("%s: failed to configure the connection: %v", tt.name, err)
+		}
+		ws, err := websocket.DialConfig(config)
+		if tt.ok != (err == nil) {
+			t.Errorf("%s: expected the connection to succeed: %v, got error %v", tt.name, tt.ok, err)
+		}
+		if ws != nil {
+			ws.Close()
+		}
+	}
+
+	// Pages from other origins are told why
+	req, _ := http.NewRequest("GET", "/events/"+event.ID+"/live?token=admin-secret", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Origin", "https://evil.example")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), problem.CodeForbidden) {
+		t.Errorf("Expected status code %d, got %d: %s", http.StatusForbidden, w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,238 @@
+package routes
+
+import (
+	"context"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/notifications"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bindMessageBody reads the message text from the request body, writing a problem
+// response and returning false when it is missing or too long.
+func bindMessageBody(c *gin.Context) (string, bool) {
+	var input struct {
+		Body string
+	}
+	err := c.ShouldBindJSON(&input)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return "", false
+	}
+	body := strings.TrimSpace(input.Body)
+	if body == "" || utf8.RuneCountInString(body) > models.MaxMessageLength {
+		problem.Write(c, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest,
+			"body must be between 1 and "+strconv.Itoa(models.MaxMessageLength)+" characters").With("field", "body"))
+		return "", false
+	}
+	return body, true
+}
+
+// attendeeConversation loads the event and checks the X-Registration-ID header
+// holds a registration for it, writing a problem response and returning false otherwise.
+func (h *EventHandler) attendeeConversation(c *gin.Context) (models.Event, string, bool) {
+	id, _ := c.Params.Get("id")
+	event, err := h.getPublicEvent(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return models.Event{}, "", false
+	}
+	registrationID := c.GetHeader(RegistrationHeader)
+	registered, err := event.IsRegistered(c.Request.Context(), registrationID)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return models.Event{}, "", false
+	}
+	if !registered {
+		problem.Respond(c, http.StatusForbidden, problem.CodeForbidden, "messaging the organizer requires a registration for the event in the "+RegistrationHeader+" header")
+		return models.Event{}, "", false
+	}
+	return event, registrationID, true
+}
+
+// sendMessage handles POST requests to /events/:id/messages endpoint.
+// It sends the organizer a message from the attendee whose registration is given
+// in the X-Registration-ID header, and notifies the organizer through the alert webhook.
+// Returns HTTP 404 if the event is not found, HTTP 403 without a registration for
+// the event or when the organizer blocked the attendee, HTTP 400 for an empty or
+// too long body, HTTP 500 if storing fails, otherwise HTTP 201 with the message.
+func (h *EventHandler) sendMessage(c *gin.Context) {
+	event, registrationID, ok := h.attendeeConversation(c)
+	if !ok {
+		return
+	}
+	body, ok := bindMessageBody(c)
+	if !ok {
+		return
+	}
+	message, err := event.SendMessage(c.Request.Context(), registrationID, models.FromAttendee, body)
+	if respondIfBlocked(c, err) {
+		return
+	}
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	Alerts.NotifyMessage(c.Request.Context(), event, message)
+	response.Respond(c, http.StatusCreated, message)
+}
+
+// getMessages handles GET requests to /events/:id/messages endpoint.
+// It returns the conversation of the attendee whose registration is given in the
+// X-Registration-ID header with the number of organizer replies they hadn't read,
+// and marks those replies as read.
+// Returns HTTP 404 if the event is not found, HTTP 403 without a registration for
+// the event, HTTP 500 if the lookup fails, otherwise HTTP 200 with the messages.
+func (h *EventHandler) getMessages(c *gin.Context) {
+	event, registrationID, ok := h.attendeeConversation(c)
+	if !ok {
+		return
+	}
+	h.respondWithConversation(c, event, registrationID, models.FromAttendee)
+}
+
+// respondWithConversation writes the registration's conversation with the number
+// of messages reader hadn't read, then marks them as read.
+func (h *EventHandler) respondWithConversation(c *gin.Context, event models.Event, registrationID, reader string) {
+	ctx := c.Request.Context()
+	unread, err := event.CountUnreadMessages(ctx, registrationID, reader)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	messages, err := event.GetConversation(ctx, registrationID)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	err = event.MarkConversationRead(ctx, registrationID, reader)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	response.Respond(c, http.StatusOK, gin.H{
+		"messages": messages,
+		"unread":   unread,
+	})
+}
+
+// getInbox handles GET requests to /events/:id/inbox endpoint.
+// It returns the organizer's conversations with the event's attendees, most
+// recently active first, with their unread counts and the total unread count.
+// Returns HTTP 404 if the event is not found, HTTP 500 if the lookup fails,
+// otherwise HTTP 200 with the conversations.
+func (h *EventHandler) getInbox(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	event, err := h.events.GetEventById(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	threads, err := event.GetMessageThreads(c.Request.Context())
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	unread := 0
+	for _, thread := range threads {
+		unread += thread.Unread
+	}
+	response.Respond(c, http.StatusOK, gin.H{
+		"threads": threads,
+		"unread":  unread,
+	})
+}
+
+// getInboxConversation handles GET requests to /events/:id/inbox/:registration_id endpoint.
+// It returns the organizer's conversation with one attendee with the number of
+// attendee messages the organizer hadn't read, and marks those messages as read.
+// Returns HTTP 404 if the event or a registration for it is not found, HTTP 500 if
+// the lookup fails, otherwise HTTP 200 with the messages.
+func (h *EventHandler) getInboxConversation(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	event, err := h.events.GetEventById(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	registration, err := models.GetRegistrationById(c.Request.Context(), c.Param("registration_id"))
+	if err != nil || registration.EventID != event.ID {
+		problem.Respond(c, http.StatusNotFound, problem.CodeNotFound, "Couldn't find a registration for the event with the ID of "+c.Param("registration_id"))
+		return
+	}
+	h.respondWithConversation(c, event, registration.ID, models.FromOrganizer)
+}
+
+// replyToMessage handles POST requests to /events/:id/inbox/:registration_id endpoint.
+// It sends the attendee a reply from the organizer and, when email is configured,
+// emails it to them under the event's sender settings.
+// Returns HTTP 404 if the event or a registration for it is not found, HTTP 403 if
+// the attendee blocked the organizer, HTTP 400 for an empty or too long body,
+// HTTP 500 if storing fails, otherwise HTTP 201 with the message.
+func (h *EventHandler) replyToMessage(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	event, err := h.events.GetEventById(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	registration, err := models.GetRegistrationById(c.Request.Context(), c.Param("registration_id"))
+	if err != nil || registration.EventID != event.ID {
+		problem.Respond(c, http.StatusNotFound, problem.CodeNotFound, "Couldn't find a registration for the event with the ID of "+c.Param("registration_id"))
+		return
+	}
+	body, ok := bindMessageBody(c)
+	if !ok {
+		return
+	}
+	message, err := event.SendMessage(c.Request.Context(), registration.ID, models.FromOrganizer, body)
+	if respondIfBlocked(c, err) {
+		return
+	}
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	emailReply(c.Request.Context(), event, registration, message)
+	response.Respond(c, http.StatusCreated, message)
+}
+
+// emailReply emails the organizer's reply to the attendee in the background, under
+// the event's sender name and verified reply-to address. It does nothing when email
+// isn't configured; failures are logged and never affect the reply.
+func emailReply(ctx context.Context, event models.Event, registration models.Registration, message models.Message) {
+	emailAttendee(ctx, event, registration.Email, notifications.MessageReply, notifications.Reply{
+		Event: eventDetails(event),
+		Name:  registration.Name,
+		Body:  message.Body,
+	})
+}
+
+// getAdminMessages handles GET requests to /admin/messages endpoint.
+// It pages through all attendee and organizer messages, newest first, for handling
+// abuse reports. Filter with event_id and registration_id and paginate with limit and offset.
+// Returns HTTP 400 for invalid pagination, HTTP 500 if the query fails, otherwise
+// HTTP 200 with the messages and the total count.
+func getAdminMessages(c *gin.Context) {
+	limit, offset, err := parsePagination(c)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+	filter := models.MessageFilter{
+		EventID:        c.Query("event_id"),
+		RegistrationID: c.Query("registration_id"),
+	}
+	messages, total, err := models.GetMessages(c.Request.Context(), filter, limit, offset)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	response.Write(c, http.StatusOK, response.Page(messages, total, limit, offset))
+}
@@ -0,0 +1,166 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMessages tests an attendee's question, the organizer's inbox and reply
+func TestMessages(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.POST("/events/:id/messages", testHandler.sendMessage)
+	router.GET("/events/:id/messages", testHandler.getMessages)
+	router.GET("/events/:id/inbox", testHandler.getInbox)
+	router.GET("/events/:id/inbox/:registration_id", testHandler.getInboxConversation)
+	router.POST("/events/:id/inbox/:registration_id", testHandler.replyToMessage)
+	mailer := useFakeMailer(t)
+
+	event := saveEvent(t, models.Event{Title: "Jazz Night"})
+	registration, err := event.Register(context.Background(), models.Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	send := func(method, path, registrationID, body string) (int, map[string]json.RawMessage) {
+		req, _ := http.NewRequest(method, "/events/"+event.ID+path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		if registrationID != "" {
+			req.Header.Set(RegistrationHeader, registrationID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response struct{ Data map[string]json.RawMessage }
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response.Data
+	}
+
+	code, _ := send("POST", "/messages", registration.ID, `{"body":"Is there parking?"}`)
+	if code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d", http.StatusCreated, code)
+	}
+
+	code, response := send("GET", "/inbox", "", "")
+	var threads []models.MessageThread
+	json.Unmarshal(response["threads"], &threads)
+	if code != http.StatusOK || string(response["unread"]) != "1" || len(threads) != 1 || threads[0].AttendeeName != "Alice" {
+		t.Fatalf("Expected one unread thread, got %d %v", code, response)
+	}
+
+	code, response = send("GET", "/inbox/"+registration.ID, "", "")
+	var messages []models.Message
+	json.Unmarshal(response["messages"], &messages)
+	if code != http.StatusOK || len(messages) != 1 || string(response["unread"]) != "1" {
+		t.Fatalf("Expected the question, got %d %v", code, response)
+	}
+	_, response = send("GET", "/inbox", "", "")
+	if string(response["unread"]) != "0" {
+		t.Errorf("Expected the question to be read, got %s unread", response["unread"])
+	}
+
+	code, _ = send("POST", "/inbox/"+registration.ID, "", `{"body":"Yes, behind the hall."}`)
+	if code != http.StatusCreated {
+		t.Fatalf("Expected status code %d for the reply, got %d", http.StatusCreated, code)
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(mailer.messages()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	sent := mailer.messages()
+	if len(sent) != 1 || sent[0].To != "alice@example.com" || !strings.Contains(sent[0].Body, "Yes, behind the hall.") {
+		t.Errorf("Expected the reply to be emailed to the attendee, got %+v", sent)
+	}
+
+	code, response = send("GET", "/messages", registration.ID, "")
+	json.Unmarshal(response["messages"], &messages)
+	if code != http.StatusOK || len(messages) != 2 || string(response["unread"]) != "1" {
+		t.Fatalf("Expected the conversation with one unread reply, got %d %v", code, response)
+	}
+	_, response = send("GET", "/messages", registration.ID, "")
+	if string(response["unread"]) != "0" {
+		t.Errorf("Expected the reply to be read, got %s unread", response["unread"])
+	}
+}
+
+// TestMessagesRejected tests messages without a registration or with an invalid body
+func TestMessagesRejected(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.POST("/events/:id/messages", testHandler.sendMessage)
+	router.GET("/events/:id/messages", testHandler.getMessages)
+	router.POST("/events/:id/inbox/:registration_id", testHandler.replyToMessage)
+	event := saveEvent(t, models.Event{Title: "Jazz Night"})
+	registration, err := event.Register(context.Background(), models.Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		method, path   string
+		registrationID string
+		body           string
+		expected       int
+	}{
+		{"no registration", "POST", "/events/" + event.ID + "/messages", "", `{"body":"Hi"}`, http.StatusForbidden},
+		{"unknown registration", "GET", "/events/" + event.ID + "/messages", "nope", "", http.StatusForbidden},
+		{"empty body", "POST", "/events/" + event.ID + "/messages", registration.ID, `{"body":"  "}`, http.StatusBadRequest},
+		{"long body", "POST", "/events/" + event.ID + "/messages", registration.ID, `{"body":"` + strings.Repeat("x", models.MaxMessageLength+1) + `"}`, http.StatusBadRequest},
+		{"missing event", "POST", "/events/missing/messages", registration.ID, `{"body":"Hi"}`, http.StatusNotFound},
+		{"reply to unknown registration", "POST", "/events/" + event.ID + "/inbox/nope", "", `{"body":"Hi"}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.registrationID != "" {
+				req.Header.Set(RegistrationHeader, tt.registrationID)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.expected {
+				t.Errorf("Expected status code %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestGetAdminMessages tests paging through messages for abuse handling
+func TestGetAdminMessages(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/admin/messages", getAdminMessages)
+	event := saveEvent(t, models.Event{Title: "Jazz Night"})
+	for _, body := range []string{"First", "Second", "Third"} {
+		_, err := event.SendMessage(context.Background(), "registration-1", models.FromAttendee, body)
+		if err != nil {
+			t.Fatalf("Failed to send message: %v", err)
+		}
+	}
+
+	req, _ := http.NewRequest("GET", "/admin/messages?event_id="+event.ID+"&limit=2", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var response struct {
+		Data []models.Message
+		Meta struct{ Total int }
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusOK || response.Meta.Total != 3 || len(response.Data) != 2 || response.Data[0].Body != "Third" {
+		t.Errorf("Expected the 2 newest of 3 messages, got %d %s", w.Code, w.Body.String())
+	}
+
+	req, _ = http.NewRequest("GET", "/admin/messages?limit=0", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an invalid limit, got %d", http.StatusBadRequest, w.Code)
+	}
+}
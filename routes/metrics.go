@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"event_booking_restapi_golang/metrics"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getMetrics handles GET requests to /metrics endpoint.
+// It serves the request and database metrics in the Prometheus text format for scraping.
+func getMetrics(c *gin.Context) {
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", metrics.ContentType)
+	metrics.Default.WriteText(c.Writer)
+}
@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"event_booking_restapi_golang/metrics"
+	"event_booking_restapi_golang/middlewares"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestGetMetrics tests that recorded requests are served in the Prometheus text format
+func TestGetMetrics(t *testing.T) {
+	router := setupTestRouter()
+	router.Use(middlewares.Metrics())
+	router.GET("/healthz", getHealthz)
+	router.GET("/metrics", getMetrics)
+
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	req, _ = http.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != metrics.ContentType {
+		t.Errorf("Expected content type %q, got %q", metrics.ContentType, contentType)
+	}
+	if !strings.Contains(w.Body.String(), `http_requests_total{route="/healthz",method="GET",status="200"}`) {
+		t.Errorf("Expected the /healthz request to be counted, got:\n%s", w.Body.String())
+	}
+}
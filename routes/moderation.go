@@ -0,0 +1,98 @@
+package routes
+
+import (
+	"context"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// moderationEnabled puts newly created events into review when MODERATION_MODE is "true".
+// It is read when the routes are registered.
+var moderationEnabled bool
+
+// getPublicEvent retrieves an event by ID only if it has been approved for public listing
+// and published. Pending and rejected events and drafts are reported as not found.
+func (h *EventHandler) getPublicEvent(ctx context.Context, id string) (models.Event, error) {
+	return h.getViewableEvent(ctx, id, "")
+}
+
+// getViewableEvent retrieves an event by ID as getPublicEvent does, except that drafts
+// are also returned to their organizer, identified by viewerID.
+func (h *EventHandler) getViewableEvent(ctx context.Context, id, viewerID string) (models.Event, error) {
+	event, err := h.events.GetEventById(ctx, id)
+	if err != nil {
+		return models.Event{}, err
+	}
+	if event.ReviewStatus != models.ReviewApproved || event.Status == models.StatusDraft && (viewerID == "" || viewerID != event.UserID) {
+		return models.Event{}, models.NotFoundError{Message: fmt.Sprint("Couldn't find an event with the ID of ", id), Kind: models.ErrEventNotFound}
+	}
+	return event, nil
+}
+
+// getPendingEvents handles GET requests to /admin/moderation/events endpoint.
+// It returns all events waiting for a moderation decision, oldest first.
+// Returns HTTP 500 if the query fails, otherwise HTTP 200 with the events.
+func (h *EventHandler) getPendingEvents(c *gin.Context) {
+	events, err := h.events.GetEventsByReviewStatus(c.Request.Context(), models.ReviewPending)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	response.Write(c, http.StatusOK, response.List(events, len(events)))
+}
+
+// approveEvent handles POST requests to /admin/moderation/events/:id/approve endpoint.
+// It approves the event so that it appears in public listings.
+// Returns HTTP 404 if the event is not found, HTTP 500 if the update fails,
+// or HTTP 200 with the approved event on success.
+func (h *EventHandler) approveEvent(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	event, err := h.events.GetEventById(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	err = h.events.SetReviewStatus(c.Request.Context(), event, models.ReviewApproved, "")
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	event.ReviewStatus, event.ReviewReason = models.ReviewApproved, ""
+	response.Respond(c, http.StatusOK, event)
+}
+
+// rejectEvent handles POST requests to /admin/moderation/events/:id/reject endpoint.
+// It rejects the event with the reason given in the JSON request body.
+// Returns HTTP 404 if the event is not found, HTTP 400 if no reason is given,
+// HTTP 500 if the update fails, or HTTP 200 with the rejected event on success.
+func (h *EventHandler) rejectEvent(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	event, err := h.events.GetEventById(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var body struct {
+		Reason string `binding:"required"`
+	}
+	err = c.ShouldBindJSON(&body)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	err = h.events.SetReviewStatus(c.Request.Context(), event, models.ReviewRejected, body.Reason)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	event.ReviewStatus, event.ReviewReason = models.ReviewRejected, body.Reason
+	response.Respond(c, http.StatusOK, event)
+}
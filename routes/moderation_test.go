@@ -0,0 +1,135 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestModerationWorkflow tests that moderated events stay hidden until approved
+func TestModerationWorkflow(t *testing.T) {
+	setupTestDatabase(t)
+	moderationEnabled = true
+	t.Cleanup(func() { moderationEnabled = false })
+
+	router := setupTestRouter()
+	router.POST("/event", testHandler.createEvent)
+	router.GET("/events", testHandler.getEvents)
+	router.GET("/events/:id", testHandler.getEvent)
+	router.GET("/admin/moderation/events", testHandler.getPendingEvents)
+	router.POST("/admin/moderation/events/:id/approve", testHandler.approveEvent)
+	router.POST("/admin/moderation/events/:id/reject", testHandler.rejectEvent)
+
+	eventData := map[string]interface{}{
+		"title":       "Moderated Event",
+		"description": "New Description",
+		"location":    "New Location",
+		"date_time":   time.Now().Format(time.RFC3339),
+	}
+	jsonData, _ := json.Marshal(eventData)
+	req, _ := http.NewRequest("POST", "/event", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	var id string
+	err := testDB.QueryRow("SELECT id FROM events WHERE name = ?", "Moderated Event").Scan(&id)
+	if err != nil {
+		t.Fatalf("Failed to get event ID: %v", err)
+	}
+
+	countListed := func() int {
+		req, _ := http.NewRequest("GET", "/events", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		events, _ := response["data"].([]interface{})
+		return len(events)
+	}
+
+	if n := countListed(); n != 0 {
+		t.Errorf("Expected pending event to be hidden from listings, got %d events", n)
+	}
+
+	req, _ = http.NewRequest("GET", "/events/"+id, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for a pending event, got %d", http.StatusNotFound, w.Code)
+	}
+
+	req, _ = http.NewRequest("GET", "/admin/moderation/events", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if pending, _ := response["data"].([]interface{}); len(pending) != 1 {
+		t.Errorf("Expected 1 pending event, got %v", response["data"])
+	}
+
+	req, _ = http.NewRequest("POST", "/admin/moderation/events/"+id+"/reject", bytes.NewBufferString(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d when rejecting without a reason, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	req, _ = http.NewRequest("POST", "/admin/moderation/events/"+id+"/approve", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if n := countListed(); n != 1 {
+		t.Errorf("Expected approved event to be listed, got %d events", n)
+	}
+}
+
+// TestRejectEvent tests rejecting an event with a reason
+func TestRejectEvent(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.POST("/admin/moderation/events/:id/reject", testHandler.rejectEvent)
+
+	_, err := testDB.Exec(`INSERT INTO events (id, name, description, location, datetime, user_id, review_status)
+		VALUES ('pending-1', 'Spam', 'Spam', 'Nowhere', ?, 'user1', 'pending_review')`, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to insert test event: %v", err)
+	}
+
+	req, _ := http.NewRequest("POST", "/admin/moderation/events/pending-1/reject", bytes.NewBufferString(`{"reason":"Looks like spam"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var status, reason string
+	err = testDB.QueryRow("SELECT review_status, review_reason FROM events WHERE id = 'pending-1'").Scan(&status, &reason)
+	if err != nil {
+		t.Fatalf("Failed to read review status: %v", err)
+	}
+	if status != "rejected" || reason != "Looks like spam" {
+		t.Errorf("Expected rejected with reason, got %s and %q", status, reason)
+	}
+
+	req, _ = http.NewRequest("POST", "/admin/moderation/events/missing/reject", bytes.NewBufferString(`{"reason":"x"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,82 @@
+package routes
+
+import (
+	"context"
+	"event_booking_restapi_golang/logging"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/notifications"
+)
+
+// Notifications sends the emails the API writes. It is set up by main from
+// SENDGRID_API_KEY or SMTP_ADDR; the nil default disables email.
+var Notifications *notifications.Notifier
+
+// eventDetails returns the details of the event that emails about it show.
+func eventDetails(event models.Event) notifications.EventDetails {
+	return notifications.EventDetails{
+		ID:       event.ID,
+		Title:    event.Title,
+		Location: event.Location,
+		Start:    event.DateTime,
+	}
+}
+
+// emailAttendee queues an email about the event to the attendee, under the event's
+// sender name and verified reply-to address. It does nothing when email isn't
+// configured; failures are logged and never affect the request.
+func emailAttendee(ctx context.Context, event models.Event, to string, template *notifications.Template, data any) {
+	if !Notifications.Enabled() {
+		return
+	}
+	sender, err := event.GetSender(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("Couldn't load the event's sender settings", "event_id", event.ID, "error", err)
+		return
+	}
+	err = Notifications.Send(ctx, notifications.Email{
+		To:       to,
+		FromName: sender.SenderName,
+		ReplyTo:  sender.ReplyToAddress(),
+		Template: template,
+		Data:     data,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Couldn't queue the email", "event_id", event.ID, "template", template.Name, "error", err)
+	}
+}
+
+// emailBooking confirms the booking to the attendee with their ticket and the
+// event's confirmation message.
+func emailBooking(ctx context.Context, event models.Event, registration models.Registration) {
+	booking := notifications.Booking{
+		Event:          eventDetails(event),
+		RegistrationID: registration.ID,
+		Name:           registration.Name,
+		Message:        event.ConfirmationMessage,
+	}
+	if registration.Ticket != nil {
+		booking.Ticket = registration.Ticket.Code
+	}
+	emailAttendee(ctx, event, registration.Email, notifications.BookingConfirmation, booking)
+}
+
+// emailBookingCancellation tells the attendee their booking is cancelled and confirms
+// the place to the attendee promoted from the waitlist, if any.
+func emailBookingCancellation(ctx context.Context, registration models.Registration, promoted *models.Registration) {
+	if !Notifications.Enabled() {
+		return
+	}
+	event, err := models.GetEventById(ctx, registration.EventID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Couldn't load the event of the cancelled booking", "event_id", registration.EventID, "error", err)
+		return
+	}
+	emailAttendee(ctx, event, registration.Email, notifications.BookingCancellation, notifications.Booking{
+		Event:          eventDetails(event),
+		RegistrationID: registration.ID,
+		Name:           registration.Name,
+	})
+	if promoted != nil {
+		emailBooking(ctx, event, *promoted)
+	}
+}
@@ -0,0 +1,64 @@
+package routes
+
+import (
+	"errors"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getMyOnboarding handles GET requests to /users/me/onboarding endpoint.
+// It returns the onboarding checklist of the organizer identified by the X-User-ID
+// header: every step in order, whether and when it was completed, and how many are done.
+// Returns HTTP 403 without a user ID, HTTP 500 if the lookup fails, otherwise HTTP 200
+// with the steps.
+func getMyOnboarding(c *gin.Context) {
+	userID := authenticatedUser(c)
+	if userID == "" {
+		problem.Respond(c, http.StatusForbidden, problem.CodeForbidden, "your onboarding checklist requires your user ID in the "+UserHeader+" header")
+		return
+	}
+	steps, err := models.GetOnboarding(c.Request.Context(), userID)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	completed := 0
+	for _, s := range steps {
+		if s.Completed {
+			completed++
+		}
+	}
+	response.Respond(c, http.StatusOK, gin.H{
+		"steps":     steps,
+		"completed": completed,
+		"total":     len(steps),
+	})
+}
+
+// completeOnboardingStep handles PUT requests to /admin/users/:user_id/onboarding/:step
+// endpoint. It records a step the organizer completed outside this service, such as
+// connecting a payment account, for the payment integration to report. Completing a
+// step again keeps the original time.
+// Returns HTTP 400 for an unknown step, HTTP 500 if storing fails, otherwise HTTP 200
+// with a success message.
+func completeOnboardingStep(c *gin.Context) {
+	userID, _ := c.Params.Get("user_id")
+	step, _ := c.Params.Get("step")
+	err := models.CompleteOnboardingStep(c.Request.Context(), userID, step, time.Now())
+	if errors.Is(err, models.ErrUnknownOnboardingStep) {
+		problem.Write(c, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, err.Error()).With("field", "step"))
+		return
+	}
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	response.Respond(c, http.StatusOK, gin.H{
+		"message": "Onboarding step completed successfully",
+	})
+}
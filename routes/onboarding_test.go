@@ -0,0 +1,69 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestOnboardingChecklist tests that organizers see their onboarding progress and
+// that steps done elsewhere are reported by admins
+func TestOnboardingChecklist(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/users/me/onboarding", getMyOnboarding)
+	router.PUT("/admin/users/:user_id/onboarding/:step", completeOnboardingStep)
+
+	_, err := models.Event{Title: "Jazz Night", Description: "Live music", Location: "Club", DateTime: time.Now().Add(time.Hour), UserID: "organizer-1"}.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to save event: %v", err)
+	}
+
+	serve := func(method, path, userID string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		if userID != "" {
+			req.Header.Set(UserHeader, userID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	checklist := func() (int, map[string]bool) {
+		w := serve("GET", "/users/me/onboarding", "organizer-1")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+		var response struct {
+			Data struct {
+				Steps     []models.OnboardingStep
+				Completed int
+			}
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		done := map[string]bool{}
+		for _, s := range response.Data.Steps {
+			done[s.Step] = s.Completed
+		}
+		return response.Data.Completed, done
+	}
+
+	if completed, done := checklist(); completed != 1 || !done[models.OnboardingFirstEvent] || len(done) != len(models.OnboardingSteps) {
+		t.Errorf("Expected only the first event step to be done, got %d %v", completed, done)
+	}
+	if w := serve("PUT", "/admin/users/organizer-1/onboarding/"+models.OnboardingPayout, ""); w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d completing a step, got %d", http.StatusOK, w.Code)
+	}
+	if completed, done := checklist(); completed != 2 || !done[models.OnboardingPayout] {
+		t.Errorf("Expected the payout step to be done, got %d %v", completed, done)
+	}
+	if w := serve("PUT", "/admin/users/organizer-1/onboarding/bake_cake", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an unknown step, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := serve("GET", "/users/me/onboarding", ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d without a user ID, got %d", http.StatusForbidden, w.Code)
+	}
+}
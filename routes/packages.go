@@ -0,0 +1,196 @@
+package routes
+
+import (
+	"encoding/json"
+	"event_booking_restapi_golang/middlewares"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// EventPackageFormat identifies the version of the signed event package format.
+const EventPackageFormat = "event-package/v1"
+
+// packageSignatureTolerance is how long after export a package can still be imported.
+const packageSignatureTolerance = 15 * time.Minute
+
+// packageSecret signs exported event packages and verifies imported ones. It is set from
+// EVENT_PACKAGE_SECRET by RegisterRoutes; export and import are disabled while it is empty.
+var packageSecret []byte
+
+// packageSource is the public base URL of this instance, set from EVENT_PACKAGE_SOURCE by
+// RegisterRoutes. Exported packages name it as their source and link media under it.
+var packageSource string
+
+// EventPackage is a public event exported for import into another instance. Only the
+// event's details travel; its registrations, waitlist and messages stay behind, and
+// its attachments are referenced rather than copied.
+type EventPackage struct {
+	Format     string                  `binding:"required"` // Always EventPackageFormat
+	Source     string                  `binding:"required"` // Base URL of the exporting instance
+	ExportedAt time.Time               // When the package was exported
+	Event      models.Event            // The event, with its ID and organizer ID on the source instance and its license
+	Media      []models.MediaReference // Attachments anyone can download from the source
+}
+
+// exportEvent handles GET requests to /events/:id/export endpoint.
+// It exports a public event as a package signed like a partner callback, with the
+// signature in the X-Signature, X-Timestamp and X-Nonce response headers. Posting the
+// body with those headers to /events/import-package on another instance imports it.
+// Returns HTTP 403 if packages are disabled or ContentReuse doesn't let the event be
+// exported, HTTP 404 if the event is not found or not public, HTTP 500 if the lookup
+// fails, otherwise HTTP 200 with the package.
+func (h *EventHandler) exportEvent(c *gin.Context) {
+	if len(packageSecret) == 0 {
+		problem.Respond(c, http.StatusForbidden, problem.CodePackagesDisabled, "event packages are disabled")
+		return
+	}
+	id, _ := c.Params.Get("id")
+	event, err := h.getPublicEvent(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !checkReusable(c, event) {
+		return
+	}
+	attachments, err := event.GetAttachments(c.Request.Context())
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+
+	source := packageBaseURL(c)
+	event.ReviewStatus, event.ReviewReason = "", ""
+	pkg := EventPackage{Format: EventPackageFormat, Source: source, ExportedAt: time.Now().UTC(), Event: event}
+	for _, a := range attachments {
+		if a.AttendeesOnly {
+			continue
+		}
+		pkg.Media = append(pkg.Media, models.MediaReference{
+			SourceID:    a.ID,
+			Kind:        a.Kind,
+			FileName:    a.FileName,
+			ContentType: a.ContentType,
+			Size:        a.Size,
+			URL:         source + "/attachments/" + a.ID,
+		})
+	}
+	body, err := json.Marshal(pkg)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := uuid.NewString()
+	c.Header(middlewares.TimestampHeader, timestamp)
+	c.Header(middlewares.NonceHeader, nonce)
+	c.Header(middlewares.SignatureHeader, middlewares.SignCallback(packageSecret, timestamp, nonce, body))
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// packageBaseURL returns the base URL exported packages name as their source: the
+// configured one, or else the one the request was made to.
+func packageBaseURL(c *gin.Context) string {
+	if packageSource != "" {
+		return strings.TrimSuffix(packageSource, "/")
+	}
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// importEventPackage handles POST requests to /events/import-package endpoint.
+// It imports an event package signed with the shared secret, as exported by another
+// instance or built by a partner integration. The event gets a new local ID and an
+// organizer ID derived from the source and the source organizer, so all events of one
+// organizer stay together. Importing the same source event again updates the event it
+// created. The content policy and moderation apply as on creation. With dry_run=true
+// the import is checked and rolled back, and the response has the event and mapping
+// it would produce.
+// Returns HTTP 401 or 409 if the signature is missing, invalid, expired or replayed,
+// HTTP 400 for an invalid package, HTTP 422 if the content policy blocks the event,
+// HTTP 500 if storing fails, otherwise HTTP 201 for a new event or HTTP 200 for an
+// updated one, with the event and its import mapping.
+func (h *EventHandler) importEventPackage(c *gin.Context) {
+	var pkg EventPackage
+	err := c.ShouldBindJSON(&pkg)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+	if pkg.Format != EventPackageFormat {
+		problem.Write(c, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest,
+			"unsupported package format, use "+EventPackageFormat).With("field", "Format"))
+		return
+	}
+	if pkg.Event.ID == "" {
+		problem.Write(c, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest,
+			"the packaged event has no ID").With("field", "Event.id"))
+		return
+	}
+
+	source := strings.TrimSuffix(pkg.Source, "/")
+	event := pkg.Event
+	event.UserID = uuid.NewSHA1(uuid.NameSpaceURL, []byte(source+"/users/"+pkg.Event.UserID)).String()
+	event.ReviewStatus, event.ReviewReason = models.ReviewApproved, ""
+	if !checkEndTime(c, event) {
+		return
+	}
+	if !cleanConfirmation(c, &event) {
+		return
+	}
+	if !cleanLicense(c, &event) {
+		return
+	}
+	if moderationEnabled {
+		event.ReviewStatus = models.ReviewPending
+	}
+	if !screenContent(c, &event) {
+		return
+	}
+
+	event, imp, created, err := models.ImportEvent(c.Request.Context(), event, source, pkg.Event.ID, pkg.Media)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	response.Write(c, status, withDryRun(c, response.New(gin.H{
+		"event":  event,
+		"import": imp,
+	})))
+}
+
+// getEventImport handles GET requests to /events/:id/import endpoint.
+// It returns where an imported event came from: the source instance, the event's ID
+// there and the references to its media.
+// Returns HTTP 404 if the event is not found, not viewable as for GET /events/:id or
+// wasn't imported, HTTP 500 if the lookup fails, otherwise HTTP 200 with the import mapping.
+func (h *EventHandler) getEventImport(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	event, err := h.getViewableEvent(c.Request.Context(), id, authenticatedUser(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	imp, err := event.GetImport(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	response.Respond(c, http.StatusOK, imp)
+}
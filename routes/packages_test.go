@@ -0,0 +1,174 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/middlewares"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// setupPackageRouter registers the package endpoints with a signing secret
+func setupPackageRouter(t *testing.T) http.Handler {
+	packageSecret, packageSource = []byte("package-secret"), "https://source.example.com/"
+	t.Cleanup(func() { packageSecret, packageSource = nil, "" })
+	router := setupTestRouter()
+	router.GET("/events/:id/export", testHandler.exportEvent)
+	router.POST("/events/import-package",
+		middlewares.VerifySignature(packageSecret, middlewares.NewMemoryNonceStore(), packageSignatureTolerance),
+		testHandler.importEventPackage)
+	router.GET("/events/:id/import", testHandler.getEventImport)
+	return router
+}
+
+// TestEventPackageRoundTrip tests exporting an event and importing the signed package
+func TestEventPackageRoundTrip(t *testing.T) {
+	setupTestDatabase(t)
+	originalDir := models.AttachmentsDir
+	models.AttachmentsDir = t.TempDir()
+	defer func() { models.AttachmentsDir = originalDir }()
+	router := setupPackageRouter(t)
+
+	event := saveEvent(t, models.Event{Title: "Jazz Night"})
+	ctx := context.Background()
+	_, err := event.AddAttachment(ctx, models.Attachment{Kind: models.AttachmentAgenda, FileName: "agenda.pdf"}, []byte("%PDF-1.7"))
+	if err != nil {
+		t.Fatalf("Failed to attach: %v", err)
+	}
+	_, err = event.AddAttachment(ctx, models.Attachment{Kind: models.AttachmentWaiver, FileName: "waiver.pdf", AttendeesOnly: true}, []byte("%PDF-1.7"))
+	if err != nil {
+		t.Fatalf("Failed to attach: %v", err)
+	}
+
+	export := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/events/"+event.ID+"/export", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		return w
+	}
+	importPackage := func(exported *httptest.ResponseRecorder) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/events/import-package", bytes.NewReader(exported.Body.Bytes()))
+		req.Header.Set("Content-Type", "application/json")
+		for _, header := range []string{middlewares.SignatureHeader, middlewares.TimestampHeader, middlewares.NonceHeader} {
+			req.Header.Set(header, exported.Header().Get(header))
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	exported := export()
+	var pkg EventPackage
+	json.Unmarshal(exported.Body.Bytes(), &pkg)
+	if pkg.Format != EventPackageFormat || pkg.Source != "https://source.example.com" || pkg.Event.ID != event.ID {
+		t.Errorf("Unexpected package %+v", pkg)
+	}
+	if len(pkg.Media) != 1 || pkg.Media[0].FileName != "agenda.pdf" || pkg.Media[0].URL != "https://source.example.com/attachments/"+pkg.Media[0].SourceID {
+		t.Errorf("Expected only the public attachment to be referenced, got %+v", pkg.Media)
+	}
+
+	w := importPackage(exported)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created struct {
+		Data struct {
+			Event  models.Event
+			Import models.EventImport
+		}
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.Data.Event.ID == event.ID || created.Data.Import.SourceEventID != event.ID || len(created.Data.Import.Media) != 1 {
+		t.Errorf("Expected a new event mapped to the source event, got %+v", created)
+	}
+
+	w = importPackage(exported)
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected a replayed package to be refused, got %d", w.Code)
+	}
+
+	w = importPackage(export())
+	var updated struct {
+		Data struct {
+			Event models.Event
+		}
+	}
+	json.Unmarshal(w.Body.Bytes(), &updated)
+	if w.Code != http.StatusOK || updated.Data.Event.ID != created.Data.Event.ID {
+		t.Errorf("Expected importing again to update event %s, got %d %s", created.Data.Event.ID, w.Code, w.Body.String())
+	}
+
+	req, _ := http.NewRequest("GET", "/events/"+created.Data.Event.ID+"/import", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	req, _ = http.NewRequest("GET", "/events/"+event.ID+"/import", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for a local event, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// TestEventPackageRejected tests unsigned, tampered and invalid packages
+func TestEventPackageRejected(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupPackageRouter(t)
+
+	send := func(body string, signed bool) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/events/import-package", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		if signed {
+			timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+			nonce := uuid.NewString()
+			req.Header.Set(middlewares.TimestampHeader, timestamp)
+			req.Header.Set(middlewares.NonceHeader, nonce)
+			req.Header.Set(middlewares.SignatureHeader, middlewares.SignCallback(packageSecret, timestamp, nonce, []byte(body)))
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	event := `{"id":"e1","title":"Jazz Night","description":"Live jazz","location":"Club","date_time":"2030-01-01T20:00:00Z"}`
+	if w := send(`{"Format":"event-package/v1","Source":"https://partner.example.com","Event":`+event+`}`, false); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status code %d for an unsigned package, got %d", http.StatusUnauthorized, w.Code)
+	}
+	for _, body := range []string{
+		`{"Format":"event-package/v2","Source":"https://partner.example.com","Event":` + event + `}`,
+		`{"Format":"event-package/v1","Event":` + event + `}`,
+		`{"Format":"event-package/v1","Source":"https://partner.example.com","Event":{"id":"e1","title":"Jazz Night"}}`,
+		`{"Format":"event-package/v1","Source":"https://partner.example.com","Event":{"title":"Jazz Night","description":"Live jazz","location":"Club","date_time":"2030-01-01T20:00:00Z"}}`,
+	} {
+		if w := send(body, true); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status code %d, got %d", body, http.StatusBadRequest, w.Code)
+		}
+	}
+	if w := send(`{"Format":"event-package/v1","Source":"https://partner.example.com","Event":`+event+`}`, true); w.Code != http.StatusCreated {
+		t.Errorf("Expected a partner-signed package to be imported, got %d %s", w.Code, w.Body.String())
+	}
+
+	packageSecret = nil
+	event2 := saveEvent(t, models.Event{Title: "Jazz Night"})
+	req, _ := http.NewRequest("GET", "/events/"+event2.ID+"/export", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var response problem.Problem
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusForbidden || response.Code != problem.CodePackagesDisabled {
+		t.Errorf("Expected export to be disabled without a secret, got %d %s", w.Code, w.Body.String())
+	}
+}
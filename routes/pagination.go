@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 20  // Number of items returned when no limit is given
+	maxPageSize     = 100 // Upper bound on the limit a client may request
+)
+
+// parsePagination reads the limit and offset query parameters.
+// The limit defaults to defaultPageSize and is capped at maxPageSize.
+// Returns an error if either parameter is not a valid non-negative number.
+func parsePagination(c *gin.Context) (limit, offset int, err error) {
+	limit = defaultPageSize
+	if raw := c.Query("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return 0, 0, errors.New("limit must be a positive number")
+		}
+		limit = min(limit, maxPageSize)
+	}
+	if raw := c.Query("offset"); raw != "" {
+		offset, err = strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			return 0, 0, errors.New("offset must be zero or a positive number")
+		}
+	}
+	return limit, offset, nil
+}
@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getMyPendingItems handles GET requests to /users/me/pending endpoint.
+// It returns the checklist of pending actions on the events of the organizer identified
+// by the X-User-ID header, the same items the daily digest lists: unanswered attendee
+// questions, undecided refund requests, low check-in rates and upcoming events
+// without an image.
+// Returns HTTP 403 without a user ID, HTTP 500 if the lookup fails, otherwise HTTP 200
+// with the items and their number.
+func getMyPendingItems(c *gin.Context) {
+	userID := authenticatedUser(c)
+	if userID == "" {
+		problem.Respond(c, http.StatusForbidden, problem.CodeForbidden, "listing your pending actions requires your user ID in the "+UserHeader+" header")
+		return
+	}
+	items, err := models.GetPendingItems(c.Request.Context(), userID, time.Now())
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	if items == nil {
+		items = []models.PendingItem{}
+	}
+	response.Write(c, http.StatusOK, response.List(items, len(items)))
+}
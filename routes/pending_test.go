@@ -0,0 +1,56 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestGetMyPendingItems tests that organizers see the checklist of their own events only
+func TestGetMyPendingItems(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/users/me/pending", getMyPendingItems)
+
+	ctx := context.Background()
+	for _, e := range []models.Event{
+		{Title: "Jazz Night", UserID: "organizer-1"},
+		{Title: "Someone Else's", UserID: "organizer-2"},
+	} {
+		e.Description, e.Location, e.DateTime = "Description", "Location", time.Now().Add(24*time.Hour)
+		_, err := e.Save(ctx)
+		if err != nil {
+			t.Fatalf("Failed to save event: %v", err)
+		}
+	}
+
+	list := func(userID string) (int, []models.PendingItem, float64) {
+		req, _ := http.NewRequest("GET", "/users/me/pending", nil)
+		if userID != "" {
+			req.Header.Set(UserHeader, userID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response struct {
+			Data []models.PendingItem
+			Meta struct{ Total float64 }
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response.Data, response.Meta.Total
+	}
+
+	code, items, total := list("organizer-1")
+	if code != http.StatusOK || total != 1 || len(items) != 1 || items[0].Kind != models.PendingMissingImage || items[0].EventTitle != "Jazz Night" {
+		t.Errorf("Expected the missing image of Jazz Night, got %d %+v of %v", code, items, total)
+	}
+	if code, items, _ := list("organizer-3"); code != http.StatusOK || items == nil || len(items) != 0 {
+		t.Errorf("Expected an empty checklist, got %d %+v", code, items)
+	}
+	if code, _, _ := list(""); code != http.StatusForbidden {
+		t.Errorf("Expected status code %d without a user ID, got %d", http.StatusForbidden, code)
+	}
+}
@@ -0,0 +1,243 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"event_booking_restapi_golang/ids"
+	"event_booking_restapi_golang/middlewares"
+	"io"
+	"mime"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// foreignIDs are the ID members that hold identifiers the API doesn't issue, such as
+// the IDs of users, of the series organizers group events in and of bookings in
+// external ticketing systems, keyed by their name in lower case without underscores.
+// They are never encoded or decoded.
+var foreignIDs = map[string]bool{
+	"userid":      true,
+	"seriesid":    true,
+	"externalid":  true,
+	"requestid":   true,
+	"sessionid":   true,
+	"anonymousid": true,
+}
+
+// userIDRoutes are the routes whose id path parameter is the ID of a user, a foreign
+// ID, rather than of an entity.
+var userIDRoutes = map[string]bool{
+	"/organizers/:id/calendar.ics": true,
+}
+
+// isIDKey reports whether the path parameter, query parameter or JSON member named
+// key holds entity identifiers: "id", "event_id", "WaiverID", "registration_ids" and
+// the like, except foreignIDs.
+func isIDKey(key string) bool {
+	norm := strings.ToLower(strings.ReplaceAll(key, "_", ""))
+	if foreignIDs[norm] {
+		return false
+	}
+	return strings.HasSuffix(norm, "id") || strings.HasSuffix(norm, "ids")
+}
+
+// decodeID returns the UUID of a public identifier, or s unchanged if it isn't one.
+func decodeID(s string) string {
+	if id, ok := ids.Public.Decode(s); ok {
+		return id
+	}
+	return s
+}
+
+// publicIDs translates between the identifiers clients see and the UUIDs entities are
+// stored under, when ids.Public obfuscates them, so handlers and models only ever
+// deal with UUIDs. On the way in it decodes ID path and query parameters, the
+// registration ID header and the ID members of JSON bodies; on the way out it
+// encodes the ID members of JSON responses. UUIDs are accepted as they are. Signed
+// requests and responses are passed through untouched, as rewriting them would break
+// their signatures.
+func publicIDs(c *gin.Context) {
+	for i, param := range c.Params {
+		if isIDKey(param.Key) && !(param.Key == "id" && userIDRoutes[c.FullPath()]) {
+			c.Params[i].Value = decodeID(param.Value)
+		}
+	}
+	query := c.Request.URL.Query()
+	changed := false
+	for key, values := range query {
+		if !isIDKey(key) {
+			continue
+		}
+		for i, value := range values {
+			if id := decodeID(value); id != value {
+				values[i], changed = id, true
+			}
+		}
+	}
+	if changed {
+		c.Request.URL.RawQuery = query.Encode()
+	}
+	if registrationID := c.GetHeader(RegistrationHeader); registrationID != "" {
+		c.Request.Header.Set(RegistrationHeader, decodeID(registrationID))
+	}
+	if c.Request.Body != nil && isJSON(c.ContentType()) && c.GetHeader(middlewares.SignatureHeader) == "" {
+		body, err := io.ReadAll(c.Request.Body)
+		if err == nil {
+			if decoded, err := rewriteIDs(body, decodeID); err == nil {
+				body = decoded
+			}
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Request.ContentLength = int64(len(body))
+	}
+
+	w := &publicIDWriter{ResponseWriter: c.Writer}
+	c.Writer = w
+	c.Next()
+	c.Writer = w.ResponseWriter
+	if w.buffer == nil {
+		return
+	}
+	body := w.buffer.Bytes()
+	if encoded, err := rewriteIDs(body, ids.Public.Encode); err == nil {
+		body = encoded
+	}
+	c.Writer.Header().Del("Content-Length")
+	c.Writer.Write(body)
+}
+
+// isJSON reports whether the media type is JSON, such as application/json or
+// application/problem+json.
+func isJSON(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// publicIDWriter holds back unsigned JSON responses so their identifiers can be
+// encoded once the handler is done. Other responses, such as CSV exports and event
+// streams, are written through as they come.
+type publicIDWriter struct {
+	gin.ResponseWriter
+	decided bool          // Whether the first write chose between buffer and pass-through
+	buffer  *bytes.Buffer // The held back body, nil when writing through
+}
+
+func (w *publicIDWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.decided = true
+		header := w.Header()
+		if isJSON(header.Get("Content-Type")) && header.Get(middlewares.SignatureHeader) == "" {
+			w.buffer = &bytes.Buffer{}
+		}
+	}
+	if w.buffer != nil {
+		return w.buffer.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *publicIDWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *publicIDWriter) Written() bool {
+	return w.buffer != nil || w.ResponseWriter.Written()
+}
+
+func (w *publicIDWriter) Size() int {
+	if w.buffer != nil {
+		return w.buffer.Len()
+	}
+	return w.ResponseWriter.Size()
+}
+
+// Flush is a no-op while the response is held back.
+func (w *publicIDWriter) Flush() {
+	if w.buffer == nil {
+		w.ResponseWriter.Flush()
+	}
+}
+
+// jsonFrame is an object or array rewriteIDs is copying, or the top level.
+type jsonFrame struct {
+	object bool   // Whether the frame is an object rather than an array
+	key    string // Name of the member whose value is being copied, inherited by arrays
+	n      int    // Number of keys and values copied so far
+}
+
+// rewriteIDs copies the JSON document, passing the string values of ID members, and
+// the strings in arrays held by them, through translate. Members keep their order;
+// insignificant whitespace is dropped.
+// Returns an error if data isn't a single JSON document.
+func rewriteIDs(data []byte, translate func(string) string) ([]byte, error) {
+	stack := []jsonFrame{{}}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var out bytes.Buffer
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		top := &stack[len(stack)-1]
+		delim, isDelim := tok.(json.Delim)
+		closing := isDelim && (delim == '}' || delim == ']')
+		isKey := false
+		if !closing {
+			switch {
+			case len(stack) == 1 && top.n > 0:
+				return nil, errors.New("more than one JSON document")
+			case top.object && top.n%2 == 0:
+				isKey = true
+				if top.n > 0 {
+					out.WriteByte(',')
+				}
+			case top.object:
+				out.WriteByte(':')
+			case top.n > 0:
+				out.WriteByte(',')
+			}
+			top.n++
+		}
+
+		switch t := tok.(type) {
+		case json.Delim:
+			out.WriteRune(rune(t))
+			if closing {
+				stack = stack[:len(stack)-1]
+			} else {
+				stack = append(stack, jsonFrame{object: t == '{', key: top.key})
+			}
+		case string:
+			if isKey {
+				top.key = t
+			} else if isIDKey(top.key) {
+				t = translate(t)
+			}
+			encoded, err := json.Marshal(t)
+			if err != nil {
+				return nil, err
+			}
+			out.Write(encoded)
+		case json.Number:
+			out.WriteString(t.String())
+		case bool:
+			if t {
+				out.WriteString("true")
+			} else {
+				out.WriteString("false")
+			}
+		case nil:
+			out.WriteString("null")
+		}
+	}
+	if len(stack) != 1 {
+		return nil, errors.New("incomplete JSON document")
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,131 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"event_booking_restapi_golang/ids"
+	"event_booking_restapi_golang/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// useObfuscatedIDs makes the test show obfuscated identifiers and restores UUIDs afterwards
+func useObfuscatedIDs(t *testing.T) *ids.Obfuscator {
+	codec, err := ids.NewObfuscator("test secret", "")
+	if err != nil {
+		t.Fatalf("Failed to create obfuscator: %v", err)
+	}
+	ids.Public = codec
+	t.Cleanup(func() { ids.Public = ids.Plain{} })
+	return codec
+}
+
+// TestPublicIDs tests that routes take and return public identifiers while UUIDs keep working
+func TestPublicIDs(t *testing.T) {
+	setupTestDatabase(t)
+	codec := useObfuscatedIDs(t)
+	router := setupTestRouter()
+	router.Use(publicIDs)
+	router.GET("/events/:id", testHandler.getEvent)
+	router.POST("/events/:id/register", testHandler.registerForEvent)
+	router.GET("/events/:id/messages", testHandler.getMessages)
+	event := saveEvent(t, models.Event{Title: "Jazz Night"})
+	publicID := codec.Encode(event.ID)
+
+	get := func(path string) (*httptest.ResponseRecorder, map[string]json.RawMessage) {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set(UserHeader, "organizer-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response map[string]json.RawMessage
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+	for _, id := range []string{publicID, event.ID} {
+		w, response := get("/events/" + id)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d for %s, got %d: %s", http.StatusOK, id, w.Code, w.Body.String())
+		}
+		var got struct {
+			ID string `json:"id"`
+		}
+		json.Unmarshal(response["data"], &got)
+		if got.ID != publicID {
+			t.Errorf("Expected the event to be shown as %s, got %s", publicID, got.ID)
+		}
+	}
+
+	body := bytes.NewBufferString(`{"name":"Alice","email":"alice@example.com"}`)
+	req, _ := http.NewRequest("POST", "/events/"+publicID+"/register", body)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var registered struct {
+		Data struct {
+			Registration struct {
+				ID      string
+				EventID string
+			}
+		}
+	}
+	json.Unmarshal(w.Body.Bytes(), &registered)
+	if w.Code != http.StatusCreated || registered.Data.Registration.EventID != publicID {
+		t.Fatalf("Expected the booking to show the event as %s, got %d: %s", publicID, w.Code, w.Body.String())
+	}
+	registrationID, ok := codec.Decode(registered.Data.Registration.ID)
+	if !ok || len(registered.Data.Registration.ID) != 22 {
+		t.Fatalf("Expected an obfuscated registration ID, got %q", registered.Data.Registration.ID)
+	}
+	var stored string
+	testDB.QueryRow("SELECT event_id FROM registrations WHERE id = ?", registrationID).Scan(&stored)
+	if stored != event.ID {
+		t.Errorf("Expected the registration to be stored under the event's UUID, got %q", stored)
+	}
+
+	req, _ = http.NewRequest("GET", "/events/"+publicID+"/messages", nil)
+	req.Header.Set(RegistrationHeader, registered.Data.Registration.ID)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected the public registration ID header to be accepted, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w, _ := get("/events/" + codec.Encode("00000000-0000-7000-8000-000000000000")); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for an unknown event, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// TestRewriteIDs tests which members are translated and that the rest of the document is kept
+func TestRewriteIDs(t *testing.T) {
+	mark := func(s string) string { return "[" + s + "]" }
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"id":"a","title":"b","n":1.50,"ok":true,"none":null}`, `{"id":"[a]","title":"b","n":1.50,"ok":true,"none":null}`},
+		{`{"EventID":"a","event_id":"b","registration_ids":["c","d"]}`, `{"EventID":"[a]","event_id":"[b]","registration_ids":["[c]","[d]"]}`},
+		{`{"user_id":"a","UserID":"b","ExternalID":"c","request_id":"d"}`, `{"user_id":"a","UserID":"b","ExternalID":"c","request_id":"d"}`},
+		{`{"events":[{"id":"a","tags":["id"]},{"id":"b"}],"total":2}`, `{"events":[{"id":"[a]","tags":["id"]},{"id":"[b]"}],"total":2}`},
+		{`{"id":{"id":"a"}}`, `{"id":{"id":"[a]"}}`},
+		{` [ "a" , {"id" : "b"} ] `, `["a",{"id":"[b]"}]`},
+		{`{"id":"<script>"}`, `{"id":"[\u003cscript\u003e]"}`},
+		{``, ``},
+	}
+	for _, tt := range tests {
+		got, err := rewriteIDs([]byte(tt.input), mark)
+		if err != nil {
+			t.Errorf("Failed to rewrite %s: %v", tt.input, err)
+			continue
+		}
+		if string(got) != tt.expected {
+			t.Errorf("rewriteIDs(%s) = %s, expected %s", tt.input, got, tt.expected)
+		}
+	}
+
+	for _, input := range []string{`{"id":`, `{"id":"a"}{"id":"b"}`, `nope`} {
+		if _, err := rewriteIDs([]byte(input), mark); err == nil {
+			t.Errorf("Expected %s to be rejected", input)
+		}
+	}
+}
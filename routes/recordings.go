@@ -0,0 +1,52 @@
+package routes
+
+import (
+	"event_booking_restapi_golang/middlewares"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Recorder records sanitized requests and responses for the users and routes being
+// debugged. It is set up by main from RECORD_USERS and RECORD_ROUTES; the nil default
+// records nothing.
+var Recorder *middlewares.Recorder
+
+// getRecordings handles GET requests to /admin/recordings endpoint.
+// It pages through the recorded requests and their responses, newest first. The
+// optional user and route query parameters narrow them down to the requests recorded
+// for one user or matching one route pattern.
+// Returns HTTP 400 for invalid pagination parameters, otherwise HTTP 200 with the
+// recordings and whether recording is enabled.
+func getRecordings(c *gin.Context) {
+	limit, offset, err := parsePagination(c)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+	user, route := c.Query("user"), c.Query("route")
+	matching := []middlewares.Recording{}
+	for _, recording := range Recorder.Recordings() {
+		if user != "" && recording.User != user || route != "" && recording.Route != route {
+			continue
+		}
+		matching = append(matching, recording)
+	}
+	page := matching[min(offset, len(matching)):min(offset+limit, len(matching))]
+	response.Write(c, http.StatusOK, response.Page(gin.H{
+		"enabled":    Recorder.Enabled(),
+		"recordings": page,
+	}, len(matching), limit, offset))
+}
+
+// clearRecordings handles DELETE requests to /admin/recordings endpoint.
+// It drops the recordings kept in memory; the recording file, if any, is kept.
+// Returns HTTP 200 with a success message.
+func clearRecordings(c *gin.Context) {
+	Recorder.Clear()
+	response.Respond(c, http.StatusOK, gin.H{
+		"message": "Recordings cleared successfully",
+	})
+}
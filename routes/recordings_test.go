@@ -0,0 +1,62 @@
+package routes
+
+import (
+	"encoding/json"
+	"event_booking_restapi_golang/middlewares"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRecordings tests listing, filtering and clearing the recorded requests
+func TestRecordings(t *testing.T) {
+	Recorder = middlewares.NewRecorder(middlewares.RecordingPolicy{
+		Users:  map[string]bool{"organizer-1": true},
+		Routes: map[string]bool{"/healthz": true},
+	})
+	defer func() { Recorder = nil }()
+	router := setupTestRouter()
+	router.Use(Recorder.Middleware())
+	router.GET("/healthz", getHealthz)
+	router.GET("/admin/recordings", getRecordings)
+	router.DELETE("/admin/recordings", clearRecordings)
+
+	serve := func(method, path, userID string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		if userID != "" {
+			req.Header.Set(UserHeader, userID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	serve("GET", "/healthz", "")
+	serve("GET", "/healthz", "organizer-1")
+
+	for query, expected := range map[string]int{"": 2, "?user=organizer-1": 1, "?route=/healthz&limit=1": 1, "?route=/events": 0} {
+		w := serve("GET", "/admin/recordings"+query, "")
+		var response struct {
+			Data struct {
+				Enabled    bool
+				Recordings []middlewares.Recording
+			}
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if w.Code != http.StatusOK || !response.Data.Enabled || len(response.Data.Recordings) != expected {
+			t.Errorf("%s: expected %d recordings, got %d: %s", query, expected, w.Code, w.Body.String())
+		}
+	}
+
+	if w := serve("GET", "/admin/recordings?offset=-1", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an invalid offset, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	serve("DELETE", "/admin/recordings", "")
+	var cleared struct {
+		Meta struct{ Total int }
+	}
+	json.Unmarshal(serve("GET", "/admin/recordings", "").Body.Bytes(), &cleared)
+	if cleared.Meta.Total != 0 {
+		t.Errorf("Expected no recordings after clearing, got %d", cleared.Meta.Total)
+	}
+}
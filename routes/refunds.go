@@ -0,0 +1,175 @@
+package routes
+
+import (
+	"event_booking_restapi_golang/logging"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/payments"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Refunds carries out approved refund requests through the payment provider. It is
+// set up by main from REFUND_WEBHOOK_URL; the nil default leaves approved refunds to
+// be made outside of the API.
+var Refunds *payments.Processor
+
+// requestRefund handles POST requests to /registrations/:id/refund-request endpoint.
+// It records the attendee's request for a refund of the booking, with the reason given
+// in the JSON request body. The registration ID acts as the attendee's credential, as
+// for cancelling the booking. Requests made at least REFUND_AUTO_APPROVE_BEFORE before
+// the event are approved at once: the booking is cancelled, the next attendee on the
+// waitlist is promoted and the refund is handed to the payment provider. Others wait
+// for the organizer's decision.
+// Returns HTTP 404 if the registration is not found, HTTP 400 if no reason is given,
+// HTTP 409 if a refund was already requested, HTTP 500 if storing it fails, otherwise
+// HTTP 201 with the refund request and whether a waitlisted attendee was promoted.
+func requestRefund(c *gin.Context) {
+	ctx := c.Request.Context()
+	registration, err := models.GetRegistrationById(ctx, c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	var body struct {
+		Reason string `binding:"required"`
+	}
+	err = c.ShouldBindJSON(&body)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	request, promoted, err := registration.RequestRefund(ctx, body.Reason, time.Now())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if request.Status == models.RefundApproved {
+		Live.Notify(request.EventID)
+		emailBookingCancellation(ctx, registration, promoted)
+		enqueueRefund(c, request)
+	}
+	response.Respond(c, http.StatusCreated, gin.H{
+		"refund_request": request,
+		"promoted":       promoted != nil,
+	})
+}
+
+// getRefundRequest handles GET requests to /registrations/:id/refund-request endpoint.
+// It returns the refund request made for the booking, so the attendee can follow it
+// after an approval has cancelled the booking. The registration ID acts as the
+// attendee's credential.
+// Returns HTTP 404 if no refund was requested for the registration, HTTP 500 if the
+// lookup fails, otherwise HTTP 200 with the refund request.
+func getRefundRequest(c *gin.Context) {
+	request, err := models.GetRefundRequestByRegistration(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	response.Respond(c, http.StatusOK, request)
+}
+
+// getEventRefundRequests handles GET requests to /events/:id/refund-requests endpoint.
+// It returns the refund requests for the event in the order they were made, only those
+// in the status given by the status query parameter if there is one. It runs behind
+// requireOrganizer.
+// Returns HTTP 404 if the event is not found, HTTP 400 for an unknown status, HTTP 500
+// if the lookup fails, otherwise HTTP 200 with the requests and their number.
+func (h *EventHandler) getEventRefundRequests(c *gin.Context) {
+	event, err := h.events.GetEventById(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	status := c.Query("status")
+	switch status {
+	case "", models.RefundRequested, models.RefundApproved, models.RefundDenied, models.RefundRefunded, models.RefundFailed:
+	default:
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, "unsupported status "+status+", use requested, approved, denied, refunded or failed")
+		return
+	}
+
+	requests, err := event.GetRefundRequests(c.Request.Context(), status)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	response.Write(c, http.StatusOK, response.List(requests, len(requests)))
+}
+
+// approveRefundRequest handles POST requests to /events/:id/refund-requests/:request_id/approve
+// endpoint. It approves the refund request, cancelling the booking, promoting the next
+// attendee on the waitlist and handing the refund to the payment provider. It runs
+// behind requireOrganizer.
+func (h *EventHandler) approveRefundRequest(c *gin.Context) {
+	h.decideRefundRequest(c, true)
+}
+
+// denyRefundRequest handles POST requests to /events/:id/refund-requests/:request_id/deny
+// endpoint. It turns the refund request down; the booking stands. It runs behind
+// requireOrganizer.
+func (h *EventHandler) denyRefundRequest(c *gin.Context) {
+	h.decideRefundRequest(c, false)
+}
+
+// decideRefundRequest records the organizer's decision on the refund request, with the
+// optional explanation in the reason field of the JSON request body.
+// Returns HTTP 404 if the request is not found or is for another event, HTTP 400 for
+// an invalid body, HTTP 409 if it was already decided, HTTP 500 if storing the decision
+// fails, otherwise HTTP 200 with the decided request and whether a waitlisted attendee
+// was promoted.
+func (h *EventHandler) decideRefundRequest(c *gin.Context, approve bool) {
+	ctx := c.Request.Context()
+	request, err := models.GetRefundRequest(ctx, c.Param("request_id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if request.EventID != c.Param("id") {
+		problem.Respond(c, http.StatusNotFound, problem.CodeNotFound, "Couldn't find a refund request with the ID of "+c.Param("request_id")+" for this event")
+		return
+	}
+	var body struct {
+		Reason string
+	}
+	if c.Request.ContentLength != 0 {
+		err = c.ShouldBindJSON(&body)
+		if err != nil {
+			problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+			return
+		}
+	}
+	// Only a booking still held is cancelled, and only then is the attendee told so
+	registration, registrationErr := models.GetRegistrationById(ctx, request.RegistrationID)
+
+	request, promoted, err := models.DecideRefundRequest(ctx, request.ID, approve, body.Reason, time.Now())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if approve {
+		Live.Notify(request.EventID)
+		if registrationErr == nil {
+			emailBookingCancellation(ctx, registration, promoted)
+		}
+		enqueueRefund(c, request)
+	}
+	response.Respond(c, http.StatusOK, gin.H{
+		"refund_request": request,
+		"promoted":       promoted != nil,
+	})
+}
+
+// enqueueRefund hands the approved request to the payment provider in the background.
+// Failures are logged; the request stays approved for the refund to be made by hand.
+func enqueueRefund(c *gin.Context, request models.RefundRequest) {
+	err := Refunds.Enqueue(c.Request.Context(), request)
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error("Couldn't queue the refund", "refund_request_id", request.ID, "error", err)
+	}
+}
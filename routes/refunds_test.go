@@ -0,0 +1,123 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestRefundRequests tests that attendees request refunds, organizers approve or deny
+// them and both see where the requests stand
+func TestRefundRequests(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.POST("/registrations/:id/refund-request", requestRefund)
+	router.GET("/registrations/:id/refund-request", getRefundRequest)
+	router.GET("/events/:id/refund-requests", testHandler.requireOrganizer, testHandler.getEventRefundRequests)
+	router.POST("/events/:id/refund-requests/:request_id/approve", testHandler.requireOrganizer, testHandler.approveRefundRequest)
+	router.POST("/events/:id/refund-requests/:request_id/deny", testHandler.requireOrganizer, testHandler.denyRefundRequest)
+
+	ctx := context.Background()
+	event, err := models.Event{Title: "Jazz Night", Description: "Live music", Location: "Club", DateTime: time.Now().Add(24 * time.Hour), UserID: "organizer-1"}.Save(ctx)
+	if err != nil {
+		t.Fatalf("Failed to save event: %v", err)
+	}
+	var registrations []models.Registration
+	for _, name := range []string{"alice", "bob"} {
+		registration, err := event.Register(ctx, models.Registration{Name: name, Email: name + "@example.com"})
+		if err != nil {
+			t.Fatalf("Failed to register: %v", err)
+		}
+		registrations = append(registrations, registration)
+	}
+
+	serve := func(method, path, body string) (int, map[string]json.RawMessage) {
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(UserHeader, "organizer-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response map[string]json.RawMessage
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+	// Reads the request from the data of a response, alone or with whether someone was promoted
+	refundRequest := func(response map[string]json.RawMessage) models.RefundRequest {
+		var request models.RefundRequest
+		var withPromoted struct {
+			RefundRequest *models.RefundRequest `json:"refund_request"`
+		}
+		if json.Unmarshal(response["data"], &withPromoted); withPromoted.RefundRequest != nil {
+			return *withPromoted.RefundRequest
+		}
+		json.Unmarshal(response["data"], &request)
+		return request
+	}
+
+	if code, _ := serve("POST", "/registrations/"+registrations[0].ID+"/refund-request", `{}`); code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d without a reason, got %d", http.StatusBadRequest, code)
+	}
+	var requests []models.RefundRequest
+	for _, registration := range registrations {
+		code, response := serve("POST", "/registrations/"+registration.ID+"/refund-request", `{"reason":"Sick"}`)
+		request := refundRequest(response)
+		if code != http.StatusCreated || request.Status != models.RefundRequested || request.Reason != "Sick" {
+			t.Fatalf("Expected the refund to be requested, got %d %+v", code, request)
+		}
+		requests = append(requests, request)
+	}
+	if code, _ := serve("POST", "/registrations/"+registrations[0].ID+"/refund-request", `{"reason":"Sick"}`); code != http.StatusConflict {
+		t.Errorf("Expected status code %d for a second request, got %d", http.StatusConflict, code)
+	}
+	if code, _ := serve("POST", "/registrations/missing/refund-request", `{"reason":"Sick"}`); code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for an unknown registration, got %d", http.StatusNotFound, code)
+	}
+
+	code, response := serve("GET", "/events/"+event.ID+"/refund-requests?status=requested", "")
+	var listed struct{ Total int }
+	json.Unmarshal(response["meta"], &listed)
+	if code != http.StatusOK || listed.Total != 2 {
+		t.Errorf("Expected the organizer to see 2 undecided requests, got %d %v", code, response)
+	}
+	if code, _ := serve("GET", "/events/"+event.ID+"/refund-requests?status=lost", ""); code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an unknown status, got %d", http.StatusBadRequest, code)
+	}
+
+	code, response = serve("POST", "/events/"+event.ID+"/refund-requests/"+requests[0].ID+"/approve", "")
+	if request := refundRequest(response); code != http.StatusOK || request.Status != models.RefundApproved {
+		t.Errorf("Expected the request to be approved, got %d %+v", code, request)
+	}
+	var approved struct {
+		Promoted *bool `json:"promoted"`
+	}
+	if json.Unmarshal(response["data"], &approved); approved.Promoted == nil || *approved.Promoted {
+		t.Errorf("Expected promoted to say nobody was waiting, got %s", response["data"])
+	}
+	code, response = serve("POST", "/events/"+event.ID+"/refund-requests/"+requests[1].ID+"/deny", `{"reason":"Within 24 hours"}`)
+	if request := refundRequest(response); code != http.StatusOK || request.Status != models.RefundDenied || request.DecisionReason != "Within 24 hours" {
+		t.Errorf("Expected the request to be denied, got %d %+v", code, request)
+	}
+	if code, _ := serve("POST", "/events/"+event.ID+"/refund-requests/"+requests[1].ID+"/approve", ""); code != http.StatusConflict {
+		t.Errorf("Expected status code %d for a decided request, got %d", http.StatusConflict, code)
+	}
+	if code, _ := serve("POST", "/events/"+event.ID+"/refund-requests/missing/approve", ""); code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for an unknown request, got %d", http.StatusNotFound, code)
+	}
+
+	// The attendee follows the request after the approval cancelled the booking
+	code, response = serve("GET", "/registrations/"+registrations[0].ID+"/refund-request", "")
+	if request := refundRequest(response); code != http.StatusOK || request.Status != models.RefundApproved {
+		t.Errorf("Expected the attendee to see the approval, got %d %+v", code, request)
+	}
+	if _, err := models.GetRegistrationById(ctx, registrations[0].ID); err == nil {
+		t.Error("Expected the approved booking to be cancelled")
+	}
+	if code, _ := serve("GET", "/registrations/missing/refund-request", ""); code != http.StatusNotFound {
+		t.Errorf("Expected status code %d without a request, got %d", http.StatusNotFound, code)
+	}
+}
@@ -0,0 +1,321 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"event_booking_restapi_golang/alerts"
+	"event_booking_restapi_golang/analytics"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"event_booking_restapi_golang/validation"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Alerts notifies organizers when bookings cross the capacity and sales velocity
+// thresholds. It is set up by main from ALERT_WEBHOOK_URL; the nil default disables alerts.
+var Alerts *alerts.Policy
+
+// registerForEvent handles POST requests to /events/:id/register endpoint.
+// It books a place on the event for the attendee described in the JSON request body.
+// Events with a waiver require the attendee to accept it by sending its ID as WaiverID.
+// The utm_* and ref query parameters are stored with the booking for attribution, and
+// the booking counts as a conversion for the session's listing order experiment variant
+// and is reported to the analytics pipeline. The organizer is alerted when the booking
+// brings the event to 80% or 100% of its capacity or bookings spike, and the attendee
+// is emailed a booking confirmation with their ticket.
+// Returns HTTP 404 if the event is not found or not public, HTTP 400 if the request or email
+// is invalid or the waiver was not accepted, HTTP 403 if the organizer blocked the attendee,
+// HTTP 409 if the event is cancelled or full or the attendee is already registered, or HTTP 201 with
+// the registration and the event's confirmation URL and message on success, so embedded
+// booking widgets can hand the attendee back to the organizer's site.
+func (h *EventHandler) registerForEvent(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	event, err := h.getPublicEvent(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var registration models.Registration
+	err = c.ShouldBindJSON(&registration)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+	err = validation.ValidateEmail(registration.Email)
+	if err != nil {
+		problem.Write(c, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, err.Error()).With("field", "email"))
+		return
+	}
+	if !acceptWaiver(c, event, &registration.WaiverAcceptance) {
+		return
+	}
+	captureAttribution(c, &registration.Attribution)
+	registration.ExternalID, registration.Source = "", ""
+
+	registration, err = event.Register(c.Request.Context(), registration)
+	if respondIfBlocked(c, err) {
+		return
+	}
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	logConversion(c, event.ID)
+	BookingMade(c.Request.Context(), event, registration)
+	trackUsage(c, analytics.BookingCompleted, map[string]any{
+		"event_id":   event.ID,
+		"utm_source": registration.UTMSource,
+		"utm_medium": registration.UTMMedium,
+	})
+	response.Respond(c, http.StatusCreated, gin.H{
+		"message":      "Registered for the event successfully",
+		"registration": registration,
+		"confirmation": gin.H{
+			"url":     event.ConfirmationURL,
+			"message": event.ConfirmationMessage,
+		},
+	})
+}
+
+// BookingMade runs the follow-ups of a new booking: the event's live attendance and
+// capacity alerts are updated and the attendee is emailed the confirmation. Bookings
+// made outside the HTTP API, such as through the gRPC EventService, call it too.
+func BookingMade(ctx context.Context, event models.Event, registration models.Registration) {
+	Live.Notify(event.ID)
+	Alerts.Check(ctx, event)
+	emailBooking(ctx, event, registration)
+}
+
+// cancelRegistration handles DELETE requests to /registrations/:id endpoint.
+// It cancels the registration and promotes the next attendee from the event's waitlist.
+// The attendee is emailed that the booking is cancelled, and the promoted attendee a
+// booking confirmation.
+// The promoted attendee's registration ID is their credential, so the response only
+// tells whether somebody was promoted.
+// Returns HTTP 404 if the registration is not found, HTTP 500 if cancellation fails,
+// or HTTP 200 with whether a waitlisted attendee was promoted on success.
+func cancelRegistration(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	registration, err := models.GetRegistrationById(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	promoted, err := registration.Cancel(c.Request.Context())
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	Live.Notify(registration.EventID)
+	emailBookingCancellation(c.Request.Context(), registration, promoted)
+	response.Respond(c, http.StatusOK, gin.H{
+		"message":  "Registration cancelled successfully",
+		"promoted": promoted != nil,
+	})
+}
+
+// rescheduleRegistration handles POST requests to /registrations/:id/reschedule endpoint.
+// It moves the booking to another occurrence of its event's series, the event_id given
+// in the JSON request body, as long as neither occurrence starts within
+// RESCHEDULE_CUTOFF. As on booking, the attendee must accept the current waiver of the
+// new occurrence, if it has one, by sending its ID as WaiverID. The registration ID acts
+// as the attendee's credential, as for cancelling the booking. The attendee is emailed a
+// confirmation with the new ticket, and the place freed on the occurrence left goes to
+// its waitlist. The promoted attendee's registration ID is their credential, so the
+// response only tells whether somebody was promoted.
+// Returns HTTP 404 if the registration or the occurrence is not found, HTTP 400 if no
+// event_id is given or the waiver wasn't accepted, HTTP 422 if the event isn't another occurrence of the series,
+// HTTP 409 if it's too late to reschedule, the attendee was checked in, or the
+// occurrence is cancelled, full or already booked by the attendee, otherwise HTTP 200
+// with the moved registration and whether a waitlisted attendee was promoted.
+func (h *EventHandler) rescheduleRegistration(c *gin.Context) {
+	ctx := c.Request.Context()
+	registration, err := models.GetRegistrationById(ctx, c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	var body struct {
+		EventID  string `json:"event_id" binding:"required"`
+		WaiverID string
+	}
+	err = c.ShouldBindJSON(&body)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	acceptance := models.WaiverAcceptance{WaiverID: body.WaiverID, WaiverIP: c.ClientIP()}
+	rescheduled, promoted, err := registration.Reschedule(ctx, body.EventID, acceptance, time.Now())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	Live.Notify(registration.EventID)
+	if event, err := h.events.GetEventById(ctx, rescheduled.EventID); err == nil {
+		BookingMade(ctx, event, rescheduled)
+	}
+	if promoted != nil {
+		if left, err := h.events.GetEventById(ctx, registration.EventID); err == nil {
+			emailBooking(ctx, left, *promoted)
+		}
+	}
+	response.Respond(c, http.StatusOK, gin.H{
+		"registration": rescheduled,
+		"promoted":     promoted != nil,
+	})
+}
+
+// eventRegistration is an entry of the organizer's attendee list: who booked and
+// whether they have arrived.
+type eventRegistration struct {
+	ID          string
+	Name        string
+	Email       string
+	CreatedAt   time.Time
+	CheckedIn   bool
+	CheckedInAt *time.Time
+}
+
+// getEventRegistrations handles GET requests to /events/:id/registrations endpoint.
+// It pages through the event's attendees in booking order, with their check-in status,
+// using the limit and offset query parameters. It runs behind requireOrganizer.
+// The page is sent as JSON, or as CSV or XML when the Accept header prefers them.
+// Returns HTTP 404 if the event is not found, HTTP 400 for invalid pagination
+// parameters, HTTP 500 if the lookup fails, otherwise HTTP 200 with the page of
+// registrations and the total number of registrations.
+func (h *EventHandler) getEventRegistrations(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	event, err := h.events.GetEventById(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	limit, offset, err := parsePagination(c)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	registrations, total, err := event.GetRegistrationsPage(c.Request.Context(), limit, offset)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	list := make([]eventRegistration, len(registrations))
+	for i, r := range registrations {
+		list[i] = eventRegistration{
+			ID:          r.ID,
+			Name:        r.Name,
+			Email:       r.Email,
+			CreatedAt:   r.CreatedAt,
+			CheckedIn:   r.CheckedInAt != nil,
+			CheckedInAt: r.CheckedInAt,
+		}
+	}
+	body := response.Page(list, total, limit, offset)
+	if mediaType := negotiate(c, mediaJSON, mediaCSV, mediaXML); mediaType != mediaJSON {
+		writeTable(c, http.StatusOK, mediaType, registrationTable(list), body.Meta.Pagination)
+		return
+	}
+	response.Write(c, http.StatusOK, body)
+}
+
+// registrationTable lays out an attendee list for the CSV and XML formats.
+func registrationTable(list []eventRegistration) table {
+	t := table{
+		name:    "registrations",
+		item:    "registration",
+		columns: []string{"id", "name", "email", "created_at", "checked_in", "checked_in_at"},
+	}
+	for _, r := range list {
+		t.rows = append(t.rows, []string{
+			r.ID, r.Name, r.Email, formatTime(&r.CreatedAt), strconv.FormatBool(r.CheckedIn), formatTime(r.CheckedInAt),
+		})
+	}
+	return t
+}
+
+// getMyRegistrations handles GET requests to /users/me/registrations endpoint.
+// It returns the attendee's bookings with their events, split into upcoming ones, soonest
+// first, and past ones, most recent first. As for the calendar, the attendee is
+// identified by the email address of one of their registrations, given in the
+// X-Registration-ID header. The optional status query parameter keeps only bookings of
+// published or cancelled events, and from and to only those of events starting within
+// the dates. Email addresses aren't verified, so only the booking of the given
+// registration carries its registration ID; the others are listed without their
+// credentials (see models.Booking.WithoutCredentials).
+// Returns HTTP 403 without a valid registration ID, HTTP 400 for invalid filters, HTTP
+// 500 if the lookup fails, otherwise HTTP 200 with the bookings.
+func getMyRegistrations(c *gin.Context) {
+	registration, err := models.GetRegistrationById(c.Request.Context(), c.GetHeader(RegistrationHeader))
+	if err != nil {
+		problem.Respond(c, http.StatusForbidden, problem.CodeForbidden, "listing your bookings requires one of your registration IDs in the "+RegistrationHeader+" header")
+		return
+	}
+	filter, err := parseBookingFilter(c)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	bookings, err := models.GetRegistrationsByEmail(c.Request.Context(), registration.Email, filter)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	now := time.Now()
+	upcoming, past := []models.Booking{}, []models.Booking{}
+	for _, b := range bookings {
+		if b.Registration.ID != registration.ID {
+			b = b.WithoutCredentials()
+		}
+		if b.Ended(now) {
+			past = append(past, b)
+		} else {
+			upcoming = append(upcoming, b)
+		}
+	}
+	slices.Reverse(past)
+	response.Respond(c, http.StatusOK, gin.H{
+		"upcoming": upcoming,
+		"past":     past,
+	})
+}
+
+// parseBookingFilter builds a BookingFilter from the status, from and to query
+// parameters, with dates as for the event listing.
+func parseBookingFilter(c *gin.Context) (models.BookingFilter, error) {
+	var filter models.BookingFilter
+	var err error
+	if raw := c.Query("from"); raw != "" {
+		filter.From, err = parseTimeParam(raw, false)
+		if err != nil {
+			return filter, errors.New("from must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+		}
+	}
+	if raw := c.Query("to"); raw != "" {
+		filter.To, err = parseTimeParam(raw, true)
+		if err != nil {
+			return filter, errors.New("to must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+		}
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return filter, errors.New("to must not be before from")
+	}
+	filter.Status = c.Query("status")
+	switch filter.Status {
+	case "", models.StatusPublished, models.StatusCancelled:
+	default:
+		return filter, errors.New("status must be published or cancelled")
+	}
+	return filter, nil
+}
@@ -0,0 +1,551 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/alerts"
+	"event_booking_restapi_golang/mail"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRegisterForEvent tests the registerForEvent handler up to and beyond capacity
+func TestRegisterForEvent(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.POST("/events/:id/register", testHandler.registerForEvent)
+
+	event := models.Event{
+		Title:       "Workshop",
+		Description: "Test Description",
+		Location:    "Test Location",
+		DateTime:    time.Now(),
+		UserID:      "test-user-123",
+		Capacity:    1,
+	}
+	event, err := event.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to save test event: %v", err)
+	}
+
+	id := event.ID
+
+	register := func(email string) *httptest.ResponseRecorder {
+		jsonData, _ := json.Marshal(map[string]interface{}{"name": "Attendee", "email": email})
+		req, _ := http.NewRequest("POST", "/events/"+id+"/register", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := register("first@example.com")
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status code %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	var response map[string]interface{}
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	if err != nil {
+		t.Errorf("Failed to parse response JSON: %v", err)
+	}
+	if data, _ := response["data"].(map[string]interface{}); data["registration"] == nil {
+		t.Error("Response should contain 'registration' field")
+	}
+
+	w = register("second@example.com")
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status code %d for a full event, got %d", http.StatusConflict, w.Code)
+	}
+}
+
+// TestRegisterForEventAlerts tests that a booking filling the event alerts the organizer
+func TestRegisterForEventAlerts(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.POST("/events/:id/register", testHandler.registerForEvent)
+
+	received := make(chan map[string]any, 5)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert map[string]any
+		json.NewDecoder(r.Body).Decode(&alert)
+		received <- alert
+	}))
+	defer server.Close()
+	notifier, _ := alerts.NewWebhookNotifier(server.URL)
+	Alerts = alerts.NewPolicy(notifier, time.Hour, 0)
+	t.Cleanup(func() { Alerts = nil })
+
+	event := models.Event{
+		Title:       "Alerted Workshop",
+		Description: "Test Description",
+		Location:    "Test Location",
+		DateTime:    time.Now(),
+		UserID:      "organizer-1",
+		Capacity:    1,
+	}
+	event, err := event.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to save test event: %v", err)
+	}
+
+	req, _ := http.NewRequest("POST", "/events/"+event.ID+"/register", bytes.NewBufferString(`{"name":"A","email":"a@example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	for _, kind := range []string{alerts.CapacityNearlyFull, alerts.CapacityFull} {
+		select {
+		case alert := <-received:
+			if alert["kind"] != kind || alert["event_id"] != event.ID || alert["user_id"] != "organizer-1" {
+				t.Errorf("Expected a %s alert for the event, got %v", kind, alert)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected a %s alert", kind)
+		}
+	}
+}
+
+// TestRegisterForEventInvalid tests the registerForEvent handler with bad input
+func TestRegisterForEventInvalid(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.POST("/events/:id/register", testHandler.registerForEvent)
+
+	req, _ := http.NewRequest("POST", "/events/non-existent-id/register", bytes.NewBufferString(`{"name":"A","email":"a@example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+
+	event := models.Event{
+		Title:       "Workshop",
+		Description: "Test Description",
+		Location:    "Test Location",
+		DateTime:    time.Now(),
+	}
+	event, err := event.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to save test event: %v", err)
+	}
+	id := event.ID
+
+	req, _ = http.NewRequest("POST", "/events/"+id+"/register", bytes.NewBufferString(`{"name":"A","email":"not-an-email"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	req, _ = http.NewRequest("POST", "/events/"+id+"/register", bytes.NewBufferString(`{"name":"A","email":"bot@mailinator.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for a disposable email, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestRegistrationConfirmation tests that the event's confirmation settings are validated and returned on booking
+func TestRegistrationConfirmation(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.POST("/event", testHandler.createEvent)
+	router.POST("/events/:id/register", testHandler.registerForEvent)
+
+	create := func(url, message string) *httptest.ResponseRecorder {
+		jsonData, _ := json.Marshal(map[string]interface{}{
+			"title":                "Widget Event",
+			"description":          "Test Description",
+			"location":             "Test Location",
+			"date_time":            time.Now().Format(time.RFC3339),
+			"confirmation_url":     url,
+			"confirmation_message": message,
+		})
+		req, _ := http.NewRequest("POST", "/event", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := create("javascript:alert(1)", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for a script URL, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := create("https://organizer.example/thanks", "<p>Thanks for booking!</p>"); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var id string
+	err := testDB.QueryRow("SELECT id FROM events WHERE name = ?", "Widget Event").Scan(&id)
+	if err != nil {
+		t.Fatalf("Failed to get event ID: %v", err)
+	}
+
+	jsonData, _ := json.Marshal(map[string]interface{}{"name": "Attendee", "email": "attendee@example.com"})
+	req, _ := http.NewRequest("POST", "/events/"+id+"/register", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response struct {
+		Data struct {
+			Confirmation struct {
+				URL     string
+				Message string
+			}
+		}
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Data.Confirmation.URL != "https://organizer.example/thanks" || response.Data.Confirmation.Message != "Thanks for booking!" {
+		t.Errorf("Unexpected confirmation %+v", response.Data.Confirmation)
+	}
+}
+
+// TestBookingEmails tests that bookings, cancellations and waitlist promotions are emailed to the attendees
+func TestBookingEmails(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.POST("/events/:id/register", testHandler.registerForEvent)
+	router.DELETE("/registrations/:id", cancelRegistration)
+	mailer := useFakeMailer(t)
+
+	event := models.Event{
+		Title:               "Jazz Night",
+		Location:            "The Hall",
+		DateTime:            time.Now().Add(24 * time.Hour),
+		Capacity:            1,
+		ConfirmationMessage: "Doors open at 7.",
+	}
+	event, err := event.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to save test event: %v", err)
+	}
+	waitForEmails := func(n int) []mail.Message {
+		deadline := time.Now().Add(time.Second)
+		for len(mailer.messages()) < n && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		return mailer.messages()
+	}
+
+	req, _ := http.NewRequest("POST", "/events/"+event.ID+"/register", bytes.NewBufferString(`{"name":"Alice","email":"alice@example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var response struct {
+		Data struct {
+			Registration models.Registration
+		}
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusCreated || response.Data.Registration.Ticket == nil {
+		t.Fatalf("Expected a booking with a ticket, got %d: %s", w.Code, w.Body.String())
+	}
+	sent := waitForEmails(1)
+	if len(sent) != 1 || sent[0].To != "alice@example.com" || sent[0].Subject != "You're booked for Jazz Night" ||
+		!strings.Contains(sent[0].Body, response.Data.Registration.Ticket.Code) || !strings.Contains(sent[0].Body, "Doors open at 7.") {
+		t.Fatalf("Expected a booking confirmation with the ticket, got %+v", sent)
+	}
+
+	_, err = event.JoinWaitlist(context.Background(), models.WaitlistEntry{Name: "Bob", Email: "bob@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to join the waitlist: %v", err)
+	}
+	req, _ = http.NewRequest("DELETE", "/registrations/"+response.Data.Registration.ID, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	sent = waitForEmails(3)
+	if len(sent) != 3 || sent[1].To != "alice@example.com" || !strings.Contains(sent[1].Subject, "is cancelled") ||
+		sent[2].To != "bob@example.com" || sent[2].Subject != "You're booked for Jazz Night" {
+		t.Errorf("Expected the cancellation and the promoted attendee's confirmation, got %+v", sent)
+	}
+}
+
+// TestRescheduleRegistration tests that attendees move their booking to another
+// occurrence of the series, getting a new ticket by email, and are told why when they can't
+func TestRescheduleRegistration(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.POST("/registrations/:id/reschedule", testHandler.rescheduleRegistration)
+	mailer := useFakeMailer(t)
+
+	ctx := context.Background()
+	var occurrences []models.Event
+	for i, title := range []string{"Pottery 1", "Pottery 2", "Pottery 3"} {
+		event, err := models.Event{Title: title, Location: "Studio", DateTime: time.Now().Add(time.Duration(i+2) * 24 * time.Hour), UserID: "organizer-1", Capacity: 1, SeriesID: "pottery"}.Save(ctx)
+		if err != nil {
+			t.Fatalf("Failed to save event: %v", err)
+		}
+		occurrences = append(occurrences, event)
+	}
+	alice, err := occurrences[0].Register(ctx, models.Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	_, err = occurrences[2].Register(ctx, models.Registration{Name: "Carol", Email: "carol@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	_, err = occurrences[0].JoinWaitlist(ctx, models.WaitlistEntry{Name: "Bob", Email: "bob@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to join waitlist: %v", err)
+	}
+	serve := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/registrations/"+alice.ID+"/reschedule", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := serve(`{}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d without an event_id, got %d", http.StatusBadRequest, w.Code)
+	}
+	w := serve(`{"event_id":"` + occurrences[2].ID + `"}`)
+	var refused map[string]any
+	json.Unmarshal(w.Body.Bytes(), &refused)
+	if w.Code != http.StatusConflict || refused["code"] != problem.CodeEventFull {
+		t.Errorf("Expected event_full for a full occurrence, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = serve(`{"event_id":"` + occurrences[1].ID + `"}`)
+	var response struct {
+		Data struct {
+			Registration models.Registration
+			Promoted     bool
+		}
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	moved := response.Data.Registration
+	if w.Code != http.StatusOK || moved.EventID != occurrences[1].ID || moved.Ticket == nil || !response.Data.Promoted {
+		t.Fatalf("Expected the booking to move to the second occurrence and promote Bob, got %d: %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "bob@example.com") {
+		t.Errorf("Expected the promoted booking to stay out of the response, got %s", w.Body.String())
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(mailer.messages()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	sent := map[string]string{}
+	for _, message := range mailer.messages() {
+		sent[message.To] = message.Subject
+		if message.To == "alice@example.com" && !strings.Contains(message.Body, moved.Ticket.Code) {
+			t.Errorf("Expected the confirmation to carry the new ticket, got %q", message.Body)
+		}
+	}
+	if len(sent) != 2 || sent["alice@example.com"] != "You're booked for Pottery 2" || sent["bob@example.com"] != "You're booked for Pottery 1" {
+		t.Errorf("Expected confirmations for Alice's move and Bob's promotion, got %+v", mailer.messages())
+	}
+	if count, _ := occurrences[0].CountRegistrations(ctx); count != 1 {
+		t.Errorf("Expected Bob to take the freed place, got %d bookings", count)
+	}
+}
+
+// TestGetEventRegistrations tests that only the organizer and admins can page through
+// the attendee list with check-in status
+func TestGetEventRegistrations(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/events/:id/registrations", testHandler.requireOrganizer, testHandler.getEventRegistrations)
+	originalKey := adminAPIKey
+	adminAPIKey = "admin-secret"
+	t.Cleanup(func() { adminAPIKey = originalKey })
+
+	event, err := models.Event{
+		Title:       "Jazz Night",
+		Description: "Test Description",
+		Location:    "Test Location",
+		DateTime:    time.Now(),
+		UserID:      "organizer-1",
+	}.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to save test event: %v", err)
+	}
+	var checkedIn models.Registration
+	for _, name := range []string{"Alice", "Bob"} {
+		registration, err := event.Register(context.Background(), models.Registration{Name: name, Email: name + "@example.com"})
+		if err != nil {
+			t.Fatalf("Failed to register: %v", err)
+		}
+		if name == "Alice" {
+			checkedIn = registration
+		}
+	}
+	_, err = event.CheckIn(context.Background(), checkedIn.Ticket.Code)
+	if err != nil {
+		t.Fatalf("Failed to check in: %v", err)
+	}
+
+	list := func(eventID, query string, headers map[string]string) (*httptest.ResponseRecorder, map[string]any) {
+		req, _ := http.NewRequest("GET", "/events/"+eventID+"/registrations"+query, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response map[string]any
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+
+	total := func(response map[string]any) any {
+		meta, _ := response["meta"].(map[string]any)
+		return meta["total"]
+	}
+	w, response := list(event.ID, "", map[string]string{UserHeader: event.UserID})
+	registrations, _ := response["data"].([]any)
+	if w.Code != http.StatusOK || len(registrations) != 2 || total(response) != float64(2) {
+		t.Fatalf("Expected both registrations for the organizer, got %d: %s", w.Code, w.Body.String())
+	}
+	first, _ := registrations[0].(map[string]any)
+	second, _ := registrations[1].(map[string]any)
+	if first["Name"] != "Alice" || first["CheckedIn"] != true || first["CheckedInAt"] == nil {
+		t.Errorf("Expected Alice to be checked in, got %v", first)
+	}
+	if second["Name"] != "Bob" || second["CheckedIn"] != false || second["CreatedAt"] == nil {
+		t.Errorf("Expected Bob not to be checked in yet, got %v", second)
+	}
+
+	w, response = list(event.ID, "?limit=1&offset=1", map[string]string{"Authorization": "Bearer admin-secret"})
+	registrations, _ = response["data"].([]any)
+	if w.Code != http.StatusOK || len(registrations) != 1 || total(response) != float64(2) {
+		t.Errorf("Expected one registration of two for an admin, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w, _ = list(event.ID, "", map[string]string{UserHeader: event.UserID, "Accept": "text/csv"})
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Body.String(), "id,name,email,created_at,checked_in,checked_in_at\n") ||
+		!strings.Contains(w.Body.String(), ",Alice,Alice@example.com,") || !strings.Contains(w.Body.String(), ",Bob,Bob@example.com,") {
+		t.Errorf("Expected the attendee list as CSV, got %d: %s", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		name     string
+		eventID  string
+		query    string
+		headers  map[string]string
+		expected int
+	}{
+		{"anonymous", event.ID, "", nil, http.StatusForbidden},
+		{"other user", event.ID, "", map[string]string{UserHeader: "someone-else"}, http.StatusForbidden},
+		{"wrong admin key", event.ID, "", map[string]string{"Authorization": "Bearer wrong"}, http.StatusForbidden},
+		{"invalid limit", event.ID, "?limit=0", map[string]string{UserHeader: event.UserID}, http.StatusBadRequest},
+		{"unknown event", "missing", "", map[string]string{UserHeader: event.UserID}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w, _ := list(tt.eventID, tt.query, tt.headers)
+		if w.Code != tt.expected {
+			t.Errorf("%s: expected status code %d, got %d: %s", tt.name, tt.expected, w.Code, w.Body.String())
+		}
+	}
+}
+
+// TestGetMyRegistrations tests listing an attendee's upcoming and past bookings with the
+// status and date filters
+func TestGetMyRegistrations(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/users/me/registrations", getMyRegistrations)
+
+	ctx := context.Background()
+	var registrationID string
+	for i, title := range []string{"Last Month", "Yesterday", "Tomorrow", "Next Month"} {
+		offset := []time.Duration{-30 * 24 * time.Hour, -24 * time.Hour, 24 * time.Hour, 30 * 24 * time.Hour}[i]
+		event, err := models.Event{Title: title, Description: "Live music", Location: "Club", DateTime: time.Now().Add(offset)}.Save(ctx)
+		if err != nil {
+			t.Fatalf("Failed to save event: %v", err)
+		}
+		registration, err := event.Register(ctx, models.Registration{Name: "Alice", Email: "alice@example.com"})
+		if err != nil {
+			t.Fatalf("Failed to register: %v", err)
+		}
+		registrationID = registration.ID
+		if title == "Next Month" {
+			_, err = event.Cancel(ctx)
+			if err != nil {
+				t.Fatalf("Failed to cancel event: %v", err)
+			}
+		}
+	}
+
+	list := func(query, registrationID string) (*httptest.ResponseRecorder, map[string][]string) {
+		req, _ := http.NewRequest("GET", "/users/me/registrations"+query, nil)
+		if registrationID != "" {
+			req.Header.Set(RegistrationHeader, registrationID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response struct{ Data map[string][]models.Booking }
+		json.Unmarshal(w.Body.Bytes(), &response)
+		titles := map[string][]string{}
+		for group, bookings := range response.Data {
+			titles[group] = []string{}
+			for _, b := range bookings {
+				titles[group] = append(titles[group], b.Event.Title)
+			}
+		}
+		return w, titles
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		upcoming string
+		past     string
+	}{
+		{"all", "", "Tomorrow,Next Month", "Yesterday,Last Month"},
+		{"published", "?status=published", "Tomorrow", "Yesterday,Last Month"},
+		{"cancelled", "?status=cancelled", "Next Month", ""},
+		{"dates", "?from=" + time.Now().Add(-48*time.Hour).Format(time.DateOnly) + "&to=" + time.Now().Add(48*time.Hour).Format(time.DateOnly), "Tomorrow", "Yesterday"},
+	}
+	for _, tt := range tests {
+		w, titles := list(tt.query, registrationID)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status code %d, got %d: %s", tt.name, http.StatusOK, w.Code, w.Body.String())
+		}
+		upcoming, past := strings.Join(titles["upcoming"], ","), strings.Join(titles["past"], ",")
+		if upcoming != tt.upcoming || past != tt.past {
+			t.Errorf("%s: expected upcoming %q and past %q, got %q and %q", tt.name, tt.upcoming, tt.past, upcoming, past)
+		}
+	}
+
+	req, _ := http.NewRequest("GET", "/users/me/registrations", nil)
+	req.Header.Set(RegistrationHeader, registrationID)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var listed struct{ Data map[string][]models.Booking }
+	json.Unmarshal(w.Body.Bytes(), &listed)
+	for _, b := range append(listed.Data["upcoming"], listed.Data["past"]...) {
+		if own := b.Event.Title == "Next Month"; (b.Registration.ID == registrationID) != own || (!own && b.Registration.ID != "") {
+			t.Errorf("Expected only the caller's own booking to carry its registration ID, got %q for %s", b.Registration.ID, b.Event.Title)
+		}
+	}
+
+	w, _ = list("?status=draft", registrationID)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an invalid status, got %d", http.StatusBadRequest, w.Code)
+	}
+	w, _ = list("", "")
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d without a registration, got %d", http.StatusForbidden, w.Code)
+	}
+}
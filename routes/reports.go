@@ -0,0 +1,209 @@
+package routes
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// reportParam describes a query parameter a report accepts.
+type reportParam struct {
+	Name        string
+	Description string
+}
+
+// report is a fixed, parameterized query that ops staff can run without database
+// access. Only the parameters it declares are accepted, and they are bound to the
+// query as values, never spliced into SQL.
+type report struct {
+	Name        string
+	Description string
+	Params      []reportParam
+	Columns     []string
+	run         func(ctx context.Context, params url.Values) ([][]string, error)
+}
+
+// reports are the reports available under /admin/reports, by name.
+var reports = map[string]report{
+	"registrations_by_day": {
+		Name:        "registrations_by_day",
+		Description: "Registrations made per UTC day, as of the last summary refresh; cancelled registrations are not counted",
+		Params: []reportParam{
+			{"from", "First day, YYYY-MM-DD (default 30 days ago)"},
+			{"to", "Last day, YYYY-MM-DD, inclusive (default today)"},
+			{"event_id", "Only registrations for this event"},
+		},
+		Columns: []string{"day", "registrations", "events"},
+		run:     runRegistrationsByDay,
+	},
+	"churned_organizers": {
+		Name:        "churned_organizers",
+		Description: "Organizers whose latest event was created before the inactivity cutoff",
+		Params: []reportParam{
+			{"inactive_days", "Days without a new event (default 90)"},
+		},
+		Columns: []string{"user_id", "events", "last_created_at", "last_event_at"},
+		run:     runChurnedOrganizers,
+	},
+}
+
+// runRegistrationsByDay runs the registrations_by_day report.
+func runRegistrationsByDay(ctx context.Context, params url.Values) ([][]string, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	from, to := today.AddDate(0, 0, -30), today
+	var err error
+	if raw := params.Get("from"); raw != "" {
+		from, err = time.Parse(time.DateOnly, raw)
+		if err != nil {
+			return nil, reportParamError("from must be a YYYY-MM-DD date")
+		}
+	}
+	if raw := params.Get("to"); raw != "" {
+		to, err = time.Parse(time.DateOnly, raw)
+		if err != nil {
+			return nil, reportParamError("to must be a YYYY-MM-DD date")
+		}
+	}
+	if to.Before(from) {
+		return nil, reportParamError("to must not be before from")
+	}
+
+	days, err := models.GetRegistrationsByDay(ctx, from, to.AddDate(0, 0, 1), params.Get("event_id"))
+	if err != nil {
+		return nil, err
+	}
+	rows := make([][]string, 0, len(days))
+	for _, d := range days {
+		rows = append(rows, []string{d.Day, strconv.Itoa(d.Registrations), strconv.Itoa(d.Events)})
+	}
+	return rows, nil
+}
+
+// runChurnedOrganizers runs the churned_organizers report.
+func runChurnedOrganizers(ctx context.Context, params url.Values) ([][]string, error) {
+	days := 90
+	if raw := params.Get("inactive_days"); raw != "" {
+		var err error
+		days, err = strconv.Atoi(raw)
+		if err != nil || days < 1 {
+			return nil, reportParamError("inactive_days must be a positive integer")
+		}
+	}
+
+	organizers, err := models.GetChurnedOrganizers(ctx, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return nil, err
+	}
+	rows := make([][]string, 0, len(organizers))
+	for _, o := range organizers {
+		rows = append(rows, []string{
+			o.UserID, strconv.Itoa(o.Events),
+			o.LastCreatedAt.UTC().Format(time.RFC3339), o.LastEventAt.UTC().Format(time.RFC3339),
+		})
+	}
+	return rows, nil
+}
+
+// reportParamError is an invalid report parameter value, reported as HTTP 400.
+type reportParamError string
+
+func (e reportParamError) Error() string {
+	return string(e)
+}
+
+// getReports handles GET requests to /admin/reports endpoint.
+// It lists the available reports with their parameters and columns, by name.
+// Returns HTTP 200 with the reports.
+func getReports(c *gin.Context) {
+	list := make([]report, 0, len(reports))
+	for _, r := range reports {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	response.Write(c, http.StatusOK, response.List(list, len(list)))
+}
+
+// runReport handles GET requests to /admin/reports/:name endpoint.
+// It runs the named report with the query parameters it declares and returns its
+// rows as JSON, or as a CSV download with ?format=csv.
+// Returns HTTP 404 for an unknown report, HTTP 400 for an unknown format, an
+// undeclared parameter or an invalid parameter value, HTTP 500 if the query fails,
+// otherwise HTTP 200 with the report.
+func runReport(c *gin.Context) {
+	name, _ := c.Params.Get("name")
+	r, ok := reports[name]
+	if !ok {
+		problem.Respond(c, http.StatusNotFound, problem.CodeNotFound, "no report named "+name)
+		return
+	}
+
+	params := c.Request.URL.Query()
+	format := params.Get("format")
+	params.Del("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		problem.Write(c, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "format must be json or csv").With("field", "format"))
+		return
+	}
+	for key := range params {
+		if !r.accepts(key) {
+			problem.Write(c, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "report "+name+" has no parameter "+key).With("field", key))
+			return
+		}
+	}
+
+	rows, err := r.run(c.Request.Context(), params)
+	var paramErr reportParamError
+	if errors.As(err, &paramErr) {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+
+	if format == "json" {
+		response.Respond(c, http.StatusOK, gin.H{
+			"report":  name,
+			"columns": r.Columns,
+			"rows":    rows,
+		})
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="`+name+`.csv"`)
+	c.Status(http.StatusOK)
+	w := csv.NewWriter(c.Writer)
+	w.Write(r.Columns)
+	for _, row := range rows {
+		for i := range row {
+			row[i] = csvSafe(row[i])
+		}
+		w.Write(row)
+	}
+	w.Flush()
+}
+
+// accepts reports whether the report declares the parameter.
+func (r report) accepts(param string) bool {
+	for _, p := range r.Params {
+		if p.Name == param {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package routes
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"event_booking_restapi_golang/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestGetReports tests listing the available reports
+func TestGetReports(t *testing.T) {
+	router := setupTestRouter()
+	router.GET("/admin/reports", getReports)
+
+	req, _ := http.NewRequest("GET", "/admin/reports", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response struct{ Data []report }
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusOK || len(response.Data) != len(reports) {
+		t.Fatalf("Expected %d reports, got %d %s", len(reports), w.Code, w.Body.String())
+	}
+	if response.Data[0].Name != "churned_organizers" || len(response.Data[1].Params) != 3 {
+		t.Errorf("Expected reports sorted by name with their parameters, got %+v", response.Data)
+	}
+}
+
+// TestRunReport tests running a report as JSON and CSV
+func TestRunReport(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/admin/reports/:name", runReport)
+
+	day := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	for i, email := range []string{"a@example.com", "b@example.com", "=cmd@example.com"} {
+		_, err := testDB.Exec("INSERT INTO registrations (id, event_id, name, email, created_at) VALUES (?,?,?,?,?)",
+			email, "e1", "Attendee", email, day.Add(time.Duration(i)*24*time.Hour))
+		if err != nil {
+			t.Fatalf("Failed to insert registration: %v", err)
+		}
+	}
+
+	err := models.RefreshRegistrationSummary(context.Background(), time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to refresh the registration summary: %v", err)
+	}
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/admin/reports/"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("registrations_by_day?from=2026-03-10&to=2026-03-11")
+	var response struct {
+		Data struct {
+			Columns []string
+			Rows    [][]string
+		}
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusOK || len(response.Data.Rows) != 2 || response.Data.Rows[0][0] != "2026-03-10" || response.Data.Rows[0][1] != "1" {
+		t.Fatalf("Expected two days of registrations, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = get("registrations_by_day?from=2026-03-10&to=2026-03-12&format=csv")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("Expected a CSV download, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil || len(records) != 4 || strings.Join(records[0], ",") != "day,registrations,events" {
+		t.Errorf("Expected a header and three days, got %v (%v)", records, err)
+	}
+
+	w = get("churned_organizers?inactive_days=30")
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected the churned organizers report, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+// TestRunReportRejected tests that unknown reports, undeclared parameters and invalid values are refused
+func TestRunReportRejected(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/admin/reports/:name", runReport)
+
+	tests := map[string]int{
+		"revenue_by_day":                                     http.StatusNotFound,
+		"registrations_by_day?format=xml":                    http.StatusBadRequest,
+		"registrations_by_day?order=day":                     http.StatusBadRequest,
+		"registrations_by_day?from=yesterday":                http.StatusBadRequest,
+		"registrations_by_day?from=2026-03-10&to=2026-03-01": http.StatusBadRequest,
+		"churned_organizers?inactive_days=0":                 http.StatusBadRequest,
+		"churned_organizers?event_id=e1":                     http.StatusBadRequest,
+	}
+	for query, expected := range tests {
+		req, _ := http.NewRequest("GET", "/admin/reports/"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != expected {
+			t.Errorf("%s: expected status %d, got %d %s", query, expected, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package routes
+
+import (
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/validation"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Content reuse policies of the platform, chosen with CONTENT_REUSE.
+const (
+	ReuseAll      = "all"      // Every public event can be exported
+	ReuseLicensed = "licensed" // Only public events whose organizer set a license can be exported
+	ReuseNone     = "none"     // No event can be exported
+)
+
+// ContentReuse is the platform's policy for exposing public events to other sites and
+// instances through the signed event packages and the event calendar files. It is set
+// by main from CONTENT_REUSE.
+var ContentReuse = ReuseAll
+
+// reusable reports whether ContentReuse lets the event be exported.
+func reusable(e models.Event) bool {
+	return ContentReuse == ReuseAll || ContentReuse == ReuseLicensed && e.License != ""
+}
+
+// checkReusable checks that ContentReuse lets the event be exported.
+// Writes HTTP 403 and returns false otherwise.
+func checkReusable(c *gin.Context, e models.Event) bool {
+	switch {
+	case ContentReuse == ReuseNone:
+		problem.Respond(c, http.StatusForbidden, problem.CodeReuseDisabled, "this platform doesn't expose events for reuse")
+		return false
+	case ContentReuse == ReuseLicensed && e.License == "":
+		problem.Respond(c, http.StatusForbidden, problem.CodeReuseDisabled, "the organizer hasn't licensed this event for reuse")
+		return false
+	}
+	return true
+}
+
+// cleanLicense validates the event's content license. Writes HTTP 400 and returns false
+// if it is invalid.
+func cleanLicense(c *gin.Context, e *models.Event) bool {
+	var err error
+	e.License, err = validation.ValidateLicense(e.License)
+	if err != nil {
+		problem.Write(c, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, err.Error()).With("field", "license"))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestContentReuse tests that the platform's reuse policy decides which events can be
+// exported as packages and calendar files
+func TestContentReuse(t *testing.T) {
+	setupTestDatabase(t)
+	packageSecret = []byte("package-secret")
+	t.Cleanup(func() { packageSecret, ContentReuse = nil, ReuseAll })
+	router := setupTestRouter()
+	router.GET("/events/:id/export", testHandler.exportEvent)
+	router.GET("/events/:id/ical", testHandler.getEventICal)
+
+	ctx := context.Background()
+	unlicensed, err := models.Event{Title: "Jazz Night", Description: "Live music", Location: "Club", DateTime: time.Now().Add(time.Hour)}.Save(ctx)
+	if err != nil {
+		t.Fatalf("Failed to save event: %v", err)
+	}
+	licensed, err := models.Event{Title: "Open Mic", Description: "Bring a song", Location: "Club", DateTime: time.Now().Add(time.Hour), License: "CC-BY-4.0"}.Save(ctx)
+	if err != nil {
+		t.Fatalf("Failed to save event: %v", err)
+	}
+
+	tests := []struct {
+		policy                       string
+		unlicensedCode, licensedCode int
+	}{
+		{ReuseAll, http.StatusOK, http.StatusOK},
+		{ReuseLicensed, http.StatusForbidden, http.StatusOK},
+		{ReuseNone, http.StatusForbidden, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		ContentReuse = tt.policy
+		for _, endpoint := range []string{"export", "ical"} {
+			for event, expected := range map[string]int{unlicensed.ID: tt.unlicensedCode, licensed.ID: tt.licensedCode} {
+				req, _ := http.NewRequest("GET", "/events/"+event+"/"+endpoint, nil)
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				if w.Code != expected {
+					t.Errorf("%s: expected status code %d for %s of %s, got %d", tt.policy, expected, endpoint, event, w.Code)
+				}
+				if w.Code == http.StatusForbidden {
+					var body map[string]any
+					json.Unmarshal(w.Body.Bytes(), &body)
+					if body["code"] != problem.CodeReuseDisabled {
+						t.Errorf("Expected code %s, got %v", problem.CodeReuseDisabled, body["code"])
+					}
+				}
+			}
+		}
+	}
+
+	// The license travels with exported packages
+	ContentReuse = ReuseLicensed
+	req, _ := http.NewRequest("GET", "/events/"+licensed.ID+"/export", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var pkg EventPackage
+	json.Unmarshal(w.Body.Bytes(), &pkg)
+	if pkg.Event.License != "CC-BY-4.0" {
+		t.Errorf("Expected the package to carry the license, got %+v", pkg.Event)
+	}
+}
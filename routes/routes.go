@@ -2,19 +2,263 @@
 // It registers all API endpoints with the Gin router and maps them to their handler functions.
 package routes
 
-import "github.com/gin-gonic/gin"
+import (
+	"event_booking_restapi_golang/experiments"
+	"event_booking_restapi_golang/ids"
+	"event_booking_restapi_golang/middlewares"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/validation"
+	"net/http"
+	"os"
 
-// RegisterRoutes registers all API routes with the provided Gin engine.
+	"github.com/gin-gonic/gin"
+)
+
+// EventHandler serves the endpoints that read or change events.
+// It gets its event storage from the caller, so tests can pass an in-memory repository.
+type EventHandler struct {
+	events models.EventRepository
+}
+
+// NewEventHandler creates an EventHandler that stores events in the given repository.
+func NewEventHandler(events models.EventRepository) *EventHandler {
+	return &EventHandler{events: events}
+}
+
+// adminAPIKey is the ADMIN_API_KEY that admin endpoints, and the organizer endpoints
+// admins may also use, require as a bearer token. It is set by RegisterRoutes.
+var adminAPIKey string
+
+// PublicReadEndpoints are the endpoints a read-only public mirror serves (READ_ONLY=true):
+// the public event listing and search, single events and their calendar files, and the
+// probes, status page, metrics and documentation.
+var PublicReadEndpoints = []string{
+	"GET /events",
+	"GET /events/:id",
+	"GET /events/:id/ical",
+	"GET /organizers/:id/calendar.ics",
+	"GET /healthz",
+	"GET /readyz",
+	"GET /status",
+	"GET /metrics",
+	"GET /docs",
+	"GET /docs/openapi.json",
+}
+
+// RegisterRoutes registers all API routes with the provided Gin engine,
+// serving events from the given repository. Errors from every endpoint, including
+// unknown paths, are RFC 7807 problem details (see the problem package).
+// Endpoints that manage an event, its attendees or its attachments are limited to the
+// event's organizer, identified by the X-User-ID header, and admins.
+// Deleting, cancelling and importing events take dry_run=true to preview the change
+// without making it (see dryRun).
+// When ids.Public obfuscates identifiers, every route takes and returns the public
+// identifiers instead of UUIDs (see publicIDs).
 // It sets up the following endpoints:
 //   - GET /events/:id - Get a specific event by ID
 //   - GET /events - Get all events
 //   - POST /event - Create a new event
 //   - PUT /events/:id - Update an existing event
+//   - PATCH /events/:id - Update only the given fields of an event
 //   - DELETE /events/:id - Delete an event
-func RegisterRoutes(server *gin.Engine) {
-	server.GET("/events", getEvents)
-	server.POST("/event", createEvent)
-	server.PUT("/events/:id", updateEvent)
-	server.GET("/events/:id", getEvent)
-	server.DELETE("/events/:id", deleteEvent)
+//   - POST /events/:id/publish - Publish a draft event
+//   - POST /events/:id/cancel - Cancel an event and email its attendees
+//   - GET /events/:id/ical - Download an event as an iCalendar file
+//   - GET /organizers/:id/calendar.ics - Subscribe to an organizer's public upcoming events as an iCalendar feed
+//   - GET /users/me/calendar.ics - Download all of an attendee's booked events as an iCalendar file
+//   - GET /users/me/registrations - An attendee's upcoming and past bookings (needs X-Registration-ID)
+//   - GET /users/me/events - The events the organizer in X-User-ID created, including drafts
+//   - GET /users/me/pending - The organizer's checklist of pending actions, as in the daily digest
+//   - GET /users/me/onboarding - The organizer's onboarding steps and which are done
+//   - GET /events/:id/export - Export a public event as a signed package
+//   - POST /events/import-package - Import a signed event package from another instance
+//   - POST /events/import - Create the events of an uploaded CSV or .ics file in one transaction
+//   - GET /events/:id/import - Show where an imported event came from
+//   - PUT /events/by-external-id/:source/:external_id - Create or update an event synced from a partner system (source key or admin key)
+//   - PUT /events/by-external-id/:source/:external_id/registrations/:registration_id - Create or update a synced booking (source key or admin key)
+//   - POST /events/:id/register - Register an attendee for an event (CAPTCHA protected)
+//   - DELETE /registrations/:id - Cancel a registration, promoting from the waitlist
+//   - GET /registrations/:id/ticket - Get the signed ticket of a registration
+//   - POST /registrations/:id/reschedule - Move a booking to another occurrence of its event's series
+//   - POST /registrations/:id/refund-request - Ask for a refund, approved at once early enough before the event
+//   - GET /registrations/:id/refund-request - Follow the refund request of a booking
+//   - GET /events/:id/refund-requests - The organizer's refund requests for the event
+//   - POST /events/:id/refund-requests/:request_id/approve - Approve a refund, cancelling the booking
+//   - POST /events/:id/refund-requests/:request_id/deny - Deny a refund
+//   - POST /events/:id/checkin - Check in the holder of a ticket code at the door
+//   - GET /events/:id/registrations - The organizer's attendee list with check-in status
+//   - GET /events/:id/forecast - Projected final attendance with confidence bounds
+//   - GET /events/:id/stats - Bookings per day, check-in, cancellation and capacity rates
+//   - GET /events/:id/live - WebSocket pushing registration and check-in counts as they change, credentials may go in the query
+//   - POST /events/:id/waitlist - Join the waitlist of a full event (CAPTCHA protected)
+//   - GET /events/:id/sender - Sender name and reply-to address for attendee emails
+//   - PUT /events/:id/sender - Set them, emailing a code to a new reply-to address
+//   - POST /events/:id/sender/verify - Confirm the reply-to address with the code
+//   - GET /events/:id/waitlist - Get the waitlist of an event
+//   - POST /events/:id/messages - Send the organizer a question (needs X-Registration-ID)
+//   - GET /events/:id/messages - The attendee's conversation with the organizer (needs X-Registration-ID)
+//   - GET /events/:id/inbox - The organizer's conversations with attendees and unread counts
+//   - GET /events/:id/inbox/:registration_id - The organizer's conversation with one attendee
+//   - POST /events/:id/inbox/:registration_id - Reply to an attendee
+//   - POST /events/:id/messages/block - Stop the organizer messaging the attendee (needs X-Registration-ID)
+//   - DELETE /events/:id/messages/block - Let the organizer message the attendee again
+//   - GET /events/:id/blocks - Attendees blocked from the organizer's events
+//   - POST /events/:id/blocks - Block an attendee from booking and messaging the organizer
+//   - DELETE /events/:id/blocks/:email - Lift the block of an attendee
+//   - POST /events/:id/attachments - Attach a file (agenda, waiver) to an event
+//   - GET /events/:id/attachments - List the attachments of an event
+//   - GET /attachments/:id - Download an attachment (attendee-only files need X-Registration-ID)
+//   - DELETE /attachments/:id - Remove an attachment
+//   - GET /analytics/opt-out - Whether the session has opted out of usage analytics
+//   - POST /analytics/opt-out - Opt the session out of usage analytics
+//   - DELETE /analytics/opt-out - Opt the session back in to usage analytics
+//
+// Admin endpoints require the ADMIN_API_KEY as a bearer token:
+//   - GET /admin/moderation/events - List events pending review
+//   - POST /admin/moderation/events/:id/approve - Approve an event for public listing
+//   - POST /admin/moderation/events/:id/reject - Reject an event with a reason
+//   - GET /admin/content-rules - List content policy rules
+//   - POST /admin/content-rules - Add a content policy rule
+//   - DELETE /admin/content-rules/:id - Remove a content policy rule
+//   - GET /admin/venue-blackouts - List the days venues are unavailable
+//   - POST /admin/venue-blackouts - Mark a venue unavailable on a day
+//   - DELETE /admin/venue-blackouts/:id - Remove a venue blackout
+//   - PUT /admin/users/:user_id/onboarding/:step - Record an onboarding step done outside the service
+//   - POST /admin/users/:user_id/token - Issue the organizer token of a user ID
+//   - GET /admin/events/:id/attendees - Export attendees with waiver acceptance (JSON or ?format=csv)
+//   - GET /admin/events/:id/analytics - Registration counts by source, medium and campaign
+//   - GET /admin/experiments/:name - Exposures and conversions per experiment variant
+//   - GET /admin/audit - Page through the ticket sales audit log
+//   - GET /admin/audit/verify - Check the audit log's hash chain
+//   - GET /admin/reports - List the reporting queries and their parameters
+//   - GET /admin/reports/:name - Run a reporting query (JSON or ?format=csv)
+//   - GET /admin/export/events - Stream every event, and with ?include=registrations every registration, as NDJSON
+//   - GET /admin/stats - Totals, events created per month and the most booked events
+//   - POST /admin/summaries/refresh - Refresh the analytics summary tables now
+//   - GET /admin/messages - Page through attendee and organizer messages for abuse handling
+//   - GET /admin/feeds/runs - Page through the reports of XML feed imports
+//   - POST /admin/feeds/:name/run - Import a configured XML feed now
+//   - GET /admin/recordings - Page through the recorded requests of the users and routes being debugged
+//   - DELETE /admin/recordings - Drop the recorded requests
+//   - GET /admin/clients/stats - Request and error counts per API client
+//   - GET /healthz - Liveness probe
+//   - GET /readyz - Readiness probe checking the database and its migrations
+//   - GET /status - Public status page with the health and uptime of the components
+//   - GET /metrics - Request and database metrics in the Prometheus text format
+//   - GET /docs - Swagger UI for exploring the API
+//   - GET /docs/openapi.json - OpenAPI 3 specification of the API
+func RegisterRoutes(server *gin.Engine, events models.EventRepository) {
+	h := NewEventHandler(events)
+	moderationEnabled = os.Getenv("MODERATION_MODE") == "true"
+	attachmentScanner = validation.AttachmentScannerFromEnv()
+	rankingExperiment = experiments.RankingFromEnv()
+	captcha := middlewares.Captcha(middlewares.CaptchaVerifierFromEnv())
+	packageSecret = []byte(os.Getenv("EVENT_PACKAGE_SECRET"))
+	packageSource = os.Getenv("EVENT_PACKAGE_SOURCE")
+	adminAPIKey = os.Getenv("ADMIN_API_KEY")
+	organizerTokenSecret = []byte(os.Getenv("ORGANIZER_TOKEN_SECRET"))
+	liveAllowedOrigins = parseOrigins(os.Getenv("LIVE_ALLOWED_ORIGINS"))
+	packageSignature := middlewares.VerifySignature(packageSecret, middlewares.NewMemoryNonceStore(), packageSignatureTolerance)
+	if _, plain := ids.Public.(ids.Plain); !plain {
+		server.Use(publicIDs)
+	}
+	server.Use(authenticateUser)
+
+	server.GET("/events", h.getEvents)
+	server.POST("/event", h.createEvent)
+	server.PUT("/events/:id", h.requireOrganizer, h.updateEvent)
+	server.PATCH("/events/:id", h.requireOrganizer, h.patchEvent)
+	server.GET("/events/:id", h.getEvent)
+	server.DELETE("/events/:id", h.requireOrganizer, dryRun, h.deleteEvent)
+	server.POST("/events/:id/publish", h.requireOrganizer, h.publishEvent)
+	server.POST("/events/:id/cancel", h.requireOrganizer, dryRun, h.cancelEvent)
+	server.GET("/events/:id/ical", h.getEventICal)
+	server.GET("/organizers/:id/calendar.ics", h.getOrganizerCalendar)
+	server.GET("/users/me/calendar.ics", getMyCalendar)
+	server.GET("/users/me/registrations", getMyRegistrations)
+	server.GET("/users/me/events", h.getMyEvents)
+	server.GET("/users/me/pending", getMyPendingItems)
+	server.GET("/users/me/onboarding", getMyOnboarding)
+	server.GET("/events/:id/export", h.exportEvent)
+	server.POST("/events/import-package", packageSignature, dryRun, h.importEventPackage)
+	server.POST("/events/import", dryRun, h.importEvents)
+	server.GET("/events/:id/import", h.getEventImport)
+	server.PUT("/events/by-external-id/:source/:external_id", requireSyncKey, h.upsertExternalEvent)
+	server.PUT("/events/by-external-id/:source/:external_id/registrations/:registration_id", requireSyncKey, h.upsertExternalRegistration)
+	server.POST("/events/:id/register", captcha, h.registerForEvent)
+	server.DELETE("/registrations/:id", cancelRegistration)
+	server.GET("/registrations/:id/ticket", h.getTicket)
+	server.POST("/registrations/:id/reschedule", h.rescheduleRegistration)
+	server.POST("/registrations/:id/refund-request", requestRefund)
+	server.GET("/registrations/:id/refund-request", getRefundRequest)
+	server.GET("/events/:id/refund-requests", h.requireOrganizer, h.getEventRefundRequests)
+	server.POST("/events/:id/refund-requests/:request_id/approve", h.requireOrganizer, h.approveRefundRequest)
+	server.POST("/events/:id/refund-requests/:request_id/deny", h.requireOrganizer, h.denyRefundRequest)
+	server.POST("/events/:id/checkin", h.requireOrganizer, h.checkInAttendee)
+	server.GET("/events/:id/registrations", h.requireOrganizer, h.getEventRegistrations)
+	server.GET("/events/:id/forecast", h.requireOrganizer, h.getEventForecast)
+	server.GET("/events/:id/stats", h.requireOrganizer, h.getEventStats)
+	server.GET("/events/:id/live", liveCredentials, authenticateUser, h.requireOrganizer, h.getLiveAttendance)
+	server.POST("/events/:id/waitlist", captcha, h.joinWaitlist)
+	server.GET("/events/:id/waitlist", h.requireOrganizer, h.getWaitlist)
+	server.POST("/events/:id/messages", h.sendMessage)
+	server.GET("/events/:id/messages", h.getMessages)
+	server.GET("/events/:id/inbox", h.requireOrganizer, h.getInbox)
+	server.GET("/events/:id/inbox/:registration_id", h.requireOrganizer, h.getInboxConversation)
+	server.POST("/events/:id/inbox/:registration_id", h.requireOrganizer, h.replyToMessage)
+	server.POST("/events/:id/messages/block", h.blockOrganizer)
+	server.DELETE("/events/:id/messages/block", h.unblockOrganizer)
+	server.GET("/events/:id/blocks", h.requireOrganizer, h.getBlocks)
+	server.POST("/events/:id/blocks", h.requireOrganizer, h.blockAttendee)
+	server.DELETE("/events/:id/blocks/:email", h.requireOrganizer, h.unblockAttendee)
+	server.GET("/events/:id/sender", h.requireOrganizer, h.getEventSender)
+	server.PUT("/events/:id/sender", h.requireOrganizer, h.updateEventSender)
+	server.POST("/events/:id/sender/verify", h.requireOrganizer, h.verifyEventSender)
+	server.POST("/events/:id/attachments", h.requireOrganizer, h.addAttachment)
+	server.GET("/events/:id/attachments", h.getAttachments)
+	server.GET("/attachments/:id", h.downloadAttachment)
+	server.DELETE("/attachments/:id", h.requireAttachmentOrganizer, deleteAttachment)
+	server.GET("/analytics/opt-out", getAnalyticsOptOut)
+	server.POST("/analytics/opt-out", optOutOfAnalytics)
+	server.DELETE("/analytics/opt-out", optInToAnalytics)
+	server.GET("/healthz", getHealthz)
+	server.GET("/readyz", getReadyz)
+	server.GET("/status", getStatus)
+	server.GET("/metrics", getMetrics)
+	server.GET("/docs", getDocs)
+	server.GET("/docs/openapi.json", getOpenAPISpec)
+
+	server.NoRoute(func(c *gin.Context) {
+		problem.Respond(c, http.StatusNotFound, problem.CodeNotFound, "no endpoint matches "+c.Request.Method+" "+c.Request.URL.Path)
+	})
+
+	admin := server.Group("/admin", middlewares.RequireAdmin(adminAPIKey))
+	admin.GET("/moderation/events", h.getPendingEvents)
+	admin.POST("/moderation/events/:id/approve", h.approveEvent)
+	admin.POST("/moderation/events/:id/reject", h.rejectEvent)
+	admin.GET("/content-rules", getContentRules)
+	admin.POST("/content-rules", createContentRule)
+	admin.DELETE("/content-rules/:id", deleteContentRule)
+	admin.GET("/venue-blackouts", getVenueBlackouts)
+	admin.POST("/venue-blackouts", createVenueBlackout)
+	admin.DELETE("/venue-blackouts/:id", deleteVenueBlackout)
+	admin.PUT("/users/:user_id/onboarding/:step", completeOnboardingStep)
+	admin.POST("/users/:user_id/token", issueOrganizerToken)
+	admin.GET("/events/:id/attendees", h.exportAttendees)
+	admin.GET("/events/:id/analytics", h.getEventAnalytics)
+	admin.GET("/experiments/:name", getExperimentResults)
+	admin.GET("/audit", getAuditLog)
+	admin.GET("/audit/verify", verifyAuditLog)
+	admin.GET("/reports", getReports)
+	admin.GET("/reports/:name", runReport)
+	admin.GET("/export/events", exportEvents)
+	admin.GET("/stats", getStats)
+	admin.POST("/summaries/refresh", refreshSummaries)
+	admin.GET("/messages", getAdminMessages)
+	admin.GET("/feeds/runs", getFeedRuns)
+	admin.POST("/feeds/:name/run", dryRun, runFeed)
+	admin.GET("/recordings", getRecordings)
+	admin.DELETE("/recordings", clearRecordings)
+	admin.GET("/clients/stats", getClientStats)
 }
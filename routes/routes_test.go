@@ -0,0 +1,238 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"event_booking_restapi_golang/middlewares"
+	"event_booking_restapi_golang/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// mockEventRepository is an in-memory EventRepository for testing handlers without a database
+type mockEventRepository struct {
+	events    map[string]models.Event
+	getErr    error
+	deleteErr error
+	deleted   []string
+}
+
+func (m *mockEventRepository) GetEventById(ctx context.Context, id string) (models.Event, error) {
+	if m.getErr != nil {
+		return models.Event{}, m.getErr
+	}
+	event, ok := m.events[id]
+	if !ok {
+		return models.Event{}, models.NotFoundError{Message: "Couldn't find an event with the ID of " + id, Kind: models.ErrEventNotFound}
+	}
+	return event, nil
+}
+
+func (m *mockEventRepository) GetEventByExternalID(ctx context.Context, source, externalID string) (models.Event, error) {
+	for _, e := range m.events {
+		if e.Source == source && e.ExternalID == externalID {
+			return e, nil
+		}
+	}
+	return models.Event{}, models.NotFoundError{Message: "Couldn't find an event with the external ID of " + externalID}
+}
+
+func (m *mockEventRepository) QueryEvents(ctx context.Context, filter models.EventFilter) ([]models.Event, error) {
+	var events []models.Event
+	for _, e := range m.events {
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+func (m *mockEventRepository) CountEvents(ctx context.Context, filter models.EventFilter) (int, error) {
+	return len(m.events), nil
+}
+
+func (m *mockEventRepository) GetEventsByReviewStatus(ctx context.Context, status string) ([]models.Event, error) {
+	var events []models.Event
+	for _, e := range m.events {
+		if e.ReviewStatus == status {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (m *mockEventRepository) GetEventsByUserId(ctx context.Context, userID string, filter models.UserEventFilter, limit, offset int) ([]models.Event, int, error) {
+	var events []models.Event
+	for _, e := range m.events {
+		if e.UserID == userID && (filter.Status == "" || e.Status == filter.Status) {
+			events = append(events, e)
+		}
+	}
+	return events, len(events), nil
+}
+
+func (m *mockEventRepository) GetEventVersionAt(ctx context.Context, id string, at time.Time) (models.EventVersion, error) {
+	e, ok := m.events[id]
+	if !ok || e.CreatedAt.After(at) {
+		return models.EventVersion{}, models.NotFoundError{Message: "event not found"}
+	}
+	return models.EventVersion{Event: e, Version: 1, RecordedAt: e.CreatedAt}, nil
+}
+
+func (m *mockEventRepository) Save(ctx context.Context, e models.Event) (models.Event, error) {
+	m.events[e.ID] = e
+	return e, nil
+}
+
+func (m *mockEventRepository) UpsertByExternalID(ctx context.Context, e models.Event) (models.Event, bool, error) {
+	existing, err := m.GetEventByExternalID(ctx, e.Source, e.ExternalID)
+	if err == nil {
+		e.ID, e.UserID, e.CreatedAt = existing.ID, existing.UserID, existing.CreatedAt
+	}
+	m.events[e.ID] = e
+	return e, err != nil, nil
+}
+
+func (m *mockEventRepository) Update(ctx context.Context, e models.Event) error {
+	m.events[e.ID] = e
+	return nil
+}
+
+func (m *mockEventRepository) Patch(ctx context.Context, e models.Event, fields map[string]any) error {
+	m.events[e.ID] = e
+	return nil
+}
+
+func (m *mockEventRepository) Delete(ctx context.Context, e models.Event) error {
+	if m.deleteErr != nil {
+		return m.deleteErr
+	}
+	m.deleted = append(m.deleted, e.ID)
+	delete(m.events, e.ID)
+	return nil
+}
+
+func (m *mockEventRepository) SetReviewStatus(ctx context.Context, e models.Event, status, reason string) error {
+	e.ReviewStatus, e.ReviewReason = status, reason
+	m.events[e.ID] = e
+	return nil
+}
+
+func (m *mockEventRepository) Cancel(ctx context.Context, e models.Event) (bool, error) {
+	stored := m.events[e.ID]
+	if stored.Status == models.StatusCancelled {
+		return false, nil
+	}
+	stored.Status = models.StatusCancelled
+	m.events[e.ID] = stored
+	return true, nil
+}
+
+// TestEventHandlerWithMockRepository tests that the event handlers use the injected repository
+func TestEventHandlerWithMockRepository(t *testing.T) {
+	repo := &mockEventRepository{events: map[string]models.Event{
+		"public":  {ID: "public", Title: "Public Event", ReviewStatus: models.ReviewApproved},
+		"pending": {ID: "pending", Title: "Pending Event", ReviewStatus: models.ReviewPending},
+	}}
+	h := NewEventHandler(repo)
+	router := setupTestRouter()
+	router.GET("/events", h.getEvents)
+	router.GET("/events/:id", h.getEvent)
+	router.DELETE("/events/:id", h.deleteEvent)
+	router.POST("/admin/moderation/events/:id/approve", h.approveEvent)
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("GET", "/events")
+	var listing struct {
+		Meta struct{ Total int }
+	}
+	json.Unmarshal(w.Body.Bytes(), &listing)
+	if w.Code != http.StatusOK || listing.Meta.Total != 2 {
+		t.Errorf("Expected 2 events with status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	if w := serve("GET", "/events/public"); w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d for a public event, got %d", http.StatusOK, w.Code)
+	}
+	if w := serve("GET", "/events/pending"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for a pending event, got %d", http.StatusNotFound, w.Code)
+	}
+
+	if w := serve("POST", "/admin/moderation/events/pending/approve"); w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d when approving, got %d", http.StatusOK, w.Code)
+	}
+	if repo.events["pending"].ReviewStatus != models.ReviewApproved {
+		t.Errorf("Expected the repository to record the approval, got %q", repo.events["pending"].ReviewStatus)
+	}
+
+	repo.getErr = errors.New("storage unavailable")
+	if w := serve("GET", "/events/public"); w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status code %d when the lookup fails, not a 404, got %d", http.StatusInternalServerError, w.Code)
+	}
+	repo.getErr = nil
+
+	repo.deleteErr = errors.New("storage unavailable")
+	if w := serve("DELETE", "/events/public"); w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status code %d when the repository fails, got %d", http.StatusInternalServerError, w.Code)
+	}
+	repo.deleteErr = nil
+	if w := serve("DELETE", "/events/public"); w.Code != http.StatusOK || len(repo.deleted) != 1 {
+		t.Errorf("Expected the event to be deleted through the repository, got %d and %v", w.Code, repo.deleted)
+	}
+}
+
+// TestReadOnlyMirror tests that the public read endpoints are registered routes and
+// that a read-only deployment serves them while refusing mutations
+func TestReadOnlyMirror(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.Use(middlewares.ReadOnly(PublicReadEndpoints))
+	repo := &mockEventRepository{events: map[string]models.Event{
+		"e1": {ID: "e1", Title: "Concert", ReviewStatus: models.ReviewApproved, DateTime: time.Now().Add(24 * time.Hour)},
+	}}
+	RegisterRoutes(router, repo)
+
+	registered := map[string]bool{}
+	for _, route := range router.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+	for _, endpoint := range PublicReadEndpoints {
+		if !registered[endpoint] {
+			t.Errorf("Expected %s to be a registered route", endpoint)
+		}
+	}
+
+	tests := []struct {
+		method, path string
+		expected     int
+	}{
+		{"GET", "/events/e1", http.StatusOK},
+		{"GET", "/events/e1/ical", http.StatusOK},
+		{"GET", "/healthz", http.StatusOK},
+		{"GET", "/status", http.StatusServiceUnavailable},
+		{"POST", "/event", http.StatusForbidden},
+		{"DELETE", "/events/e1", http.StatusForbidden},
+		{"POST", "/events/e1/register", http.StatusForbidden},
+		{"GET", "/events/e1/waitlist", http.StatusForbidden},
+		{"GET", "/admin/audit", http.StatusForbidden},
+		{"GET", "/nowhere", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, tt.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.expected {
+			t.Errorf("%s %s: expected status code %d, got %d: %s", tt.method, tt.path, tt.expected, w.Code, w.Body.String())
+		}
+	}
+	if len(repo.deleted) > 0 {
+		t.Errorf("Expected no deletions on a read-only mirror, got %v", repo.deleted)
+	}
+}
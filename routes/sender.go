@@ -0,0 +1,148 @@
+package routes
+
+import (
+	"errors"
+	"event_booking_restapi_golang/logging"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/notifications"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"event_booking_restapi_golang/validation"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSenderNameLength caps the display name organizers can send emails under.
+const maxSenderNameLength = 100
+
+// senderSettings is the request body of PUT /events/:id/sender.
+type senderSettings struct {
+	SenderName string
+	ReplyTo    string
+}
+
+// getEventSender handles GET requests to /events/:id/sender endpoint.
+// It returns the display name and reply-to address used for attendee emails about
+// the event, and whether the reply-to address is verified.
+// Returns HTTP 404 if the event is not found, HTTP 500 if the lookup fails,
+// otherwise HTTP 200 with the settings.
+func (h *EventHandler) getEventSender(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	event, err := h.events.GetEventById(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	sender, err := event.GetSender(c.Request.Context())
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	response.Respond(c, http.StatusOK, sender)
+}
+
+// updateEventSender handles PUT requests to /events/:id/sender endpoint.
+// It sets the display name and reply-to address used for attendee emails about the
+// event. A new or still unverified reply-to address is sent a verification code,
+// and isn't used until the code is confirmed with POST /events/:id/sender/verify.
+// Returns HTTP 404 if the event is not found, HTTP 400 for an invalid name or
+// address, HTTP 503 if a verification email is needed but email isn't configured,
+// HTTP 500 if storing the settings or sending the email fails, otherwise HTTP 200
+// with the settings and whether a verification email was sent.
+func (h *EventHandler) updateEventSender(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	event, err := h.events.GetEventById(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var settings senderSettings
+	err = c.ShouldBindJSON(&settings)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+	settings.SenderName = strings.TrimSpace(settings.SenderName)
+	settings.ReplyTo = strings.TrimSpace(settings.ReplyTo)
+	if utf8.RuneCountInString(settings.SenderName) > maxSenderNameLength || strings.IndexFunc(settings.SenderName, unicode.IsControl) >= 0 {
+		problem.Write(c, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "senderName must be at most 100 characters without control characters").With("field", "senderName"))
+		return
+	}
+	if settings.ReplyTo != "" {
+		err = validation.ValidateEmail(settings.ReplyTo)
+		if err != nil {
+			problem.Write(c, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, err.Error()).With("field", "replyTo"))
+			return
+		}
+		current, err := event.GetSender(c.Request.Context())
+		if err != nil {
+			problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+			return
+		}
+		needsVerification := settings.ReplyTo != current.ReplyTo || !current.ReplyToVerified
+		if needsVerification && !Notifications.Enabled() {
+			problem.Respond(c, http.StatusServiceUnavailable, problem.CodeMailUnavailable, "reply-to addresses can't be verified because email isn't configured")
+			return
+		}
+	}
+
+	sender, code, err := event.SetSender(c.Request.Context(), settings.SenderName, settings.ReplyTo)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	if code != "" {
+		err = Notifications.SendNow(c.Request.Context(), notifications.Email{
+			To:       sender.ReplyTo,
+			Template: notifications.SenderVerification,
+			Data:     notifications.Verification{Event: eventDetails(event), Code: code},
+		})
+		if err != nil {
+			logging.FromContext(c.Request.Context()).Error("Couldn't send the reply-to verification email", "event_id", event.ID, "error", err)
+			problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, "couldn't send the verification email, please try again")
+			return
+		}
+	}
+	response.Respond(c, http.StatusOK, gin.H{
+		"sender":            sender,
+		"verification_sent": code != "",
+	})
+}
+
+// verifyEventSender handles POST requests to /events/:id/sender/verify endpoint.
+// It confirms the event's reply-to address with the code emailed to it, after which
+// attendee emails about the event use it.
+// Returns HTTP 404 if the event is not found, HTTP 400 if the code is missing, wrong
+// or expired, HTTP 500 if the update fails, otherwise HTTP 200 with the settings.
+func (h *EventHandler) verifyEventSender(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	event, err := h.events.GetEventById(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var body struct {
+		Code string `binding:"required"`
+	}
+	err = c.ShouldBindJSON(&body)
+	if err != nil {
+		problem.Write(c, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, err.Error()).With("field", "code"))
+		return
+	}
+	sender, err := event.VerifyReplyTo(c.Request.Context(), strings.TrimSpace(body.Code))
+	if errors.Is(err, models.ErrInvalidVerificationCode) {
+		problem.Write(c, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, err.Error()).With("field", "code"))
+		return
+	}
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	response.Respond(c, http.StatusOK, sender)
+}
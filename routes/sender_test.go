@@ -0,0 +1,161 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"event_booking_restapi_golang/jobs"
+	"event_booking_restapi_golang/mail"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/notifications"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+)
+
+// fakeMailer records the messages it is asked to send and can be told to fail
+type fakeMailer struct {
+	mu   sync.Mutex
+	sent []mail.Message
+	err  error
+}
+
+func (m *fakeMailer) Send(ctx context.Context, msg mail.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// messages returns the messages sent so far
+func (m *fakeMailer) messages() []mail.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]mail.Message(nil), m.sent...)
+}
+
+// useFakeMailer sends the test's emails to a fake mailer through a running job queue,
+// and stops sending them afterwards. It needs the test database to be set up first.
+func useFakeMailer(t *testing.T) *fakeMailer {
+	mailer := &fakeMailer{}
+	queue := jobs.New(1)
+	Notifications = notifications.New(mailer, queue)
+	queue.Start()
+	t.Cleanup(func() {
+		queue.Close(context.Background())
+		Notifications = nil
+	})
+	return mailer
+}
+
+// TestEventSender tests setting the sender and verifying the reply-to address with the emailed code
+func TestEventSender(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/events/:id/sender", testHandler.getEventSender)
+	router.PUT("/events/:id/sender", testHandler.updateEventSender)
+	router.POST("/events/:id/sender/verify", testHandler.verifyEventSender)
+	mailer := useFakeMailer(t)
+	event := saveEvent(t, models.Event{Title: "Jazz Night"})
+
+	send := func(method, path, body string) (*httptest.ResponseRecorder, models.EventSender) {
+		req, _ := http.NewRequest(method, "/events/"+event.ID+path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response struct {
+			Data json.RawMessage
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		// Updates respond with the settings and whether a code was sent
+		var sender struct{ Sender models.EventSender }
+		if method != "PUT" {
+			json.Unmarshal(response.Data, &sender.Sender)
+		} else {
+			json.Unmarshal(response.Data, &sender)
+		}
+		return w, sender.Sender
+	}
+
+	w, sender := send("PUT", "/sender", `{"senderName":"Jazz Club","replyTo":"bookings@jazz.example.com"}`)
+	if w.Code != http.StatusOK || sender.ReplyToVerified || len(mailer.messages()) != 1 {
+		t.Fatalf("Expected the settings to be stored and a code emailed, got %d %s", w.Code, w.Body.String())
+	}
+	if mailer.messages()[0].To != "bookings@jazz.example.com" {
+		t.Errorf("Expected the code to be sent to the reply-to address, got %+v", mailer.messages()[0])
+	}
+	code := regexp.MustCompile(`code is: ([0-9a-f]+)`).FindStringSubmatch(mailer.messages()[0].Body)
+	if code == nil {
+		t.Fatalf("Expected a code in the email, got %q", mailer.messages()[0].Body)
+	}
+
+	w, _ = send("POST", "/sender/verify", `{"code":"wrong"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for a wrong code, got %d", http.StatusBadRequest, w.Code)
+	}
+	w, sender = send("POST", "/sender/verify", `{"code":"`+code[1]+`"}`)
+	if w.Code != http.StatusOK || !sender.ReplyToVerified {
+		t.Fatalf("Expected the reply-to to be verified, got %d %s", w.Code, w.Body.String())
+	}
+
+	w, sender = send("GET", "/sender", "")
+	if w.Code != http.StatusOK || sender.SenderName != "Jazz Club" || sender.ReplyTo != "bookings@jazz.example.com" || !sender.ReplyToVerified {
+		t.Errorf("Unexpected settings %d %s", w.Code, w.Body.String())
+	}
+
+	// Renaming keeps the verified address without emailing it again
+	w, sender = send("PUT", "/sender", `{"senderName":"The Jazz Club","replyTo":"bookings@jazz.example.com"}`)
+	if w.Code != http.StatusOK || !sender.ReplyToVerified || len(mailer.messages()) != 1 {
+		t.Errorf("Expected the verified address to be kept, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+// TestEventSenderRejected tests invalid settings and missing email configuration
+func TestEventSenderRejected(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.PUT("/events/:id/sender", testHandler.updateEventSender)
+	router.POST("/events/:id/sender/verify", testHandler.verifyEventSender)
+	event := saveEvent(t, models.Event{Title: "Jazz Night"})
+
+	put := func(path, body string) int {
+		req, _ := http.NewRequest("PUT", path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := put("/events/"+event.ID+"/sender", `{"replyTo":"bookings@jazz.example.com"}`); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status code %d without email, got %d", http.StatusServiceUnavailable, code)
+	}
+	if code := put("/events/"+event.ID+"/sender", `{"senderName":"Jazz Club"}`); code != http.StatusOK {
+		t.Errorf("Expected a sender name without reply-to to need no email, got %d", code)
+	}
+
+	mailer := useFakeMailer(t)
+	tests := map[string]int{
+		`{"replyTo":"not-an-email"}`:                                      http.StatusBadRequest,
+		`{"senderName":"Club\r\nBcc: victim@example.com"}`:                http.StatusBadRequest,
+		`{"senderName":"` + string(bytes.Repeat([]byte("x"), 101)) + `"}`: http.StatusBadRequest,
+	}
+	for body, expected := range tests {
+		if code := put("/events/"+event.ID+"/sender", body); code != expected {
+			t.Errorf("%s: expected status code %d, got %d", body, expected, code)
+		}
+	}
+	if code := put("/events/missing/sender", `{"senderName":"Jazz Club"}`); code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for a missing event, got %d", http.StatusNotFound, code)
+	}
+
+	mailer.err = errors.New("relay is down")
+	if code := put("/events/"+event.ID+"/sender", `{"replyTo":"bookings@jazz.example.com"}`); code != http.StatusInternalServerError {
+		t.Errorf("Expected status code %d when the email can't be sent, got %d", http.StatusInternalServerError, code)
+	}
+}
@@ -0,0 +1,87 @@
+package routes
+
+import (
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Bounds of the admin statistics' query parameters.
+const (
+	defaultStatsMonths = 12  // Months of events created per month when none are asked for
+	maxStatsMonths     = 120 // Most months that can be asked for
+	defaultStatsTop    = 10  // Most booked events listed when no number is asked for
+	maxStatsTop        = 100 // Most booked events that can be asked for
+)
+
+// getStats handles GET requests to /admin/stats endpoint.
+// It reports the platform's totals of events, users (the organizers with events) and
+// registrations, the events created in each of the past months query parameter months
+// (default 12, the current month included) and the top query parameter events with
+// the most bookings (default 10). The figures are counted live, unlike the summary tables.
+// Returns HTTP 400 if a parameter is invalid, HTTP 500 if a query fails, otherwise
+// HTTP 200 with the statistics.
+func getStats(c *gin.Context) {
+	months, err := strconv.Atoi(c.DefaultQuery("months", strconv.Itoa(defaultStatsMonths)))
+	if err != nil || months < 1 || months > maxStatsMonths {
+		problem.Write(c, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest,
+			"months must be a number from 1 to "+strconv.Itoa(maxStatsMonths)).With("field", "months"))
+		return
+	}
+	top, err := strconv.Atoi(c.DefaultQuery("top", strconv.Itoa(defaultStatsTop)))
+	if err != nil || top < 1 || top > maxStatsTop {
+		problem.Write(c, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest,
+			"top must be a number from 1 to "+strconv.Itoa(maxStatsTop)).With("field", "top"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	totals, err := models.GetStatsTotals(ctx)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	now := time.Now().UTC()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	perMonth, err := models.GetEventsPerMonth(ctx, thisMonth.AddDate(0, 1-months, 0), now)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	topEvents, err := models.GetTopEventsByBookings(ctx, top)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	response.Respond(c, http.StatusOK, gin.H{
+		"totals":           totals,
+		"events_per_month": perMonth,
+		"top_events":       topEvents,
+	})
+}
+
+// getEventStats handles GET requests to /events/:id/stats endpoint.
+// It reports the event's bookings per day, check-in rate, cancellation rate and how
+// much of its capacity is booked, so organizers can gauge demand. It runs behind
+// requireOrganizer.
+// Returns HTTP 404 if the event is not found, HTTP 500 if a query fails, otherwise
+// HTTP 200 with the statistics.
+func (h *EventHandler) getEventStats(c *gin.Context) {
+	event, err := h.events.GetEventById(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	stats, err := event.GetStats(c.Request.Context())
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	response.Respond(c, http.StatusOK, stats)
+}
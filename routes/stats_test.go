@@ -0,0 +1,115 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestGetStats tests reporting the platform's totals, events per month and most booked events
+func TestGetStats(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/admin/stats", getStats)
+
+	ctx := context.Background()
+	jazz, err := models.Event{Title: "Jazz Night", Description: "Live music", Location: "Club", DateTime: time.Now().Add(24 * time.Hour), UserID: "organizer-1"}.Save(ctx)
+	if err != nil {
+		t.Fatalf("Failed to save test event: %v", err)
+	}
+	_, err = models.Event{Title: "Poetry Slam", Description: "Open mic", Location: "Library", DateTime: time.Now().Add(48 * time.Hour), UserID: "organizer-2"}.Save(ctx)
+	if err != nil {
+		t.Fatalf("Failed to save test event: %v", err)
+	}
+	_, err = jazz.Register(ctx, models.Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	req, _ := http.NewRequest("GET", "/admin/stats?months=3&top=1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var body struct {
+		Data struct {
+			Totals         models.StatsTotals     `json:"totals"`
+			EventsPerMonth []models.MonthlyEvents `json:"events_per_month"`
+			TopEvents      []models.TopEvent      `json:"top_events"`
+		}
+	}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	stats := body.Data
+	if stats.Totals != (models.StatsTotals{Events: 2, Users: 2, Registrations: 1}) {
+		t.Errorf("Unexpected totals %+v", stats.Totals)
+	}
+	if len(stats.EventsPerMonth) != 3 || stats.EventsPerMonth[2].Month != time.Now().UTC().Format("2006-01") || stats.EventsPerMonth[2].Events != 2 {
+		t.Errorf("Expected 3 months ending with this one's 2 events, got %+v", stats.EventsPerMonth)
+	}
+	if len(stats.TopEvents) != 1 || stats.TopEvents[0].EventID != jazz.ID || stats.TopEvents[0].Bookings != 1 {
+		t.Errorf("Expected the booked event on top, got %+v", stats.TopEvents)
+	}
+
+	for _, query := range []string{"?months=0", "?months=121", "?top=many"} {
+		req, _ := http.NewRequest("GET", "/admin/stats"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d for %s, got %d", http.StatusBadRequest, query, w.Code)
+		}
+	}
+}
+
+// TestGetEventStats tests that organizers get the booking statistics of their event
+func TestGetEventStats(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/events/:id/stats", testHandler.requireOrganizer, testHandler.getEventStats)
+
+	ctx := context.Background()
+	event, err := models.Event{Title: "Jazz Night", Description: "Live music", Location: "Club", DateTime: time.Now().Add(7 * 24 * time.Hour), UserID: "organizer-1"}.Save(ctx)
+	if err != nil {
+		t.Fatalf("Failed to save event: %v", err)
+	}
+	for _, email := range []string{"alice@example.com", "bob@example.com"} {
+		registration, err := event.Register(ctx, models.Registration{Name: "Attendee", Email: email})
+		if err != nil {
+			t.Fatalf("Failed to register: %v", err)
+		}
+		if email == "bob@example.com" {
+			if _, err := registration.Cancel(ctx); err != nil {
+				t.Fatalf("Failed to cancel: %v", err)
+			}
+		}
+	}
+
+	serve := func(path, userID string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set(UserHeader, userID)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("/events/"+event.ID+"/stats", "organizer-1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response struct{ Data models.EventStats }
+	json.Unmarshal(w.Body.Bytes(), &response)
+	s := response.Data
+	if s.Registered != 1 || s.Cancelled != 1 || s.CancellationRate != 50 || s.CapacityUtilization != nil || len(s.RegistrationsByDay) != 1 {
+		t.Errorf("Expected one booking held and one cancelled today, got %+v", s)
+	}
+	if w := serve("/events/"+event.ID+"/stats", "someone-else"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d for another user, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := serve("/events/missing/stats", "organizer-1"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for an unknown event, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"event_booking_restapi_golang/summaries"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// refreshSummaries handles POST requests to /admin/summaries/refresh endpoint.
+// It refreshes the summary tables behind the analytics endpoints now instead of
+// waiting for the scheduled refresh, rebuilding them from scratch with ?full=true.
+// Returns HTTP 400 for an invalid full value, HTTP 500 if the refresh fails,
+// otherwise HTTP 200 with the refresh time.
+func refreshSummaries(c *gin.Context) {
+	full := false
+	switch c.Query("full") {
+	case "", "false":
+	case "true":
+		full = true
+	default:
+		problem.Write(c, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "full must be true or false").With("field", "full"))
+		return
+	}
+
+	refreshedAt, err := summaries.Refresh(c.Request.Context(), full)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	response.Respond(c, http.StatusOK, gin.H{
+		"refreshed_at": refreshedAt.Format(time.RFC3339),
+		"full":         full,
+	})
+}
@@ -0,0 +1,57 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestRefreshSummaries tests refreshing the summary tables on demand
+func TestRefreshSummaries(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.POST("/admin/summaries/refresh", refreshSummaries)
+
+	day := time.Now().UTC().Add(-time.Hour)
+	_, err := testDB.Exec("INSERT INTO registrations (id, event_id, name, email, created_at) VALUES (?,?,?,?,?)",
+		"r1", "e1", "Attendee", "a@example.com", day)
+	if err != nil {
+		t.Fatalf("Failed to insert registration: %v", err)
+	}
+
+	tests := map[string]int{
+		"":             http.StatusOK,
+		"?full=true":   http.StatusOK,
+		"?full=false":  http.StatusOK,
+		"?full=always": http.StatusBadRequest,
+	}
+	for query, expected := range tests {
+		req, _ := http.NewRequest("POST", "/admin/summaries/refresh"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != expected {
+			t.Errorf("%s: expected status code %d, got %d: %s", query, expected, w.Code, w.Body.String())
+		}
+		if expected != http.StatusOK {
+			continue
+		}
+		var response struct {
+			Data struct {
+				RefreshedAt string `json:"refreshed_at"`
+				Full        bool
+			}
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if _, err := time.Parse(time.RFC3339, response.Data.RefreshedAt); err != nil || response.Data.Full != (query == "?full=true") {
+			t.Errorf("%s: unexpected response %s", query, w.Body.String())
+		}
+	}
+
+	var registrations int
+	err = testDB.QueryRow("SELECT registrations FROM registration_daily_summary WHERE event_id = 'e1'").Scan(&registrations)
+	if err != nil || registrations != 1 {
+		t.Errorf("Expected the registration in the summary, got %d (%v)", registrations, err)
+	}
+}
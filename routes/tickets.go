@@ -0,0 +1,40 @@
+package routes
+
+import (
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getTicket handles GET requests to /registrations/:id/ticket endpoint.
+// It returns the ticket of the registration with the attendee's name and the event, for
+// showing the ticket code as a QR code at the door. The registration ID acts as the
+// attendee's credential, as for the attendee calendar. Registrations booked before
+// tickets were introduced are issued a ticket on first request.
+// Returns HTTP 404 if the registration is not found, HTTP 500 if the lookup fails,
+// otherwise HTTP 200 with the ticket.
+func (h *EventHandler) getTicket(c *gin.Context) {
+	registration, err := models.GetRegistrationById(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	event, err := h.events.GetEventById(c.Request.Context(), registration.EventID)
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	ticket, err := registration.GetTicket(c.Request.Context())
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	response.Respond(c, http.StatusOK, gin.H{
+		"ticket":   ticket,
+		"attendee": registration.Name,
+		"event":    event,
+	})
+}
@@ -0,0 +1,63 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestGetTicket tests fetching the ticket issued with a registration and issuing one
+// for registrations that predate tickets
+func TestGetTicket(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.GET("/registrations/:id/ticket", testHandler.getTicket)
+
+	event := saveEvent(t, models.Event{Title: "Jazz Night"})
+	registration, err := event.Register(context.Background(), models.Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	get := func(id string) (*httptest.ResponseRecorder, models.Ticket) {
+		req, _ := http.NewRequest("GET", "/registrations/"+id+"/ticket", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response struct {
+			Data struct {
+				Ticket   models.Ticket
+				Attendee string
+				Event    models.Event
+			}
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if w.Code == http.StatusOK && (response.Data.Attendee != "Alice" || response.Data.Event.Title != "Jazz Night") {
+			t.Errorf("Expected Alice's ticket for Jazz Night, got %s", w.Body.String())
+		}
+		return w, response.Data.Ticket
+	}
+
+	w, ticket := get(registration.ID)
+	if w.Code != http.StatusOK || ticket.Code != registration.Ticket.Code || ticket.EventID != event.ID {
+		t.Fatalf("Expected the ticket issued with the registration, got %d: %s", w.Code, w.Body.String())
+	}
+
+	_, err = testDB.Exec("DELETE FROM tickets")
+	if err != nil {
+		t.Fatalf("Failed to delete tickets: %v", err)
+	}
+	w, reissued := get(registration.ID)
+	if w.Code != http.StatusOK || reissued.Code == "" || reissued.Code == ticket.Code {
+		t.Errorf("Expected a new ticket for a registration without one, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, again := get(registration.ID); again.Code != reissued.Code {
+		t.Errorf("Expected the same ticket on every request, got %s and %s", reissued.Code, again.Code)
+	}
+
+	if w, _ := get("missing"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for an unknown registration, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,105 @@
+package routes
+
+import (
+	"errors"
+	"event_booking_restapi_golang/holidays"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Holidays is the calendar organizers are warned against when they schedule events on
+// a holiday of their region. It is set by main from HOLIDAYS_FILE; nil disables the warnings.
+var Holidays *holidays.Calendar
+
+// holidayRegion reads the holiday region of the event being saved from the region query
+// parameter, falling back to HOLIDAY_REGION. Writes HTTP 400 and returns false if the
+// calendar doesn't know the region.
+func holidayRegion(c *gin.Context) (string, bool) {
+	region, ok := Holidays.Region(c.Query("region"))
+	if !ok {
+		problem.Write(c, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "the holiday calendar has no region "+c.Query("region")).
+			With("field", "region"))
+		return "", false
+	}
+	return region, true
+}
+
+// holidayWarnings lists the holidays of the region the event falls on, for the organizer
+// to reconsider the date, as the errors of the response. Holidays never stop an event
+// from being saved, and cancelled events get no warnings.
+func holidayWarnings(region string, e models.Event) []response.Error {
+	if e.Status == models.StatusCancelled {
+		return nil
+	}
+	var warnings []response.Error
+	for _, holiday := range Holidays.Between(region, e.DateTime, e.EndDateTime) {
+		warnings = append(warnings, response.NewError("holiday", "the event falls on "+holiday.Name+" on "+holiday.Date).
+			With("region", holiday.Region).
+			With("date", holiday.Date).
+			With("name", holiday.Name))
+	}
+	return warnings
+}
+
+// respondIfVenueUnavailable writes HTTP 409 with code venue_unavailable and returns true
+// when err is a venue blackout error.
+func respondIfVenueUnavailable(c *gin.Context, err error) bool {
+	var unavailable models.VenueUnavailableError
+	if !errors.As(err, &unavailable) {
+		return false
+	}
+	problem.Write(c, problem.New(http.StatusConflict, problem.CodeVenueUnavailable, unavailable.Error()).
+		With("location", unavailable.Location).
+		With("date", unavailable.Date))
+	return true
+}
+
+// getVenueBlackouts handles GET requests to /admin/venue-blackouts endpoint.
+// The location query parameter limits the blackouts to one venue, ignoring case.
+// Returns HTTP 500 if the query fails, otherwise HTTP 200 with the blackouts in date order.
+func getVenueBlackouts(c *gin.Context) {
+	blackouts, err := models.GetVenueBlackouts(c.Request.Context(), c.Query("location"))
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	response.Write(c, http.StatusOK, response.List(blackouts, len(blackouts)))
+}
+
+// createVenueBlackout handles POST requests to /admin/venue-blackouts endpoint.
+// It marks a venue unavailable on a day from the JSON request body. Events already
+// scheduled on the day are kept; only creating or moving events onto it is refused.
+// Returns HTTP 400 if the request or date is invalid, or HTTP 201 with the blackout on success.
+func createVenueBlackout(c *gin.Context) {
+	var blackout models.VenueBlackout
+	err := c.ShouldBindJSON(&blackout)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	blackout, err = blackout.Save(c.Request.Context())
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+	response.Respond(c, http.StatusCreated, blackout)
+}
+
+// deleteVenueBlackout handles DELETE requests to /admin/venue-blackouts/:id endpoint.
+// Returns HTTP 404 if the blackout is not found, otherwise HTTP 200 with a success message.
+func deleteVenueBlackout(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	err := models.VenueBlackout{ID: id}.Delete(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	response.Respond(c, http.StatusOK, gin.H{
+		"message": "Venue blackout deleted successfully",
+	})
+}
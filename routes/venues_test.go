@@ -0,0 +1,95 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"event_booking_restapi_golang/holidays"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestVenueBlackoutsAndHolidays tests that admins manage venue blackouts, which refuse
+// events on those days, and that organizers are warned about holidays without being refused
+func TestVenueBlackoutsAndHolidays(t *testing.T) {
+	setupTestDatabase(t)
+	calendar, err := holidays.Load(strings.NewReader(`{"us": [{"date": "07-04", "name": "Independence Day"}], "de": [{"date": "10-03", "name": "Tag der Deutschen Einheit"}]}`), "us")
+	if err != nil {
+		t.Fatalf("Failed to load calendar: %v", err)
+	}
+	original := Holidays
+	Holidays = calendar
+	t.Cleanup(func() { Holidays = original })
+	router := setupTestRouter()
+	router.POST("/event", testHandler.createEvent)
+	router.PATCH("/events/:id", testHandler.patchEvent)
+	router.GET("/admin/venue-blackouts", getVenueBlackouts)
+	router.POST("/admin/venue-blackouts", createVenueBlackout)
+	router.DELETE("/admin/venue-blackouts/:id", deleteVenueBlackout)
+
+	serve := func(method, path, body string) (int, map[string]json.RawMessage) {
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(UserHeader, "organizer-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response map[string]json.RawMessage
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+
+	if code, _ := serve("POST", "/admin/venue-blackouts", `{"location":"Town Hall","date":"June 1"}`); code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an invalid date, got %d", http.StatusBadRequest, code)
+	}
+	code, response := serve("POST", "/admin/venue-blackouts", `{"location":"Town Hall","date":"2030-07-04","reason":"Fireworks"}`)
+	if code != http.StatusCreated {
+		t.Fatalf("Expected status code %d creating a blackout, got %d", http.StatusCreated, code)
+	}
+	var blackout struct{ ID string }
+	json.Unmarshal(response["data"], &blackout)
+
+	code, response = serve("POST", "/event", `{"title":"Parade","description":"Marching band","location":"Town Hall","date_time":"2030-07-04T18:00:00Z"}`)
+	if code != http.StatusConflict || string(response["code"]) != `"venue_unavailable"` || string(response["date"]) != `"2030-07-04"` {
+		t.Errorf("Expected the blackout to refuse the event, got %d %v", code, response)
+	}
+
+	code, response = serve("POST", "/event", `{"title":"Picnic","description":"Hot dogs","location":"Park","date_time":"2030-07-04T18:00:00Z"}`)
+	if code != http.StatusCreated {
+		t.Fatalf("Expected status code %d for an event on a holiday, got %d", http.StatusCreated, code)
+	}
+	var warnings []map[string]string
+	json.Unmarshal(response["errors"], &warnings)
+	if len(warnings) != 1 || warnings[0]["code"] != "holiday" || warnings[0]["name"] != "Independence Day" || warnings[0]["region"] != "us" {
+		t.Errorf("Expected a warning about Independence Day, got %v", warnings)
+	}
+	var event struct{ ID string }
+	json.Unmarshal(response["data"], &event)
+
+	code, response = serve("PATCH", "/events/"+event.ID+"?region=de", `{"date_time":"2030-07-05T18:00:00Z"}`)
+	if _, warned := response["errors"]; code != http.StatusOK || warned {
+		t.Errorf("Expected no warnings on a working day, got %d %v", code, response)
+	}
+	if code, _ := serve("PATCH", "/events/"+event.ID+"?region=atlantis", `{"title":"Picnic"}`); code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an unknown region, got %d", http.StatusBadRequest, code)
+	}
+	if code, _ := serve("PATCH", "/events/"+event.ID, `{"location":"town hall","date_time":"2030-07-04T12:00:00Z"}`); code != http.StatusConflict {
+		t.Errorf("Expected status code %d moving the event onto the blackout, got %d", http.StatusConflict, code)
+	}
+
+	code, response = serve("GET", "/admin/venue-blackouts?location=TOWN%20HALL", "")
+	var blackouts []map[string]any
+	json.Unmarshal(response["data"], &blackouts)
+	if code != http.StatusOK || len(blackouts) != 1 {
+		t.Errorf("Expected the Town Hall blackout, got %d %v", code, blackouts)
+	}
+	if code, _ := serve("DELETE", "/admin/venue-blackouts/"+blackout.ID, ""); code != http.StatusOK {
+		t.Errorf("Expected status code %d deleting the blackout, got %d", http.StatusOK, code)
+	}
+	if code, _ := serve("DELETE", "/admin/venue-blackouts/"+blackout.ID, ""); code != http.StatusNotFound {
+		t.Errorf("Expected status code %d deleting it twice, got %d", http.StatusNotFound, code)
+	}
+	if code, _ := serve("PATCH", "/events/"+event.ID, `{"location":"Town Hall","date_time":"2030-07-04T12:00:00Z"}`); code != http.StatusOK {
+		t.Errorf("Expected the event to move once the blackout is removed, got %d", code)
+	}
+}
@@ -0,0 +1,74 @@
+package routes
+
+import (
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/problem"
+	"event_booking_restapi_golang/response"
+	"event_booking_restapi_golang/validation"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// joinWaitlist handles POST requests to /events/:id/waitlist endpoint.
+// It adds the attendee from the JSON request body to the waitlist of a full event.
+// The event waiver and utm_* and ref attribution are taken here as on registration and
+// carry over on promotion.
+// Returns HTTP 404 if the event is not found or not public, HTTP 400 if the request or email
+// is invalid or the waiver was not accepted, HTTP 403 if the organizer blocked the attendee,
+// HTTP 409 if the event is cancelled or still has places or the attendee is already booked or waiting,
+// or HTTP 201 with the waitlist entry on success.
+func (h *EventHandler) joinWaitlist(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	event, err := h.getPublicEvent(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var entry models.WaitlistEntry
+	err = c.ShouldBindJSON(&entry)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+	err = validation.ValidateEmail(entry.Email)
+	if err != nil {
+		problem.Write(c, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, err.Error()).With("field", "email"))
+		return
+	}
+	if !acceptWaiver(c, event, &entry.WaiverAcceptance) {
+		return
+	}
+	captureAttribution(c, &entry.Attribution)
+
+	entry, err = event.JoinWaitlist(c.Request.Context(), entry)
+	if respondIfBlocked(c, err) {
+		return
+	}
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	response.Respond(c, http.StatusCreated, entry)
+}
+
+// getWaitlist handles GET requests to /events/:id/waitlist endpoint.
+// It returns the event's waitlist in the order attendees will be promoted.
+// Returns HTTP 404 if the event is not found, HTTP 500 if the query fails,
+// or HTTP 200 with the waitlist on success.
+func (h *EventHandler) getWaitlist(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	event, err := h.events.GetEventById(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	waitlist, err := event.GetWaitlist(c.Request.Context())
+	if err != nil {
+		problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, err.Error())
+		return
+	}
+	response.Write(c, http.StatusOK, response.List(waitlist, len(waitlist)))
+}
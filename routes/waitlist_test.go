@@ -0,0 +1,126 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"event_booking_restapi_golang/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWaitlistFlow tests joining the waitlist, listing it and promotion on cancellation
+func TestWaitlistFlow(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.POST("/events/:id/waitlist", testHandler.joinWaitlist)
+	router.GET("/events/:id/waitlist", testHandler.getWaitlist)
+	router.DELETE("/registrations/:id", cancelRegistration)
+
+	event := models.Event{
+		Title:       "Sold Out Show",
+		Description: "Test Description",
+		Location:    "Test Location",
+		DateTime:    time.Now(),
+		Capacity:    1,
+	}
+	event, err := event.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to save test event: %v", err)
+	}
+
+	registration, err := event.Register(context.Background(), models.Registration{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	jsonData, _ := json.Marshal(map[string]interface{}{"name": "Bob", "email": "bob@example.com"})
+	req, _ := http.NewRequest("POST", "/events/"+event.ID+"/waitlist", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status code %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	req, _ = http.NewRequest("GET", "/events/"+event.ID+"/waitlist", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	if err != nil {
+		t.Errorf("Failed to parse response JSON: %v", err)
+	}
+	waitlist, ok := response["data"].([]interface{})
+	if !ok || len(waitlist) != 1 {
+		t.Errorf("Expected 1 waitlist entry, got %v", response["data"])
+	}
+
+	req, _ = http.NewRequest("DELETE", "/registrations/"+registration.ID, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	response = map[string]interface{}{}
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	if err != nil {
+		t.Errorf("Failed to parse response JSON: %v", err)
+	}
+	if data, _ := response["data"].(map[string]interface{}); data["promoted"] != true {
+		t.Errorf("Response should tell that a waitlisted attendee was promoted, got %v", data)
+	}
+	if strings.Contains(w.Body.String(), "bob@example.com") {
+		t.Errorf("Response should leave out the promoted attendee's registration, got %s", w.Body.String())
+	}
+}
+
+// TestJoinWaitlistNotFull tests that open events refuse waitlist entries
+func TestJoinWaitlistNotFull(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.POST("/events/:id/waitlist", testHandler.joinWaitlist)
+
+	event := models.Event{
+		Title:       "Open Event",
+		Description: "Test Description",
+		Location:    "Test Location",
+		DateTime:    time.Now(),
+		Capacity:    10,
+	}
+	event, err := event.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to save test event: %v", err)
+	}
+	id := event.ID
+
+	req, _ := http.NewRequest("POST", "/events/"+id+"/waitlist", bytes.NewBufferString(`{"name":"Bob","email":"bob@example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status code %d, got %d", http.StatusConflict, w.Code)
+	}
+}
+
+// TestCancelRegistrationNotFound tests the cancelRegistration handler with non-existent ID
+func TestCancelRegistrationNotFound(t *testing.T) {
+	setupTestDatabase(t)
+	router := setupTestRouter()
+	router.DELETE("/registrations/:id", cancelRegistration)
+
+	req, _ := http.NewRequest("DELETE", "/registrations/non-existent-id", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
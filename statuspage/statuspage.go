@@ -0,0 +1,266 @@
+// Package statuspage checks the components the API depends on, such as the database,
+// the job queue and the mail and payment providers, at a fixed interval and reports
+// how they are doing for a public status page, with their uptime over the past day,
+// week and month from the stored outcomes of the checks.
+package statuspage
+
+import (
+	"context"
+	"event_booking_restapi_golang/breaker"
+	"event_booking_restapi_golang/db"
+	"event_booking_restapi_golang/models"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Component states, from best to worst.
+const (
+	Operational = "operational" // Working normally
+	Degraded    = "degraded"    // Working, but slowly or with errors
+	Down        = models.ProbeDown
+	Unknown     = "unknown" // Not checked yet
+)
+
+// severity orders the states so the overall state is the worst of the components'.
+var severity = map[string]int{Operational: 0, Unknown: 1, Degraded: 2, Down: 3}
+
+// checkTimeout bounds each check, so a hung component is reported down instead of
+// stalling the others.
+const checkTimeout = 5 * time.Second
+
+// Jobs due this long ago and still waiting mean the workers fall behind or have stopped.
+const (
+	queueDegradedAfter = 5 * time.Minute
+	queueDownAfter     = 30 * time.Minute
+)
+
+// maxUnsaved bounds the checks kept in memory while they can't be stored, such as
+// while the database is down; the oldest are dropped first.
+const maxUnsaved = 1000
+
+// uptimeWindows are the periods uptime is reported over, by their name in reports.
+// Checks older than the longest are deleted.
+var uptimeWindows = []struct {
+	name   string
+	period time.Duration
+}{
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+}
+
+// Result is the outcome of a check.
+type Result struct {
+	State   string        // One of the component states
+	Detail  string        // What is wrong, for the public; empty when operational
+	Circuit breaker.State // State of the circuit breaker guarding the calls to a provider, empty for other components
+}
+
+// Check checks how a component is doing.
+type Check func(ctx context.Context) Result
+
+// Component is how a component is doing on the status page.
+type Component struct {
+	Name      string             `json:"name"`
+	Status    string             `json:"status"`            // One of the component states
+	Detail    string             `json:"detail,omitempty"`  // What is wrong
+	Circuit   breaker.State      `json:"circuit,omitempty"` // State of the circuit breaker of providers
+	CheckedAt *time.Time         `json:"checked_at"`        // When it was last checked, nil until it is
+	Uptime    map[string]float64 `json:"uptime"`            // Percentage of the checks in each window that didn't find it down
+}
+
+// Report is the content of the status page.
+type Report struct {
+	Status     string      `json:"status"` // The worst state of the components
+	Components []Component `json:"components"`
+}
+
+// Monitor runs the checks of the components at an interval and keeps the latest report.
+type Monitor struct {
+	interval time.Duration
+	names    []string
+	checks   map[string]Check
+	readOnly bool // Leave the stored checks to another deployment
+
+	mu      sync.RWMutex
+	report  Report
+	unsaved []models.Probe // Checks waiting to be stored, oldest first
+}
+
+// New returns a monitor running its checks every interval once started.
+func New(interval time.Duration) *Monitor {
+	return &Monitor{interval: interval, checks: map[string]Check{}}
+}
+
+// Add registers the check of the named component. Components are reported in the
+// order they are added. It must be called before the monitor is started.
+func (m *Monitor) Add(name string, check Check) {
+	m.names = append(m.names, name)
+	m.checks[name] = check
+}
+
+// ReadOnly makes the monitor read the uptime from the checks stored by the main
+// instance instead of storing and pruning its own, for read-only mirrors. It must be
+// called before the monitor is started.
+func (m *Monitor) ReadOnly() {
+	m.readOnly = true
+}
+
+// Start runs the checks right away and then every interval in a background goroutine.
+func (m *Monitor) Start() {
+	go func() {
+		for {
+			m.Run(context.Background(), time.Now())
+			time.Sleep(m.interval)
+		}
+	}()
+}
+
+// Run checks every component at now, stores the outcomes and updates the report
+// with them and the uptime of the components. Outcomes that can't be stored are kept
+// and stored with the next run, and the report then leaves out the uptime. Read-only
+// monitors store nothing.
+func (m *Monitor) Run(ctx context.Context, now time.Time) {
+	components := make([]Component, len(m.names))
+	var wg sync.WaitGroup
+	for i, name := range m.names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+			result := m.checks[name](checkCtx)
+			cancel()
+			components[i] = Component{Name: name, Status: result.State, Detail: result.Detail, Circuit: result.Circuit, CheckedAt: &now}
+		}()
+	}
+	wg.Wait()
+	if m.readOnly {
+		m.addUptime(ctx, components, now)
+		m.publish(components)
+		return
+	}
+
+	probes := make([]models.Probe, len(components))
+	for i, c := range components {
+		probes[i] = models.Probe{Component: c.Name, CheckedAt: now, State: c.Status, Detail: c.Detail}
+	}
+	m.mu.Lock()
+	m.unsaved = append(m.unsaved, probes...)
+	if len(m.unsaved) > maxUnsaved {
+		m.unsaved = m.unsaved[len(m.unsaved)-maxUnsaved:]
+	}
+	unsaved := m.unsaved
+	m.mu.Unlock()
+
+	err := models.RecordProbes(ctx, unsaved)
+	if err == nil {
+		m.mu.Lock()
+		m.unsaved = m.unsaved[len(unsaved):]
+		m.mu.Unlock()
+		m.addUptime(ctx, components, now)
+		longest := uptimeWindows[len(uptimeWindows)-1].period
+		if _, err := models.PruneProbes(ctx, now.Add(-longest)); err != nil {
+			slog.Error("Couldn't delete old status checks", "error", err)
+		}
+	} else {
+		slog.Error("Couldn't store the status checks, keeping them for the next run", "checks", len(unsaved), "error", err)
+	}
+	m.publish(components)
+}
+
+// publish makes the checked components the latest report.
+func (m *Monitor) publish(components []Component) {
+	report := Report{Status: Operational, Components: components}
+	for _, c := range components {
+		if severity[c.Status] > severity[report.Status] {
+			report.Status = c.Status
+		}
+	}
+	m.mu.Lock()
+	m.report = report
+	m.mu.Unlock()
+}
+
+// addUptime sets the uptime of the components over each window ending at now.
+func (m *Monitor) addUptime(ctx context.Context, components []Component, now time.Time) {
+	for _, window := range uptimeWindows {
+		uptime, err := models.ProbeUptime(ctx, now.Add(-window.period))
+		if err != nil {
+			slog.Error("Couldn't compute the uptime of the components", "window", window.name, "error", err)
+			return
+		}
+		for i := range components {
+			if percent, ok := uptime[components[i].Name]; ok {
+				if components[i].Uptime == nil {
+					components[i].Uptime = map[string]float64{}
+				}
+				components[i].Uptime[window.name] = percent
+			}
+		}
+	}
+}
+
+// Report returns the latest report. Before the first run every component is unknown.
+func (m *Monitor) Report() Report {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.report.Components != nil {
+		return m.report
+	}
+	report := Report{Status: Unknown, Components: make([]Component, len(m.names))}
+	for i, name := range m.names {
+		report.Components[i] = Component{Name: name, Status: Unknown}
+	}
+	return report
+}
+
+// DatabaseCheck checks that the database is reachable and its schema up to date.
+func DatabaseCheck() Check {
+	return func(ctx context.Context) Result {
+		err := db.Ping(ctx)
+		if err != nil {
+			slog.Warn("Status check found the database down", "error", err)
+			return Result{State: Down, Detail: "the database can't be reached"}
+		}
+		return Result{State: Operational}
+	}
+}
+
+// QueueCheck checks that the background job workers keep up, from how long the
+// oldest due job has been waiting.
+func QueueCheck() Check {
+	return func(ctx context.Context) Result {
+		now := time.Now()
+		due, ok, err := models.OldestDueJob(ctx, now)
+		switch {
+		case err != nil:
+			slog.Warn("Status check couldn't read the job queue", "error", err)
+			return Result{State: Down, Detail: "the job queue can't be read"}
+		case ok && now.Sub(due) >= queueDownAfter:
+			return Result{State: Down, Detail: "background jobs such as emails and refunds aren't running"}
+		case ok && now.Sub(due) >= queueDegradedAfter:
+			return Result{State: Degraded, Detail: "background jobs such as emails and refunds are delayed"}
+		}
+		return Result{State: Operational}
+	}
+}
+
+// BreakerCheck checks an external provider through the circuit breaker guarding the
+// calls to it: it is down while the circuit is open, degraded while its latest calls
+// failed or the circuit is being tried again, and operational otherwise.
+func BreakerCheck(b *breaker.Breaker) Check {
+	return func(ctx context.Context) Result {
+		snapshot := b.Snapshot()
+		result := Result{State: Operational, Circuit: snapshot.State}
+		switch {
+		case snapshot.State == breaker.Open:
+			result.State, result.Detail = Down, "the provider is failing, calls to it are paused"
+		case snapshot.State == breaker.HalfOpen:
+			result.State, result.Detail = Degraded, "the provider was failing and is being tried again"
+		case snapshot.Failures > 0:
+			result.State, result.Detail = Degraded, "the latest calls to the provider failed"
+		}
+		return result
+	}
+}
@@ -0,0 +1,105 @@
+package statuspage
+
+import (
+	"context"
+	"errors"
+	"event_booking_restapi_golang/breaker"
+	"event_booking_restapi_golang/testutils"
+	"testing"
+	"time"
+)
+
+// fixed returns a check that always finds the given state
+func fixed(state string) Check {
+	return func(ctx context.Context) Result { return Result{State: state} }
+}
+
+// TestMonitor tests that runs report the worst state and the uptime from the stored checks
+func TestMonitor(t *testing.T) {
+	tdb := testutils.SetupTestDatabase(t)
+	defer tdb.Cleanup()
+	ctx := context.Background()
+
+	database := Operational
+	m := New(time.Minute)
+	m.Add("database", func(ctx context.Context) Result { return Result{State: database} })
+	m.Add("email", fixed(Degraded))
+
+	report := m.Report()
+	if report.Status != Unknown || len(report.Components) != 2 || report.Components[0].Status != Unknown {
+		t.Fatalf("Expected unknown components before the first run, got %+v", report)
+	}
+
+	now := time.Now()
+	m.Run(ctx, now.Add(-40*24*time.Hour)) // Older than every window, deleted by the later runs
+	m.Run(ctx, now.Add(-3*time.Hour))
+	m.Run(ctx, now.Add(-2*time.Hour))
+	database = Down
+	m.Run(ctx, now.Add(-time.Hour))
+	database = Operational
+	m.Run(ctx, now)
+
+	report = m.Report()
+	if report.Status != Degraded {
+		t.Errorf("Expected the worst state of the components, got %s", report.Status)
+	}
+	db := report.Components[0]
+	if db.Name != "database" || db.Uptime["24h"] != 75 || db.Uptime["30d"] != 75 {
+		t.Errorf("Expected 3 of the 4 checks in the windows to find the database up, got %+v", db)
+	}
+	if email := report.Components[1]; email.Uptime["7d"] != 100 {
+		t.Errorf("Expected degraded checks to count as up, got %+v", email)
+	}
+	var stored int
+	tdb.DB.QueryRow("SELECT COUNT(*) FROM status_probes").Scan(&stored)
+	if stored != 8 {
+		t.Errorf("Expected the checks older than the longest window to be deleted, %d stored", stored)
+	}
+}
+
+// TestMonitorReadOnly tests that read-only monitors report the uptime of the stored
+// checks without storing their own
+func TestMonitorReadOnly(t *testing.T) {
+	tdb := testutils.SetupTestDatabase(t)
+	defer tdb.Cleanup()
+	ctx := context.Background()
+	now := time.Now()
+
+	primary := New(time.Minute)
+	primary.Add("database", fixed(Down))
+	primary.Run(ctx, now.Add(-time.Hour))
+
+	mirror := New(time.Minute)
+	mirror.ReadOnly()
+	mirror.Add("database", fixed(Operational))
+	mirror.Run(ctx, now)
+
+	report := mirror.Report()
+	if report.Status != Operational || report.Components[0].Uptime["24h"] != 0 {
+		t.Errorf("Expected the mirror's check with the stored uptime, got %+v", report)
+	}
+	var stored int
+	tdb.DB.QueryRow("SELECT COUNT(*) FROM status_probes").Scan(&stored)
+	if stored != 1 {
+		t.Errorf("Expected the mirror to store no checks, %d stored", stored)
+	}
+}
+
+// TestBreakerCheck tests that providers are reported from the state of their circuit breaker
+func TestBreakerCheck(t *testing.T) {
+	b := breaker.New(2, time.Minute)
+	check := BreakerCheck(b)
+	if result := check(context.Background()); result.State != Operational || result.Circuit != breaker.Closed {
+		t.Errorf("Expected an operational provider, got %+v", result)
+	}
+
+	fail := func() error { return errors.New("timeout") }
+	b.Call(fail, nil)
+	if result := check(context.Background()); result.State != Degraded || result.Circuit != breaker.Closed {
+		t.Errorf("Expected a failing provider to be degraded, got %+v", result)
+	}
+	b.Call(fail, nil)
+	if result := check(context.Background()); result.State != Down || result.Circuit != breaker.Open || result.Detail == "" {
+		t.Errorf("Expected a provider with an open circuit to be down, got %+v", result)
+	}
+}
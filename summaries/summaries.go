@@ -0,0 +1,63 @@
+// Package summaries keeps the summary tables behind the analytics endpoints up to
+// date: registrations and views per event and day. The endpoints read the summaries
+// instead of aggregating the transactional tables on every request.
+package summaries
+
+import (
+	"context"
+	"event_booking_restapi_golang/models"
+	"log/slog"
+	"time"
+)
+
+// Lookback is how far before the last refresh an incremental refresh recomputes
+// the daily registrations, so cancellations of recent bookings are picked up.
+// Cancellations of older bookings are only reflected by a full rebuild.
+const Lookback = 7 * 24 * time.Hour
+
+// Refresh adds the counted event views to the summaries and recomputes the daily
+// registrations. An incremental refresh only recomputes the days from Lookback
+// before the previous refresh; a full one, or the first one, rebuilds every day.
+// Returns the time the registrations were refreshed at.
+func Refresh(ctx context.Context, full bool) (time.Time, error) {
+	err := models.FlushEventViews(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	now := time.Now().UTC()
+	var since time.Time
+	if !full {
+		last, err := models.GetSummaryRefreshedAt(ctx, models.RegistrationSummary)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if !last.IsZero() {
+			since = last.Add(-Lookback)
+		}
+	}
+	err = models.RefreshRegistrationSummary(ctx, since)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return now, models.SetSummaryRefreshedAt(ctx, models.RegistrationSummary, now)
+}
+
+// Start refreshes the summaries immediately and then on every interval in a
+// background goroutine, rebuilding them fully once every rebuildEvery. Failures are
+// logged and retried on the next run.
+func Start(interval, rebuildEvery time.Duration) {
+	go func() {
+		var lastRebuild time.Time
+		for {
+			full := time.Since(lastRebuild) >= rebuildEvery
+			_, err := Refresh(context.Background(), full)
+			if err != nil {
+				slog.Error("Couldn't refresh the summary tables", "full", full, "error", err)
+			} else if full {
+				lastRebuild = time.Now()
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
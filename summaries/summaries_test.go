@@ -0,0 +1,70 @@
+package summaries
+
+import (
+	"context"
+	"event_booking_restapi_golang/models"
+	"event_booking_restapi_golang/testutils"
+	"testing"
+	"time"
+)
+
+// TestRefresh tests that incremental refreshes recompute recent days and full ones every day
+func TestRefresh(t *testing.T) {
+	tdb := testutils.SetupTestDatabase(t)
+	defer tdb.Cleanup()
+	now := time.Now().UTC()
+	insert := func(id string, at time.Time) {
+		_, err := tdb.DB.Exec("INSERT INTO registrations (id, event_id, name, email, created_at) VALUES (?,?,?,?,?)",
+			id, "e1", "Attendee", id+"@example.com", at)
+		if err != nil {
+			t.Fatalf("Failed to insert registration: %v", err)
+		}
+	}
+	cancel := func(id string) {
+		_, err := tdb.DB.Exec("DELETE FROM registrations WHERE id = ?", id)
+		if err != nil {
+			t.Fatalf("Failed to cancel registration: %v", err)
+		}
+	}
+	total := func() int {
+		days, err := models.GetRegistrationsByDay(context.Background(), now.AddDate(0, 0, -60), now.AddDate(0, 0, 1), "e1")
+		if err != nil {
+			t.Fatalf("Failed to get registrations by day: %v", err)
+		}
+		registrations := 0
+		for _, d := range days {
+			registrations += d.Registrations
+		}
+		return registrations
+	}
+	insert("old", now.AddDate(0, 0, -30))
+	insert("recent", now.Add(-time.Hour))
+
+	// The first refresh rebuilds everything even when it isn't asked to
+	refreshedAt, err := Refresh(context.Background(), false)
+	if err != nil || refreshedAt.IsZero() {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+	if total() != 2 {
+		t.Fatalf("Expected 2 registrations, got %d", total())
+	}
+
+	cancel("old")
+	cancel("recent")
+	insert("new", now)
+	_, err = Refresh(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+	if total() != 2 {
+		t.Errorf("Expected the old registration to remain until a full rebuild, got %d", total())
+	}
+
+	_, err = Refresh(context.Background(), true)
+	if err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+	if total() != 1 {
+		t.Errorf("Expected only the new registration after a full rebuild, got %d", total())
+	}
+}
@@ -24,7 +24,7 @@ func SetupTestDatabase(t *testing.T) *TestDB {
 		t.Fatalf("Failed to create test database: %v", err)
 	}
 
-	// Create events table for testing
+	// Create the application tables for testing
 	createTableSQL := `
 	CREATE TABLE IF NOT EXISTS events (
 		id TEXT PRIMARY KEY,
@@ -32,7 +32,268 @@ func SetupTestDatabase(t *testing.T) *TestDB {
 		description TEXT NOT NULL,
 		location TEXT NOT NULL,
 		datetime DATETIME NOT NULL,
-		user_id TEXT
+		user_id TEXT,
+		capacity INTEGER NOT NULL DEFAULT 0,
+		review_status TEXT NOT NULL DEFAULT 'approved',
+		review_reason TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		confirmation_url TEXT NOT NULL DEFAULT '',
+		confirmation_message TEXT NOT NULL DEFAULT '',
+		external_id TEXT NOT NULL DEFAULT '',
+		external_source TEXT NOT NULL DEFAULT '',
+		end_datetime DATETIME,
+		status TEXT NOT NULL DEFAULT 'published',
+		slug TEXT NOT NULL DEFAULT '',
+		license TEXT NOT NULL DEFAULT '',
+		version INTEGER NOT NULL DEFAULT 0,
+		series_id TEXT NOT NULL DEFAULT ''
+	);
+	CREATE TABLE IF NOT EXISTS registrations (
+		id TEXT PRIMARY KEY,
+		event_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		email TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		waiver_id TEXT NOT NULL DEFAULT '',
+		waiver_accepted_at DATETIME,
+		waiver_ip TEXT NOT NULL DEFAULT '',
+		utm_source TEXT NOT NULL DEFAULT '',
+		utm_medium TEXT NOT NULL DEFAULT '',
+		utm_campaign TEXT NOT NULL DEFAULT '',
+		utm_term TEXT NOT NULL DEFAULT '',
+		utm_content TEXT NOT NULL DEFAULT '',
+		ref TEXT NOT NULL DEFAULT '',
+		external_id TEXT NOT NULL DEFAULT '',
+		external_source TEXT NOT NULL DEFAULT '',
+		checked_in_at DATETIME,
+		moved_at DATETIME,
+		UNIQUE (event_id, email)
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS events_external_id ON events (external_source, external_id) WHERE external_id <> '';
+	CREATE UNIQUE INDEX IF NOT EXISTS registrations_external_id ON registrations (external_source, external_id) WHERE external_id <> '';
+	CREATE TABLE IF NOT EXISTS waitlist (
+		id TEXT PRIMARY KEY,
+		event_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		email TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		waiver_id TEXT NOT NULL DEFAULT '',
+		waiver_accepted_at DATETIME,
+		waiver_ip TEXT NOT NULL DEFAULT '',
+		utm_source TEXT NOT NULL DEFAULT '',
+		utm_medium TEXT NOT NULL DEFAULT '',
+		utm_campaign TEXT NOT NULL DEFAULT '',
+		utm_term TEXT NOT NULL DEFAULT '',
+		utm_content TEXT NOT NULL DEFAULT '',
+		ref TEXT NOT NULL DEFAULT '',
+		UNIQUE (event_id, email)
+	);
+	CREATE TABLE IF NOT EXISTS content_rules (
+		id TEXT PRIMARY KEY,
+		pattern TEXT NOT NULL,
+		is_regex INTEGER NOT NULL DEFAULT 0,
+		action TEXT NOT NULL,
+		created_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS attachments (
+		id TEXT PRIMARY KEY,
+		event_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		file_name TEXT NOT NULL,
+		content_type TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		attendees_only BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS experiment_events (
+		id TEXT PRIMARY KEY,
+		experiment TEXT NOT NULL,
+		variant TEXT NOT NULL,
+		session_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		event_id TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS audit_log (
+		seq INTEGER PRIMARY KEY,
+		action TEXT NOT NULL,
+		event_id TEXT NOT NULL DEFAULT '',
+		subject_id TEXT NOT NULL DEFAULT '',
+		details TEXT NOT NULL DEFAULT '{}',
+		created_at DATETIME NOT NULL,
+		prev_hash TEXT NOT NULL,
+		hash TEXT NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS audit_export (
+		sink TEXT PRIMARY KEY,
+		last_seq INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE TABLE IF NOT EXISTS analytics_opt_outs (
+		session_id TEXT PRIMARY KEY,
+		created_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS registration_daily_summary (
+		event_id TEXT NOT NULL,
+		day TEXT NOT NULL,
+		registrations INTEGER NOT NULL,
+		PRIMARY KEY (event_id, day)
+	);
+	CREATE TABLE IF NOT EXISTS event_view_daily_summary (
+		event_id TEXT NOT NULL,
+		day TEXT NOT NULL,
+		views INTEGER NOT NULL,
+		PRIMARY KEY (event_id, day)
+	);
+	CREATE TABLE IF NOT EXISTS summary_refresh (
+		name TEXT PRIMARY KEY,
+		refreshed_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS event_alerts (
+		event_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		sent_at DATETIME NOT NULL,
+		PRIMARY KEY (event_id, kind)
+	);
+	CREATE TABLE IF NOT EXISTS event_senders (
+		event_id TEXT PRIMARY KEY,
+		sender_name TEXT NOT NULL DEFAULT '',
+		reply_to TEXT NOT NULL DEFAULT '',
+		reply_to_verified_at DATETIME,
+		verification_hash TEXT NOT NULL DEFAULT '',
+		verification_expires_at DATETIME
+	);
+	CREATE TABLE IF NOT EXISTS event_messages (
+		id TEXT PRIMARY KEY,
+		event_id TEXT NOT NULL,
+		registration_id TEXT NOT NULL,
+		sender TEXT NOT NULL,
+		body TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		read_at DATETIME
+	);
+	CREATE TABLE IF NOT EXISTS user_blocks (
+		organizer_id TEXT NOT NULL,
+		email TEXT NOT NULL,
+		blocked_by TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		PRIMARY KEY (organizer_id, email, blocked_by)
+	);
+	CREATE TABLE IF NOT EXISTS event_imports (
+		event_id TEXT PRIMARY KEY,
+		source TEXT NOT NULL,
+		source_event_id TEXT NOT NULL,
+		media TEXT NOT NULL,
+		imported_at DATETIME NOT NULL,
+		UNIQUE (source, source_event_id)
+	);
+	CREATE TABLE IF NOT EXISTS feed_runs (
+		id TEXT PRIMARY KEY,
+		feed TEXT NOT NULL,
+		started_at DATETIME NOT NULL,
+		finished_at DATETIME NOT NULL,
+		added INTEGER NOT NULL DEFAULT 0,
+		updated INTEGER NOT NULL DEFAULT 0,
+		skipped INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		skips TEXT NOT NULL DEFAULT '[]'
+	);
+	CREATE TABLE IF NOT EXISTS tickets (
+		id TEXT PRIMARY KEY,
+		registration_id TEXT NOT NULL UNIQUE,
+		event_id TEXT NOT NULL,
+		code TEXT NOT NULL UNIQUE,
+		created_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS event_versions (
+		event_id TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		recorded_at DATETIME NOT NULL,
+		deleted BOOLEAN NOT NULL DEFAULT FALSE,
+		name TEXT NOT NULL,
+		description TEXT NOT NULL,
+		location TEXT NOT NULL,
+		datetime DATETIME NOT NULL,
+		user_id TEXT,
+		capacity INTEGER NOT NULL DEFAULT 0,
+		review_status TEXT NOT NULL DEFAULT 'approved',
+		review_reason TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		confirmation_url TEXT NOT NULL DEFAULT '',
+		confirmation_message TEXT NOT NULL DEFAULT '',
+		external_id TEXT NOT NULL DEFAULT '',
+		external_source TEXT NOT NULL DEFAULT '',
+		end_datetime DATETIME,
+		status TEXT NOT NULL DEFAULT 'published',
+		slug TEXT NOT NULL DEFAULT '',
+		license TEXT NOT NULL DEFAULT '',
+		series_id TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (event_id, version)
+	);
+	CREATE TABLE IF NOT EXISTS jobs (
+		id TEXT PRIMARY KEY,
+		kind TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		run_at DATETIME NOT NULL,
+		locked_at DATETIME,
+		last_error TEXT NOT NULL DEFAULT '',
+		request_id TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS refund_requests (
+		id TEXT PRIMARY KEY,
+		registration_id TEXT NOT NULL UNIQUE,
+		event_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		email TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'requested',
+		auto_approved BOOLEAN NOT NULL DEFAULT FALSE,
+		decision_reason TEXT NOT NULL DEFAULT '',
+		provider_reference TEXT NOT NULL DEFAULT '',
+		error TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		decided_at DATETIME,
+		refunded_at DATETIME
+	);
+	CREATE TABLE IF NOT EXISTS venue_blackouts (
+		id TEXT PRIMARY KEY,
+		location TEXT NOT NULL,
+		blackout_date TEXT NOT NULL,
+		reason TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS organizer_onboarding (
+		user_id TEXT NOT NULL,
+		step TEXT NOT NULL,
+		completed_at DATETIME NOT NULL,
+		PRIMARY KEY (user_id, step)
+	);
+	CREATE TABLE IF NOT EXISTS status_probes (
+		id TEXT PRIMARY KEY,
+		component TEXT NOT NULL,
+		checked_at DATETIME NOT NULL,
+		state TEXT NOT NULL,
+		detail TEXT NOT NULL DEFAULT ''
+	);
+	CREATE TABLE IF NOT EXISTS registration_cancellations (
+		registration_id TEXT PRIMARY KEY,
+		event_id TEXT NOT NULL,
+		registered_at DATETIME NOT NULL,
+		cancelled_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS registration_moves (
+		id TEXT PRIMARY KEY,
+		registration_id TEXT NOT NULL,
+		event_id TEXT NOT NULL,
+		to_event_id TEXT NOT NULL,
+		registered_at DATETIME NOT NULL,
+		moved_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER PRIMARY KEY,
+		migrated_at DATETIME NOT NULL
 	)
 	`
 	_, err = testDB.Exec(createTableSQL)
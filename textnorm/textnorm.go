@@ -0,0 +1,75 @@
+// Package textnorm normalizes free text, such as event titles, for slugs and search.
+// Text is folded to lowercase words of letters and digits: accents are removed,
+// Latin letters without a decomposition and Cyrillic letters are transliterated to
+// ASCII, and emoji, symbols and punctuation only separate words. So "Café Zürich 🎉"
+// and "cafe zurich" fold to the same text. Letters of other scripts are kept as they
+// are, lowercased.
+package textnorm
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxSlugLength is the longest slug Slug returns, in bytes.
+const MaxSlugLength = 80
+
+// transliterations spells letters that don't decompose into a base letter and accents.
+var transliterations = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'ł': "l", 'đ': "d", 'ð': "d", 'þ': "th",
+	'ı': "i", 'ŋ': "ng", 'ħ': "h", 'ĸ': "k", 'ŧ': "t", 'ſ': "s",
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "i", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "iu",
+	'я': "ia", 'і': "i", 'ї': "i", 'є': "ie", 'ґ': "g",
+}
+
+// Fold returns the words of s, folded as described in the package documentation and
+// separated by single spaces. Apostrophes are dropped without splitting words, so
+// "O'Brien's" folds to "obriens".
+func Fold(s string) string {
+	var b strings.Builder
+	pending := false // Whether a space is due before the next word
+	for _, r := range norm.NFKD.String(s) {
+		switch {
+		case unicode.In(r, unicode.Mn, unicode.Me), r == '\'', r == '’':
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			r = unicode.ToLower(r)
+			if pending && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			pending = false
+			if spelled, ok := transliterations[r]; ok {
+				b.WriteString(spelled)
+			} else {
+				b.WriteRune(r)
+			}
+		default:
+			pending = true
+		}
+	}
+	return b.String()
+}
+
+// Words returns the folded words of s, for matching them against folded text.
+func Words(s string) []string {
+	return strings.Fields(Fold(s))
+}
+
+// Slug returns the folded words of s joined by hyphens, for use in URLs. Slugs longer
+// than MaxSlugLength are cut at the last whole word that fits. Text without letters or
+// digits, such as only emoji, has an empty slug.
+func Slug(s string) string {
+	slug := strings.ReplaceAll(Fold(s), " ", "-")
+	if len(slug) <= MaxSlugLength {
+		return slug
+	}
+	if cut := strings.LastIndexByte(slug[:MaxSlugLength+1], '-'); cut > 0 {
+		return slug[:cut]
+	}
+	return strings.ToValidUTF8(slug[:MaxSlugLength], "")
+}
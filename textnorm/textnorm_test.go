@@ -0,0 +1,65 @@
+package textnorm
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// TestFold tests accent folding, transliteration and emoji stripping
+func TestFold(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Café Zürich 🎉", "cafe zurich"},
+		{"cafe zurich", "cafe zurich"},
+		{"CAFÉ   ZÜRICH", "cafe zurich"},
+		{"Café Zürich", "cafe zurich"},
+		{"Straße Ærø Łódź", "strasse aero lodz"},
+		{"Москва Jazz", "moskva jazz"},
+		{"O'Brien’s Pub", "obriens pub"},
+		{"Rock & Roll — Live!", "rock roll live"},
+		{"👩‍👩‍👧 Family Day 1️⃣", "family day 1"},
+		{"ﬁnal ＴＥＳＴ ½", "final test 1 2"},
+		{"東京 Meetup", "東京 meetup"},
+		{"🎉🎉", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Fold(tt.input); got != tt.expected {
+			t.Errorf("Fold(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+// TestWords tests splitting folded text into words
+func TestWords(t *testing.T) {
+	if got := Words("  Zürich, Café!  "); !slices.Equal(got, []string{"zurich", "cafe"}) {
+		t.Errorf("Expected [zurich cafe], got %q", got)
+	}
+	if got := Words("🎉"); len(got) != 0 {
+		t.Errorf("Expected no words, got %q", got)
+	}
+}
+
+// TestSlug tests that slugs are stable, URL-safe and limited in length
+func TestSlug(t *testing.T) {
+	if got := Slug("Café Zürich 🎉"); got != "cafe-zurich" {
+		t.Errorf("Expected cafe-zurich, got %q", got)
+	}
+	if Slug("Café Zürich 🎉") != Slug("  cafe   ZURICH!") {
+		t.Error("Expected equivalent titles to have the same slug")
+	}
+
+	long := Slug(strings.Repeat("festival ", 20))
+	if len(long) > MaxSlugLength || strings.HasSuffix(long, "-") || !strings.HasSuffix(long, "festival") {
+		t.Errorf("Expected the slug to be cut at a whole word within %d bytes, got %q", MaxSlugLength, long)
+	}
+
+	word := Slug(strings.Repeat("é東", 40))
+	if len(word) > MaxSlugLength || !utf8.ValidString(word) {
+		t.Errorf("Expected a valid slug within %d bytes, got %q", MaxSlugLength, word)
+	}
+}
@@ -0,0 +1,104 @@
+package validation
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"event_booking_restapi_golang/logging"
+	"net/http"
+	"os"
+	"time"
+)
+
+// MaxAttachmentSize is the largest file accepted as an event attachment.
+const MaxAttachmentSize = 10 << 20
+
+// Errors returned by ValidateAttachment and AttachmentScanner implementations.
+var (
+	ErrAttachmentEmpty    = errors.New("attachment is empty")
+	ErrAttachmentTooLarge = errors.New("attachment is larger than 10 MB")
+	ErrAttachmentType     = errors.New("attachment must be a PDF, PNG or JPEG file")
+	ErrAttachmentInfected = errors.New("attachment was rejected by the virus scanner")
+)
+
+// allowedAttachmentTypes lists the content types accepted for attachments.
+var allowedAttachmentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/png":       true,
+	"image/jpeg":      true,
+}
+
+// ValidateAttachment checks the size of an uploaded file and detects its content
+// type from the file's leading bytes, ignoring whatever type the client claimed.
+// Returns the detected content type.
+func ValidateAttachment(content []byte) (string, error) {
+	if len(content) == 0 {
+		return "", ErrAttachmentEmpty
+	}
+	if len(content) > MaxAttachmentSize {
+		return "", ErrAttachmentTooLarge
+	}
+	contentType := http.DetectContentType(content)
+	if !allowedAttachmentTypes[contentType] {
+		return "", ErrAttachmentType
+	}
+	return contentType, nil
+}
+
+// AttachmentScanner checks uploaded files for malware before they are stored.
+// Scan returns ErrAttachmentInfected for files that must be rejected.
+type AttachmentScanner interface {
+	Scan(ctx context.Context, fileName string, content []byte) error
+}
+
+// httpScanner posts files to an external scanning service. The service answers
+// 200 for clean files and 406 or 422 for infected ones.
+type httpScanner struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPScanner returns an AttachmentScanner that delegates to the scanning service at url.
+func NewHTTPScanner(url string) AttachmentScanner {
+	return httpScanner{
+		url:    url,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Scan sends the file to the scanning service, along with the request ID carried
+// by ctx. Files are rejected when the service is unreachable or answers
+// unexpectedly, so nothing unscanned is stored.
+func (s httpScanner) Scan(ctx context.Context, fileName string, content []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("X-File-Name", fileName)
+	logging.SetRequestID(req)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotAcceptable, http.StatusUnprocessableEntity:
+		return ErrAttachmentInfected
+	default:
+		return errors.New("unexpected status from virus scanner: " + resp.Status)
+	}
+}
+
+// AttachmentScannerFromEnv returns a scanner for the service at ATTACHMENT_SCAN_URL.
+// Returns nil when no service is configured, which disables scanning.
+func AttachmentScannerFromEnv() AttachmentScanner {
+	url := os.Getenv("ATTACHMENT_SCAN_URL")
+	if url == "" {
+		return nil
+	}
+	return NewHTTPScanner(url)
+}
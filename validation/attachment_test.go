@@ -0,0 +1,74 @@
+package validation
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"event_booking_restapi_golang/logging"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestValidateAttachment tests size limits and content type detection
+func TestValidateAttachment(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     []byte
+		contentType string
+		expected    error
+	}{
+		{"pdf", []byte("%PDF-1.7\n..."), "application/pdf", nil},
+		{"png", []byte("\x89PNG\r\n\x1a\n...."), "image/png", nil},
+		{"empty", nil, "", ErrAttachmentEmpty},
+		{"text", []byte("just some notes"), "", ErrAttachmentType},
+		{"html renamed to pdf", []byte("<html><script>alert(1)</script></html>"), "", ErrAttachmentType},
+		{"too large", append([]byte("%PDF-1.7\n"), bytes.Repeat([]byte{0}, MaxAttachmentSize)...), "", ErrAttachmentTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contentType, err := ValidateAttachment(tt.content)
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+			if contentType != tt.contentType {
+				t.Errorf("Expected content type %q, got %q", tt.contentType, contentType)
+			}
+		})
+	}
+}
+
+// TestHTTPScanner tests the scanning service responses
+func TestHTTPScanner(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("X-File-Name") {
+		case "traced.pdf":
+			if r.Header.Get(logging.RequestIDHeader) != "req-42" {
+				w.WriteHeader(http.StatusBadRequest)
+			}
+		case "clean.pdf":
+			w.WriteHeader(http.StatusOK)
+		case "eicar.pdf":
+			w.WriteHeader(http.StatusNotAcceptable)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	scanner := NewHTTPScanner(server.URL)
+	ctx := context.Background()
+	if err := scanner.Scan(ctx, "clean.pdf", []byte("%PDF")); err != nil {
+		t.Errorf("Expected clean file to pass, got %v", err)
+	}
+	if err := scanner.Scan(ctx, "eicar.pdf", []byte("%PDF")); !errors.Is(err, ErrAttachmentInfected) {
+		t.Errorf("Expected ErrAttachmentInfected, got %v", err)
+	}
+	if err := scanner.Scan(ctx, "other.pdf", []byte("%PDF")); err == nil || errors.Is(err, ErrAttachmentInfected) {
+		t.Errorf("Expected scanner outage to be reported as an error, got %v", err)
+	}
+	if err := scanner.Scan(logging.WithRequestID(ctx, "req-42"), "traced.pdf", []byte("%PDF")); err != nil {
+		t.Errorf("Expected the request ID to be passed to the scanner, got %v", err)
+	}
+}
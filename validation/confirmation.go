@@ -0,0 +1,61 @@
+package validation
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Limits for the post-registration confirmation settings of an event.
+const (
+	MaxRedirectURLLength         = 2048
+	MaxConfirmationMessageLength = 1000
+)
+
+// Errors returned by ValidateRedirectURL and SanitizeConfirmationMessage.
+var (
+	ErrInvalidRedirectURL  = errors.New("confirmation URL must be an absolute http or https URL")
+	ErrConfirmationTooLong = errors.New("confirmation message must be at most 1000 characters")
+)
+
+// htmlTag matches markup, which is stripped from confirmation messages.
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// ValidateRedirectURL checks that raw is an absolute http or https URL without
+// embedded credentials, so that booking widgets can safely navigate to it.
+// An empty string is accepted and means no redirect. Returns the normalized URL.
+func ValidateRedirectURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", nil
+	}
+	if len(raw) > MaxRedirectURLLength {
+		return "", ErrInvalidRedirectURL
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || u.User != nil {
+		return "", ErrInvalidRedirectURL
+	}
+	return u.String(), nil
+}
+
+// SanitizeConfirmationMessage reduces a confirmation message to plain text by
+// removing markup and control characters other than line breaks.
+// Returns ErrConfirmationTooLong when the cleaned message is over the limit.
+func SanitizeConfirmationMessage(message string) (string, error) {
+	message = htmlTag.ReplaceAllString(message, "")
+	message = strings.Map(func(r rune) rune {
+		if r == '\n' || !unicode.IsControl(r) {
+			return r
+		}
+		return -1
+	}, message)
+	message = strings.TrimSpace(message)
+	if utf8.RuneCountInString(message) > MaxConfirmationMessageLength {
+		return "", ErrConfirmationTooLong
+	}
+	return message, nil
+}
@@ -0,0 +1,146 @@
+// Package validation provides input validation shared by the API handlers.
+// It checks email deliverability before addresses are stored for bookings.
+package validation
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/mail"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Errors returned by ValidateEmail, phrased so they can be shown to the user as is.
+var (
+	ErrInvalidEmail    = errors.New("email address is not valid, check it for typos")
+	ErrDisposableEmail = errors.New("disposable email addresses are not accepted, please use a permanent address")
+	ErrUndeliverable   = errors.New("email domain does not accept mail, check the part after the @")
+)
+
+// defaultDisposableDomains seeds the blocklist before the first refresh.
+var defaultDisposableDomains = []string{
+	"10minutemail.com",
+	"guerrillamail.com",
+	"mailinator.com",
+	"sharklasers.com",
+	"tempmail.com",
+	"temp-mail.org",
+	"throwawaymail.com",
+	"trashmail.com",
+	"yopmail.com",
+}
+
+var (
+	blocklistMu sync.RWMutex
+	blocklist   = newDomainSet(defaultDisposableDomains)
+)
+
+// lookupMX resolves mail exchangers for a domain; replaced in tests.
+var lookupMX = func(ctx context.Context, domain string) ([]*net.MX, error) {
+	return net.DefaultResolver.LookupMX(ctx, domain)
+}
+
+// checkMX reports whether the DNS deliverability check is enabled with EMAIL_CHECK_MX;
+// replaced in tests.
+var checkMX = func() bool {
+	return os.Getenv("EMAIL_CHECK_MX") == "true"
+}
+
+// newDomainSet builds a lookup set of lower-cased domains.
+func newDomainSet(domains []string) map[string]bool {
+	set := make(map[string]bool, len(domains))
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if domain != "" && !strings.HasPrefix(domain, "#") {
+			set[domain] = true
+		}
+	}
+	return set
+}
+
+// ValidateEmail checks the syntax of the address, rejects disposable domains and,
+// when EMAIL_CHECK_MX is enabled, verifies the domain publishes mail exchangers.
+func ValidateEmail(email string) error {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return ErrInvalidEmail
+	}
+	at := strings.LastIndexByte(email, '@')
+	domain := strings.ToLower(email[at+1:])
+	if !strings.Contains(domain, ".") {
+		return ErrInvalidEmail
+	}
+
+	blocklistMu.RLock()
+	disposable := blocklist[domain]
+	blocklistMu.RUnlock()
+	if disposable {
+		return ErrDisposableEmail
+	}
+
+	if checkMX() {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		records, err := lookupMX(ctx, domain)
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return ErrUndeliverable
+		}
+		// A single "." record is the null MX, meaning the domain accepts no mail.
+		if err == nil && (len(records) == 0 || (len(records) == 1 && records[0].Host == ".")) {
+			return ErrUndeliverable
+		}
+		// Other DNS failures are temporary and must not block legitimate users.
+	}
+
+	return nil
+}
+
+// RefreshDisposableDomains downloads a newline separated list of disposable
+// domains and merges it with the built-in defaults.
+func RefreshDisposableDomains(url string) error {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("unexpected status fetching disposable domains: " + resp.Status)
+	}
+
+	domains := append([]string{}, defaultDisposableDomains...)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		domains = append(domains, scanner.Text())
+	}
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+
+	blocklistMu.Lock()
+	blocklist = newDomainSet(domains)
+	blocklistMu.Unlock()
+	return nil
+}
+
+// StartDisposableDomainsRefresh refreshes the blocklist from url immediately and
+// then on every interval in a background goroutine. Failures are logged and the
+// previous list is kept.
+func StartDisposableDomainsRefresh(url string, interval time.Duration) {
+	go func() {
+		for {
+			err := RefreshDisposableDomains(url)
+			if err != nil {
+				slog.Error("Couldn't refresh disposable email domains", "error", err)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
@@ -0,0 +1,88 @@
+// Package validation contains unit tests for the input validators.
+package validation
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestValidateEmail tests syntax and disposable domain checks
+func TestValidateEmail(t *testing.T) {
+	tests := []struct {
+		email    string
+		expected error
+	}{
+		{"alice@example.com", nil},
+		{"not-an-email", ErrInvalidEmail},
+		{"Alice <alice@example.com>", ErrInvalidEmail},
+		{"alice@localhost", ErrInvalidEmail},
+		{"bot@mailinator.com", ErrDisposableEmail},
+		{"bot@YopMail.com", ErrDisposableEmail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			err := ValidateEmail(tt.email)
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+// TestValidateEmailMX tests the MX deliverability check with a stubbed resolver
+func TestValidateEmailMX(t *testing.T) {
+	originalLookup, originalCheck := lookupMX, checkMX
+	t.Cleanup(func() { lookupMX, checkMX = originalLookup, originalCheck })
+	checkMX = func() bool { return true }
+
+	lookupMX = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		switch domain {
+		case "good.com":
+			return []*net.MX{{Host: "mx.good.com", Pref: 10}}, nil
+		case "nullmx.com":
+			return []*net.MX{{Host: ".", Pref: 0}}, nil
+		case "flaky.com":
+			return nil, &net.DNSError{Err: "timeout", IsTimeout: true}
+		default:
+			return nil, &net.DNSError{Err: "no such host", IsNotFound: true}
+		}
+	}
+
+	if err := ValidateEmail("a@good.com"); err != nil {
+		t.Errorf("Expected good.com to be deliverable, got %v", err)
+	}
+	if err := ValidateEmail("a@nullmx.com"); !errors.Is(err, ErrUndeliverable) {
+		t.Errorf("Expected ErrUndeliverable for null MX, got %v", err)
+	}
+	if err := ValidateEmail("a@missing.com"); !errors.Is(err, ErrUndeliverable) {
+		t.Errorf("Expected ErrUndeliverable for missing domain, got %v", err)
+	}
+	if err := ValidateEmail("a@flaky.com"); err != nil {
+		t.Errorf("Expected temporary DNS failures to be tolerated, got %v", err)
+	}
+}
+
+// TestRefreshDisposableDomains tests loading the blocklist from a URL
+func TestRefreshDisposableDomains(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("# disposable domains\nfreshspam.io\n"))
+	}))
+	defer server.Close()
+
+	err := RefreshDisposableDomains(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to refresh disposable domains: %v", err)
+	}
+
+	if err := ValidateEmail("bot@freshspam.io"); !errors.Is(err, ErrDisposableEmail) {
+		t.Errorf("Expected refreshed domain to be blocked, got %v", err)
+	}
+	if err := ValidateEmail("bot@mailinator.com"); !errors.Is(err, ErrDisposableEmail) {
+		t.Errorf("Expected built-in domains to stay blocked, got %v", err)
+	}
+}
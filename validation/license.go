@@ -0,0 +1,40 @@
+package validation
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// maxLicenseExpressionLength limits licenses given as SPDX expressions. URLs are
+// limited like redirect URLs.
+const maxLicenseExpressionLength = 128
+
+// ErrInvalidLicense is returned by ValidateLicense.
+var ErrInvalidLicense = errors.New("license must be an SPDX license identifier such as CC-BY-4.0 or an absolute http or https URL of the terms")
+
+// spdxExpression matches SPDX license identifiers and simple expressions of them,
+// such as CC-BY-SA-4.0, LicenseRef-partner-terms or "CC-BY-4.0 OR CC0-1.0".
+var spdxExpression = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9.+-]*( (AND|OR|WITH) [A-Za-z0-9][A-Za-z0-9.+-]*)*$`)
+
+// ValidateLicense checks that raw names the terms an event's details may be reused
+// under, either as an SPDX license expression or as the URL of the organizer's terms.
+// An empty string is accepted and means all rights are reserved. Returns the trimmed
+// license.
+func ValidateLicense(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", nil
+	}
+	if strings.HasPrefix(raw, "http://") || strings.HasPrefix(raw, "https://") {
+		u, err := ValidateRedirectURL(raw)
+		if err != nil {
+			return "", ErrInvalidLicense
+		}
+		return u, nil
+	}
+	if len(raw) > maxLicenseExpressionLength || !spdxExpression.MatchString(raw) {
+		return "", ErrInvalidLicense
+	}
+	return raw, nil
+}